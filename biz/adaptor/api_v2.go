@@ -0,0 +1,88 @@
+package adaptor
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	hertz "github.com/cloudwego/hertz/pkg/protocol/consts"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ApiV2Response /api/v2 的统一响应，成功时 code 为 OK、data 为接口数据，失败时 code 为 consts.ApiCode* 且没有 data
+type ApiV2Response struct {
+	Code      string `json:"code"`
+	Message   string `json:"message"`          // 英文文案
+	Detail    string `json:"detail,omitempty"` // 具体原因，如出错的字段
+	RequestId string `json:"requestId,omitempty"`
+	Data      any    `json:"data,omitempty"`
+}
+
+type apiV2Error struct {
+	code   string
+	status int
+}
+
+// apiV2Errors 业务错误对应的 v2 错误码与 HTTP 状态码，未登记的参数错误为 INVALID_ARGUMENT，其余为 INTERNAL
+var apiV2Errors = map[error]apiV2Error{
+	consts.ErrApiKeyInvalid:      {consts.ApiCodeUnauthenticated, hertz.StatusUnauthorized},
+	consts.ErrApiSignature:       {consts.ApiCodeInvalidSignature, hertz.StatusUnauthorized},
+	consts.ErrApiReplay:          {consts.ApiCodeReplayedRequest, hertz.StatusUnauthorized},
+	consts.ErrApiIPDenied:        {consts.ApiCodeIPNotAllowed, hertz.StatusForbidden},
+	consts.ErrTooManyRequests:    {consts.ApiCodeRateLimited, hertz.StatusTooManyRequests},
+	consts.ErrApiDailyQuota:      {consts.ApiCodeDailyQuotaExceeded, hertz.StatusTooManyRequests},
+	consts.ErrApiMonthlyQuota:    {consts.ApiCodeMonthlyQuotaExceeded, hertz.StatusPaymentRequired},
+	consts.ErrCall:               {consts.ApiCodeEvaluateFailed, hertz.StatusBadGateway},
+	consts.ErrApiEvaluateTimeout: {consts.ApiCodeEvaluateTimeout, hertz.StatusGatewayTimeout},
+}
+
+func isApiV2(c *app.RequestContext) bool {
+	return strings.HasPrefix(string(c.Path()), consts.ApiV2PathPrefix)
+}
+
+// apiV2ErrorOf 错误对应的 v2 错误码与 HTTP 状态码
+func apiV2ErrorOf(err error) apiV2Error {
+	if e, ok := apiV2Errors[err]; ok {
+		return e
+	}
+	if s, ok := status.FromError(err); ok && s.Code() == codes.InvalidArgument {
+		return apiV2Error{consts.ApiCodeInvalidArgument, hertz.StatusBadRequest}
+	}
+	return apiV2Error{consts.ApiCodeInternal, hertz.StatusInternalServerError}
+}
+
+// writeApiV2 按 v2 的响应结构写出结果，错误时用 HTTP 状态码和 code 区分错误类型
+func writeApiV2(ctx context.Context, c *app.RequestContext, resp any, err error) {
+	body := &ApiV2Response{
+		Code:      consts.ApiCodeOK,
+		Message:   "success",
+		RequestId: log.RequestId(ctx),
+	}
+	if err == nil {
+		body.Data = resp
+		c.JSON(hertz.StatusOK, body)
+		return
+	}
+
+	s, _ := status.FromError(err)
+	e := apiV2ErrorOf(err)
+	if e.code == consts.ApiCodeInternal {
+		log.CtxError(ctx, "api v2 internal error, err=%s", err.Error())
+	}
+	body.Code = e.code
+	var errno *consts.Errno
+	switch {
+	case e.code == consts.ApiCodeInternal:
+		body.Message = hertz.StatusMessage(e.status)
+	case errors.As(err, &errno):
+		body.Message = errno.Message(consts.LangEn)
+		body.Detail = errno.Detail()
+	default:
+		body.Message = s.Message()
+	}
+	c.JSON(e.status, body)
+}
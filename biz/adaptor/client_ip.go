@@ -0,0 +1,36 @@
+package adaptor
+
+import (
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/util/log"
+	"net"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// NewClientIP 只信任配置中可信代理转发的 X-Forwarded-For / X-Real-IP，其余请求取连接地址，
+// 避免 hertz 默认信任任意来源时客户端伪造请求头绕过 IP 白名单和按 IP 限流
+func NewClientIP(c *config.Config) app.ClientIP {
+	trusted := make([]*net.IPNet, 0, len(c.TrustedProxies))
+	for _, entry := range c.TrustedProxies {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Error("可信代理配置无效, entry: %s, err: %v", entry, err)
+			continue
+		}
+		trusted = append(trusted, ipNet)
+	}
+	return app.ClientIPWithOption(app.ClientIPOptions{
+		RemoteIPHeaders: []string{"X-Forwarded-For", "X-Real-IP"},
+		TrustedCIDRs:    trusted,
+	})
+}
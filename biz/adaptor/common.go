@@ -2,10 +2,12 @@ package adaptor
 
 import (
 	"context"
+	"errors"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
+	"math/rand"
 	"net/http"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -43,40 +45,77 @@ func (m *headerProvider) Keys() []string {
 	return out
 }
 
+// errHttpStatus 需要用 HTTP 状态码区分的业务错误，响应体仍为 BizError
+var errHttpStatus = map[error]int{
+	consts.ErrTooManyRequests: hertz.StatusTooManyRequests,
+	consts.ErrApiKeyInvalid:   hertz.StatusUnauthorized,
+	consts.ErrApiDailyQuota:   hertz.StatusTooManyRequests,
+	consts.ErrApiMonthlyQuota: hertz.StatusPaymentRequired,
+	consts.ErrApiSignature:    hertz.StatusUnauthorized,
+	consts.ErrApiReplay:       hertz.StatusUnauthorized,
+	consts.ErrApiIPDenied:     hertz.StatusForbidden,
+}
+
 func PostProcess(ctx context.Context, c *app.RequestContext, req, resp any, err error) {
 	path := string(c.Path())
-	if !shouldSkipLogging(path) {
-		log.CtxInfo(ctx, "[%s] req=%s, resp=%s, err=%v", path, util.JSONF(req), truncateLogContent(util.JSONF(resp), 1000), err)
+	if !shouldSkipLogging(path, err) {
+		log.CtxInfow(ctx, "access",
+			log.Field("path", path),
+			log.Field("req", maskLogContent(util.JSONF(req))),
+			log.Field("resp", truncateLogContent(maskLogContent(util.JSONF(resp)), 1000)),
+			log.Field("err", err),
+		)
 	}
 	b3.New().Inject(ctx, &headerProvider{headers: &c.Response.Header})
 
+	if isApiV2(c) {
+		writeApiV2(ctx, c, resp, err)
+		return
+	}
+
 	switch err {
 	case nil:
 		c.JSON(hertz.StatusOK, resp)
 	case consts.ErrForbidden:
-		c.JSON(hertz.StatusForbidden, err.Error())
+		c.JSON(hertz.StatusForbidden, newBizError(c, err))
+	case consts.ErrTooManyRequests, consts.ErrApiKeyInvalid, consts.ErrApiDailyQuota, consts.ErrApiMonthlyQuota,
+		consts.ErrApiSignature, consts.ErrApiReplay, consts.ErrApiIPDenied:
+		c.JSON(errHttpStatus[err], newBizError(c, err))
 	default:
-		if s, ok := status.FromError(err); ok {
-			c.JSON(http.StatusOK, &BizError{
-				Code: uint32(s.Code()),
-				Msg:  s.Message(),
-			})
+		if _, ok := status.FromError(err); ok {
+			c.JSON(http.StatusOK, newBizError(c, err))
 		} else {
-			log.CtxError(ctx, "internal error, err=%s", err.Error())
-			code := hertz.StatusInternalServerError
-			c.String(code, hertz.StatusMessage(code))
+			log.CtxErrorw(ctx, "internal error", log.Field("path", path), log.Field("err", err.Error()))
+			c.JSON(hertz.StatusInternalServerError, newBizError(c, consts.ErrInternal))
 		}
 	}
 }
 
+// BindFailed 处理参数绑定与校验失败，统一校验规则的参数错误按业务错误返回，其余返回 400，绑定错误放在 detail 中
+func BindFailed(ctx context.Context, c *app.RequestContext, err error) {
+	var errno *consts.Errno
+	if errors.As(err, &errno) {
+		PostProcess(ctx, c, nil, nil, errno)
+		return
+	}
+	errno = consts.ErrInvalidParams.WithDetail(err.Error())
+	if isApiV2(c) {
+		PostProcess(ctx, c, nil, nil, errno)
+		return
+	}
+	c.JSON(hertz.StatusBadRequest, newBizError(c, errno))
+}
+
+// BizError 错误响应，msg 按请求语言翻译，detail 为具体原因，如出错的字段
 type BizError struct {
-	Code uint32 `json:"code"`
-	Msg  string `json:"msg"`
+	Code   uint32 `json:"code"`
+	Msg    string `json:"msg"`
+	Detail string `json:"detail,omitempty"`
 }
 
-func shouldSkipLogging(path string) bool {
+func shouldSkipLogging(path string, err error) bool {
 	cfg := config.GetConfig()
-	if cfg == nil || cfg.Log.NoLogPaths == nil {
+	if cfg == nil {
 		return false
 	}
 
@@ -85,6 +124,13 @@ func shouldSkipLogging(path string) bool {
 			return true
 		}
 	}
+	// 出错的请求不采样
+	if err != nil {
+		return false
+	}
+	if rate, ok := cfg.Log.SampleRates[path]; ok {
+		return rand.Float64() >= rate
+	}
 	return false
 }
 
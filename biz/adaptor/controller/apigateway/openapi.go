@@ -0,0 +1,84 @@
+package apigateway
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/openapi"
+	"net/http"
+	"sync"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	hertz "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// apiSpec 对外 API 的接口清单，新增或修改外部接口时在此登记，请求与响应的 schema 由结构体反射生成
+var apiSpec = &openapi.Spec{
+	Title:        "essay-show API",
+	Version:      "2.0",
+	ApiKeyHeader: consts.ApiKeyHeader,
+	Headers:      []string{consts.ApiSignHeaderTimestamp, consts.ApiSignHeaderNonce, consts.ApiSignHeaderSignature},
+	Operations: []openapi.Operation{
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/essay/evaluate/stream",
+			Summary: "流式批改作文，每个 SSE 事件的 data 为一条消息，type 为 complete 时批改完成",
+			Tags:    []string{"v1"},
+			Req:     &show.EssayEvaluateReq{},
+			Resp:    &util.StreamMessage{},
+			Error:   &adaptor.BizError{},
+			Stream:  true,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/api/v1/sts/ocr",
+			Summary: "识别作文图片",
+			Tags:    []string{"v1"},
+			Req:     &show.OCRWithPreprocessReq{},
+			Resp:    &show.OCRResp{},
+			Error:   &adaptor.BizError{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/v1/usage",
+			Summary: "查询当前 API Key 的用量与剩余配额",
+			Tags:    []string{"v1"},
+			Req:     &show.GetApiUsageReq{},
+			Resp:    &show.GetApiUsageResp{},
+			Error:   &adaptor.BizError{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/api/v1/errors",
+			Summary: "错误码目录，包含各错误的 code、HTTP 状态码、文案与建议处理方式",
+			Tags:    []string{"v1"},
+			Resp:    &show.GetApiErrorsResp{},
+			Error:   &adaptor.BizError{},
+		},
+		{
+			Method:   http.MethodPost,
+			Path:     "/api/v2/essay/evaluate",
+			Summary:  "同步批改作文，批改完成后一次返回",
+			Tags:     []string{"v2"},
+			Req:      &show.EssayEvaluateReq{},
+			Resp:     &show.APIEssayEvaluateV2Resp{},
+			Envelope: &adaptor.ApiV2Response{},
+			Error:    &adaptor.ApiV2Response{},
+		},
+	},
+}
+
+var (
+	apiDocOnce sync.Once
+	apiDoc     *openapi.Document
+)
+
+// OpenAPI - 对外 API 的 OpenAPI 3.0 描述，按当前代码中的结构体生成，供调用方生成 SDK
+func OpenAPI(ctx context.Context, c *app.RequestContext) {
+	apiDocOnce.Do(func() {
+		apiDoc = openapi.Build(apiSpec)
+	})
+	c.JSON(hertz.StatusOK, apiDoc)
+}
@@ -3,14 +3,18 @@ package apigateway
 import (
 	"context"
 	"encoding/json"
+	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/service"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
 	"essay-show/provider"
 	"net/http"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
+	hertz "github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/cloudwego/hertz/pkg/protocol/sse"
 )
 
@@ -18,34 +22,49 @@ import (
 func APIEssayEvaluateStreamV1(ctx context.Context, c *app.RequestContext) {
 	var req show.EssayEvaluateReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		c.String(hertz.StatusBadRequest, err.Error())
 		return
 	}
 
 	log.CtxInfo(ctx, "[API-Gateway-V1] req=%s", util.JSONF(&req))
 
+	p := provider.Get()
+	caller := adaptor.ExtractApiCaller(ctx)
+	acquired, err := p.ApiMeter.Acquire(ctx, caller)
+	if err != nil {
+		adaptor.PostProcess(ctx, c, &req, nil, err)
+		return
+	}
+
 	c.SetStatusCode(http.StatusOK)
 	w := sse.NewWriter(c)
+	metrics.SSEConnections.WithLabelValues("api_essay_evaluate").Inc()
+	defer metrics.SSEConnections.WithLabelValues("api_essay_evaluate").Dec()
 
-	resultChan := make(chan string, 100)
+	resultChan := util.NewStreamChan(ctx)
 
 	go func(ctx context.Context) {
-		p := provider.Get()
-		defer close(resultChan)
-		p.EssayService.APIEssayEvaluateStreamV1(ctx, &req, resultChan)
+		defer util.CloseStreamChan(resultChan)
+		err := p.EssayService.APIEssayEvaluateStreamV1(ctx, &req, resultChan)
+		metrics.EvaluateDone("api", err == nil)
 	}(ctx)
 
+	// 只有批改完成才计入用量，其余情况归还额度
+	completed := false
 	for jsonMessage := range resultChan {
-		err := w.WriteEvent("", "", []byte(jsonMessage))
+		// 消息体中的 seq、task_id 原样转发，并作为 SSE 事件 id
+		var msgData util.StreamMessage
+		json.Unmarshal([]byte(jsonMessage), &msgData)
+		err := w.WriteEvent(msgData.EventId(), "", []byte(jsonMessage))
 		if err != nil {
-			log.Error("发送SSE事件失败: %v", err)
+			log.CtxError(ctx, "发送SSE事件失败: %v", err)
 			break
 		}
 
-		var msgData util.StreamMessage
-		json.Unmarshal([]byte(jsonMessage), &msgData)
 		if msgData.Type == util.STComplete {
 			log.CtxInfo(ctx, "[API-Gateway-V1] 批改完成")
+			completed = true
+			p.ApiMeter.Record(ctx, caller, consts.ApiUsageEvaluate, service.EstimateTokens(req.Title, req.Text, jsonMessage))
 			break
 		}
 		if msgData.Type == util.STError {
@@ -53,25 +72,62 @@ func APIEssayEvaluateStreamV1(ctx context.Context, c *app.RequestContext) {
 			break
 		}
 	}
+	if !completed {
+		p.ApiMeter.Release(ctx, caller, acquired)
+	}
+}
+
+// APIEssayEvaluateV2 - API网关专用的同步批改接口 (v2.0)
+// 服务端聚合流式结果后一次返回，响应结构与错误码见 adaptor.ApiV2Response
+func APIEssayEvaluateV2(ctx context.Context, c *app.RequestContext) {
+	var req show.EssayEvaluateReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	caller := adaptor.ExtractApiCaller(ctx)
+	acquired, err := p.ApiMeter.Acquire(ctx, caller)
+	if err != nil {
+		adaptor.PostProcess(ctx, c, &req, nil, err)
+		return
+	}
+
+	resp, err := p.EssayService.APIEssayEvaluateV2(ctx, &req)
+	metrics.EvaluateDone("api", err == nil)
+	if err != nil {
+		p.ApiMeter.Release(ctx, caller, acquired)
+	} else {
+		p.ApiMeter.Record(ctx, caller, consts.ApiUsageEvaluate, service.EstimateTokens(req.Title, req.Text, string(resp.Result)))
+	}
+	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
 
 // APIOCRV1 - API网关专用的OCR接口 (v1.0)
-// 简化版本：无需认证、无需校验次数
+// 简化版本：由网关中间件校验 API Key，无需用户登录、无需校验次数
 // 专门用于API网关调用，只负责核心的OCR识别功能
 func APIOCRV1(ctx context.Context, c *app.RequestContext) {
-	var req show.OCRReq
+	var req show.OCRWithPreprocessReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		c.String(hertz.StatusBadRequest, err.Error())
 		return
 	}
 
 	log.CtxInfo(ctx, "[API-Gateway-OCR-V1] req=%s", util.JSONF(&req))
 
 	p := provider.Get()
+	caller := adaptor.ExtractApiCaller(ctx)
+	acquired, err := p.ApiMeter.Acquire(ctx, caller)
+	if err != nil {
+		adaptor.PostProcess(ctx, c, &req, nil, err)
+		return
+	}
 	resp, err := p.StsService.APIOCRV1(ctx, &req)
 	if err != nil {
-		log.Error("[API-Gateway-OCR-V1] OCR失败: %v", err)
-		c.JSON(consts.StatusInternalServerError, map[string]interface{}{
+		p.ApiMeter.Release(ctx, caller, acquired)
+		log.CtxError(ctx, "[API-Gateway-OCR-V1] OCR失败: %v", err)
+		c.JSON(hertz.StatusInternalServerError, map[string]interface{}{
 			"code":    50000,
 			"message": "OCR识别失败",
 			"error":   err.Error(),
@@ -80,5 +136,24 @@ func APIOCRV1(ctx context.Context, c *app.RequestContext) {
 	}
 
 	log.CtxInfo(ctx, "[API-Gateway-OCR-V1] OCR成功")
-	c.JSON(consts.StatusOK, resp)
+	p.ApiMeter.Record(ctx, caller, consts.ApiUsageOcr, 0)
+	c.JSON(hertz.StatusOK, resp)
+}
+
+// APIUsageV1 - API网关调用方自助查询用量与剩余配额 (v1.0)
+func APIUsageV1(ctx context.Context, c *app.RequestContext) {
+	var req show.GetApiUsageReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.GetApiUsage(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// APIErrorsV1 - 错误码目录，列出全部业务错误的 code、文案与建议处理方式
+func APIErrorsV1(ctx context.Context, c *app.RequestContext) {
+	adaptor.PostProcess(ctx, c, nil, adaptor.ErrorCatalog(), nil)
 }
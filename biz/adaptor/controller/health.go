@@ -0,0 +1,27 @@
+package controller
+
+import (
+	"context"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/utils"
+	"github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// Healthz 存活探针，进程能处理请求即返回成功，不检查依赖
+func Healthz(ctx context.Context, c *app.RequestContext) {
+	c.JSON(consts.StatusOK, utils.H{
+		"status": "ok",
+	})
+}
+
+// Readyz 就绪探针，必需的依赖不可用时返回 503，下游服务只返回状态不影响结果
+func Readyz(ctx context.Context, c *app.RequestContext) {
+	status := provider.Get().HealthService.Readyz(ctx)
+	code := consts.StatusOK
+	if !status.Ready {
+		code = consts.StatusServiceUnavailable
+	}
+	c.JSON(code, status)
+}
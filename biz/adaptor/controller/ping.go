@@ -13,7 +13,7 @@ import (
 
 // Ping .
 func Ping(ctx context.Context, c *app.RequestContext) {
-	log.Info("ping")
+	log.CtxInfo(ctx, "ping")
 	c.JSON(consts.StatusOK, utils.H{
 		"message": "pong",
 	})
@@ -9,7 +9,6 @@ import (
 	"essay-show/provider"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // GetAdminHomeworkStatistics .
@@ -19,7 +18,7 @@ func GetAdminHomeworkStatistics(ctx context.Context, c *app.RequestContext) {
 	var req show.GetAdminHomeworkStatisticsReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -35,7 +34,7 @@ func AddGradeCount(ctx context.Context, c *app.RequestContext) {
 	var req show.AddGradeCountReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -43,3 +42,355 @@ func AddGradeCount(ctx context.Context, c *app.RequestContext) {
 	resp, err := p.AdminService.AddGradeCount(ctx, &req)
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
+
+// SearchUsers .
+// @router /admin/user/search [GET]
+func SearchUsers(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.SearchUsersReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.SearchUsers(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateUserStatus .
+// @router /admin/user/status [POST]
+func UpdateUserStatus(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateUserStatusReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.UpdateUserStatus(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// AdjustUserCount .
+// @router /admin/user/count [POST]
+func AdjustUserCount(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.AdjustUserCountReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.AdjustUserCount(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// AdjustUserOcrCount .
+// @router /admin/user/ocr_count [POST]
+func AdjustUserOcrCount(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.AdjustUserOcrCountReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.AdjustUserOcrCount(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetEvaluateStatistics .
+// @router /admin/evaluate/statistics [GET]
+func GetEvaluateStatistics(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetEvaluateStatisticsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.GetEvaluateStatistics(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListAuditLogs .
+// @router /admin/audit/logs [GET]
+func ListAuditLogs(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListAuditLogsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.ListAuditLogs(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListEvaluateRevisions .
+// @router /admin/audit/revisions [GET]
+func ListEvaluateRevisions(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListEvaluateRevisionsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.ListEvaluateRevisions(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListModerationRecords .
+// @router /admin/audit/moderation [GET]
+func ListModerationRecords(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListModerationRecordsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.ListModerationRecords(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// CreateFeedbackExport .
+// @router /admin/evaluate/feedback_export [POST]
+func CreateFeedbackExport(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.CreateFeedbackExportReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.CreateFeedbackExport(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetFeedbackExport .
+// @router /admin/evaluate/feedback_export [GET]
+func GetFeedbackExport(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetFeedbackExportReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.GetFeedbackExport(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListRiskRecords .
+// @router /admin/risk/list [GET]
+func ListRiskRecords(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListRiskRecordsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.ListRiskRecords(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ReviewRiskRecord .
+// @router /admin/risk/review [POST]
+func ReviewRiskRecord(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ReviewRiskRecordReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.ReviewRiskRecord(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetRewardSetting .
+// @router /admin/setting/reward [GET]
+func GetRewardSetting(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetRewardSettingReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.GetRewardSetting(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateRewardSetting .
+// @router /admin/setting/reward [POST]
+func UpdateRewardSetting(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateRewardSettingReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.UpdateRewardSetting(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RefreshQuestionBankCache .
+// @router /admin/question_bank/refresh [POST]
+func RefreshQuestionBankCache(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RefreshQuestionBankCacheReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.AdminService.RefreshQuestionBankCache(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// CreateApiKey .
+// @router /admin/api_key/create [POST]
+func CreateApiKey(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.CreateApiKeyReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.CreateApiKey(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListApiKeys .
+// @router /admin/api_key/list [GET]
+func ListApiKeys(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListApiKeysReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.ListApiKeys(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// DisableApiKey .
+// @router /admin/api_key/disable [POST]
+func DisableApiKey(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.DisableApiKeyReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.DisableApiKey(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RotateApiKey .
+// @router /admin/api_key/rotate [POST]
+func RotateApiKey(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RotateApiKeyReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.RotateApiKey(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateApiKeyQuota .
+// @router /admin/api_key/quota [POST]
+func UpdateApiKeyQuota(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateApiKeyQuotaReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.UpdateApiKeyQuota(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateApiKeyWhitelist .
+// @router /admin/api_key/whitelist [POST]
+func UpdateApiKeyWhitelist(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateApiKeyWhitelistReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.UpdateApiKeyWhitelist(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetApiKeyUsage .
+// @router /admin/api_key/usage [GET]
+func GetApiKeyUsage(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetApiKeyUsageReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ApiKeyService.GetApiKeyUsage(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
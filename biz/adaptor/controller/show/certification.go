@@ -0,0 +1,66 @@
+package show
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	show "essay-show/biz/application/dto/essay/show"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ApplyCertification .
+// @router /user/certification/apply [POST]
+func ApplyCertification(ctx context.Context, c *app.RequestContext) {
+	var req show.ApplyCertificationReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.CertService.ApplyCertification(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetCertification .
+// @router /user/certification [GET]
+func GetCertification(ctx context.Context, c *app.RequestContext) {
+	var req show.GetCertificationReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.CertService.GetCertification(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListCertifications .
+// @router /admin/certification/list [GET]
+func ListCertifications(ctx context.Context, c *app.RequestContext) {
+	var req show.ListCertificationsReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.CertService.ListCertifications(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ReviewCertification .
+// @router /admin/certification/review [POST]
+func ReviewCertification(ctx context.Context, c *app.RequestContext) {
+	var req show.ReviewCertificationReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.CertService.ReviewCertification(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
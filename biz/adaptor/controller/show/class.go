@@ -9,7 +9,6 @@ import (
 	"essay-show/provider"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // CreateClass .
@@ -19,7 +18,7 @@ func CreateClass(ctx context.Context, c *app.RequestContext) {
 	var req show.CreateClassReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -35,7 +34,7 @@ func ListClasses(ctx context.Context, c *app.RequestContext) {
 	var req show.ListClassesReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -51,7 +50,7 @@ func GetClassMembers(ctx context.Context, c *app.RequestContext) {
 	var req show.GetClassMembersReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -67,7 +66,7 @@ func BindClassMember(ctx context.Context, c *app.RequestContext) {
 	var req show.BindClassMemberReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -83,7 +82,7 @@ func CreateClassMembers(ctx context.Context, c *app.RequestContext) {
 	var req show.CreateClassMembersReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -99,7 +98,7 @@ func EditClassMemberName(ctx context.Context, c *app.RequestContext) {
 	var req show.EditClassMemberNameReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -115,7 +114,7 @@ func UnbindClassMember(ctx context.Context, c *app.RequestContext) {
 	var req show.UnbindClassMemberReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -131,7 +130,7 @@ func DeleteClassMember(ctx context.Context, c *app.RequestContext) {
 	var req show.DeleteClassMemberReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -147,7 +146,7 @@ func GetClassMemberInfo(ctx context.Context, c *app.RequestContext) {
 	var req show.GetClassMemberInfoReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
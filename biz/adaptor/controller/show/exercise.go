@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"essay-show/biz/adaptor"
 	show "essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/metrics"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
 	"essay-show/provider"
@@ -21,10 +22,10 @@ import (
 // @router /exercise/create [POST]
 func CreateExercise(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.CreateExerciseReq
+	var req show.GenerateExerciseReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -40,7 +41,7 @@ func ListSimpleExercises(ctx context.Context, c *app.RequestContext) {
 	var req show.ListSimpleExercisesReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -56,7 +57,7 @@ func GetExercise(ctx context.Context, c *app.RequestContext) {
 	var req show.GetExerciseReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -69,10 +70,10 @@ func GetExercise(ctx context.Context, c *app.RequestContext) {
 // @router /exercise/do [POST]
 func DoExercise(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.DoExerciseReq
+	var req show.DoExerciseAttemptReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -88,7 +89,7 @@ func LikeExercise(ctx context.Context, c *app.RequestContext) {
 	var req show.LikeExerciseReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -101,10 +102,10 @@ func LikeExercise(ctx context.Context, c *app.RequestContext) {
 // @router /exercise/create/stream [POST]
 func CreateExerciseStream(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.CreateExerciseReq
+	var req show.GenerateExerciseReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -112,11 +113,11 @@ func CreateExerciseStream(ctx context.Context, c *app.RequestContext) {
 	c.SetStatusCode(http.StatusOK)
 
 	// 创建结果通道 - 现在接收JSON字符串
-	resultChan := make(chan string, 100)
+	resultChan := util.NewStreamChan(ctx)
 
 	// 启动练习生成服务
 	go func() {
-		defer close(resultChan)
+		defer util.CloseStreamChan(resultChan)
 		p := provider.Get()
 		err := p.ExerciseService.CreateExerciseStream(ctx, &req, resultChan)
 		if err != nil {
@@ -124,15 +125,129 @@ func CreateExerciseStream(ctx context.Context, c *app.RequestContext) {
 		}
 	}()
 	w := sse.NewWriter(c)
+	metrics.SSEConnections.WithLabelValues("exercise_create").Inc()
+	defer metrics.SSEConnections.WithLabelValues("exercise_create").Dec()
 
 	// 实时转发流式数据 - 使用官方文档的方式
 	for jsonMessage := range resultChan {
-		w.WriteEvent("", "", []byte(jsonMessage))
-
 		var msgData util.StreamMessage
 		json.Unmarshal([]byte(jsonMessage), &msgData)
+		w.WriteEvent(msgData.EventId(), "", []byte(jsonMessage))
+
 		if msgData.Type == util.STComplete || msgData.Type == util.STError {
 			break
 		}
 	}
 }
+
+// ListExercises .
+// @router /exercise/list [POST]
+func ListExercises(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListExercisesReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.ListExercises(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetExerciseHistory .
+// @router /exercise/history [POST]
+func GetExerciseHistory(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetExerciseHistoryReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.GetExerciseHistory(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// AssignExerciseToClass .
+// @router /exercise/assign [POST]
+func AssignExerciseToClass(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.AssignExerciseToClassReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.AssignExerciseToClass(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListPendingExercises .
+// @router /exercise/pending [POST]
+func ListPendingExercises(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListPendingExercisesReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.ListPendingExercises(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetAssignmentProgress .
+// @router /exercise/assignment/progress [POST]
+func GetAssignmentProgress(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetAssignmentProgressReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.GetAssignmentProgress(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// CreateWeaknessExercise .
+// @router /exercise/create/weakness [POST]
+func CreateWeaknessExercise(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.CreateWeaknessExerciseReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.CreateWeaknessExercise(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetExerciseStats .
+// @router /exercise/stats [POST]
+func GetExerciseStats(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetExerciseStatsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ExerciseService.GetExerciseStats(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
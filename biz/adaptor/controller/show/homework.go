@@ -10,7 +10,6 @@ import (
 	"essay-show/provider"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // CreateHomework .
@@ -20,7 +19,7 @@ func CreateHomework(ctx context.Context, c *app.RequestContext) {
 	var req show.CreateHomeworkReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -36,7 +35,7 @@ func ListHomeworks(ctx context.Context, c *app.RequestContext) {
 	var req show.ListHomeworksReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -49,10 +48,10 @@ func ListHomeworks(ctx context.Context, c *app.RequestContext) {
 // @router /homework/submit [POST]
 func SubmitHomework(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.SubmitHomeworkReq
+	var req show.SubmitHomeworkWithConfirmReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -68,7 +67,7 @@ func GetSubmissions(ctx context.Context, c *app.RequestContext) {
 	var req show.GetSubmissionsReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -84,7 +83,7 @@ func GetSubmissionEvaluate(ctx context.Context, c *app.RequestContext) {
 	var req show.GetSubmissionEvaluateReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -100,7 +99,7 @@ func ModifySubmissionEvaluate(ctx context.Context, c *app.RequestContext) {
 	var req show.ModifySubmissionEvaluateReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -109,20 +108,52 @@ func ModifySubmissionEvaluate(ctx context.Context, c *app.RequestContext) {
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
 
+// ListSubmissionEvaluateVersions .
+// @router /homework/submission/versions [GET]
+func ListSubmissionEvaluateVersions(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListSubmissionEvaluateVersionsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.ListSubmissionEvaluateVersions(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RollbackSubmissionEvaluate .
+// @router /homework/submission/rollback [POST]
+func RollbackSubmissionEvaluate(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RollbackSubmissionEvaluateReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.RollbackSubmissionEvaluate(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
 // DownloadSubmissionEvaluate .
 // @router /homework/submission/download [POST]
 func DownloadSubmissionEvaluate(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.DownloadSubmissionEvaluateReq
+	var req show.DownloadSubmissionEvaluateWithFormatReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
 	p := provider.Get()
 	resp, err := p.HomeworkService.DownloadSubmissionEvaluate(ctx, &req)
-	log.Info("下载批改结果: %+v", resp)
+	log.CtxInfo(ctx, "下载批改结果: %+v", resp)
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
 
@@ -133,7 +164,7 @@ func ReCorrectHomework(ctx context.Context, c *app.RequestContext) {
 	var req show.ReCorrectHomeworkReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -149,7 +180,7 @@ func EditHomework(ctx context.Context, c *app.RequestContext) {
 	var req show.EditHomeworkReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -165,7 +196,7 @@ func DownloadLessonPlan(ctx context.Context, c *app.RequestContext) {
 	var req show.DownloadLessonPlanReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -181,7 +212,7 @@ func GetHomeworkStatistics(ctx context.Context, c *app.RequestContext) {
 	var req show.GetHomeworkStatisticsReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -197,7 +228,7 @@ func DeleteHomework(ctx context.Context, c *app.RequestContext) {
 	var req show.DeleteHomeworkReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 	p := provider.Get()
@@ -212,7 +243,7 @@ func ReEvaluateHomework(ctx context.Context, c *app.RequestContext) {
 	var req show.ReEvaluateHomeworkReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -228,7 +259,7 @@ func GetUserSubmissions(ctx context.Context, c *app.RequestContext) {
 	var req show.GetUserSubmissionsReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 	p := provider.Get()
@@ -243,7 +274,7 @@ func ModifySubmissionEvaluateSaveHistory(ctx context.Context, c *app.RequestCont
 	var req show.ModifySubmissionEvaluateSaveHistoryReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -251,3 +282,291 @@ func ModifySubmissionEvaluateSaveHistory(ctx context.Context, c *app.RequestCont
 	resp, err := p.HomeworkService.ModifySubmissionEvaluateSaveHistory(ctx, &req)
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
+
+// GetSubmissionText .
+// @router /homework/submission/text [GET]
+func GetSubmissionText(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetSubmissionTextReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetSubmissionText(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ConfirmSubmissionText .
+// @router /homework/submission/text/confirm [POST]
+func ConfirmSubmissionText(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ConfirmSubmissionTextReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.ConfirmSubmissionText(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// SetHomeworkNeatness .
+// @router /homework/neatness [POST]
+func SetHomeworkNeatness(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.SetHomeworkNeatnessReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.SetHomeworkNeatness(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// SetHomeworkWordLimit .
+// @router /homework/word_limit [POST]
+func SetHomeworkWordLimit(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.SetHomeworkWordLimitReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.SetHomeworkWordLimit(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// DownloadClassReport .
+// @router /homework/class/report [POST]
+func DownloadClassReport(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.DownloadClassReportReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.DownloadClassReport(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetClassReport .
+// @router /homework/class/report [GET]
+func GetClassReport(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetClassReportReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetClassReport(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// CreateDownloadTask .
+// @router /homework/submission/download/task [POST]
+func CreateDownloadTask(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.DownloadSubmissionEvaluateWithFormatReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.CreateDownloadTask(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetDownloadTask .
+// @router /homework/submission/download/task [GET]
+func GetDownloadTask(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetDownloadTaskReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetDownloadTask(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GenerateLessonPlan .
+// @router /homework/lesson_plan/generate [POST]
+func GenerateLessonPlan(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GenerateLessonPlanReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GenerateLessonPlan(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RegenerateLessonPlan .
+// @router /homework/lesson_plan/regenerate [POST]
+func RegenerateLessonPlan(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RegenerateLessonPlanReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.RegenerateLessonPlan(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListLessonPlans .
+// @router /homework/lesson_plan/list [GET]
+func ListLessonPlans(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListLessonPlansReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.ListLessonPlans(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetLessonPlan .
+// @router /homework/lesson_plan [GET]
+func GetLessonPlan(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetLessonPlanReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetLessonPlan(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListWeeklyReports .
+// @router /homework/class/weekly_report/list [GET]
+func ListWeeklyReports(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListWeeklyReportsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.ListWeeklyReports(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetWeeklyReport .
+// @router /homework/class/weekly_report [GET]
+func GetWeeklyReport(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetWeeklyReportReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetWeeklyReport(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetStudentProgress .
+// @router /homework/student/progress [GET]
+func GetStudentProgress(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetStudentProgressReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetStudentProgress(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetStudentRadar .
+// @router /homework/student/radar [GET]
+func GetStudentRadar(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetStudentRadarReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetStudentRadar(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetClassCommonMistakes .
+// @router /homework/common_mistakes [GET]
+func GetClassCommonMistakes(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetClassCommonMistakesReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetClassCommonMistakes(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetTeacherDashboard .
+// @router /homework/dashboard [GET]
+func GetTeacherDashboard(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetTeacherDashboardReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.HomeworkService.GetTeacherDashboard(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
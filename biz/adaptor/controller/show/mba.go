@@ -7,7 +7,6 @@ import (
 	"essay-show/provider"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // ListMbaQuestions .
@@ -15,7 +14,7 @@ import (
 func ListMbaQuestions(ctx context.Context, c *app.RequestContext) {
 	var req show.ListMbaQuestionsReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -29,7 +28,7 @@ func ListMbaQuestions(ctx context.Context, c *app.RequestContext) {
 func GetMbaQuestion(ctx context.Context, c *app.RequestContext) {
 	var req show.GetMbaQuestionReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -43,7 +42,7 @@ func GetMbaQuestion(ctx context.Context, c *app.RequestContext) {
 func SubmitMbaAnswer(ctx context.Context, c *app.RequestContext) {
 	var req show.SubmitMbaAnswerReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -57,7 +56,7 @@ func SubmitMbaAnswer(ctx context.Context, c *app.RequestContext) {
 func GetMbaEvaluate(ctx context.Context, c *app.RequestContext) {
 	var req show.GetMbaEvaluateReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -71,7 +70,7 @@ func GetMbaEvaluate(ctx context.Context, c *app.RequestContext) {
 func ListMbaEvaluates(ctx context.Context, c *app.RequestContext) {
 	var req show.ListMbaEvaluatesReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -7,7 +7,6 @@ import (
 	"essay-show/provider"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // ListMembershipProducts .
@@ -15,7 +14,7 @@ import (
 func ListMembershipProducts(ctx context.Context, c *app.RequestContext) {
 	var req show.ListMembershipProductsReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -29,7 +28,7 @@ func ListMembershipProducts(ctx context.Context, c *app.RequestContext) {
 func SignMembership(ctx context.Context, c *app.RequestContext) {
 	var req show.SignMembershipReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -43,7 +42,7 @@ func SignMembership(ctx context.Context, c *app.RequestContext) {
 func GetMembershipStatus(ctx context.Context, c *app.RequestContext) {
 	var req show.GetMembershipStatusReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -63,7 +62,7 @@ type platformVirtualPayNotify struct {
 func MembershipNotify(ctx context.Context, c *app.RequestContext) {
 	var notify platformVirtualPayNotify
 	if err := c.BindAndValidate(&notify); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -0,0 +1,94 @@
+package show
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	show "essay-show/biz/application/dto/essay/show"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// GenerateBindCode .
+// @router /user/parent/bind_code [POST]
+func GenerateBindCode(ctx context.Context, c *app.RequestContext) {
+	var req show.GenerateBindCodeReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ParentService.GenerateBindCode(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// BindChild .
+// @router /parent/bind [POST]
+func BindChild(ctx context.Context, c *app.RequestContext) {
+	var req show.BindChildReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ParentService.BindChild(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UnbindChild .
+// @router /parent/unbind [POST]
+func UnbindChild(ctx context.Context, c *app.RequestContext) {
+	var req show.UnbindChildReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ParentService.UnbindChild(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListChildren .
+// @router /parent/children [GET]
+func ListChildren(ctx context.Context, c *app.RequestContext) {
+	var req show.ListChildrenReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ParentService.ListChildren(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListChildHomeworks .
+// @router /parent/child/homeworks [GET]
+func ListChildHomeworks(ctx context.Context, c *app.RequestContext) {
+	var req show.ListChildHomeworksReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ParentService.ListChildHomeworks(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetChildSubmissionEvaluate .
+// @router /parent/child/submission/evaluate [GET]
+func GetChildSubmissionEvaluate(ctx context.Context, c *app.RequestContext) {
+	var req show.GetChildSubmissionEvaluateReq
+	if err := c.BindAndValidate(&req); err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.ParentService.GetChildSubmissionEvaluate(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
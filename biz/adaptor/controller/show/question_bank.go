@@ -10,17 +10,16 @@ import (
 	"essay-show/provider"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 )
 
 // ListQuestionBanks 获取题库列表
 // @router /question_bank/list [GET]
 func ListQuestionBanks(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.ListQuestionBanksReq
+	var req show.SearchQuestionBanksReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -28,3 +27,179 @@ func ListQuestionBanks(ctx context.Context, c *app.RequestContext) {
 	resp, err := p.QuestionBankService.ListQuestionBanks(ctx, &req)
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
+
+// CreateQuestion .
+// @router /question_bank/create [POST]
+func CreateQuestion(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.CreateQuestionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.CreateQuestion(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateQuestion .
+// @router /question_bank/update [POST]
+func UpdateQuestion(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateQuestionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.UpdateQuestion(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// DeleteQuestion .
+// @router /question_bank/delete [POST]
+func DeleteQuestion(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.DeleteQuestionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.DeleteQuestion(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListMyQuestions .
+// @router /question_bank/mine [GET]
+func ListMyQuestions(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListMyQuestionsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.ListMyQuestions(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// AssignQuestion .
+// @router /question_bank/assign [POST]
+func AssignQuestion(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.AssignQuestionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.AssignQuestion(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetQuestionBankDetail .
+// @router /question_bank/detail [GET]
+func GetQuestionBankDetail(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetQuestionBankDetailReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.GetQuestionBankDetail(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetQuestionBankTree .
+// @router /question_bank/tree [GET]
+func GetQuestionBankTree(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetQuestionBankTreeReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.GetQuestionBankTree(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// AddFavorite .
+// @router /question_bank/favorite/add [POST]
+func AddFavorite(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.FavoriteQuestionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.AddFavorite(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RemoveFavorite .
+// @router /question_bank/favorite/remove [POST]
+func RemoveFavorite(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.FavoriteQuestionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.RemoveFavorite(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListFavorites .
+// @router /question_bank/favorites [GET]
+func ListFavorites(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListFavoritesReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.ListFavorites(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// AssignFavorite .
+// @router /question_bank/favorite/assign [POST]
+func AssignFavorite(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.AssignFavoriteReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.QuestionBankService.AssignFavorite(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
@@ -7,13 +7,13 @@ import (
 	"encoding/json"
 	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/metrics"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
 	"essay-show/provider"
 	"net/http"
 
 	"github.com/cloudwego/hertz/pkg/app"
-	"github.com/cloudwego/hertz/pkg/protocol/consts"
 	"github.com/cloudwego/hertz/pkg/protocol/sse"
 )
 
@@ -24,7 +24,7 @@ func SignIn(ctx context.Context, c *app.RequestContext) {
 	var req show.SignInReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -40,7 +40,7 @@ func GetUserInfo(ctx context.Context, c *app.RequestContext) {
 	var req show.GetUserInfoReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -60,7 +60,7 @@ func EssayEvaluate(ctx context.Context, c *app.RequestContext) {
 func EssayEvaluateStream(ctx context.Context, c *app.RequestContext) {
 	var req show.EssayEvaluateReq
 	if err := c.BindAndValidate(&req); err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -68,26 +68,29 @@ func EssayEvaluateStream(ctx context.Context, c *app.RequestContext) {
 
 	c.SetStatusCode(http.StatusOK)
 	w := sse.NewWriter(c)
+	metrics.SSEConnections.WithLabelValues("essay_evaluate").Inc()
+	defer metrics.SSEConnections.WithLabelValues("essay_evaluate").Dec()
 
-	resultChan := make(chan string, 100)
+	resultChan := util.NewStreamChan(ctx)
 
 	// 启动批改服务
 	go func(ctx context.Context) {
 		p := provider.Get()
-		defer close(resultChan)
-		p.EssayService.EssayEvaluateStream(ctx, &req, resultChan)
+		defer util.CloseStreamChan(resultChan)
+		err := p.EssayService.EssayEvaluateStream(ctx, &req, resultChan)
+		metrics.EvaluateDone("essay", err == nil)
 	}(ctx)
 
 	// 实时转发流式数据
 	for jsonMessage := range resultChan {
-		err := w.WriteEvent("", "", []byte(jsonMessage))
+		var msgData util.StreamMessage
+		json.Unmarshal([]byte(jsonMessage), &msgData)
+		err := w.WriteEvent(msgData.EventId(), "", []byte(jsonMessage))
 		if err != nil {
-			log.Error("发送SSE事件失败: %v", err)
+			log.CtxError(ctx, "发送SSE事件失败: %v", err)
 			break
 		}
 
-		var msgData util.StreamMessage
-		json.Unmarshal([]byte(jsonMessage), &msgData)
 		if msgData.Type == util.STComplete {
 			break
 		}
@@ -105,7 +108,7 @@ func GetEvaluateLogs(ctx context.Context, c *app.RequestContext) {
 	var req show.GetEssayEvaluateLogsReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -121,7 +124,7 @@ func LikeEvaluate(ctx context.Context, c *app.RequestContext) {
 	var req show.LikeEvaluateReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -137,7 +140,7 @@ func UpdateUserInfo(ctx context.Context, c *app.RequestContext) {
 	var req show.UpdateUserInfoReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -153,7 +156,7 @@ func GetInvitationCode(ctx context.Context, c *app.RequestContext) {
 	var req show.GetInvitationCodeReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -169,7 +172,7 @@ func GetDailyAttend(ctx context.Context, c *app.RequestContext) {
 	var req show.GetDailyAttendReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -185,7 +188,7 @@ func DailyAttend(ctx context.Context, c *app.RequestContext) {
 	var req show.DailyAttendReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -198,10 +201,10 @@ func DailyAttend(ctx context.Context, c *app.RequestContext) {
 // @router /sts/ocr [POST]
 func OCR(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.OCRReq
+	var req show.OCRWithPreprocessReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -214,10 +217,10 @@ func OCR(ctx context.Context, c *app.RequestContext) {
 // @router /sts/apply [POST]
 func ApplySignedUrl(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.ApplySignedUrlReq
+	var req show.ApplySignedUrlWithSizeReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -226,6 +229,22 @@ func ApplySignedUrl(ctx context.Context, c *app.RequestContext) {
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
 
+// BatchApplySignedUrl .
+// @router /sts/apply/batch [POST]
+func BatchApplySignedUrl(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.BatchApplySignedUrlReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.StsService.BatchApplySignedUrl(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
 // SendVerifyCode .
 // @router /sts/send_verify_code [POST]
 func SendVerifyCode(ctx context.Context, c *app.RequestContext) {
@@ -233,7 +252,7 @@ func SendVerifyCode(ctx context.Context, c *app.RequestContext) {
 	var req show.SendVerifyCodeReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 	p := provider.Get()
@@ -248,7 +267,7 @@ func SubmitFeedback(ctx context.Context, c *app.RequestContext) {
 	var req show.SubmitFeedbackReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -261,10 +280,10 @@ func SubmitFeedback(ctx context.Context, c *app.RequestContext) {
 // @router /essay/evaluate/download [POST]
 func DownloadEvaluate(ctx context.Context, c *app.RequestContext) {
 	var err error
-	var req show.DownloadEvaluateReq
+	var req show.DownloadEvaluateWithFormatReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -280,7 +299,7 @@ func BindAuth(ctx context.Context, c *app.RequestContext) {
 	var req show.BindAuthReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -296,7 +315,7 @@ func EvaluateModify(ctx context.Context, c *app.RequestContext) {
 	var req show.EvaluateModifyReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -305,6 +324,38 @@ func EvaluateModify(ctx context.Context, c *app.RequestContext) {
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
 
+// ListEvaluateVersions .
+// @router /essay/evaluate/versions [GET]
+func ListEvaluateVersions(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListEvaluateVersionsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.EssayService.ListEvaluateVersions(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RollbackEvaluate .
+// @router /essay/evaluate/rollback [POST]
+func RollbackEvaluate(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RollbackEvaluateReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.EssayService.RollbackEvaluate(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
 // GenerateUrlLink .
 // @router /sts/generate_url_link [POST]
 func GenerateUrlLink(ctx context.Context, c *app.RequestContext) {
@@ -312,7 +363,7 @@ func GenerateUrlLink(ctx context.Context, c *app.RequestContext) {
 	var req show.GenerateUrlLinkReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -328,7 +379,7 @@ func FillInvitationCode(ctx context.Context, c *app.RequestContext) {
 	var req show.FillInvitationCodeReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 
@@ -344,10 +395,351 @@ func DeleteEvaluate(ctx context.Context, c *app.RequestContext) {
 	var req show.DeleteEvaluateReq
 	err = c.BindAndValidate(&req)
 	if err != nil {
-		c.String(consts.StatusBadRequest, err.Error())
+		adaptor.BindFailed(ctx, c, err)
 		return
 	}
 	p := provider.Get()
 	resp, err := p.EssayService.DeleteEvaluate(ctx, &req)
 	adaptor.PostProcess(ctx, c, &req, resp, err)
 }
+
+// SetPassword .
+// @router /user/password/set [POST]
+func SetPassword(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.SetPasswordReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.SetPassword(ctx, &req)
+	adaptor.PostProcess(ctx, c, nil, resp, err)
+}
+
+// ChangePassword .
+// @router /user/password/change [POST]
+func ChangePassword(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ChangePasswordReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.ChangePassword(ctx, &req)
+	adaptor.PostProcess(ctx, c, nil, resp, err)
+}
+
+// RefreshToken .
+// @router /user/refresh_token [POST]
+func RefreshToken(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RefreshTokenReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.RefreshToken(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// SignOut .
+// @router /user/sign_out [POST]
+func SignOut(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.SignOutReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.SignOut(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListSessions .
+// @router /user/sessions [GET]
+func ListSessions(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListSessionsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.ListSessions(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// RevokeSession .
+// @router /user/session/revoke [POST]
+func RevokeSession(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.RevokeSessionReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.RevokeSession(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ExportMyData .
+// @router /user/data_export [POST]
+func ExportMyData(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ExportMyDataReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.ExportMyData(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetMyDataExport .
+// @router /user/data_export [GET]
+func GetMyDataExport(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetDownloadTaskReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.GetMyDataExport(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// MakeupAttend .
+// @router /user/daily_attend/makeup [POST]
+func MakeupAttend(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.MakeupAttendReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.MakeupAttend(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetInvitationStats .
+// @router /user/invitation/stats [GET]
+func GetInvitationStats(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetInvitationStatsReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.GetInvitationStats(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// CorrectOCRText .
+// @router /sts/ocr/correct [POST]
+func CorrectOCRText(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.CorrectOCRTextReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.StsService.CorrectOCRText(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetOcrQuota .
+// @router /sts/ocr/quota [GET]
+func GetOcrQuota(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetOcrQuotaReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.StsService.GetOcrQuota(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UploadFile .
+// @router /sts/upload [POST]
+func UploadFile(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UploadFileReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+	file, err := c.FormFile("file")
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.StsService.UploadFile(ctx, &req, file)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetWatermark .
+// @router /user/watermark [GET]
+func GetWatermark(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetWatermarkReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.GetWatermark(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateWatermark .
+// @router /user/watermark [POST]
+func UpdateWatermark(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateWatermarkReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.UpdateWatermark(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetNotifySetting .
+// @router /user/notify [GET]
+func GetNotifySetting(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetNotifySettingReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.GetNotifySetting(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateNotifySetting .
+// @router /user/notify [POST]
+func UpdateNotifySetting(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateNotifySettingReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.UpdateNotifySetting(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// GetNotificationPreference .
+// @router /user/notify/preference [GET]
+func GetNotificationPreference(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.GetNotificationPreferenceReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.GetNotificationPreference(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// UpdateNotificationPreference .
+// @router /user/notify/preference [POST]
+func UpdateNotificationPreference(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.UpdateNotificationPreferenceReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.UpdateNotificationPreference(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ListMessages .
+// @router /user/messages [GET]
+func ListMessages(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ListMessagesReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.ListMessages(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
+
+// ReadMessages .
+// @router /user/messages/read [POST]
+func ReadMessages(ctx context.Context, c *app.RequestContext) {
+	var err error
+	var req show.ReadMessagesReq
+	err = c.BindAndValidate(&req)
+	if err != nil {
+		adaptor.BindFailed(ctx, c, err)
+		return
+	}
+
+	p := provider.Get()
+	resp, err := p.UserService.ReadMessages(ctx, &req)
+	adaptor.PostProcess(ctx, c, &req, resp, err)
+}
@@ -0,0 +1,62 @@
+package adaptor
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/spf13/cast"
+)
+
+const (
+	deviceTokenHeader = "X-Device-Token"
+	deviceTokenType   = "device"
+)
+
+// IssueDeviceToken 为服务端分配的设备号签发设备令牌并写入响应头 X-Device-Token，
+// 客户端保存后在之后的请求中原样回传，设备号无法由客户端自行指定
+func IssueDeviceToken(ctx context.Context, deviceId string) error {
+	c, err := ExtractContext(ctx)
+	if err != nil {
+		return err
+	}
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(config.GetConfig().Auth.SecretKey))
+	if err != nil {
+		return err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"typ":      deviceTokenType,
+		"deviceId": deviceId,
+		"iat":      time.Now().Unix(),
+	})
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return err
+	}
+	c.Response.Header.Set(deviceTokenHeader, tokenString)
+	return nil
+}
+
+// ExtractDeviceId 校验客户端回传的设备令牌并取出设备号，令牌缺失或验签失败时返回空，调用方应视为未知设备
+func ExtractDeviceId(ctx context.Context) string {
+	c, err := ExtractContext(ctx)
+	if err != nil {
+		return ""
+	}
+	tokenString := c.GetHeader(deviceTokenHeader)
+	if len(tokenString) == 0 {
+		return ""
+	}
+	token, err := jwt.Parse(string(tokenString), func(_ *jwt.Token) (interface{}, error) {
+		return jwt.ParseECPublicKeyFromPEM([]byte(config.GetConfig().Auth.PublicKey))
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodES256.Alg()}))
+	if err != nil || !token.Valid {
+		return ""
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || cast.ToString(claims["typ"]) != deviceTokenType {
+		return ""
+	}
+	return cast.ToString(claims["deviceId"])
+}
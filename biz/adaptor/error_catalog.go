@@ -0,0 +1,37 @@
+package adaptor
+
+import (
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+
+	hertz "github.com/cloudwego/hertz/pkg/protocol/consts"
+)
+
+// ErrorCatalog 错误码目录，从 consts 中定义的错误收集，HTTP 状态码与 PostProcess、writeApiV2 的处理一致
+func ErrorCatalog() *show.GetApiErrorsResp {
+	errnos := consts.Errnos()
+	resp := &show.GetApiErrorsResp{Errors: make([]*show.ApiErrorInfo, 0, len(errnos))}
+	for _, errno := range errnos {
+		httpStatus, ok := errHttpStatus[errno]
+		switch {
+		case ok:
+		case errno == consts.ErrForbidden:
+			httpStatus = hertz.StatusForbidden
+		case errno == consts.ErrInternal:
+			httpStatus = hertz.StatusInternalServerError
+		default:
+			httpStatus = hertz.StatusOK
+		}
+		v2 := apiV2ErrorOf(errno)
+		resp.Errors = append(resp.Errors, &show.ApiErrorInfo{
+			Code:         uint32(errno.Code()),
+			HttpStatus:   httpStatus,
+			V2Code:       v2.code,
+			V2HttpStatus: v2.status,
+			Message:      errno.Message(consts.LangEn),
+			MessageZh:    errno.Message(consts.LangZh),
+			Advice:       errno.Advice(),
+		})
+	}
+	return resp
+}
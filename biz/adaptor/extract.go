@@ -2,10 +2,14 @@ package adaptor
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"essay-show/biz/application/dto/basic"
 	"essay-show/biz/application/dto/essay/sts"
+	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/util"
@@ -14,9 +18,15 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc/metadata"
 )
 
-const hertzContext = "hertz_context"
+const (
+	hertzContext     = "hertz_context"
+	revokeCheckedKey = "token_revoke_checked" // 本次请求 token 吊销检查的结果
+)
 
 func InjectContext(ctx context.Context, c *app.RequestContext) context.Context {
 	return context.WithValue(ctx, hertzContext, c)
@@ -38,22 +48,14 @@ func ExtractUserMeta(ctx context.Context) (user *basic.UserMeta) {
 			log.CtxInfo(ctx, "验签失败, err=%v", err)
 		}
 	}()
-	c, err := ExtractContext(ctx)
+	claims, err := parseToken(ctx)
 	if err != nil {
 		return
 	}
-	tokenString := c.GetHeader("Authorization")
-	token, err := jwt.Parse(string(tokenString), func(_ *jwt.Token) (interface{}, error) {
-		return jwt.ParseECPublicKeyFromPEM([]byte(config.GetConfig().Auth.PublicKey))
-	})
-	if err != nil {
+	if err = checkRevoked(ctx, claims); err != nil {
 		return
 	}
-	if !token.Valid {
-		err = errors.New("token is not valid")
-		return
-	}
-	data, err := json.Marshal(token.Claims)
+	data, err := json.Marshal(claims)
 	if err != nil {
 		return
 	}
@@ -74,34 +76,228 @@ func ExtractUserMeta(ctx context.Context) (user *basic.UserMeta) {
 	return
 }
 
+// TokenMeta 当前请求 token 的标识信息，用于登出与会话管理
+type TokenMeta struct {
+	Jti      string
+	UserId   string
+	DeviceId string
+	Iat      int64
+	Exp      int64
+}
+
+// ExtractTokenMeta 解析当前请求 token 的 jti 与有效期，旧 token 没有 jti 时以 token 摘要代替
+func ExtractTokenMeta(ctx context.Context) (*TokenMeta, error) {
+	claims, err := parseToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return newTokenMeta(tokenId(ctx, claims), claims), nil
+}
+
+// ExtractTokenUserId 只校验签名取出 token 中的用户 id，不查黑名单，仅用于日志等不做鉴权的场景
+func ExtractTokenUserId(ctx context.Context) string {
+	claims, err := parseToken(ctx)
+	if err != nil {
+		return ""
+	}
+	return cast.ToString(claims["userId"])
+}
+
+// ExtractClientInfo 获取客户端 IP 与 UA，设备号由服务端签发，见 ExtractDeviceId
+func ExtractClientInfo(ctx context.Context) (ip, ua string) {
+	c, err := ExtractContext(ctx)
+	if err != nil {
+		return
+	}
+	return c.ClientIP(), string(c.UserAgent())
+}
+
+const apiCallerContext = "api_caller"
+
+// ApiCaller 通过 API Key 鉴权的外部调用方
+type ApiCaller struct {
+	KeyId        string
+	Name         string
+	DailyQuota   int64    // 0 表示不限
+	MonthlyQuota int64    // 0 表示不限
+	SignSecret   string   // 请求签名的密钥，为空表示尚未分配，需轮换后才能签名
+	IPWhitelist  []string // 允许的来源 IP 或 CIDR，为空时不限制
+}
+
+func WithApiCaller(ctx context.Context, caller *ApiCaller) context.Context {
+	return context.WithValue(ctx, apiCallerContext, caller)
+}
+
+// ExtractApiCaller 获取当前请求的外部调用方，不是 /api/v1 请求时返回 nil
+func ExtractApiCaller(ctx context.Context) *ApiCaller {
+	caller, _ := ctx.Value(apiCallerContext).(*ApiCaller)
+	return caller
+}
+
+func newTokenMeta(jti string, claims jwt.MapClaims) *TokenMeta {
+	return &TokenMeta{
+		Jti:      jti,
+		UserId:   cast.ToString(claims["userId"]),
+		DeviceId: cast.ToString(claims["deviceId"]),
+		Iat:      cast.ToInt64(claims["iat"]),
+		Exp:      cast.ToInt64(claims["exp"]),
+	}
+}
+
+// authorization 取出 Authorization 头，gRPC 请求从 metadata 的 authorization 中读取
+func authorization(ctx context.Context) ([]byte, error) {
+	if c, err := ExtractContext(ctx); err == nil {
+		return c.GetHeader("Authorization"), nil
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			return []byte(v[0]), nil
+		}
+	}
+	return nil, errors.New("authorization not found")
+}
+
+// parseToken 校验并解析 Authorization 头中的 token
+func parseToken(ctx context.Context) (jwt.MapClaims, error) {
+	tokenString, err := authorization(ctx)
+	if err != nil {
+		return nil, err
+	}
+	token, err := jwt.Parse(string(tokenString), func(_ *jwt.Token) (interface{}, error) {
+		return jwt.ParseECPublicKeyFromPEM([]byte(config.GetConfig().Auth.PublicKey))
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !token.Valid {
+		return nil, errors.New("token is not valid")
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("token claims is not valid")
+	}
+	return claims, nil
+}
+
+// checkRevoked 检查 token 是否已登出或被踢下线，同一请求内只查询一次 redis；
+// redis 异常时按 Auth.RevokeCheckStrict 决定拒绝还是放行
+func checkRevoked(ctx context.Context, claims jwt.MapClaims) error {
+	c, _ := ExtractContext(ctx)
+	if c != nil {
+		if v, ok := c.Get(revokeCheckedKey); ok {
+			err, _ := v.(error)
+			return err
+		}
+	}
+	err := lookupRevoked(ctx, claims)
+	if c != nil {
+		c.Set(revokeCheckedKey, err)
+	}
+	return err
+}
+
+func lookupRevoked(ctx context.Context, claims jwt.MapClaims) error {
+	cfg := config.GetConfig()
+	blacklist := cache.NewTokenBlacklistMapper(cfg)
+	revoked, before, err := blacklist.Lookup(ctx, tokenId(ctx, claims), cast.ToString(claims["userId"]))
+	if err != nil {
+		log.CtxError(ctx, "查询token吊销状态失败, err=%v", err)
+		if cfg.Auth.RevokeCheckStrict {
+			return errors.New("token revocation check failed")
+		}
+		return nil
+	}
+	if revoked {
+		return errors.New("token has been revoked")
+	}
+	if before > 0 && issuedAtMilli(claims) <= before {
+		return errors.New("token has been revoked")
+	}
+	return nil
+}
+
+// issuedAtMilli token 的毫秒签发时间，旧 token 没有 iatMs 时按秒级 iat 换算
+func issuedAtMilli(claims jwt.MapClaims) int64 {
+	if ms := cast.ToInt64(claims["iatMs"]); ms > 0 {
+		return ms
+	}
+	return cast.ToInt64(claims["iat"]) * 1000
+}
+
+func tokenId(ctx context.Context, claims jwt.MapClaims) string {
+	if jti := cast.ToString(claims["jti"]); jti != "" {
+		return jti
+	}
+	tokenString, err := authorization(ctx)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(tokenString)
+	return hex.EncodeToString(sum[:])
+}
+
 // generateJwtToken 生成jwt
 /*
 生成 ECDSA 私钥: openssl ecparam -genkey -name prime256v1 -noout -out private_key.pem
 从私钥中提取公钥: openssl ec -in private_key.pem -pubout -out public_key.pem
 */
-func GenerateJwtToken(resp *sts.SignInResp) (string, int64, error) {
+func GenerateJwtToken(resp *sts.SignInResp, deviceId string) (string, *TokenMeta, error) {
 	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(config.GetConfig().Auth.SecretKey))
 	if err != nil {
-		return "", 0, err
+		return "", nil, err
 	}
-	iat := time.Now().Unix()
+	now := time.Now()
+	iat := now.Unix()
 	exp := iat + config.GetConfig().Auth.AccessExpire
 	claims := make(jwt.MapClaims)
+	claims["jti"] = uuid.NewString()
 	claims["exp"] = exp
 	claims["iat"] = iat
+	claims["iatMs"] = now.UnixMilli()
 	claims["userId"] = resp.UserId
 	claims["appId"] = consts.AppId
-	claims["deviceId"] = "" // 暂时传空
+	claims["deviceId"] = deviceId
 	claims["wechatUserMeta"] = &basic.WechatUserMeta{
 		AppId:   resp.AppId,
 		OpenId:  resp.OpenId,
 		UnionId: resp.UnionId,
 	}
+	return signJwtToken(key, claims)
+}
+
+// RefreshJwtToken 用临近过期的合法 token 换发新 token，保留原有 claims，更新 jti、签发与过期时间
+func RefreshJwtToken(ctx context.Context) (string, *TokenMeta, error) {
+	claims, err := parseToken(ctx)
+	if err != nil {
+		return "", nil, consts.ErrNotAuthentication
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return "", nil, consts.ErrNotAuthentication
+	}
+	if int64(exp)-time.Now().Unix() > config.GetConfig().Auth.RefreshWindow {
+		return "", nil, consts.ErrRefreshTooEarly
+	}
+
+	key, err := jwt.ParseECPrivateKeyFromPEM([]byte(config.GetConfig().Auth.SecretKey))
+	if err != nil {
+		return "", nil, err
+	}
+	now := time.Now()
+	iat := now.Unix()
+	claims["jti"] = uuid.NewString()
+	claims["iat"] = iat
+	claims["iatMs"] = now.UnixMilli()
+	claims["exp"] = iat + config.GetConfig().Auth.AccessExpire
+	return signJwtToken(key, claims)
+}
+
+func signJwtToken(key *ecdsa.PrivateKey, claims jwt.MapClaims) (string, *TokenMeta, error) {
 	token := jwt.New(jwt.SigningMethodES256)
 	token.Claims = claims
 	tokenString, err := token.SignedString(key)
 	if err != nil {
-		return "", 0, err
+		return "", nil, err
 	}
-	return tokenString, exp, nil
+	return tokenString, newTokenMeta(cast.ToString(claims["jti"]), claims), nil
 }
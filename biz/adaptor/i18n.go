@@ -0,0 +1,36 @@
+package adaptor
+
+import (
+	"errors"
+	"essay-show/biz/infrastructure/consts"
+	"strings"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"google.golang.org/grpc/status"
+)
+
+// requestLang 错误文案的语言，外部 API 固定为英文，其余按 Accept-Language 选择，默认中文
+func requestLang(c *app.RequestContext) string {
+	if strings.HasPrefix(string(c.Path()), consts.ApiPathPrefix) {
+		return consts.LangEn
+	}
+	return consts.ParseLang(string(c.GetHeader("Accept-Language")))
+}
+
+// newBizError 按请求语言生成错误响应，自定义错误的文案查多语言映射，具体原因放在 detail 中
+func newBizError(c *app.RequestContext, err error) *BizError {
+	lang := requestLang(c)
+	var errno *consts.Errno
+	if errors.As(err, &errno) {
+		return &BizError{
+			Code:   uint32(errno.Code()),
+			Msg:    errno.Message(lang),
+			Detail: errno.Detail(),
+		}
+	}
+	s, _ := status.FromError(err)
+	return &BizError{
+		Code: uint32(s.Code()),
+		Msg:  s.Message(),
+	}
+}
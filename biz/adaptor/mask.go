@@ -0,0 +1,85 @@
+package adaptor
+
+import (
+	"essay-show/biz/infrastructure/config"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// maskedFields 访问日志中值会被整体替换为 *** 的字段
+var maskedFields = []string{
+	"password", "oldPassword", "verifyCode",
+	"token", "accessToken", "refreshToken", "sessionToken",
+	"secretId", "secretKey", "Authorization",
+}
+
+var (
+	// phonePattern 大陆手机号
+	phonePattern = regexp.MustCompile(`1[3-9]\d{9}`)
+	// signParamPattern url 中的签名参数，json 中的 & 会被转义为 \u0026
+	signParamPattern = regexp.MustCompile(`(?i)((?:\?|&|\\u0026)(?:q-signature|q-ak|x-cos-security-token|signature|sign|token|access_token|sessionToken)=)[^&"\s\\]*`)
+
+	// fieldPattern 按配置编译的敏感字段正则，配置热加载后重新编译
+	fieldPattern atomic.Pointer[compiledFieldPattern]
+)
+
+type compiledFieldPattern struct {
+	cfg *config.Config
+	re  *regexp.Regexp
+}
+
+// maskLogContent 脱敏 json 格式的日志内容：敏感字段、手机号和 url 签名参数
+func maskLogContent(content string) string {
+	content = getFieldPattern().ReplaceAllString(content, `$1"***"`)
+	content = signParamPattern.ReplaceAllString(content, "${1}***")
+	return maskPhone(content)
+}
+
+// MaskLogContent 供 gRPC 等 PostProcess 之外的访问日志脱敏
+func MaskLogContent(content string) string {
+	return maskLogContent(content)
+}
+
+// maskPhone 隐藏手机号中间 4 位，前后紧挨数字或字母的不是手机号，避免误伤 id
+func maskPhone(content string) string {
+	locs := phonePattern.FindAllStringIndex(content, -1)
+	if len(locs) == 0 {
+		return content
+	}
+	b := []byte(content)
+	for _, loc := range locs {
+		if isAlnum(b, loc[0]-1) || isAlnum(b, loc[1]) {
+			continue
+		}
+		copy(b[loc[0]+3:loc[0]+7], "****")
+	}
+	return string(b)
+}
+
+func isAlnum(b []byte, i int) bool {
+	if i < 0 || i >= len(b) {
+		return false
+	}
+	c := b[i]
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// getFieldPattern 默认字段加上配置中的 MaskFields，首次使用或配置变化时编译
+func getFieldPattern() *regexp.Regexp {
+	cfg := config.GetConfig()
+	if p := fieldPattern.Load(); p != nil && p.cfg == cfg {
+		return p.re
+	}
+	fields := maskedFields
+	if cfg != nil {
+		fields = append(fields[:len(fields):len(fields)], cfg.Log.MaskFields...)
+	}
+	quoted := make([]string, 0, len(fields))
+	for _, f := range fields {
+		quoted = append(quoted, regexp.QuoteMeta(f))
+	}
+	re := regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	fieldPattern.Store(&compiledFieldPattern{cfg: cfg, re: re})
+	return re
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ApiIPWhitelist 外部 API 的来源 IP 校验，需在 ApiKeyAuth 之后使用；密钥未配置白名单时不限制
+func ApiIPWhitelist() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if err := provider.Get().ApiKeyService.CheckIP(ctx, adaptor.ExtractApiCaller(ctx), c.ClientIP()); err != nil {
+			adaptor.PostProcess(ctx, c, nil, nil, err)
+			c.Abort()
+			return
+		}
+		c.Next(ctx)
+	}
+}
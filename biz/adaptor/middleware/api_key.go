@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ApiKeyAuth 外部 API 的鉴权，校验 X-Api-Key 头并把调用方写入 context，后续通过 adaptor.ExtractApiCaller 读取
+func ApiKeyAuth() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		k, err := provider.Get().ApiKeyService.Authenticate(ctx, string(c.GetHeader(consts.ApiKeyHeader)))
+		if err != nil {
+			adaptor.PostProcess(ctx, c, nil, nil, err)
+			c.Abort()
+			return
+		}
+		ctx = adaptor.WithApiCaller(ctx, &adaptor.ApiCaller{
+			KeyId:        k.ID.Hex(),
+			Name:         k.Name,
+			DailyQuota:   k.DailyQuota,
+			MonthlyQuota: k.MonthlyQuota,
+			SignSecret:   k.SignSecret,
+			IPWhitelist:  k.IPWhitelist,
+		})
+		c.Next(ctx)
+	}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ApiSignature 外部 API 的请求签名校验，防重放和篡改，需在 ApiKeyAuth 之后使用。
+// 调用方在 X-Timestamp、X-Nonce、X-Signature 头中携带签名，算法见 service.ApiKeyService.VerifySignature；
+// 配置 ApiSign.Required 为 false 时未携带签名的请求直接放行
+func ApiSignature() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		signature := string(c.GetHeader(consts.ApiSignHeaderSignature))
+		if signature == "" && !config.GetConfig().ApiSign.Required {
+			c.Next(ctx)
+			return
+		}
+		err := provider.Get().ApiKeyService.VerifySignature(ctx, adaptor.ExtractApiCaller(ctx),
+			string(c.Method()), string(c.URI().RequestURI()),
+			string(c.GetHeader(consts.ApiSignHeaderTimestamp)), string(c.GetHeader(consts.ApiSignHeaderNonce)),
+			signature, c.Request.Body())
+		if err != nil {
+			adaptor.PostProcess(ctx, c, nil, nil, err)
+			c.Abort()
+			return
+		}
+		c.Next(ctx)
+	}
+}
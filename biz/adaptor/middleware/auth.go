@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/service"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/provider"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// RequireRole 路由级角色校验，当前用户不是 roles 之一或已被封禁时直接中断请求，
+// 校验通过后当前用户随 ctx 传给 handler，service 用 service.CurrentUser 取用
+func RequireRole(roles ...string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		u, err := provider.Get().Guard.RequireRole(ctx, roles...)
+		if err != nil {
+			adaptor.PostProcess(ctx, c, nil, nil, err)
+			c.Abort()
+			return
+		}
+		c.Next(service.WithCurrentUser(ctx, u))
+	}
+}
+
+// TeacherOnly 仅教师可访问
+func TeacherOnly() app.HandlerFunc {
+	return RequireRole(consts.RoleTeacher)
+}
+
+// StudentOnly 仅学生可访问
+func StudentOnly() app.HandlerFunc {
+	return RequireRole(consts.RoleStudent)
+}
+
+// AdminOnly 仅管理员可访问
+func AdminOnly() app.HandlerFunc {
+	return RequireRole(consts.RoleAdmin)
+}
+
+// GuardianOnly 家长相关接口的入口校验。家长不单独设角色，学生或教师账号通过绑定码
+// 与孩子建立绑定关系后即为家长，查看具体孩子的数据时由 ParentService.checkGuardian 按绑定关系校验
+func GuardianOnly() app.HandlerFunc {
+	return RequireRole(consts.RoleStudent, consts.RoleTeacher)
+}
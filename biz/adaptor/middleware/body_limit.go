@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/consts"
+	"fmt"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// BodyLimit 限制非文件上传请求的请求体大小，文件上传仍由 server.WithMaxRequestBodySize 限制
+func BodyLimit() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		if !bytes.HasPrefix(c.ContentType(), []byte("multipart/form-data")) && len(c.Request.Body()) > consts.JsonBodyMaxSize {
+			adaptor.PostProcess(ctx, c, nil, nil, consts.InvalidParams("body", fmt.Sprintf("不能超过 %dMB", consts.JsonBodyMaxSize>>20)))
+			c.Abort()
+			return
+		}
+		c.Next(ctx)
+	}
+}
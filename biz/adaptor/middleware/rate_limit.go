@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// RateLimit 按 IP 和登录用户做全局令牌桶限流，令牌存在 Redis 中多实例共享，需在 adaptor.InjectContext 之后使用；
+// IP 由 adaptor.NewClientIP 解析，只采信可信代理的转发头，客户端无法伪造 IP 换桶
+func RateLimit() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		cfg := config.GetConfig().RateLimit
+		if !allow(ctx, c, "ip:"+c.ClientIP(), cfg.IP) {
+			return
+		}
+		if userId := adaptor.ExtractTokenUserId(ctx); userId != "" && !allow(ctx, c, "user:"+userId, cfg.User) {
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+// RouteRateLimit 批改、OCR 等昂贵接口的单独配额，group 对应配置 RateLimit.Routes 的键，按用户计，未登录时按 IP
+func RouteRateLimit(group string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		key := "ip:" + c.ClientIP()
+		if userId := adaptor.ExtractTokenUserId(ctx); userId != "" {
+			key = "user:" + userId
+		}
+		if !allow(ctx, c, group+":"+key, adaptor.RouteBucket(group)) {
+			return
+		}
+		c.Next(ctx)
+	}
+}
+
+// allow 从 key 对应的桶中取一个令牌，取不到时返回 429 并中断请求
+func allow(ctx context.Context, c *app.RequestContext, key string, bucket config.Bucket) bool {
+	if adaptor.AllowRequest(ctx, key, bucket) {
+		return true
+	}
+	// 每秒至少补充一个令牌，1 秒后一定可以重试
+	c.Header("Retry-After", "1")
+	adaptor.PostProcess(ctx, c, nil, nil, consts.ErrTooManyRequests)
+	c.Abort()
+	return false
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/util/log"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/google/uuid"
+)
+
+// RequestId 读取或生成请求 id 并写回响应头，把 request_id 和 user_id 注入日志字段，需在 adaptor.InjectContext 之后使用
+func RequestId() app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		requestId := string(c.GetHeader(log.HeaderRequestId))
+		if requestId == "" {
+			requestId = uuid.NewString()
+		}
+		c.Header(log.HeaderRequestId, requestId)
+		ctx = log.WithRequest(ctx, requestId, adaptor.ExtractTokenUserId(ctx))
+		c.Next(ctx)
+	}
+}
@@ -0,0 +1,47 @@
+package adaptor
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+
+	"github.com/zeromicro/go-zero/core/collection"
+	"github.com/zeromicro/go-zero/core/limit"
+)
+
+// defaultRouteBuckets 配置中未设置的限流分组使用的配额
+var defaultRouteBuckets = map[string]config.Bucket{
+	consts.RateLimitEvaluate: {Rate: consts.RateLimitEvaluateRate, Burst: consts.RateLimitEvaluateBurst},
+	consts.RateLimitOcr:      {Rate: consts.RateLimitOcrRate, Burst: consts.RateLimitOcrBurst},
+}
+
+// limiters 按 key 和配额缓存令牌桶对象，同一个桶复用一个 TokenLimiter，
+// Redis 不可用时由它内置的进程内限流兜底，且只有一个探活协程
+var limiters, _ = collection.NewCache(consts.RateLimiterCacheExpire, collection.WithLimit(consts.RateLimiterCacheLimit))
+
+// RouteBucket 限流分组的配额，group 对应配置 RateLimit.Routes 的键，未配置时使用默认配额
+func RouteBucket(group string) config.Bucket {
+	if bucket, ok := config.GetConfig().RateLimit.Routes[group]; ok {
+		return bucket
+	}
+	return defaultRouteBuckets[group]
+}
+
+// AllowRequest 从 key 对应的令牌桶中取一个令牌，HTTP 中间件与 gRPC 拦截器共用，Rate 不大于 0 时不限流
+func AllowRequest(ctx context.Context, key string, bucket config.Bucket) bool {
+	if bucket.Rate <= 0 {
+		return true
+	}
+	return tokenLimiter(key, bucket).AllowCtx(ctx)
+}
+
+// tokenLimiter 取 key 对应的令牌桶，配额变更后按新配额创建
+func tokenLimiter(key string, bucket config.Bucket) *limit.TokenLimiter {
+	burst := max(bucket.Burst, bucket.Rate)
+	v, _ := limiters.Take(fmt.Sprintf("%s:%d:%d", key, bucket.Rate, burst), func() (any, error) {
+		return limit.NewTokenLimiter(bucket.Rate, burst, redis.GetRedis(config.GetConfig()), consts.RateLimitKeyPrefix+key), nil
+	})
+	return v.(*limit.TokenLimiter)
+}
@@ -3,6 +3,9 @@
 package show
 
 import (
+	"essay-show/biz/adaptor/middleware"
+	"essay-show/biz/infrastructure/consts"
+
 	"github.com/cloudwego/hertz/pkg/app"
 )
 
@@ -22,8 +25,7 @@ func _essayMw() []app.HandlerFunc {
 }
 
 func _essayevaluateMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.RouteRateLimit(consts.RateLimitEvaluate)}
 }
 
 func _likeevaluateMw() []app.HandlerFunc {
@@ -92,8 +94,7 @@ func _applysignedurlMw() []app.HandlerFunc {
 }
 
 func _ocrMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.RouteRateLimit(consts.RateLimitOcr)}
 }
 
 func _sendverifycodeMw() []app.HandlerFunc {
@@ -182,8 +183,7 @@ func _createexercisestreamMw() []app.HandlerFunc {
 }
 
 func _essayevaluatestreamMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.RouteRateLimit(consts.RateLimitEvaluate)}
 }
 
 func _bindauthMw() []app.HandlerFunc {
@@ -197,8 +197,7 @@ func _classMw() []app.HandlerFunc {
 }
 
 func _createclassMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _getclassMw() []app.HandlerFunc {
@@ -227,8 +226,7 @@ func _homeworkMw() []app.HandlerFunc {
 }
 
 func _createhomeworkMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _gethomeworkMw() []app.HandlerFunc {
@@ -277,8 +275,7 @@ func _listhomeworksforstuMw() []app.HandlerFunc {
 }
 
 func _getsubmissionsMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _gethomeworkevaluateMw() []app.HandlerFunc {
@@ -312,13 +309,11 @@ func _downloadsubmissionevaluateMw() []app.HandlerFunc {
 }
 
 func _modifysubmissionevaluateMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _recorrecthomeworkMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _sendwechatmessageMw() []app.HandlerFunc {
@@ -332,13 +327,11 @@ func _deleteevaluateMw() []app.HandlerFunc {
 }
 
 func _deletehomeworkMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _edithomeworkMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _isneedrenameMw() []app.HandlerFunc {
@@ -372,8 +365,7 @@ func _fillsourceMw() []app.HandlerFunc {
 }
 
 func _gethomeworkstatisticsMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _membersMw() []app.HandlerFunc {
@@ -382,28 +374,23 @@ func _membersMw() []app.HandlerFunc {
 }
 
 func _bindclassmemberMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.StudentOnly()}
 }
 
 func _createclassmembersMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _editclassmembernameMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _unbindclassmemberMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.StudentOnly()}
 }
 
 func _deleteclassmemberMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _deleteMw() []app.HandlerFunc {
@@ -422,13 +409,11 @@ func _delete1Mw() []app.HandlerFunc {
 }
 
 func _getclassmemberinfoMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.StudentOnly()}
 }
 
 func _reevaluatehomeworkMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly(), middleware.RouteRateLimit(consts.RateLimitEvaluate)}
 }
 
 func _getusersubmissionsMw() []app.HandlerFunc {
@@ -442,8 +427,7 @@ func _user0Mw() []app.HandlerFunc {
 }
 
 func _adminMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.AdminOnly()}
 }
 
 func _getadminhomeworkstatisticsMw() []app.HandlerFunc {
@@ -477,8 +461,7 @@ func _modifyMw() []app.HandlerFunc {
 }
 
 func _modifysubmissionevaluatesavehistoryMw() []app.HandlerFunc {
-	// your code...
-	return nil
+	return []app.HandlerFunc{middleware.TeacherOnly()}
 }
 
 func _mbaMw() []app.HandlerFunc {
@@ -0,0 +1,235 @@
+package rpc
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/service"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"essay-show/provider"
+	"net"
+	"runtime/debug"
+	"strings"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// methodRateLimits 昂贵方法的单独限流分组，与 HTTP 路由上的 RouteRateLimit 一致
+var methodRateLimits = map[string]string{
+	"/essay.show.show/EssayEvaluate": consts.RateLimitEvaluate,
+	"/essay.show.show/OCR":           consts.RateLimitOcr,
+}
+
+// methodRoles 需要校验角色的方法，与 HTTP 路由上的 TeacherOnly 等中间件一致
+var methodRoles = map[string][]string{
+	"/essay.show.homework/GetSubmissions":        {consts.RoleTeacher},
+	"/essay.show.homework/GetHomeworkStatistics": {consts.RoleTeacher},
+}
+
+// validator 与 HTTP 相同的参数规则：图片数、文本字数和必填字段
+var validator = adaptor.NewValidator()
+
+// 服务与方法名和 IDL（essay/show/show.proto）中的 service 一致，内部系统可直接用 IDL 生成 gRPC 客户端。
+// 鉴权与 HTTP 相同，在 metadata 的 authorization 中携带用户 token；业务错误本身即 grpc status，原样返回
+var showService = grpc.ServiceDesc{
+	ServiceName: "essay.show.show",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("essay.show.show", "EssayEvaluate", func(ctx context.Context, req *show.EssayEvaluateReq) (*show.EssayEvaluateResp, error) {
+			return provider.Get().EssayService.EssayEvaluate(ctx, req)
+		}),
+		unary("essay.show.show", "OCR", func(ctx context.Context, req *show.OCRReq) (*show.OCRResp, error) {
+			return provider.Get().StsService.OCR(ctx, &show.OCRWithPreprocessReq{Ocr: req.Ocr, LeftType: req.LeftType})
+		}),
+	},
+}
+
+var homeworkService = grpc.ServiceDesc{
+	ServiceName: "essay.show.homework",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		unary("essay.show.homework", "ListHomeworks", func(ctx context.Context, req *show.ListHomeworksReq) (*show.ListHomeworksResp, error) {
+			resp, err := provider.Get().HomeworkService.ListHomeworks(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return resp.ToProto(), nil
+		}),
+		unary("essay.show.homework", "GetSubmissions", func(ctx context.Context, req *show.GetSubmissionsReq) (*show.GetSubmissionsResp, error) {
+			resp, err := provider.Get().HomeworkService.GetSubmissions(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return resp.ToProto(), nil
+		}),
+		unary("essay.show.homework", "GetUserSubmissions", func(ctx context.Context, req *show.GetUserSubmissionsReq) (*show.GetUserSubmissionsResp, error) {
+			return provider.Get().HomeworkService.GetUserSubmissions(ctx, req)
+		}),
+		unary("essay.show.homework", "GetSubmissionEvaluate", func(ctx context.Context, req *show.GetSubmissionEvaluateReq) (*show.GetSubmissionEvaluateResp, error) {
+			resp, err := provider.Get().HomeworkService.GetSubmissionEvaluate(ctx, req)
+			if err != nil {
+				return nil, err
+			}
+			return resp.GetSubmissionEvaluateResp, nil
+		}),
+		unary("essay.show.homework", "GetHomeworkStatistics", func(ctx context.Context, req *show.GetHomeworkStatisticsReq) (*show.GetHomeworkStatisticsResp, error) {
+			return provider.Get().HomeworkService.GetHomeworkStatistics(ctx, req)
+		}),
+	},
+}
+
+// unary 把 service 方法包装为 gRPC 一元方法，请求和响应均为 IDL 生成的 proto 消息
+func unary[Req, Resp any](service, method string, h func(ctx context.Context, req *Req) (Resp, error)) grpc.MethodDesc {
+	info := &grpc.UnaryServerInfo{FullMethod: "/" + service + "/" + method}
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(_ any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return h(ctx, req)
+			}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return h(ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+// Serve 在 listenOn 上启动 gRPC server，返回的 server 用于停机时 GracefulStop
+func Serve(listenOn string) (*grpc.Server, error) {
+	lis, err := net.Listen("tcp", listenOn)
+	if err != nil {
+		return nil, err
+	}
+	// 请求体上限、限流和参数校验与 HTTP 一致
+	s := grpc.NewServer(
+		grpc.MaxRecvMsgSize(consts.JsonBodyMaxSize),
+		grpc.ChainUnaryInterceptor(requestInterceptor, recoverInterceptor, limitInterceptor, roleInterceptor),
+		grpc.ChainStreamInterceptor(limitStreamInterceptor),
+	)
+	s.RegisterService(&showService, struct{}{})
+	s.RegisterService(&homeworkService, struct{}{})
+	go func() {
+		if err := s.Serve(lis); err != nil {
+			log.Error("gRPC server 退出, err: %v", err)
+		}
+	}()
+	log.Info("gRPC server start, listen on %s", listenOn)
+	return s, nil
+}
+
+// requestInterceptor 与 HTTP 的 RequestId 中间件和 PostProcess 对应：注入 request_id、user_id 日志字段并记录访问日志
+func requestInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	var requestId string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(strings.ToLower(log.HeaderRequestId)); len(v) > 0 {
+			requestId = v[0]
+		}
+	}
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
+	ctx = log.WithRequest(ctx, requestId, adaptor.ExtractTokenUserId(ctx))
+
+	resp, err := handler(ctx, req)
+	log.CtxInfow(ctx, "rpc access",
+		log.Field("method", info.FullMethod),
+		log.Field("req", adaptor.MaskLogContent(util.JSONF(req))),
+		log.Field("err", err),
+	)
+	return resp, err
+}
+
+func recoverInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.CtxError(ctx, "rpc panic, method: %s, err: %v, stack: %s", info.FullMethod, r, debug.Stack())
+			err = consts.ErrCall
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// limitInterceptor 与 HTTP 的 RateLimit、RouteRateLimit 中间件和 Validator 对应：限流后校验请求参数
+func limitInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if err := checkRateLimit(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	if err := validator.ValidateStruct(req); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// roleInterceptor 与 HTTP 的 RequireRole 中间件对应：校验角色后把当前用户放入 ctx，供 service.CurrentUser 取用
+func roleInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	roles, ok := methodRoles[info.FullMethod]
+	if !ok {
+		return handler(ctx, req)
+	}
+	u, err := provider.Get().Guard.RequireRole(ctx, roles...)
+	if err != nil {
+		return nil, err
+	}
+	return handler(service.WithCurrentUser(ctx, u), req)
+}
+
+// limitStreamInterceptor 流式方法建立时限流一次，之后收到的每条消息都校验参数
+func limitStreamInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := checkRateLimit(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, &validatedStream{ServerStream: ss})
+}
+
+type validatedStream struct {
+	grpc.ServerStream
+}
+
+func (s *validatedStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return validator.ValidateStruct(m)
+}
+
+// checkRateLimit 按连接 IP 和登录用户做全局限流，昂贵方法再按用户（未登录时按 IP）使用单独配额
+func checkRateLimit(ctx context.Context, method string) error {
+	cfg := config.GetConfig().RateLimit
+	key := "ip:" + peerIP(ctx)
+	if !adaptor.AllowRequest(ctx, key, cfg.IP) {
+		return consts.ErrTooManyRequests
+	}
+	if userId := adaptor.ExtractTokenUserId(ctx); userId != "" {
+		key = "user:" + userId
+		if !adaptor.AllowRequest(ctx, key, cfg.User) {
+			return consts.ErrTooManyRequests
+		}
+	}
+	if group, ok := methodRateLimits[method]; ok && !adaptor.AllowRequest(ctx, group+":"+key, adaptor.RouteBucket(group)) {
+		return consts.ErrTooManyRequests
+	}
+	return nil
+}
+
+// peerIP gRPC 连接的对端 IP，内部调用不经过 HTTP 代理，直接取连接地址
+func peerIP(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,121 @@
+package adaptor
+
+import (
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cloudwego/hertz/pkg/app/server/binding"
+)
+
+// fieldMaxLength 按字段名限制字符串的最大字数，对所有请求生效
+var fieldMaxLength = map[string]int{
+	"Text":        consts.TextMaxLength,
+	"Content":     consts.TextMaxLength,
+	"Title":       consts.TitleMaxLength,
+	"Description": consts.DescriptionMaxLength,
+	"Remark":      consts.DescriptionMaxLength,
+}
+
+// imageFields 图片 url 列表字段，数量不能超过 consts.ImageMaxCount
+var imageFields = map[string]bool{
+	"Images": true,
+	"Ocr":    true,
+}
+
+// requiredFields 按请求类型登记的必填字段，字符串不能为空白，列表不能为空
+var requiredFields = map[reflect.Type][]string{
+	reflect.TypeOf((*show.OCRReq)(nil)).Elem():                       {"Ocr"},
+	reflect.TypeOf((*show.CreateClassReq)(nil)).Elem():               {"Name"},
+	reflect.TypeOf((*show.CreateHomeworkReq)(nil)).Elem():            {"Title", "ClassIds"},
+	reflect.TypeOf((*show.SubmitHomeworkReq)(nil)).Elem():            {"HomeworkId", "MemberId", "Images"},
+	reflect.TypeOf((*show.SubmitHomeworkWithConfirmReq)(nil)).Elem(): {"HomeworkId", "MemberId"}, // images 与 text 至少一项，由 service 校验
+	reflect.TypeOf((*show.SubmitMbaAnswerReq)(nil)).Elem():           {"QuestionId"},
+	reflect.TypeOf((*show.ApplyCertificationReq)(nil)).Elem():        {"RealName", "Images"},
+	reflect.TypeOf((*show.CreateApiKeyReq)(nil)).Elem():              {"Name"},
+	reflect.TypeOf((*show.DisableApiKeyReq)(nil)).Elem():             {"Id"},
+	reflect.TypeOf((*show.RotateApiKeyReq)(nil)).Elem():              {"Id"},
+	reflect.TypeOf((*show.UpdateApiKeyQuotaReq)(nil)).Elem():         {"Id"},
+	reflect.TypeOf((*show.GetApiKeyUsageReq)(nil)).Elem():            {"Id"},
+	reflect.TypeOf((*show.UpdateApiKeyWhitelistReq)(nil)).Elem():     {"Id"},
+	reflect.TypeOf((*show.ListEvaluateRevisionsReq)(nil)).Elem():     {"TargetType", "TargetId"},
+
+	// 批改结果版本与回滚
+	reflect.TypeOf((*show.ListEvaluateVersionsReq)(nil)).Elem():           {"Id"},
+	reflect.TypeOf((*show.RollbackEvaluateReq)(nil)).Elem():               {"Id", "Version"},
+	reflect.TypeOf((*show.ListSubmissionEvaluateVersionsReq)(nil)).Elem(): {"SubmissionId"},
+	reflect.TypeOf((*show.RollbackSubmissionEvaluateReq)(nil)).Elem():     {"SubmissionId", "Version"},
+	reflect.TypeOf((*show.GetFeedbackExportReq)(nil)).Elem():              {"Id"},
+	reflect.TypeOf((*show.SetHomeworkNeatnessReq)(nil)).Elem():            {"HomeworkId"},
+	reflect.TypeOf((*show.SetHomeworkWordLimitReq)(nil)).Elem():           {"HomeworkId"},
+}
+
+// Validator 在 hertz 默认校验之后执行统一的参数规则：图片数、文本字数和按请求类型登记的必填字段，
+// 不通过时返回带字段名的 consts.InvalidParams
+type Validator struct {
+	binding.StructValidator
+}
+
+func NewValidator() *Validator {
+	return &Validator{StructValidator: binding.NewValidator(binding.NewValidateConfig())}
+}
+
+func (v *Validator) ValidateStruct(obj interface{}) error {
+	if err := v.StructValidator.ValidateStruct(obj); err != nil {
+		return err
+	}
+	rv := reflect.Indirect(reflect.ValueOf(obj))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	rt := rv.Type()
+	for _, name := range requiredFields[rt] {
+		sf, _ := rt.FieldByName(name)
+		if isBlank(rv.FieldByName(name)) {
+			return consts.InvalidParams(jsonName(sf), "不能为空")
+		}
+	}
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := reflect.Indirect(rv.Field(i))
+		switch {
+		case imageFields[sf.Name] && fv.Kind() == reflect.Slice:
+			if fv.Len() > consts.ImageMaxCount {
+				return consts.InvalidParams(jsonName(sf), fmt.Sprintf("最多 %d 张图片", consts.ImageMaxCount))
+			}
+		case fieldMaxLength[sf.Name] > 0 && fv.Kind() == reflect.String:
+			if limit := fieldMaxLength[sf.Name]; utf8.RuneCountInString(fv.String()) > limit {
+				return consts.InvalidParams(jsonName(sf), fmt.Sprintf("最多 %d 字", limit))
+			}
+		}
+	}
+	return nil
+}
+
+func isBlank(v reflect.Value) bool {
+	v = reflect.Indirect(v)
+	switch v.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(v.String()) == ""
+	case reflect.Slice:
+		return v.Len() == 0
+	case reflect.Invalid:
+		return true
+	default:
+		return v.IsZero()
+	}
+}
+
+// jsonName 错误信息中使用接口字段名
+func jsonName(sf reflect.StructField) string {
+	if name, _, _ := strings.Cut(sf.Tag.Get("json"), ","); name != "" {
+		return name
+	}
+	return sf.Name
+}
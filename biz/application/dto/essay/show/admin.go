@@ -0,0 +1,330 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// 管理后台接口的请求与响应，IDL 尚未覆盖，手动维护
+
+// AdminUser 管理后台展示的用户信息
+type AdminUser struct {
+	Id            string `form:"id" json:"id" query:"id"`
+	Username      string `form:"username" json:"username" query:"username"`
+	Phone         string `form:"phone" json:"phone" query:"phone"`
+	Role          string `form:"role" json:"role" query:"role"`
+	Status        int64  `form:"status" json:"status" query:"status"` // 0: 正常, 1: 已封禁
+	Count         int64  `form:"count" json:"count" query:"count"`
+	School        string `form:"school" json:"school" query:"school"`
+	Grade         int64  `form:"grade" json:"grade" query:"grade"`
+	VipExpireTime int64  `form:"vipExpireTime" json:"vipExpireTime" query:"vipExpireTime"`
+	CreateTime    int64  `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type SearchUsersReq struct {
+	Keyword           string                   `form:"keyword" json:"keyword" query:"keyword"` // 手机号或用户名
+	Role              string                   `form:"role" json:"role" query:"role"`
+	Status            *int64                   `form:"status" json:"status,omitempty" query:"status"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type SearchUsersResp struct {
+	Code  int64        `form:"code" json:"code" query:"code"`
+	Msg   string       `form:"msg" json:"msg" query:"msg"`
+	Users []*AdminUser `form:"users" json:"users" query:"users"`
+	Total int64        `form:"total" json:"total" query:"total"`
+}
+
+type UpdateUserStatusReq struct {
+	UserId string `form:"userId" json:"userId" query:"userId"`
+	Banned bool   `form:"banned" json:"banned" query:"banned"` // true: 封禁, false: 解封
+	Reason string `form:"reason" json:"reason" query:"reason"`
+}
+
+type AdjustUserCountReq struct {
+	UserId string `form:"userId" json:"userId" query:"userId"`
+	Delta  int64  `form:"delta" json:"delta" query:"delta"` // 正数增加，负数扣减
+	Reason string `form:"reason" json:"reason" query:"reason"`
+}
+
+type AdjustUserCountResp struct {
+	Code  int64  `form:"code" json:"code" query:"code"`
+	Msg   string `form:"msg" json:"msg" query:"msg"`
+	Count int64  `form:"count" json:"count" query:"count"` // 调整后的剩余次数
+}
+
+type AdjustUserOcrCountReq struct {
+	UserId string `form:"userId" json:"userId" query:"userId"`
+	Delta  int64  `form:"delta" json:"delta" query:"delta"` // 正数增加，负数扣减
+	Reason string `form:"reason" json:"reason" query:"reason"`
+}
+
+type AdjustUserOcrCountResp struct {
+	Code     int64  `form:"code" json:"code" query:"code"`
+	Msg      string `form:"msg" json:"msg" query:"msg"`
+	OcrCount int64  `form:"ocrCount" json:"ocrCount" query:"ocrCount"` // 调整后的剩余 OCR 次数
+}
+
+type GetEvaluateStatisticsReq struct {
+	StartTime *int64 `form:"startTime" json:"startTime,omitempty" query:"startTime"` // 秒级时间戳，默认当天 0 点
+	EndTime   *int64 `form:"endTime" json:"endTime,omitempty" query:"endTime"`       // 秒级时间戳，默认当前时间
+}
+
+type GetEvaluateStatisticsResp struct {
+	Code               int64  `form:"code" json:"code" query:"code"`
+	Msg                string `form:"msg" json:"msg" query:"msg"`
+	EssayEvaluateCount int64  `form:"essayEvaluateCount" json:"essayEvaluateCount" query:"essayEvaluateCount"` // 个人作文批改次数
+	EssayUserCount     int64  `form:"essayUserCount" json:"essayUserCount" query:"essayUserCount"`             // 发起个人批改的用户数
+	HomeworkGraded     int64  `form:"homeworkGraded" json:"homeworkGraded" query:"homeworkGraded"`             // 作业批改完成数
+	HomeworkFailed     int64  `form:"homeworkFailed" json:"homeworkFailed" query:"homeworkFailed"`             // 作业批改失败数
+	StartTime          int64  `form:"startTime" json:"startTime" query:"startTime"`
+	EndTime            int64  `form:"endTime" json:"endTime" query:"endTime"`
+}
+
+// AuditLog 审计日志
+type AuditLog struct {
+	Id         string `form:"id" json:"id" query:"id"`
+	OperatorId string `form:"operatorId" json:"operatorId" query:"operatorId"`
+	Action     string `form:"action" json:"action" query:"action"`
+	TargetId   string `form:"targetId" json:"targetId" query:"targetId"`
+	Ip         string `form:"ip" json:"ip" query:"ip"`
+	UserAgent  string `form:"userAgent" json:"userAgent" query:"userAgent"`
+	Summary    string `form:"summary" json:"summary" query:"summary"`
+	CreateTime int64  `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListAuditLogsReq struct {
+	OperatorId        string                   `form:"operatorId" json:"operatorId" query:"operatorId"`
+	Action            string                   `form:"action" json:"action" query:"action"`
+	StartTime         *int64                   `form:"startTime" json:"startTime,omitempty" query:"startTime"` // 秒级时间戳
+	EndTime           *int64                   `form:"endTime" json:"endTime,omitempty" query:"endTime"`       // 秒级时间戳
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListAuditLogsResp struct {
+	Code  int64       `form:"code" json:"code" query:"code"`
+	Msg   string      `form:"msg" json:"msg" query:"msg"`
+	Logs  []*AuditLog `form:"logs" json:"logs" query:"logs"`
+	Total int64       `form:"total" json:"total" query:"total"`
+}
+
+// EvaluateRevision 一次批改结果修改
+type EvaluateRevision struct {
+	Id         string         `form:"id" json:"id" query:"id"`
+	TargetType string         `form:"targetType" json:"targetType" query:"targetType"`
+	TargetId   string         `form:"targetId" json:"targetId" query:"targetId"`
+	OperatorId string         `form:"operatorId" json:"operatorId" query:"operatorId"`
+	Changes    []*FieldChange `form:"changes" json:"changes" query:"changes"`
+	CreateTime int64          `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+// FieldChange 批改结果中一个字段的修改，field 为 JSON 路径
+type FieldChange struct {
+	Field  string `form:"field" json:"field" query:"field"`
+	Before string `form:"before" json:"before" query:"before"`
+	After  string `form:"after" json:"after" query:"after"`
+}
+
+type ListEvaluateRevisionsReq struct {
+	TargetType        string                   `form:"targetType" json:"targetType" query:"targetType"` // submission: 作业提交, log: 个人作文批改记录
+	TargetId          string                   `form:"targetId" json:"targetId" query:"targetId"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListEvaluateRevisionsResp struct {
+	Code      int64               `form:"code" json:"code" query:"code"`
+	Msg       string              `form:"msg" json:"msg" query:"msg"`
+	Revisions []*EvaluateRevision `form:"revisions" json:"revisions" query:"revisions"`
+	Total     int64               `form:"total" json:"total" query:"total"`
+}
+
+// ModerationRecord 一次未通过的作文内容安全审核
+type ModerationRecord struct {
+	Id         string   `form:"id" json:"id" query:"id"`
+	Scene      string   `form:"scene" json:"scene" query:"scene"` // essay: 个人批改, homework: 作业批改
+	TargetId   string   `form:"targetId" json:"targetId" query:"targetId"`
+	UserId     string   `form:"userId" json:"userId" query:"userId"`
+	Source     string   `form:"source" json:"source" query:"source"` // local: 本地词库, remote: 中台接口
+	Hits       []string `form:"hits" json:"hits" query:"hits"`
+	Excerpt    string   `form:"excerpt" json:"excerpt" query:"excerpt"`
+	CreateTime int64    `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListModerationRecordsReq struct {
+	Scene             string                   `form:"scene" json:"scene" query:"scene"`
+	UserId            string                   `form:"userId" json:"userId" query:"userId"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListModerationRecordsResp struct {
+	Code    int64               `form:"code" json:"code" query:"code"`
+	Msg     string              `form:"msg" json:"msg" query:"msg"`
+	Records []*ModerationRecord `form:"records" json:"records" query:"records"`
+	Total   int64               `form:"total" json:"total" query:"total"`
+}
+
+// CreateFeedbackExportReq 导出 [startTime, endTime) 内教师修改过的批改结果，时间为秒级时间戳
+type CreateFeedbackExportReq struct {
+	StartTime int64 `form:"startTime" json:"startTime" query:"startTime"`
+	EndTime   int64 `form:"endTime" json:"endTime" query:"endTime"`
+}
+
+// CreateFeedbackExportResp 导出在后台进行，id 用于查询导出结果
+type CreateFeedbackExportResp struct {
+	Code int64  `form:"code" json:"code" query:"code"`
+	Msg  string `form:"msg" json:"msg" query:"msg"`
+	Id   string `form:"id" json:"id" query:"id"`
+}
+
+type GetFeedbackExportReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+// GetFeedbackExportResp status 0: 生成中, 1: 已完成, 2: 生成失败；url 为 jsonl 文件，每行一条样本
+type GetFeedbackExportResp struct {
+	Code         int64  `form:"code" json:"code" query:"code"`
+	Msg          string `form:"msg" json:"msg" query:"msg"`
+	Status       int64  `form:"status" json:"status" query:"status"`
+	Count        int64  `form:"count" json:"count" query:"count"`
+	Url          string `form:"url" json:"url,omitempty" query:"url"`
+	SessionToken string `form:"sessionToken" json:"sessionToken,omitempty" query:"sessionToken"`
+	Message      string `form:"message" json:"message,omitempty" query:"message"`
+}
+
+// RiskRecord 风控记录
+type RiskRecord struct {
+	Id         string `form:"id" json:"id" query:"id"`
+	UserId     string `form:"userId" json:"userId" query:"userId"`
+	Type       string `form:"type" json:"type" query:"type"`
+	DeviceId   string `form:"deviceId" json:"deviceId" query:"deviceId"`
+	Ip         string `form:"ip" json:"ip" query:"ip"`
+	Detail     string `form:"detail" json:"detail" query:"detail"`
+	Status     int64  `form:"status" json:"status" query:"status"` // 0: 待审核, 1: 确认正常, 2: 确认作弊
+	Remark     string `form:"remark" json:"remark" query:"remark"`
+	CreateTime int64  `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListRiskRecordsReq struct {
+	Type              string                   `form:"type" json:"type" query:"type"`
+	Status            *int64                   `form:"status" json:"status,omitempty" query:"status"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListRiskRecordsResp struct {
+	Code    int64         `form:"code" json:"code" query:"code"`
+	Msg     string        `form:"msg" json:"msg" query:"msg"`
+	Records []*RiskRecord `form:"records" json:"records" query:"records"`
+	Total   int64         `form:"total" json:"total" query:"total"`
+}
+
+type ReviewRiskRecordReq struct {
+	Id      string `form:"id" json:"id" query:"id"`
+	Status  int64  `form:"status" json:"status" query:"status"`    // 1: 确认正常, 2: 确认作弊
+	BanUser bool   `form:"banUser" json:"banUser" query:"banUser"` // 确认作弊时是否封禁该用户
+	Remark  string `form:"remark" json:"remark" query:"remark"`
+}
+
+type GetRewardSettingReq struct{}
+
+type GetRewardSettingResp struct {
+	Code               int64  `form:"code" json:"code" query:"code"`
+	Msg                string `form:"msg" json:"msg" query:"msg"`
+	DefaultCount       int64  `form:"defaultCount" json:"defaultCount" query:"defaultCount"`                   // 新用户初始批改次数
+	AttendReward       int64  `form:"attendReward" json:"attendReward" query:"attendReward"`                   // 每日签到奖励
+	AttendStreakReward int64  `form:"attendStreakReward" json:"attendStreakReward" query:"attendStreakReward"` // 连续签到满周期额外奖励
+	InviterReward      int64  `form:"inviterReward" json:"inviterReward" query:"inviterReward"`                // 邀请人奖励
+	InviteeReward      int64  `form:"inviteeReward" json:"inviteeReward" query:"inviteeReward"`                // 被邀请人奖励
+	OcrDailyFree       int64  `form:"ocrDailyFree" json:"ocrDailyFree" query:"ocrDailyFree"`                   // 每天免费 OCR 次数
+}
+
+// UpdateRewardSettingReq 为空的字段保持不变
+type UpdateRewardSettingReq struct {
+	DefaultCount       *int64 `form:"defaultCount" json:"defaultCount,omitempty" query:"defaultCount"`
+	AttendReward       *int64 `form:"attendReward" json:"attendReward,omitempty" query:"attendReward"`
+	AttendStreakReward *int64 `form:"attendStreakReward" json:"attendStreakReward,omitempty" query:"attendStreakReward"`
+	InviterReward      *int64 `form:"inviterReward" json:"inviterReward,omitempty" query:"inviterReward"`
+	InviteeReward      *int64 `form:"inviteeReward" json:"inviteeReward,omitempty" query:"inviteeReward"`
+	OcrDailyFree       *int64 `form:"ocrDailyFree" json:"ocrDailyFree,omitempty" query:"ocrDailyFree"`
+}
+
+type RefreshQuestionBankCacheReq struct{}
+
+// ApiKey 外部 API 调用方的密钥，不含明文
+type ApiKey struct {
+	Id           string   `form:"id" json:"id" query:"id"`
+	Name         string   `form:"name" json:"name" query:"name"`
+	Prefix       string   `form:"prefix" json:"prefix" query:"prefix"` // 明文密钥的前几位
+	Status       int64    `form:"status" json:"status" query:"status"` // 0: 启用, 1: 已禁用
+	CreatorId    string   `form:"creatorId" json:"creatorId" query:"creatorId"`
+	DailyQuota   int64    `form:"dailyQuota" json:"dailyQuota" query:"dailyQuota"`       // 每日调用次数上限，0 表示不限
+	MonthlyQuota int64    `form:"monthlyQuota" json:"monthlyQuota" query:"monthlyQuota"` // 每月调用次数上限，0 表示不限
+	IpWhitelist  []string `form:"ipWhitelist" json:"ipWhitelist" query:"ipWhitelist"`
+	RotateTime   int64    `form:"rotateTime" json:"rotateTime" query:"rotateTime"`
+	CreateTime   int64    `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type CreateApiKeyReq struct {
+	Name         string   `form:"name" json:"name" query:"name"`                         // 调用方名称
+	DailyQuota   int64    `form:"dailyQuota" json:"dailyQuota" query:"dailyQuota"`       // 每日调用次数上限，0 表示不限
+	MonthlyQuota int64    `form:"monthlyQuota" json:"monthlyQuota" query:"monthlyQuota"` // 每月调用次数上限，0 表示不限
+	IpWhitelist  []string `form:"ipWhitelist" json:"ipWhitelist" query:"ipWhitelist"`    // 允许的来源 IP 或 CIDR，为空时不限制
+}
+
+// ApiKeySecretResp 创建和轮换密钥的响应，明文密钥和签名密钥只返回这一次
+type ApiKeySecretResp struct {
+	Code   int64  `form:"code" json:"code" query:"code"`
+	Msg    string `form:"msg" json:"msg" query:"msg"`
+	Id     string `form:"id" json:"id" query:"id"`
+	Key    string `form:"key" json:"key" query:"key"`
+	Secret string `form:"secret" json:"secret" query:"secret"` // 请求签名的 HMAC 密钥
+}
+
+type ListApiKeysReq struct {
+	Status            *int64                   `form:"status" json:"status,omitempty" query:"status"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListApiKeysResp struct {
+	Code  int64     `form:"code" json:"code" query:"code"`
+	Msg   string    `form:"msg" json:"msg" query:"msg"`
+	Keys  []*ApiKey `form:"keys" json:"keys" query:"keys"`
+	Total int64     `form:"total" json:"total" query:"total"`
+}
+
+type DisableApiKeyReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+type RotateApiKeyReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+type UpdateApiKeyQuotaReq struct {
+	Id           string `form:"id" json:"id" query:"id"`
+	DailyQuota   int64  `form:"dailyQuota" json:"dailyQuota" query:"dailyQuota"`       // 0 表示不限
+	MonthlyQuota int64  `form:"monthlyQuota" json:"monthlyQuota" query:"monthlyQuota"` // 0 表示不限
+}
+
+// ApiUsage 一个密钥一天的用量
+type ApiUsage struct {
+	Date          string `form:"date" json:"date" query:"date"`
+	Calls         int64  `form:"calls" json:"calls" query:"calls"`
+	EvaluateCount int64  `form:"evaluateCount" json:"evaluateCount" query:"evaluateCount"`
+	OcrCount      int64  `form:"ocrCount" json:"ocrCount" query:"ocrCount"`
+	Tokens        int64  `form:"tokens" json:"tokens" query:"tokens"` // 按字数估算
+}
+
+type GetApiKeyUsageReq struct {
+	Id        string `form:"id" json:"id" query:"id"`
+	StartDate string `form:"startDate" json:"startDate" query:"startDate"` // 2006-01-02，默认本月 1 号
+	EndDate   string `form:"endDate" json:"endDate" query:"endDate"`       // 2006-01-02，默认今天
+}
+
+type GetApiKeyUsageResp struct {
+	Code   int64       `form:"code" json:"code" query:"code"`
+	Msg    string      `form:"msg" json:"msg" query:"msg"`
+	Usages []*ApiUsage `form:"usages" json:"usages" query:"usages"` // 按日期升序，没有调用的日期不返回
+	Total  *ApiUsage   `form:"total" json:"total" query:"total"`    // 时间范围内的合计，Date 为空
+}
+
+type UpdateApiKeyWhitelistReq struct {
+	Id          string   `form:"id" json:"id" query:"id"`
+	IpWhitelist []string `form:"ipWhitelist" json:"ipWhitelist" query:"ipWhitelist"` // IP 或 CIDR，如 1.2.3.4、10.0.0.0/8，为空时不限制
+}
@@ -0,0 +1,43 @@
+package show
+
+import "encoding/json"
+
+// 对外 API（/api/v1、/api/v2）的请求与响应，IDL 尚未覆盖，手动维护
+
+type GetApiUsageReq struct {
+	StartDate   string `form:"startDate" json:"startDate" query:"startDate"`       // 2006-01-02，默认本月 1 号
+	EndDate     string `form:"endDate" json:"endDate" query:"endDate"`             // 2006-01-02，默认今天
+	Granularity string `form:"granularity" json:"granularity" query:"granularity"` // day: 按天聚合（默认）, month: 按月聚合
+}
+
+type GetApiUsageResp struct {
+	Code             int64       `form:"code" json:"code" query:"code"`
+	Msg              string      `form:"msg" json:"msg" query:"msg"`
+	Usages           []*ApiUsage `form:"usages" json:"usages" query:"usages"`                               // 按时间升序，Date 为日期或月份，没有调用的时段不返回
+	Total            *ApiUsage   `form:"total" json:"total" query:"total"`                                  // 时间范围内的合计，Date 为空
+	DailyQuota       int64       `form:"dailyQuota" json:"dailyQuota" query:"dailyQuota"`                   // 0 表示不限
+	MonthlyQuota     int64       `form:"monthlyQuota" json:"monthlyQuota" query:"monthlyQuota"`             // 0 表示不限
+	DailyRemaining   int64       `form:"dailyRemaining" json:"dailyRemaining" query:"dailyRemaining"`       // 今日剩余次数，不限时为 -1
+	MonthlyRemaining int64       `form:"monthlyRemaining" json:"monthlyRemaining" query:"monthlyRemaining"` // 本月剩余次数，不限时为 -1
+}
+
+// APIEssayEvaluateV2Resp 同步批改的结果，外层的 code、message 由 v2 的统一响应结构提供
+type APIEssayEvaluateV2Resp struct {
+	Result json.RawMessage `form:"result" json:"result" query:"result"` // 批改结果对象，与 v1 complete 事件中 response 字符串解析后的结构一致
+}
+
+// GetApiErrorsResp 错误码目录，包含服务端定义的全部业务错误
+type GetApiErrorsResp struct {
+	Errors []*ApiErrorInfo `form:"errors" json:"errors" query:"errors"`
+}
+
+// ApiErrorInfo 一个业务错误，同一 code 可能对应多条文案不同的错误
+type ApiErrorInfo struct {
+	Code         uint32 `form:"code" json:"code" query:"code"`                         // v1 错误响应中的 code
+	HttpStatus   int    `form:"httpStatus" json:"httpStatus" query:"httpStatus"`       // v1 错误响应的 HTTP 状态码
+	V2Code       string `form:"v2Code" json:"v2Code" query:"v2Code"`                   // v2 错误响应中的 code
+	V2HttpStatus int    `form:"v2HttpStatus" json:"v2HttpStatus" query:"v2HttpStatus"` // v2 错误响应的 HTTP 状态码
+	Message      string `form:"message" json:"message" query:"message"`                // 英文文案
+	MessageZh    string `form:"messageZh" json:"messageZh" query:"messageZh"`          // 中文文案
+	Advice       string `form:"advice" json:"advice" query:"advice"`                   // 建议处理方式
+}
@@ -0,0 +1,50 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// 教师资质认证接口的请求与响应，IDL 尚未覆盖，手动维护
+
+type Certification struct {
+	Id         string   `form:"id" json:"id" query:"id"`
+	UserId     string   `form:"userId" json:"userId" query:"userId"`
+	RealName   string   `form:"realName" json:"realName" query:"realName"`
+	School     string   `form:"school" json:"school" query:"school"`
+	Images     []string `form:"images" json:"images" query:"images"`
+	Status     int64    `form:"status" json:"status" query:"status"` // 0: 待审核, 1: 已通过, 2: 已驳回
+	Reason     string   `form:"reason" json:"reason" query:"reason"` // 驳回原因
+	CreateTime int64    `form:"createTime" json:"createTime" query:"createTime"`
+	ReviewTime int64    `form:"reviewTime" json:"reviewTime" query:"reviewTime"`
+}
+
+type ApplyCertificationReq struct {
+	RealName string   `form:"realName" json:"realName" query:"realName"`
+	School   string   `form:"school" json:"school" query:"school"`
+	Images   []string `form:"images" json:"images" query:"images"` // 先通过 /sts/apply 上传后得到的图片地址
+}
+
+type GetCertificationReq struct{}
+
+type GetCertificationResp struct {
+	Code          int64          `form:"code" json:"code" query:"code"`
+	Msg           string         `form:"msg" json:"msg" query:"msg"`
+	Status        int64          `form:"status" json:"status" query:"status"` // -1: 未申请
+	Certification *Certification `form:"certification" json:"certification,omitempty" query:"certification"`
+}
+
+type ListCertificationsReq struct {
+	Status            *int64                   `form:"status" json:"status,omitempty" query:"status"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListCertificationsResp struct {
+	Code           int64            `form:"code" json:"code" query:"code"`
+	Msg            string           `form:"msg" json:"msg" query:"msg"`
+	Certifications []*Certification `form:"certifications" json:"certifications" query:"certifications"`
+	Total          int64            `form:"total" json:"total" query:"total"`
+}
+
+type ReviewCertificationReq struct {
+	Id       string `form:"id" json:"id" query:"id"`
+	Approved bool   `form:"approved" json:"approved" query:"approved"`
+	Reason   string `form:"reason" json:"reason" query:"reason"`
+}
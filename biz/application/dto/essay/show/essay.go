@@ -0,0 +1,41 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// 批改相关接口的请求与响应，IDL 尚未覆盖，手动维护
+
+// DownloadEvaluateWithFormatReq 在 IDL 的 DownloadEvaluateReq 基础上增加导出格式
+type DownloadEvaluateWithFormatReq struct {
+	Id             string                  `form:"id" json:"id" query:"id"`
+	ExcludeOptions *EvaluateExcludeOptions `form:"excludeOptions" json:"excludeOptions" query:"excludeOptions"`
+	Format         string                  `form:"format" json:"format,omitempty" query:"format"` // pdf / docx，不传为 pdf
+}
+
+// EvaluateVersion 批改结果的一个版本，version 为 1 时是首次修改前的结果，rollbackFrom 为回滚时恢复的版本号
+type EvaluateVersion struct {
+	Version      int64  `form:"version" json:"version" query:"version"`
+	Source       string `form:"source" json:"source" query:"source"` // original: 原始结果, modify: 人工修改, rollback: 回滚
+	OperatorId   string `form:"operatorId" json:"operatorId" query:"operatorId"`
+	RollbackFrom int64  `form:"rollbackFrom" json:"rollbackFrom,omitempty" query:"rollbackFrom"`
+	Response     string `form:"response" json:"response" query:"response"`
+	CreateTime   int64  `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListEvaluateVersionsReq struct {
+	Id                string                   `form:"id" json:"id" query:"id"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+// ListEvaluateVersionsResp versions 按版本号倒序，还没有修改过的批改结果没有版本
+type ListEvaluateVersionsResp struct {
+	Code     int64              `form:"code" json:"code" query:"code"`
+	Msg      string             `form:"msg" json:"msg" query:"msg"`
+	Versions []*EvaluateVersion `form:"versions" json:"versions" query:"versions"`
+	Total    int64              `form:"total" json:"total" query:"total"`
+}
+
+// RollbackEvaluateReq 把批改结果恢复到 version 版本，恢复后的结果作为新版本保存
+type RollbackEvaluateReq struct {
+	Id      string `form:"id" json:"id" query:"id"`
+	Version int64  `form:"version" json:"version" query:"version"`
+}
@@ -0,0 +1,222 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// 练习相关接口的请求与响应，IDL 尚未覆盖，手动维护
+
+// GenerateExerciseReq 在 IDL 的 CreateExerciseReq 基础上增加难度
+type GenerateExerciseReq struct {
+	LogId      string `form:"logId" json:"logId" query:"logId"`
+	Difficulty string `form:"difficulty" json:"difficulty" query:"difficulty"` // basic 基础 / improve 提高 / advanced 拔高，不传由下游决定
+}
+
+// ExerciseSummary 练习列表中的一项
+type ExerciseSummary struct {
+	Id            string `form:"id" json:"id" query:"id"`
+	LogId         string `form:"logId" json:"logId" query:"logId"`                         // 来源批改记录
+	AssignmentId  string `form:"assignmentId" json:"assignmentId" query:"assignmentId"`    // 教师布置记录，自己生成的练习为空
+	Difficulty    string `form:"difficulty" json:"difficulty" query:"difficulty"`          // 生成时指定的难度，未指定为空
+	QuestionCount int64  `form:"questionCount" json:"questionCount" query:"questionCount"` // 题目数
+	Finished      bool   `form:"finished" json:"finished" query:"finished"`                // 是否作答过
+	Score         int64  `form:"score" json:"score" query:"score"`                         // 最近一次得分，未作答为 -1
+	FullScore     int64  `form:"fullScore" json:"fullScore" query:"fullScore"`             // 满分
+	AttemptCount  int64  `form:"attemptCount" json:"attemptCount" query:"attemptCount"`    // 作答次数
+	FinishTime    int64  `form:"finishTime" json:"finishTime" query:"finishTime"`          // 最近一次作答时间
+	Like          int64  `form:"like" json:"like" query:"like"`
+	CreateTime    int64  `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListExercisesReq struct {
+	LogId             *string                  `form:"logId" json:"logId,omitempty" query:"logId"`                // 按来源批改记录过滤
+	Difficulty        *string                  `form:"difficulty" json:"difficulty,omitempty" query:"difficulty"` // 按难度过滤
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListExercisesResp struct {
+	Code      int64              `form:"code" json:"code" query:"code"`
+	Msg       string             `form:"msg" json:"msg" query:"msg"`
+	Exercises []*ExerciseSummary `form:"exercises" json:"exercises" query:"exercises"`
+	Total     int64              `form:"total" json:"total" query:"total"`
+}
+
+// FillQuestion 填空题
+type FillQuestion struct {
+	Id          string   `form:"id" json:"id" query:"id"`
+	Question    string   `form:"question" json:"question" query:"question"`
+	Explanation string   `form:"explanation" json:"explanation" query:"explanation"`
+	Answers     []string `form:"answers" json:"answers" query:"answers"` // 可接受的答案
+	Score       int64    `form:"score" json:"score" query:"score"`
+}
+
+// ShortQuestion 简答题
+type ShortQuestion struct {
+	Id          string `form:"id" json:"id" query:"id"`
+	Question    string `form:"question" json:"question" query:"question"`
+	Explanation string `form:"explanation" json:"explanation" query:"explanation"`
+	Reference   string `form:"reference" json:"reference" query:"reference"` // 参考答案
+	Score       int64  `form:"score" json:"score" query:"score"`
+}
+
+// GetExerciseDetailResp 在 IDL 的 GetExerciseResp 基础上返回填空题与简答题
+type GetExerciseDetailResp struct {
+	Code           int64            `form:"code" json:"code" query:"code"`
+	Msg            string           `form:"msg" json:"msg" query:"msg"`
+	Exercise       *Exercise        `form:"exercise" json:"exercise" query:"exercise"`
+	FillQuestions  []*FillQuestion  `form:"fillQuestions" json:"fillQuestions" query:"fillQuestions"`
+	ShortQuestions []*ShortQuestion `form:"shortQuestions" json:"shortQuestions" query:"shortQuestions"`
+}
+
+// AttemptAnswer 一道题的作答，选择题填 option，填空题与简答题填 answer
+type AttemptAnswer struct {
+	Id     string `form:"id" json:"id" query:"id"`
+	Option string `form:"option" json:"option" query:"option"`
+	Answer string `form:"answer" json:"answer" query:"answer"`
+}
+
+// DoExerciseAttemptReq 在 IDL 的 DoExerciseReq 基础上增加作答用时
+type DoExerciseAttemptReq struct {
+	Id       string           `form:"id" json:"id" query:"id"`
+	Records  []*AttemptAnswer `form:"records" json:"records" query:"records"`
+	Duration int64            `form:"duration" json:"duration" query:"duration"` // 作答用时，单位秒
+}
+
+// AttemptRecord 一道题的作答结果
+type AttemptRecord struct {
+	Id      string `form:"id" json:"id" query:"id"`
+	Option  string `form:"option" json:"option" query:"option"`
+	Answer  string `form:"answer" json:"answer" query:"answer"`
+	Comment string `form:"comment" json:"comment" query:"comment"` // 简答题的判分评语
+	Score   int64  `form:"score" json:"score" query:"score"`
+}
+
+// ExerciseAttempt 一次作答的成绩
+type ExerciseAttempt struct {
+	Attempt       int64            `form:"attempt" json:"attempt" query:"attempt"` // 第几次作答，从 1 开始
+	Records       []*AttemptRecord `form:"records" json:"records" query:"records"`
+	Score         int64            `form:"score" json:"score" query:"score"`
+	FullScore     int64            `form:"fullScore" json:"fullScore" query:"fullScore"`
+	CorrectCount  int64            `form:"correctCount" json:"correctCount" query:"correctCount"` // 得满分的题数
+	QuestionCount int64            `form:"questionCount" json:"questionCount" query:"questionCount"`
+	Accuracy      float64          `form:"accuracy" json:"accuracy" query:"accuracy"` // 正确率，0-100
+	Duration      int64            `form:"duration" json:"duration" query:"duration"`
+	CreateTime    int64            `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type DoExerciseAttemptResp struct {
+	Code    int64            `form:"code" json:"code" query:"code"`
+	Msg     string           `form:"msg" json:"msg" query:"msg"`
+	Records *Records         `form:"records" json:"records" query:"records"`
+	Attempt *ExerciseAttempt `form:"attempt" json:"attempt" query:"attempt"`
+}
+
+type GetExerciseHistoryReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+type GetExerciseHistoryResp struct {
+	Code          int64              `form:"code" json:"code" query:"code"`
+	Msg           string             `form:"msg" json:"msg" query:"msg"`
+	Attempts      []*ExerciseAttempt `form:"attempts" json:"attempts" query:"attempts"` // 按作答顺序排列
+	BestScore     int64              `form:"bestScore" json:"bestScore" query:"bestScore"`
+	AccuracyTrend []float64          `form:"accuracyTrend" json:"accuracyTrend" query:"accuracyTrend"` // 每次作答的正确率
+}
+
+type AssignExerciseToClassReq struct {
+	ExerciseId string `form:"exerciseId" json:"exerciseId" query:"exerciseId"`
+	ClassId    string `form:"classId" json:"classId" query:"classId"`
+}
+
+type AssignExerciseToClassResp struct {
+	Code         int64  `form:"code" json:"code" query:"code"`
+	Msg          string `form:"msg" json:"msg" query:"msg"`
+	AssignmentId string `form:"assignmentId" json:"assignmentId" query:"assignmentId"`
+	StudentCount int64  `form:"studentCount" json:"studentCount" query:"studentCount"` // 生成练习的学生数
+	SkippedCount int64  `form:"skippedCount" json:"skippedCount" query:"skippedCount"` // 未绑定账号而跳过的学生数
+}
+
+type ListPendingExercisesReq struct {
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type GetAssignmentProgressReq struct {
+	AssignmentId string `form:"assignmentId" json:"assignmentId" query:"assignmentId"`
+}
+
+// AssignmentStudent 布置练习中单个学生的完成情况
+type AssignmentStudent struct {
+	UserId     string `form:"userId" json:"userId" query:"userId"`
+	Name       string `form:"name" json:"name" query:"name"`
+	ExerciseId string `form:"exerciseId" json:"exerciseId" query:"exerciseId"`
+	Finished   bool   `form:"finished" json:"finished" query:"finished"`
+	Score      int64  `form:"score" json:"score" query:"score"` // 最近一次得分，未作答为 -1
+	FinishTime int64  `form:"finishTime" json:"finishTime" query:"finishTime"`
+}
+
+type GetAssignmentProgressResp struct {
+	Code           int64                `form:"code" json:"code" query:"code"`
+	Msg            string               `form:"msg" json:"msg" query:"msg"`
+	StudentCount   int64                `form:"studentCount" json:"studentCount" query:"studentCount"`
+	FinishedCount  int64                `form:"finishedCount" json:"finishedCount" query:"finishedCount"`
+	CompletionRate float64              `form:"completionRate" json:"completionRate" query:"completionRate"` // 完成率，0-100
+	Students       []*AssignmentStudent `form:"students" json:"students" query:"students"`
+}
+
+// CreateWeaknessExerciseReq 不传 userId 时分析自己的作文，教师可以为自己班级的学生生成
+type CreateWeaknessExerciseReq struct {
+	UserId     *string `form:"userId" json:"userId,omitempty" query:"userId"`
+	EssayCount *int64  `form:"essayCount" json:"essayCount,omitempty" query:"essayCount"` // 分析最近多少篇作文，默认 5，最多 20
+	Difficulty string  `form:"difficulty" json:"difficulty" query:"difficulty"`
+}
+
+// WrongWord 高频错词
+type WrongWord struct {
+	Ori     string `form:"ori" json:"ori" query:"ori"`
+	Revised string `form:"revised" json:"revised" query:"revised"`
+	Count   int64  `form:"count" json:"count" query:"count"`
+}
+
+// Weakness 近几篇作文的共性问题
+type Weakness struct {
+	EssayCount        int64            `form:"essayCount" json:"essayCount" query:"essayCount"`
+	GrammarMistakeNum int64            `form:"grammarMistakeNum" json:"grammarMistakeNum" query:"grammarMistakeNum"`
+	WrittenMistakeNum int64            `form:"writtenMistakeNum" json:"writtenMistakeNum" query:"writtenMistakeNum"`
+	MistakeTypes      map[string]int64 `form:"mistakeTypes" json:"mistakeTypes" query:"mistakeTypes"`
+	WrongWords        []*WrongWord     `form:"wrongWords" json:"wrongWords" query:"wrongWords"`
+}
+
+type CreateWeaknessExerciseResp struct {
+	Code     int64     `form:"code" json:"code" query:"code"`
+	Msg      string    `form:"msg" json:"msg" query:"msg"`
+	Exercise *Exercise `form:"exercise" json:"exercise" query:"exercise"`
+	Weakness *Weakness `form:"weakness" json:"weakness" query:"weakness"`
+}
+
+type GetExerciseStatsReq struct{}
+
+// ExerciseStatItem 一个题型或知识点的作答统计
+type ExerciseStatItem struct {
+	Name         string  `form:"name" json:"name" query:"name"` // 题型为 choice/fill/short，知识点为名称
+	AnswerCount  int64   `form:"answerCount" json:"answerCount" query:"answerCount"`
+	CorrectCount int64   `form:"correctCount" json:"correctCount" query:"correctCount"`
+	Accuracy     float64 `form:"accuracy" json:"accuracy" query:"accuracy"` // 正确率，0-100
+}
+
+// ExerciseDailyStat 一天内的作答统计
+type ExerciseDailyStat struct {
+	Date         int64   `form:"date" json:"date" query:"date"` // 当天 0 点的秒级时间戳
+	AttemptCount int64   `form:"attemptCount" json:"attemptCount" query:"attemptCount"`
+	AnswerCount  int64   `form:"answerCount" json:"answerCount" query:"answerCount"`
+	Accuracy     float64 `form:"accuracy" json:"accuracy" query:"accuracy"`
+}
+
+type GetExerciseStatsResp struct {
+	Code          int64                `form:"code" json:"code" query:"code"`
+	Msg           string               `form:"msg" json:"msg" query:"msg"`
+	ExerciseCount int64                `form:"exerciseCount" json:"exerciseCount" query:"exerciseCount"` // 作答过的练习套数
+	AttemptCount  int64                `form:"attemptCount" json:"attemptCount" query:"attemptCount"`    // 累计作答次数
+	AnswerCount   int64                `form:"answerCount" json:"answerCount" query:"answerCount"`       // 累计作答题数
+	Accuracy      float64              `form:"accuracy" json:"accuracy" query:"accuracy"`
+	QuestionTypes []*ExerciseStatItem  `form:"questionTypes" json:"questionTypes" query:"questionTypes"`
+	Knowledges    []*ExerciseStatItem  `form:"knowledges" json:"knowledges" query:"knowledges"`
+	Trend         []*ExerciseDailyStat `form:"trend" json:"trend" query:"trend"` // 最近 30 天，按日期正序
+}
@@ -0,0 +1,381 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// 作业相关接口的请求与响应，IDL 尚未覆盖，手动维护
+
+// SubmitHomeworkWithConfirmReq 在 IDL 的 SubmitHomeworkReq 基础上增加 confirmText，
+// 为 true 时识别完成后先等待学生确认文本，确认后再进入批改；
+// 填写 text 时为纯文本提交，跳过识别直接批改，images 可以为空
+type SubmitHomeworkWithConfirmReq struct {
+	HomeworkId  string   `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	MemberId    string   `form:"memberId" json:"memberId" query:"memberId"`
+	Images      []string `form:"images" json:"images" query:"images"` // 图片或 PDF 扫描件 URL 列表
+	ConfirmText bool     `form:"confirmText" json:"confirmText" query:"confirmText"`
+	Title       string   `form:"title" json:"title" query:"title"` // 纯文本提交的标题
+	Text        string   `form:"text" json:"text" query:"text"`    // 纯文本提交的正文
+}
+
+type GetSubmissionTextReq struct {
+	SubmissionId string `form:"submissionId" json:"submissionId" query:"submissionId"`
+}
+
+type GetSubmissionTextResp struct {
+	Code   int64  `form:"code" json:"code" query:"code"`
+	Msg    string `form:"msg" json:"msg" query:"msg"`
+	Status int64  `form:"status" json:"status" query:"status"` // 4 为等待确认文本
+	Title  string `form:"title" json:"title" query:"title"`
+	Text   string `form:"text" json:"text" query:"text"`
+}
+
+// ConfirmSubmissionTextReq 学生确认（可修改）识别出的文本，确认后进入批改
+type ConfirmSubmissionTextReq struct {
+	SubmissionId string `form:"submissionId" json:"submissionId" query:"submissionId"`
+	Title        string `form:"title" json:"title" query:"title"`
+	Text         string `form:"text" json:"text" query:"text"`
+}
+
+// GenerateLessonPlanReq 基于作业中选定的已批改提交生成讲评教案，submissionIds 为空时使用全部已批改提交
+type GenerateLessonPlanReq struct {
+	HomeworkId    string   `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	SubmissionIds []string `form:"submissionIds" json:"submissionIds" query:"submissionIds"`
+	Duration      int64    `form:"duration" json:"duration" query:"duration"` // 课时时长（分钟），为 0 时默认 40
+	Focus         string   `form:"focus" json:"focus" query:"focus"`          // 讲评侧重点
+}
+
+// RegenerateLessonPlanReq 用原教案的提交换参数重新生成，原教案保留
+type RegenerateLessonPlanReq struct {
+	Id       string `form:"id" json:"id" query:"id"`
+	Duration int64  `form:"duration" json:"duration" query:"duration"`
+	Focus    string `form:"focus" json:"focus" query:"focus"`
+}
+
+// LessonPlan 已生成的教案，列表中不返回下载地址
+type LessonPlan struct {
+	Id            string   `form:"id" json:"id" query:"id"`
+	HomeworkId    string   `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	HomeworkTitle string   `form:"homeworkTitle" json:"homeworkTitle" query:"homeworkTitle"`
+	ClassId       string   `form:"classId" json:"classId" query:"classId"`
+	SubmissionIds []string `form:"submissionIds" json:"submissionIds" query:"submissionIds"`
+	Duration      int64    `form:"duration" json:"duration" query:"duration"`
+	Focus         string   `form:"focus" json:"focus" query:"focus"`
+	Url           string   `form:"url" json:"url,omitempty" query:"url"`
+	SessionToken  string   `form:"sessionToken" json:"sessionToken,omitempty" query:"sessionToken"`
+	CreateTime    int64    `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListLessonPlansReq struct {
+	HomeworkId        string                   `form:"homeworkId" json:"homeworkId" query:"homeworkId"` // 为空时查询全部作业
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListLessonPlansResp struct {
+	Code  int64         `form:"code" json:"code" query:"code"`
+	Msg   string        `form:"msg" json:"msg" query:"msg"`
+	Plans []*LessonPlan `form:"plans" json:"plans" query:"plans"`
+	Total int64         `form:"total" json:"total" query:"total"`
+}
+
+type GetLessonPlanReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+type GetLessonPlanResp struct {
+	Code int64       `form:"code" json:"code" query:"code"`
+	Msg  string      `form:"msg" json:"msg" query:"msg"`
+	Plan *LessonPlan `form:"plan" json:"plan" query:"plan"`
+}
+
+// GenerateLessonPlanResp 生成的教案会保存，id 用于之后查看
+type GenerateLessonPlanResp struct {
+	Code         int64  `form:"code" json:"code" query:"code"`
+	Msg          string `form:"msg" json:"msg" query:"msg"`
+	Id           string `form:"id" json:"id" query:"id"`
+	Url          string `form:"url" json:"url" query:"url"`
+	SessionToken string `form:"sessionToken" json:"sessionToken" query:"sessionToken"`
+}
+
+type GetStudentProgressReq struct {
+	MemberId string `form:"memberId" json:"memberId" query:"memberId"`
+}
+
+// StudentProgressPoint 一次作文的得分，同一份作业只取最新一次批改，分项分为 0 表示该学段没有此项
+type StudentProgressPoint struct {
+	SubmissionId  string  `form:"submissionId" json:"submissionId" query:"submissionId"`
+	HomeworkId    string  `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	HomeworkTitle string  `form:"homeworkTitle" json:"homeworkTitle" query:"homeworkTitle"`
+	Score         float64 `form:"score" json:"score" query:"score"`
+	Content       int64   `form:"content" json:"content" query:"content"`
+	Expression    int64   `form:"expression" json:"expression" query:"expression"`
+	Structure     int64   `form:"structure" json:"structure" query:"structure"`
+	Development   int64   `form:"development" json:"development" query:"development"`
+	WordCount     int64   `form:"wordCount" json:"wordCount" query:"wordCount"`
+	SubmitTime    int64   `form:"submitTime" json:"submitTime" query:"submitTime"`
+}
+
+// GetStudentProgressResp points 按提交时间正序
+type GetStudentProgressResp struct {
+	Code   int64                   `form:"code" json:"code" query:"code"`
+	Msg    string                  `form:"msg" json:"msg" query:"msg"`
+	Name   string                  `form:"name" json:"name" query:"name"`
+	Points []*StudentProgressPoint `form:"points" json:"points" query:"points"`
+}
+
+// GetStudentRadarReq count 为统计最近的作文篇数，为 0 时使用默认值
+type GetStudentRadarReq struct {
+	MemberId string `form:"memberId" json:"memberId" query:"memberId"`
+	Count    int64  `form:"count" json:"count" query:"count"`
+}
+
+// RadarDimension 雷达图的一个维度，score 为平均得分率换算的百分制分数
+type RadarDimension struct {
+	Key   string  `form:"key" json:"key" query:"key"`
+	Name  string  `form:"name" json:"name" query:"name"`
+	Score float64 `form:"score" json:"score" query:"score"`
+	Max   float64 `form:"max" json:"max" query:"max"`
+}
+
+type GetStudentRadarResp struct {
+	Code       int64             `form:"code" json:"code" query:"code"`
+	Msg        string            `form:"msg" json:"msg" query:"msg"`
+	Name       string            `form:"name" json:"name" query:"name"`
+	Count      int64             `form:"count" json:"count" query:"count"` // 实际统计的作文篇数
+	Dimensions []*RadarDimension `form:"dimensions" json:"dimensions" query:"dimensions"`
+}
+
+// GetClassCommonMistakesReq top 为返回的高频错误条数，为 0 时使用默认值
+type GetClassCommonMistakesReq struct {
+	HomeworkId string `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	Top        int64  `form:"top" json:"top" query:"top"`
+}
+
+// CommonMistake 一处被批改纠正的错误，studentCount 为出现过该错误的学生数
+type CommonMistake struct {
+	Ori          string `form:"ori" json:"ori" query:"ori"`
+	Revised      string `form:"revised" json:"revised" query:"revised"`
+	Type         string `form:"type" json:"type" query:"type"`
+	Count        int64  `form:"count" json:"count" query:"count"`
+	StudentCount int64  `form:"studentCount" json:"studentCount" query:"studentCount"`
+}
+
+type MistakeTypeCount struct {
+	Type  string `form:"type" json:"type" query:"type"`
+	Count int64  `form:"count" json:"count" query:"count"`
+}
+
+// GetClassCommonMistakesResp mistakes 与 types 均按次数倒序
+type GetClassCommonMistakesResp struct {
+	Code              int64               `form:"code" json:"code" query:"code"`
+	Msg               string              `form:"msg" json:"msg" query:"msg"`
+	EssayCount        int64               `form:"essayCount" json:"essayCount" query:"essayCount"`
+	GrammarMistakeNum int64               `form:"grammarMistakeNum" json:"grammarMistakeNum" query:"grammarMistakeNum"`
+	WrittenMistakeNum int64               `form:"writtenMistakeNum" json:"writtenMistakeNum" query:"writtenMistakeNum"`
+	Types             []*MistakeTypeCount `form:"types" json:"types" query:"types"`
+	Mistakes          []*CommonMistake    `form:"mistakes" json:"mistakes" query:"mistakes"`
+}
+
+type GetTeacherDashboardReq struct{}
+
+// DashboardHomework 最近作业的提交情况，submitted 为提交过的学生数
+type DashboardHomework struct {
+	HomeworkId  string  `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	Title       string  `form:"title" json:"title" query:"title"`
+	ClassId     string  `form:"classId" json:"classId" query:"classId"`
+	ClassName   string  `form:"className" json:"className" query:"className"`
+	Submitted   int64   `form:"submitted" json:"submitted" query:"submitted"`
+	MemberCount int64   `form:"memberCount" json:"memberCount" query:"memberCount"`
+	SubmitRate  float64 `form:"submitRate" json:"submitRate" query:"submitRate"`
+	CreateTime  int64   `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+// GetTeacherDashboardResp 教师首页汇总，count 为剩余批改次数，会员为 -1
+type GetTeacherDashboardResp struct {
+	Code           int64                `form:"code" json:"code" query:"code"`
+	Msg            string               `form:"msg" json:"msg" query:"msg"`
+	PendingCount   int64                `form:"pendingCount" json:"pendingCount" query:"pendingCount"`
+	FailedCount    int64                `form:"failedCount" json:"failedCount" query:"failedCount"`
+	CompletedToday int64                `form:"completedToday" json:"completedToday" query:"completedToday"`
+	Count          int64                `form:"count" json:"count" query:"count"`
+	Homeworks      []*DashboardHomework `form:"homeworks" json:"homeworks" query:"homeworks"`
+}
+
+// WeeklyReport 班级学情周报，时间为秒级时间戳，weekEnd 为下周一 0 点
+type WeeklyReport struct {
+	Id             string            `form:"id" json:"id" query:"id"`
+	ClassId        string            `form:"classId" json:"classId" query:"classId"`
+	WeekStart      int64             `form:"weekStart" json:"weekStart" query:"weekStart"`
+	WeekEnd        int64             `form:"weekEnd" json:"weekEnd" query:"weekEnd"`
+	MemberCount    int64             `form:"memberCount" json:"memberCount" query:"memberCount"`
+	SubmitRate     float64           `form:"submitRate" json:"submitRate" query:"submitRate"`
+	AverageScore   float64           `form:"averageScore" json:"averageScore" query:"averageScore"`
+	Homeworks      []*WeeklyHomework `form:"homeworks" json:"homeworks,omitempty" query:"homeworks"`
+	CommonProblems []string          `form:"commonProblems" json:"commonProblems" query:"commonProblems"`
+	CreateTime     int64             `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type WeeklyHomework struct {
+	HomeworkId   string  `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	Title        string  `form:"title" json:"title" query:"title"`
+	Submitted    int64   `form:"submitted" json:"submitted" query:"submitted"`
+	SubmitRate   float64 `form:"submitRate" json:"submitRate" query:"submitRate"`
+	AverageScore float64 `form:"averageScore" json:"averageScore" query:"averageScore"`
+}
+
+type ListWeeklyReportsReq struct {
+	ClassId           string                   `form:"classId" json:"classId" query:"classId"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+// ListWeeklyReportsResp 列表中不返回各作业明细
+type ListWeeklyReportsResp struct {
+	Code    int64           `form:"code" json:"code" query:"code"`
+	Msg     string          `form:"msg" json:"msg" query:"msg"`
+	Reports []*WeeklyReport `form:"reports" json:"reports" query:"reports"`
+	Total   int64           `form:"total" json:"total" query:"total"`
+}
+
+type GetWeeklyReportReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+type GetWeeklyReportResp struct {
+	Code   int64         `form:"code" json:"code" query:"code"`
+	Msg    string        `form:"msg" json:"msg" query:"msg"`
+	Report *WeeklyReport `form:"report" json:"report" query:"report"`
+}
+
+// DownloadClassReportReq 把班级在 [startTime, endTime) 内已批改的作业汇总成一份 PDF，时间为秒级时间戳
+type DownloadClassReportReq struct {
+	ClassId   string `form:"classId" json:"classId" query:"classId"`
+	StartTime int64  `form:"startTime" json:"startTime" query:"startTime"`
+	EndTime   int64  `form:"endTime" json:"endTime" query:"endTime"`
+}
+
+// DownloadClassReportResp 报告异步生成，id 用于查询生成结果，生成完成后会推送微信消息
+type DownloadClassReportResp struct {
+	Code int64  `form:"code" json:"code" query:"code"`
+	Msg  string `form:"msg" json:"msg" query:"msg"`
+	Id   string `form:"id" json:"id" query:"id"`
+}
+
+type GetClassReportReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+type GetClassReportResp struct {
+	Code         int64  `form:"code" json:"code" query:"code"`
+	Msg          string `form:"msg" json:"msg" query:"msg"`
+	Status       int64  `form:"status" json:"status" query:"status"` // 0: 生成中, 1: 已完成, 2: 生成失败
+	Url          string `form:"url" json:"url" query:"url"`
+	SessionToken string `form:"sessionToken" json:"sessionToken" query:"sessionToken"`
+	Message      string `form:"message" json:"message" query:"message"` // 失败原因
+}
+
+// DownloadSubmissionEvaluateWithFormatReq 在 IDL 的 DownloadSubmissionEvaluateReq 基础上增加导出格式
+type DownloadSubmissionEvaluateWithFormatReq struct {
+	SubmissionIds  []string                `form:"submissionIds" json:"submissionIds" query:"submissionIds"`
+	ExcludeOptions *EvaluateExcludeOptions `form:"excludeOptions" json:"excludeOptions" query:"excludeOptions"`
+	Format         string                  `form:"format" json:"format,omitempty" query:"format"` // pdf / docx，不传为 pdf
+}
+
+// CreateDownloadTaskResp 批量下载改为后台生成，taskId 用于查询生成结果
+type CreateDownloadTaskResp struct {
+	Code   int64  `form:"code" json:"code" query:"code"`
+	Msg    string `form:"msg" json:"msg" query:"msg"`
+	TaskId string `form:"taskId" json:"taskId" query:"taskId"`
+}
+
+type GetDownloadTaskReq struct {
+	TaskId string `form:"taskId" json:"taskId" query:"taskId"`
+}
+
+type GetDownloadTaskResp struct {
+	Code         int64  `form:"code" json:"code" query:"code"`
+	Msg          string `form:"msg" json:"msg" query:"msg"`
+	Status       int64  `form:"status" json:"status" query:"status"` // 0: 生成中, 1: 已完成, 2: 生成失败
+	Url          string `form:"url" json:"url" query:"url"`
+	SessionToken string `form:"sessionToken" json:"sessionToken" query:"sessionToken"`
+	Message      string `form:"message" json:"message" query:"message"` // 失败原因
+}
+
+type ListSubmissionEvaluateVersionsReq struct {
+	SubmissionId      string                   `form:"submissionId" json:"submissionId" query:"submissionId"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+// RollbackSubmissionEvaluateReq 把作业提交的批改结果恢复到 version 版本，恢复后的结果作为新版本保存
+type RollbackSubmissionEvaluateReq struct {
+	SubmissionId string `form:"submissionId" json:"submissionId" query:"submissionId"`
+	Version      int64  `form:"version" json:"version" query:"version"`
+}
+
+// SubmissionInfoWithAiScore 在 IDL 的 SubmissionInfo 基础上增加 AI 生成疑似度（0~1）和书写工整度（0~100），未检测时不返回；
+// wordLimitMessage 为不符合作业字数要求时的标注，如"未达字数要求"
+type SubmissionInfoWithAiScore struct {
+	*SubmissionInfo
+	AiScore          *float64 `form:"aiScore" json:"aiScore,omitempty" query:"aiScore"`
+	Neatness         *float64 `form:"neatness" json:"neatness,omitempty" query:"neatness"`
+	WordCount        int64    `form:"wordCount" json:"wordCount,omitempty" query:"wordCount"`
+	WordLimitMessage string   `form:"wordLimitMessage" json:"wordLimitMessage,omitempty" query:"wordLimitMessage"`
+}
+
+type GetSubmissionsWithAiScoreResp struct {
+	Submissions []*SubmissionInfoWithAiScore `form:"submissions" json:"submissions" query:"submissions"`
+	Total       int64                        `form:"total" json:"total" query:"total"`
+}
+
+// ToProto 转为 IDL 的 GetSubmissionsResp，gRPC 接口不返回疑似度
+func (r *GetSubmissionsWithAiScoreResp) ToProto() *GetSubmissionsResp {
+	submissions := make([]*SubmissionInfo, 0, len(r.Submissions))
+	for _, s := range r.Submissions {
+		submissions = append(submissions, s.SubmissionInfo)
+	}
+	return &GetSubmissionsResp{Submissions: submissions, Total: r.Total}
+}
+
+// GetSubmissionEvaluateWithAiScoreResp 批改结果附带 AI 生成疑似度与书写工整度，疑似度只返回给布置作业的教师；
+// neatnessDeduction 为纳入总评时从得分中扣除的卷面分；subject 决定 response 的结构，0: 语文, 1: 英语
+type GetSubmissionEvaluateWithAiScoreResp struct {
+	*GetSubmissionEvaluateResp
+	AiScore           *float64 `form:"aiScore" json:"aiScore,omitempty" query:"aiScore"`
+	Neatness          *float64 `form:"neatness" json:"neatness,omitempty" query:"neatness"`
+	NeatnessDeduction float64  `form:"neatnessDeduction" json:"neatnessDeduction" query:"neatnessDeduction"`
+	WordCount         int64    `form:"wordCount" json:"wordCount" query:"wordCount"`
+	WordLimitMessage  string   `form:"wordLimitMessage" json:"wordLimitMessage,omitempty" query:"wordLimitMessage"`
+	Subject           int64    `form:"subject" json:"subject" query:"subject"`
+}
+
+// SetHomeworkNeatnessReq 设置书写工整度是否纳入总评，纳入时按工整度扣除最多 3 分卷面分
+type SetHomeworkNeatnessReq struct {
+	HomeworkId string `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	InTotal    bool   `form:"inTotal" json:"inTotal" query:"inTotal"`
+}
+
+// SetHomeworkWordLimitReq 设置作业字数要求，为 0 时不限制，只影响之后批改的提交
+type SetHomeworkWordLimitReq struct {
+	HomeworkId string `form:"homeworkId" json:"homeworkId" query:"homeworkId"`
+	MinWords   int64  `form:"minWords" json:"minWords" query:"minWords"`
+	MaxWords   int64  `form:"maxWords" json:"maxWords" query:"maxWords"`
+}
+
+// HomeworkInfoWithRequirement 在 IDL 的 HomeworkInfo 基础上增加字数要求与工整度设置，学生提交前可以看到字数要求
+type HomeworkInfoWithRequirement struct {
+	*HomeworkInfo
+	MinWords        int64 `form:"minWords" json:"minWords" query:"minWords"`
+	MaxWords        int64 `form:"maxWords" json:"maxWords" query:"maxWords"`
+	NeatnessInTotal bool  `form:"neatnessInTotal" json:"neatnessInTotal" query:"neatnessInTotal"`
+}
+
+type ListHomeworksWithRequirementResp struct {
+	Homeworks []*HomeworkInfoWithRequirement `form:"homeworks" json:"homeworks" query:"homeworks"`
+	Total     int64                          `form:"total" json:"total" query:"total"`
+}
+
+// ToProto 转为 IDL 的 ListHomeworksResp，gRPC 接口不返回字数要求
+func (r *ListHomeworksWithRequirementResp) ToProto() *ListHomeworksResp {
+	homeworks := make([]*HomeworkInfo, 0, len(r.Homeworks))
+	for _, h := range r.Homeworks {
+		homeworks = append(homeworks, h.HomeworkInfo)
+	}
+	return &ListHomeworksResp{Homeworks: homeworks, Total: r.Total}
+}
@@ -0,0 +1,60 @@
+package show
+
+// 家长端接口的请求与响应，IDL 尚未覆盖，手动维护
+
+type GenerateBindCodeReq struct{}
+
+type GenerateBindCodeResp struct {
+	Code       int64  `form:"code" json:"code" query:"code"`
+	Msg        string `form:"msg" json:"msg" query:"msg"`
+	BindCode   string `form:"bindCode" json:"bindCode" query:"bindCode"`
+	ExpireTime int64  `form:"expireTime" json:"expireTime" query:"expireTime"`
+}
+
+type BindChildReq struct {
+	BindCode string `form:"bindCode" json:"bindCode" query:"bindCode"`
+}
+
+type UnbindChildReq struct {
+	ChildId string `form:"childId" json:"childId" query:"childId"`
+}
+
+type ChildInfo struct {
+	UserId   string `form:"userId" json:"userId" query:"userId"`
+	Name     string `form:"name" json:"name" query:"name"`
+	School   string `form:"school" json:"school" query:"school"`
+	Grade    int64  `form:"grade" json:"grade" query:"grade"`
+	BindTime int64  `form:"bindTime" json:"bindTime" query:"bindTime"`
+}
+
+type ListChildrenReq struct{}
+
+type ListChildrenResp struct {
+	Code     int64        `form:"code" json:"code" query:"code"`
+	Msg      string       `form:"msg" json:"msg" query:"msg"`
+	Children []*ChildInfo `form:"children" json:"children" query:"children"`
+}
+
+type ListChildHomeworksReq struct {
+	ChildId string `form:"childId" json:"childId" query:"childId"`
+	ClassId string `form:"classId" json:"classId" query:"classId"` // 为空时返回孩子所在全部班级
+}
+
+// ChildClassHomeworks 孩子在一个班级下的作业完成情况
+type ChildClassHomeworks struct {
+	ClassId   string          `form:"classId" json:"classId" query:"classId"`
+	ClassName string          `form:"className" json:"className" query:"className"`
+	MemberId  string          `form:"memberId" json:"memberId" query:"memberId"`
+	Homeworks []*HomeworkInfo `form:"homeworks" json:"homeworks" query:"homeworks"`
+}
+
+type ListChildHomeworksResp struct {
+	Code    int64                  `form:"code" json:"code" query:"code"`
+	Msg     string                 `form:"msg" json:"msg" query:"msg"`
+	Classes []*ChildClassHomeworks `form:"classes" json:"classes" query:"classes"`
+}
+
+type GetChildSubmissionEvaluateReq struct {
+	ChildId      string `form:"childId" json:"childId" query:"childId"`
+	SubmissionId string `form:"submissionId" json:"submissionId" query:"submissionId"`
+}
@@ -0,0 +1,146 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// 题库相关接口的请求与响应，IDL 尚未覆盖，手动维护
+
+// SearchQuestionBanksReq 在 IDL 的 ListQuestionBanksReq 基础上增加关键字与文体过滤
+type SearchQuestionBanksReq struct {
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+	Type              QuestionBankType         `form:"type" json:"type" query:"type"`
+	Grade             []int64                  `form:"grade" json:"grade" query:"grade"`
+	Keyword           *string                  `form:"keyword" json:"keyword,omitempty" query:"keyword"` // 匹配题目名称或描述
+	Genre             *string                  `form:"genre" json:"genre,omitempty" query:"genre"`       // 文体
+}
+
+type GetQuestionBankDetailReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+// SampleEssay 题目下的一篇范文
+type SampleEssay struct {
+	Id      string `form:"id" json:"id" query:"id"`
+	Title   string `form:"title" json:"title" query:"title"`
+	Content string `form:"content" json:"content" query:"content"`
+	Score   int64  `form:"score" json:"score" query:"score"`
+	Comment string `form:"comment" json:"comment" query:"comment"` // 范文点评
+}
+
+type GetQuestionBankDetailResp struct {
+	Code         int64          `form:"code" json:"code" query:"code"`
+	Msg          string         `form:"msg" json:"msg" query:"msg"`
+	QuestionBank *QuestionBank  `form:"questionBank" json:"questionBank" query:"questionBank"`
+	Requirement  string         `form:"requirement" json:"requirement" query:"requirement"` // 写作要求
+	Samples      []*SampleEssay `form:"samples" json:"samples" query:"samples"`
+}
+
+type GetQuestionBankTreeReq struct {
+	TextbookVersion *int64 `form:"textbookVersion" json:"textbookVersion,omitempty" query:"textbookVersion"` // 只返回该教材版本
+}
+
+// QuestionBankTreeNode 教材版本 -> 年级 -> 单元 的一个节点，Count 为该节点下的题目总数
+type QuestionBankTreeNode struct {
+	Value    int64                   `form:"value" json:"value" query:"value"` // 教材版本、年级或单元的编号
+	Count    int64                   `form:"count" json:"count" query:"count"`
+	Children []*QuestionBankTreeNode `form:"children" json:"children,omitempty" query:"children"`
+}
+
+type GetQuestionBankTreeResp struct {
+	Code     int64                   `form:"code" json:"code" query:"code"`
+	Msg      string                  `form:"msg" json:"msg" query:"msg"`
+	Versions []*QuestionBankTreeNode `form:"versions" json:"versions" query:"versions"`
+}
+
+// TeacherQuestion 教师自建的作文题，可通过 AssignQuestion 直接布置为作业
+type TeacherQuestion struct {
+	Id          string  `form:"id" json:"id" query:"id"`
+	Name        string  `form:"name" json:"name" query:"name"`
+	Description string  `form:"description" json:"description" query:"description"`
+	Grade       int64   `form:"grade" json:"grade" query:"grade"`
+	Unit        int64   `form:"unit" json:"unit" query:"unit"`
+	EssayType   string  `form:"essayType" json:"essayType" query:"essayType"`
+	TotalScore  *int64  `form:"totalScore" json:"totalScore,omitempty" query:"totalScore"`
+	Standard    *string `form:"standard" json:"standard,omitempty" query:"standard"`
+	CreateTime  int64   `form:"createTime" json:"createTime" query:"createTime"`
+	UpdateTime  int64   `form:"updateTime" json:"updateTime" query:"updateTime"`
+}
+
+type CreateQuestionReq struct {
+	Name        string  `form:"name" json:"name" query:"name"`
+	Description string  `form:"description" json:"description" query:"description"`
+	Grade       int64   `form:"grade" json:"grade" query:"grade"`
+	Unit        int64   `form:"unit" json:"unit" query:"unit"`
+	EssayType   string  `form:"essayType" json:"essayType" query:"essayType"`
+	TotalScore  *int64  `form:"totalScore" json:"totalScore,omitempty" query:"totalScore"`
+	Standard    *string `form:"standard" json:"standard,omitempty" query:"standard"`
+}
+
+type CreateQuestionResp struct {
+	Code     int64            `form:"code" json:"code" query:"code"`
+	Msg      string           `form:"msg" json:"msg" query:"msg"`
+	Question *TeacherQuestion `form:"question" json:"question" query:"question"`
+}
+
+// UpdateQuestionReq 只更新传入的字段
+type UpdateQuestionReq struct {
+	Id          string  `form:"id" json:"id" query:"id"`
+	Name        *string `form:"name" json:"name,omitempty" query:"name"`
+	Description *string `form:"description" json:"description,omitempty" query:"description"`
+	Grade       *int64  `form:"grade" json:"grade,omitempty" query:"grade"`
+	Unit        *int64  `form:"unit" json:"unit,omitempty" query:"unit"`
+	EssayType   *string `form:"essayType" json:"essayType,omitempty" query:"essayType"`
+	TotalScore  *int64  `form:"totalScore" json:"totalScore,omitempty" query:"totalScore"`
+	Standard    *string `form:"standard" json:"standard,omitempty" query:"standard"`
+}
+
+type DeleteQuestionReq struct {
+	Id string `form:"id" json:"id" query:"id"`
+}
+
+// AssignQuestionReq 把自建题目布置到班级，题目的名称、要求、年级、文体、总分与批改标准带入作业
+type AssignQuestionReq struct {
+	QuestionId string   `form:"questionId" json:"questionId" query:"questionId"`
+	Subject    Subject  `form:"subject" json:"subject" query:"subject"`
+	ClassIds   []string `form:"classIds" json:"classIds" query:"classIds"`
+}
+
+type ListMyQuestionsReq struct {
+	Grade             *int64                   `form:"grade" json:"grade,omitempty" query:"grade"`
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListMyQuestionsResp struct {
+	Code      int64              `form:"code" json:"code" query:"code"`
+	Msg       string             `form:"msg" json:"msg" query:"msg"`
+	Questions []*TeacherQuestion `form:"questions" json:"questions" query:"questions"`
+	Total     int64              `form:"total" json:"total" query:"total"`
+}
+
+type FavoriteQuestionReq struct {
+	QuestionId string `form:"questionId" json:"questionId" query:"questionId"` // 题库题目 id
+}
+
+// AssignFavoriteReq 把收藏的题库题目布置到班级，题目的名称、描述、年级、文体带入作业
+type AssignFavoriteReq struct {
+	QuestionId string   `form:"questionId" json:"questionId" query:"questionId"` // 题库题目 id
+	Subject    Subject  `form:"subject" json:"subject" query:"subject"`
+	ClassIds   []string `form:"classIds" json:"classIds" query:"classIds"`
+}
+
+type ListFavoritesReq struct {
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+// FavoriteQuestion 收藏的题目，可通过 AssignFavorite 直接布置为作业
+type FavoriteQuestion struct {
+	QuestionBank *QuestionBank `form:"questionBank" json:"questionBank" query:"questionBank"`
+	Requirement  string        `form:"requirement" json:"requirement" query:"requirement"` // 写作要求
+	FavoriteTime int64         `form:"favoriteTime" json:"favoriteTime" query:"favoriteTime"`
+}
+
+type ListFavoritesResp struct {
+	Code      int64               `form:"code" json:"code" query:"code"`
+	Msg       string              `form:"msg" json:"msg" query:"msg"`
+	Favorites []*FavoriteQuestion `form:"favorites" json:"favorites" query:"favorites"`
+	Total     int64               `form:"total" json:"total" query:"total"`
+}
@@ -0,0 +1,63 @@
+package show
+
+// OCRWithPreprocessReq 在 IDL 的 OCRReq 基础上增加识别前的图片预处理选项
+type OCRWithPreprocessReq struct {
+	Ocr        []string `form:"ocr" json:"ocr" query:"ocr"`
+	LeftType   *string  `form:"leftType" json:"leftType,omitempty" query:"leftType"`
+	Preprocess []string `form:"preprocess" json:"preprocess,omitempty" query:"preprocess"` // rotate: 旋转矫正, deshadow: 去阴影
+}
+
+// ApplySignedUrlWithSizeReq 在 IDL 的 ApplySignedUrlReq 基础上增加声明的文件大小
+type ApplySignedUrlWithSizeReq struct {
+	Prefix *string `form:"prefix" json:"prefix,omitempty" query:"prefix"`
+	Suffix *string `form:"suffix" json:"suffix,omitempty" query:"suffix"`
+	Size   int64   `form:"size" json:"size,omitempty" query:"size"` // 文件大小（字节），超过上限时拒绝
+}
+
+// SignedUrlFile 要上传的一个文件，prefix、suffix、size 含义与 ApplySignedUrlWithSizeReq 相同
+type SignedUrlFile struct {
+	Prefix *string `form:"prefix" json:"prefix,omitempty" query:"prefix"`
+	Suffix *string `form:"suffix" json:"suffix,omitempty" query:"suffix"`
+	Size   int64   `form:"size" json:"size,omitempty" query:"size"`
+}
+
+type BatchApplySignedUrlReq struct {
+	Files []*SignedUrlFile `form:"files" json:"files" query:"files"`
+}
+
+// BatchApplySignedUrlResp urls 与请求的 files 一一对应，共用同一个 sessionToken
+type BatchApplySignedUrlResp struct {
+	Code         int64    `form:"code" json:"code" query:"code"`
+	Msg          string   `form:"msg" json:"msg" query:"msg"`
+	SessionToken string   `form:"sessionToken" json:"sessionToken" query:"sessionToken"`
+	Urls         []string `form:"urls" json:"urls" query:"urls"`
+}
+
+// CorrectOCRTextReq 保存对一组图片识别结果的人工校正，之后对同一组图片 OCR 直接返回校正后的文本
+type CorrectOCRTextReq struct {
+	Ocr   []string `form:"ocr" json:"ocr" query:"ocr"`
+	Title string   `form:"title" json:"title" query:"title"`
+	Text  string   `form:"text" json:"text" query:"text"`
+}
+
+type GetOcrQuotaReq struct{}
+
+// GetOcrQuotaResp OCR 配额与批改次数分开，每天先使用免费次数，用完后扣减 ocrCount
+type GetOcrQuotaResp struct {
+	Code      int64  `form:"code" json:"code" query:"code"`
+	Msg       string `form:"msg" json:"msg" query:"msg"`
+	DailyFree int64  `form:"dailyFree" json:"dailyFree" query:"dailyFree"` // 每天免费次数
+	UsedToday int64  `form:"usedToday" json:"usedToday" query:"usedToday"` // 今日已使用的免费次数
+	OcrCount  int64  `form:"ocrCount" json:"ocrCount" query:"ocrCount"`    // 剩余 OCR 次数
+}
+
+// UploadFileReq 服务端直传，文件通过 multipart 表单的 file 字段上传
+type UploadFileReq struct {
+	Prefix *string `form:"prefix" json:"prefix,omitempty" query:"prefix"`
+}
+
+type UploadFileResp struct {
+	Code int64  `form:"code" json:"code" query:"code"`
+	Msg  string `form:"msg" json:"msg" query:"msg"`
+	Url  string `form:"url" json:"url" query:"url"` // 文件在 cos 上的地址，不含签名
+}
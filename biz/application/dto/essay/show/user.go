@@ -0,0 +1,175 @@
+package show
+
+import "essay-show/biz/application/dto/basic"
+
+// UserInfoPayload 在 IDL 生成的用户信息上补充扩展字段
+type UserInfoPayload struct {
+	*GetUserInfoResp_Payload
+	CertStatus  int64 `form:"certStatus" json:"certStatus" query:"certStatus"`    // 教师认证状态，-1: 未申请
+	IsParent    bool  `form:"isParent" json:"isParent" query:"isParent"`          // 是否为家长账号
+	HasPassword bool  `form:"hasPassword" json:"hasPassword" query:"hasPassword"` // 是否已设置密码
+}
+
+type UserInfoResp struct {
+	Code    int64            `form:"code" json:"code" query:"code"`
+	Msg     string           `form:"msg" json:"msg" query:"msg"`
+	Payload *UserInfoPayload `form:"payload" json:"payload" query:"payload"`
+}
+
+// DailyAttendInfoResp 在 IDL 生成的签到信息上补充连续签到字段
+type DailyAttendInfoResp struct {
+	*GetDailyAttendResp
+	Streak           int64   `form:"streak" json:"streak" query:"streak"`                               // 当前连续签到天数
+	NextRewardStreak int64   `form:"nextRewardStreak" json:"nextRewardStreak" query:"nextRewardStreak"` // 下一个额外奖励对应的连续天数
+	MakeupHistory    []int64 `form:"makeupHistory" json:"makeupHistory" query:"makeupHistory"`          // 指定月份中属于补签的日期，History 中包含这些日期
+	MakeupRemain     int64   `form:"makeupRemain" json:"makeupRemain" query:"makeupRemain"`             // 本月剩余补签次数
+}
+
+type MakeupAttendReq struct {
+	Day int64 `form:"day" json:"day" query:"day"` // 本月需要补签的日期
+}
+
+type GetWatermarkReq struct{}
+
+// GetWatermarkResp 导出批改结果时的水印设置，text 为空时使用用户名
+type GetWatermarkResp struct {
+	Code     int64  `form:"code" json:"code" query:"code"`
+	Msg      string `form:"msg" json:"msg" query:"msg"`
+	Disabled bool   `form:"disabled" json:"disabled" query:"disabled"`
+	Text     string `form:"text" json:"text" query:"text"`
+}
+
+type UpdateWatermarkReq struct {
+	Disabled bool   `form:"disabled" json:"disabled" query:"disabled"` // 关闭水印
+	Text     string `form:"text" json:"text" query:"text"`             // 学校名、班级名等，为空时使用用户名
+}
+
+type GetNotifySettingReq struct{}
+
+// GetNotifySettingResp 邮箱与默认通知渠道，channel 为 wechat、inapp、email、sms 或 none
+type GetNotifySettingResp struct {
+	Code    int64  `form:"code" json:"code" query:"code"`
+	Msg     string `form:"msg" json:"msg" query:"msg"`
+	Email   string `form:"email" json:"email" query:"email"`
+	Channel string `form:"channel" json:"channel" query:"channel"`
+}
+
+type UpdateNotifySettingReq struct {
+	Email   string `form:"email" json:"email" query:"email"`       // 接收通知的邮箱，为空时解绑
+	Channel string `form:"channel" json:"channel" query:"channel"` // 选择 email 时必须绑定邮箱，选择 sms 时账号必须已绑定手机号
+}
+
+type GetNotificationPreferenceReq struct{}
+
+// NotificationPreferenceItem 一个通知事件当前生效的接收渠道
+type NotificationPreferenceItem struct {
+	Event   string `form:"event" json:"event" query:"event"`
+	Name    string `form:"name" json:"name" query:"name"`
+	Channel string `form:"channel" json:"channel" query:"channel"`
+}
+
+type GetNotificationPreferenceResp struct {
+	Code  int64                         `form:"code" json:"code" query:"code"`
+	Msg   string                        `form:"msg" json:"msg" query:"msg"`
+	Items []*NotificationPreferenceItem `form:"items" json:"items" query:"items"`
+}
+
+type UpdateNotificationPreferenceReq struct {
+	Preference map[string]string `form:"preference" json:"preference" query:"preference"` // 事件类型 -> 渠道，渠道为空表示使用默认渠道
+}
+
+// Message 站内通知
+type Message struct {
+	Id         string `form:"id" json:"id" query:"id"`
+	Event      string `form:"event" json:"event" query:"event"`
+	Title      string `form:"title" json:"title" query:"title"`
+	Content    string `form:"content" json:"content" query:"content"`
+	Page       string `form:"page" json:"page" query:"page"`
+	Read       bool   `form:"read" json:"read" query:"read"`
+	CreateTime int64  `form:"createTime" json:"createTime" query:"createTime"`
+}
+
+type ListMessagesReq struct {
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type ListMessagesResp struct {
+	Code     int64      `form:"code" json:"code" query:"code"`
+	Msg      string     `form:"msg" json:"msg" query:"msg"`
+	Messages []*Message `form:"messages" json:"messages" query:"messages"`
+	Total    int64      `form:"total" json:"total" query:"total"`
+	Unread   int64      `form:"unread" json:"unread" query:"unread"`
+}
+
+type ReadMessagesReq struct {
+	Ids []string `form:"ids" json:"ids" query:"ids"` // 为空时全部标记已读
+}
+
+type SetPasswordReq struct {
+	Password string `form:"password" json:"password" query:"password"`
+}
+
+type ChangePasswordReq struct {
+	OldPassword string `form:"oldPassword" json:"oldPassword" query:"oldPassword"`
+	NewPassword string `form:"newPassword" json:"newPassword" query:"newPassword"`
+}
+
+type RefreshTokenReq struct{}
+
+type RefreshTokenResp struct {
+	Code         int64  `form:"code" json:"code" query:"code"`
+	Msg          string `form:"msg" json:"msg" query:"msg"`
+	AccessToken  string `form:"accessToken" json:"accessToken" query:"accessToken"`
+	AccessExpire int64  `form:"accessExpire" json:"accessExpire" query:"accessExpire"`
+}
+
+type SignOutReq struct {
+	All bool `form:"all" json:"all" query:"all"` // 是否踢出所有设备
+}
+
+type SessionInfo struct {
+	Id         string `form:"id" json:"id" query:"id"`
+	DeviceId   string `form:"deviceId" json:"deviceId" query:"deviceId"`
+	Ip         string `form:"ip" json:"ip" query:"ip"`
+	UserAgent  string `form:"userAgent" json:"userAgent" query:"userAgent"`
+	LoginTime  int64  `form:"loginTime" json:"loginTime" query:"loginTime"`
+	ExpireTime int64  `form:"expireTime" json:"expireTime" query:"expireTime"`
+	Current    bool   `form:"current" json:"current" query:"current"` // 是否为当前设备
+}
+
+type ListSessionsReq struct{}
+
+type ListSessionsResp struct {
+	Code     int64          `form:"code" json:"code" query:"code"`
+	Msg      string         `form:"msg" json:"msg" query:"msg"`
+	Sessions []*SessionInfo `form:"sessions" json:"sessions" query:"sessions"`
+}
+
+type RevokeSessionReq struct {
+	SessionId string `form:"sessionId" json:"sessionId" query:"sessionId"`
+}
+
+// Invitee 我邀请的用户
+type Invitee struct {
+	UserId     string `form:"userId" json:"userId" query:"userId"`
+	Username   string `form:"username" json:"username" query:"username"`
+	Active     bool   `form:"active" json:"active" query:"active"` // 是否批改过作文
+	Reward     int64  `form:"reward" json:"reward" query:"reward"` // 本次邀请获得的次数，早期记录为 0
+	InviteTime int64  `form:"inviteTime" json:"inviteTime" query:"inviteTime"`
+}
+
+// ExportMyDataReq 导出个人数据，结果通过 GetMyDataExport 按 taskId 查询
+type ExportMyDataReq struct{}
+
+type GetInvitationStatsReq struct {
+	PaginationOptions *basic.PaginationOptions `form:"paginationOptions" json:"paginationOptions" query:"paginationOptions"`
+}
+
+type GetInvitationStatsResp struct {
+	Code         int64      `form:"code" json:"code" query:"code"`
+	Msg          string     `form:"msg" json:"msg" query:"msg"`
+	InvitedCount int64      `form:"invitedCount" json:"invitedCount" query:"invitedCount"` // 累计邀请人数
+	RewardTotal  int64      `form:"rewardTotal" json:"rewardTotal" query:"rewardTotal"`    // 累计获得次数
+	Invitees     []*Invitee `form:"invitees" json:"invitees" query:"invitees"`
+	Total        int64      `form:"total" json:"total" query:"total"`
+}
@@ -0,0 +1,31 @@
+package stateless
+
+// EnglishEvaluate 英语作文的批改结果，与语文的 Evaluate 结构不同：正文按段落给出，
+// 逐句给出语法、拼写纠错，按内容、语言、结构三项打分
+type EnglishEvaluate struct {
+	Title       string              `json:"title"`
+	Text        []string            `json:"text"`
+	WordNum     int                 `json:"wordNum"`
+	Comment     string              `json:"comment"`     // 总评
+	Suggestion  string              `json:"suggestion"`  // 建议
+	Corrections []EnglishCorrection `json:"corrections"` // 纠错
+	Scores      EnglishScores       `json:"scores"`
+}
+
+type EnglishCorrection struct {
+	ParagraphIndex int    `json:"paragraphIndex"`
+	SentenceIndex  int    `json:"sentenceIndex"`
+	Type           string `json:"type"` // grammar: 语法, spelling: 拼写, vocabulary: 用词
+	Original       string `json:"original"`
+	Revised        string `json:"revised"`
+	Reason         string `json:"reason"`
+}
+
+type EnglishScores struct {
+	All       int `json:"all"`
+	Content   int `json:"content"`
+	Language  int `json:"language"`
+	Structure int `json:"structure"`
+	// 总分 / 满分
+	AllWithTotal string `json:"allWithTotal"`
+}
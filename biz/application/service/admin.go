@@ -2,12 +2,21 @@ package service
 
 import (
 	"context"
-	"essay-show/biz/adaptor"
+	"errors"
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/audit"
 	"essay-show/biz/infrastructure/repository/homework"
+	logRepo "essay-show/biz/infrastructure/repository/log"
+	"essay-show/biz/infrastructure/repository/risk"
+	"essay-show/biz/infrastructure/repository/session"
+	"essay-show/biz/infrastructure/repository/setting"
 	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"time"
 
 	"github.com/google/wire"
 )
@@ -15,12 +24,41 @@ import (
 type IAdminService interface {
 	GetAdminHomeworkStatistics(ctx context.Context, req *show.GetAdminHomeworkStatisticsReq) (*show.GetAdminHomeworkStatisticsResp, error)
 	AddGradeCount(ctx context.Context, req *show.AddGradeCountReq) (*show.Response, error)
+	SearchUsers(ctx context.Context, req *show.SearchUsersReq) (*show.SearchUsersResp, error)
+	UpdateUserStatus(ctx context.Context, req *show.UpdateUserStatusReq) (*show.Response, error)
+	AdjustUserCount(ctx context.Context, req *show.AdjustUserCountReq) (*show.AdjustUserCountResp, error)
+	AdjustUserOcrCount(ctx context.Context, req *show.AdjustUserOcrCountReq) (*show.AdjustUserOcrCountResp, error)
+	GetEvaluateStatistics(ctx context.Context, req *show.GetEvaluateStatisticsReq) (*show.GetEvaluateStatisticsResp, error)
+	ListAuditLogs(ctx context.Context, req *show.ListAuditLogsReq) (*show.ListAuditLogsResp, error)
+	ListEvaluateRevisions(ctx context.Context, req *show.ListEvaluateRevisionsReq) (*show.ListEvaluateRevisionsResp, error)
+	ListModerationRecords(ctx context.Context, req *show.ListModerationRecordsReq) (*show.ListModerationRecordsResp, error)
+	CreateFeedbackExport(ctx context.Context, req *show.CreateFeedbackExportReq) (*show.CreateFeedbackExportResp, error)
+	GetFeedbackExport(ctx context.Context, req *show.GetFeedbackExportReq) (*show.GetFeedbackExportResp, error)
+	ListRiskRecords(ctx context.Context, req *show.ListRiskRecordsReq) (*show.ListRiskRecordsResp, error)
+	ReviewRiskRecord(ctx context.Context, req *show.ReviewRiskRecordReq) (*show.Response, error)
+	GetRewardSetting(ctx context.Context, req *show.GetRewardSettingReq) (*show.GetRewardSettingResp, error)
+	UpdateRewardSetting(ctx context.Context, req *show.UpdateRewardSettingReq) (*show.Response, error)
+	RefreshQuestionBankCache(ctx context.Context, req *show.RefreshQuestionBankCacheReq) (*show.Response, error)
 }
 
 type AdminService struct {
-	HomeworkMapper   *homework.MongoMapper
-	UserMapper       *user.MongoMapper
-	SubmissionMapper *homework.SubmissionMongoMapper
+	HomeworkMapper    *homework.MongoMapper
+	UserMapper        *user.MongoMapper
+	SubmissionMapper  *homework.SubmissionMongoMapper
+	LogMapper         *logRepo.MongoMapper
+	AuditMapper       *audit.MongoMapper
+	RevisionMapper    *audit.RevisionMongoMapper
+	VersionMapper     *audit.VersionMongoMapper
+	FeedbackExport    *audit.FeedbackExportMongoMapper
+	ModerationMapper  *audit.ModerationMongoMapper
+	Audit             *AuditRecorder
+	RiskMapper        *risk.MongoMapper
+	SettingMapper     *setting.MongoMapper
+	Rewards           *RewardConfig
+	QuestionBankCache *cache.QuestionBankCacheMapper
+	Downstream        util.DownstreamClient
+	Blacklist         *cache.TokenBlacklistMapper
+	SessionMapper     *session.MongoMapper
 }
 
 var AdminServiceSet = wire.NewSet(
@@ -29,19 +67,8 @@ var AdminServiceSet = wire.NewSet(
 )
 
 func (s *AdminService) GetAdminHomeworkStatistics(ctx context.Context, req *show.GetAdminHomeworkStatisticsReq) (*show.GetAdminHomeworkStatisticsResp, error) {
-	userMeta := adaptor.ExtractUserMeta(ctx)
-	if userMeta.GetUserId() == "" {
-		return nil, consts.ErrNotAuthentication
-	}
-
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
-	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-
-	if user.Role != consts.RoleAdmin {
-		return nil, consts.ErrNotAuthentication
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
 	}
 
 	var (
@@ -59,7 +86,7 @@ func (s *AdminService) GetAdminHomeworkStatistics(ctx context.Context, req *show
 
 	homeworks, total, err := s.HomeworkMapper.FindHomeworks(ctx, page, pageSize, req.Topic, req.StartTime, req.EndTime)
 	if err != nil {
-		log.Error("获取作业列表失败: %v", err)
+		log.CtxError(ctx, "获取作业列表失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
@@ -75,7 +102,7 @@ func (s *AdminService) GetAdminHomeworkStatistics(ctx context.Context, req *show
 
 		submissions, err := s.SubmissionMapper.FindAllByHomework(ctx, homework.ID.Hex(), &[]int{consts.StatusCompleted, consts.StatusModified})
 		if err != nil {
-			log.Error("获取作业提交列表失败: %v", err)
+			log.CtxError(ctx, "获取作业提交列表失败: %v", err)
 			return nil, consts.ErrNotFound
 		}
 		for _, submission := range submissions {
@@ -95,19 +122,9 @@ func (s *AdminService) GetAdminHomeworkStatistics(ctx context.Context, req *show
 }
 
 func (s *AdminService) AddGradeCount(ctx context.Context, req *show.AddGradeCountReq) (*show.Response, error) {
-	userMeta := adaptor.ExtractUserMeta(ctx)
-	if userMeta.GetUserId() == "" {
-		return nil, consts.ErrNotAuthentication
-	}
-
-	operator, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	operator, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-
-	if operator.Role != consts.RoleAdmin {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	if req.Phone == "" || req.Count <= 0 {
@@ -116,18 +133,415 @@ func (s *AdminService) AddGradeCount(ctx context.Context, req *show.AddGradeCoun
 
 	target, err := s.UserMapper.FindOneByPhone(ctx, req.Phone)
 	if err != nil {
-		log.Error("根据手机号获取用户失败, phone: %s, err: %v", req.Phone, err)
+		log.CtxError(ctx, "根据手机号获取用户失败, phone: %s, err: %v", req.Phone, err)
 		return nil, consts.ErrNotFound
 	}
 
 	if err = s.UserMapper.UpdateCount(ctx, target.ID.Hex(), req.Count); err != nil {
-		log.Error("增加批改次数失败, userId: %s, count: %d, err: %v", target.ID.Hex(), req.Count, err)
+		log.CtxError(ctx, "增加批改次数失败, userId: %s, count: %d, err: %v", target.ID.Hex(), req.Count, err)
 		return nil, consts.ErrUpdate
 	}
 
-	log.Info("管理员 %s 给用户 %s(%s) 增加批改次数 %d", operator.ID.Hex(), target.ID.Hex(), req.Phone, req.Count)
+	log.CtxInfo(ctx, "管理员 %s 给用户 %s(%s) 增加批改次数 %d", operator.ID.Hex(), target.ID.Hex(), req.Phone, req.Count)
 	return &show.Response{
 		Code: 0,
 		Msg:  "增加成功",
 	}, nil
 }
+
+// SearchUsers 按手机号/用户名搜索用户
+func (s *AdminService) SearchUsers(ctx context.Context, req *show.SearchUsersReq) (*show.SearchUsersResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	users, total, err := s.UserMapper.Search(ctx, req.Keyword, req.Role, req.Status, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "搜索用户失败, keyword: %s, err: %v", req.Keyword, err)
+		return nil, consts.ErrNotFound
+	}
+
+	dtos := make([]*show.AdminUser, 0, len(users))
+	for _, u := range users {
+		dtos = append(dtos, &show.AdminUser{
+			Id:            u.ID.Hex(),
+			Username:      u.Username,
+			Phone:         u.Phone,
+			Role:          u.Role,
+			Status:        int64(u.Status),
+			Count:         u.Count,
+			School:        u.School,
+			Grade:         u.Grade,
+			VipExpireTime: u.VipExpireTime.Unix(),
+			CreateTime:    u.CreateTime.Unix(),
+		})
+	}
+	return &show.SearchUsersResp{Code: 0, Msg: "success", Users: dtos, Total: total}, nil
+}
+
+// UpdateUserStatus 封禁或解封用户，封禁后用户无法登录
+func (s *AdminService) UpdateUserStatus(ctx context.Context, req *show.UpdateUserStatusReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserId == "" || req.UserId == operator.ID.Hex() {
+		return nil, consts.ErrInvalidParams
+	}
+
+	target, err := s.UserMapper.FindOne(ctx, req.UserId)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	status := consts.UserStatusNormal
+	if req.Banned {
+		status = consts.UserStatusBanned
+	}
+	if err = s.UserMapper.UpdateStatus(ctx, target.ID.Hex(), status); err != nil {
+		log.CtxError(ctx, "更新用户状态失败, userId: %s, status: %d, err: %v", target.ID.Hex(), status, err)
+		return nil, consts.ErrUpdate
+	}
+
+	if req.Banned {
+		if err = s.kickUser(ctx, target.ID.Hex()); err != nil {
+			return nil, consts.ErrCall
+		}
+	}
+
+	log.CtxInfo(ctx, "管理员 %s 将用户 %s 状态改为 %d, 原因: %s", operator.ID.Hex(), target.ID.Hex(), status, req.Reason)
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionUpdateUserStatus, target.ID.Hex(),
+		fmt.Sprintf("状态 %d -> %d, 原因: %s", target.Status, status, req.Reason))
+	return util.Succeed("操作成功")
+}
+
+// kickUser 吊销用户此前签发的所有 token 并将其会话全部下线，封禁后已登录的设备立即失效
+func (s *AdminService) kickUser(ctx context.Context, userId string) error {
+	if err := s.Blacklist.RevokeAllBefore(ctx, userId, time.Now().UnixMilli()); err != nil {
+		log.CtxError(ctx, "吊销封禁用户 token 失败, userId: %s, err: %v", userId, err)
+		return err
+	}
+	if err := s.SessionMapper.RevokeAllByUser(ctx, userId); err != nil {
+		log.CtxError(ctx, "下线封禁用户会话失败, userId: %s, err: %v", userId, err)
+	}
+	return nil
+}
+
+// AdjustUserCount 调整用户剩余批改次数，扣减时最多扣到 0
+func (s *AdminService) AdjustUserCount(ctx context.Context, req *show.AdjustUserCountReq) (*show.AdjustUserCountResp, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserId == "" || req.Delta == 0 {
+		return nil, consts.ErrInvalidParams
+	}
+
+	target, err := s.UserMapper.AdjustCount(ctx, req.UserId, req.Delta)
+	if err != nil {
+		log.CtxError(ctx, "调整批改次数失败, userId: %s, delta: %d, err: %v", req.UserId, req.Delta, err)
+		if errors.Is(err, consts.ErrNotFound) || errors.Is(err, consts.ErrInvalidObjectId) {
+			return nil, consts.ErrNotFound
+		}
+		return nil, consts.ErrUpdate
+	}
+
+	log.CtxInfo(ctx, "管理员 %s 调整用户 %s 批改次数 %d, 原因: %s", operator.ID.Hex(), target.ID.Hex(), req.Delta, req.Reason)
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionAdjustUserCount, target.ID.Hex(),
+		fmt.Sprintf("批改次数调整 %d, 调整后 %d, 原因: %s", req.Delta, target.Count, req.Reason))
+	return &show.AdjustUserCountResp{Code: 0, Msg: "调整成功", Count: target.Count}, nil
+}
+
+// AdjustUserOcrCount 调整用户剩余 OCR 次数（每日免费次数用完后使用），扣减时最多扣到 0
+func (s *AdminService) AdjustUserOcrCount(ctx context.Context, req *show.AdjustUserOcrCountReq) (*show.AdjustUserOcrCountResp, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserId == "" || req.Delta == 0 {
+		return nil, consts.ErrInvalidParams
+	}
+
+	target, err := s.UserMapper.AdjustOcrCount(ctx, req.UserId, req.Delta)
+	if err != nil {
+		log.CtxError(ctx, "调整OCR次数失败, userId: %s, delta: %d, err: %v", req.UserId, req.Delta, err)
+		if errors.Is(err, consts.ErrNotFound) || errors.Is(err, consts.ErrInvalidObjectId) {
+			return nil, consts.ErrNotFound
+		}
+		return nil, consts.ErrUpdate
+	}
+
+	log.CtxInfo(ctx, "管理员 %s 调整用户 %s OCR次数 %d, 原因: %s", operator.ID.Hex(), target.ID.Hex(), req.Delta, req.Reason)
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionAdjustOcrCount, target.ID.Hex(),
+		fmt.Sprintf("OCR次数调整 %d, 调整后 %d, 原因: %s", req.Delta, target.OcrCount, req.Reason))
+	return &show.AdjustUserOcrCountResp{Code: 0, Msg: "调整成功", OcrCount: target.OcrCount}, nil
+}
+
+// GetEvaluateStatistics 查看全局批改量，默认统计当天
+func (s *AdminService) GetEvaluateStatistics(ctx context.Context, req *show.GetEvaluateStatisticsReq) (*show.GetEvaluateStatisticsResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	end := now
+	if req.StartTime != nil {
+		start = time.Unix(*req.StartTime, 0)
+	}
+	if req.EndTime != nil {
+		end = time.Unix(*req.EndTime, 0)
+	}
+	if !start.Before(end) {
+		return nil, consts.ErrInvalidParams
+	}
+
+	essayCount, err := s.LogMapper.CountByTime(ctx, start, end)
+	if err != nil {
+		log.CtxError(ctx, "统计批改记录失败: %v", err)
+		return nil, consts.ErrNotFound
+	}
+	userCount, err := s.LogMapper.CountUsersByTime(ctx, start, end)
+	if err != nil {
+		log.CtxError(ctx, "统计批改用户失败: %v", err)
+		return nil, consts.ErrNotFound
+	}
+	graded, err := s.SubmissionMapper.CountByStatusAndTime(ctx, []int{consts.StatusCompleted, consts.StatusModified}, start, end)
+	if err != nil {
+		log.CtxError(ctx, "统计作业批改量失败: %v", err)
+		return nil, consts.ErrNotFound
+	}
+	failed, err := s.SubmissionMapper.CountByStatusAndTime(ctx, []int{consts.StatusFailed}, start, end)
+	if err != nil {
+		log.CtxError(ctx, "统计作业批改失败量失败: %v", err)
+		return nil, consts.ErrNotFound
+	}
+
+	return &show.GetEvaluateStatisticsResp{
+		Code:               0,
+		Msg:                "success",
+		EssayEvaluateCount: essayCount,
+		EssayUserCount:     userCount,
+		HomeworkGraded:     graded,
+		HomeworkFailed:     failed,
+		StartTime:          start.Unix(),
+		EndTime:            end.Unix(),
+	}, nil
+}
+
+// ListAuditLogs 分页查询审计日志，可按操作人、操作类型与时间范围过滤
+func (s *AdminService) ListAuditLogs(ctx context.Context, req *show.ListAuditLogsReq) (*show.ListAuditLogsResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	logs, total, err := s.AuditMapper.FindMany(ctx, &audit.Filter{
+		OperatorID: req.OperatorId,
+		Action:     req.Action,
+		StartTime:  req.StartTime,
+		EndTime:    req.EndTime,
+	}, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询审计日志失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	dtos := make([]*show.AuditLog, 0, len(logs))
+	for _, l := range logs {
+		dtos = append(dtos, &show.AuditLog{
+			Id:         l.ID.Hex(),
+			OperatorId: l.OperatorID,
+			Action:     l.Action,
+			TargetId:   l.TargetID,
+			Ip:         l.IP,
+			UserAgent:  l.UserAgent,
+			Summary:    l.Summary,
+			CreateTime: l.CreateTime.Unix(),
+		})
+	}
+	return &show.ListAuditLogsResp{Code: 0, Msg: "success", Logs: dtos, Total: total}, nil
+}
+
+// ListEvaluateRevisions 分页查询一条批改结果的修改历史，包含修改人、时间与字段级差异
+func (s *AdminService) ListEvaluateRevisions(ctx context.Context, req *show.ListEvaluateRevisionsReq) (*show.ListEvaluateRevisionsResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+	if req.TargetType != consts.RevisionTargetSubmission && req.TargetType != consts.RevisionTargetLog {
+		return nil, consts.InvalidParams("targetType", "只能为 submission 或 log")
+	}
+
+	revisions, total, err := s.RevisionMapper.FindByTarget(ctx, req.TargetType, req.TargetId, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询批改修改历史失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	dtos := make([]*show.EvaluateRevision, 0, len(revisions))
+	for _, r := range revisions {
+		changes := make([]*show.FieldChange, 0, len(r.Changes))
+		for _, c := range r.Changes {
+			changes = append(changes, &show.FieldChange{Field: c.Field, Before: c.Before, After: c.After})
+		}
+		dtos = append(dtos, &show.EvaluateRevision{
+			Id:         r.ID.Hex(),
+			TargetType: r.TargetType,
+			TargetId:   r.TargetID,
+			OperatorId: r.OperatorID,
+			Changes:    changes,
+			CreateTime: r.CreateTime.Unix(),
+		})
+	}
+	return &show.ListEvaluateRevisionsResp{Code: 0, Msg: "success", Revisions: dtos, Total: total}, nil
+}
+
+// ListModerationRecords 分页查询未通过的作文内容安全审核记录
+func (s *AdminService) ListModerationRecords(ctx context.Context, req *show.ListModerationRecordsReq) (*show.ListModerationRecordsResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	records, total, err := s.ModerationMapper.FindMany(ctx, req.Scene, req.UserId, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询内容审核记录失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	return &show.ListModerationRecordsResp{Code: 0, Msg: "success", Records: toModerationRecords(records), Total: total}, nil
+}
+
+// ListRiskRecords 分页查询风控记录，供人工审核
+func (s *AdminService) ListRiskRecords(ctx context.Context, req *show.ListRiskRecordsReq) (*show.ListRiskRecordsResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	records, total, err := s.RiskMapper.FindMany(ctx, req.Type, req.Status, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询风控记录失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	dtos := make([]*show.RiskRecord, 0, len(records))
+	for _, r := range records {
+		dtos = append(dtos, &show.RiskRecord{
+			Id:         r.ID.Hex(),
+			UserId:     r.UserID,
+			Type:       r.Type,
+			DeviceId:   r.DeviceID,
+			Ip:         r.IP,
+			Detail:     r.Detail,
+			Status:     int64(r.Status),
+			Remark:     r.Remark,
+			CreateTime: r.CreateTime.Unix(),
+		})
+	}
+	return &show.ListRiskRecordsResp{Code: 0, Msg: "success", Records: dtos, Total: total}, nil
+}
+
+// ReviewRiskRecord 审核风控记录，确认作弊时可一并封禁用户
+func (s *AdminService) ReviewRiskRecord(ctx context.Context, req *show.ReviewRiskRecordReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status != consts.RiskStatusNormal && req.Status != consts.RiskStatusFraud {
+		return nil, consts.ErrInvalidParams
+	}
+
+	r, err := s.RiskMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.RiskMapper.Review(ctx, r.ID, int(req.Status), req.Remark, operator.ID.Hex())
+	if err != nil {
+		log.CtxError(ctx, "审核风控记录失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+	if !ok {
+		return util.Fail(-1, "该记录已审核"), nil
+	}
+
+	if req.Status == consts.RiskStatusFraud && req.BanUser {
+		if err = s.UserMapper.UpdateStatus(ctx, r.UserID, consts.UserStatusBanned); err != nil {
+			log.CtxError(ctx, "封禁用户失败, userId: %s, err: %v", r.UserID, err)
+			return nil, consts.ErrUpdate
+		}
+		if err = s.kickUser(ctx, r.UserID); err != nil {
+			return nil, consts.ErrCall
+		}
+		s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionUpdateUserStatus, r.UserID,
+			fmt.Sprintf("风控审核确认作弊并封禁, 风控记录: %s", req.Id))
+	}
+	return util.Succeed("审核成功")
+}
+
+// GetRewardSetting 查看当前生效的奖励参数
+func (s *AdminService) GetRewardSetting(ctx context.Context, req *show.GetRewardSettingReq) (*show.GetRewardSettingResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	rewards := s.Rewards.Load(ctx)
+	return &show.GetRewardSettingResp{
+		Code:               0,
+		Msg:                "success",
+		DefaultCount:       rewards.DefaultCount,
+		AttendReward:       rewards.AttendReward,
+		AttendStreakReward: rewards.AttendStreakReward,
+		InviterReward:      rewards.InviterReward,
+		InviteeReward:      rewards.InviteeReward,
+		OcrDailyFree:       rewards.OcrDailyFree,
+	}, nil
+}
+
+// UpdateRewardSetting 修改奖励参数，只更新传入的字段，修改后即时生效
+func (s *AdminService) UpdateRewardSetting(ctx context.Context, req *show.UpdateRewardSettingReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range []*int64{req.DefaultCount, req.AttendReward, req.AttendStreakReward, req.InviterReward, req.InviteeReward, req.OcrDailyFree} {
+		if v != nil && *v < 0 {
+			return nil, consts.ErrInvalidParams
+		}
+	}
+
+	before := s.Rewards.Load(ctx)
+	err = s.SettingMapper.UpdateReward(ctx, &setting.RewardSetting{
+		DefaultCount:       req.DefaultCount,
+		AttendReward:       req.AttendReward,
+		AttendStreakReward: req.AttendStreakReward,
+		InviterReward:      req.InviterReward,
+		InviteeReward:      req.InviteeReward,
+		OcrDailyFree:       req.OcrDailyFree,
+		UpdaterID:          operator.ID.Hex(),
+	})
+	if err != nil {
+		log.CtxError(ctx, "更新奖励配置失败: %v", err)
+		return nil, consts.ErrUpdate
+	}
+
+	after := s.Rewards.Load(ctx)
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionUpdateRewardSetting, setting.RewardSettingID,
+		fmt.Sprintf("奖励配置 %+v -> %+v", *before, *after))
+	return util.Succeed("修改成功")
+}
+
+// RefreshQuestionBankCache 题库数据在 MySQL 中更新后调用，使题库列表缓存失效
+func (s *AdminService) RefreshQuestionBankCache(ctx context.Context, req *show.RefreshQuestionBankCacheReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.QuestionBankCache.Invalidate(ctx); err != nil {
+		log.CtxError(ctx, "刷新题库缓存失败: %v", err)
+		return nil, consts.ErrUpdate
+	}
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionRefreshQuestionBank, "", "刷新题库列表缓存")
+	return util.Succeed("刷新成功")
+}
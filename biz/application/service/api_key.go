@@ -0,0 +1,460 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/apikey"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/wire"
+	"github.com/samber/lo"
+)
+
+type IApiKeyService interface {
+	CreateApiKey(ctx context.Context, req *show.CreateApiKeyReq) (*show.ApiKeySecretResp, error)
+	ListApiKeys(ctx context.Context, req *show.ListApiKeysReq) (*show.ListApiKeysResp, error)
+	DisableApiKey(ctx context.Context, req *show.DisableApiKeyReq) (*show.Response, error)
+	RotateApiKey(ctx context.Context, req *show.RotateApiKeyReq) (*show.ApiKeySecretResp, error)
+	UpdateApiKeyQuota(ctx context.Context, req *show.UpdateApiKeyQuotaReq) (*show.Response, error)
+	UpdateApiKeyWhitelist(ctx context.Context, req *show.UpdateApiKeyWhitelistReq) (*show.Response, error)
+	GetApiKeyUsage(ctx context.Context, req *show.GetApiKeyUsageReq) (*show.GetApiKeyUsageResp, error)
+	GetApiUsage(ctx context.Context, req *show.GetApiUsageReq) (*show.GetApiUsageResp, error)
+	Authenticate(ctx context.Context, key string) (*apikey.ApiKey, error)
+	VerifySignature(ctx context.Context, caller *adaptor.ApiCaller, method, uri, timestamp, nonce, signature string, body []byte) error
+	CheckIP(ctx context.Context, caller *adaptor.ApiCaller, ip string) error
+}
+
+type ApiKeyService struct {
+	ApiKeyMapper *apikey.MongoMapper
+	UsageMapper  *apikey.UsageMongoMapper
+	QuotaMapper  *cache.ApiQuotaMapper
+	NonceMapper  *cache.ApiNonceMapper
+	Audit        *AuditRecorder
+}
+
+var ApiKeyServiceSet = wire.NewSet(
+	wire.Struct(new(ApiKeyService), "*"),
+	wire.Bind(new(IApiKeyService), new(*ApiKeyService)),
+)
+
+// CreateApiKey 为外部调用方创建密钥和签名密钥，明文只在响应中返回一次
+func (s *ApiKeyService) CreateApiKey(ctx context.Context, req *show.CreateApiKeyReq) (*show.ApiKeySecretResp, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.DailyQuota < 0 || req.MonthlyQuota < 0 {
+		return nil, consts.ErrInvalidParams
+	}
+	whitelist, err := normalizeWhitelist(req.IpWhitelist)
+	if err != nil {
+		return nil, err
+	}
+
+	key, secret, err := genApiKey()
+	if err != nil {
+		log.CtxError(ctx, "生成 API Key 失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	k := &apikey.ApiKey{
+		Name:         strings.TrimSpace(req.Name),
+		Prefix:       key[:consts.ApiKeyShownLength],
+		KeyHash:      hashApiKey(key),
+		SignSecret:   secret,
+		Status:       consts.ApiKeyStatusEnabled,
+		CreatorID:    operator.ID.Hex(),
+		DailyQuota:   req.DailyQuota,
+		MonthlyQuota: req.MonthlyQuota,
+		IPWhitelist:  whitelist,
+	}
+	if err = s.ApiKeyMapper.Insert(ctx, k); err != nil {
+		log.CtxError(ctx, "创建 API Key 失败, name: %s, err: %v", k.Name, err)
+		return nil, consts.ErrCall
+	}
+
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionCreateApiKey, k.ID.Hex(),
+		fmt.Sprintf("创建 API Key, 调用方: %s, 前缀: %s", k.Name, k.Prefix))
+	return &show.ApiKeySecretResp{Code: 0, Msg: "success", Id: k.ID.Hex(), Key: key, Secret: secret}, nil
+}
+
+// ListApiKeys 分页查询密钥，不返回明文和摘要
+func (s *ApiKeyService) ListApiKeys(ctx context.Context, req *show.ListApiKeysReq) (*show.ListApiKeysResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	keys, total, err := s.ApiKeyMapper.FindMany(ctx, req.Status, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询 API Key 失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	dtos := make([]*show.ApiKey, 0, len(keys))
+	for _, k := range keys {
+		dto := &show.ApiKey{
+			Id:           k.ID.Hex(),
+			Name:         k.Name,
+			Prefix:       k.Prefix,
+			Status:       int64(k.Status),
+			CreatorId:    k.CreatorID,
+			DailyQuota:   k.DailyQuota,
+			MonthlyQuota: k.MonthlyQuota,
+			IpWhitelist:  k.IPWhitelist,
+			CreateTime:   k.CreateTime.Unix(),
+		}
+		if !k.RotateTime.IsZero() {
+			dto.RotateTime = k.RotateTime.Unix()
+		}
+		dtos = append(dtos, dto)
+	}
+	return &show.ListApiKeysResp{Code: 0, Msg: "success", Keys: dtos, Total: total}, nil
+}
+
+// DisableApiKey 禁用密钥，立即生效，禁用后不能恢复，需要时重新创建
+func (s *ApiKeyService) DisableApiKey(ctx context.Context, req *show.DisableApiKeyReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := s.ApiKeyMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if k.Status == consts.ApiKeyStatusDisabled {
+		return util.Succeed("已禁用")
+	}
+	if err = s.ApiKeyMapper.UpdateStatus(ctx, k, consts.ApiKeyStatusDisabled); err != nil {
+		log.CtxError(ctx, "禁用 API Key 失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionDisableApiKey, req.Id,
+		fmt.Sprintf("禁用 API Key, 调用方: %s, 前缀: %s", k.Name, k.Prefix))
+	return util.Succeed("禁用成功")
+}
+
+// RotateApiKey 为调用方换发新密钥和签名密钥，旧密钥立即失效，新密钥明文只在响应中返回一次
+func (s *ApiKeyService) RotateApiKey(ctx context.Context, req *show.RotateApiKeyReq) (*show.ApiKeySecretResp, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := s.ApiKeyMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if k.Status == consts.ApiKeyStatusDisabled {
+		return nil, consts.ErrApiKeyInvalid
+	}
+
+	key, secret, err := genApiKey()
+	if err != nil {
+		log.CtxError(ctx, "生成 API Key 失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	ok, err := s.ApiKeyMapper.Rotate(ctx, k, key[:consts.ApiKeyShownLength], hashApiKey(key), secret)
+	if err != nil {
+		log.CtxError(ctx, "轮换 API Key 失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+	if !ok {
+		return nil, consts.ErrUpdate
+	}
+
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionRotateApiKey, req.Id,
+		fmt.Sprintf("轮换 API Key, 调用方: %s, 旧前缀: %s", k.Name, k.Prefix))
+	return &show.ApiKeySecretResp{Code: 0, Msg: "success", Id: req.Id, Key: key, Secret: secret}, nil
+}
+
+// UpdateApiKeyQuota 修改密钥的每日、每月调用配额，0 表示不限，立即生效
+func (s *ApiKeyService) UpdateApiKeyQuota(ctx context.Context, req *show.UpdateApiKeyQuotaReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if req.DailyQuota < 0 || req.MonthlyQuota < 0 {
+		return nil, consts.ErrInvalidParams
+	}
+
+	k, err := s.ApiKeyMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.ApiKeyMapper.UpdateQuota(ctx, k, req.DailyQuota, req.MonthlyQuota); err != nil {
+		log.CtxError(ctx, "修改 API Key 配额失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionUpdateApiKeyQuota, req.Id,
+		fmt.Sprintf("修改 API Key 配额, 调用方: %s, 每日: %d -> %d, 每月: %d -> %d",
+			k.Name, k.DailyQuota, req.DailyQuota, k.MonthlyQuota, req.MonthlyQuota))
+	return util.Succeed("修改成功")
+}
+
+// UpdateApiKeyWhitelist 修改密钥的来源 IP 白名单，为空时不限制，立即生效
+func (s *ApiKeyService) UpdateApiKeyWhitelist(ctx context.Context, req *show.UpdateApiKeyWhitelistReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	whitelist, err := normalizeWhitelist(req.IpWhitelist)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := s.ApiKeyMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.ApiKeyMapper.UpdateWhitelist(ctx, k, whitelist); err != nil {
+		log.CtxError(ctx, "修改 API Key 白名单失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+
+	s.Audit.Record(ctx, operator.ID.Hex(), consts.AuditActionUpdateApiWhitelist, req.Id,
+		fmt.Sprintf("修改 API Key 白名单, 调用方: %s, %v -> %v", k.Name, k.IPWhitelist, whitelist))
+	return util.Succeed("修改成功")
+}
+
+// GetApiKeyUsage 查询密钥每天的用量与合计，供与调用方对账，默认查询本月
+func (s *ApiKeyService) GetApiKeyUsage(ctx context.Context, req *show.GetApiKeyUsageReq) (*show.GetApiKeyUsageResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.ApiKeyMapper.FindOne(ctx, req.Id); err != nil {
+		return nil, err
+	}
+
+	usages, total, err := s.usageReport(ctx, req.Id, req.StartDate, req.EndDate, consts.ApiUsageGranularityDay)
+	if err != nil {
+		return nil, err
+	}
+	return &show.GetApiKeyUsageResp{Code: 0, Msg: "success", Usages: usages, Total: total}, nil
+}
+
+// GetApiUsage 外部调用方自助查询当前密钥的用量明细与剩余配额，默认查询本月、按天聚合
+func (s *ApiKeyService) GetApiUsage(ctx context.Context, req *show.GetApiUsageReq) (*show.GetApiUsageResp, error) {
+	caller := adaptor.ExtractApiCaller(ctx)
+	if caller == nil {
+		return nil, consts.ErrApiKeyInvalid
+	}
+	granularity := req.Granularity
+	if granularity == "" {
+		granularity = consts.ApiUsageGranularityDay
+	}
+	if granularity != consts.ApiUsageGranularityDay && granularity != consts.ApiUsageGranularityMonth {
+		return nil, consts.InvalidParams("granularity", "只能为 day 或 month")
+	}
+
+	usages, total, err := s.usageReport(ctx, caller.KeyId, req.StartDate, req.EndDate, granularity)
+	if err != nil {
+		return nil, err
+	}
+	daily, monthly, err := s.QuotaMapper.Get(ctx, caller.KeyId, time.Now())
+	if err != nil {
+		log.CtxError(ctx, "查询 API 配额计数失败, keyId: %s, err: %v", caller.KeyId, err)
+		return nil, consts.ErrCall
+	}
+	return &show.GetApiUsageResp{
+		Code:             0,
+		Msg:              "success",
+		Usages:           usages,
+		Total:            total,
+		DailyQuota:       caller.DailyQuota,
+		MonthlyQuota:     caller.MonthlyQuota,
+		DailyRemaining:   remainingQuota(caller.DailyQuota, daily),
+		MonthlyRemaining: remainingQuota(caller.MonthlyQuota, monthly),
+	}, nil
+}
+
+// usageReport 查询密钥在 [startDate, endDate] 内的用量，按天或按月聚合，日期为空时默认本月 1 号到今天
+func (s *ApiKeyService) usageReport(ctx context.Context, keyId, startDate, endDate, granularity string) ([]*show.ApiUsage, *show.ApiUsage, error) {
+	now := time.Now()
+	start, end := now.AddDate(0, 0, 1-now.Day()), now
+	var err error
+	if startDate != "" {
+		if start, err = time.ParseInLocation(time.DateOnly, startDate, time.Local); err != nil {
+			return nil, nil, consts.InvalidParams("startDate", "格式应为 2006-01-02")
+		}
+	}
+	if endDate != "" {
+		if end, err = time.ParseInLocation(time.DateOnly, endDate, time.Local); err != nil {
+			return nil, nil, consts.InvalidParams("endDate", "格式应为 2006-01-02")
+		}
+	}
+	if end.Before(start) || end.Sub(start) > consts.ApiUsageMaxDays*24*time.Hour {
+		return nil, nil, consts.InvalidParams("endDate", fmt.Sprintf("需不早于 startDate 且跨度不超过 %d 天", consts.ApiUsageMaxDays))
+	}
+
+	usages, err := s.UsageMapper.FindByKey(ctx, keyId, start.Format(time.DateOnly), end.Format(time.DateOnly))
+	if err != nil {
+		log.CtxError(ctx, "查询 API 用量失败, keyId: %s, err: %v", keyId, err)
+		return nil, nil, consts.ErrCall
+	}
+
+	total := &show.ApiUsage{}
+	dtos := make([]*show.ApiUsage, 0, len(usages))
+	for _, u := range usages {
+		period := u.Date
+		if granularity == consts.ApiUsageGranularityMonth {
+			period = u.Month
+		}
+		// 记录已按日期升序，同一个月的记录相邻
+		if len(dtos) == 0 || dtos[len(dtos)-1].Date != period {
+			dtos = append(dtos, &show.ApiUsage{Date: period})
+		}
+		for _, agg := range []*show.ApiUsage{dtos[len(dtos)-1], total} {
+			agg.Calls += u.Calls
+			agg.EvaluateCount += u.EvaluateCount
+			agg.OcrCount += u.OcrCount
+			agg.Tokens += u.Tokens
+		}
+	}
+	return dtos, total, nil
+}
+
+// remainingQuota 剩余调用次数，配额为 0 即不限时返回 -1
+func remainingQuota(quota, used int64) int64 {
+	if quota <= 0 {
+		return -1
+	}
+	return max(quota-used, 0)
+}
+
+// Authenticate 校验网关请求携带的密钥，返回对应的调用方；密钥不存在或已禁用时返回 ErrApiKeyInvalid
+func (s *ApiKeyService) Authenticate(ctx context.Context, key string) (*apikey.ApiKey, error) {
+	if !strings.HasPrefix(key, consts.ApiKeyPlainPrefix) {
+		return nil, consts.ErrApiKeyInvalid
+	}
+	k, err := s.ApiKeyMapper.FindByHash(ctx, hashApiKey(key))
+	if err != nil || k.Status != consts.ApiKeyStatusEnabled {
+		return nil, consts.ErrApiKeyInvalid
+	}
+	return k, nil
+}
+
+// VerifySignature 校验请求签名并对 nonce 去重，签名原文为
+// METHOD\nURI\nTIMESTAMP\nNONCE\nHEX(SHA256(BODY))，URI 含查询串，用签名密钥做 HMAC-SHA256 后十六进制编码
+func (s *ApiKeyService) VerifySignature(ctx context.Context, caller *adaptor.ApiCaller, method, uri, timestamp, nonce, signature string, body []byte) error {
+	if caller == nil || caller.SignSecret == "" || signature == "" || nonce == "" || len(nonce) > consts.ApiNonceMaxLength {
+		return consts.ErrApiSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return consts.ErrApiSignature
+	}
+	window := config.GetConfig().ApiSign.Window
+	if window <= 0 {
+		window = consts.ApiSignWindow
+	}
+	if d := time.Now().Unix() - ts; d > window || d < -window {
+		return consts.ErrApiReplay
+	}
+
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(caller.SignSecret))
+	mac.Write([]byte(strings.Join([]string{method, uri, timestamp, nonce, hex.EncodeToString(bodyHash[:])}, "\n")))
+	expected := hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(signature))) {
+		return consts.ErrApiSignature
+	}
+
+	// 签名通过后再占用 nonce，避免伪造请求耗尽调用方的 nonce；过期时间覆盖时间戳前后两个窗口
+	ok, err := s.NonceMapper.Use(ctx, caller.KeyId, nonce, int(2*window))
+	if err != nil {
+		log.CtxError(ctx, "API nonce 去重失败, keyId: %s, err: %v", caller.KeyId, err)
+		return consts.ErrCall
+	}
+	if !ok {
+		return consts.ErrApiReplay
+	}
+	return nil
+}
+
+// CheckIP 来源 IP 需在调用方的白名单内，白名单为空时不限制；拒绝时写入审计日志供安全审计，
+// 同一密钥和来源 IP 在限频周期内只记录一次，避免被反复请求刷写审计
+func (s *ApiKeyService) CheckIP(ctx context.Context, caller *adaptor.ApiCaller, ip string) error {
+	if caller == nil {
+		return consts.ErrApiKeyInvalid
+	}
+	if len(caller.IPWhitelist) == 0 {
+		return nil
+	}
+	if addr := net.ParseIP(ip); addr != nil {
+		for _, entry := range caller.IPWhitelist {
+			if entry == addr.String() {
+				return nil
+			}
+			if _, ipNet, err := net.ParseCIDR(entry); err == nil && ipNet.Contains(addr) {
+				return nil
+			}
+		}
+	}
+
+	log.CtxInfo(ctx, "API 来源 IP 不在白名单, keyId: %s, name: %s, ip: %s", caller.KeyId, caller.Name, ip)
+	first, err := s.NonceMapper.MarkIPDenied(ctx, caller.KeyId, ip, consts.ApiIPDeniedAuditPeriod)
+	if err != nil {
+		log.CtxError(ctx, "记录白名单拒绝标记失败, keyId: %s, err: %v", caller.KeyId, err)
+	}
+	if !first && err == nil {
+		return consts.ErrApiIPDenied
+	}
+	s.Audit.Record(ctx, caller.KeyId, consts.AuditActionApiIPDenied, caller.KeyId,
+		fmt.Sprintf("来源 IP 不在白名单, 调用方: %s, ip: %s", caller.Name, ip))
+	return consts.ErrApiIPDenied
+}
+
+// normalizeWhitelist 校验白名单条目为 IP 或 CIDR，去掉空白与重复，IP 统一为标准写法
+func normalizeWhitelist(entries []string) ([]string, error) {
+	if len(entries) > consts.ApiIPWhitelistMax {
+		return nil, consts.InvalidParams("ipWhitelist", fmt.Sprintf("最多 %d 条", consts.ApiIPWhitelistMax))
+	}
+	whitelist := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if addr := net.ParseIP(entry); addr != nil {
+			entry = addr.String()
+		} else if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			entry = ipNet.String()
+		} else {
+			return nil, consts.InvalidParams("ipWhitelist", entry+" 不是合法的 IP 或 CIDR")
+		}
+		if !lo.Contains(whitelist, entry) {
+			whitelist = append(whitelist, entry)
+		}
+	}
+	return whitelist, nil
+}
+
+// genApiKey 生成明文密钥和签名密钥，明文密钥为固定前缀加随机串
+func genApiKey() (key, secret string, err error) {
+	b := make([]byte, consts.ApiKeyRandomBytes+consts.ApiSecretBytes)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", err
+	}
+	return consts.ApiKeyPlainPrefix + hex.EncodeToString(b[:consts.ApiKeyRandomBytes]), hex.EncodeToString(b[consts.ApiKeyRandomBytes:]), nil
+}
+
+func hashApiKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
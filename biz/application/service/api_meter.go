@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/apikey"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/wire"
+)
+
+// ApiMeter 外部 API 按密钥的配额与计量：调用前占用当天和当月的次数，失败时归还，成功后把用量累加到库中供对账
+type ApiMeter struct {
+	QuotaMapper *cache.ApiQuotaMapper
+	UsageMapper *apikey.UsageMongoMapper
+}
+
+var ApiMeterSet = wire.NewSet(
+	wire.Struct(new(ApiMeter), "*"),
+)
+
+// Acquire 占用一次调用额度，超出每日配额返回 ErrApiDailyQuota，超出每月配额返回 ErrApiMonthlyQuota；
+// 计数服务不可用时放行，不影响外部调用。返回占用的时间，调用失败时原样传给 Release；放行时为零值
+func (m *ApiMeter) Acquire(ctx context.Context, caller *adaptor.ApiCaller) (time.Time, error) {
+	if caller == nil {
+		return time.Time{}, consts.ErrApiKeyInvalid
+	}
+	now := time.Now()
+	daily, monthly, err := m.QuotaMapper.Incr(ctx, caller.KeyId, now)
+	if err != nil {
+		log.CtxError(ctx, "API 配额计数失败, keyId: %s, err: %v", caller.KeyId, err)
+		return time.Time{}, nil
+	}
+	switch {
+	case caller.MonthlyQuota > 0 && monthly > caller.MonthlyQuota:
+		err = consts.ErrApiMonthlyQuota
+	case caller.DailyQuota > 0 && daily > caller.DailyQuota:
+		err = consts.ErrApiDailyQuota
+	default:
+		return now, nil
+	}
+	if rerr := m.QuotaMapper.Decr(ctx, caller.KeyId, now); rerr != nil {
+		log.CtxError(ctx, "归还 API 配额失败, keyId: %s, err: %v", caller.KeyId, rerr)
+	}
+	return time.Time{}, err
+}
+
+// Release 调用失败时归还 Acquire 占用的额度，at 为 Acquire 返回的时间，调用跨天或跨月时归还到占用时的计数；
+// at 为零值说明 Acquire 未计数，不归还
+func (m *ApiMeter) Release(ctx context.Context, caller *adaptor.ApiCaller, at time.Time) {
+	if at.IsZero() {
+		return
+	}
+	if err := m.QuotaMapper.Decr(ctx, caller.KeyId, at); err != nil {
+		log.CtxError(ctx, "归还 API 配额失败, keyId: %s, err: %v", caller.KeyId, err)
+	}
+}
+
+// Record 记录一次成功调用的用量，usage 为 consts.ApiUsage*，写入失败只打日志
+func (m *ApiMeter) Record(ctx context.Context, caller *adaptor.ApiCaller, usage string, tokens int64) {
+	if err := m.UsageMapper.Inc(ctx, caller.KeyId, time.Now(), usage, tokens); err != nil {
+		log.CtxError(ctx, "记录 API 用量失败, keyId: %s, usage: %s, tokens: %d, err: %v", caller.KeyId, usage, tokens, err)
+	}
+}
+
+// EstimateTokens 下游不返回 token 用量，按输入输出的字数估算，中文约一字一个 token
+func EstimateTokens(texts ...string) int64 {
+	var n int
+	for _, t := range texts {
+		n += utf8.RuneCountInString(t)
+	}
+	return int64(n)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"sort"
+
+	"github.com/google/wire"
+	"github.com/samber/lo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// AuditRecorder 记录登录与敏感操作，写入失败只打日志，不影响业务结果
+type AuditRecorder struct {
+	AuditMapper    *audit.MongoMapper
+	RevisionMapper *audit.RevisionMongoMapper
+	VersionMapper  *audit.VersionMongoMapper
+}
+
+var AuditRecorderSet = wire.NewSet(
+	wire.Struct(new(AuditRecorder), "*"),
+)
+
+func (r *AuditRecorder) Record(ctx context.Context, operatorId, action, targetId, summary string) {
+	ip, ua := adaptor.ExtractClientInfo(ctx)
+	err := r.AuditMapper.Insert(ctx, &audit.AuditLog{
+		OperatorID: operatorId,
+		Action:     action,
+		TargetID:   targetId,
+		IP:         ip,
+		UserAgent:  ua,
+		Summary:    summary,
+	})
+	if err != nil {
+		log.CtxError(ctx, "写入审计日志失败, operatorId: %s, action: %s, err: %v", operatorId, action, err)
+	}
+}
+
+// RecordRevision 记录一次批改结果修改，before、after 为修改前后的批改结果 JSON，按字段保存差异，没有差异时不记录
+func (r *AuditRecorder) RecordRevision(ctx context.Context, operatorId, targetType, targetId, before, after string) {
+	changes := diffJSON(before, after)
+	if len(changes) == 0 {
+		return
+	}
+	err := r.RevisionMapper.Insert(ctx, &audit.Revision{
+		TargetType: targetType,
+		TargetID:   targetId,
+		OperatorID: operatorId,
+		Changes:    changes,
+	})
+	if err != nil {
+		log.CtxError(ctx, "写入批改修改历史失败, operatorId: %s, targetId: %s, err: %v", operatorId, targetId, err)
+	}
+}
+
+// RecordVersion 把修改后的批改结果保存为新版本，版本号接在最新版本之后；
+// 修改前的结果 before 还不是最新版本时（首次修改或 AI 重批之后）先把它保存下来，使 AI 批改结果可以找回
+func (r *AuditRecorder) RecordVersion(ctx context.Context, before string, v *audit.Version) {
+	if err := r.SnapshotVersion(ctx, v.TargetType, v.TargetID, before); err != nil {
+		log.CtxError(ctx, "保存修改前的批改版本失败, targetId: %s, err: %v", v.TargetID, err)
+		return
+	}
+	if err := r.insertVersion(ctx, v, false); err != nil {
+		log.CtxError(ctx, "保存批改版本失败, targetId: %s, version: %d, err: %v", v.TargetID, v.Version, err)
+	}
+}
+
+// SnapshotVersion 批改结果被 AI 重批覆盖前调用，response 与最新版本不同时保存为新版本；
+// 还没有版本时保存为第 1 版（原始结果）
+func (r *AuditRecorder) SnapshotVersion(ctx context.Context, targetType, targetId, response string) error {
+	if response == "" {
+		return nil
+	}
+	v := &audit.Version{TargetType: targetType, TargetID: targetId, Source: consts.VersionSourceRegrade, Response: response}
+	return r.insertVersion(ctx, v, true)
+}
+
+// insertVersion 以最新版本号加一写入版本，版本号被并发写入占用时重新读取最新版本后重试；
+// snapshot 为 true 时内容与最新版本相同则不写入，没有版本时来源记为原始结果
+func (r *AuditRecorder) insertVersion(ctx context.Context, v *audit.Version, snapshot bool) error {
+	for i := 0; ; i++ {
+		latest, err := r.VersionMapper.FindLatest(ctx, v.TargetType, v.TargetID)
+		switch {
+		case errors.Is(err, consts.ErrNotFound):
+			latest = nil
+		case err != nil:
+			return err
+		}
+
+		v.ID = primitive.NilObjectID
+		v.Version = 1
+		if latest != nil {
+			v.Version = latest.Version + 1
+		}
+		if snapshot {
+			if latest != nil && latest.Response == v.Response {
+				return nil
+			}
+			if latest == nil {
+				v.Source = consts.VersionSourceOriginal
+			}
+		}
+
+		err = r.VersionMapper.Insert(ctx, v)
+		if err == nil || !mongo.IsDuplicateKeyError(err) || i >= consts.VersionInsertRetry {
+			return err
+		}
+	}
+}
+
+// toEvaluateVersions 批改版本转为接口结构
+func toEvaluateVersions(versions []*audit.Version) []*show.EvaluateVersion {
+	dtos := make([]*show.EvaluateVersion, 0, len(versions))
+	for _, v := range versions {
+		dtos = append(dtos, &show.EvaluateVersion{
+			Version:      v.Version,
+			Source:       v.Source,
+			OperatorId:   v.OperatorID,
+			RollbackFrom: v.RollbackFrom,
+			Response:     v.Response,
+			CreateTime:   v.CreateTime.Unix(),
+		})
+	}
+	return dtos
+}
+
+// diffJSON 把两段 JSON 展开为叶子字段后逐个比较，按字段路径排序；无法解析时整体作为一个字段比较
+func diffJSON(before, after string) []*audit.FieldChange {
+	var b, a any
+	if json.Unmarshal([]byte(before), &b) != nil || json.Unmarshal([]byte(after), &a) != nil {
+		if before == after {
+			return nil
+		}
+		return []*audit.FieldChange{{Field: "", Before: before, After: after}}
+	}
+	bf, af := map[string]string{}, map[string]string{}
+	flattenJSON("", b, bf)
+	flattenJSON("", a, af)
+
+	fields := lo.Union(lo.Keys(bf), lo.Keys(af))
+	sort.Strings(fields)
+	var changes []*audit.FieldChange
+	for _, field := range fields {
+		if bf[field] != af[field] {
+			changes = append(changes, &audit.FieldChange{Field: field, Before: bf[field], After: af[field]})
+		}
+	}
+	return changes
+}
+
+// flattenJSON 展开为 路径 -> 值，对象用 . 连接键，数组用 [i]，字符串保存原文，其余保存 JSON 编码
+func flattenJSON(prefix string, v any, out map[string]string) {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, child := range val {
+			if prefix == "" {
+				flattenJSON(k, child, out)
+			} else {
+				flattenJSON(prefix+"."+k, child, out)
+			}
+		}
+	case []any:
+		for i, child := range val {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, out)
+		}
+	case string:
+		out[prefix] = val
+	default:
+		raw, _ := json.Marshal(val)
+		out[prefix] = string(raw)
+	}
+}
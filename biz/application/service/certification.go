@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/certification"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+
+	"github.com/google/wire"
+)
+
+type ICertificationService interface {
+	ApplyCertification(ctx context.Context, req *show.ApplyCertificationReq) (*show.Response, error)
+	GetCertification(ctx context.Context, req *show.GetCertificationReq) (*show.GetCertificationResp, error)
+	ListCertifications(ctx context.Context, req *show.ListCertificationsReq) (*show.ListCertificationsResp, error)
+	ReviewCertification(ctx context.Context, req *show.ReviewCertificationReq) (*show.Response, error)
+}
+
+type CertificationService struct {
+	CertMapper *certification.MongoMapper
+	UserMapper *user.MongoMapper
+}
+
+var CertificationServiceSet = wire.NewSet(
+	wire.Struct(new(CertificationService), "*"),
+	wire.Bind(new(ICertificationService), new(*CertificationService)),
+)
+
+// ApplyCertification 提交教师资质认证材料，审核中不可重复提交
+func (s *CertificationService) ApplyCertification(ctx context.Context, req *show.ApplyCertificationReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if req.RealName == "" || len(req.Images) == 0 {
+		return nil, consts.ErrInvalidParams
+	}
+
+	last, err := s.CertMapper.FindLatestByUser(ctx, userMeta.GetUserId())
+	if err != nil && !errors.Is(err, consts.ErrNotFound) {
+		log.CtxError(ctx, "查询认证申请失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+	if last != nil {
+		switch last.Status {
+		case consts.CertStatusPending:
+			return nil, consts.ErrCertificationPending
+		case consts.CertStatusApproved:
+			return util.Succeed("已通过认证，无需重复提交")
+		}
+	}
+
+	c := &certification.Certification{
+		UserID:   userMeta.GetUserId(),
+		RealName: req.RealName,
+		School:   req.School,
+		Images:   req.Images,
+		Status:   consts.CertStatusPending,
+	}
+	if err = s.CertMapper.Insert(ctx, c); err != nil {
+		log.CtxError(ctx, "提交认证申请失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+	return util.Succeed("提交成功，请等待审核")
+}
+
+// GetCertification 查询自己最近一次认证申请
+func (s *CertificationService) GetCertification(ctx context.Context, req *show.GetCertificationReq) (*show.GetCertificationResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	c, err := s.CertMapper.FindLatestByUser(ctx, userMeta.GetUserId())
+	if errors.Is(err, consts.ErrNotFound) {
+		return &show.GetCertificationResp{Code: 0, Msg: "success", Status: consts.CertStatusNone}, nil
+	} else if err != nil {
+		log.CtxError(ctx, "查询认证申请失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+
+	return &show.GetCertificationResp{
+		Code:          0,
+		Msg:           "success",
+		Status:        int64(c.Status),
+		Certification: toCertificationDTO(c),
+	}, nil
+}
+
+// ListCertifications 管理员分页查看认证申请
+func (s *CertificationService) ListCertifications(ctx context.Context, req *show.ListCertificationsReq) (*show.ListCertificationsResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	data, total, err := s.CertMapper.FindMany(ctx, req.Status, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询认证申请列表失败: %v", err)
+		return nil, consts.ErrNotFound
+	}
+
+	dtos := make([]*show.Certification, 0, len(data))
+	for _, c := range data {
+		dtos = append(dtos, toCertificationDTO(c))
+	}
+	return &show.ListCertificationsResp{Code: 0, Msg: "success", Certifications: dtos, Total: total}, nil
+}
+
+// ReviewCertification 管理员审核认证申请，通过后授予教师角色
+func (s *CertificationService) ReviewCertification(ctx context.Context, req *show.ReviewCertificationReq) (*show.Response, error) {
+	operator, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := s.CertMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !req.Approved && req.Reason == "" {
+		return nil, consts.ErrInvalidParams
+	}
+
+	status := consts.CertStatusRejected
+	if req.Approved {
+		status = consts.CertStatusApproved
+	}
+	ok, err := s.CertMapper.Review(ctx, c.ID, status, req.Reason, operator.ID.Hex())
+	if err != nil {
+		log.CtxError(ctx, "审核认证申请失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+	if !ok {
+		return util.Fail(-1, "该申请已被审核"), nil
+	}
+
+	if req.Approved {
+		if _, err := s.UserMapper.FindOne(ctx, c.UserID); err != nil {
+			return nil, consts.ErrNotFound
+		}
+		if err = s.UserMapper.UpdateRole(ctx, c.UserID, consts.RoleTeacher, c.School); err != nil {
+			log.CtxError(ctx, "授予教师角色失败, userId: %s, err: %v", c.UserID, err)
+			return nil, consts.ErrUpdate
+		}
+	}
+
+	log.CtxInfo(ctx, "管理员 %s 审核教师认证 %s, 用户 %s, 结果 %d", operator.ID.Hex(), req.Id, c.UserID, status)
+	return util.Succeed("审核完成")
+}
+
+func toCertificationDTO(c *certification.Certification) *show.Certification {
+	dto := &show.Certification{
+		Id:         c.ID.Hex(),
+		UserId:     c.UserID,
+		RealName:   c.RealName,
+		School:     c.School,
+		Images:     c.Images,
+		Status:     int64(c.Status),
+		Reason:     c.Reason,
+		CreateTime: c.CreateTime.Unix(),
+	}
+	if !c.ReviewTime.IsZero() {
+		dto.ReviewTime = c.ReviewTime.Unix()
+	}
+	return dto
+}
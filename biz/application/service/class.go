@@ -6,6 +6,7 @@ import (
 	"essay-show/biz/application/dto/essay/show"
 	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
@@ -32,6 +33,8 @@ type ClassService struct {
 	ClassMapper  *class.MongoMapper
 	MemberMapper *class.MemberMongoMapper
 	UserMapper   *user.MongoMapper
+	Transactor   *transaction.Transactor
+	Guard        *Guard
 }
 
 var ClassServiceSet = wire.NewSet(
@@ -45,13 +48,9 @@ func (s *ClassService) CreateClass(ctx context.Context, req *show.CreateClassReq
 		return nil, consts.ErrNotAuthentication
 	}
 
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v, userID: %s", err, userMeta.GetUserId())
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	// 创建班级
@@ -67,7 +66,7 @@ func (s *ClassService) CreateClass(ctx context.Context, req *show.CreateClassReq
 
 	err = s.ClassMapper.Insert(ctx, c)
 	if err != nil {
-		log.Error("创建班级失败: %v", err)
+		log.CtxError(ctx, "创建班级失败: %v", err)
 		return nil, consts.ErrCreateClass
 	}
 
@@ -95,7 +94,7 @@ func (s *ClassService) ListClasses(ctx context.Context, req *show.ListClassesReq
 
 	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
+		log.CtxError(ctx, "获取用户信息失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
@@ -103,7 +102,7 @@ func (s *ClassService) ListClasses(ctx context.Context, req *show.ListClassesReq
 	if user.Role == consts.RoleTeacher {
 		classes, total, err := s.ClassMapper.FindByCreator(ctx, userMeta.GetUserId(), page, pageSize)
 		if err != nil {
-			log.Error("获取班级列表失败: %v", err)
+			log.CtxError(ctx, "获取班级列表失败: %v", err)
 			return nil, consts.ErrGetClassList
 		}
 
@@ -111,7 +110,7 @@ func (s *ClassService) ListClasses(ctx context.Context, req *show.ListClassesReq
 		for _, c := range classes {
 			user, err := s.UserMapper.FindOne(ctx, c.CreatorID)
 			if err != nil {
-				log.Error("获取用户信息失败: %v", err)
+				log.CtxError(ctx, "获取用户信息失败: %v", err)
 				continue
 			}
 
@@ -134,7 +133,7 @@ func (s *ClassService) ListClasses(ctx context.Context, req *show.ListClassesReq
 	// 获取学生班级
 	members, total, err := s.MemberMapper.FindByStuID(ctx, userMeta.GetUserId())
 	if err != nil {
-		log.Error("获取学生班级失败: %v", err)
+		log.CtxError(ctx, "获取学生班级失败: %v", err)
 		return nil, consts.ErrGetClassList
 	}
 
@@ -142,12 +141,12 @@ func (s *ClassService) ListClasses(ctx context.Context, req *show.ListClassesReq
 	for _, m := range members {
 		c, err := s.ClassMapper.FindOne(ctx, m.ClassID)
 		if err != nil {
-			log.Error("获取班级信息失败: %v, classID: %v", err, m.ClassID)
+			log.CtxError(ctx, "获取班级信息失败: %v, classID: %v", err, m.ClassID)
 			continue
 		}
 		user, err := s.UserMapper.FindOne(ctx, c.CreatorID)
 		if err != nil {
-			log.Error("获取用户信息失败: %v, createID: %v", err, c.CreatorID)
+			log.CtxError(ctx, "获取用户信息失败: %v, createID: %v", err, c.CreatorID)
 			continue
 		}
 		classInfos = append(classInfos, &show.ClassInfo{
@@ -175,35 +174,45 @@ func (s *ClassService) CreateClassMembers(ctx context.Context, req *show.CreateC
 	if len(req.Names) == 0 {
 		return nil, consts.ErrInvalidParams
 	}
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+	if _, err := s.Guard.ClassOwner(ctx, meta.GetUserId(), req.ClassId); err != nil {
+		return nil, err
+	}
 
 	success := make([]bool, len(req.Names))
-	newMemberCount := int64(0)
-
+	var members []*class.ClassMember
+	added := make(map[string]bool)
 	for i, name := range req.Names {
+		success[i] = true
+		if added[name] {
+			continue
+		}
 		existingMember, err := s.MemberMapper.FindByClassIDAndName(ctx, req.ClassId, name)
 		if err == nil && existingMember != nil {
-			success[i] = true
 			continue
 		}
-
-		member := &class.ClassMember{
+		added[name] = true
+		members = append(members, &class.ClassMember{
 			ClassID: req.ClassId,
 			Name:    name,
-		}
-		err = s.MemberMapper.Insert(ctx, member)
-		if err != nil {
-			log.Error("创建班级成员 %s 失败: %v", name, err)
-			success[i] = false
-		} else {
-			success[i] = true
-			newMemberCount++
-		}
+		})
 	}
 
-	if newMemberCount > 0 {
-		err := s.ClassMapper.UpdateMemberCount(ctx, req.ClassId, newMemberCount)
+	// 插入成员与更新班级成员数在同一事务中，任一失败时都不生效
+	if len(members) > 0 {
+		err := s.Transactor.Do(ctx, func(ctx context.Context) error {
+			for _, member := range members {
+				if err := s.MemberMapper.Insert(ctx, member); err != nil {
+					return err
+				}
+			}
+			return s.ClassMapper.UpdateMemberCount(ctx, req.ClassId, int64(len(members)))
+		})
 		if err != nil {
-			log.Error("更新班级成员数量失败: %v", err)
+			log.CtxError(ctx, "创建班级成员失败, classId: %s, error: %v", req.ClassId, err)
+			return nil, consts.ErrCreateClassMember
 		}
 	}
 
@@ -229,7 +238,7 @@ func (s *ClassService) GetClassMembers(ctx context.Context, req *show.GetClassMe
 	// 获取班级成员
 	members, total, err := s.MemberMapper.FindByClassID(ctx, req.ClassId, page, pageSize)
 	if err != nil {
-		log.Error("获取班级成员失败: %v", err)
+		log.CtxError(ctx, "获取班级成员失败: %v", err)
 		return nil, consts.ErrGetClassMembers
 	}
 
@@ -264,13 +273,9 @@ func (s *ClassService) BindClassMember(ctx context.Context, req *show.BindClassM
 	userID := meta.GetUserId()
 
 	// 确认学生身份
-	u, err := s.UserMapper.FindOne(ctx, userID)
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if u.Role != consts.RoleStudent {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	// 检查是否已经是班级成员且成员没被绑定
@@ -313,7 +318,7 @@ func (s *ClassService) BindClassMember(ctx context.Context, req *show.BindClassM
 			"join_time": time.Now(),
 		}
 		if err := s.MemberMapper.UpdateFields(ctx, existingMember.ID, updateFields); err != nil {
-			log.Error("绑定班级成员失败: %v", err)
+			log.CtxError(ctx, "绑定班级成员失败: %v", err)
 			return nil, consts.ErrBindClassMember
 		}
 		return util.Succeed("绑定成功")
@@ -333,16 +338,11 @@ func (s *ClassService) UnbindClassMember(ctx context.Context, req *show.UnbindCl
 	if meta.GetUserId() == "" {
 		return nil, consts.ErrNotAuthentication
 	}
-	userID := meta.GetUserId()
 
 	// 确认学生身份
-	u, err := s.UserMapper.FindOne(ctx, userID)
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if u.Role != consts.RoleStudent {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	updateFields := bson.M{
@@ -368,14 +368,25 @@ func (s *ClassService) EditClassMemberName(ctx context.Context, req *show.EditCl
 		return nil, consts.ErrNotAuthentication
 	}
 
-	updateFields := bson.M{
-		"name": req.Name,
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
 	}
 
 	oid, err := primitive.ObjectIDFromHex(req.MemberId)
 	if err != nil {
 		return nil, consts.ErrInvalidObjectId
 	}
+	member, err := s.MemberMapper.FindByMemberID(ctx, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.Guard.ClassOwner(ctx, meta.GetUserId(), member.ClassID); err != nil {
+		return nil, err
+	}
+
+	updateFields := bson.M{
+		"name": req.Name,
+	}
 
 	err = s.MemberMapper.UpdateFields(ctx, oid, updateFields)
 	if err != nil {
@@ -392,25 +403,26 @@ func (s *ClassService) DeleteClassMember(ctx context.Context, req *show.DeleteCl
 	userID := meta.GetUserId()
 
 	// 确认教师身份
-	u, err := s.UserMapper.FindOne(ctx, userID)
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if u.Role != consts.RoleTeacher {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
-	err = s.MemberMapper.Delete(ctx, req.MemberId)
+	member, err := s.MemberMapper.FindByMemberID(ctx, req.MemberId)
 	if err != nil {
 		return nil, err
 	}
-	member, err := s.MemberMapper.FindByMemberID(ctx, req.MemberId)
-	if err != nil {
+	if _, err = s.Guard.ClassOwner(ctx, userID, member.ClassID); err != nil {
 		return nil, err
 	}
-	err = s.ClassMapper.UpdateMemberCount(ctx, member.ClassID, -1)
+	err = s.Transactor.Do(ctx, func(ctx context.Context) error {
+		if err := s.MemberMapper.Delete(ctx, req.MemberId); err != nil {
+			return err
+		}
+		return s.ClassMapper.UpdateMemberCount(ctx, member.ClassID, -1)
+	})
 	if err != nil {
+		log.CtxError(ctx, "删除班级成员失败, memberId: %s, error: %v", req.MemberId, err)
 		return nil, err
 	}
 	// 删除成员作业 TODO
@@ -425,18 +437,14 @@ func (s *ClassService) GetClassMemberInfo(ctx context.Context, req *show.GetClas
 	userID := meta.GetUserId()
 
 	// 确认学生身份
-	u, err := s.UserMapper.FindOne(ctx, userID)
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v, userID: %s", err, userID)
-		return nil, consts.ErrNotFound
-	}
-	if u.Role != consts.RoleStudent {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	member, err := s.MemberMapper.FindByClassIDAndStuID(ctx, req.ClassId, userID)
 	if err != nil {
-		log.Error("获取班级成员信息失败: %v, classID: %s, userID: %s", err, req.ClassId, userID)
+		log.CtxError(ctx, "获取班级成员信息失败: %v, classID: %s, userID: %s", err, req.ClassId, userID)
 		return nil, consts.ErrNotFound
 	}
 	return &show.GetClassMemberInfoResp{
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// DownloadClassReport 提交班级整册批改报告的生成任务，报告在后台生成，完成后推送微信消息
+func (s *HomeworkService) DownloadClassReport(ctx context.Context, req *show.DownloadClassReportReq) (*show.DownloadClassReportResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	start, end := time.Unix(req.StartTime, 0), time.Unix(req.EndTime, 0)
+	if req.StartTime <= 0 || !end.After(start) || end.Sub(start) > consts.ClassReportMaxDays*24*time.Hour {
+		return nil, consts.ErrReportTimeRange
+	}
+
+	classInfo, err := s.Guard.ClassOwner(ctx, userMeta.GetUserId(), req.ClassId)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &homework.ClassReport{
+		ClassID:   req.ClassId,
+		CreatorID: userMeta.GetUserId(),
+		StartTime: start,
+		EndTime:   end,
+		Status:    consts.ReportStatusGenerating,
+	}
+	if err = s.ReportMapper.Insert(ctx, report); err != nil {
+		log.CtxError(ctx, "创建班级报告任务失败: %v", err)
+		return nil, consts.ErrCreateReport
+	}
+
+	go s.generateClassReport(context.Background(), report, classInfo)
+
+	return &show.DownloadClassReportResp{
+		Code: 0,
+		Msg:  "报告生成中，完成后将通知下载",
+		Id:   report.ID.Hex(),
+	}, nil
+}
+
+// GetClassReport 查询班级报告的生成结果
+func (s *HomeworkService) GetClassReport(ctx context.Context, req *show.GetClassReportReq) (*show.GetClassReportResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	report, err := s.ReportMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if report.CreatorID != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	return &show.GetClassReportResp{
+		Code:         0,
+		Msg:          "success",
+		Status:       int64(report.Status),
+		Url:          report.Url,
+		SessionToken: report.SessionToken,
+		Message:      report.Message,
+	}, nil
+}
+
+// generateClassReport 汇总期间内每份作业每个学生最新一次已完成的批改，交给下游生成 PDF 并回填结果
+func (s *HomeworkService) generateClassReport(ctx context.Context, report *homework.ClassReport, classInfo *class.Class) {
+	data, err := s.buildClassReportData(ctx, report, classInfo)
+	if err == nil {
+		report.Url, report.SessionToken, err = s.requestClassReport(ctx, data)
+	}
+	if err != nil {
+		log.CtxError(ctx, "生成班级报告失败, reportId: %s, error: %v", report.ID.Hex(), err)
+		report.Status = consts.ReportStatusFailed
+		report.Message = err.Error()
+	} else {
+		report.Status = consts.ReportStatusDone
+	}
+	if err = s.ReportMapper.Update(ctx, report); err != nil {
+		log.CtxError(ctx, "保存班级报告结果失败, reportId: %s, error: %v", report.ID.Hex(), err)
+		return
+	}
+	s.notifyClassReport(ctx, report, classInfo)
+}
+
+func (s *HomeworkService) buildClassReportData(ctx context.Context, report *homework.ClassReport, classInfo *class.Class) (map[string]any, error) {
+	homeworks, err := s.HomeworkMapper.FindAllByClassAndTime(ctx, report.ClassID, report.StartTime, report.EndTime)
+	if err != nil {
+		return nil, fmt.Errorf("查询班级作业失败: %w", err)
+	}
+
+	var (
+		sections        []map[string]any
+		catalog         []string
+		submissionCount int
+		scoreSum        float64
+		scoreCount      int
+		memberNames     = make(map[string]string)
+		// 学生在各份作业中的得分，用于统计页的学生排名
+		memberScores = make(map[string][]float64)
+	)
+	for _, hw := range homeworks {
+		// 报告按语文的批改结果排版，其他学科的作业不纳入
+		if hw.Subject != consts.SubjectChinese {
+			continue
+		}
+		submissions, err := s.SubmissionMapper.FindAllByHomework(ctx, hw.ID.Hex(), &[]int{consts.StatusCompleted, consts.StatusModified})
+		if err != nil {
+			log.CtxError(ctx, "查询作业提交记录失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+			continue
+		}
+
+		isWebTopic := hw.Topic == consts.TopicTypeWeb
+		seen := make(map[string]bool)
+		var essayList []map[string]any
+		var scores []float64
+		// 提交记录按更新时间倒序，每个学生只取最新一次
+		for _, sub := range submissions {
+			if seen[sub.MemberId] {
+				continue
+			}
+			seen[sub.MemberId] = true
+
+			name, ok := memberNames[sub.MemberId]
+			if !ok {
+				member, err := s.MemberMapper.FindByMemberID(ctx, sub.MemberId)
+				if err != nil {
+					log.CtxError(ctx, "获取学生信息失败, memberId: %s, error: %v", sub.MemberId, err)
+					continue
+				}
+				name = member.Name
+				memberNames[sub.MemberId] = name
+			}
+
+			var data any
+			if isWebTopic {
+				data, err = stateless.BuildWebExportEvaluateData(sub.Response)
+			} else {
+				data, err = stateless.BuildExportEvaluateData(sub.Response, nil)
+			}
+			if err != nil {
+				log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", sub.ID.Hex(), err)
+				continue
+			}
+			essayList = append(essayList, map[string]any{
+				"data":    data,
+				"user_id": name,
+			})
+
+			if score, err := cast.ToFloat64E(sub.GradeResult); err == nil {
+				scores = append(scores, score)
+				memberScores[sub.MemberId] = append(memberScores[sub.MemberId], score)
+				scoreSum += score
+				scoreCount++
+			}
+		}
+		if len(essayList) == 0 {
+			continue
+		}
+
+		submissionCount += len(essayList)
+		avg, maxScore, minScore := scoreSummary(scores)
+		catalog = append(catalog, hw.Title)
+		sections = append(sections, map[string]any{
+			"title":       hw.Title,
+			"topic":       hw.Topic,
+			"create_time": hw.CreateTime.Format(time.DateOnly),
+			"total_score": hw.TotalScore,
+			"statistics": map[string]any{
+				"submitted": len(essayList),
+				"average":   avg,
+				"max":       maxScore,
+				"min":       minScore,
+			},
+			"essay_list": essayList,
+		})
+	}
+	if len(sections) == 0 {
+		return nil, consts.ErrNoCompletedSubmissions
+	}
+
+	teacher, err := s.UserMapper.FindOne(ctx, report.CreatorID)
+	if err != nil {
+		log.CtxError(ctx, "获取教师水印设置失败, userId: %s, error: %v", report.CreatorID, err)
+	}
+	watermark, watermarkText := downloadWatermark(teacher, false)
+
+	ranking := make([]map[string]any, 0, len(memberScores))
+	for memberId, scores := range memberScores {
+		avg, _, _ := scoreSummary(scores)
+		ranking = append(ranking, map[string]any{
+			"name":    memberNames[memberId],
+			"count":   len(scores),
+			"average": avg,
+		})
+	}
+	sort.SliceStable(ranking, func(i, j int) bool {
+		return ranking[i]["average"].(float64) > ranking[j]["average"].(float64)
+	})
+
+	var average float64
+	if scoreCount > 0 {
+		average = math.Round(scoreSum/float64(scoreCount)*10) / 10
+	}
+	return map[string]any{
+		"cover": map[string]any{
+			"class_name": classInfo.Name,
+			"start_date": report.StartTime.Format(time.DateOnly),
+			"end_date":   report.EndTime.Add(-time.Second).Format(time.DateOnly),
+		},
+		"catalog": catalog,
+		"statistics": map[string]any{
+			"member_count":     classInfo.MemberCount,
+			"homework_count":   len(sections),
+			"submission_count": submissionCount,
+			"average":          average,
+			"ranking":          ranking,
+		},
+		"homeworks":      sections,
+		"watermark":      watermark,
+		"watermark_text": watermarkText,
+	}, nil
+}
+
+// requestClassReport 调下游生成报告，返回 (url, sessionToken, error)
+func (s *HomeworkService) requestClassReport(ctx context.Context, data map[string]any) (string, string, error) {
+	return downloadUrlFromResp(s.Downstream.ClassReport(ctx, data))
+}
+
+// notifyClassReport 按教师的通知偏好推送报告生成结果，推送失败只记录日志
+func (s *HomeworkService) notifyClassReport(ctx context.Context, report *homework.ClassReport, classInfo *class.Class) {
+	teacher, err := s.UserMapper.FindOne(ctx, report.CreatorID)
+	if err != nil {
+		log.CtxError(ctx, "获取教师信息失败, reportId: %s, error: %v", report.ID.Hex(), err)
+		return
+	}
+	result := "整册批改报告已生成，点击下载"
+	if report.Status == consts.ReportStatusFailed {
+		result = "整册批改报告生成失败，请稍后重试"
+	}
+	s.Notifier.Notify(ctx, teacher, consts.NotifyEventClassReport, notifyMessage{
+		Subject:    fmt.Sprintf("%s 整册批改报告", classInfo.Name),
+		Content:    fmt.Sprintf("%s %s 至 %s 的%s。", classInfo.Name, report.StartTime.Format(time.DateOnly), report.EndTime.Add(-time.Second).Format(time.DateOnly), result),
+		TemplateId: config.GetConfig().Notify.ReportTemplateId,
+		Data: map[string]string{
+			"thing1": classInfo.Name,
+			"thing2": result,
+		},
+		Page: fmt.Sprintf("%s?id=%s", consts.ClassReportJumpPage, report.ID.Hex()),
+	})
+}
+
+// scoreSummary 返回平均分（保留一位小数）、最高分和最低分，没有分数时均为 0
+func scoreSummary(scores []float64) (avg, maxScore, minScore float64) {
+	if len(scores) == 0 {
+		return 0, 0, 0
+	}
+	var sum float64
+	maxScore, minScore = scores[0], scores[0]
+	for _, score := range scores {
+		sum += score
+		maxScore = math.Max(maxScore, score)
+		minScore = math.Min(minScore, score)
+	}
+	return math.Round(sum/float64(len(scores))*10) / 10, maxScore, minScore
+}
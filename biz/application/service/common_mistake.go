@@ -0,0 +1,168 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/consts"
+	eu "essay-show/biz/infrastructure/util/exercise"
+	"essay-show/biz/infrastructure/util/log"
+	"sort"
+)
+
+// GetClassCommonMistakes 聚合作业下每个学生最新一次批改中的错词和语法错误，输出高频错误供讲评
+func (s *HomeworkService) GetClassCommonMistakes(ctx context.Context, req *show.GetClassCommonMistakesReq) (*show.GetClassCommonMistakesResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	top := req.Top
+	if top == 0 {
+		top = consts.CommonMistakeDefaultTop
+	}
+	if top < 0 || top > consts.CommonMistakeMaxTop {
+		return nil, consts.ErrInvalidParams
+	}
+
+	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if h.CreatorID != userMeta.GetUserId() {
+		log.CtxError(ctx, "用户无权查看此作业共性错误, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
+		return nil, consts.ErrForbidden
+	}
+	if h.Topic == consts.TopicTypeWeb {
+		return nil, consts.ErrInvalidParams
+	}
+
+	submissions, err := s.SubmissionMapper.FindAllByHomework(ctx, req.HomeworkId, &[]int{consts.StatusCompleted, consts.StatusModified})
+	if err != nil {
+		log.CtxError(ctx, "查询作业提交记录失败, homeworkId: %s, error: %v", req.HomeworkId, err)
+		return nil, consts.ErrCall
+	}
+
+	resp := &show.GetClassCommonMistakesResp{Code: 0, Msg: "success"}
+	mistakes := make(map[[2]string]*show.CommonMistake)
+	types := make(map[string]int64)
+	seen := make(map[string]bool)
+	// 提交记录按更新时间倒序，每个学生只统计最新一次
+	for _, sub := range submissions {
+		if seen[sub.MemberId] {
+			continue
+		}
+		seen[sub.MemberId] = true
+
+		found, grammar, written, err := submissionMistakes(sub.Subject, sub.Response)
+		if err != nil {
+			log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", sub.ID.Hex(), err)
+			continue
+		}
+		resp.EssayCount++
+		resp.GrammarMistakeNum += grammar
+		resp.WrittenMistakeNum += written
+
+		counted := make(map[[2]string]bool)
+		for _, f := range found {
+			types[f.Type]++
+
+			key := [2]string{f.Ori, f.Revised}
+			m, ok := mistakes[key]
+			if !ok {
+				m = &show.CommonMistake{Ori: f.Ori, Revised: f.Revised, Type: f.Type}
+				mistakes[key] = m
+			}
+			m.Count++
+			if !counted[key] {
+				counted[key] = true
+				m.StudentCount++
+			}
+		}
+	}
+	if resp.EssayCount == 0 {
+		return nil, consts.ErrNoCompletedSubmissions
+	}
+
+	resp.Mistakes = make([]*show.CommonMistake, 0, len(mistakes))
+	for _, m := range mistakes {
+		resp.Mistakes = append(resp.Mistakes, m)
+	}
+	sort.Slice(resp.Mistakes, func(i, j int) bool {
+		a, b := resp.Mistakes[i], resp.Mistakes[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.StudentCount != b.StudentCount {
+			return a.StudentCount > b.StudentCount
+		}
+		return a.Ori < b.Ori
+	})
+	if int64(len(resp.Mistakes)) > top {
+		resp.Mistakes = resp.Mistakes[:top]
+	}
+
+	resp.Types = make([]*show.MistakeTypeCount, 0, len(types))
+	for typ, n := range types {
+		resp.Types = append(resp.Types, &show.MistakeTypeCount{Type: typ, Count: n})
+	}
+	sort.Slice(resp.Types, func(i, j int) bool {
+		if resp.Types[i].Count != resp.Types[j].Count {
+			return resp.Types[i].Count > resp.Types[j].Count
+		}
+		return resp.Types[i].Type < resp.Types[j].Type
+	})
+	return resp, nil
+}
+
+// englishMistakeTypes 英语纠错类型对应的错误类型名称
+var englishMistakeTypes = map[string]string{
+	"grammar":    "语法",
+	"spelling":   "拼写",
+	"vocabulary": "用词",
+}
+
+// submissionMistakes 按学科解析一次批改结果中带修改建议的错误，返回错误列表以及语法、错别字（拼写）错误数
+func submissionMistakes(subject int64, response string) (found []*show.CommonMistake, grammar, written int64, err error) {
+	if subject == consts.SubjectEnglish {
+		var e stateless.EnglishEvaluate
+		if err = json.Unmarshal([]byte(response), &e); err != nil {
+			return nil, 0, 0, err
+		}
+		for _, c := range e.Corrections {
+			if c.Original == "" || c.Revised == "" {
+				continue
+			}
+			switch c.Type {
+			case "grammar":
+				grammar++
+			case "spelling":
+				written++
+			}
+			typ, ok := englishMistakeTypes[c.Type]
+			if !ok {
+				typ = "其他"
+			}
+			found = append(found, &show.CommonMistake{Ori: c.Original, Revised: c.Revised, Type: typ})
+		}
+		return found, grammar, written, nil
+	}
+
+	var e stateless.Evaluate
+	if err = json.Unmarshal([]byte(response), &e); err != nil {
+		return nil, 0, 0, err
+	}
+	for _, para := range e.AIEvaluation.WordSentenceEvaluation.SentenceEvaluations {
+		for _, sent := range para {
+			for _, we := range sent.WordEvaluations {
+				// 只有带修改建议的词才算错误
+				if we.Ori == "" || we.Revised == "" {
+					continue
+				}
+				found = append(found, &show.CommonMistake{Ori: we.Ori, Revised: we.Revised, Type: eu.MistakeType(we.Type)})
+			}
+		}
+	}
+	return found, int64(e.EssayInfo.Counting.GrammarMistakeNum), int64(e.EssayInfo.Counting.WrittenMistakeNum), nil
+}
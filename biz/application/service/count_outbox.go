@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
+	"essay-show/biz/infrastructure/repository/outbox"
+	"essay-show/biz/infrastructure/repository/transaction"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// CountOutbox 批改次数变更的 outbox：变更先作为事件与批改结果在同一事务中落库，
+// 写入后立即尝试执行，失败的由后台任务重试，保证最终只执行一次
+type CountOutbox struct {
+	OutboxMapper *outbox.MongoMapper
+	UserMapper   *user.MongoMapper
+	Transactor   *transaction.Transactor
+}
+
+var CountOutboxSet = wire.NewSet(
+	wire.Struct(new(CountOutbox), "*"),
+)
+
+// Record 写入一次次数变更事件，需要在批改结果的事务中调用
+func (o *CountOutbox) Record(ctx context.Context, userId string, delta int64, reason, refId string) (*outbox.Event, error) {
+	e := &outbox.Event{
+		UserID: userId,
+		Delta:  delta,
+		Reason: reason,
+		RefID:  refId,
+		Status: consts.OutboxStatusPending,
+	}
+	if err := o.OutboxMapper.Insert(ctx, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// Apply 执行一次次数变更，标记完成与修改次数在同一事务中，事件已执行过时跳过；失败只记录重试，不返回错误
+func (o *CountOutbox) Apply(ctx context.Context, e *outbox.Event) {
+	var applied bool
+	err := o.Transactor.Do(ctx, func(ctx context.Context) error {
+		ok, err := o.OutboxMapper.MarkDone(ctx, e.ID)
+		if err != nil || !ok {
+			return err
+		}
+		applied = true
+		return o.UserMapper.UpdateCount(ctx, e.UserID, e.Delta)
+	})
+	if err == nil {
+		if applied && e.Delta < 0 {
+			metrics.CountConsumed.WithLabelValues(e.Reason).Add(float64(-e.Delta))
+		}
+		return
+	}
+	log.CtxError(ctx, "执行批改次数变更失败, eventId: %s, userId: %s, delta: %d, error: %v", e.ID.Hex(), e.UserID, e.Delta, err)
+	if err = o.OutboxMapper.MarkRetry(ctx, e, err.Error()); err != nil {
+		log.CtxError(ctx, "记录次数变更重试失败, eventId: %s, error: %v", e.ID.Hex(), err)
+	}
+}
+
+// StartDispatcher 启动次数变更的重试任务，每分钟执行一次写入超过 consts.OutboxRetryDelay 仍未完成的事件
+func (o *CountOutbox) StartDispatcher(ctx context.Context) {
+	log.CtxInfo(ctx, "启动批改次数变更重试任务")
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				o.dispatch(context.Background())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (o *CountOutbox) dispatch(ctx context.Context) {
+	events, err := o.OutboxMapper.FindPending(ctx, time.Now().Add(-consts.OutboxRetryDelay), consts.OutboxBatchSize)
+	if err != nil {
+		log.CtxError(ctx, "查询待执行的次数变更失败: %v", err)
+		return
+	}
+	for _, e := range events {
+		o.Apply(ctx, e)
+	}
+}
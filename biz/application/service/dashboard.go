@@ -0,0 +1,66 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+)
+
+// GetTeacherDashboard 教师首页汇总：待批改数、今日完成数、剩余批改次数和最近作业的提交率
+func (s *HomeworkService) GetTeacherDashboard(ctx context.Context, req *show.GetTeacherDashboardReq) (*show.GetTeacherDashboardResp, error) {
+	u, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	teacherId := u.ID.Hex()
+
+	resp := &show.GetTeacherDashboardResp{Code: 0, Msg: "success", Count: u.Count}
+	if user.IsVipActive(u) {
+		resp.Count = -1
+	}
+
+	if resp.PendingCount, err = s.SubmissionMapper.CountByTeacher(ctx, teacherId, []int{consts.StatusInitialized, consts.StatusGrading}, time.Time{}); err != nil {
+		log.CtxError(ctx, "统计待批改提交失败, userId: %s, error: %v", teacherId, err)
+		return nil, consts.ErrCall
+	}
+	if resp.FailedCount, err = s.SubmissionMapper.CountByTeacher(ctx, teacherId, []int{consts.StatusFailed}, time.Time{}); err != nil {
+		log.CtxError(ctx, "统计批改失败提交失败, userId: %s, error: %v", teacherId, err)
+		return nil, consts.ErrCall
+	}
+	y, m, d := time.Now().Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	if resp.CompletedToday, err = s.SubmissionMapper.CountByTeacher(ctx, teacherId, []int{consts.StatusCompleted, consts.StatusModified}, today); err != nil {
+		log.CtxError(ctx, "统计今日完成提交失败, userId: %s, error: %v", teacherId, err)
+		return nil, consts.ErrCall
+	}
+
+	homeworks, err := s.HomeworkMapper.FindRecentByCreator(ctx, teacherId, consts.DashboardRecentHomeworks)
+	if err != nil {
+		log.CtxError(ctx, "查询最近作业失败, userId: %s, error: %v", teacherId, err)
+		return nil, consts.ErrGetHomeworkList
+	}
+	resp.Homeworks = make([]*show.DashboardHomework, 0, len(homeworks))
+	for _, hw := range homeworks {
+		item := &show.DashboardHomework{
+			HomeworkId: hw.ID.Hex(),
+			Title:      hw.Title,
+			ClassId:    hw.ClassID,
+			CreateTime: hw.CreateTime.Unix(),
+		}
+		if classInfo, err := s.ClassMapper.FindOne(ctx, hw.ClassID); err == nil {
+			item.ClassName = classInfo.Name
+			item.MemberCount = classInfo.MemberCount
+		}
+		if item.Submitted, err = s.SubmissionMapper.CountMembersByHomework(ctx, hw.ID.Hex()); err != nil {
+			log.CtxError(ctx, "统计作业提交人数失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+		}
+		if item.MemberCount > 0 {
+			item.SubmitRate = roundRatio(float64(item.Submitted) / float64(item.MemberCount))
+		}
+		resp.Homeworks = append(resp.Homeworks, item)
+	}
+	return resp, nil
+}
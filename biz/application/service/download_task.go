@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateDownloadTask 批量下载作业提交的批改结果，提交后立即返回 taskId，结果在后台生成后写入缓存
+func (s *HomeworkService) CreateDownloadTask(ctx context.Context, req *show.DownloadSubmissionEvaluateWithFormatReq) (*show.CreateDownloadTaskResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	format, ok := exportFormat(req.Format)
+	if !ok || len(req.SubmissionIds) == 0 {
+		return nil, consts.ErrInvalidParams
+	}
+	if err := s.checkSubmissionsOwner(ctx, userMeta.GetUserId(), req.SubmissionIds); err != nil {
+		return nil, err
+	}
+
+	task := &cache.DownloadTask{
+		Id:         uuid.New().String(),
+		UserId:     userMeta.GetUserId(),
+		Status:     consts.ReportStatusGenerating,
+		CreateTime: time.Now().Unix(),
+	}
+	if err := s.DownloadTask.Set(ctx, task); err != nil {
+		log.CtxError(ctx, "创建下载任务失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	go func() {
+		ctx := context.Background()
+		url, sessionToken, err := s.exportSubmissions(ctx, task.UserId, req.SubmissionIds, req.ExcludeOptions, format)
+		if err != nil {
+			task.Status = consts.ReportStatusFailed
+			task.Message = err.Error()
+		} else {
+			task.Status = consts.ReportStatusDone
+			task.Url = url
+			task.SessionToken = sessionToken
+		}
+		if err = s.DownloadTask.Set(ctx, task); err != nil {
+			log.CtxError(ctx, "保存下载任务结果失败, taskId: %s, error: %v", task.Id, err)
+		}
+	}()
+
+	return &show.CreateDownloadTaskResp{
+		Code:   0,
+		Msg:    "下载任务已提交",
+		TaskId: task.Id,
+	}, nil
+}
+
+// GetDownloadTask 查询下载任务的状态与最终链接，任务结果保留 1 小时
+func (s *HomeworkService) GetDownloadTask(ctx context.Context, req *show.GetDownloadTaskReq) (*show.GetDownloadTaskResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	task, err := s.DownloadTask.Get(ctx, req.TaskId)
+	if err != nil {
+		log.CtxError(ctx, "查询下载任务失败, taskId: %s, error: %v", req.TaskId, err)
+		return nil, consts.ErrCall
+	}
+	if task == nil || task.UserId != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	return &show.GetDownloadTaskResp{
+		Code:         0,
+		Msg:          "success",
+		Status:       int64(task.Status),
+		Url:          task.Url,
+		SessionToken: task.SessionToken,
+		Message:      task.Message,
+	}, nil
+}
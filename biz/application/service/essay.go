@@ -10,9 +10,13 @@ import (
 	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/lock"
+	"essay-show/biz/infrastructure/repository/audit"
 	"essay-show/biz/infrastructure/repository/log"
+	"essay-show/biz/infrastructure/repository/outbox"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/export"
 	logx "essay-show/biz/infrastructure/util/log"
 	"fmt"
 	"strings"
@@ -24,12 +28,16 @@ import (
 )
 
 type IEssayService interface {
-	EssayEvaluateStream(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan<- string) error
-	APIEssayEvaluateStreamV1(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan<- string) error
+	EssayEvaluateStream(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan string) error
+	EssayEvaluate(ctx context.Context, req *show.EssayEvaluateReq) (*show.EssayEvaluateResp, error)
+	APIEssayEvaluateStreamV1(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan string) error
+	APIEssayEvaluateV2(ctx context.Context, req *show.EssayEvaluateReq) (*show.APIEssayEvaluateV2Resp, error)
 	GetEvaluateLogs(ctx context.Context, req *show.GetEssayEvaluateLogsReq) (resp *show.GetEssayEvaluateLogsResp, err error)
 	LikeEvaluate(ctx context.Context, req *show.LikeEvaluateReq) (resp *show.Response, err error)
-	DownloadEvaluate(ctx context.Context, req *show.DownloadEvaluateReq) (resp *show.DownloadEvaluateResp, err error)
+	DownloadEvaluate(ctx context.Context, req *show.DownloadEvaluateWithFormatReq) (resp *show.DownloadEvaluateResp, err error)
 	EvaluateModify(ctx context.Context, req *show.EvaluateModifyReq) (resp *show.Response, err error)
+	ListEvaluateVersions(ctx context.Context, req *show.ListEvaluateVersionsReq) (*show.ListEvaluateVersionsResp, error)
+	RollbackEvaluate(ctx context.Context, req *show.RollbackEvaluateReq) (*show.Response, error)
 	DeleteEvaluate(ctx context.Context, req *show.DeleteEvaluateReq) (resp *show.Response, err error)
 }
 
@@ -37,6 +45,12 @@ type EssayService struct {
 	LogMapper           *log.MongoMapper
 	UserMapper          *user.MongoMapper
 	DownloadCacheMapper *cache.DownloadCacheMapper
+	VersionMapper       *audit.VersionMongoMapper
+	Audit               *AuditRecorder
+	Transactor          *transaction.Transactor
+	CountOutbox         *CountOutbox
+	Downstream          util.DownstreamClient
+	Moderator           *ContentModerator
 }
 
 var EssayServiceSet = wire.NewSet(
@@ -45,7 +59,7 @@ var EssayServiceSet = wire.NewSet(
 )
 
 // EssayEvaluateStream 流式批改作文
-func (s *EssayService) EssayEvaluateStream(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan<- string) error {
+func (s *EssayService) EssayEvaluateStream(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan string) error {
 	meta := adaptor.ExtractUserMeta(ctx)
 	if meta.GetUserId() == "" {
 		util.SendStreamMessage(resultChan, util.STError, "用户未认证", nil)
@@ -67,6 +81,12 @@ func (s *EssayService) EssayEvaluateStream(ctx context.Context, req *show.EssayE
 		}
 	}
 
+	// 内容安全审核
+	if s.Moderator.Check(ctx, consts.ModerationSceneEssay, "", u.ID.Hex(), req.Title, req.Text) != nil {
+		util.SendStreamMessage(resultChan, util.STError, consts.ErrContentBlocked.Error(), nil)
+		return consts.ErrContentBlocked
+	}
+
 	// 获取锁 - 调整TTL以适应复杂作文批改时间
 	key := "evaluate" + meta.GetUserId()
 	distributedLock := lock.NewEvaMutex(ctx, key, 30, 200)
@@ -78,7 +98,7 @@ func (s *EssayService) EssayEvaluateStream(ctx context.Context, req *show.EssayE
 	defer func() {
 		// 释放锁
 		if err = distributedLock.Unlock(); err != nil || distributedLock.Expired() {
-			logx.Error("unlock error: %v, lock expired: %v", err, distributedLock.Expired())
+			logx.CtxError(ctx, "unlock error: %v, lock expired: %v", err, distributedLock.Expired())
 		}
 	}()
 
@@ -89,7 +109,6 @@ func (s *EssayService) EssayEvaluateStream(ctx context.Context, req *show.EssayE
 	// 启动下游调用
 	go func() {
 		defer close(downstreamChan) // 确保HTTP请求完成后关闭channel，避免主函数永远阻塞
-		client := util.GetHttpClient()
 
 		// 准备分项打分比例（自动分配：总分除以3）
 		var ratio *util.ScoreRatio
@@ -98,14 +117,14 @@ func (s *EssayService) EssayEvaluateStream(ctx context.Context, req *show.EssayE
 		}
 
 		// 参数: title, text, grade, totalScore, essayType, prompt, standard, ratio, resultChan
-		client.EvaluateStream(ctx, req.Title, req.Text, req.Grade, &req.TotalScore, req.EssayType, req.Description, nil, ratio, downstreamChan)
+		s.Downstream.EvaluateStream(ctx, req.Title, req.Text, req.Grade, &req.TotalScore, req.EssayType, req.Description, nil, ratio, nil, downstreamChan)
 	}()
 
 	for jsonMessage := range downstreamChan {
 		// 解析下游JSON消息
 		var data map[string]interface{}
 		if parseErr := json.Unmarshal([]byte(jsonMessage), &data); parseErr != nil {
-			logx.Error("解析下游JSON消息失败: %v", parseErr)
+			logx.CtxError(ctx, "解析下游JSON消息失败: %v", parseErr)
 			continue
 		}
 		// 检查消息类型并转发
@@ -145,21 +164,26 @@ exitLoop:
 		l.Grade = *req.Grade
 	}
 
-	err = s.LogMapper.Insert(ctx, l)
+	// 批改记录与扣次数事件在同一事务中写入（VIP 用户不扣次数），次数由 outbox 保证最终扣减
+	var event *outbox.Event
+	err = s.Transactor.Do(ctx, func(ctx context.Context) error {
+		if err := s.LogMapper.Insert(ctx, l); err != nil {
+			return err
+		}
+		if user.IsVipActive(u) {
+			return nil
+		}
+		var err error
+		event, err = s.CountOutbox.Record(ctx, meta.GetUserId(), -1, consts.CountReasonEssay, l.ID.Hex())
+		return err
+	})
 	if err != nil {
-		logx.Error("log insert failed %v", err)
+		logx.CtxError(ctx, "log insert failed %v", err)
 		util.SendStreamMessage(resultChan, util.STError, "日志记录失败", nil)
 		return consts.ErrCall
 	}
-
-	// 扣除用户剩余次数（VIP 用户跳过）
-	if !user.IsVipActive(u) {
-		err = s.UserMapper.UpdateCount(ctx, meta.GetUserId(), -1)
-		if err != nil {
-			logx.Error("user count update failed %v", err)
-			util.SendStreamMessage(resultChan, util.STError, "用户次数扣减失败", nil)
-			return consts.ErrCall
-		}
+	if event != nil {
+		s.CountOutbox.Apply(ctx, event)
 	}
 
 	// 发送最终完成消息
@@ -173,6 +197,50 @@ exitLoop:
 	return nil
 }
 
+// EssayEvaluate 同步批改作文，消费 EssayEvaluateStream 的结果，批改完成后一次返回，供 gRPC 等非流式调用方使用
+func (s *EssayService) EssayEvaluate(ctx context.Context, req *show.EssayEvaluateReq) (*show.EssayEvaluateResp, error) {
+	data, err := collectStream(ctx, func(resultChan chan string) error {
+		return s.EssayEvaluateStream(ctx, req, resultChan)
+	})
+	if err != nil {
+		return nil, err
+	}
+	resp := new(show.EssayEvaluateResp)
+	if err = json.Unmarshal(data, resp); err != nil {
+		logx.CtxError(ctx, "解析批改结果失败, err: %v", err)
+		return nil, consts.ErrCall
+	}
+	return resp, nil
+}
+
+// collectStream 执行流式批改并丢弃中间消息，返回 complete 消息中的 data；run 返回错误时以其为准
+func collectStream(ctx context.Context, run func(resultChan chan string) error) (json.RawMessage, error) {
+	resultChan := util.NewStreamChan(ctx)
+	var err error
+	go func() {
+		defer util.CloseStreamChan(resultChan)
+		err = run(resultChan)
+	}()
+
+	var data json.RawMessage
+	for jsonMessage := range resultChan {
+		var msg struct {
+			Type util.StreamType `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if json.Unmarshal([]byte(jsonMessage), &msg) == nil && msg.Type == util.STComplete {
+			data = msg.Data
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, consts.ErrCall
+	}
+	return data, nil
+}
+
 // GetEvaluateLogs 分页查找获取正常的批改记录
 func (s *EssayService) GetEvaluateLogs(ctx context.Context, req *show.GetEssayEvaluateLogsReq) (resp *show.GetEssayEvaluateLogsResp, err error) {
 	// 获取用户信息
@@ -216,27 +284,31 @@ func (s *EssayService) LikeEvaluate(ctx context.Context, req *show.LikeEvaluateR
 	l.Like = req.Like
 	err = s.LogMapper.Update(ctx, l)
 	if err != nil {
-		logx.Error(err.Error())
+		logx.CtxError(ctx, err.Error())
 		return util.Fail(999, "标记失败"), nil
 	}
 	return util.Succeed("标记成功")
 }
 
-// DownloadEvaluate 下载批改结果
-func (s *EssayService) DownloadEvaluate(ctx context.Context, req *show.DownloadEvaluateReq) (resp *show.DownloadEvaluateResp, err error) {
+// DownloadEvaluate 下载批改结果，format 为 docx 时导出可编辑的 Word
+func (s *EssayService) DownloadEvaluate(ctx context.Context, req *show.DownloadEvaluateWithFormatReq) (resp *show.DownloadEvaluateResp, err error) {
 	meta := adaptor.ExtractUserMeta(ctx)
 	if meta.GetUserId() == "" {
 		return nil, consts.ErrNotAuthentication
 	}
+	format, ok := exportFormat(req.Format)
+	if !ok {
+		return nil, consts.ErrInvalidParams
+	}
 
 	// if cachedResp, err := s.DownloadCacheMapper.Get(ctx, req.Id); err == nil {
-	// 	logx.Info("缓存命中，直接返回下载链接, id: %s", req.Id)
+	// 	logx.CtxInfo(ctx, "缓存命中，直接返回下载链接, id: %s", req.Id)
 	// 	return cachedResp, nil
 	// }
 
 	l, err := s.LogMapper.FindOne(ctx, req.Id)
 	if err != nil {
-		logx.Error("查询批改记录失败: %v", err)
+		logx.CtxError(ctx, "查询批改记录失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
@@ -246,16 +318,17 @@ func (s *EssayService) DownloadEvaluate(ctx context.Context, req *show.DownloadE
 
 	user, err := s.UserMapper.FindOne(ctx, meta.GetUserId())
 	if err != nil {
-		logx.Error("获取用户信息失败: %v", err)
+		logx.CtxError(ctx, "获取用户信息失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
-	exportResult, err := stateless.BuildExportEvaluateData(l.Response, req.GetExcludeOptions())
+	exportResult, err := stateless.BuildExportEvaluateData(l.Response, req.ExcludeOptions)
 	if err != nil {
-		logx.Error("解析批改结果失败: %v", err)
+		logx.CtxError(ctx, "解析批改结果失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
+	watermark, watermarkText := downloadWatermark(user, true)
 	downloadData := map[string]any{
 		"essay_list": []map[string]any{
 			{
@@ -263,31 +336,29 @@ func (s *EssayService) DownloadEvaluate(ctx context.Context, req *show.DownloadE
 				"user_id": user.Username,
 			},
 		},
-		"watermark": true,
+		"watermark":      watermark,
+		"watermark_text": watermarkText,
 	}
 
 	// 调用下游API生成下载链接
-	client := util.GetHttpClient()
-	_resp, err := client.EssayPolish(ctx, downloadData)
-	if err != nil {
-		logx.Error("调用批改结果下载服务失败: %v", err)
-		return nil, consts.ErrCall
-	}
-
-	// 检查下游响应
-	code := int64(_resp["code"].(float64))
-	if code != 200 {
-		msg := _resp["msg"].(string)
-		logx.Error("批改结果下载服务返回错误: %s, exportResult: %s", msg, exportResult.ToJson())
-		return nil, consts.ErrCall
+	var _resp map[string]any
+	if format == consts.ExportFormatDocx {
+		_resp, err = s.Downstream.EssayPolishDocx(ctx, downloadData)
+	} else {
+		_resp, err = s.Downstream.EssayPolish(ctx, downloadData)
 	}
-
-	url, urlOk := _resp["signedUrl"].(string)
-	sessionToken, tokenOk := _resp["sessionToken"].(string)
-
-	if !urlOk || !tokenOk {
-		logx.Error("下游返回的url或sessionToken字段格式错误")
-		return nil, consts.ErrCall
+	url, sessionToken, err := downloadUrlFromResp(_resp, err)
+	if err != nil {
+		logx.CtxError(ctx, "批改结果下载服务失败: %v, exportResult: %s", err, exportResult.ToJson())
+		if format != consts.ExportFormatPdf {
+			return nil, consts.ErrCall
+		}
+		// 下游不可用时本地渲染简版 PDF 兜底
+		url, sessionToken, err = exportPdfLocally(ctx, s.Downstream, meta.GetUserId(), []export.Essay{{Name: user.Username, Data: exportResult}})
+		if err != nil {
+			logx.CtxError(ctx, "本地渲染批改结果失败: %v", err)
+			return nil, consts.ErrCall
+		}
 	}
 
 	// 构造响应结果
@@ -298,22 +369,33 @@ func (s *EssayService) DownloadEvaluate(ctx context.Context, req *show.DownloadE
 
 	// 将结果存入缓存
 	// if err := s.DownloadCacheMapper.Set(ctx, req.Id, result); err != nil {
-	// 	logx.Error("存储缓存失败: %v", err)
+	// 	logx.CtxError(ctx, "存储缓存失败: %v", err)
 	// 	// 缓存失败不影响正常返回结果
 	// } else {
-	// 	logx.Info("成功缓存下载链接, id: %s, 缓存时间: 1小时", req.Id)
+	// 	logx.CtxInfo(ctx, "成功缓存下载链接, id: %s, 缓存时间: 1小时", req.Id)
 	// }
 
 	return result, nil
 }
 
+// exportFormat 校验批改结果的导出格式，不传时为 pdf
+func exportFormat(format string) (string, bool) {
+	switch format {
+	case "":
+		return consts.ExportFormatPdf, true
+	case consts.ExportFormatPdf, consts.ExportFormatDocx:
+		return format, true
+	default:
+		return "", false
+	}
+}
+
 // APIEssayEvaluateStreamV1 API网关专用流式批改作文接口
-func (s *EssayService) APIEssayEvaluateStreamV1(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan<- string) error {
+func (s *EssayService) APIEssayEvaluateStreamV1(ctx context.Context, req *show.EssayEvaluateReq, resultChan chan string) error {
 	downstreamChan := make(chan string, 100)
 	var finalResult string
 	go func() {
 		defer close(downstreamChan)
-		client := util.GetHttpClient()
 
 		// 准备分项打分比例（自动分配：总分除以3）
 		var ratio *util.ScoreRatio
@@ -327,14 +409,14 @@ func (s *EssayService) APIEssayEvaluateStreamV1(ctx context.Context, req *show.E
 		}
 
 		// 参数: title, text, grade, totalScore, essayType, prompt, standard, ratio, resultChan
-		client.EvaluateStream(ctx, req.Title, req.Text, req.Grade, nil, req.EssayType, req.Description, nil, ratio, downstreamChan)
+		s.Downstream.EvaluateStream(ctx, req.Title, req.Text, req.Grade, nil, req.EssayType, req.Description, nil, ratio, nil, downstreamChan)
 	}()
 
 	for jsonMessage := range downstreamChan {
 		// 对每条流式消息进行校验和过滤
 		validatedMessage, jump, err := s.validateAndFilterStreamMessage(jsonMessage)
 		if err != nil {
-			logx.Error("流式消息校验失败: %v, 原始消息: %s", err, jsonMessage)
+			logx.CtxError(ctx, "流式消息校验失败: %v, 原始消息: %s", err, jsonMessage)
 			continue
 		}
 		if jump {
@@ -343,7 +425,7 @@ func (s *EssayService) APIEssayEvaluateStreamV1(ctx context.Context, req *show.E
 
 		var data map[string]any
 		if parseErr := json.Unmarshal([]byte(validatedMessage), &data); parseErr != nil {
-			logx.Error("解析校验后的JSON消息失败: %v, validatedMessage:%s", parseErr, validatedMessage)
+			logx.CtxError(ctx, "解析校验后的JSON消息失败: %v, validatedMessage:%s", parseErr, validatedMessage)
 			continue
 		}
 
@@ -383,6 +465,31 @@ exitLoop:
 	return nil
 }
 
+// APIEssayEvaluateV2 API网关专用同步批改作文接口，在服务端消费 v1 的流式结果，批改完成后一次返回
+func (s *EssayService) APIEssayEvaluateV2(ctx context.Context, req *show.EssayEvaluateReq) (*show.APIEssayEvaluateV2Resp, error) {
+	ctx, cancel := context.WithTimeout(ctx, consts.ApiV2EvaluateTimeout)
+	defer cancel()
+
+	data, err := collectStream(ctx, func(resultChan chan string) error {
+		return s.APIEssayEvaluateStreamV1(ctx, req, resultChan)
+	})
+	if ctx.Err() == context.DeadlineExceeded {
+		logx.CtxError(ctx, "[API-V2] 批改超时, timeout: %s", consts.ApiV2EvaluateTimeout)
+		return nil, consts.ErrApiEvaluateTimeout
+	}
+	var result struct {
+		Response string `json:"response"`
+	}
+	if err == nil {
+		err = json.Unmarshal(data, &result)
+	}
+	if err != nil || result.Response == "" {
+		logx.CtxError(ctx, "[API-V2] 批改失败, err: %v", err)
+		return nil, consts.ErrCall
+	}
+	return &show.APIEssayEvaluateV2Resp{Result: json.RawMessage(result.Response)}, nil
+}
+
 // validateAndFilterStreamMessage 校验并过滤流式消息，确保每条消息都符合API网关的数据结构
 func (s *EssayService) validateAndFilterStreamMessage(messageJSON string) (string, bool, error) {
 	var rawMessage map[string]any
@@ -438,7 +545,7 @@ func (s *EssayService) EvaluateModify(ctx context.Context, req *show.EvaluateMod
 
 	l, err := s.LogMapper.FindOne(ctx, req.Id)
 	if err != nil {
-		logx.Error("查询批改记录失败: %v", err)
+		logx.CtxError(ctx, "查询批改记录失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
@@ -448,7 +555,7 @@ func (s *EssayService) EvaluateModify(ctx context.Context, req *show.EvaluateMod
 
 	var evaluateResult stateless.Evaluate
 	if err := json.Unmarshal([]byte(l.Response), &evaluateResult); err != nil {
-		logx.Error("解析批改结果失败: %v", err)
+		logx.CtxError(ctx, "解析批改结果失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
@@ -518,23 +625,98 @@ func (s *EssayService) EvaluateModify(ctx context.Context, req *show.EvaluateMod
 
 	modifiedResponse, err := json.Marshal(evaluateResult)
 	if err != nil {
-		logx.Error("序列化修改后的批改结果失败: %v", err)
+		logx.CtxError(ctx, "序列化修改后的批改结果失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
+	before := l.Response
 	l.Response = string(modifiedResponse)
 	if err := s.LogMapper.Update(ctx, l); err != nil {
-		logx.Error("更新批改记录失败: %v", err)
+		logx.CtxError(ctx, "更新批改记录失败: %v", err)
 		return nil, consts.ErrCall
 	}
-
-	logx.Info("批改记录修改成功，ID: %s", req.Id)
+	// 结果已修改，旧的下载链接不能再命中
+	if err := s.DownloadCacheMapper.Delete(ctx, req.Id); err != nil {
+		logx.CtxError(ctx, "删除下载缓存失败, id: %s, err: %v", req.Id, err)
+	}
+
+	s.Audit.Record(ctx, meta.GetUserId(), consts.AuditActionModifyEvaluate, req.Id, "修改个人作文批改结果")
+	s.Audit.RecordRevision(ctx, meta.GetUserId(), consts.RevisionTargetLog, req.Id, before, l.Response)
+	s.Audit.RecordVersion(ctx, before, &audit.Version{
+		TargetType: consts.RevisionTargetLog,
+		TargetID:   req.Id,
+		Source:     consts.VersionSourceModify,
+		OperatorID: meta.GetUserId(),
+		Response:   l.Response,
+	})
+	logx.CtxInfo(ctx, "批改记录修改成功，ID: %s", req.Id)
 	return &show.Response{
 		Code: 0,
 		Msg:  "修改成功",
 	}, nil
 }
 
+// ListEvaluateVersions 查询个人作文批改结果的历史版本
+func (s *EssayService) ListEvaluateVersions(ctx context.Context, req *show.ListEvaluateVersionsReq) (*show.ListEvaluateVersionsResp, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	l, err := s.LogMapper.FindOne(ctx, req.Id)
+	if err != nil || l.UserId != meta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	versions, total, err := s.VersionMapper.FindByTarget(ctx, consts.RevisionTargetLog, req.Id, req.PaginationOptions)
+	if err != nil {
+		logx.CtxError(ctx, "查询批改版本失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrCall
+	}
+	return &show.ListEvaluateVersionsResp{Code: 0, Msg: "success", Versions: toEvaluateVersions(versions), Total: total}, nil
+}
+
+// RollbackEvaluate 把个人作文批改结果恢复到指定的历史版本，恢复本身也作为新版本保存，之后仍可再回滚
+func (s *EssayService) RollbackEvaluate(ctx context.Context, req *show.RollbackEvaluateReq) (*show.Response, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	l, err := s.LogMapper.FindOne(ctx, req.Id)
+	if err != nil || l.UserId != meta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	v, err := s.VersionMapper.FindOne(ctx, consts.RevisionTargetLog, req.Id, req.Version)
+	if err != nil {
+		return nil, consts.InvalidParams("version", "版本不存在")
+	}
+
+	before := l.Response
+	l.Response = v.Response
+	l.Status = 1
+	if err := s.LogMapper.Update(ctx, l); err != nil {
+		logx.CtxError(ctx, "回滚批改记录失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	if err := s.DownloadCacheMapper.Delete(ctx, req.Id); err != nil {
+		logx.CtxError(ctx, "删除下载缓存失败, id: %s, err: %v", req.Id, err)
+	}
+
+	s.Audit.Record(ctx, meta.GetUserId(), consts.AuditActionRollbackEvaluate, req.Id, fmt.Sprintf("个人作文批改结果回滚到第 %d 版", req.Version))
+	s.Audit.RecordRevision(ctx, meta.GetUserId(), consts.RevisionTargetLog, req.Id, before, l.Response)
+	s.Audit.RecordVersion(ctx, before, &audit.Version{
+		TargetType:   consts.RevisionTargetLog,
+		TargetID:     req.Id,
+		Source:       consts.VersionSourceRollback,
+		OperatorID:   meta.GetUserId(),
+		RollbackFrom: req.Version,
+		Response:     l.Response,
+	})
+	return &show.Response{Code: 0, Msg: "回滚成功"}, nil
+}
+
 func (s *EssayService) DeleteEvaluate(ctx context.Context, req *show.DeleteEvaluateReq) (resp *show.Response, err error) {
 	meta := adaptor.ExtractUserMeta(ctx)
 	if meta.GetUserId() == "" {
@@ -543,20 +725,21 @@ func (s *EssayService) DeleteEvaluate(ctx context.Context, req *show.DeleteEvalu
 
 	l, err := s.LogMapper.FindOne(ctx, req.Id)
 	if err != nil {
-		logx.Error("查询批改记录失败: %v", err)
+		logx.CtxError(ctx, "查询批改记录失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	if l.UserId != meta.GetUserId() {
-		logx.Error("用户无权删除此批改记录, userId: %s, logUserId: %s", meta.GetUserId(), l.UserId)
+		logx.CtxError(ctx, "用户无权删除此批改记录, userId: %s, logUserId: %s", meta.GetUserId(), l.UserId)
 		return nil, consts.ErrNotFound
 	}
 
 	err = s.LogMapper.Delete(ctx, req.Id)
 	if err != nil {
-		logx.Error("删除批改记录失败: %v", err)
+		logx.CtxError(ctx, "删除批改记录失败: %v", err)
 		return nil, consts.ErrCall
 	}
+	s.Audit.Record(ctx, meta.GetUserId(), consts.AuditActionDeleteEvaluate, req.Id, "删除个人作文批改记录")
 
 	return &show.Response{
 		Code: 0,
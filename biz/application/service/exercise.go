@@ -3,34 +3,52 @@ package service
 import (
 	"errors"
 	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/basic"
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/class"
 	"essay-show/biz/infrastructure/repository/exercise"
 	"essay-show/biz/infrastructure/repository/log"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
 	eu "essay-show/biz/infrastructure/util/exercise"
 	logx "essay-show/biz/infrastructure/util/log"
+	"math"
 	"time"
 
 	"github.com/google/wire"
 	"github.com/jinzhu/copier"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/net/context"
 )
 
 type IExerciseService interface {
-	CreateExercise(ctx context.Context, req *show.CreateExerciseReq) (resp *show.CreateExerciseResp, err error)
-	CreateExerciseStream(ctx context.Context, req *show.CreateExerciseReq, resultChan chan<- string) error
+	CreateExercise(ctx context.Context, req *show.GenerateExerciseReq) (resp *show.CreateExerciseResp, err error)
+	CreateExerciseStream(ctx context.Context, req *show.GenerateExerciseReq, resultChan chan string) error
 	ListSimpleExercises(ctx context.Context, req *show.ListSimpleExercisesReq) (resp *show.ListSimpleExercisesResp, err error)
-	GetExercise(ctx context.Context, req *show.GetExerciseReq) (resp *show.GetExerciseResp, err error)
-	DoExercise(ctx context.Context, req *show.DoExerciseReq) (resp *show.DoExerciseResp, err error)
+	GetExercise(ctx context.Context, req *show.GetExerciseReq) (resp *show.GetExerciseDetailResp, err error)
+	DoExercise(ctx context.Context, req *show.DoExerciseAttemptReq) (resp *show.DoExerciseAttemptResp, err error)
 	LikeExercise(ctx context.Context, req *show.LikeExerciseReq) (resp *show.Response, err error)
+	ListExercises(ctx context.Context, req *show.ListExercisesReq) (resp *show.ListExercisesResp, err error)
+	GetExerciseHistory(ctx context.Context, req *show.GetExerciseHistoryReq) (resp *show.GetExerciseHistoryResp, err error)
+	AssignExerciseToClass(ctx context.Context, req *show.AssignExerciseToClassReq) (resp *show.AssignExerciseToClassResp, err error)
+	ListPendingExercises(ctx context.Context, req *show.ListPendingExercisesReq) (resp *show.ListExercisesResp, err error)
+	GetAssignmentProgress(ctx context.Context, req *show.GetAssignmentProgressReq) (resp *show.GetAssignmentProgressResp, err error)
+	CreateWeaknessExercise(ctx context.Context, req *show.CreateWeaknessExerciseReq) (resp *show.CreateWeaknessExerciseResp, err error)
+	GetExerciseStats(ctx context.Context, req *show.GetExerciseStatsReq) (resp *show.GetExerciseStatsResp, err error)
 }
 
 type ExerciseService struct {
-	ExerciseMapper *exercise.MongoMapper
-	LogMapper      *log.MongoMapper
-	UserMapper     *user.MongoMapper
+	ExerciseMapper   *exercise.MongoMapper
+	LogMapper        *log.MongoMapper
+	UserMapper       *user.MongoMapper
+	ClassMapper      *class.MongoMapper
+	MemberMapper     *class.MemberMongoMapper
+	AssignmentMapper *exercise.AssignmentMongoMapper
+	Guard            *Guard
+	ShortAnswerLimit *cache.ShortAnswerLimiter
+	Downstream       util.DownstreamClient
 }
 
 var ExerciseServiceSet = wire.NewSet(
@@ -39,11 +57,15 @@ var ExerciseServiceSet = wire.NewSet(
 )
 
 // CreateExercise 创建一套练习
-func (s ExerciseService) CreateExercise(ctx context.Context, req *show.CreateExerciseReq) (resp *show.CreateExerciseResp, err error) {
+func (s ExerciseService) CreateExercise(ctx context.Context, req *show.GenerateExerciseReq) (resp *show.CreateExerciseResp, err error) {
+	if !eu.ValidDifficulty(req.Difficulty) {
+		return nil, consts.ErrInvalidDifficulty
+	}
+
 	// 获取批改记录
 	l, err := s.LogMapper.FindOne(ctx, req.LogId)
 	if err != nil {
-		logx.Error("获取批改记录失败, err:%v", err.Error())
+		logx.CtxError(ctx, "获取批改记录失败, err:%v", err.Error())
 		return nil, consts.ErrInvalidObjectId
 	}
 
@@ -54,23 +76,24 @@ func (s ExerciseService) CreateExercise(ctx context.Context, req *show.CreateExe
 	}
 	u, err := s.UserMapper.FindOne(ctx, userMeta.UserId)
 	if err != nil {
-		logx.Error("获取用户信息失败, err:%v", err.Error())
+		logx.CtxError(ctx, "获取用户信息失败, err:%v", err.Error())
 		return nil, consts.ErrNotAuthentication
 	}
 
 	// 调用生成接口
-	e, err := eu.GenerateExercise(ctx, u.Grade, l)
+	e, err := eu.GenerateExercise(ctx, s.Downstream, u.Grade, req.Difficulty, l)
 	if err != nil {
-		logx.Error("生成练习失败, err:%v", err.Error())
+		logx.CtxError(ctx, "生成练习失败, err:%v", err.Error())
 		return nil, consts.ErrCreateExercise
 	}
 
 	// 存储练习
 	e.LogId = req.LogId
 	e.UserId = userMeta.UserId
+	e.Difficulty = req.Difficulty
 	err = s.ExerciseMapper.Insert(ctx, e)
 	if err != nil {
-		logx.Error("存储练习失败, err:%v", err.Error())
+		logx.CtxError(ctx, "存储练习失败, err:%v", err.Error())
 		return nil, consts.ErrCreateExercise
 	}
 
@@ -133,9 +156,9 @@ func (s ExerciseService) ListSimpleExercises(ctx context.Context, req *show.List
 			dto.FinishTime = lastRecord.CreateTime.Unix()
 		} else {
 			// 无作答记录则均用-1占位
-			for _, cq := range v.Question.ChoiceQuestions {
+			for _, id := range questionIds(v.Question) {
 				records = append(records, &show.ListSimpleExercisesResp_Record{
-					Id:    cq.Id,
+					Id:    id,
 					Score: -1,
 				})
 			}
@@ -153,8 +176,412 @@ func (s ExerciseService) ListSimpleExercises(ctx context.Context, req *show.List
 
 }
 
+// ListExercises 获取我生成过的练习，按创建时间倒序
+func (s ExerciseService) ListExercises(ctx context.Context, req *show.ListExercisesReq) (*show.ListExercisesResp, error) {
+	// 获取用户信息
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	data, total, err := s.ExerciseMapper.FindManyByUserId(ctx, userMeta.GetUserId(), req.LogId, req.Difficulty, req.PaginationOptions)
+	if err != nil {
+		logx.CtxError(ctx, "查询练习列表失败, err:%v", err.Error())
+		return nil, consts.ErrNotFound
+	}
+
+	dtos := make([]*show.ExerciseSummary, 0, len(data))
+	for _, v := range data {
+		dtos = append(dtos, toExerciseSummary(v))
+	}
+
+	return &show.ListExercisesResp{
+		Code:      0,
+		Msg:       "success",
+		Exercises: dtos,
+		Total:     total,
+	}, nil
+}
+
+func toExerciseSummary(v *exercise.Exercise) *show.ExerciseSummary {
+	dto := &show.ExerciseSummary{
+		Id:           v.ID.Hex(),
+		LogId:        v.LogId,
+		AssignmentId: v.AssignmentID,
+		Difficulty:   v.Difficulty,
+		Score:        -1,
+		Like:         v.Like,
+		CreateTime:   v.CreateTime.Unix(),
+	}
+	if v.Question != nil {
+		dto.QuestionCount = int64(len(questionIds(v.Question)))
+		dto.FullScore = fullScore(v.Question)
+	}
+	// 有作答记录时取最后一次
+	if v.History != nil && len(v.History.Records) > 0 {
+		last := v.History.Records[len(v.History.Records)-1]
+		dto.Finished = true
+		dto.Score = last.Score
+		dto.AttemptCount = int64(len(v.History.Records))
+		dto.FinishTime = last.CreateTime.Unix()
+	}
+	return dto
+}
+
+// AssignExerciseToClass 把自己的一套练习布置给班级，为每个已绑定账号的学生生成一份练习实例
+func (s ExerciseService) AssignExerciseToClass(ctx context.Context, req *show.AssignExerciseToClassReq) (*show.AssignExerciseToClassResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+	c, err := s.Guard.ClassOwner(ctx, userMeta.GetUserId(), req.ClassId)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := s.ExerciseMapper.FindOneById(ctx, req.ExerciseId)
+	if err != nil || src.UserId != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	members, _, err := s.MemberMapper.FindByClassID(ctx, req.ClassId, 1, max(c.MemberCount, 1))
+	if err != nil {
+		logx.CtxError(ctx, "获取班级成员失败, classId:%s, err:%v", req.ClassId, err.Error())
+		return nil, consts.ErrCall
+	}
+
+	a := &exercise.Assignment{
+		ID:        primitive.NewObjectID(),
+		SourceID:  req.ExerciseId,
+		ClassID:   req.ClassId,
+		TeacherID: userMeta.GetUserId(),
+	}
+	es := make([]*exercise.Exercise, 0, len(members))
+	for _, m := range members {
+		// 未绑定账号的学生无法作答
+		if m.UserID == nil || *m.UserID == "" {
+			continue
+		}
+		es = append(es, &exercise.Exercise{
+			UserId:       *m.UserID,
+			LogId:        src.LogId,
+			Question:     src.Question,
+			History:      &exercise.History{Records: make([]*exercise.Records, 0)},
+			AssignmentID: a.ID.Hex(),
+			Difficulty:   src.Difficulty,
+		})
+	}
+	if len(es) == 0 {
+		return nil, consts.ErrNoBoundStudent
+	}
+
+	if err = s.ExerciseMapper.InsertMany(ctx, es); err != nil {
+		logx.CtxError(ctx, "生成班级练习失败, classId:%s, err:%v", req.ClassId, err.Error())
+		return nil, consts.ErrCreateExercise
+	}
+	a.StudentCount = int64(len(es))
+	a.CreateTime = time.Now()
+	if err = s.AssignmentMapper.Insert(ctx, a); err != nil {
+		logx.CtxError(ctx, "保存练习布置记录失败, classId:%s, err:%v", req.ClassId, err.Error())
+		return nil, consts.ErrCreateExercise
+	}
+
+	return &show.AssignExerciseToClassResp{
+		Code:         0,
+		Msg:          "布置成功",
+		AssignmentId: a.ID.Hex(),
+		StudentCount: a.StudentCount,
+		SkippedCount: int64(len(members)) - a.StudentCount,
+	}, nil
+}
+
+// ListPendingExercises 学生查看老师布置且尚未完成的练习
+func (s ExerciseService) ListPendingExercises(ctx context.Context, req *show.ListPendingExercisesReq) (*show.ListExercisesResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	data, total, err := s.ExerciseMapper.FindPendingAssigned(ctx, userMeta.GetUserId(), req.PaginationOptions)
+	if err != nil {
+		logx.CtxError(ctx, "查询待完成练习失败, err:%v", err.Error())
+		return nil, consts.ErrNotFound
+	}
+
+	dtos := make([]*show.ExerciseSummary, 0, len(data))
+	for _, v := range data {
+		dtos = append(dtos, toExerciseSummary(v))
+	}
+	return &show.ListExercisesResp{
+		Code:      0,
+		Msg:       "success",
+		Exercises: dtos,
+		Total:     total,
+	}, nil
+}
+
+// GetAssignmentProgress 教师查看一次练习布置的完成情况
+func (s ExerciseService) GetAssignmentProgress(ctx context.Context, req *show.GetAssignmentProgressReq) (*show.GetAssignmentProgressResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	a, err := s.AssignmentMapper.FindOne(ctx, req.AssignmentId)
+	if err != nil {
+		return nil, err
+	}
+	if a.TeacherID != userMeta.GetUserId() {
+		return nil, consts.ErrForbidden
+	}
+
+	es, err := s.ExerciseMapper.FindByAssignment(ctx, req.AssignmentId)
+	if err != nil {
+		logx.CtxError(ctx, "查询布置练习失败, assignmentId:%s, err:%v", req.AssignmentId, err.Error())
+		return nil, consts.ErrCall
+	}
+
+	resp := &show.GetAssignmentProgressResp{
+		Code:         0,
+		Msg:          "success",
+		StudentCount: int64(len(es)),
+		Students:     make([]*show.AssignmentStudent, 0, len(es)),
+	}
+	for _, e := range es {
+		summary := toExerciseSummary(e)
+		st := &show.AssignmentStudent{
+			UserId:     e.UserId,
+			ExerciseId: summary.Id,
+			Finished:   summary.Finished,
+			Score:      summary.Score,
+			FinishTime: summary.FinishTime,
+		}
+		if m, err := s.MemberMapper.FindByClassIDAndStuID(ctx, a.ClassID, e.UserId); err == nil {
+			st.Name = m.Name
+		}
+		if st.Finished {
+			resp.FinishedCount++
+		}
+		resp.Students = append(resp.Students, st)
+	}
+	if resp.StudentCount > 0 {
+		resp.CompletionRate = math.Round(float64(resp.FinishedCount)/float64(resp.StudentCount)*10000) / 100
+	}
+	return resp, nil
+}
+
+// CreateWeaknessExercise 聚合学生最近几篇作文的共性问题，生成针对性练习
+func (s ExerciseService) CreateWeaknessExercise(ctx context.Context, req *show.CreateWeaknessExerciseReq) (*show.CreateWeaknessExerciseResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if !eu.ValidDifficulty(req.Difficulty) {
+		return nil, consts.ErrInvalidDifficulty
+	}
+
+	studentId := userMeta.GetUserId()
+	if req.UserId != nil && *req.UserId != "" && *req.UserId != studentId {
+		if err := s.checkTeacherOf(ctx, userMeta.GetUserId(), *req.UserId); err != nil {
+			return nil, err
+		}
+		studentId = *req.UserId
+	}
+	student, err := s.UserMapper.FindOne(ctx, studentId)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	n := int64(consts.WeaknessEssayCount)
+	if req.EssayCount != nil && *req.EssayCount > 0 {
+		n = min(*req.EssayCount, consts.WeaknessEssayMaxCount)
+	}
+	page := int64(1)
+	logs, _, err := s.LogMapper.FindMany(ctx, studentId, &basic.PaginationOptions{Page: &page, Limit: &n})
+	if err != nil {
+		logx.CtxError(ctx, "获取批改记录失败, userId:%s, err:%v", studentId, err.Error())
+		return nil, consts.ErrNotFound
+	}
+	if len(logs) == 0 {
+		return nil, consts.ErrNoEvaluateLog
+	}
+
+	w := eu.AnalyzeWeakness(logs)
+	e, err := eu.GenerateExerciseByWeakness(ctx, s.Downstream, student.Grade, req.Difficulty, logs[0], w)
+	if err != nil {
+		logx.CtxError(ctx, "生成薄弱点练习失败, userId:%s, err:%v", studentId, err.Error())
+		return nil, consts.ErrCreateExercise
+	}
+
+	// 练习归属学生，以最近一篇作文作为来源批改记录
+	e.UserId = studentId
+	e.LogId = logs[0].ID.Hex()
+	e.Difficulty = req.Difficulty
+	for _, l := range logs {
+		e.SourceLogIds = append(e.SourceLogIds, l.ID.Hex())
+	}
+	if err = s.ExerciseMapper.Insert(ctx, e); err != nil {
+		logx.CtxError(ctx, "存储练习失败, err:%v", err.Error())
+		return nil, consts.ErrCreateExercise
+	}
+
+	dto := &show.Exercise{}
+	if err = copier.Copy(dto, e); err != nil {
+		return nil, err
+	}
+	dto.Id = e.ID.Hex()
+	dto.CreateTime = e.CreateTime.Unix()
+	dto.UpdateTime = e.CreateTime.Unix()
+
+	wDto := &show.Weakness{
+		EssayCount:        int64(w.EssayCount),
+		GrammarMistakeNum: int64(w.GrammarMistakeNum),
+		WrittenMistakeNum: int64(w.WrittenMistakeNum),
+		MistakeTypes:      make(map[string]int64, len(w.MistakeTypes)),
+		WrongWords:        make([]*show.WrongWord, 0, len(w.WrongWords)),
+	}
+	for k, v := range w.MistakeTypes {
+		wDto.MistakeTypes[k] = int64(v)
+	}
+	for _, ww := range w.WrongWords {
+		wDto.WrongWords = append(wDto.WrongWords, &show.WrongWord{Ori: ww.Ori, Revised: ww.Revised, Count: int64(ww.Count)})
+	}
+
+	return &show.CreateWeaknessExerciseResp{
+		Code:     0,
+		Msg:      "success",
+		Exercise: dto,
+		Weakness: wDto,
+	}, nil
+}
+
+// GetExerciseStats 按题型、知识点统计学生的正确率与练习次数，附最近 30 天趋势
+func (s ExerciseService) GetExerciseStats(ctx context.Context, req *show.GetExerciseStatsReq) (*show.GetExerciseStatsResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	es, err := s.ExerciseMapper.FindAnsweredByUserId(ctx, userMeta.GetUserId(), consts.ExerciseStatsMaxCount)
+	if err != nil {
+		logx.CtxError(ctx, "查询作答过的练习失败, userId:%s, err:%v", userMeta.GetUserId(), err.Error())
+		return nil, consts.ErrNotFound
+	}
+	st := eu.AnalyzeStats(es, time.Now(), consts.ExerciseStatsTrendDays)
+
+	resp := &show.GetExerciseStatsResp{
+		Code:          0,
+		Msg:           "success",
+		ExerciseCount: st.ExerciseCount,
+		AttemptCount:  st.AttemptCount,
+		AnswerCount:   st.AnswerCount,
+		Accuracy:      accuracy(st.CorrectCount, st.AnswerCount),
+		QuestionTypes: toStatItems(st.QuestionTypes),
+		Knowledges:    toStatItems(st.Knowledges),
+		Trend:         make([]*show.ExerciseDailyStat, 0, len(st.Trend)),
+	}
+	for _, d := range st.Trend {
+		resp.Trend = append(resp.Trend, &show.ExerciseDailyStat{
+			Date:         d.Date.Unix(),
+			AttemptCount: d.AttemptCount,
+			AnswerCount:  d.AnswerCount,
+			Accuracy:     accuracy(d.CorrectCount, d.AnswerCount),
+		})
+	}
+	return resp, nil
+}
+
+func toStatItems(items []*eu.StatItem) []*show.ExerciseStatItem {
+	dtos := make([]*show.ExerciseStatItem, 0, len(items))
+	for _, item := range items {
+		dtos = append(dtos, &show.ExerciseStatItem{
+			Name:         item.Name,
+			AnswerCount:  item.AnswerCount,
+			CorrectCount: item.CorrectCount,
+			Accuracy:     accuracy(item.CorrectCount, item.AnswerCount),
+		})
+	}
+	return dtos
+}
+
+// accuracy 百分比正确率，保留两位小数
+func accuracy(correct, total int64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return math.Round(float64(correct)/float64(total)*10000) / 100
+}
+
+// checkTeacherOf 校验 teacherId 是否为 studentId 所在某个班级的创建者
+func (s ExerciseService) checkTeacherOf(ctx context.Context, teacherId, studentId string) error {
+	members, _, err := s.MemberMapper.FindByStuID(ctx, studentId)
+	if err != nil {
+		return consts.ErrForbidden
+	}
+	for _, m := range members {
+		c, err := s.ClassMapper.FindOne(ctx, m.ClassID)
+		if err == nil && c.CreatorID == teacherId {
+			return nil
+		}
+	}
+	return consts.ErrForbidden
+}
+
+// fullScore 每道题的满分累加，选择题取最高分选项
+func fullScore(q *exercise.Question) int64 {
+	var sum int64
+	for _, s := range questionFullScores(q) {
+		sum += s
+	}
+	return sum
+}
+
+// questionFullScores 题目 id 到该题满分的映射
+func questionFullScores(q *exercise.Question) map[string]int64 {
+	best := make(map[string]int64)
+	if q == nil {
+		return best
+	}
+	for _, cq := range q.ChoiceQuestions {
+		best[cq.Id] = 0
+		for _, o := range cq.Options {
+			best[cq.Id] = max(best[cq.Id], o.Score)
+		}
+	}
+	for _, fq := range q.FillQuestions {
+		best[fq.Id] = fq.Score
+	}
+	for _, sq := range q.ShortQuestions {
+		best[sq.Id] = sq.Score
+	}
+	return best
+}
+
+// questionIds 按选择题、填空题、简答题的顺序列出题目 id
+func questionIds(q *exercise.Question) []string {
+	ids := make([]string, 0)
+	if q == nil {
+		return ids
+	}
+	for _, cq := range q.ChoiceQuestions {
+		ids = append(ids, cq.Id)
+	}
+	for _, fq := range q.FillQuestions {
+		ids = append(ids, fq.Id)
+	}
+	for _, sq := range q.ShortQuestions {
+		ids = append(ids, sq.Id)
+	}
+	return ids
+}
+
 // GetExercise 获取一次练习的详细记录
-func (s ExerciseService) GetExercise(ctx context.Context, req *show.GetExerciseReq) (*show.GetExerciseResp, error) {
+func (s ExerciseService) GetExercise(ctx context.Context, req *show.GetExerciseReq) (*show.GetExerciseDetailResp, error) {
 	// 查询练习
 	e, err := s.ExerciseMapper.FindOneById(ctx, req.Id)
 	if err != nil {
@@ -181,6 +608,28 @@ func (s ExerciseService) GetExercise(ctx context.Context, req *show.GetExerciseR
 		cqs = append(cqs, cq)
 	}
 
+	// 处理填空题与简答题
+	fqs := make([]*show.FillQuestion, 0, len(e.Question.FillQuestions))
+	for _, v := range e.Question.FillQuestions {
+		fqs = append(fqs, &show.FillQuestion{
+			Id:          v.Id,
+			Question:    v.Question,
+			Explanation: v.Explanation,
+			Answers:     v.Answers,
+			Score:       v.Score,
+		})
+	}
+	sqs := make([]*show.ShortQuestion, 0, len(e.Question.ShortQuestions))
+	for _, v := range e.Question.ShortQuestions {
+		sqs = append(sqs, &show.ShortQuestion{
+			Id:          v.Id,
+			Question:    v.Question,
+			Explanation: v.Explanation,
+			Reference:   v.Reference,
+			Score:       v.Score,
+		})
+	}
+
 	// 处理答题记录
 	rds := make([]*show.Records, 0)
 	for _, v := range e.History.Records {
@@ -211,73 +660,104 @@ func (s ExerciseService) GetExercise(ctx context.Context, req *show.GetExerciseR
 		Status:     e.Status,
 	}
 
-	return &show.GetExerciseResp{
-		Code:     0,
-		Msg:      "success",
-		Exercise: dto,
+	return &show.GetExerciseDetailResp{
+		Code:           0,
+		Msg:            "success",
+		Exercise:       dto,
+		FillQuestions:  fqs,
+		ShortQuestions: sqs,
 	}, nil
 }
 
 // DoExercise 提交一次练习作答，目前是没有暂时记录的，需要完成所有的题目然后结算
-func (s ExerciseService) DoExercise(ctx context.Context, req *show.DoExerciseReq) (resp *show.DoExerciseResp, err error) {
+// 同一练习可以多次作答，每次作答追加一条记录
+func (s ExerciseService) DoExercise(ctx context.Context, req *show.DoExerciseAttemptReq) (resp *show.DoExerciseAttemptResp, err error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
 	e, err := s.ExerciseMapper.FindOneById(ctx, req.Id)
 	if err != nil {
 		return nil, consts.ErrNotFound
 	}
-
-	// 初始化
-	if e.History == nil {
-		rds := make([]*exercise.Records, 0)
-		e.History = &exercise.History{Records: rds}
+	// 只能作答自己的练习，布置给他人的练习按不存在处理
+	if e.UserId != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
 	}
 
 	// 用map存储题目id与题目
-	cqs := e.Question.ChoiceQuestions
 	qMap := make(map[string]*exercise.ChoiceQuestion)
-	for _, v := range cqs {
+	for _, v := range e.Question.ChoiceQuestions {
 		qMap[v.Id] = v
 	}
+	fMap := make(map[string]*exercise.FillQuestion)
+	for _, v := range e.Question.FillQuestions {
+		fMap[v.Id] = v
+	}
+	sMap := make(map[string]*exercise.ShortQuestion)
+	for _, v := range e.Question.ShortQuestions {
+		sMap[v.Id] = v
+	}
+
+	// 简答题需要调用下游判分，含简答题的作答占用一次当天的判分限额
+	for _, v := range req.Records {
+		if _, ok := sMap[v.Id]; !ok {
+			continue
+		}
+		if allowed, err := s.ShortAnswerLimit.Allow(ctx, userMeta.GetUserId()); err != nil {
+			logx.CtxError(ctx, "简答题判分限额检查失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		} else if !allowed {
+			return nil, consts.ErrShortAnswerQuota
+		}
+		break
+	}
 
-	// 做题记录
+	// 做题记录，按题型分别判分
 	rs := make([]*exercise.Record, 0)
 	var sum int64
 	for _, v := range req.Records {
-		// 根据id获取题目
+		var r *exercise.Record
 		if q, ok := qMap[v.Id]; ok {
-			var score int64
+			r = &exercise.Record{Id: q.Id, Option: v.Option}
 			for _, o := range q.Options {
 				if o.Option == v.Option {
-					score = o.Score
+					r.Score = o.Score
 				}
 			}
-			// 构造单题记录
-			r := &exercise.Record{
-				Id:     q.Id,
-				Option: v.Option,
-				Score:  score,
+		} else if q, ok := fMap[v.Id]; ok {
+			r = &exercise.Record{Id: q.Id, Answer: v.Answer, Score: eu.GradeFillAnswer(q, v.Answer)}
+		} else if q, ok := sMap[v.Id]; ok {
+			score, comment, err := eu.GradeShortAnswer(ctx, s.Downstream, q, v.Answer)
+			if err != nil {
+				logx.CtxError(ctx, "简答题判分失败, exerciseId:%s, questionId:%s, err:%v", req.Id, q.Id, err.Error())
+				return nil, consts.ErrGradeShortAnswer
 			}
-			sum += score
-			rs = append(rs, r)
+			r = &exercise.Record{Id: q.Id, Answer: v.Answer, Comment: comment, Score: score}
+		} else {
+			continue
 		}
+		sum += r.Score
+		rs = append(rs, r)
 	}
 	// 构造练习作答记录
 	rds := &exercise.Records{
 		Records:    rs,
 		Score:      sum,
+		Duration:   max(req.Duration, 0),
 		CreateTime: time.Now(),
 	}
 
-	// 更新记录
-	e.History.Records = append(e.History.Records, rds)
-	err = s.ExerciseMapper.Update(ctx, e)
+	// 追加记录
+	attempts, err := s.ExerciseMapper.PushRecords(ctx, e.ID, rds)
 	if err != nil {
-		logx.Error("更新练习记录失败")
+		logx.CtxError(ctx, "更新练习记录失败, exerciseId:%s, err:%v", req.Id, err)
 		return nil, consts.ErrDoExercise
 	}
 
-	// 将最新的记录返回
+	// 将本次的记录返回
 	rsDto := make([]*show.Record, 0)
-	for _, v := range e.History.Records[len(e.History.Records)-1].Records {
+	for _, v := range rds.Records {
 		rsDto = append(rsDto, &show.Record{
 			Id:     v.Id,
 			Option: v.Option,
@@ -289,14 +769,79 @@ func (s ExerciseService) DoExercise(ctx context.Context, req *show.DoExerciseReq
 		Score:      rds.Score,
 		CreateTime: rds.CreateTime.Unix(),
 	}
-	resp = &show.DoExerciseResp{
+	resp = &show.DoExerciseAttemptResp{
 		Code:    0,
 		Msg:     "success",
 		Records: dto,
+		Attempt: buildAttempt(e.Question, rds, attempts),
 	}
 	return
 }
 
+// GetExerciseHistory 获取一次练习的所有作答记录与正确率趋势
+func (s ExerciseService) GetExerciseHistory(ctx context.Context, req *show.GetExerciseHistoryReq) (*show.GetExerciseHistoryResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	e, err := s.ExerciseMapper.FindOneById(ctx, req.Id)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if e.UserId != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	resp := &show.GetExerciseHistoryResp{
+		Code:          0,
+		Msg:           "success",
+		Attempts:      make([]*show.ExerciseAttempt, 0),
+		AccuracyTrend: make([]float64, 0),
+	}
+	if e.History == nil {
+		return resp, nil
+	}
+	for i, rds := range e.History.Records {
+		attempt := buildAttempt(e.Question, rds, int64(i+1))
+		resp.Attempts = append(resp.Attempts, attempt)
+		resp.AccuracyTrend = append(resp.AccuracyTrend, attempt.Accuracy)
+		resp.BestScore = max(resp.BestScore, attempt.Score)
+	}
+	return resp, nil
+}
+
+// buildAttempt 统计一次作答的成绩，该题得满分视为答对
+func buildAttempt(q *exercise.Question, rds *exercise.Records, attempt int64) *show.ExerciseAttempt {
+	best := questionFullScores(q)
+
+	dto := &show.ExerciseAttempt{
+		Attempt:       attempt,
+		Records:       make([]*show.AttemptRecord, 0, len(rds.Records)),
+		Score:         rds.Score,
+		QuestionCount: int64(len(best)),
+		Duration:      rds.Duration,
+		CreateTime:    rds.CreateTime.Unix(),
+	}
+	if q != nil {
+		dto.FullScore = fullScore(q)
+	}
+	for _, r := range rds.Records {
+		dto.Records = append(dto.Records, &show.AttemptRecord{
+			Id:      r.Id,
+			Option:  r.Option,
+			Answer:  r.Answer,
+			Comment: r.Comment,
+			Score:   r.Score,
+		})
+		if b, ok := best[r.Id]; ok && b > 0 && r.Score == b {
+			dto.CorrectCount++
+		}
+	}
+	dto.Accuracy = accuracy(dto.CorrectCount, dto.QuestionCount)
+	return dto
+}
+
 // LikeExercise 点赞或点踩一个练习
 func (s ExerciseService) LikeExercise(ctx context.Context, req *show.LikeExerciseReq) (resp *show.Response, err error) {
 	// 查询练习
@@ -322,11 +867,16 @@ func (s ExerciseService) LikeExercise(ctx context.Context, req *show.LikeExercis
 }
 
 // CreateExerciseStream 流式创建练习
-func (s ExerciseService) CreateExerciseStream(ctx context.Context, req *show.CreateExerciseReq, resultChan chan<- string) error {
+func (s ExerciseService) CreateExerciseStream(ctx context.Context, req *show.GenerateExerciseReq, resultChan chan string) error {
+	if !eu.ValidDifficulty(req.Difficulty) {
+		util.SendStreamMessage(resultChan, util.STError, "练习难度不合法", nil)
+		return consts.ErrInvalidDifficulty
+	}
+
 	// 获取批改记录
 	l, err := s.LogMapper.FindOne(ctx, req.LogId)
 	if err != nil {
-		logx.Error("获取批改记录失败, err:%v", err.Error())
+		logx.CtxError(ctx, "获取批改记录失败, err:%v", err.Error())
 		util.SendStreamMessage(resultChan, util.STError, "获取批改记录失败", nil)
 		return consts.ErrInvalidObjectId
 	}
@@ -340,14 +890,14 @@ func (s ExerciseService) CreateExerciseStream(ctx context.Context, req *show.Cre
 
 	u, err := s.UserMapper.FindOne(ctx, userMeta.UserId)
 	if err != nil {
-		logx.Error("获取用户信息失败, err:%v", err.Error())
+		logx.CtxError(ctx, "获取用户信息失败, err:%v", err.Error())
 		util.SendStreamMessage(resultChan, util.STError, "获取用户信息失败", nil)
 		return consts.ErrNotAuthentication
 	}
 
-	e, err := eu.GenerateExerciseStream(ctx, u.Grade, l, resultChan)
+	e, err := eu.GenerateExerciseStream(ctx, s.Downstream, u.Grade, req.Difficulty, l, resultChan)
 	if err != nil {
-		logx.Error("生成练习失败, err:%v", err.Error())
+		logx.CtxError(ctx, "生成练习失败, err:%v", err.Error())
 		util.SendStreamMessage(resultChan, util.STError, "生成练习失败", nil)
 		return err
 	}
@@ -355,9 +905,10 @@ func (s ExerciseService) CreateExerciseStream(ctx context.Context, req *show.Cre
 	// 存储练习
 	e.LogId = req.LogId
 	e.UserId = userMeta.UserId
+	e.Difficulty = req.Difficulty
 	err = s.ExerciseMapper.Insert(ctx, e)
 	if err != nil {
-		logx.Error("存储练习失败, err:%v", err.Error())
+		logx.CtxError(ctx, "存储练习失败, err:%v", err.Error())
 		util.SendStreamMessage(resultChan, util.STError, "存储练习失败", nil)
 		return consts.ErrCreateExercise
 	}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/export"
+	"fmt"
+	"net/http"
+)
+
+const localExportPrefix = "export"
+
+// downloadWatermark 按用户的水印设置返回下载数据中的 (watermark, watermark_text)，
+// 用户未设置时是否加水印由 defaultOn 决定，水印文字默认为用户名
+func downloadWatermark(u *user.User, defaultOn bool) (bool, string) {
+	if u == nil {
+		return defaultOn, ""
+	}
+	if u.Watermark == nil {
+		return defaultOn, u.Username
+	}
+	if u.Watermark.Disabled {
+		return false, ""
+	}
+	if u.Watermark.Text == "" {
+		return true, u.Username
+	}
+	return true, u.Watermark.Text
+}
+
+// downloadUrlFromResp 解析下游导出服务的响应，返回 (url, sessionToken, error)
+func downloadUrlFromResp(resp map[string]any, err error) (string, string, error) {
+	if err != nil {
+		return "", "", fmt.Errorf("调用下载服务失败: %w", err)
+	}
+	if code, _ := resp["code"].(float64); code != 200 {
+		msg, _ := resp["msg"].(string)
+		return "", "", fmt.Errorf("下载服务返回错误: %s", msg)
+	}
+	url, urlOk := resp["signedUrl"].(string)
+	sessionToken, tokenOk := resp["sessionToken"].(string)
+	if !urlOk || !tokenOk {
+		return "", "", fmt.Errorf("下游返回的url或sessionToken字段格式错误")
+	}
+	return url, sessionToken, nil
+}
+
+// exportPdfLocally 本地渲染简版批改结果 PDF 并转存到用户的 cos 目录，返回与下游相同的 (url, sessionToken)
+func exportPdfLocally(ctx context.Context, client util.DownstreamClient, userId string, essays []export.Essay) (string, string, error) {
+	data, err := export.RenderEvaluatePdf(ctx, essays)
+	if err != nil {
+		return "", "", err
+	}
+	return putCosObject(ctx, client, userId, localExportPrefix, ".pdf", "application/pdf", data)
+}
+
+// putCosObject 把文件上传到用户 cos 目录下的 prefix 子目录，返回下载用的 (url, sessionToken)
+func putCosObject(ctx context.Context, client util.DownstreamClient, userId, prefix, suffix, contentType string, data []byte) (string, string, error) {
+	cred, err := genCosCredential(ctx, client, userId)
+	if err != nil {
+		return "", "", fmt.Errorf("申请cos临时密钥失败: %w", err)
+	}
+	key := cosObjectKey(userId, &prefix, suffix)
+	putUrl, err := genSignedUrl(ctx, client, cred, http.MethodPut, key, nil)
+	if err != nil {
+		return "", "", err
+	}
+	if err = client.PutObject(ctx, putUrl, contentType, data); err != nil {
+		return "", "", err
+	}
+	getUrl, err := genSignedUrl(ctx, client, cred, http.MethodGet, key, nil)
+	if err != nil {
+		return "", "", err
+	}
+	return getUrl, cred["sessionToken"].(string), nil
+}
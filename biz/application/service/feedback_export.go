@@ -0,0 +1,158 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"time"
+)
+
+const feedbackExportPrefix = "feedback"
+
+// feedbackSample 导出文件中的一条样本，不含用户、教师与记录 id，sampleId 为记录 id 的摘要，同一记录在多次导出中一致
+type feedbackSample struct {
+	SampleId   string               `json:"sampleId"`
+	TargetType string               `json:"targetType"` // submission: 作业提交, log: 个人作文批改
+	Original   json.RawMessage      `json:"original"`   // AI 原始批改结果
+	Final      json.RawMessage      `json:"final"`      // 人工修改后的最终结果
+	Changes    []*audit.FieldChange `json:"changes"`
+	Revisions  int64                `json:"revisions"` // 人工修改与回滚的次数
+	ModifyTime int64                `json:"modifyTime"`
+}
+
+// CreateFeedbackExport 提交教师修改数据的导出任务，把期间内被人工修改过的批改结果的原始版本、最终版本与字段差异
+// 匿名化后导出为 jsonl 文件存到对象存储，供算法侧迭代
+func (s *AdminService) CreateFeedbackExport(ctx context.Context, req *show.CreateFeedbackExportReq) (*show.CreateFeedbackExportResp, error) {
+	admin, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end := time.Unix(req.StartTime, 0), time.Unix(req.EndTime, 0)
+	if req.StartTime <= 0 || !end.After(start) || end.Sub(start) > consts.FeedbackExportMaxDays*24*time.Hour {
+		return nil, consts.ErrReportTimeRange
+	}
+
+	task := &audit.FeedbackExport{
+		CreatorID: admin.ID.Hex(),
+		StartTime: start,
+		EndTime:   end,
+		Status:    consts.ReportStatusGenerating,
+	}
+	if err = s.FeedbackExport.Insert(ctx, task); err != nil {
+		log.CtxError(ctx, "创建教师修改数据导出任务失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	s.Audit.Record(ctx, task.CreatorID, consts.AuditActionExportFeedback, task.ID.Hex(),
+		fmt.Sprintf("导出教师修改数据, %s ~ %s", start.Format(time.DateOnly), end.Format(time.DateOnly)))
+
+	go s.generateFeedbackExport(context.Background(), task)
+
+	return &show.CreateFeedbackExportResp{Code: 0, Msg: "导出任务已提交", Id: task.ID.Hex()}, nil
+}
+
+// GetFeedbackExport 查询教师修改数据导出任务的结果
+func (s *AdminService) GetFeedbackExport(ctx context.Context, req *show.GetFeedbackExportReq) (*show.GetFeedbackExportResp, error) {
+	if _, err := CurrentUser(ctx); err != nil {
+		return nil, err
+	}
+
+	task, err := s.FeedbackExport.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &show.GetFeedbackExportResp{
+		Code:         0,
+		Msg:          "success",
+		Status:       int64(task.Status),
+		Count:        task.Count,
+		Url:          task.Url,
+		SessionToken: task.SessionToken,
+		Message:      task.Message,
+	}, nil
+}
+
+func (s *AdminService) generateFeedbackExport(ctx context.Context, task *audit.FeedbackExport) {
+	data, count, err := s.buildFeedbackSamples(ctx, task.StartTime, task.EndTime)
+	if err == nil {
+		task.Url, task.SessionToken, err = putCosObject(ctx, s.Downstream, task.CreatorID, feedbackExportPrefix, ".jsonl", "application/x-ndjson", data)
+	}
+	if err != nil {
+		log.CtxError(ctx, "导出教师修改数据失败, taskId: %s, error: %v", task.ID.Hex(), err)
+		task.Status = consts.ReportStatusFailed
+		task.Message = err.Error()
+	} else {
+		task.Status = consts.ReportStatusDone
+		task.Count = count
+	}
+	if err = s.FeedbackExport.Update(ctx, task); err != nil {
+		log.CtxError(ctx, "保存教师修改数据导出结果失败, taskId: %s, error: %v", task.ID.Hex(), err)
+	}
+}
+
+// buildFeedbackSamples 期间内有修改的每条批改结果导出一行，取第 1 版与最新版比较，回滚到原始结果、没有差异的不导出；
+// 每行按访问日志的规则脱敏手机号与链接签名
+func (s *AdminService) buildFeedbackSamples(ctx context.Context, start, end time.Time) ([]byte, int64, error) {
+	modified, err := s.VersionMapper.FindModifiedBetween(ctx, start, end)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询批改版本失败: %w", err)
+	}
+
+	var (
+		buf   bytes.Buffer
+		count int64
+		seen  = make(map[string]bool)
+	)
+	for _, m := range modified {
+		key := m.TargetType + ":" + m.TargetID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		original, err := s.VersionMapper.FindOne(ctx, m.TargetType, m.TargetID, 1)
+		if err != nil {
+			log.CtxError(ctx, "查询批改原始版本失败, target: %s, error: %v", key, err)
+			continue
+		}
+		latest, err := s.VersionMapper.FindLatest(ctx, m.TargetType, m.TargetID)
+		if err != nil {
+			log.CtxError(ctx, "查询批改最新版本失败, target: %s, error: %v", key, err)
+			continue
+		}
+		if !json.Valid([]byte(original.Response)) || !json.Valid([]byte(latest.Response)) {
+			continue
+		}
+		changes := diffJSON(original.Response, latest.Response)
+		if len(changes) == 0 {
+			continue
+		}
+
+		digest := sha256.Sum256([]byte(key))
+		line, err := json.Marshal(&feedbackSample{
+			SampleId:   hex.EncodeToString(digest[:8]),
+			TargetType: m.TargetType,
+			Original:   json.RawMessage(original.Response),
+			Final:      json.RawMessage(latest.Response),
+			Changes:    changes,
+			Revisions:  latest.Version - 1,
+			ModifyTime: latest.CreateTime.Unix(),
+		})
+		if err != nil {
+			log.CtxError(ctx, "序列化导出样本失败, target: %s, error: %v", key, err)
+			continue
+		}
+		buf.WriteString(adaptor.MaskLogContent(string(line)))
+		buf.WriteByte('\n')
+		count++
+	}
+	return buf.Bytes(), count, nil
+}
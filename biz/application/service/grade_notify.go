@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+
+	"github.com/spf13/cast"
+)
+
+// notifyGradeDone 作业批改完成后通知学生，全班都批改完成时再给教师推送一次汇总，推送失败只记录日志
+func (s *HomeworkService) notifyGradeDone(ctx context.Context, submission *homework.HomeworkSubmission, member *class.ClassMember, hw *homework.Homework) {
+	conf := config.GetConfig().Notify
+	if !conf.GradeNotify {
+		return
+	}
+
+	if member.UserID != nil && *member.UserID != "" {
+		if student, err := s.UserMapper.FindOne(ctx, *member.UserID); err == nil {
+			s.Notifier.Notify(ctx, student, consts.NotifyEventGradeDone, notifyMessage{
+				Subject:    fmt.Sprintf("作业批改完成：%s", hw.Title),
+				Content:    fmt.Sprintf("你提交的作业「%s」已批改完成，得分 %s，请在小程序中查看批改结果。", hw.Title, submission.GradeResult),
+				TemplateId: conf.GradeTemplateId,
+				Data: map[string]string{
+					"thing1": hw.Title,
+					"thing2": "作业已批改完成，点击查看结果",
+				},
+				Page: fmt.Sprintf("%s?submissionId=%s", consts.GradeResultJumpPage, submission.ID.Hex()),
+			})
+		}
+	}
+
+	s.notifyClassGradeDone(ctx, hw, conf.ClassGradeTemplateId)
+}
+
+// notifyClassGradeDone 全班每个学生都有已批改的提交且没有批改中的提交时，给教师推送汇总通知，每份作业只推送一次
+func (s *HomeworkService) notifyClassGradeDone(ctx context.Context, hw *homework.Homework, templateId string) {
+	if !hw.GradeNotifyTime.IsZero() {
+		return
+	}
+	teacher, err := s.UserMapper.FindOne(ctx, hw.CreatorID)
+	if err != nil {
+		log.CtxError(ctx, "获取教师信息失败, userId: %s, error: %v", hw.CreatorID, err)
+		return
+	}
+	// 教师不接收或没有可用的微信模板时，不再统计全班批改进度
+	switch notifyChannel(teacher, consts.NotifyEventClassGradeDone) {
+	case consts.NotifyChannelNone:
+		return
+	case consts.NotifyChannelWechat:
+		if templateId == "" {
+			return
+		}
+	}
+
+	classInfo, err := s.ClassMapper.FindOne(ctx, hw.ClassID)
+	if err != nil {
+		log.CtxError(ctx, "获取班级信息失败, classId: %s, error: %v", hw.ClassID, err)
+		return
+	}
+	submissions, err := s.SubmissionMapper.FindAllByHomework(ctx, hw.ID.Hex(), nil)
+	if err != nil {
+		log.CtxError(ctx, "查询作业提交记录失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+		return
+	}
+
+	graded := make(map[string]bool)
+	var scores []float64
+	for _, sub := range submissions {
+		switch sub.Status {
+		case consts.StatusInitialized, consts.StatusGrading, consts.StatusPendingText:
+			return
+		case consts.StatusCompleted, consts.StatusModified:
+			// 提交记录按更新时间倒序，每个学生只统计最新一次
+			if graded[sub.MemberId] {
+				continue
+			}
+			graded[sub.MemberId] = true
+			if score, err := cast.ToFloat64E(sub.GradeResult); err == nil {
+				scores = append(scores, score)
+			}
+		}
+	}
+	if len(graded) == 0 || int64(len(graded)) < classInfo.MemberCount {
+		return
+	}
+
+	ok, err := s.HomeworkMapper.MarkGradeNotified(ctx, hw.ID)
+	if err != nil {
+		log.CtxError(ctx, "标记作业批改通知失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+		return
+	}
+	if !ok {
+		return
+	}
+
+	avg, maxScore, minScore := scoreSummary(scores)
+	summary := fmt.Sprintf("%d人已批改，平均分%v，最高%v，最低%v", len(graded), avg, maxScore, minScore)
+	s.Notifier.Notify(ctx, teacher, consts.NotifyEventClassGradeDone, notifyMessage{
+		Subject:    fmt.Sprintf("%s 全班批改完成：%s", classInfo.Name, hw.Title),
+		Content:    fmt.Sprintf("%s 的作业「%s」已全部批改完成，%s。", classInfo.Name, hw.Title, summary),
+		TemplateId: templateId,
+		Data: map[string]string{
+			"thing1": classInfo.Name,
+			"thing2": hw.Title,
+			"thing3": summary,
+		},
+		Page: fmt.Sprintf("%s?homeworkId=%s", consts.ClassGradeJumpPage, hw.ID.Hex()),
+	})
+}
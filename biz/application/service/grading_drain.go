@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// inflight 记录正在批改的任务，停机时用于等待在途批改完成并找出未完成的任务
+type inflight struct {
+	mu  sync.Mutex
+	wg  sync.WaitGroup
+	ids map[primitive.ObjectID]string
+}
+
+// add 登记在途批改，owner 为领取时记录的 owner，不使用租约的任务传空
+func (f *inflight) add(id primitive.ObjectID, owner string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ids == nil {
+		f.ids = make(map[primitive.ObjectID]string)
+	}
+	f.ids[id] = owner
+	f.wg.Add(1)
+}
+
+func (f *inflight) done(id primitive.ObjectID) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.ids, id)
+	f.wg.Done()
+}
+
+// wait 等待在途任务全部完成，ctx 结束时返回仍未完成的任务 id 及其 owner
+func (f *inflight) wait(ctx context.Context) map[primitive.ObjectID]string {
+	ch := make(chan struct{})
+	go func() {
+		f.wg.Wait()
+		close(ch)
+	}()
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ids := make(map[primitive.ObjectID]string, len(f.ids))
+	for id, owner := range f.ids {
+		ids[id] = owner
+	}
+	return ids
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util/log"
+
+	"github.com/google/wire"
+	"github.com/samber/lo"
+)
+
+// Guard 可复用的权限校验：路由中间件用 RequireRole 按角色拦截，各 service 用它校验班级归属
+type Guard struct {
+	UserMapper  *user.MongoMapper
+	ClassMapper *class.MongoMapper
+}
+
+var GuardSet = wire.NewSet(
+	wire.Struct(new(Guard), "*"),
+)
+
+// RequireRole 当前用户需为 roles 之一且未被封禁，返回当前用户；非教师访问教师接口时提示先认证
+func (g *Guard) RequireRole(ctx context.Context, roles ...string) (*user.User, error) {
+	userId := adaptor.ExtractUserMeta(ctx).GetUserId()
+	if userId == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	u, err := g.UserMapper.FindOne(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "获取用户信息失败, userId: %s, error: %v", userId, err)
+		return nil, consts.ErrNotFound
+	}
+	if u.Status == consts.UserStatusBanned {
+		return nil, consts.ErrForbidden
+	}
+	if !lo.Contains(roles, u.Role) {
+		if lo.Contains(roles, consts.RoleTeacher) {
+			return nil, consts.ErrTeacherNotCertified
+		}
+		return nil, consts.ErrForbidden
+	}
+	return u, nil
+}
+
+type currentUserKey struct{}
+
+// WithCurrentUser 路由角色中间件校验通过后把当前用户放入 ctx，service 通过 CurrentUser 取用，不再重复查询和校验
+func WithCurrentUser(ctx context.Context, u *user.User) context.Context {
+	return context.WithValue(ctx, currentUserKey{}, u)
+}
+
+// CurrentUser 取出路由角色中间件校验过的当前用户；请求没有经过角色中间件时返回 ErrForbidden，漏配路由时拒绝而不是放行
+func CurrentUser(ctx context.Context) (*user.User, error) {
+	u, ok := ctx.Value(currentUserKey{}).(*user.User)
+	if !ok || u == nil {
+		return nil, consts.ErrForbidden
+	}
+	return u, nil
+}
+
+// ClassOwner 班级需存在且由 userId 创建
+func (g *Guard) ClassOwner(ctx context.Context, userId, classId string) (*class.Class, error) {
+	c, err := g.ClassMapper.FindOne(ctx, classId)
+	if err != nil {
+		log.CtxError(ctx, "获取班级信息失败, classId: %s, error: %v", classId, err)
+		return nil, consts.ErrNotFound
+	}
+	if c.CreatorID != userId {
+		log.CtxError(ctx, "用户无权操作此班级, userId: %s, creatorId: %s", userId, c.CreatorID)
+		return nil, consts.ErrForbidden
+	}
+	return c, nil
+}
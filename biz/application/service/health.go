@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+	"essay-show/biz/infrastructure/repository/health"
+	"essay-show/biz/infrastructure/repository/question_bank"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"sync"
+	"time"
+
+	"github.com/google/wire"
+)
+
+type IHealthService interface {
+	Readyz(ctx context.Context) *ReadyStatus
+}
+
+type HealthService struct {
+	Config             *config.Config
+	MongoPinger        *health.MongoPinger
+	QuestionBankMapper *question_bank.MySQLMapper
+	Downstream         util.DownstreamClient
+}
+
+var HealthServiceSet = wire.NewSet(
+	wire.Struct(new(HealthService), "*"),
+	wire.Bind(new(IHealthService), new(*HealthService)),
+)
+
+// ReadyStatus 就绪检查结果，Ready 只由必需的依赖决定
+type ReadyStatus struct {
+	Ready        bool                `json:"ready"`
+	Dependencies []*DependencyStatus `json:"dependencies"`
+}
+
+// DependencyStatus 单个依赖的检查结果，下游不是必需依赖，不可用时只降级相关功能
+type DependencyStatus struct {
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Ok       bool   `json:"ok"`
+	Latency  int64  `json:"latency"` // 毫秒
+	Error    string `json:"error,omitempty"`
+}
+
+type dependencyCheck struct {
+	name     string
+	required bool
+	check    func(ctx context.Context) error
+}
+
+// Readyz 并发检查 Mongo、Redis、MySQL 和下游服务的连通性，每个依赖最多等待 consts.HealthCheckTimeout
+func (s *HealthService) Readyz(ctx context.Context) *ReadyStatus {
+	checks := []dependencyCheck{
+		{"mongo", true, s.MongoPinger.Ping},
+		{"redis", true, func(ctx context.Context) error {
+			if !redis.GetRedis(s.Config).PingCtx(ctx) {
+				return errors.New("ping failed")
+			}
+			return nil
+		}},
+		{"mysql", true, s.QuestionBankMapper.Ping},
+		{"platform", false, s.probe(s.Config.Api.PlatfromURL)},
+		{"stateless", false, s.probe(s.Config.Api.StatelessURL)},
+		{"algorithm", false, s.probe(s.Config.Api.AlgorithmURL)},
+	}
+
+	status := &ReadyStatus{Ready: true, Dependencies: make([]*DependencyStatus, len(checks))}
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c dependencyCheck) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, consts.HealthCheckTimeout)
+			defer cancel()
+			start := time.Now()
+			err := c.check(ctx)
+			dep := &DependencyStatus{
+				Name:     c.name,
+				Required: c.required,
+				Ok:       err == nil,
+				Latency:  time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				dep.Error = err.Error()
+				log.CtxError(ctx, "依赖检查失败, name: %s, error: %v", c.name, err)
+			}
+			status.Dependencies[i] = dep
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, dep := range status.Dependencies {
+		if dep.Required && !dep.Ok {
+			status.Ready = false
+		}
+	}
+	return status
+}
+
+func (s *HealthService) probe(url string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		if url == "" {
+			return errors.New("未配置地址")
+		}
+		return s.Downstream.Probe(ctx, url)
+	}
+}
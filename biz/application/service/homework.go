@@ -3,19 +3,30 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
 	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/audit"
 	"essay-show/biz/infrastructure/repository/class"
 	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/repository/ocr"
+	"essay-show/biz/infrastructure/repository/outbox"
+	"essay-show/biz/infrastructure/repository/softdelete"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/export"
 	"essay-show/biz/infrastructure/util/log"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
+	"unicode"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/google/wire"
@@ -26,20 +37,44 @@ import (
 type IHomeworkService interface {
 	CreateHomework(ctx context.Context, req *show.CreateHomeworkReq) (*show.CreateHomeworkResp, error)
 	EditHomework(ctx context.Context, req *show.EditHomeworkReq) (*show.Response, error)
-	ListHomeworks(ctx context.Context, req *show.ListHomeworksReq) (*show.ListHomeworksResp, error)
-	SubmitHomework(ctx context.Context, req *show.SubmitHomeworkReq) (*show.SubmitHomeworkResp, error)
-	GetSubmissions(ctx context.Context, req *show.GetSubmissionsReq) (*show.GetSubmissionsResp, error)
+	SetHomeworkNeatness(ctx context.Context, req *show.SetHomeworkNeatnessReq) (*show.Response, error)
+	SetHomeworkWordLimit(ctx context.Context, req *show.SetHomeworkWordLimitReq) (*show.Response, error)
+	ListHomeworks(ctx context.Context, req *show.ListHomeworksReq) (*show.ListHomeworksWithRequirementResp, error)
+	SubmitHomework(ctx context.Context, req *show.SubmitHomeworkWithConfirmReq) (*show.SubmitHomeworkResp, error)
+	GetSubmissionText(ctx context.Context, req *show.GetSubmissionTextReq) (*show.GetSubmissionTextResp, error)
+	ConfirmSubmissionText(ctx context.Context, req *show.ConfirmSubmissionTextReq) (*show.Response, error)
+	GetSubmissions(ctx context.Context, req *show.GetSubmissionsReq) (*show.GetSubmissionsWithAiScoreResp, error)
 	GetUserSubmissions(ctx context.Context, req *show.GetUserSubmissionsReq) (*show.GetUserSubmissionsResp, error)
-	GetSubmissionEvaluate(ctx context.Context, req *show.GetSubmissionEvaluateReq) (*show.GetSubmissionEvaluateResp, error)
+	GetSubmissionEvaluate(ctx context.Context, req *show.GetSubmissionEvaluateReq) (*show.GetSubmissionEvaluateWithAiScoreResp, error)
 	ModifySubmissionEvaluate(ctx context.Context, req *show.ModifySubmissionEvaluateReq) (*show.Response, error)
+	ListSubmissionEvaluateVersions(ctx context.Context, req *show.ListSubmissionEvaluateVersionsReq) (*show.ListEvaluateVersionsResp, error)
+	RollbackSubmissionEvaluate(ctx context.Context, req *show.RollbackSubmissionEvaluateReq) (*show.Response, error)
 	ModifySubmissionEvaluateSaveHistory(ctx context.Context, req *show.ModifySubmissionEvaluateSaveHistoryReq) (*show.ModifySubmissionEvaluateSaveHistoryResp, error)
-	DownloadSubmissionEvaluate(ctx context.Context, req *show.DownloadSubmissionEvaluateReq) (*show.DownloadSubmissionEvaluateResp, error)
+	DownloadSubmissionEvaluate(ctx context.Context, req *show.DownloadSubmissionEvaluateWithFormatReq) (*show.DownloadSubmissionEvaluateResp, error)
 	DownloadLessonPlan(ctx context.Context, req *show.DownloadLessonPlanReq) (*show.DownloadLessonPlanResp, error)
 	ReCorrectHomework(ctx context.Context, req *show.ReCorrectHomeworkReq) (*show.ReCorrectHomeworkResp, error)
 	ReEvaluateHomework(ctx context.Context, req *show.ReEvaluateHomeworkReq) (*show.ReEvaluateHomeworkResp, error)
 	DeleteHomework(ctx context.Context, req *show.DeleteHomeworkReq) (*show.Response, error)
 	GetHomeworkStatistics(ctx context.Context, req *show.GetHomeworkStatisticsReq) (*show.GetHomeworkStatisticsResp, error)
+	DownloadClassReport(ctx context.Context, req *show.DownloadClassReportReq) (*show.DownloadClassReportResp, error)
+	GetClassReport(ctx context.Context, req *show.GetClassReportReq) (*show.GetClassReportResp, error)
+	ListWeeklyReports(ctx context.Context, req *show.ListWeeklyReportsReq) (*show.ListWeeklyReportsResp, error)
+	GetWeeklyReport(ctx context.Context, req *show.GetWeeklyReportReq) (*show.GetWeeklyReportResp, error)
+	GetStudentProgress(ctx context.Context, req *show.GetStudentProgressReq) (*show.GetStudentProgressResp, error)
+	GetStudentRadar(ctx context.Context, req *show.GetStudentRadarReq) (*show.GetStudentRadarResp, error)
+	GetClassCommonMistakes(ctx context.Context, req *show.GetClassCommonMistakesReq) (*show.GetClassCommonMistakesResp, error)
+	GetTeacherDashboard(ctx context.Context, req *show.GetTeacherDashboardReq) (*show.GetTeacherDashboardResp, error)
+	GenerateLessonPlan(ctx context.Context, req *show.GenerateLessonPlanReq) (*show.GenerateLessonPlanResp, error)
+	RegenerateLessonPlan(ctx context.Context, req *show.RegenerateLessonPlanReq) (*show.GenerateLessonPlanResp, error)
+	ListLessonPlans(ctx context.Context, req *show.ListLessonPlansReq) (*show.ListLessonPlansResp, error)
+	GetLessonPlan(ctx context.Context, req *show.GetLessonPlanReq) (*show.GetLessonPlanResp, error)
+	CreateDownloadTask(ctx context.Context, req *show.DownloadSubmissionEvaluateWithFormatReq) (*show.CreateDownloadTaskResp, error)
+	GetDownloadTask(ctx context.Context, req *show.GetDownloadTaskReq) (*show.GetDownloadTaskResp, error)
 	StartGrader(ctx context.Context) error
+	DrainGrading(ctx context.Context)
+	StartDailySummary(ctx context.Context)
+	StartWeeklyReport(ctx context.Context)
+	StartSoftDeletePurge(ctx context.Context)
 }
 
 type HomeworkService struct {
@@ -49,6 +84,25 @@ type HomeworkService struct {
 	MemberMapper     *class.MemberMongoMapper
 	UserMapper       *user.MongoMapper
 	EssayService     IEssayService
+	VersionMapper    *audit.VersionMongoMapper
+	Audit            *AuditRecorder
+	OcrCache         *cache.OcrCacheMapper
+	CorrectionMapper *ocr.MongoMapper
+	ReportMapper     *homework.ReportMongoMapper
+	DownloadTask     *cache.DownloadTaskMapper
+	DownloadCache    *cache.DownloadCacheMapper
+	NotifyCache      *cache.NotifyMapper
+	Notifier         *Notifier
+	LessonPlanMapper *homework.LessonPlanMongoMapper
+	WeeklyMapper     *homework.WeeklyReportMongoMapper
+	Transactor       *transaction.Transactor
+	CountOutbox      *CountOutbox
+	Downstream       util.DownstreamClient
+	Guard            *Guard
+	GradeQueue       *cache.GradeQueueMapper
+	Moderator        *ContentModerator
+
+	grading inflight `wire:"-"`
 }
 
 var HomeworkServiceSet = wire.NewSet(
@@ -65,34 +119,35 @@ func (s *HomeworkService) CreateHomework(ctx context.Context, req *show.CreateHo
 	}
 
 	// 校验教师身份
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
+		return nil, err
 	}
-	if user.Role != consts.RoleTeacher {
-		return nil, consts.ErrNotAuthentication
+
+	if !util.IsSupportSubject(int64(req.Subject), req.Topic) {
+		return nil, consts.ErrUnsupportedSubject
+	}
+
+	// 只能给自己创建的班级布置作业
+	for _, classId := range req.ClassIds {
+		if _, err = s.Guard.ClassOwner(ctx, userMeta.GetUserId(), classId); err != nil {
+			return nil, err
+		}
 	}
 
 	homeworkIds := make([]string, 0, len(req.ClassIds))
 
 	lo.ForEach(req.ClassIds, func(classId string, _ int) {
 
-		// 验证班级是否存在
-		_, err = s.ClassMapper.FindOne(ctx, classId)
-		if err != nil {
-			log.Error("班级不存在: %v", err)
-			return
-		}
-
 		// 验证自定义评分标准（如果提供）
 		if err := s.validateCustomScoring(req); err != nil {
 			return
 		}
 
-		var grade int64
-		if req.Grade == nil {
-			grade = 3
+		// 未指定年级时默认三年级
+		grade := int64(3)
+		if req.Grade != nil {
+			grade = *req.Grade
 		}
 
 		// 创建作业
@@ -119,8 +174,7 @@ func (s *HomeworkService) CreateHomework(ctx context.Context, req *show.CreateHo
 
 		// 网页端提交作业，需自定义批改
 		if req.Topic == 3 {
-			httpClient := util.GetHttpClient()
-			extractRubricCategoriesResponse, err := httpClient.ExtractRubricCategories(ctx, map[string]any{
+			extractRubricCategoriesResponse, err := s.Downstream.ExtractRubricCategories(ctx, map[string]any{
 				"rubric_text": req.Standard,
 				"grade_type":  util.GetGradeType(req.Grade),
 			})
@@ -141,7 +195,7 @@ func (s *HomeworkService) CreateHomework(ctx context.Context, req *show.CreateHo
 
 		err = s.HomeworkMapper.Insert(ctx, h)
 		if err != nil {
-			log.Error("创建作业失败: %v", err)
+			log.CtxError(ctx, "创建作业失败: %v", err)
 			return
 		}
 
@@ -285,23 +339,19 @@ func (s *HomeworkService) EditHomework(ctx context.Context, req *show.EditHomewo
 		return nil, consts.ErrNotAuthentication
 	}
 
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("作业不存在: %v", err)
+		log.CtxError(ctx, "作业不存在: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	if h.CreatorID != userMeta.GetUserId() {
-		log.Error("用户无权编辑此作业, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
+		log.CtxError(ctx, "用户无权编辑此作业, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
 		return nil, consts.ErrForbidden
 	}
 
@@ -321,7 +371,85 @@ func (s *HomeworkService) EditHomework(ctx context.Context, req *show.EditHomewo
 	h.DevelopmentScore = req.DevelopmentScore
 
 	if err := s.HomeworkMapper.Update(ctx, h); err != nil {
-		log.Error("编辑作业失败: %v", err)
+		log.CtxError(ctx, "编辑作业失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	return &show.Response{
+		Code: 0,
+		Msg:  "更新成功",
+	}, nil
+}
+
+// SetHomeworkNeatness 设置书写工整度是否纳入总评，只影响之后批改的提交，已有得分不重新计算
+func (s *HomeworkService) SetHomeworkNeatness(ctx context.Context, req *show.SetHomeworkNeatnessReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	_, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
+	if err != nil {
+		log.CtxError(ctx, "作业不存在: %v", err)
+		return nil, consts.ErrNotFound
+	}
+	if h.CreatorID != userMeta.GetUserId() {
+		log.CtxError(ctx, "用户无权编辑此作业, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
+		return nil, consts.ErrForbidden
+	}
+
+	h.NeatnessInTotal = req.InTotal
+	if err := s.HomeworkMapper.Update(ctx, h); err != nil {
+		log.CtxError(ctx, "设置书写工整度失败: %v", err)
+		return nil, consts.ErrCall
+	}
+
+	return &show.Response{
+		Code: 0,
+		Msg:  "更新成功",
+	}, nil
+}
+
+// SetHomeworkWordLimit 设置作业字数要求，批改时不符合要求的提交会在结果中标注，不影响批改
+func (s *HomeworkService) SetHomeworkWordLimit(ctx context.Context, req *show.SetHomeworkWordLimitReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	if req.MinWords < 0 || req.MinWords > consts.TextMaxLength {
+		return nil, consts.InvalidParams("minWords", fmt.Sprintf("应在 0~%d 之间", consts.TextMaxLength))
+	}
+	if req.MaxWords < 0 || req.MaxWords > consts.TextMaxLength {
+		return nil, consts.InvalidParams("maxWords", fmt.Sprintf("应在 0~%d 之间", consts.TextMaxLength))
+	}
+	if req.MaxWords > 0 && req.MaxWords < req.MinWords {
+		return nil, consts.InvalidParams("maxWords", "不能小于 minWords")
+	}
+
+	_, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
+	if err != nil {
+		log.CtxError(ctx, "作业不存在: %v", err)
+		return nil, consts.ErrNotFound
+	}
+	if h.CreatorID != userMeta.GetUserId() {
+		log.CtxError(ctx, "用户无权编辑此作业, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
+		return nil, consts.ErrForbidden
+	}
+
+	h.MinWords = req.MinWords
+	h.MaxWords = req.MaxWords
+	if err := s.HomeworkMapper.Update(ctx, h); err != nil {
+		log.CtxError(ctx, "设置字数要求失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
@@ -332,7 +460,7 @@ func (s *HomeworkService) EditHomework(ctx context.Context, req *show.EditHomewo
 }
 
 // ListHomeworks 获取作业列表
-func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomeworksReq) (*show.ListHomeworksResp, error) {
+func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomeworksReq) (*show.ListHomeworksWithRequirementResp, error) {
 	// 获取用户信息
 	userMeta := adaptor.ExtractUserMeta(ctx)
 	if userMeta.GetUserId() == "" {
@@ -342,7 +470,7 @@ func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomew
 	// 确认身份
 	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
+		log.CtxError(ctx, "获取用户信息失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
@@ -350,18 +478,14 @@ func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomew
 	c := new(class.Class)
 	member := new(class.ClassMember)
 	if u.Role == consts.RoleTeacher {
-		c, err = s.ClassMapper.FindOne(ctx, req.ClassId)
+		c, err = s.Guard.ClassOwner(ctx, userMeta.GetUserId(), req.ClassId)
 		if err != nil {
-			log.Error("班级不存在: %v", err)
-			return nil, consts.ErrNotFound
-		}
-		if c.CreatorID != userMeta.GetUserId() {
-			return nil, consts.ErrForbidden
+			return nil, err
 		}
 	} else {
 		member, err = s.MemberMapper.FindByClassIDAndStuID(ctx, req.ClassId, userMeta.GetUserId())
 		if err != nil {
-			log.Error("获取班级成员失败: %v", err)
+			log.CtxError(ctx, "获取班级成员失败: %v", err)
 			return nil, err
 		}
 	}
@@ -379,11 +503,11 @@ func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomew
 
 	homeworks, total, err := s.HomeworkMapper.FindByClassID(ctx, req.ClassId, page, pageSize)
 	if err != nil {
-		log.Error("获取作业列表失败: %v", err)
+		log.CtxError(ctx, "获取作业列表失败: %v", err)
 		return nil, consts.ErrGetHomeworkList
 	}
 
-	homeworkInfos := make([]*show.HomeworkInfo, 0, len(homeworks))
+	homeworkInfos := make([]*show.HomeworkInfoWithRequirement, 0, len(homeworks))
 	for _, h := range homeworks {
 		homeworkInfo := &show.HomeworkInfo{
 			Id:               h.ID.Hex(),
@@ -406,7 +530,7 @@ func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomew
 		if u.Role == consts.RoleTeacher {
 			submissions, err := s.SubmissionMapper.FindByHomeworkID(ctx, h.ID.Hex())
 			if err != nil {
-				log.Error("获取提交情况失败: %v", err)
+				log.CtxError(ctx, "获取提交情况失败: %v", err)
 				return nil, consts.ErrGetHomeworkList
 			}
 			submitCount := int64(len(submissions))
@@ -432,7 +556,7 @@ func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomew
 				status := show.HomeworkStatus(consts.StatusNotSubmission)
 				homeworkInfo.Status = &status
 			case err != nil:
-				log.Error("获取提交情况失败: %v", err)
+				log.CtxError(ctx, "获取提交情况失败: %v", err)
 				return nil, consts.ErrGetHomeworkList
 			default:
 				status := show.HomeworkStatus(submission.Status)
@@ -448,17 +572,22 @@ func (s *HomeworkService) ListHomeworks(ctx context.Context, req *show.ListHomew
 				}
 			}
 		}
-		homeworkInfos = append(homeworkInfos, homeworkInfo)
+		homeworkInfos = append(homeworkInfos, &show.HomeworkInfoWithRequirement{
+			HomeworkInfo:    homeworkInfo,
+			MinWords:        h.MinWords,
+			MaxWords:        h.MaxWords,
+			NeatnessInTotal: h.NeatnessInTotal,
+		})
 	}
 
-	return &show.ListHomeworksResp{
+	return &show.ListHomeworksWithRequirementResp{
 		Homeworks: homeworkInfos,
 		Total:     total,
 	}, nil
 }
 
 // GetHomework 获取作业批改结果
-func (s *HomeworkService) GetSubmissionEvaluate(ctx context.Context, req *show.GetSubmissionEvaluateReq) (*show.GetSubmissionEvaluateResp, error) {
+func (s *HomeworkService) GetSubmissionEvaluate(ctx context.Context, req *show.GetSubmissionEvaluateReq) (*show.GetSubmissionEvaluateWithAiScoreResp, error) {
 	// 获取用户信息
 	userMeta := adaptor.ExtractUserMeta(ctx)
 	if userMeta.GetUserId() == "" {
@@ -468,23 +597,35 @@ func (s *HomeworkService) GetSubmissionEvaluate(ctx context.Context, req *show.G
 	// 获取提交情况
 	submission, err := s.SubmissionMapper.FindOne(ctx, req.SubmissionId)
 	if err != nil {
-		log.Error("获取作业详情失败: %v", err)
+		log.CtxError(ctx, "获取作业详情失败: %v", err)
 		return nil, consts.ErrGetHomework
 	}
 
 	if submission.Status != consts.StatusCompleted && submission.Status != consts.StatusModified {
-		log.Error("批改未完成")
+		log.CtxError(ctx, "批改未完成")
 		return nil, consts.ErrHomeworkNotGrade
 	}
 
-	return &show.GetSubmissionEvaluateResp{
-		Id:       submission.ID.Hex(),
-		Response: submission.Response,
-	}, nil
+	resp := &show.GetSubmissionEvaluateWithAiScoreResp{
+		GetSubmissionEvaluateResp: &show.GetSubmissionEvaluateResp{
+			Id:       submission.ID.Hex(),
+			Response: submission.Response,
+		},
+		Neatness:          submission.Neatness,
+		NeatnessDeduction: submission.NeatnessDeduction,
+		WordCount:         submission.WordCount,
+		WordLimitMessage:  submission.WordLimitMessage,
+		Subject:           submission.Subject,
+	}
+	// 疑似度只给布置作业的教师参考，不返回给学生
+	if submission.TeacherID == userMeta.GetUserId() {
+		resp.AiScore = submission.AiScore
+	}
+	return resp, nil
 }
 
-// SubmitHomework 提交作业
-func (s *HomeworkService) SubmitHomework(ctx context.Context, req *show.SubmitHomeworkReq) (*show.SubmitHomeworkResp, error) {
+// SubmitHomework 提交作业，confirmText 为 true 时识别完成后等待学生确认文本再批改；填写 text 时跳过识别直接批改
+func (s *HomeworkService) SubmitHomework(ctx context.Context, req *show.SubmitHomeworkWithConfirmReq) (*show.SubmitHomeworkResp, error) {
 	userMeta := adaptor.ExtractUserMeta(ctx)
 	if userMeta.GetUserId() == "" {
 		return nil, consts.ErrNotAuthentication
@@ -492,51 +633,129 @@ func (s *HomeworkService) SubmitHomework(ctx context.Context, req *show.SubmitHo
 
 	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("作业不存在: %v", err)
+		log.CtxError(ctx, "作业不存在: %v", err)
 		return nil, consts.ErrNotFound
 	}
 	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
+		log.CtxError(ctx, "获取用户信息失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
+	textSubmit := strings.TrimSpace(req.Text) != ""
+	if !textSubmit && len(req.Images) == 0 {
+		return nil, consts.InvalidParams("images", "图片和文本不能同时为空")
+	}
+
 	// 教师端可直接提交，学生端需检查member和userid是否绑定
 	member, err := s.MemberMapper.FindByMemberID(ctx, req.MemberId)
 	if err != nil {
-		log.Error("获取班级成员失败: %v", err)
+		log.CtxError(ctx, "获取班级成员失败: %v", err)
 		return nil, consts.ErrGetClassMembers
 	}
 	if member.UserID != nil && *member.UserID != userMeta.GetUserId() && user.Role == consts.RoleStudent {
-		log.Error("用户无权提交此作业, userId: %s, memberId: %s", userMeta.GetUserId(), req.MemberId)
+		log.CtxError(ctx, "用户无权提交此作业, userId: %s, memberId: %s", userMeta.GetUserId(), req.MemberId)
 		return nil, consts.ErrForbidden
 	}
 
 	submission := &homework.HomeworkSubmission{
-		HomeworkID: req.HomeworkId,
-		MemberId:   req.MemberId,
-		TeacherID:  h.CreatorID,
-		Images:     req.Images,
-		Status:     consts.StatusInitialized,
-		SubmitType: consts.RecorrectTypeFirst,
+		HomeworkID:  req.HomeworkId,
+		MemberId:    req.MemberId,
+		TeacherID:   h.CreatorID,
+		Images:      req.Images,
+		Status:      consts.StatusInitialized,
+		SubmitType:  consts.RecorrectTypeFirst,
+		ConfirmText: req.ConfirmText,
+	}
+	// 纯文本提交跳过识别，文本由学生直接填写，也不需要确认
+	if textSubmit {
+		submission.Title = req.Title
+		submission.Text = req.Text
+		submission.TextSubmit = true
+		submission.ConfirmText = false
 	}
 
 	err = s.SubmissionMapper.Insert(ctx, submission)
 	if err != nil {
-		log.Error("提交作业失败: %v", err)
+		log.CtxError(ctx, "提交作业失败: %v", err)
 		return nil, consts.ErrSubmitHomework
 	}
 
-	log.Info("作业提交成功 [SubmissionID: %s, StudentID: %s, HomeworkID: %s]",
+	log.CtxInfo(ctx, "作业提交成功 [SubmissionID: %s, StudentID: %s, HomeworkID: %s]",
 		submission.ID.Hex(), userMeta.UserId, req.HomeworkId)
+	s.enqueueGrade(ctx, submission.ID)
 
 	return &show.SubmitHomeworkResp{
 		SubmissionId: submission.ID.Hex(),
 	}, nil
 }
 
+// GetSubmissionText 获取提交识别出的文本，供学生在批改前确认
+func (s *HomeworkService) GetSubmissionText(ctx context.Context, req *show.GetSubmissionTextReq) (*show.GetSubmissionTextResp, error) {
+	submission, err := s.findOwnSubmission(ctx, req.SubmissionId)
+	if err != nil {
+		return nil, err
+	}
+	return &show.GetSubmissionTextResp{
+		Code:   0,
+		Msg:    "success",
+		Status: int64(submission.Status),
+		Title:  submission.Title,
+		Text:   submission.Text,
+	}, nil
+}
+
+// ConfirmSubmissionText 学生确认（可修改）识别文本，保存后按确认的文本进入批改
+func (s *HomeworkService) ConfirmSubmissionText(ctx context.Context, req *show.ConfirmSubmissionTextReq) (*show.Response, error) {
+	if strings.TrimSpace(req.Text) == "" {
+		return nil, consts.ErrInvalidParams
+	}
+	submission, err := s.findOwnSubmission(ctx, req.SubmissionId)
+	if err != nil {
+		return nil, err
+	}
+	if submission.Status != consts.StatusPendingText {
+		return nil, consts.ErrSubmissionNotPendingText
+	}
+
+	// 只在仍等待确认时写入，并发确认或批改已开始时不覆盖
+	ok, err := s.SubmissionMapper.ConfirmText(ctx, submission.ID, req.Title, req.Text)
+	if err != nil {
+		log.CtxError(ctx, "保存确认文本失败, submissionId: %s, err: %v", req.SubmissionId, err)
+		return nil, consts.ErrUpdate
+	}
+	if !ok {
+		return nil, consts.ErrSubmissionNotPendingText
+	}
+	s.enqueueGrade(ctx, submission.ID)
+	return util.Succeed("已确认，开始批改")
+}
+
+// findOwnSubmission 获取当前用户提交的作业，布置作业的老师也可以访问
+func (s *HomeworkService) findOwnSubmission(ctx context.Context, id string) (*homework.HomeworkSubmission, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	submission, err := s.SubmissionMapper.FindOne(ctx, id)
+	if err != nil {
+		return nil, consts.ErrGetSubmission
+	}
+	if submission.TeacherID == userMeta.GetUserId() {
+		return submission, nil
+	}
+	member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId)
+	if err != nil {
+		return nil, consts.ErrGetClassMembers
+	}
+	if member.UserID == nil || *member.UserID != userMeta.GetUserId() {
+		return nil, consts.ErrForbidden
+	}
+	return submission, nil
+}
+
 // GetSubmissions 教师端获取提交详情
-func (s *HomeworkService) GetSubmissions(ctx context.Context, req *show.GetSubmissionsReq) (*show.GetSubmissionsResp, error) {
+func (s *HomeworkService) GetSubmissions(ctx context.Context, req *show.GetSubmissionsReq) (*show.GetSubmissionsWithAiScoreResp, error) {
 	// 获取用户信息
 	userMeta := adaptor.ExtractUserMeta(ctx)
 	if userMeta.GetUserId() == "" {
@@ -556,32 +775,28 @@ func (s *HomeworkService) GetSubmissions(ctx context.Context, req *show.GetSubmi
 	}
 
 	// 确认老师身份
-	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if u.Role != consts.RoleTeacher {
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	// 获取作业信息
 	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("作业不存在: %v", err)
+		log.CtxError(ctx, "作业不存在: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	// 获取班级成员
 	members, total, err := s.MemberMapper.FindByClassID(ctx, h.ClassID, page, pageSize)
 	if err != nil {
-		log.Error("获取班级成员失败: %v", err)
+		log.CtxError(ctx, "获取班级成员失败: %v", err)
 		return nil, consts.ErrGetClassMembers
 	}
 
-	submissionInfos := make([]*show.SubmissionInfo, 0)
+	submissionInfos := make([]*show.SubmissionInfoWithAiScore, 0)
 	for _, m := range members {
-		sub := &show.SubmissionInfo{MemberId: m.ID.Hex(), MemberName: m.Name}
+		sub := &show.SubmissionInfoWithAiScore{SubmissionInfo: &show.SubmissionInfo{MemberId: m.ID.Hex(), MemberName: m.Name}}
 
 		// 查询学生提交记录
 		userSubmission, err := s.SubmissionMapper.FindLatestByMemberAndHomework(ctx, m.ID.Hex(), req.HomeworkId)
@@ -589,7 +804,7 @@ func (s *HomeworkService) GetSubmissions(ctx context.Context, req *show.GetSubmi
 		case err == consts.ErrNotFound:
 			sub.Status = consts.StatusNotSubmission
 		case err != nil:
-			log.Error("获取学生提交记录失败: %v", err)
+			log.CtxError(ctx, "获取学生提交记录失败: %v", err)
 			return nil, consts.ErrGetSubmission
 		default:
 			sub.Status = show.HomeworkStatus(userSubmission.Status)
@@ -599,6 +814,10 @@ func (s *HomeworkService) GetSubmissions(ctx context.Context, req *show.GetSubmi
 			sub.Id = &id
 			sub.Title = &userSubmission.Title
 			sub.SubmitTime = &submitTime
+			sub.AiScore = userSubmission.AiScore
+			sub.Neatness = userSubmission.Neatness
+			sub.WordCount = userSubmission.WordCount
+			sub.WordLimitMessage = userSubmission.WordLimitMessage
 			if userSubmission.Status == consts.StatusCompleted || userSubmission.Status == consts.StatusModified {
 				sub.GradeResult = &userSubmission.GradeResult
 			} else if userSubmission.Status == consts.StatusFailed {
@@ -610,7 +829,7 @@ func (s *HomeworkService) GetSubmissions(ctx context.Context, req *show.GetSubmi
 		submissionInfos = append(submissionInfos, sub)
 	}
 
-	return &show.GetSubmissionsResp{
+	return &show.GetSubmissionsWithAiScoreResp{
 		Submissions: submissionInfos,
 		Total:       total,
 	}, nil
@@ -639,7 +858,7 @@ func (s *HomeworkService) GetUserSubmissions(ctx context.Context, req *show.GetU
 	// 查询用户在某作业下全部提交记录
 	submissions, total, err := s.SubmissionMapper.FindByMemberAndHomework(ctx, req.MemberId, req.HomeworkId, page, pageSize)
 	if err != nil {
-		log.Error("获取提交记录失败: %v", err)
+		log.CtxError(ctx, "获取提交记录失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 	ids := make([]string, 0, len(submissions))
@@ -658,10 +877,14 @@ func displaySubmissionFailMessage(reason string) string {
 	switch {
 	case strings.Contains(reason, "老师批改次数不足"):
 		return "老师批改次数不足，请补充批改次数后重试"
+	case strings.Contains(reason, "PDF 页数过多"):
+		return "PDF 页数过多，请让学生拆分后重新上传"
 	case strings.Contains(reason, "OCR"), strings.Contains(reason, "识别"):
 		return "图片识别失败，请让学生重新上传清晰图片"
 	case strings.Contains(reason, "作业不存在"):
 		return "作业不存在，无法批改"
+	case strings.Contains(reason, "学科"):
+		return "该学科暂不支持批改"
 	case strings.Contains(reason, "批改结果为空"), strings.Contains(reason, "批改结果不合法"):
 		return "批改服务返回异常，请稍后重试"
 	case strings.Contains(reason, "扣除批改次数失败"):
@@ -682,14 +905,9 @@ func (s *HomeworkService) ReCorrectHomework(ctx context.Context, req *show.ReCor
 	}
 
 	// 校验教师身份
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		log.Error("用户不是教师，无权重批作业, userId: %s, role: %d", userMeta.GetUserId(), user.Role)
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	submissionIds := make([]string, 0)
@@ -697,19 +915,25 @@ func (s *HomeworkService) ReCorrectHomework(ctx context.Context, req *show.ReCor
 		// 查询提交记录
 		submission, err := s.SubmissionMapper.FindOne(ctx, submissionId)
 		if err != nil {
-			log.Error("查询提交记录失败: submissionId=%s, error=%v", submissionId, err)
+			log.CtxError(ctx, "查询提交记录失败: submissionId=%s, error=%v", submissionId, err)
 			return
 		}
 
 		// 验证提交是否属于当前教师
 		if submission.TeacherID != userMeta.GetUserId() {
-			log.Error("提交不属于当前教师: submissionId=%s, teacherId=%s, userId=%s",
+			log.CtxError(ctx, "提交不属于当前教师: submissionId=%s, teacherId=%s, userId=%s",
 				submissionId, submission.TeacherID, userMeta.GetUserId())
 			return
 		}
 
 		if submission.Status == consts.StatusInitialized || submission.Status == consts.StatusGrading {
-			log.Info("提交状态不允许重批: submissionId=%s, status=%d", submissionId, submission.Status)
+			log.CtxInfo(ctx, "提交状态不允许重批: submissionId=%s, status=%d", submissionId, submission.Status)
+			return
+		}
+
+		// 清空前把之前的批改结果保存为版本，重批后仍可回滚
+		if err := s.Audit.SnapshotVersion(ctx, consts.RevisionTargetSubmission, submissionId, submission.Response); err != nil {
+			log.CtxError(ctx, "保存重批前的批改版本失败: submissionId=%s, error=%v", submissionId, err)
 			return
 		}
 
@@ -720,14 +944,15 @@ func (s *HomeworkService) ReCorrectHomework(ctx context.Context, req *show.ReCor
 		submission.UpdateTime = time.Now()
 
 		if err := s.SubmissionMapper.Update(ctx, submission); err != nil {
-			log.Error("更新提交状态失败: submissionId=%s, error=%v", submissionId, err)
+			log.CtxError(ctx, "更新提交状态失败: submissionId=%s, error=%v", submissionId, err)
 			return
 		}
+		s.enqueueGrade(ctx, submission.ID)
 
 		submissionIds = append(submissionIds, submissionId)
 	})
 
-	log.Info("批改重批完成: submissionIds=%v", submissionIds)
+	log.CtxInfo(ctx, "批改重批完成: submissionIds=%v", submissionIds)
 
 	return &show.ReCorrectHomeworkResp{
 		SubmissionIds: submissionIds,
@@ -743,14 +968,9 @@ func (s *HomeworkService) ReEvaluateHomework(ctx context.Context, req *show.ReEv
 	}
 
 	// 校验教师身份
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		log.Error("用户不是教师，无权重批作业, userId: %s, role: %d", userMeta.GetUserId(), user.Role)
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	submissionId := req.SubmissionId
@@ -758,20 +978,20 @@ func (s *HomeworkService) ReEvaluateHomework(ctx context.Context, req *show.ReEv
 	// 查询提交记录
 	submission, err := s.SubmissionMapper.FindOne(ctx, submissionId)
 	if err != nil {
-		log.Error("查询提交记录失败: submissionId=%s, error=%v", submissionId, err)
+		log.CtxError(ctx, "查询提交记录失败: submissionId=%s, error=%v", submissionId, err)
 		return nil, consts.ErrNotFound
 	}
 
 	// 验证提交是否属于当前教师
 	if submission.TeacherID != userMeta.GetUserId() {
-		log.Error("提交不属于当前教师: submissionId=%s, teacherId=%s, userId=%s",
+		log.CtxError(ctx, "提交不属于当前教师: submissionId=%s, teacherId=%s, userId=%s",
 			submissionId, submission.TeacherID, userMeta.GetUserId())
 		return nil, consts.ErrNotFound
 	}
 
 	submissions, err := s.SubmissionMapper.FindAllByMemberAndHomework(ctx, submission.MemberId, submission.HomeworkID)
 	if err != nil {
-		log.Error("查询提交历史失败: memberId=%s, homeworkId=%s, error=%v", submission.MemberId, submission.HomeworkID, err)
+		log.CtxError(ctx, "查询提交历史失败: memberId=%s, homeworkId=%s, error=%v", submission.MemberId, submission.HomeworkID, err)
 		return nil, consts.ErrCall
 	}
 
@@ -806,11 +1026,16 @@ func (s *HomeworkService) ReEvaluateHomework(ctx context.Context, req *show.ReEv
 	}
 
 	if err := s.SubmissionMapper.Insert(ctx, newSubmission); err != nil {
-		log.Error("提交作业失败: %v", err)
+		log.CtxError(ctx, "提交作业失败: %v", err)
 		return nil, consts.ErrSubmitHomework
 	}
+	// 沿用的批改结果会被重批覆盖，先保存为新提交的第 1 版，重批后仍可回滚
+	if err := s.Audit.SnapshotVersion(ctx, consts.RevisionTargetSubmission, newSubmission.ID.Hex(), newSubmission.Response); err != nil {
+		log.CtxError(ctx, "保存重批前的批改版本失败: submissionId=%s, error=%v", newSubmission.ID.Hex(), err)
+	}
 
-	log.Info("作业重批完成: submissionId=%s", newSubmission.ID.Hex())
+	log.CtxInfo(ctx, "作业重批完成: submissionId=%s", newSubmission.ID.Hex())
+	s.enqueueGrade(ctx, newSubmission.ID)
 
 	return &show.ReEvaluateHomeworkResp{
 		SubmissionId: newSubmission.ID.Hex(),
@@ -824,34 +1049,13 @@ func (s *HomeworkService) keepOnlyOriginalSubmission(ctx context.Context, submis
 
 	for _, historySubmission := range submissions[1:] {
 		if err := s.SubmissionMapper.Delete(ctx, historySubmission.ID.Hex()); err != nil {
-			log.Error("删除历史提交记录失败: submissionId=%s, error=%v", historySubmission.ID.Hex(), err)
+			log.CtxError(ctx, "删除历史提交记录失败: submissionId=%s, error=%v", historySubmission.ID.Hex(), err)
 			return consts.ErrCall
 		}
 	}
 	return nil
 }
 
-// StartGrader 启动作业批改定时器
-func (s *HomeworkService) StartGrader(ctx context.Context) error {
-	log.Info("启动作业批改定时器")
-
-	go func() {
-		ticker := time.NewTicker(30 * time.Second)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				s.processHomeworkSubmissions(context.Background())
-			}
-		}
-	}()
-
-	return nil
-}
-
 // ModifySubmissionEvaluate 修改作业提交的批改结果
 func (s *HomeworkService) ModifySubmissionEvaluate(ctx context.Context, req *show.ModifySubmissionEvaluateReq) (*show.Response, error) {
 	userMeta := adaptor.ExtractUserMeta(ctx)
@@ -859,30 +1063,29 @@ func (s *HomeworkService) ModifySubmissionEvaluate(ctx context.Context, req *sho
 		return nil, consts.ErrNotAuthentication
 	}
 
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		log.Error("用户不是教师，无权修改批改结果, userId: %s, role: %d", userMeta.GetUserId(), user.Role)
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	submission, err := s.SubmissionMapper.FindOne(ctx, req.SubmissionId)
 	if err != nil {
-		log.Error("查询提交记录失败: %v", err)
+		log.CtxError(ctx, "查询提交记录失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	if submission.TeacherID != userMeta.GetUserId() {
-		log.Error("提交记录不属于当前教师, teacherId: %s, userId: %s", submission.TeacherID, userMeta.GetUserId())
+		log.CtxError(ctx, "提交记录不属于当前教师, teacherId: %s, userId: %s", submission.TeacherID, userMeta.GetUserId())
 		return nil, consts.ErrNotFound
 	}
+	// 分项修改按语文的评分项进行，其他学科的批改结果结构不同
+	if submission.Subject != consts.SubjectChinese {
+		return nil, consts.ErrUnsupportedSubject
+	}
 
 	var evaluateResult stateless.Evaluate
 	if err := json.Unmarshal([]byte(submission.Response), &evaluateResult); err != nil {
-		log.Error("解析批改结果失败: %v", err)
+		log.CtxError(ctx, "解析批改结果失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
@@ -952,20 +1155,102 @@ func (s *HomeworkService) ModifySubmissionEvaluate(ctx context.Context, req *sho
 
 	evaluateBytes, err := json.Marshal(evaluateResult)
 	if err != nil {
-		log.Error("序列化批改结果失败: %v", err)
+		log.CtxError(ctx, "序列化批改结果失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
 	// 更新提交记录
+	before := submission.Response
 	submission.Response = string(evaluateBytes)
 	if err := s.SubmissionMapper.Update(ctx, submission); err != nil {
-		log.Error("更新提交记录失败: %v", err)
+		log.CtxError(ctx, "更新提交记录失败: %v", err)
 		return nil, consts.ErrCall
 	}
+	s.invalidateDownloadCache(ctx, req.SubmissionId)
+	s.Audit.Record(ctx, userMeta.GetUserId(), consts.AuditActionModifySubmission, req.SubmissionId,
+		fmt.Sprintf("修改作业批改结果, homeworkId: %s, memberId: %s", submission.HomeworkID, submission.MemberId))
+	s.Audit.RecordRevision(ctx, userMeta.GetUserId(), consts.RevisionTargetSubmission, req.SubmissionId, before, submission.Response)
+	s.Audit.RecordVersion(ctx, before, &audit.Version{
+		TargetType: consts.RevisionTargetSubmission,
+		TargetID:   req.SubmissionId,
+		Source:     consts.VersionSourceModify,
+		OperatorID: userMeta.GetUserId(),
+		Response:   submission.Response,
+	})
 
 	return util.Succeed("修改成功")
 }
 
+// ListSubmissionEvaluateVersions 查询作业提交批改结果的历史版本，仅布置作业的教师可查
+func (s *HomeworkService) ListSubmissionEvaluateVersions(ctx context.Context, req *show.ListSubmissionEvaluateVersionsReq) (*show.ListEvaluateVersionsResp, error) {
+	if _, err := s.findTeacherSubmission(ctx, req.SubmissionId); err != nil {
+		return nil, err
+	}
+
+	versions, total, err := s.VersionMapper.FindByTarget(ctx, consts.RevisionTargetSubmission, req.SubmissionId, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询批改版本失败, submissionId: %s, err: %v", req.SubmissionId, err)
+		return nil, consts.ErrCall
+	}
+	return &show.ListEvaluateVersionsResp{Code: 0, Msg: "success", Versions: toEvaluateVersions(versions), Total: total}, nil
+}
+
+// RollbackSubmissionEvaluate 把作业提交的批改结果恢复到指定的历史版本，恢复本身也作为新版本保存，之后仍可再回滚
+func (s *HomeworkService) RollbackSubmissionEvaluate(ctx context.Context, req *show.RollbackSubmissionEvaluateReq) (*show.Response, error) {
+	submission, err := s.findTeacherSubmission(ctx, req.SubmissionId)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.VersionMapper.FindOne(ctx, consts.RevisionTargetSubmission, req.SubmissionId, req.Version)
+	if err != nil {
+		return nil, consts.InvalidParams("version", "版本不存在")
+	}
+
+	before := submission.Response
+	submission.Response = v.Response
+	submission.Status = consts.StatusModified
+	if err := s.SubmissionMapper.Update(ctx, submission); err != nil {
+		log.CtxError(ctx, "回滚提交记录失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	s.invalidateDownloadCache(ctx, req.SubmissionId)
+
+	operatorId := adaptor.ExtractUserMeta(ctx).GetUserId()
+	s.Audit.Record(ctx, operatorId, consts.AuditActionRollbackEvaluate, req.SubmissionId,
+		fmt.Sprintf("作业批改结果回滚到第 %d 版, homeworkId: %s, memberId: %s", req.Version, submission.HomeworkID, submission.MemberId))
+	s.Audit.RecordRevision(ctx, operatorId, consts.RevisionTargetSubmission, req.SubmissionId, before, submission.Response)
+	s.Audit.RecordVersion(ctx, before, &audit.Version{
+		TargetType:   consts.RevisionTargetSubmission,
+		TargetID:     req.SubmissionId,
+		Source:       consts.VersionSourceRollback,
+		OperatorID:   operatorId,
+		RollbackFrom: req.Version,
+		Response:     submission.Response,
+	})
+
+	return util.Succeed("回滚成功")
+}
+
+// findTeacherSubmission 查询当前教师布置的作业下的提交，不属于当前教师时按不存在处理
+func (s *HomeworkService) findTeacherSubmission(ctx context.Context, submissionId string) (*homework.HomeworkSubmission, error) {
+	teacher, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	submission, err := s.SubmissionMapper.FindOne(ctx, submissionId)
+	if err != nil {
+		log.CtxError(ctx, "查询提交记录失败: %v", err)
+		return nil, consts.ErrNotFound
+	}
+	if submission.TeacherID != teacher.ID.Hex() {
+		log.CtxError(ctx, "提交记录不属于当前教师, teacherId: %s, userId: %s", submission.TeacherID, teacher.ID.Hex())
+		return nil, consts.ErrNotFound
+	}
+	return submission, nil
+}
+
 // ModifySubmissionEvaluateSaveHistory 修改作业提交的批改结果-留痕
 func (s *HomeworkService) ModifySubmissionEvaluateSaveHistory(ctx context.Context, req *show.ModifySubmissionEvaluateSaveHistoryReq) (*show.ModifySubmissionEvaluateSaveHistoryResp, error) {
 	userMeta := adaptor.ExtractUserMeta(ctx)
@@ -973,45 +1258,40 @@ func (s *HomeworkService) ModifySubmissionEvaluateSaveHistory(ctx context.Contex
 		return nil, consts.ErrNotAuthentication
 	}
 
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		log.Error("用户不是教师，无权修改批改结果, userId: %s, role: %d", userMeta.GetUserId(), user.Role)
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	if req.Topic != consts.TopicTypeWeb {
-		log.Error("仅支持课堂练习留痕修改, submissionId: %s, topic: %d", req.SubmissionId, req.Topic)
+		log.CtxError(ctx, "仅支持课堂练习留痕修改, submissionId: %s, topic: %d", req.SubmissionId, req.Topic)
 		return nil, consts.ErrInvalidParams
 	}
 
 	submission, err := s.SubmissionMapper.FindOne(ctx, req.SubmissionId)
 	if err != nil {
-		log.Error("查询提交记录失败: submissionId=%s, error=%v", req.SubmissionId, err)
+		log.CtxError(ctx, "查询提交记录失败: submissionId=%s, error=%v", req.SubmissionId, err)
 		return nil, consts.ErrNotFound
 	}
 
 	if submission.TeacherID != userMeta.GetUserId() {
-		log.Error("提交记录不属于当前教师, teacherId: %s, userId: %s", submission.TeacherID, userMeta.GetUserId())
+		log.CtxError(ctx, "提交记录不属于当前教师, teacherId: %s, userId: %s", submission.TeacherID, userMeta.GetUserId())
 		return nil, consts.ErrNotFound
 	}
 
 	hw, err := s.HomeworkMapper.FindOne(ctx, submission.HomeworkID)
 	if err != nil {
-		log.Error("查询作业失败: homeworkId=%s, error=%v", submission.HomeworkID, err)
+		log.CtxError(ctx, "查询作业失败: homeworkId=%s, error=%v", submission.HomeworkID, err)
 		return nil, consts.ErrNotFound
 	}
 	if hw.Topic != consts.TopicTypeWeb {
-		log.Error("作业类型不支持留痕修改, homeworkId: %s, topic: %d", submission.HomeworkID, hw.Topic)
+		log.CtxError(ctx, "作业类型不支持留痕修改, homeworkId: %s, topic: %d", submission.HomeworkID, hw.Topic)
 		return nil, consts.ErrInvalidParams
 	}
 
 	submissions, err := s.SubmissionMapper.FindAllByMemberAndHomework(ctx, submission.MemberId, submission.HomeworkID)
 	if err != nil {
-		log.Error("查询提交历史失败: memberId=%s, homeworkId=%s, error=%v", submission.MemberId, submission.HomeworkID, err)
+		log.CtxError(ctx, "查询提交历史失败: memberId=%s, homeworkId=%s, error=%v", submission.MemberId, submission.HomeworkID, err)
 		return nil, consts.ErrCall
 	}
 
@@ -1024,6 +1304,7 @@ func (s *HomeworkService) ModifySubmissionEvaluateSaveHistory(ctx context.Contex
 		MemberId:    submission.MemberId,
 		TeacherID:   submission.TeacherID,
 		Images:      submission.Images,
+		TextSubmit:  submission.TextSubmit,
 		GradeResult: submission.GradeResult,
 		Title:       submission.Title,
 		Text:        submission.Text,
@@ -1035,41 +1316,112 @@ func (s *HomeworkService) ModifySubmissionEvaluateSaveHistory(ctx context.Contex
 	}
 
 	if err := s.SubmissionMapper.Insert(ctx, newSubmission); err != nil {
-		log.Error("创建留痕提交记录失败: submissionId=%s, error=%v", req.SubmissionId, err)
+		log.CtxError(ctx, "创建留痕提交记录失败: submissionId=%s, error=%v", req.SubmissionId, err)
 		return nil, consts.ErrSubmitHomework
 	}
+	s.invalidateDownloadCache(ctx, req.SubmissionId)
+	s.Audit.Record(ctx, userMeta.GetUserId(), consts.AuditActionModifySubmission, req.SubmissionId,
+		fmt.Sprintf("留痕修改作业批改结果, homeworkId: %s, 新记录: %s", submission.HomeworkID, newSubmission.ID.Hex()))
+	s.Audit.RecordRevision(ctx, userMeta.GetUserId(), consts.RevisionTargetSubmission, req.SubmissionId, submission.Response, newSubmission.Response)
 
 	return &show.ModifySubmissionEvaluateSaveHistoryResp{
 		Id: newSubmission.ID.Hex(),
 	}, nil
 }
 
-// DownloadSubmissionEvaluate 下载作业提交的批改结果
-func (s *HomeworkService) DownloadSubmissionEvaluate(ctx context.Context, req *show.DownloadSubmissionEvaluateReq) (*show.DownloadSubmissionEvaluateResp, error) {
+// invalidateDownloadCache 批改结果修改后删除该提交相关的下载缓存，失败只记录日志
+func (s *HomeworkService) invalidateDownloadCache(ctx context.Context, submissionId string) {
+	if err := s.DownloadCache.DeleteBySubmission(ctx, submissionId); err != nil {
+		log.CtxError(ctx, "删除下载缓存失败, submissionId: %s, err: %v", submissionId, err)
+	}
+}
+
+// DownloadSubmissionEvaluate 下载作业提交的批改结果，format 为 docx 时导出可编辑的 Word。
+// 同一教师以相同格式与导出选项下载同一批提交时直接返回缓存的链接，批改结果修改后缓存随之失效
+func (s *HomeworkService) DownloadSubmissionEvaluate(ctx context.Context, req *show.DownloadSubmissionEvaluateWithFormatReq) (*show.DownloadSubmissionEvaluateResp, error) {
 	userMeta := adaptor.ExtractUserMeta(ctx)
 	if userMeta.GetUserId() == "" {
 		return nil, consts.ErrNotAuthentication
 	}
+	format, ok := exportFormat(req.Format)
+	if !ok {
+		return nil, consts.ErrInvalidParams
+	}
+
+	// 缓存按提交 id 组合，命中前也要确认这批提交都属于当前教师
+	if err := s.checkSubmissionsOwner(ctx, userMeta.GetUserId(), req.SubmissionIds); err != nil {
+		return nil, err
+	}
+
+	exclude, _ := json.Marshal(req.ExcludeOptions)
+	variant := fmt.Sprintf("%s|%s|%s", userMeta.GetUserId(), format, exclude)
+	if cached, err := s.DownloadCache.GetBatch(ctx, req.SubmissionIds, variant); err == nil {
+		return &show.DownloadSubmissionEvaluateResp{
+			Url:          cached.Url,
+			SessionToken: cached.SessionToken,
+		}, nil
+	}
+
+	url, sessionToken, err := s.exportSubmissions(ctx, userMeta.GetUserId(), req.SubmissionIds, req.ExcludeOptions, format)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.DownloadCache.SetBatch(ctx, req.SubmissionIds, variant, &show.DownloadEvaluateResp{
+		Url:          url,
+		SessionToken: sessionToken,
+	}); err != nil {
+		log.CtxError(ctx, "写入下载缓存失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+	}
+	return &show.DownloadSubmissionEvaluateResp{
+		Url:          url,
+		SessionToken: sessionToken,
+	}, nil
+}
+
+// checkSubmissionsOwner 校验一批提交都由该教师布置的作业产生，有任意一份不属于该教师时拒绝整批请求
+func (s *HomeworkService) checkSubmissionsOwner(ctx context.Context, userId string, submissionIds []string) error {
+	ids := lo.Uniq(submissionIds)
+	n, err := s.SubmissionMapper.CountOwned(ctx, userId, ids)
+	if errors.Is(err, consts.ErrInvalidObjectId) {
+		return consts.ErrInvalidParams
+	} else if err != nil {
+		log.CtxError(ctx, "校验提交归属失败, userId: %s, err: %v", userId, err)
+		return consts.ErrCall
+	}
+	if n != int64(len(ids)) {
+		return consts.ErrForbidden
+	}
+	return nil
+}
 
+// exportSubmissions 导出一批提交的批改结果，返回 (url, sessionToken, error)，只导出与第一份提交同一 Topic 的提交
+func (s *HomeworkService) exportSubmissions(ctx context.Context, userId string, submissionIds []string, exclude *show.EvaluateExcludeOptions, format string) (string, string, error) {
 	var submissions []*homework.HomeworkSubmission
 	var batchTopic int64 = -1
-	for _, submissionId := range req.SubmissionIds {
+	skippedSubject := false
+	for _, submissionId := range submissionIds {
 		submission, err := s.SubmissionMapper.FindOne(ctx, submissionId)
 		if err != nil {
-			log.Error("查询提交记录失败, submissionId: %s, error: %v", submissionId, err)
+			log.CtxError(ctx, "查询提交记录失败, submissionId: %s, error: %v", submissionId, err)
+			continue
+		}
+		// 导出模板按语文的批改结果排版，其他学科的结果结构不同
+		if submission.Subject != consts.SubjectChinese {
+			log.CtxError(ctx, "跳过不支持导出的学科, submissionId: %s, subject: %d", submissionId, submission.Subject)
+			skippedSubject = true
 			continue
 		}
 
 		hw, err := s.HomeworkMapper.FindOne(ctx, submission.HomeworkID)
 		if err != nil {
-			log.Error("查询作业失败, submissionId: %s, homeworkId: %s, error: %v", submissionId, submission.HomeworkID, err)
+			log.CtxError(ctx, "查询作业失败, submissionId: %s, homeworkId: %s, error: %v", submissionId, submission.HomeworkID, err)
 			continue
 		}
 
 		if batchTopic == -1 {
 			batchTopic = hw.Topic
 		} else if hw.Topic != batchTopic {
-			log.Error("跳过 Topic 不一致的提交, submissionId: %s, expectTopic: %d, actualTopic: %d", submissionId, batchTopic, hw.Topic)
+			log.CtxError(ctx, "跳过 Topic 不一致的提交, submissionId: %s, expectTopic: %d, actualTopic: %d", submissionId, batchTopic, hw.Topic)
 			continue
 		}
 
@@ -1077,12 +1429,16 @@ func (s *HomeworkService) DownloadSubmissionEvaluate(ctx context.Context, req *s
 	}
 
 	if len(submissions) == 0 {
-		return nil, consts.ErrNotFound
+		if skippedSubject {
+			return "", "", consts.ErrUnsupportedSubject
+		}
+		return "", "", consts.ErrNotFound
 	}
 
 	isWebTopic := batchTopic == consts.TopicTypeWeb
 
 	var essayList []map[string]any
+	var localEssays []export.Essay
 	for _, submission := range submissions {
 		if submission.Status != consts.StatusCompleted && submission.Status != consts.StatusModified {
 			continue
@@ -1090,25 +1446,26 @@ func (s *HomeworkService) DownloadSubmissionEvaluate(ctx context.Context, req *s
 
 		member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId)
 		if err != nil {
-			log.Error("获取学生信息失败: %v", err)
-			return nil, consts.ErrNotFound
+			log.CtxError(ctx, "获取学生信息失败: %v", err)
+			return "", "", consts.ErrNotFound
 		}
 
 		var data any
 		if isWebTopic {
 			webData, err := stateless.BuildWebExportEvaluateData(submission.Response)
 			if err != nil {
-				log.Error("解析网页端批改结果失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
+				log.CtxError(ctx, "解析网页端批改结果失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
 				continue
 			}
 			data = webData
 		} else {
-			exportResult, err := stateless.BuildExportEvaluateData(submission.Response, req.GetExcludeOptions())
+			exportResult, err := stateless.BuildExportEvaluateData(submission.Response, exclude)
 			if err != nil {
-				log.Error("解析批改结果失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
+				log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
 				continue
 			}
 			data = exportResult
+			localEssays = append(localEssays, export.Essay{Name: member.Name, Data: exportResult})
 		}
 
 		essayList = append(essayList, map[string]any{
@@ -1118,49 +1475,45 @@ func (s *HomeworkService) DownloadSubmissionEvaluate(ctx context.Context, req *s
 	}
 
 	if len(essayList) == 0 {
-		return nil, consts.ErrCall
+		return "", "", consts.ErrCall
 	}
 
-	client := util.GetHttpClient()
-	var (
-		_resp map[string]any
-		err   error
-	)
-	downloadData := map[string]any{
-		"essay_list": essayList,
-		"watermark":  0,
-	}
-	if isWebTopic {
-		_resp, err = client.OpencourseEssayExportPdf(ctx, downloadData)
-	} else {
-		_resp, err = client.EssayPolish(ctx, downloadData)
-	}
+	teacher, err := s.UserMapper.FindOne(ctx, userId)
 	if err != nil {
-		log.Error("调用批改结果下载服务失败: %v", err)
-		return nil, consts.ErrCall
+		log.CtxError(ctx, "获取教师水印设置失败, userId: %s, error: %v", userId, err)
 	}
+	watermark, watermarkText := downloadWatermark(teacher, false)
 
-	code := int64(_resp["code"].(float64))
-	if code != 200 {
-		msg := _resp["msg"].(string)
-		log.Error("批改结果下载服务返回错误: %s", msg)
-		return nil, consts.ErrCall
+	var _resp map[string]any
+	downloadData := map[string]any{
+		"essay_list":     essayList,
+		"watermark":      watermark,
+		"watermark_text": watermarkText,
 	}
-
-	url, urlOk := _resp["signedUrl"].(string)
-	sessionToken, tokenOk := _resp["sessionToken"].(string)
-
-	if !urlOk || !tokenOk {
-		log.Error("下游返回的url或sessionToken字段格式错误")
-		return nil, consts.ErrCall
+	switch {
+	case isWebTopic && format == consts.ExportFormatDocx:
+		_resp, err = s.Downstream.OpencourseEssayExportDocx(ctx, downloadData)
+	case isWebTopic:
+		_resp, err = s.Downstream.OpencourseEssayExportPdf(ctx, downloadData)
+	case format == consts.ExportFormatDocx:
+		_resp, err = s.Downstream.EssayPolishDocx(ctx, downloadData)
+	default:
+		_resp, err = s.Downstream.EssayPolish(ctx, downloadData)
 	}
-
-	result := &show.DownloadSubmissionEvaluateResp{
-		Url:          url,
-		SessionToken: sessionToken,
+	url, sessionToken, err := downloadUrlFromResp(_resp, err)
+	if err != nil {
+		log.CtxError(ctx, "批改结果下载服务失败: %v", err)
+		if isWebTopic || format != consts.ExportFormatPdf {
+			return "", "", consts.ErrCall
+		}
+		// 下游不可用时本地渲染简版 PDF 兜底
+		url, sessionToken, err = exportPdfLocally(ctx, s.Downstream, userId, localEssays)
+		if err != nil {
+			log.CtxError(ctx, "本地渲染批改结果失败: %v", err)
+			return "", "", consts.ErrCall
+		}
 	}
-
-	return result, nil
+	return url, sessionToken, nil
 }
 
 func (s *HomeworkService) DownloadLessonPlan(ctx context.Context, req *show.DownloadLessonPlanReq) (*show.DownloadLessonPlanResp, error) {
@@ -1171,93 +1524,48 @@ func (s *HomeworkService) DownloadLessonPlan(ctx context.Context, req *show.Down
 
 	homework, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("查询作业失败, homeworkId: %s, error: %v", req.HomeworkId, err)
+		log.CtxError(ctx, "查询作业失败, homeworkId: %s, error: %v", req.HomeworkId, err)
 		return nil, consts.ErrNotFound
 	}
 
 	if homework.CreatorID != userMeta.GetUserId() {
-		log.Error("用户无权下载此作业教案, userId: %s, creatorId: %s", userMeta.GetUserId(), homework.CreatorID)
+		log.CtxError(ctx, "用户无权下载此作业教案, userId: %s, creatorId: %s", userMeta.GetUserId(), homework.CreatorID)
 		return nil, consts.ErrForbidden
 	}
 
 	classInfo, err := s.ClassMapper.FindOne(ctx, homework.ClassID)
 	if err != nil {
-		log.Error("获取班级信息失败: %v", err)
+		log.CtxError(ctx, "获取班级信息失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	submissions, err := s.SubmissionMapper.FindByHomeworkID(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("查询作业提交记录失败, homeworkId: %s, error: %v", req.HomeworkId, err)
+		log.CtxError(ctx, "查询作业提交记录失败, homeworkId: %s, error: %v", req.HomeworkId, err)
 		return nil, consts.ErrCall
 	}
 
 	if len(submissions) == 0 {
-		log.Error("没有找到已批改的提交记录, homeworkId: %s", req.HomeworkId)
+		log.CtxError(ctx, "没有找到已批改的提交记录, homeworkId: %s", req.HomeworkId)
 		return nil, consts.ErrNotFound
 	}
 
-	var essayList []map[string]any
-	for _, submission := range submissions {
-		if submission.Status != consts.StatusCompleted && submission.Status != consts.StatusModified {
-			continue
-		}
-
-		var evaluateResult stateless.Evaluate
-		if err := json.Unmarshal([]byte(submission.Response), &evaluateResult); err != nil {
-			log.Error("解析批改结果失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
-			continue
-		}
-
-		member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId)
-		if err != nil {
-			log.Error("获取学生信息失败, memberId: %s, error: %v", submission.MemberId, err)
-			continue
-		}
-
-		essayData := map[string]any{
-			"student_name": member.Name,
-			"title":        submission.Title,
-			"scores": map[string]any{
-				"all":                  evaluateResult.AIEvaluation.ScoreEvaluation.Scores.All,
-				"allWithTotal":         evaluateResult.AIEvaluation.ScoreEvaluation.Scores.AllWithTotal,
-				"appearance":           evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Appearance,
-				"content":              evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Content,
-				"contentWithTotal":     evaluateResult.AIEvaluation.ScoreEvaluation.Scores.ContentWithTotal,
-				"developmentWithTotal": evaluateResult.AIEvaluation.ScoreEvaluation.Scores.DevelopmentWithTotal,
-				"expression":           evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Expression,
-				"expressionWithTotal":  evaluateResult.AIEvaluation.ScoreEvaluation.Scores.ExpressionWithTotal,
-				"structure":            evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Structure,
-				"structureWithTotal":   evaluateResult.AIEvaluation.ScoreEvaluation.Scores.StructureWithTotal,
-			},
-			"comments": map[string]any{
-				"appearance": evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Appearance,
-				"content":    evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Content,
-				"expression": evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Expression,
-				"structure":  evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Structure,
-			},
-			"text": evaluateResult.Text,
-		}
-
-		essayList = append(essayList, essayData)
-	}
-
+	essayList := s.lessonPlanEssays(ctx, submissions)
 	if len(essayList) == 0 {
-		log.Error("没有已完成批改的提交记录可用于生成教案, homeworkId: %s", req.HomeworkId)
+		log.CtxError(ctx, "没有已完成批改的提交记录可用于生成教案, homeworkId: %s", req.HomeworkId)
 		return nil, consts.ErrNotFound
 	}
 
-	client := util.GetHttpClient()
-	_resp, err := client.LessonPlan(ctx, classInfo, homework, essayList)
+	_resp, err := s.Downstream.LessonPlan(ctx, classInfo, homework, essayList, consts.LessonPlanDuration, "")
 	if err != nil {
-		log.Error("调用教案下载服务失败: %v", err)
+		log.CtxError(ctx, "调用教案下载服务失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
 	code := int64(_resp["code"].(float64))
 	if code != 200 {
 		msg := _resp["msg"].(string)
-		log.Error("教案下载服务返回错误: %s", msg)
+		log.CtxError(ctx, "教案下载服务返回错误: %s", msg)
 		return nil, consts.ErrCall
 	}
 
@@ -1265,7 +1573,7 @@ func (s *HomeworkService) DownloadLessonPlan(ctx context.Context, req *show.Down
 	sessionToken, tokenOk := _resp["sessionToken"].(string)
 
 	if !urlOk || !tokenOk {
-		log.Error("下游返回的url或sessionToken字段格式错误")
+		log.CtxError(ctx, "下游返回的url或sessionToken字段格式错误")
 		return nil, consts.ErrCall
 	}
 
@@ -1277,59 +1585,12 @@ func (s *HomeworkService) DownloadLessonPlan(ctx context.Context, req *show.Down
 	return result, nil
 }
 
-func (s *HomeworkService) processHomeworkSubmissions(ctx context.Context) {
-	defer s.processTimeoutSubmissions(ctx)
-
-	const maxConcurrency = 10
-	submissions, err := s.SubmissionMapper.FindByStatus(ctx, []int{consts.StatusInitialized})
-	if err != nil {
-		log.Error("查询待批改作业失败: %v", err)
-		return
-	}
-
-	if len(submissions) == 0 {
-		return
-	}
-
-	log.Info("找到 %d 个待批改的作业", len(submissions))
-
-	sem := make(chan struct{}, maxConcurrency)
-	var wg sync.WaitGroup
-
-	for _, submission := range submissions {
-		success, err := s.SubmissionMapper.TryUpdateStatusToGrading(ctx, submission.ID, consts.StatusInitialized, consts.StatusGrading)
-		if err != nil {
-			log.Error("更新作业状态失败: %v", err)
-			continue
-		}
-
-		if !success {
-			continue
-		}
-
-		sem <- struct{}{}
-		wg.Add(1)
-
-		go func(sub *homework.HomeworkSubmission) {
-			defer func() {
-				<-sem
-				wg.Done()
-			}()
-
-			s.processOneSubmission(ctx, sub)
-
-		}(submission)
-	}
-
-	wg.Wait()
-}
-
 // processOneSubmission 处理单个作业提交
 func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *homework.HomeworkSubmission) {
 	// 查询学生信息
 	member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId)
 	if err != nil {
-		log.Error("查询学生信息失败: %v", err)
+		log.CtxError(ctx, "查询学生信息失败: %v", err)
 		markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
 		return
 	}
@@ -1337,7 +1598,7 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 	// 查询老师批改次数
 	teacher, err := s.UserMapper.FindOne(ctx, submission.TeacherID)
 	if err != nil {
-		log.Error("查询老师信息失败: %v", err)
+		log.CtxError(ctx, "查询老师信息失败: %v", err)
 		markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
 		return
 	}
@@ -1356,16 +1617,50 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 	if !util.IsSupportHomeworkTopic(homework.Topic) {
 		return
 	}
+	// 不支持的学科在识别前失败，不消耗识别与批改
+	if !util.IsSupportSubject(homework.Subject, homework.Topic) {
+		markSubmissionFailed(ctx, submission, s.SubmissionMapper, consts.ErrUnsupportedSubject.Error())
+		return
+	}
+	submission.Subject = homework.Subject
 
-	if submission.SubmitType == consts.RecorrectTypeFirst || submission.SubmitType == consts.RecorrectTypeImage {
-		title, content, err := util.GetHttpClient().OcrExtract(ctx, submission.Images)
+	// 纯文本提交和已确认文本的提交不需要识别；学生或教师校正过这组图片时直接使用校正后的文本，视为已确认
+	needOcr := (submission.SubmitType == consts.RecorrectTypeFirst || submission.SubmitType == consts.RecorrectTypeImage) && !submission.TextConfirmed && !submission.TextSubmit
+	if needOcr {
+		if c := s.findCorrection(ctx, submission); c != nil {
+			submission.Title = c.Title
+			submission.Text = c.Text
+			submission.TextConfirmed = true
+			needOcr = false
+		}
+	}
+	if needOcr {
+		result, err := ocrWithCache(ctx, s.Downstream, s.OcrCache, submission.Images, "", nil)
 		if err != nil {
 			markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
 			return
 		}
-		submission.Title = title
-		submission.Text = content
+		submission.Title = result.Title
+		submission.Text = result.Content
+		submission.Neatness = result.Neatness
+
+		// 需要学生确认文本的提交先停在待确认状态，确认后重新进入批改队列
+		if submission.ConfirmText {
+			submission.Status = consts.StatusPendingText
+			if err = s.saveOwned(ctx, submission); err != nil {
+				markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
+			}
+			return
+		}
+	}
+
+	// 内容安全审核未通过时拦截批改，按批改失败通知教师
+	if s.Moderator.Check(ctx, consts.ModerationSceneHomework, submission.ID.Hex(), submission.TeacherID, submission.Title, submission.Text) != nil {
+		markSubmissionFailed(ctx, submission, s.SubmissionMapper, consts.ErrContentBlocked.Error())
+		return
 	}
+	s.detectAIText(ctx, submission)
+	checkWordLimit(submission, homework)
 
 	prompt := *homework.Description
 	essayType := *homework.EssayType
@@ -1375,14 +1670,16 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 
 	submission.UpdateTime = time.Now()
 	submission.Status = consts.StatusGrading
-	s.SubmissionMapper.Update(ctx, submission)
+	if err = s.saveOwned(ctx, submission); errors.Is(err, errLeaseLost) {
+		abandonGrade(submission)
+		return
+	}
 
 	resultChan := make(chan string, 100)
 	var finalResult string
 
 	// 网页端提交作业，自定义批改标准
 	if homework.Topic == consts.TopicTypeWeb {
-		httpClient := util.GetHttpClient()
 		data := map[string]any{
 			"student_id":      member.ID,
 			"student_name":    member.Name,
@@ -1400,30 +1697,26 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 		if submission.SubmitType == consts.RecorrectTypeAspect {
 			data["aspect"] = submission.Aspect
 		}
-		gradeSingleStudentResponse, err := httpClient.GradeSingleStudent(ctx, data)
+		gradeSingleStudentResponse, err := s.Downstream.GradeSingleStudent(ctx, data)
 		if err != nil {
 			markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
 			return
 		}
 		if submission.SubmitType != consts.RecorrectTypeAspect {
 			submission.GradeResult = cast.ToString(gradeSingleStudentResponse["score"].(float64))
+			applyNeatnessDeduction(submission, homework)
 		}
 		submission.Status = consts.StatusCompleted
 		submission.UpdateTime = time.Now()
 		resp, _ := json.Marshal(gradeSingleStudentResponse)
 		submission.Response = string(resp)
-		if err := s.SubmissionMapper.Update(ctx, submission); err != nil {
-			log.Error("保存批改结果失败: %v", err)
+		if err := s.saveGradeResult(ctx, submission, !user.IsVipActive(teacher)); err != nil {
+			log.CtxError(ctx, "保存批改结果失败: %v", err)
 			markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
 			return
 		}
-		// 扣除老师批改次数（VIP 跳过）
-		if !user.IsVipActive(teacher) {
-			if err := s.UserMapper.UpdateCount(ctx, submission.TeacherID, -1); err != nil {
-				log.Error("扣除老师批改次数失败: %v", err)
-			}
-		}
-		log.Info("网页端作业批改完成: %s", submission.ID.Hex())
+		log.CtxInfo(ctx, "网页端作业批改完成: %s", submission.ID.Hex())
+		s.notifyGradeDone(ctx, submission, member, homework)
 		return
 	}
 
@@ -1444,16 +1737,26 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 		ratio = util.CalculateScoreRatio(grade, totalScore)
 	}
 
-	// 调用批改服务
+	// 接管中断的批改时把已落盘的中间结果交给下游，已完成的步骤直接重放，不再重新计算
+	partial := submission.PartialResponse
+	if len(partial) > 0 {
+		log.CtxInfo(ctx, "恢复中断的批改: %s, 重放已有中间结果 %d 步", submission.ID.Hex(), len(partial))
+	}
+
+	// 调用批改服务，按学科路由到不同的批改链路
 	go func() {
 		defer close(resultChan)
-		util.GetHttpClient().EvaluateStream(ctx, submission.Title, submission.Text, &grade, &totalScore, &essayType, &prompt, &standard, ratio, resultChan)
+		if homework.Subject == consts.SubjectEnglish {
+			s.Downstream.EvaluateEnglishStream(ctx, submission.Title, submission.Text, &grade, &totalScore, &prompt, partial, resultChan)
+			return
+		}
+		s.Downstream.EvaluateStream(ctx, submission.Title, submission.Text, &grade, &totalScore, &essayType, &prompt, &standard, ratio, partial, resultChan)
 	}()
 
 	for jsonMessage := range resultChan {
 		var data map[string]any
 		if parseErr := json.Unmarshal([]byte(jsonMessage), &data); parseErr != nil {
-			log.Error("解析下游JSON消息失败: %v", parseErr)
+			log.CtxError(ctx, "解析下游JSON消息失败: %v", parseErr)
 			continue
 		}
 		// 检查消息类型并转发
@@ -1465,6 +1768,10 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 						finalResult = string(resultBytes)
 					}
 				}
+			case "progress":
+				if step, ok := data["step"].(string); ok {
+					s.savePartial(ctx, submission, step, data["data"])
+				}
 			case "error":
 				markSubmissionFailed(ctx, submission, s.SubmissionMapper, data["message"].(string))
 				return
@@ -1478,61 +1785,188 @@ func (s *HomeworkService) processOneSubmission(ctx context.Context, submission *
 		return
 	}
 
-	// 解析存储的批改结果到结构体
-	var evaluateResult stateless.Evaluate
-	if err := json.Unmarshal([]byte(finalResult), &evaluateResult); err != nil {
+	// 按学科解析批改结果，取出总分
+	allWithTotal, err := totalScoreOf(homework.Subject, finalResult)
+	if err != nil {
 		markSubmissionFailed(ctx, submission, s.SubmissionMapper, "批改结果不合法")
 		return
 	}
 
-	// 扣除老师批改次数（VIP 跳过）
-	if !user.IsVipActive(teacher) {
-		if err := s.UserMapper.UpdateCount(ctx, submission.TeacherID, -1); err != nil {
-			markSubmissionFailed(ctx, submission, s.SubmissionMapper, "扣除批改次数失败")
-			log.Error("扣除老师批改次数失败: %v", err)
-			return
-		}
-	}
-
 	// 保存批改结果
 	submission.Status = consts.StatusCompleted
 	submission.UpdateTime = time.Now()
 	submission.Response = finalResult
-	submission.GradeResult = strings.Split(evaluateResult.AIEvaluation.ScoreEvaluation.Scores.AllWithTotal, "/")[0]
-	if err := s.SubmissionMapper.Update(ctx, submission); err != nil {
-		log.Error("保存批改结果失败: %v", err)
+	submission.GradeResult = strings.Split(allWithTotal, "/")[0]
+	applyNeatnessDeduction(submission, homework)
+	if err := s.saveGradeResult(ctx, submission, !user.IsVipActive(teacher)); err != nil {
+		log.CtxError(ctx, "保存批改结果失败: %v", err)
 		markSubmissionFailed(ctx, submission, s.SubmissionMapper, err.Error())
 		return
 	}
 
-	log.Info("作业批改完成: %s", submission.ID.Hex())
+	log.CtxInfo(ctx, "作业批改完成: %s", submission.ID.Hex())
+	s.notifyGradeDone(ctx, submission, member, homework)
 }
 
-// processTimeoutSubmissions 处理超时任务
-func (s *HomeworkService) processTimeoutSubmissions(ctx context.Context) {
-	timeoutTime := time.Now().Add(-20 * time.Minute)
-	submissions, err := s.SubmissionMapper.FindTimeoutSubmissions(ctx, consts.StatusGrading, timeoutTime)
+// detectAIText 检测作文的 AI 生成疑似度，随批改状态一起保存；已检测过（如接管的批改）不重复检测，失败只记录日志
+func (s *HomeworkService) detectAIText(ctx context.Context, submission *homework.HomeworkSubmission) {
+	if !config.GetConfig().AiDetect.Enabled || submission.AiScore != nil {
+		return
+	}
+	score, err := s.Downstream.DetectAIText(ctx, submission.Title, submission.Text)
 	if err != nil {
+		log.CtxError(ctx, "AI 生成检测失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
 		return
 	}
+	submission.AiScore = &score
+}
 
-	for _, submission := range submissions {
-		submission.Status = consts.StatusInitialized
-		submission.UpdateTime = time.Now()
-		s.SubmissionMapper.Update(ctx, submission)
-		log.Info("重置超时任务: %s", submission.ID.Hex())
+// totalScoreOf 按学科解析批改结果，返回"得分/满分"形式的总分，语文为 stateless.Evaluate，英语为 stateless.EnglishEvaluate
+func totalScoreOf(subject int64, response string) (string, error) {
+	if subject == consts.SubjectEnglish {
+		var e stateless.EnglishEvaluate
+		if err := json.Unmarshal([]byte(response), &e); err != nil {
+			return "", err
+		}
+		return e.Scores.AllWithTotal, nil
+	}
+	var e stateless.Evaluate
+	if err := json.Unmarshal([]byte(response), &e); err != nil {
+		return "", err
+	}
+	return e.AIEvaluation.ScoreEvaluation.Scores.AllWithTotal, nil
+}
+
+// checkWordLimit 统计作文字数并按作业的字数要求标注，不符合要求时仍继续批改
+func checkWordLimit(submission *homework.HomeworkSubmission, h *homework.Homework) {
+	submission.WordCount = countWords(submission.Text)
+	switch {
+	case h.MinWords > 0 && submission.WordCount < h.MinWords:
+		submission.WordLimitMessage = fmt.Sprintf("未达字数要求（要求不少于 %d 字，实际 %d 字）", h.MinWords, submission.WordCount)
+	case h.MaxWords > 0 && submission.WordCount > h.MaxWords:
+		submission.WordLimitMessage = fmt.Sprintf("超出字数要求（要求不超过 %d 字，实际 %d 字）", h.MaxWords, submission.WordCount)
+	default:
+		submission.WordLimitMessage = ""
 	}
 }
 
+// countWords 统计作文字数，汉字按字计，其他文字按连续的字母数字计为一个词，标点与空白不计
+func countWords(text string) int64 {
+	var n int64
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			n++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				n++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return n
+}
+
+// applyNeatnessDeduction 作业设置书写工整度纳入总评时，从批改得分中扣除卷面分，
+// 满分 consts.NeatnessMaxDeduction 按 100 - 工整度 折算并取整，得分不低于 0；批改结果本身不变
+func applyNeatnessDeduction(submission *homework.HomeworkSubmission, h *homework.Homework) {
+	submission.NeatnessDeduction = 0
+	if !h.NeatnessInTotal || submission.Neatness == nil {
+		return
+	}
+	score, err := strconv.ParseFloat(strings.TrimSpace(submission.GradeResult), 64)
+	if err != nil {
+		log.Error("批改得分无法解析, 不扣卷面分, submissionId: %s, gradeResult: %s", submission.ID.Hex(), submission.GradeResult)
+		return
+	}
+	deduction := math.Min(math.Round(consts.NeatnessMaxDeduction*(100-*submission.Neatness)/100), score)
+	submission.NeatnessDeduction = deduction
+	submission.GradeResult = strconv.FormatFloat(score-deduction, 'f', -1, 64)
+}
+
+// StartSoftDeletePurge 启动作业、班级软删除记录的清理任务
+func (s *HomeworkService) StartSoftDeletePurge(ctx context.Context) {
+	softdelete.StartPurge(ctx, consts.SoftDeleteRetentionDays*24*time.Hour, map[string]softdelete.Purger{
+		homework.HomeworkCollectionName: s.HomeworkMapper,
+		class.ClassCollectionName:       s.ClassMapper,
+	})
+}
+
+// saveGradeResult 保存批改结果并写入扣除老师批改次数的事件，两者在同一事务中，VIP 不扣次数；
+// 租约已被接管时整个事务回滚，不重复扣次数
+func (s *HomeworkService) saveGradeResult(ctx context.Context, submission *homework.HomeworkSubmission, deductCount bool) error {
+	var event *outbox.Event
+	err := s.Transactor.Do(ctx, func(ctx context.Context) error {
+		if err := s.saveOwned(ctx, submission); err != nil {
+			return err
+		}
+		if !deductCount {
+			return nil
+		}
+		var err error
+		event, err = s.CountOutbox.Record(ctx, submission.TeacherID, -1, consts.CountReasonHomework, submission.ID.Hex())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if event != nil {
+		s.CountOutbox.Apply(ctx, event)
+	}
+	return nil
+}
+
+// DrainGrading 停机时等待在途批改完成，超过 consts.GradingDrainTimeout 仍未完成的提交重置为待批改，
+// 由其他实例或重启后重新领取，避免卡在批改中等待超时
+func (s *HomeworkService) DrainGrading(ctx context.Context) {
+	waitCtx, cancel := context.WithTimeout(ctx, consts.GradingDrainTimeout)
+	defer cancel()
+	for id, owner := range s.grading.wait(waitCtx) {
+		// 只释放仍由本实例持有的提交，不覆盖刚写入的结果；释放后本实例迟到的写入不再生效
+		ok, err := s.SubmissionMapper.Release(ctx, id, owner)
+		if err != nil {
+			log.CtxError(ctx, "停机重置作业状态失败, submissionId: %s, error: %v", id.Hex(), err)
+			continue
+		}
+		if ok {
+			log.CtxInfo(ctx, "停机重置未完成的批改: %s", id.Hex())
+			s.enqueueGrade(ctx, id)
+		}
+	}
+}
+
+// findCorrection 查找提交图片的 OCR 校正文本，先找绑定的学生，再找布置作业的教师，没有时返回 nil
+func (s *HomeworkService) findCorrection(ctx context.Context, submission *homework.HomeworkSubmission) *ocr.Correction {
+	userIds := make([]string, 0, 2)
+	if member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId); err == nil && member.UserID != nil {
+		userIds = append(userIds, *member.UserID)
+	}
+	userIds = append(userIds, submission.TeacherID)
+	for _, userId := range userIds {
+		if c, err := s.CorrectionMapper.FindByImages(ctx, userId, submission.Images); err == nil {
+			return c
+		}
+	}
+	return nil
+}
+
 func markSubmissionFailed(ctx context.Context, submission *homework.HomeworkSubmission, submissionMapper *homework.SubmissionMongoMapper, reason string) {
 	submission.Status = consts.StatusFailed
 	submission.Message = reason
 	submission.UpdateTime = time.Now()
 
-	if err := submissionMapper.Update(ctx, submission); err != nil {
-		log.Error("标记作业失败状态失败: %v", err)
-	} else {
-		log.Info("标记作业失败: %s, 原因: %s", submission.ID.Hex(), reason)
+	ok, err := submissionMapper.UpdateOwned(ctx, submission)
+	switch {
+	case err != nil:
+		log.CtxError(ctx, "标记作业失败状态失败: %v", err)
+	case !ok:
+		abandonGrade(submission)
+	default:
+		log.CtxInfo(ctx, "标记作业失败: %s, 原因: %s", submission.ID.Hex(), reason)
 	}
 }
 
@@ -1542,32 +1976,28 @@ func (s *HomeworkService) DeleteHomework(ctx context.Context, req *show.DeleteHo
 		return nil, consts.ErrNotAuthentication
 	}
 
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		log.Error("用户不是教师，无权删除作业, userId: %s, role: %d", userMeta.GetUserId(), user.Role)
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("作业不存在: %v", err)
+		log.CtxError(ctx, "作业不存在: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	if h.CreatorID != userMeta.GetUserId() {
-		log.Error("用户无权删除此作业, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
+		log.CtxError(ctx, "用户无权删除此作业, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
 		return nil, consts.ErrForbidden
 	}
 
 	err = s.HomeworkMapper.Delete(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("删除作业失败: %v", err)
+		log.CtxError(ctx, "删除作业失败: %v", err)
 		return nil, consts.ErrCall
 	}
+	s.Audit.Record(ctx, userMeta.GetUserId(), consts.AuditActionDeleteHomework, req.HomeworkId, fmt.Sprintf("删除作业: %s", h.Title))
 
 	return &show.Response{
 		Code: 0,
@@ -1581,40 +2011,35 @@ func (s *HomeworkService) GetHomeworkStatistics(ctx context.Context, req *show.G
 		return nil, consts.ErrNotAuthentication
 	}
 
-	user, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	_, err := CurrentUser(ctx)
 	if err != nil {
-		log.Error("获取用户信息失败: %v", err)
-		return nil, consts.ErrNotFound
-	}
-	if user.Role != consts.RoleTeacher {
-		log.Error("用户不是教师，无权查看统计, userId: %s, role: %d", userMeta.GetUserId(), user.Role)
-		return nil, consts.ErrNotAuthentication
+		return nil, err
 	}
 
 	h, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("作业不存在: %v", err)
+		log.CtxError(ctx, "作业不存在: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	if h.CreatorID != userMeta.GetUserId() {
-		log.Error("用户无权查看此作业统计, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
+		log.CtxError(ctx, "用户无权查看此作业统计, userId: %s, creatorId: %s", userMeta.GetUserId(), h.CreatorID)
 		return nil, consts.ErrForbidden
 	}
 	if h.Topic == consts.TopicTypeWeb {
-		log.Error("课堂练习批改结果结构不支持班级统计, homeworkId: %s, topic: %d", req.HomeworkId, h.Topic)
+		log.CtxError(ctx, "课堂练习批改结果结构不支持班级统计, homeworkId: %s, topic: %d", req.HomeworkId, h.Topic)
 		return nil, consts.ErrInvalidParams
 	}
 
 	classInfo, err := s.ClassMapper.FindOne(ctx, h.ClassID)
 	if err != nil {
-		log.Error("获取班级信息失败: %v", err)
+		log.CtxError(ctx, "获取班级信息失败: %v", err)
 		return nil, consts.ErrNotFound
 	}
 
 	submissions, err := s.SubmissionMapper.FindByHomeworkID(ctx, req.HomeworkId)
 	if err != nil {
-		log.Error("获取作业提交列表失败: %v", err)
+		log.CtxError(ctx, "获取作业提交列表失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
@@ -1631,9 +2056,13 @@ func (s *HomeworkService) GetHomeworkStatistics(ctx context.Context, req *show.G
 
 	statisticsData := make([]map[string]any, 0, len(completedSubmissions))
 	for _, sub := range completedSubmissions {
+		// 统计服务按语文的词句和分项评价分析，其他学科的提交不参与
+		if sub.Subject != consts.SubjectChinese {
+			continue
+		}
 		var evaluateResult stateless.Evaluate
 		if err := json.Unmarshal([]byte(sub.Response), &evaluateResult); err != nil {
-			log.Error("解析批改结果失败, submissionId: %s, error: %v", sub.ID.Hex(), err)
+			log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", sub.ID.Hex(), err)
 			continue
 		}
 
@@ -1644,17 +2073,16 @@ func (s *HomeworkService) GetHomeworkStatistics(ctx context.Context, req *show.G
 		statisticsData = append(statisticsData, studentData)
 	}
 	if len(statisticsData) == 0 {
-		log.Error("没有可用于统计的有效批改结果, homeworkId: %s", req.HomeworkId)
+		log.CtxError(ctx, "没有可用于统计的有效批改结果, homeworkId: %s", req.HomeworkId)
 		return nil, consts.ErrNoCompletedSubmissions
 	}
 
-	client := util.GetHttpClient()
-	resp, err := client.AnalyzeClassStatistics(ctx, map[string]any{
+	resp, err := s.Downstream.AnalyzeClassStatistics(ctx, map[string]any{
 		"submittedStudents": statisticsData,
 		"totalStudents":     classInfo.MemberCount,
 	})
 	if err != nil {
-		log.Error("调用统计服务失败: %v", err)
+		log.CtxError(ctx, "调用统计服务失败: %v", err)
 		return nil, consts.ErrCall
 	}
 
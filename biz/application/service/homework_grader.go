@@ -0,0 +1,221 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errLeaseLost 批改租约已被其他实例接管，本次批改的结果不再写入
+var errLeaseLost = errors.New("批改租约已被接管")
+
+// StartGrader 启动作业批改消费者：提交进入待批改时投递到任务总线，各实例的消费者通过消费组领取，
+// 再以 findAndModify 原子领取提交，同一提交只会被一个实例批改；另有定时检查补投租约过期、投递失败或丢失的任务
+func (s *HomeworkService) StartGrader(ctx context.Context) error {
+	log.CtxInfo(ctx, "启动作业批改消费者")
+	if err := s.GradeQueue.EnsureGroup(ctx); err != nil {
+		log.CtxError(ctx, "创建批改任务消费组失败: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	consumer := fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	for i := 0; i < consts.GradeWorkers; i++ {
+		go s.consumeGradeTasks(ctx, consumer)
+	}
+
+	go func() {
+		ticker := time.NewTicker(consts.GradeSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sweepSubmissions(context.WithoutCancel(ctx))
+			}
+		}
+	}()
+
+	return nil
+}
+
+// consumeGradeTasks stop 结束后不再领取新任务，已领取的批改使用不随停机取消的 ctx 继续执行
+func (s *HomeworkService) consumeGradeTasks(stop context.Context, consumer string) {
+	ctx := context.WithoutCancel(stop)
+	for stop.Err() == nil {
+		tasks, err := s.GradeQueue.Read(ctx, consumer, 1, consts.GradeQueueBlock)
+		if err != nil {
+			log.Error("领取批改任务失败: %v", err)
+			select {
+			case <-stop.Done():
+			case <-time.After(consts.GradeQueueBlock):
+			}
+			continue
+		}
+		for _, task := range tasks {
+			s.handleGradeTask(ctx, consumer, task)
+		}
+	}
+}
+
+// handleGradeTask 领取后立即确认消息，是否批改以原子领取提交的结果为准：重复投递或已被其他实例领取的任务直接跳过。
+// 领取时记录 owner 和租约，批改期间定时续约，批改结果只在仍持有租约时写入；
+// 领取后实例宕机的任务在租约过期后由 sweepSubmissions 重新投递，被其他实例接管
+func (s *HomeworkService) handleGradeTask(ctx context.Context, consumer string, task *cache.GradeTask) {
+	if err := s.GradeQueue.Ack(ctx, task.Id); err != nil {
+		log.CtxError(ctx, "确认批改任务失败, taskId: %s, error: %v", task.Id, err)
+	}
+	id, err := primitive.ObjectIDFromHex(task.SubmissionId)
+	if err != nil {
+		log.CtxError(ctx, "批改任务的提交 id 无效, taskId: %s, submissionId: %s", task.Id, task.SubmissionId)
+		return
+	}
+
+	// owner 精确到消息，同一实例重复领取同一提交时也能区分
+	owner := consumer + "/" + task.Id
+	submission, err := s.SubmissionMapper.Claim(ctx, id, owner, consts.GradeLease)
+	if err != nil {
+		log.CtxError(ctx, "领取待批改作业失败, submissionId: %s, error: %v", task.SubmissionId, err)
+		return
+	}
+	if submission == nil {
+		return
+	}
+	s.grading.add(id, owner)
+	defer s.grading.done(id)
+
+	renewCtx, stopRenew := context.WithCancel(ctx)
+	defer stopRenew()
+	go s.renewLease(renewCtx, id, owner)
+
+	s.processOneSubmission(ctx, submission)
+	switch submission.Status {
+	case consts.StatusCompleted:
+		metrics.EvaluateDone("homework", true)
+	case consts.StatusFailed:
+		metrics.EvaluateDone("homework", false)
+		s.notifyGradeFailed(ctx, submission)
+	}
+}
+
+// renewLease 批改期间定时续约，租约已被接管时停止续约，之后的写入由 UpdateOwned 拒绝
+func (s *HomeworkService) renewLease(ctx context.Context, id primitive.ObjectID, owner string) {
+	ticker := time.NewTicker(consts.GradeLease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			ok, err := s.SubmissionMapper.RenewLease(ctx, id, owner, consts.GradeLease)
+			if err != nil {
+				log.CtxError(ctx, "批改续约失败, submissionId: %s, error: %v", id.Hex(), err)
+				continue
+			}
+			if !ok {
+				log.CtxInfo(ctx, "批改租约已被接管, submissionId: %s, owner: %s", id.Hex(), owner)
+				return
+			}
+		}
+	}
+}
+
+// enqueueGrade 投递批改任务，失败时只打日志，由 sweepSubmissions 补投
+func (s *HomeworkService) enqueueGrade(ctx context.Context, id primitive.ObjectID) {
+	if err := s.GradeQueue.Publish(ctx, id.Hex()); err != nil {
+		log.CtxError(ctx, "投递批改任务失败, submissionId: %s, error: %v", id.Hex(), err)
+	}
+}
+
+// sweepSubmissions 重新投递租约过期的批改和长时间未被领取的待批改提交，没有租约的超时批改重置为待批改
+func (s *HomeworkService) sweepSubmissions(ctx context.Context) {
+	expired, err := s.SubmissionMapper.FindExpiredLeases(ctx, time.Now())
+	if err != nil {
+		log.CtxError(ctx, "查询租约过期的批改失败: %v", err)
+	}
+	for _, submission := range expired {
+		log.CtxInfo(ctx, "批改租约过期, 重新投递: %s, owner: %s", submission.ID.Hex(), submission.Owner)
+		s.enqueueGrade(ctx, submission.ID)
+	}
+
+	timeouts, err := s.SubmissionMapper.FindTimeoutSubmissions(ctx, consts.StatusGrading, time.Now().Add(-consts.GradeTimeout))
+	if err != nil {
+		log.CtxError(ctx, "查询超时批改失败: %v", err)
+	}
+	for _, submission := range timeouts {
+		// 有租约的批改由续约和租约过期处理，这里只处理没有租约的旧数据
+		if !submission.LeaseExpire.IsZero() {
+			continue
+		}
+		ok, err := s.SubmissionMapper.TryUpdateStatusToGrading(ctx, submission.ID, consts.StatusGrading, consts.StatusInitialized)
+		if err != nil || !ok {
+			continue
+		}
+		log.CtxInfo(ctx, "重置超时任务: %s", submission.ID.Hex())
+		s.enqueueGrade(ctx, submission.ID)
+	}
+
+	pending, err := s.SubmissionMapper.FindByStatus(ctx, []int{consts.StatusInitialized})
+	if err != nil {
+		log.CtxError(ctx, "查询待批改作业失败: %v", err)
+		return
+	}
+	metrics.GradingQueue.WithLabelValues("homework").Set(float64(len(pending)))
+	for _, submission := range pending {
+		if time.Since(submission.UpdateTime) > consts.GradeResendAfter {
+			log.CtxInfo(ctx, "重新投递未被领取的批改任务: %s", submission.ID.Hex())
+			s.enqueueGrade(ctx, submission.ID)
+		}
+	}
+}
+
+// saveOwned 仅在仍持有批改租约时写入提交，租约已被接管时返回 errLeaseLost
+func (s *HomeworkService) saveOwned(ctx context.Context, submission *homework.HomeworkSubmission) error {
+	ok, err := s.SubmissionMapper.UpdateOwned(ctx, submission)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// abandonGrade 租约已被接管时放弃本次批改，状态恢复为批改中，结果和通知由接管的实例负责
+func abandonGrade(submission *homework.HomeworkSubmission) {
+	log.Info("批改租约已被接管, 放弃本次批改: %s, owner: %s", submission.ID.Hex(), submission.Owner)
+	submission.Status = consts.StatusGrading
+}
+
+// savePartial 增量落盘一步批改中间结果，实例崩溃后接管的实例可以据此恢复，写入失败只打日志不影响批改
+func (s *HomeworkService) savePartial(ctx context.Context, submission *homework.HomeworkSubmission, step string, data any) {
+	// step 作为字段路径的一部分，不接受空值和 mongo 的特殊字符
+	if step == "" || strings.ContainsAny(step, ".$") {
+		return
+	}
+	ok, err := s.SubmissionMapper.SavePartial(ctx, submission.ID, submission.Owner, step, data)
+	if err != nil {
+		log.CtxError(ctx, "保存批改中间结果失败, submissionId: %s, step: %s, error: %v", submission.ID.Hex(), step, err)
+		return
+	}
+	if !ok {
+		log.CtxInfo(ctx, "批改租约已被接管, 不再保存中间结果: %s, step: %s", submission.ID.Hex(), step)
+		return
+	}
+	if submission.PartialResponse == nil {
+		submission.PartialResponse = make(map[string]any)
+	}
+	submission.PartialResponse[step] = data
+}
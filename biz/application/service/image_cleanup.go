@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// ImageCleaner 作业提交原图的生命周期清理：批改完成超过保留天数的提交删除 cos 上的原图，
+// 每个提交的清理结果写入 audit.ImageCleanup，删除失败的图片留在提交中等下次重试
+type ImageCleaner struct {
+	SubmissionMapper *homework.SubmissionMongoMapper
+	CleanupMapper    *audit.ImageCleanupMongoMapper
+	Downstream       util.DownstreamClient
+}
+
+var ImageCleanerSet = wire.NewSet(
+	wire.Struct(new(ImageCleaner), "*"),
+)
+
+// Start 启动原图清理任务，每 consts.ImageCleanupInterval 执行一次，未开启 ImageTTL.Enabled 时跳过，配置热加载后生效
+func (c *ImageCleaner) Start(ctx context.Context) {
+	log.CtxInfo(ctx, "启动作业提交原图清理任务")
+	go func() {
+		ticker := time.NewTicker(consts.ImageCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if cfg := config.GetConfig().ImageTTL; cfg.Enabled {
+					c.run(ctx, cfg)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// run 分批清理，某批没有清理成功的提交时结束，避免反复处理同一批失败的提交
+func (c *ImageCleaner) run(ctx context.Context, cfg config.ImageTTL) {
+	status := []int{consts.StatusCompleted}
+	if !cfg.KeepModified {
+		status = append(status, consts.StatusModified)
+	}
+	before := time.Now().AddDate(0, 0, -cfg.GetDays())
+
+	total := 0
+	for {
+		submissions, err := c.SubmissionMapper.FindImageExpired(ctx, status, before, consts.ImageCleanupBatchSize)
+		if err != nil {
+			log.CtxError(ctx, "查询待清理原图的作业提交失败: %v", err)
+			break
+		}
+		cleaned := 0
+		for _, s := range submissions {
+			if c.cleanSubmission(ctx, s) {
+				cleaned++
+			}
+		}
+		total += cleaned
+		if len(submissions) < consts.ImageCleanupBatchSize || cleaned == 0 {
+			break
+		}
+	}
+	log.CtxInfo(ctx, "作业提交原图清理完成, before: %s, submissions: %d", before.Format(time.DateOnly), total)
+}
+
+// cleanSubmission 先认领再删除一个提交的全部原图，最后写回删除失败的图片并记录清理日志；
+// 认领失败（提交已被更新）时不删除，不是本服务 cos 目录下的图片不删除，只从提交中移除
+func (c *ImageCleaner) cleanSubmission(ctx context.Context, s *homework.HomeworkSubmission) bool {
+	ok, err := c.SubmissionMapper.ClaimImages(ctx, s)
+	if err != nil {
+		log.CtxError(ctx, "认领作业提交原图失败, submissionId: %s, error: %v", s.ID.Hex(), err)
+		return false
+	}
+	if !ok {
+		return false
+	}
+
+	var failed []string
+	for _, image := range s.PurgingImages {
+		key, ok := cosKeyFromUrl(image)
+		if !ok {
+			continue
+		}
+		if err := deleteCosObject(ctx, c.Downstream, key); err != nil {
+			log.CtxError(ctx, "删除作业提交原图失败, submissionId: %s, key: %s, error: %v", s.ID.Hex(), key, err)
+			failed = append(failed, image)
+		}
+	}
+
+	if err = c.SubmissionMapper.FinishPurgeImages(ctx, s.ID, failed); err != nil {
+		log.CtxError(ctx, "更新作业提交原图失败, submissionId: %s, error: %v", s.ID.Hex(), err)
+		return false
+	}
+	err = c.CleanupMapper.Insert(ctx, &audit.ImageCleanup{
+		SubmissionID: s.ID.Hex(),
+		HomeworkID:   s.HomeworkID,
+		MemberID:     s.MemberId,
+		Images:       s.PurgingImages,
+		Failed:       failed,
+		CompleteTime: s.UpdateTime,
+	})
+	if err != nil {
+		log.CtxError(ctx, "写入原图清理日志失败, submissionId: %s, error: %v", s.ID.Hex(), err)
+	}
+	return len(failed) < len(s.PurgingImages)
+}
+
+// cosKeyFromUrl 从图片 url 中解析本服务 cos 目录下的对象路径，其他来源的图片返回 false
+func cosKeyFromUrl(image string) (string, bool) {
+	u, err := url.Parse(image)
+	if err != nil {
+		return "", false
+	}
+	key := strings.TrimPrefix(u.Path, "/")
+	return key, strings.HasPrefix(key, fmt.Sprintf("essays_%s/", config.GetConfig().State))
+}
+
+// deleteCosObject 为单个对象申请临时密钥并删除
+func deleteCosObject(ctx context.Context, client util.DownstreamClient, key string) error {
+	sts, err := client.GenCosSts(ctx, key)
+	if err != nil {
+		return fmt.Errorf("申请cos临时密钥失败: %w", err)
+	}
+	cred, ok := sts["data"].(map[string]any)
+	if code, _ := sts["code"].(float64); code != 0 || !ok {
+		return errors.New("申请cos临时密钥失败")
+	}
+	deleteUrl, err := genSignedUrl(ctx, client, cred, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+	return client.DeleteObject(ctx, deleteUrl)
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util/log"
+	"strings"
+	"unicode/utf8"
+)
+
+// GenerateLessonPlan 教师选择作业中的已批改提交生成讲评教案，教案下载地址会保存下来
+func (s *HomeworkService) GenerateLessonPlan(ctx context.Context, req *show.GenerateLessonPlanReq) (*show.GenerateLessonPlanResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	hw, err := s.HomeworkMapper.FindOne(ctx, req.HomeworkId)
+	if err != nil {
+		log.CtxError(ctx, "查询作业失败, homeworkId: %s, error: %v", req.HomeworkId, err)
+		return nil, consts.ErrNotFound
+	}
+	if hw.CreatorID != userMeta.GetUserId() {
+		log.CtxError(ctx, "用户无权生成此作业教案, userId: %s, creatorId: %s", userMeta.GetUserId(), hw.CreatorID)
+		return nil, consts.ErrForbidden
+	}
+
+	duration, focus, err := lessonPlanParams(req.Duration, req.Focus)
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := s.selectLessonPlanSubmissions(ctx, hw.ID.Hex(), req.SubmissionIds)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveLessonPlan(ctx, &homework.LessonPlan{
+		HomeworkID: hw.ID.Hex(),
+		ClassID:    hw.ClassID,
+		CreatorID:  userMeta.GetUserId(),
+		Duration:   duration,
+		Focus:      focus,
+	}, hw, submissions)
+}
+
+// RegenerateLessonPlan 沿用原教案选择的提交，按新的课时时长和侧重点重新生成一份教案
+func (s *HomeworkService) RegenerateLessonPlan(ctx context.Context, req *show.RegenerateLessonPlanReq) (*show.GenerateLessonPlanResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	origin, err := s.LessonPlanMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if origin.CreatorID != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+	duration, focus, err := lessonPlanParams(req.Duration, req.Focus)
+	if err != nil {
+		return nil, err
+	}
+
+	hw, err := s.HomeworkMapper.FindOne(ctx, origin.HomeworkID)
+	if err != nil {
+		log.CtxError(ctx, "查询作业失败, homeworkId: %s, error: %v", origin.HomeworkID, err)
+		return nil, consts.ErrNotFound
+	}
+	submissions, err := s.selectLessonPlanSubmissions(ctx, hw.ID.Hex(), origin.SubmissionIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.saveLessonPlan(ctx, &homework.LessonPlan{
+		HomeworkID: origin.HomeworkID,
+		ClassID:    origin.ClassID,
+		CreatorID:  origin.CreatorID,
+		Duration:   duration,
+		Focus:      focus,
+	}, hw, submissions)
+}
+
+// ListLessonPlans 分页查询教师生成过的教案
+func (s *HomeworkService) ListLessonPlans(ctx context.Context, req *show.ListLessonPlansReq) (*show.ListLessonPlansResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	plans, total, err := s.LessonPlanMapper.FindByCreator(ctx, userMeta.GetUserId(), req.HomeworkId, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询教案列表失败, userId: %s, error: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+
+	titles := make(map[string]string)
+	dtos := make([]*show.LessonPlan, 0, len(plans))
+	for _, plan := range plans {
+		title, ok := titles[plan.HomeworkID]
+		if !ok {
+			if hw, err := s.HomeworkMapper.FindOne(ctx, plan.HomeworkID); err == nil {
+				title = hw.Title
+			}
+			titles[plan.HomeworkID] = title
+		}
+		dto := lessonPlanDTO(plan)
+		dto.HomeworkTitle = title
+		dto.Url, dto.SessionToken = "", ""
+		dtos = append(dtos, dto)
+	}
+	return &show.ListLessonPlansResp{
+		Code:  0,
+		Msg:   "success",
+		Plans: dtos,
+		Total: total,
+	}, nil
+}
+
+// GetLessonPlan 查看一份教案及其下载地址
+func (s *HomeworkService) GetLessonPlan(ctx context.Context, req *show.GetLessonPlanReq) (*show.GetLessonPlanResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	plan, err := s.LessonPlanMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if plan.CreatorID != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+
+	dto := lessonPlanDTO(plan)
+	if hw, err := s.HomeworkMapper.FindOne(ctx, plan.HomeworkID); err == nil {
+		dto.HomeworkTitle = hw.Title
+	}
+	return &show.GetLessonPlanResp{
+		Code: 0,
+		Msg:  "success",
+		Plan: dto,
+	}, nil
+}
+
+// lessonPlanParams 校验课时时长与侧重点，时长为 0 时使用默认值
+func lessonPlanParams(duration int64, focus string) (int64, string, error) {
+	if duration == 0 {
+		duration = consts.LessonPlanDuration
+	}
+	focus = strings.TrimSpace(focus)
+	if duration < 0 || duration > consts.LessonPlanMaxDuration || utf8.RuneCountInString(focus) > consts.LessonPlanFocusMaxLength {
+		return 0, "", consts.ErrInvalidParams
+	}
+	return duration, focus, nil
+}
+
+// saveLessonPlan 生成教案并保存
+func (s *HomeworkService) saveLessonPlan(ctx context.Context, plan *homework.LessonPlan, hw *homework.Homework, submissions []*homework.HomeworkSubmission) (*show.GenerateLessonPlanResp, error) {
+	if err := s.generateLessonPlan(ctx, plan, hw, submissions); err != nil {
+		return nil, err
+	}
+	if err := s.LessonPlanMapper.Insert(ctx, plan); err != nil {
+		log.CtxError(ctx, "保存教案失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+		return nil, consts.ErrCall
+	}
+
+	return &show.GenerateLessonPlanResp{
+		Code:         0,
+		Msg:          "success",
+		Id:           plan.ID.Hex(),
+		Url:          plan.Url,
+		SessionToken: plan.SessionToken,
+	}, nil
+}
+
+func lessonPlanDTO(plan *homework.LessonPlan) *show.LessonPlan {
+	return &show.LessonPlan{
+		Id:            plan.ID.Hex(),
+		HomeworkId:    plan.HomeworkID,
+		ClassId:       plan.ClassID,
+		SubmissionIds: plan.SubmissionIDs,
+		Duration:      plan.Duration,
+		Focus:         plan.Focus,
+		Url:           plan.Url,
+		SessionToken:  plan.SessionToken,
+		CreateTime:    plan.CreateTime.Unix(),
+	}
+}
+
+// selectLessonPlanSubmissions 返回作业下选定的已批改提交，未选择时返回全部已批改提交
+func (s *HomeworkService) selectLessonPlanSubmissions(ctx context.Context, homeworkId string, submissionIds []string) ([]*homework.HomeworkSubmission, error) {
+	submissions, err := s.SubmissionMapper.FindAllByHomework(ctx, homeworkId, &[]int{consts.StatusCompleted, consts.StatusModified})
+	if err != nil {
+		log.CtxError(ctx, "查询作业提交记录失败, homeworkId: %s, error: %v", homeworkId, err)
+		return nil, consts.ErrCall
+	}
+	if len(submissionIds) > 0 {
+		selected := make(map[string]bool, len(submissionIds))
+		for _, id := range submissionIds {
+			selected[id] = true
+		}
+		filtered := make([]*homework.HomeworkSubmission, 0, len(submissionIds))
+		for _, sub := range submissions {
+			if selected[sub.ID.Hex()] {
+				filtered = append(filtered, sub)
+			}
+		}
+		// 选择了不属于该作业或未批改完成的提交
+		if len(filtered) != len(selected) {
+			return nil, consts.ErrInvalidParams
+		}
+		submissions = filtered
+	}
+	if len(submissions) == 0 {
+		return nil, consts.ErrNoCompletedSubmissions
+	}
+	return submissions, nil
+}
+
+// generateLessonPlan 组装 essay_list 调用下游生成教案，结果回填到 plan
+func (s *HomeworkService) generateLessonPlan(ctx context.Context, plan *homework.LessonPlan, hw *homework.Homework, submissions []*homework.HomeworkSubmission) error {
+	classInfo, err := s.ClassMapper.FindOne(ctx, hw.ClassID)
+	if err != nil {
+		log.CtxError(ctx, "获取班级信息失败: %v", err)
+		return consts.ErrNotFound
+	}
+
+	essayList := s.lessonPlanEssays(ctx, submissions)
+	if len(essayList) == 0 {
+		return consts.ErrNoCompletedSubmissions
+	}
+
+	url, sessionToken, err := downloadUrlFromResp(s.Downstream.LessonPlan(ctx, classInfo, hw, essayList, plan.Duration, plan.Focus))
+	if err != nil {
+		log.CtxError(ctx, "生成教案失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+		return consts.ErrCall
+	}
+
+	plan.SubmissionIDs = make([]string, 0, len(submissions))
+	for _, sub := range submissions {
+		plan.SubmissionIDs = append(plan.SubmissionIDs, sub.ID.Hex())
+	}
+	plan.Url, plan.SessionToken = url, sessionToken
+	return nil
+}
+
+// lessonPlanEssays 把已批改提交转换成教案服务需要的 essay_list，解析失败和非语文的提交跳过
+func (s *HomeworkService) lessonPlanEssays(ctx context.Context, submissions []*homework.HomeworkSubmission) []map[string]any {
+	var essayList []map[string]any
+	for _, submission := range submissions {
+		if submission.Status != consts.StatusCompleted && submission.Status != consts.StatusModified {
+			continue
+		}
+		// 教案服务按语文的分项得分与评语生成讲评，其他学科的提交不参与
+		if submission.Subject != consts.SubjectChinese {
+			continue
+		}
+
+		var evaluateResult stateless.Evaluate
+		if err := json.Unmarshal([]byte(submission.Response), &evaluateResult); err != nil {
+			log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", submission.ID.Hex(), err)
+			continue
+		}
+
+		member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId)
+		if err != nil {
+			log.CtxError(ctx, "获取学生信息失败, memberId: %s, error: %v", submission.MemberId, err)
+			continue
+		}
+
+		essayData := map[string]any{
+			"student_name": member.Name,
+			"title":        submission.Title,
+			"scores": map[string]any{
+				"all":                  evaluateResult.AIEvaluation.ScoreEvaluation.Scores.All,
+				"allWithTotal":         evaluateResult.AIEvaluation.ScoreEvaluation.Scores.AllWithTotal,
+				"appearance":           evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Appearance,
+				"content":              evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Content,
+				"contentWithTotal":     evaluateResult.AIEvaluation.ScoreEvaluation.Scores.ContentWithTotal,
+				"developmentWithTotal": evaluateResult.AIEvaluation.ScoreEvaluation.Scores.DevelopmentWithTotal,
+				"expression":           evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Expression,
+				"expressionWithTotal":  evaluateResult.AIEvaluation.ScoreEvaluation.Scores.ExpressionWithTotal,
+				"structure":            evaluateResult.AIEvaluation.ScoreEvaluation.Scores.Structure,
+				"structureWithTotal":   evaluateResult.AIEvaluation.ScoreEvaluation.Scores.StructureWithTotal,
+			},
+			"comments": map[string]any{
+				"appearance": evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Appearance,
+				"content":    evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Content,
+				"expression": evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Expression,
+				"structure":  evaluateResult.AIEvaluation.ScoreEvaluation.Comments.Structure,
+			},
+			"text": evaluateResult.Text,
+		}
+
+		essayList = append(essayList, essayData)
+	}
+	return essayList
+}
@@ -6,7 +6,10 @@ import (
 	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
 	mbaRepo "essay-show/biz/infrastructure/repository/mba"
+	"essay-show/biz/infrastructure/repository/outbox"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
 	logx "essay-show/biz/infrastructure/util/log"
@@ -24,12 +27,18 @@ type IMbaService interface {
 	GetMbaEvaluate(ctx context.Context, req *show.GetMbaEvaluateReq) (*show.GetMbaEvaluateResp, error)
 	ListMbaEvaluates(ctx context.Context, req *show.ListMbaEvaluatesReq) (*show.ListMbaEvaluatesResp, error)
 	StartGrader(ctx context.Context) error
+	DrainGrading(ctx context.Context)
 }
 
 type MbaService struct {
 	QuestionMapper *mbaRepo.QuestionMongoMapper
 	RecordMapper   *mbaRepo.RecordMongoMapper
 	UserMapper     *user.MongoMapper
+	Transactor     *transaction.Transactor
+	CountOutbox    *CountOutbox
+	Downstream     util.DownstreamClient
+
+	grading inflight `wire:"-"`
 }
 
 var MbaServiceSet = wire.NewSet(
@@ -164,7 +173,7 @@ func (s *MbaService) SubmitMbaAnswer(ctx context.Context, req *show.SubmitMbaAns
 		record.Title = question.Title
 	}
 	if err := s.RecordMapper.Insert(ctx, record); err != nil {
-		logx.Error("MbaRecord Insert error: %v", err)
+		logx.CtxError(ctx, "MbaRecord Insert error: %v", err)
 		return nil, consts.ErrCall
 	}
 
@@ -267,7 +276,7 @@ const mbaConcurrency = 5
 
 // StartGrader 启动 MBA 批改定时器（服务启动时调用一次）
 func (s *MbaService) StartGrader(ctx context.Context) error {
-	logx.Info("启动 MBA 批改定时器")
+	logx.CtxInfo(ctx, "启动 MBA 批改定时器")
 	go func() {
 		ticker := time.NewTicker(30 * time.Second)
 		defer ticker.Stop()
@@ -276,15 +285,16 @@ func (s *MbaService) StartGrader(ctx context.Context) error {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				s.processMbaRecords(context.Background())
+				s.processMbaRecords(ctx)
 			}
 		}
 	}()
 	return nil
 }
 
-// processMbaRecords 扫描 StatusInitialized 记录并并发批改
-func (s *MbaService) processMbaRecords(ctx context.Context) {
+// processMbaRecords 扫描 StatusInitialized 记录并并发批改；stop 结束后不再领取新记录，已领取的继续批改完
+func (s *MbaService) processMbaRecords(stop context.Context) {
+	ctx := context.WithoutCancel(stop)
 	defer s.processTimeoutMbaRecords(ctx)
 
 	records, err := s.RecordMapper.FindByStatus(ctx, []int32{consts.StatusInitialized})
@@ -292,6 +302,7 @@ func (s *MbaService) processMbaRecords(ctx context.Context) {
 		logx.Error("processMbaRecords FindByStatus error: %v", err)
 		return
 	}
+	metrics.GradingQueue.WithLabelValues("mba").Set(float64(len(records)))
 	if len(records) == 0 {
 		return
 	}
@@ -302,6 +313,10 @@ func (s *MbaService) processMbaRecords(ctx context.Context) {
 	var wg sync.WaitGroup
 
 	for _, r := range records {
+		if stop.Err() != nil {
+			logx.Info("processMbaRecords: 服务停机中，停止领取新记录")
+			break
+		}
 		ok, err := s.RecordMapper.TryUpdateStatusToGrading(ctx, r.ID, consts.StatusInitialized, consts.StatusGrading)
 		if err != nil {
 			logx.Error("processMbaRecords TryUpdateStatusToGrading error: %v", err)
@@ -311,19 +326,37 @@ func (s *MbaService) processMbaRecords(ctx context.Context) {
 			continue
 		}
 
+		s.grading.add(r.ID, "")
 		sem <- struct{}{}
 		wg.Add(1)
 		go func(rec *mbaRepo.MbaRecord) {
 			defer func() {
 				<-sem
 				wg.Done()
+				s.grading.done(rec.ID)
 			}()
-			s.processOneRecord(context.Background(), rec)
+			s.processOneRecord(ctx, rec)
 		}(r)
 	}
 	wg.Wait()
 }
 
+// DrainGrading 停机时等待在途批改完成，超时仍未完成的记录重置为 StatusInitialized
+func (s *MbaService) DrainGrading(ctx context.Context) {
+	waitCtx, cancel := context.WithTimeout(ctx, consts.GradingDrainTimeout)
+	defer cancel()
+	for id := range s.grading.wait(waitCtx) {
+		ok, err := s.RecordMapper.TryUpdateStatusToGrading(ctx, id, consts.StatusGrading, consts.StatusInitialized)
+		if err != nil {
+			logx.CtxError(ctx, "DrainGrading reset error: %v, recordId: %s", err, id.Hex())
+			continue
+		}
+		if ok {
+			logx.CtxInfo(ctx, "DrainGrading: 停机重置未完成的记录 %s", id.Hex())
+		}
+	}
+}
+
 // processTimeoutMbaRecords 将超过 20 分钟仍处于 StatusGrading 的记录重置为 StatusInitialized
 func (s *MbaService) processTimeoutMbaRecords(ctx context.Context) {
 	timeoutTime := time.Now().Add(-20 * time.Minute)
@@ -333,10 +366,10 @@ func (s *MbaService) processTimeoutMbaRecords(ctx context.Context) {
 	}
 	for _, r := range records {
 		if err := s.RecordMapper.UpdateAfterGrading(ctx, r.ID.Hex(), consts.StatusInitialized, "", 0); err != nil {
-			logx.Error("processTimeoutMbaRecords reset error: %v, recordId: %s", err, r.ID.Hex())
+			logx.CtxError(ctx, "processTimeoutMbaRecords reset error: %v, recordId: %s", err, r.ID.Hex())
 			continue
 		}
-		logx.Info("processTimeoutMbaRecords: 重置超时任务 %s", r.ID.Hex())
+		logx.CtxInfo(ctx, "processTimeoutMbaRecords: 重置超时任务 %s", r.ID.Hex())
 	}
 }
 
@@ -345,19 +378,19 @@ func (s *MbaService) processOneRecord(ctx context.Context, r *mbaRepo.MbaRecord)
 	// 1. 确定作文原文：优先 Essay 字段（直接提交），否则从 Ocr 图片识别
 	essay := r.Essay
 	if essay == "" && len(r.Ocr) > 0 {
-		_, content, err := util.GetHttpClient().OcrExtract(ctx, r.Ocr)
+		_, content, err := s.Downstream.OcrExtract(ctx, r.Ocr)
 		if err != nil {
-			logx.Error("processOneRecord OcrExtract error: %v, recordId: %s", err, r.ID.Hex())
+			logx.CtxError(ctx, "processOneRecord OcrExtract error: %v, recordId: %s", err, r.ID.Hex())
 			_ = s.RecordMapper.UpdateAfterGrading(ctx, r.ID.Hex(), consts.StatusFailed, "", 0)
 			return
 		}
 		essay = content
 		if err := s.RecordMapper.UpdateEssay(ctx, r.ID.Hex(), essay); err != nil {
-			logx.Error("processOneRecord UpdateEssay error: %v, recordId: %s", err, r.ID.Hex())
+			logx.CtxError(ctx, "processOneRecord UpdateEssay error: %v, recordId: %s", err, r.ID.Hex())
 		}
 	}
 	if essay == "" {
-		logx.Error("processOneRecord: 作文内容为空, recordId: %s", r.ID.Hex())
+		logx.CtxError(ctx, "processOneRecord: 作文内容为空, recordId: %s", r.ID.Hex())
 		_ = s.RecordMapper.UpdateAfterGrading(ctx, r.ID.Hex(), consts.StatusFailed, "", 0)
 		return
 	}
@@ -365,7 +398,7 @@ func (s *MbaService) processOneRecord(ctx context.Context, r *mbaRepo.MbaRecord)
 	// 2. 加载题目
 	question, err := s.QuestionMapper.FindOne(ctx, r.QuestionId)
 	if err != nil {
-		logx.Error("processOneRecord FindQuestion error: %v, recordId: %s", err, r.ID.Hex())
+		logx.CtxError(ctx, "processOneRecord FindQuestion error: %v, recordId: %s", err, r.ID.Hex())
 		_ = s.RecordMapper.UpdateAfterGrading(ctx, r.ID.Hex(), consts.StatusFailed, "", 0)
 		return
 	}
@@ -373,12 +406,12 @@ func (s *MbaService) processOneRecord(ctx context.Context, r *mbaRepo.MbaRecord)
 	// 3. 读取用户信息（含次数校验和 memory_summary）
 	u, err := s.UserMapper.FindOne(ctx, r.UserId)
 	if err != nil {
-		logx.Error("processOneRecord FindUser error: %v, recordId: %s", err, r.ID.Hex())
+		logx.CtxError(ctx, "processOneRecord FindUser error: %v, recordId: %s", err, r.ID.Hex())
 		_ = s.RecordMapper.UpdateAfterGrading(ctx, r.ID.Hex(), consts.StatusFailed, "", 0)
 		return
 	}
 	if !user.IsVipActive(u) && u.Count < 1 {
-		logx.Error("processOneRecord: 用户批改次数不足, recordId: %s, userId: %s", r.ID.Hex(), r.UserId)
+		logx.CtxError(ctx, "processOneRecord: 用户批改次数不足, recordId: %s, userId: %s", r.ID.Hex(), r.UserId)
 		_ = s.RecordMapper.UpdateAfterGrading(ctx, r.ID.Hex(), consts.StatusFailed, "", 0)
 		return
 	}
@@ -392,18 +425,19 @@ func (s *MbaService) processOneRecord(ctx context.Context, r *mbaRepo.MbaRecord)
 
 // runGrading 调用 AI 批改接口并将结果写回数据库
 func (s *MbaService) runGrading(ctx context.Context, recordId, userId, essayType, material, perspectives, essay, memorySummary string, deductCount bool) {
-	client := util.GetHttpClient()
-	raw, err := client.MbaGrade(ctx, essayType, material, perspectives, essay, memorySummary)
+	raw, err := s.Downstream.MbaGrade(ctx, essayType, material, perspectives, essay, memorySummary)
 	if err != nil {
-		logx.Error("runGrading MbaGrade error: %v, recordId: %s", err, recordId)
+		logx.CtxError(ctx, "runGrading MbaGrade error: %v, recordId: %s", err, recordId)
 		_ = s.RecordMapper.UpdateAfterGrading(ctx, recordId, consts.StatusFailed, "", 0)
+		metrics.EvaluateDone("mba", false)
 		return
 	}
 
 	inner, _ := raw["result"].(map[string]any)
 	if inner == nil {
-		logx.Error("runGrading: missing result field, recordId: %s", recordId)
+		logx.CtxError(ctx, "runGrading: missing result field, recordId: %s", recordId)
 		_ = s.RecordMapper.UpdateAfterGrading(ctx, recordId, consts.StatusFailed, "", 0)
+		metrics.EvaluateDone("mba", false)
 		return
 	}
 
@@ -411,22 +445,35 @@ func (s *MbaService) runGrading(ctx context.Context, recordId, userId, essayType
 	responseStr := string(responseBytes)
 	score := cast.ToInt64(inner["total_score"])
 
-	if err := s.RecordMapper.UpdateAfterGrading(ctx, recordId, consts.StatusCompleted, responseStr, score); err != nil {
-		logx.Error("runGrading UpdateAfterGrading error: %v, recordId: %s", err, recordId)
-	}
-
-	// 扣除用户批改次数（VIP 用户跳过）
-	if deductCount {
-		if err := s.UserMapper.UpdateCount(ctx, userId, -1); err != nil {
-			logx.Error("runGrading UpdateCount error: %v, recordId: %s, userId: %s", err, recordId, userId)
+	// 保存结果与扣次数事件在同一事务中（VIP 用户不扣次数），次数由 outbox 保证最终扣减
+	var event *outbox.Event
+	err = s.Transactor.Do(ctx, func(ctx context.Context) error {
+		if err := s.RecordMapper.UpdateAfterGrading(ctx, recordId, consts.StatusCompleted, responseStr, score); err != nil {
+			return err
+		}
+		if !deductCount {
+			return nil
 		}
+		var err error
+		event, err = s.CountOutbox.Record(ctx, userId, -1, consts.CountReasonMba, recordId)
+		return err
+	})
+	if err != nil {
+		logx.CtxError(ctx, "runGrading save result error: %v, recordId: %s, userId: %s", err, recordId, userId)
+		_ = s.RecordMapper.UpdateAfterGrading(ctx, recordId, consts.StatusFailed, "", 0)
+		metrics.EvaluateDone("mba", false)
+		return
+	}
+	if event != nil {
+		s.CountOutbox.Apply(ctx, event)
 	}
+	metrics.EvaluateDone("mba", true)
 
 	// updated_summary 是下次批改要带的 memory_summary
 	newMemory := cast.ToString(inner["updated_summary"])
 	if newMemory != "" {
 		if err := s.UserMapper.UpdateMbaMemory(ctx, userId, essayType, newMemory); err != nil {
-			logx.Error("runGrading UpdateMbaMemory error: %v", err)
+			logx.CtxError(ctx, "runGrading UpdateMbaMemory error: %v", err)
 		}
 	}
 }
@@ -28,6 +28,7 @@ type MembershipService struct {
 	ProductMapper *membershipRepo.ProductMongoMapper
 	OrderMapper   *membershipRepo.OrderMongoMapper
 	UserMapper    *userRepo.MongoMapper
+	Downstream    util.DownstreamClient
 }
 
 var MembershipServiceSet = wire.NewSet(
@@ -38,7 +39,7 @@ var MembershipServiceSet = wire.NewSet(
 func (s *MembershipService) ListProducts(ctx context.Context, req *show.ListMembershipProductsReq) (*show.ListMembershipProductsResp, error) {
 	products, err := s.ProductMapper.FindActive(ctx)
 	if err != nil {
-		log.Error("ListProducts error: %v", err)
+		log.CtxError(ctx, "ListProducts error: %v", err)
 		return &show.ListMembershipProductsResp{Code: -1, Msg: "查询失败"}, nil
 	}
 	var pbProducts []*show.MembershipProduct
@@ -76,13 +77,13 @@ func (s *MembershipService) SignMembership(ctx context.Context, req *show.SignMe
 		Status:       consts.MembershipOrderStatusPending,
 	}
 	if err := s.OrderMapper.Insert(ctx, order); err != nil {
-		log.Error("SignMembership Insert order error: %v", err)
+		log.CtxError(ctx, "SignMembership Insert order error: %v", err)
 		return nil, consts.ErrCall
 	}
 
-	signData, paySig, signature, err := util.GetHttpClient().VirtualPaySign(ctx, meta.GetUserId(), req.JsCode, req.ProductId, product.PriceFen, orderNo)
+	signData, paySig, signature, err := s.Downstream.VirtualPaySign(ctx, meta.GetUserId(), req.JsCode, req.ProductId, product.PriceFen, orderNo)
 	if err != nil {
-		log.Error("SignMembership VirtualPaySign error: %v", err)
+		log.CtxError(ctx, "SignMembership VirtualPaySign error: %v", err)
 		return nil, consts.ErrPurchaseMembershipFailed
 	}
 
@@ -101,7 +102,7 @@ func (s *MembershipService) HandleNotify(ctx context.Context, req *show.Membersh
 	case "deliver_success":
 		return s.handleDeliverSuccess(ctx, req)
 	default:
-		log.Error("HandleNotify unknown event_type: %s", req.EventType)
+		log.CtxError(ctx, "HandleNotify unknown event_type: %s", req.EventType)
 		return &show.Response{Code: 0, Msg: "ok"}, nil
 	}
 }
@@ -109,7 +110,7 @@ func (s *MembershipService) HandleNotify(ctx context.Context, req *show.Membersh
 func (s *MembershipService) handleDeliverSuccess(ctx context.Context, req *show.MembershipNotifyReq) (*show.Response, error) {
 	order, err := s.OrderMapper.FindByOrderNo(ctx, req.OrderNo)
 	if err != nil {
-		log.Error("handleDeliverSuccess FindByOrderNo error: %v, orderNo: %s", err, req.OrderNo)
+		log.CtxError(ctx, "handleDeliverSuccess FindByOrderNo error: %v, orderNo: %s", err, req.OrderNo)
 		return &show.Response{Code: -1, Msg: "order not found"}, nil
 	}
 	if order.Status == consts.MembershipOrderStatusSuccess {
@@ -118,7 +119,7 @@ func (s *MembershipService) handleDeliverSuccess(ctx context.Context, req *show.
 
 	u, err := s.UserMapper.FindOne(ctx, order.UserID)
 	if err != nil {
-		log.Error("handleDeliverSuccess FindUser error: %v", err)
+		log.CtxError(ctx, "handleDeliverSuccess FindUser error: %v", err)
 		return &show.Response{Code: -1, Msg: "user not found"}, nil
 	}
 
@@ -129,13 +130,13 @@ func (s *MembershipService) handleDeliverSuccess(ctx context.Context, req *show.
 	periodEnd := base.AddDate(0, 0, order.DurationDays)
 
 	if err := s.UserMapper.UpdateVip(ctx, order.UserID, periodEnd); err != nil {
-		log.Error("handleDeliverSuccess UpdateVip error: %v", err)
+		log.CtxError(ctx, "handleDeliverSuccess UpdateVip error: %v", err)
 		return &show.Response{Code: -1, Msg: "activate vip failed"}, nil
 	}
 	if err := s.OrderMapper.UpdateStatus(ctx, req.OrderNo, consts.MembershipOrderStatusSuccess, req.TransactionId, base, periodEnd); err != nil {
-		log.Error("handleDeliverSuccess UpdateStatus error: %v", err)
+		log.CtxError(ctx, "handleDeliverSuccess UpdateStatus error: %v", err)
 	}
-	log.Info("handleDeliverSuccess: VIP activated/extended for user %s, expire %s", order.UserID, periodEnd.Format(time.RFC3339))
+	log.CtxInfo(ctx, "handleDeliverSuccess: VIP activated/extended for user %s, expire %s", order.UserID, periodEnd.Format(time.RFC3339))
 	return &show.Response{Code: 0, Msg: "ok"}, nil
 }
 
@@ -165,7 +166,7 @@ func (s *MembershipService) GetStatus(ctx context.Context, req *show.GetMembersh
 
 // StartExpiryReminder 启动到期提醒定时器，仅在临期时通过微信订阅消息提醒用户手动续购
 func (s *MembershipService) StartExpiryReminder(ctx context.Context) {
-	log.Info("启动会员到期提醒定时器")
+	log.CtxInfo(ctx, "启动会员到期提醒定时器")
 	go func() {
 		ticker := time.NewTicker(1 * time.Hour)
 		defer ticker.Stop()
@@ -183,11 +184,11 @@ func (s *MembershipService) StartExpiryReminder(ctx context.Context) {
 func (s *MembershipService) remindExpiringUsers(ctx context.Context) {
 	users, err := s.UserMapper.FindUsersNearExpiry(ctx, time.Now(), time.Now().Add(24*time.Hour))
 	if err != nil {
-		log.Error("remindExpiringUsers FindUsersNearExpiry error: %v", err)
+		log.CtxError(ctx, "remindExpiringUsers FindUsersNearExpiry error: %v", err)
 		return
 	}
 	for _, u := range users {
 		// TODO: 通过微信小程序订阅消息提醒用户会员即将到期，需前端配合申请订阅消息模板 ID。
-		log.Info("会员即将到期提醒: userId=%s, expire=%s", u.ID.Hex(), u.VipExpireTime.Format(time.RFC3339))
+		log.CtxInfo(ctx, "会员即将到期提醒: userId=%s, expire=%s", u.ID.Hex(), u.VipExpireTime.Format(time.RFC3339))
 	}
 }
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"strings"
+	"unicode"
+
+	"github.com/google/wire"
+)
+
+// ContentModerator 批改前的作文内容安全审核，未通过的审核写入 audit.Moderation。
+// 中台接口调用失败时只按本地词库判断，不因审核服务不可用拦截批改
+type ContentModerator struct {
+	ModerationMapper *audit.ModerationMongoMapper
+	Downstream       util.DownstreamClient
+}
+
+var ContentModeratorSet = wire.NewSet(
+	wire.Struct(new(ContentModerator), "*"),
+)
+
+// Check 审核标题与正文，未开启审核或审核通过时返回 nil，未通过时返回已保存的审核记录
+func (m *ContentModerator) Check(ctx context.Context, scene, targetId, userId, title, text string) *audit.Moderation {
+	conf := config.GetConfig().Moderation
+	if !conf.Enabled {
+		return nil
+	}
+	content := strings.TrimSpace(title + "\n" + text)
+	if content == "" {
+		return nil
+	}
+
+	record := &audit.Moderation{Scene: scene, TargetID: targetId, UserID: userId}
+	if hits := matchWords(content, conf.Words); len(hits) > 0 {
+		record.Source, record.Hits = consts.ModerationSourceLocal, hits
+	} else if conf.Remote {
+		pass, labels, err := m.Downstream.TextSecurityCheck(ctx, content)
+		if err != nil {
+			log.CtxError(ctx, "内容安全检测失败, scene: %s, userId: %s, err: %v", scene, userId, err)
+			return nil
+		}
+		if pass {
+			return nil
+		}
+		record.Source, record.Hits = consts.ModerationSourceRemote, labels
+	} else {
+		return nil
+	}
+
+	record.Excerpt = excerpt(content, consts.ModerationExcerptLength)
+	if err := m.ModerationMapper.Insert(ctx, record); err != nil {
+		log.CtxError(ctx, "保存内容审核记录失败, scene: %s, userId: %s, err: %v", scene, userId, err)
+	}
+	log.CtxInfo(ctx, "作文内容未通过安全审核, scene: %s, targetId: %s, userId: %s, hits: %v", scene, targetId, userId, record.Hits)
+	return record
+}
+
+// matchWords 返回 content 中出现的敏感词，匹配时忽略大小写与空白，避免用空格、换行拆开敏感词绕过
+func matchWords(content string, words []string) []string {
+	normalized := normalizeText(content)
+	var hits []string
+	for _, w := range words {
+		if nw := normalizeText(w); nw != "" && strings.Contains(normalized, nw) {
+			hits = append(hits, w)
+		}
+	}
+	return hits
+}
+
+func normalizeText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return unicode.ToLower(r)
+	}, s)
+}
+
+// excerpt 截取前 n 个字
+func excerpt(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
+
+// toModerationRecords 审核记录转为接口返回的结构
+func toModerationRecords(records []*audit.Moderation) []*show.ModerationRecord {
+	dtos := make([]*show.ModerationRecord, 0, len(records))
+	for _, r := range records {
+		dtos = append(dtos, &show.ModerationRecord{
+			Id:         r.ID.Hex(),
+			Scene:      r.Scene,
+			TargetId:   r.TargetID,
+			UserId:     r.UserID,
+			Source:     r.Source,
+			Hits:       r.Hits,
+			Excerpt:    r.Excerpt,
+			CreateTime: r.CreateTime.Unix(),
+		})
+	}
+	return dtos
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/repository/message"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/email"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bytedance/gopkg/util/gopool"
+	"github.com/google/wire"
+)
+
+// Notifier 按用户的通知偏好选择渠道发送通知，发送失败只打日志，不影响业务结果
+type Notifier struct {
+	SmsLimiter    *cache.SmsLimiter
+	MessageMapper *message.MongoMapper
+	Downstream    util.DownstreamClient
+}
+
+var NotifierSet = wire.NewSet(
+	wire.Struct(new(Notifier), "*"),
+)
+
+// notifyEvents 用户可以设置偏好的通知事件，顺序即设置页的展示顺序
+var notifyEvents = []struct {
+	Event string
+	Name  string
+}{
+	{consts.NotifyEventGradeDone, "作业批改完成"},
+	{consts.NotifyEventClassGradeDone, "全班作业批改完成"},
+	{consts.NotifyEventGradeFailed, "作业批改失败"},
+	{consts.NotifyEventDailySummary, "每日作业提交汇总"},
+	{consts.NotifyEventClassReport, "班级报告生成完成"},
+	{consts.NotifyEventNewDevice, "账号在新设备登录"},
+	{consts.NotifyEventInvitation, "邀请好友成功"},
+	{consts.NotifyEventWeeklyReport, "班级学情周报"},
+}
+
+// eventDefaultChannels 用户未单独设置时与默认渠道不同的事件
+var eventDefaultChannels = map[string]string{
+	consts.NotifyEventNewDevice: consts.NotifyChannelSms,
+}
+
+// notifyMessage 同一条通知在不同渠道下的内容，微信、短信渠道未配置模板时不发送
+type notifyMessage struct {
+	Subject       string // 邮件标题、站内通知标题
+	Content       string // 邮件正文、站内通知内容
+	TemplateId    string
+	Data          map[string]string
+	Page          string
+	SmsTemplateId string
+	SmsParams     map[string]string
+}
+
+// notifyChannel 返回用户在该事件上选择的通知渠道，优先级：事件偏好 > 事件默认渠道 > 用户默认渠道 > 微信
+func notifyChannel(u *user.User, event string) string {
+	if u.Notify != nil {
+		if channel := u.Notify.Preference[event]; channel != "" {
+			return channel
+		}
+	}
+	if channel, ok := eventDefaultChannels[event]; ok {
+		return channel
+	}
+	if u.Notify == nil || u.Notify.Channel == "" {
+		return consts.NotifyChannelWechat
+	}
+	return u.Notify.Channel
+}
+
+// Notify 发送前检查用户的通知偏好，按选择的渠道发送，选择不接收时直接丢弃
+func (n *Notifier) Notify(ctx context.Context, u *user.User, event string, msg notifyMessage) {
+	switch notifyChannel(u, event) {
+	case consts.NotifyChannelEmail:
+		if u.Notify == nil || u.Notify.Email == "" {
+			return
+		}
+		if err := email.Send(u.Notify.Email, msg.Subject, msg.Content); err != nil {
+			log.CtxError(ctx, "发送邮件通知失败, userId: %s, subject: %s, error: %v", u.ID.Hex(), msg.Subject, err)
+		}
+	case consts.NotifyChannelSms:
+		n.SendSms(ctx, u.Phone, msg.SmsTemplateId, msg.SmsParams)
+	case consts.NotifyChannelInApp:
+		err := n.MessageMapper.Insert(ctx, &message.Message{
+			UserID:  u.ID.Hex(),
+			Event:   event,
+			Title:   msg.Subject,
+			Content: msg.Content,
+			Page:    msg.Page,
+		})
+		if err != nil {
+			log.CtxError(ctx, "写入站内通知失败, userId: %s, event: %s, error: %v", u.ID.Hex(), event, err)
+		}
+	case consts.NotifyChannelWechat:
+		if msg.TemplateId == "" {
+			return
+		}
+		n.sendWechatNotify(ctx, u.ID.Hex(), msg.TemplateId, msg.Data, msg.Page)
+	}
+}
+
+// NotifyAsync 在后台发送通知，邮件、短信等外部调用不阻塞当前请求，请求结束后 ctx 取消也不影响发送
+func (n *Notifier) NotifyAsync(ctx context.Context, u *user.User, event string, msg notifyMessage) {
+	ctx = context.WithoutCancel(ctx)
+	gopool.Go(func() {
+		n.Notify(ctx, u, event, msg)
+	})
+}
+
+// SendSms 通过中台发送通知短信，同一手机号按 consts.SmsRateLimit 限流，超限时丢弃
+func (n *Notifier) SendSms(ctx context.Context, phone, templateId string, params map[string]string) {
+	if phone == "" || templateId == "" {
+		return
+	}
+	ok, err := n.SmsLimiter.Allow(ctx, phone)
+	if err != nil {
+		log.CtxError(ctx, "短信限流检查失败, phone: %s, error: %v", phone, err)
+		return
+	}
+	if !ok {
+		log.CtxInfo(ctx, "短信发送过于频繁，已丢弃, phone: %s, templateId: %s", phone, templateId)
+		return
+	}
+	resp, err := n.Downstream.SendSms(ctx, phone, templateId, params)
+	if err != nil {
+		log.CtxError(ctx, "发送短信失败, phone: %s, templateId: %s, error: %v", phone, templateId, err)
+		return
+	}
+	if code, ok := resp["code"].(float64); !ok || code != 0 {
+		log.CtxError(ctx, "发送短信失败, phone: %s, templateId: %s, resp=%v", phone, templateId, resp)
+	}
+}
+
+// sendWechatNotify 推送微信订阅消息，失败只记录日志
+func (n *Notifier) sendWechatNotify(ctx context.Context, userId, templateId string, data map[string]string, page string) {
+	resp, err := n.Downstream.SendWechatMessage(ctx, userId, templateId, data, &page)
+	if err != nil {
+		log.CtxError(ctx, "发送微信通知失败, userId: %s, templateId: %s, error: %v", userId, templateId, err)
+		return
+	}
+	if code, ok := resp["code"].(float64); !ok || code != 0 {
+		log.CtxError(ctx, "发送微信通知失败, userId: %s, templateId: %s, resp=%v", userId, templateId, resp)
+	}
+}
+
+// notifyGradeFailed 作业批改失败后提醒教师人工处理，异步发送不占用批改协程
+func (s *HomeworkService) notifyGradeFailed(ctx context.Context, submission *homework.HomeworkSubmission) {
+	teacher, err := s.UserMapper.FindOne(ctx, submission.TeacherID)
+	if err != nil {
+		log.CtxError(ctx, "获取教师信息失败, userId: %s, error: %v", submission.TeacherID, err)
+		return
+	}
+	hw, err := s.HomeworkMapper.FindOne(ctx, submission.HomeworkID)
+	if err != nil {
+		log.CtxError(ctx, "获取作业信息失败, homeworkId: %s, error: %v", submission.HomeworkID, err)
+		return
+	}
+	var name string
+	if member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId); err == nil {
+		name = member.Name
+	}
+
+	conf := config.GetConfig().Notify
+	s.Notifier.NotifyAsync(ctx, teacher, consts.NotifyEventGradeFailed, notifyMessage{
+		Subject:    fmt.Sprintf("作业批改失败：%s", hw.Title),
+		Content:    fmt.Sprintf("作业「%s」中学生 %s 的提交批改失败，原因：%s。\n请在小程序中查看并重新批改。", hw.Title, name, submission.Message),
+		TemplateId: conf.GradeFailTemplateId,
+		Data: map[string]string{
+			"thing1": hw.Title,
+			"thing2": name,
+			"thing3": "批改失败，请查看并重新批改",
+		},
+		Page:          fmt.Sprintf("%s?homeworkId=%s", consts.ClassGradeJumpPage, hw.ID.Hex()),
+		SmsTemplateId: conf.GradeFailSmsTemplateId,
+		SmsParams: map[string]string{
+			"homework": hw.Title,
+			"name":     name,
+		},
+	})
+}
+
+// StartDailySummary 启动每日作业提交汇总定时器，每小时检查一次，到达发送时刻后给教师发送前一天的提交情况
+func (s *HomeworkService) StartDailySummary(ctx context.Context) {
+	log.CtxInfo(ctx, "启动每日作业提交汇总定时器")
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.sendDailySummary(context.Background(), time.Now())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (s *HomeworkService) sendDailySummary(ctx context.Context, now time.Time) {
+	if now.Hour() != config.GetConfig().Notify.GetDailySummaryHour() {
+		return
+	}
+	ok, err := s.NotifyCache.TryMarkDailySummary(ctx, now.Format(time.DateOnly))
+	if err != nil || !ok {
+		return
+	}
+
+	end := now.Truncate(time.Hour)
+	start := end.Add(-24 * time.Hour)
+	submissions, err := s.SubmissionMapper.FindByCreateTime(ctx, start, end)
+	if err != nil {
+		log.CtxError(ctx, "查询每日提交记录失败: %v", err)
+		return
+	}
+
+	// teacherId -> homeworkId -> 当天的提交
+	byTeacher := make(map[string]map[string][]*homework.HomeworkSubmission)
+	for _, sub := range submissions {
+		if byTeacher[sub.TeacherID] == nil {
+			byTeacher[sub.TeacherID] = make(map[string][]*homework.HomeworkSubmission)
+		}
+		byTeacher[sub.TeacherID][sub.HomeworkID] = append(byTeacher[sub.TeacherID][sub.HomeworkID], sub)
+	}
+
+	for teacherId, homeworks := range byTeacher {
+		teacher, err := s.UserMapper.FindOne(ctx, teacherId)
+		if err != nil {
+			log.CtxError(ctx, "获取教师信息失败, userId: %s, error: %v", teacherId, err)
+			continue
+		}
+		// 订阅消息是一次性授权，汇总不配置微信模板，只走邮件、站内等渠道
+		switch notifyChannel(teacher, consts.NotifyEventDailySummary) {
+		case consts.NotifyChannelWechat, consts.NotifyChannelNone:
+			continue
+		}
+
+		var lines []string
+		for homeworkId, subs := range homeworks {
+			title := homeworkId
+			if hw, err := s.HomeworkMapper.FindOne(ctx, homeworkId); err == nil {
+				title = hw.Title
+			}
+			var completed, failed int
+			for _, sub := range subs {
+				switch sub.Status {
+				case consts.StatusCompleted, consts.StatusModified:
+					completed++
+				case consts.StatusFailed:
+					failed++
+				}
+			}
+			lines = append(lines, fmt.Sprintf("「%s」新提交 %d 份，已批改 %d 份，批改失败 %d 份", title, len(subs), completed, failed))
+		}
+		sort.Strings(lines)
+
+		s.Notifier.Notify(ctx, teacher, consts.NotifyEventDailySummary, notifyMessage{
+			Subject: fmt.Sprintf("每日作业提交汇总 %s", start.Format(time.DateOnly)),
+			Content: fmt.Sprintf("%s 至 %s 的作业提交情况：\n%s",
+				start.Format("2006-01-02 15:04"), end.Format("2006-01-02 15:04"), strings.Join(lines, "\n")),
+		})
+	}
+}
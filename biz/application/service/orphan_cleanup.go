@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/certification"
+	"essay-show/biz/infrastructure/repository/feedback"
+	"essay-show/biz/infrastructure/repository/homework"
+	logRepo "essay-show/biz/infrastructure/repository/log"
+	mbaRepo "essay-show/biz/infrastructure/repository/mba"
+	"essay-show/biz/infrastructure/repository/upload"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// OrphanCleaner 孤儿文件对账：申请加签 url 或服务端转存时登记的对象，超过配置天数仍未被
+// 批改记录、作业提交、资质认证、反馈和 MBA 批改引用的，从 cos 删除
+type OrphanCleaner struct {
+	UploadMapper     *upload.MongoMapper
+	LogMapper        *logRepo.MongoMapper
+	SubmissionMapper *homework.SubmissionMongoMapper
+	CertMapper       *certification.MongoMapper
+	FeedbackMapper   *feedback.MongoMapper
+	MbaRecordMapper  *mbaRepo.RecordMongoMapper
+	Downstream       util.DownstreamClient
+}
+
+var OrphanCleanerSet = wire.NewSet(
+	wire.Struct(new(OrphanCleaner), "*"),
+)
+
+// Start 启动孤儿文件对账任务，每 consts.OrphanCleanupInterval 执行一次，未开启 Orphan.Enabled 时跳过，配置热加载后生效
+func (c *OrphanCleaner) Start(ctx context.Context) {
+	log.CtxInfo(ctx, "启动孤儿文件对账任务")
+	go func() {
+		ticker := time.NewTicker(consts.OrphanCleanupInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if cfg := config.GetConfig().Orphan; cfg.Enabled {
+					c.run(context.Background(), time.Now().AddDate(0, 0, -cfg.GetDays()))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// run 对账 before 之前登记的对象。引用一定写在对象生成之后，所以只需收集最早的待对账对象生成之后写入的引用；
+// 某批没有对账成功的对象时结束，避免反复处理同一批失败的对象
+func (c *OrphanCleaner) run(ctx context.Context, before time.Time) {
+	var (
+		refs                map[string]bool
+		referenced, deleted int
+	)
+	for {
+		uploads, err := c.UploadMapper.FindPending(ctx, before, consts.OrphanCleanupBatchSize)
+		if err != nil {
+			log.CtxError(ctx, "查询待对账的上传对象失败: %v", err)
+			break
+		}
+		if len(uploads) == 0 {
+			break
+		}
+		if refs == nil {
+			if refs, err = c.referencedKeys(ctx, uploads[0].CreateTime); err != nil {
+				log.CtxError(ctx, "收集上传对象的引用失败: %v", err)
+				break
+			}
+		}
+
+		checked := 0
+		for _, u := range uploads {
+			status := consts.UploadStatusReferenced
+			if !refs[u.Key] {
+				if err = deleteCosObject(ctx, c.Downstream, u.Key); err != nil {
+					log.CtxError(ctx, "删除孤儿文件失败, key: %s, error: %v", u.Key, err)
+					continue
+				}
+				status = consts.UploadStatusDeleted
+			}
+			if err = c.UploadMapper.UpdateStatus(ctx, u.ID, status); err != nil {
+				log.CtxError(ctx, "保存对账结果失败, key: %s, error: %v", u.Key, err)
+				continue
+			}
+			if status == consts.UploadStatusDeleted {
+				log.CtxInfo(ctx, "删除孤儿文件, userId: %s, key: %s", u.UserID, u.Key)
+				deleted++
+			} else {
+				referenced++
+			}
+			checked++
+		}
+		if len(uploads) < consts.OrphanCleanupBatchSize || checked == 0 {
+			break
+		}
+	}
+	log.CtxInfo(ctx, "孤儿文件对账完成, before: %s, referenced: %d, deleted: %d", before.Format(time.DateOnly), referenced, deleted)
+}
+
+// referencedKeys 收集 since 之后写入的业务数据引用的对象路径
+func (c *OrphanCleaner) referencedKeys(ctx context.Context, since time.Time) (map[string]bool, error) {
+	referrers := []upload.Referrer{c.LogMapper, c.SubmissionMapper, c.CertMapper, c.FeedbackMapper, c.MbaRecordMapper}
+	keys := make(map[string]bool)
+	for _, r := range referrers {
+		images, err := r.DistinctImagesSince(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+		for _, image := range images {
+			if key, ok := cosKeyFromUrl(image); ok {
+				keys[key] = true
+			}
+		}
+	}
+	return keys, nil
+}
@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/guardian"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
+	"essay-show/biz/infrastructure/util/log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/wire"
+)
+
+type IParentService interface {
+	GenerateBindCode(ctx context.Context, req *show.GenerateBindCodeReq) (*show.GenerateBindCodeResp, error)
+	BindChild(ctx context.Context, req *show.BindChildReq) (*show.Response, error)
+	UnbindChild(ctx context.Context, req *show.UnbindChildReq) (*show.Response, error)
+	ListChildren(ctx context.Context, req *show.ListChildrenReq) (*show.ListChildrenResp, error)
+	ListChildHomeworks(ctx context.Context, req *show.ListChildHomeworksReq) (*show.ListChildHomeworksResp, error)
+	GetChildSubmissionEvaluate(ctx context.Context, req *show.GetChildSubmissionEvaluateReq) (*show.GetSubmissionEvaluateWithAiScoreResp, error)
+}
+
+type ParentService struct {
+	GuardianMapper   *guardian.MongoMapper
+	BindCodeMapper   *cache.BindCodeMapper
+	UserMapper       *user.MongoMapper
+	ClassMapper      *class.MongoMapper
+	MemberMapper     *class.MemberMongoMapper
+	HomeworkMapper   *homework.MongoMapper
+	SubmissionMapper *homework.SubmissionMongoMapper
+}
+
+var ParentServiceSet = wire.NewSet(
+	wire.Struct(new(ParentService), "*"),
+	wire.Bind(new(IParentService), new(*ParentService)),
+)
+
+// childHomeworkLimit 每个班级最多返回的作业数
+const childHomeworkLimit = 50
+
+// GenerateBindCode 学生生成家长绑定码，30 分钟内有效，角色由路由 StudentOnly 中间件校验
+func (s *ParentService) GenerateBindCode(ctx context.Context, req *show.GenerateBindCodeReq) (*show.GenerateBindCodeResp, error) {
+	u, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 10; i++ {
+		code, err := genBindCode()
+		if err != nil {
+			return nil, consts.ErrCall
+		}
+		ok, err := s.BindCodeMapper.Set(ctx, code, u.ID.Hex())
+		if err != nil {
+			log.CtxError(ctx, "保存绑定码失败, userId: %s, err: %v", u.ID.Hex(), err)
+			return nil, consts.ErrCall
+		}
+		if ok {
+			return &show.GenerateBindCodeResp{
+				Code:       0,
+				Msg:        "success",
+				BindCode:   code,
+				ExpireTime: time.Now().Unix() + s.BindCodeMapper.Expire(),
+			}, nil
+		}
+	}
+	return nil, consts.ErrCall
+}
+
+// BindChild 家长使用绑定码关联孩子账号，只保存绑定关系，不修改账号角色；
+// 错误次数按用户和绑定码统计，超过上限后锁定一段时间，角色由路由 GuardianOnly 中间件校验
+func (s *ParentService) BindChild(ctx context.Context, req *show.BindChildReq) (*show.Response, error) {
+	parent, err := CurrentUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	locked, err := s.BindCodeMapper.Locked(ctx, parent.ID.Hex())
+	if err != nil {
+		log.CtxError(ctx, "查询绑定码错误次数失败, userId: %s, err: %v", parent.ID.Hex(), err)
+		return nil, consts.ErrCall
+	}
+	if locked {
+		return nil, consts.ErrBindCodeLocked
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(req.BindCode))
+	childID, err := s.BindCodeMapper.Get(ctx, code)
+	if err != nil {
+		log.CtxError(ctx, "查询绑定码失败, err: %v", err)
+		return nil, consts.ErrCall
+	}
+	if childID == "" || childID == parent.ID.Hex() {
+		if err = s.BindCodeMapper.Fail(ctx, parent.ID.Hex(), code); err != nil {
+			log.CtxError(ctx, "记录绑定码错误次数失败, userId: %s, err: %v", parent.ID.Hex(), err)
+		}
+		return nil, consts.ErrInvalidBindCode
+	}
+
+	_, err = s.GuardianMapper.FindOne(ctx, parent.ID.Hex(), childID)
+	if err == nil {
+		return util.Succeed("已绑定")
+	} else if !errors.Is(err, consts.ErrNotFound) {
+		return nil, consts.ErrBindChild
+	}
+
+	if err = s.GuardianMapper.Insert(ctx, &guardian.Binding{ParentID: parent.ID.Hex(), ChildID: childID}); err != nil {
+		log.CtxError(ctx, "绑定孩子失败, parentId: %s, childId: %s, err: %v", parent.ID.Hex(), childID, err)
+		return nil, consts.ErrBindChild
+	}
+	// 绑定码一次性使用
+	_ = s.BindCodeMapper.Delete(ctx, code)
+	_ = s.BindCodeMapper.Reset(ctx, parent.ID.Hex())
+	return util.Succeed("绑定成功")
+}
+
+// UnbindChild 家长解除与孩子的绑定
+func (s *ParentService) UnbindChild(ctx context.Context, req *show.UnbindChildReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	if err := s.checkGuardian(ctx, userMeta.GetUserId(), req.ChildId); err != nil {
+		return nil, err
+	}
+	if err := s.GuardianMapper.Delete(ctx, userMeta.GetUserId(), req.ChildId); err != nil {
+		log.CtxError(ctx, "解除绑定失败, parentId: %s, childId: %s, err: %v", userMeta.GetUserId(), req.ChildId, err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("解绑成功")
+}
+
+// ListChildren 家长查看已绑定的孩子
+func (s *ParentService) ListChildren(ctx context.Context, req *show.ListChildrenReq) (*show.ListChildrenResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	bindings, err := s.GuardianMapper.FindByParent(ctx, userMeta.GetUserId())
+	if err != nil {
+		log.CtxError(ctx, "获取绑定关系失败, parentId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrNotFound
+	}
+
+	children := make([]*show.ChildInfo, 0, len(bindings))
+	for _, b := range bindings {
+		child, err := s.UserMapper.FindOne(ctx, b.ChildID)
+		if err != nil {
+			log.CtxError(ctx, "获取孩子信息失败, childId: %s, err: %v", b.ChildID, err)
+			continue
+		}
+		children = append(children, &show.ChildInfo{
+			UserId:   b.ChildID,
+			Name:     child.Username,
+			School:   child.School,
+			Grade:    child.Grade,
+			BindTime: b.CreateTime.Unix(),
+		})
+	}
+	return &show.ListChildrenResp{Code: 0, Msg: "success", Children: children}, nil
+}
+
+// ListChildHomeworks 家长只读查看孩子各班级的作业完成情况
+func (s *ParentService) ListChildHomeworks(ctx context.Context, req *show.ListChildHomeworksReq) (*show.ListChildHomeworksResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	if err := s.checkGuardian(ctx, userMeta.GetUserId(), req.ChildId); err != nil {
+		return nil, err
+	}
+
+	members, _, err := s.MemberMapper.FindByStuID(ctx, req.ChildId)
+	if err != nil {
+		log.CtxError(ctx, "获取孩子班级失败, childId: %s, err: %v", req.ChildId, err)
+		return nil, consts.ErrGetClassList
+	}
+
+	classes := make([]*show.ChildClassHomeworks, 0, len(members))
+	for _, m := range members {
+		if req.ClassId != "" && m.ClassID != req.ClassId {
+			continue
+		}
+		c, err := s.ClassMapper.FindOne(ctx, m.ClassID)
+		if err != nil {
+			log.CtxError(ctx, "获取班级失败, classId: %s, err: %v", m.ClassID, err)
+			continue
+		}
+		homeworks, _, err := s.HomeworkMapper.FindByClassID(ctx, m.ClassID, 1, childHomeworkLimit)
+		if err != nil {
+			log.CtxError(ctx, "获取作业列表失败, classId: %s, err: %v", m.ClassID, err)
+			return nil, consts.ErrGetHomeworkList
+		}
+
+		infos := make([]*show.HomeworkInfo, 0, len(homeworks))
+		for _, h := range homeworks {
+			info := &show.HomeworkInfo{
+				Id:          h.ID.Hex(),
+				Subject:     show.Subject(h.Subject),
+				Topic:       h.Topic,
+				Title:       h.Title,
+				Description: h.Description,
+				Grade:       h.Grade,
+				TotalScore:  h.TotalScore,
+				EssayType:   h.EssayType,
+				CreateTime:  h.CreateTime.Unix(),
+			}
+			submission, err := s.SubmissionMapper.FindLatestByMemberAndHomework(ctx, m.ID.Hex(), h.ID.Hex())
+			switch {
+			case errors.Is(err, consts.ErrNotFound):
+				status := show.HomeworkStatus(consts.StatusNotSubmission)
+				info.Status = &status
+			case err != nil:
+				log.CtxError(ctx, "获取提交情况失败: %v", err)
+				return nil, consts.ErrGetHomeworkList
+			default:
+				status := show.HomeworkStatus(submission.Status)
+				submissionId := submission.ID.Hex()
+				submitTime := submission.CreateTime.Unix()
+				info.Status = &status
+				info.SubmissionId = &submissionId
+				info.SubmitTime = &submitTime
+				if submission.Status == consts.StatusCompleted || submission.Status == consts.StatusModified {
+					info.GradeResult = &submission.GradeResult
+				}
+			}
+			infos = append(infos, info)
+		}
+
+		classes = append(classes, &show.ChildClassHomeworks{
+			ClassId:   m.ClassID,
+			ClassName: c.Name,
+			MemberId:  m.ID.Hex(),
+			Homeworks: infos,
+		})
+	}
+	return &show.ListChildHomeworksResp{Code: 0, Msg: "success", Classes: classes}, nil
+}
+
+// GetChildSubmissionEvaluate 家长查看孩子某次提交的批改结果
+func (s *ParentService) GetChildSubmissionEvaluate(ctx context.Context, req *show.GetChildSubmissionEvaluateReq) (*show.GetSubmissionEvaluateWithAiScoreResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	if err := s.checkGuardian(ctx, userMeta.GetUserId(), req.ChildId); err != nil {
+		return nil, err
+	}
+
+	submission, err := s.SubmissionMapper.FindOne(ctx, req.SubmissionId)
+	if err != nil {
+		log.CtxError(ctx, "获取作业详情失败: %v", err)
+		return nil, consts.ErrGetHomework
+	}
+
+	// 提交必须属于孩子的成员位
+	member, err := s.MemberMapper.FindByMemberID(ctx, submission.MemberId)
+	if err != nil || member.UserID == nil || *member.UserID != req.ChildId {
+		return nil, consts.ErrNotGuardian
+	}
+
+	if submission.Status != consts.StatusCompleted && submission.Status != consts.StatusModified {
+		return nil, consts.ErrHomeworkNotGrade
+	}
+
+	// 返回学科供客户端按学科解析批改结果，疑似度只给教师参考
+	return &show.GetSubmissionEvaluateWithAiScoreResp{
+		GetSubmissionEvaluateResp: &show.GetSubmissionEvaluateResp{
+			Id:       submission.ID.Hex(),
+			Response: submission.Response,
+		},
+		Neatness:          submission.Neatness,
+		NeatnessDeduction: submission.NeatnessDeduction,
+		WordCount:         submission.WordCount,
+		WordLimitMessage:  submission.WordLimitMessage,
+		Subject:           submission.Subject,
+	}, nil
+}
+
+// checkGuardian 校验家长与孩子的绑定关系
+func (s *ParentService) checkGuardian(ctx context.Context, parentID, childID string) error {
+	if childID == "" {
+		return consts.ErrInvalidParams
+	}
+	_, err := s.GuardianMapper.FindOne(ctx, parentID, childID)
+	if errors.Is(err, consts.ErrNotFound) {
+		return consts.ErrNotGuardian
+	} else if err != nil {
+		log.CtxError(ctx, "查询绑定关系失败, parentId: %s, childId: %s, err: %v", parentID, childID, err)
+		return consts.ErrCall
+	}
+	return nil
+}
+
+// bindCodeAlphabet 绑定码字符集，去掉了容易混淆的 0/O、1/I
+const bindCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// genBindCode 生成 consts.BindCodeLength 位字母数字绑定码
+func genBindCode() (string, error) {
+	code := make([]byte, consts.BindCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(bindCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code[i] = bindCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
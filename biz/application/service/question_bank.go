@@ -2,19 +2,42 @@ package service
 
 import (
 	"context"
+	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/repository/question_bank"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
+	"strconv"
+	"strings"
 
 	"github.com/google/wire"
 )
 
 type IQuestionBankService interface {
-	ListQuestionBanks(ctx context.Context, req *show.ListQuestionBanksReq) (*show.ListQuestionBanksResp, error)
+	ListQuestionBanks(ctx context.Context, req *show.SearchQuestionBanksReq) (*show.ListQuestionBanksResp, error)
+	GetQuestionBankDetail(ctx context.Context, req *show.GetQuestionBankDetailReq) (*show.GetQuestionBankDetailResp, error)
+	GetQuestionBankTree(ctx context.Context, req *show.GetQuestionBankTreeReq) (*show.GetQuestionBankTreeResp, error)
+	CreateQuestion(ctx context.Context, req *show.CreateQuestionReq) (*show.CreateQuestionResp, error)
+	UpdateQuestion(ctx context.Context, req *show.UpdateQuestionReq) (*show.Response, error)
+	DeleteQuestion(ctx context.Context, req *show.DeleteQuestionReq) (*show.Response, error)
+	ListMyQuestions(ctx context.Context, req *show.ListMyQuestionsReq) (*show.ListMyQuestionsResp, error)
+	AssignQuestion(ctx context.Context, req *show.AssignQuestionReq) (*show.CreateHomeworkResp, error)
+	AddFavorite(ctx context.Context, req *show.FavoriteQuestionReq) (*show.Response, error)
+	RemoveFavorite(ctx context.Context, req *show.FavoriteQuestionReq) (*show.Response, error)
+	ListFavorites(ctx context.Context, req *show.ListFavoritesReq) (*show.ListFavoritesResp, error)
+	AssignFavorite(ctx context.Context, req *show.AssignFavoriteReq) (*show.CreateHomeworkResp, error)
 }
 
 type QuestionBankService struct {
-	QuestionBankMapper *question_bank.MySQLMapper
+	QuestionBankMapper    *question_bank.MySQLMapper
+	TeacherQuestionMapper *question_bank.TeacherQuestionMongoMapper
+	UserMapper            *user.MongoMapper
+	QuestionBankCache     *cache.QuestionBankCacheMapper
+	FavoriteMapper        *question_bank.FavoriteMongoMapper
+	HomeworkService       IHomeworkService
 }
 
 var QuestionBankServiceSet = wire.NewSet(
@@ -22,20 +45,393 @@ var QuestionBankServiceSet = wire.NewSet(
 	wire.Bind(new(IQuestionBankService), new(*QuestionBankService)),
 )
 
-// ListQuestionBanks 获取题库列表
-func (s *QuestionBankService) ListQuestionBanks(ctx context.Context, req *show.ListQuestionBanksReq) (*show.ListQuestionBanksResp, error) {
+// ListQuestionBanks 获取题库列表，支持按关键字与文体过滤，结果按筛选条件缓存
+func (s *QuestionBankService) ListQuestionBanks(ctx context.Context, req *show.SearchQuestionBanksReq) (*show.ListQuestionBanksResp, error) {
+	if cached, err := s.QuestionBankCache.Get(ctx, req); err != nil {
+		log.CtxError(ctx, "读取题库缓存失败: %v", err)
+	} else if cached != nil {
+		return cached, nil
+	}
 
 	// 调用数据层获取题库列表
 	questionBanks, total, err := s.QuestionBankMapper.ListQuestionBanks(ctx, req)
 	if err != nil {
-		log.Error("Failed to get question banks from database: %v", err)
+		log.CtxError(ctx, "Failed to get question banks from database: %v", err)
 		return nil, err
 	}
 
-	log.Info("Successfully retrieved %d question banks, total: %d", len(questionBanks), total)
+	log.CtxInfo(ctx, "Successfully retrieved %d question banks, total: %d", len(questionBanks), total)
 
-	return &show.ListQuestionBanksResp{
+	resp := &show.ListQuestionBanksResp{
 		QuestionBanks: questionBanks,
 		Total:         total,
+	}
+	if err = s.QuestionBankCache.Set(ctx, req, resp); err != nil {
+		log.CtxError(ctx, "写入题库缓存失败: %v", err)
+	}
+	return resp, nil
+}
+
+// GetQuestionBankDetail 获取题目的完整描述、写作要求与范文，供学生写作前查看
+func (s *QuestionBankService) GetQuestionBankDetail(ctx context.Context, req *show.GetQuestionBankDetailReq) (*show.GetQuestionBankDetailResp, error) {
+	id, err := strconv.Atoi(req.Id)
+	if err != nil {
+		return nil, consts.ErrInvalidParams
+	}
+	essay, err := s.QuestionBankMapper.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := s.QuestionBankMapper.FindSamples(ctx, id)
+	if err != nil {
+		return nil, consts.ErrInternal
+	}
+
+	resp := &show.GetQuestionBankDetailResp{
+		Code:         0,
+		Msg:          "success",
+		QuestionBank: question_bank.ToQuestionBank(essay),
+		Samples:      make([]*show.SampleEssay, 0, len(samples)),
+	}
+	if essay.Requirement != nil {
+		resp.Requirement = *essay.Requirement
+	}
+	for _, sp := range samples {
+		dto := &show.SampleEssay{
+			Id:      strconv.Itoa(sp.ID),
+			Title:   sp.Title,
+			Content: sp.Content,
+		}
+		if sp.Score != nil {
+			dto.Score = int64(*sp.Score)
+		}
+		if sp.Comment != nil {
+			dto.Comment = *sp.Comment
+		}
+		resp.Samples = append(resp.Samples, dto)
+	}
+	return resp, nil
+}
+
+// GetQuestionBankTree 按教材版本、年级、单元返回课内题库的树状目录与各节点题目数量
+func (s *QuestionBankService) GetQuestionBankTree(ctx context.Context, req *show.GetQuestionBankTreeReq) (*show.GetQuestionBankTreeResp, error) {
+	counts, err := s.QuestionBankMapper.CountByUnit(ctx, req.TextbookVersion)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	// counts 已按版本、年级、单元排序，相邻的同值分组即可
+	versions := make([]*show.QuestionBankTreeNode, 0)
+	var version, grade *show.QuestionBankTreeNode
+	for _, c := range counts {
+		if version == nil || version.Value != c.TextbookVersion {
+			version = &show.QuestionBankTreeNode{Value: c.TextbookVersion}
+			versions = append(versions, version)
+			grade = nil
+		}
+		if grade == nil || grade.Value != c.Grade {
+			grade = &show.QuestionBankTreeNode{Value: c.Grade}
+			version.Children = append(version.Children, grade)
+		}
+		grade.Children = append(grade.Children, &show.QuestionBankTreeNode{Value: c.Unit, Count: c.Count})
+		grade.Count += c.Count
+		version.Count += c.Count
+	}
+
+	return &show.GetQuestionBankTreeResp{
+		Code:     0,
+		Msg:      "success",
+		Versions: versions,
+	}, nil
+}
+
+// CreateQuestion 教师向个人题库添加一道作文题
+func (s *QuestionBankService) CreateQuestion(ctx context.Context, req *show.CreateQuestionReq) (*show.CreateQuestionResp, error) {
+	teacherID, err := s.currentTeacher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, consts.ErrInvalidParams
+	}
+
+	q := &question_bank.TeacherQuestion{
+		CreatorID:   teacherID,
+		Name:        strings.TrimSpace(req.Name),
+		Description: req.Description,
+		Grade:       req.Grade,
+		Unit:        req.Unit,
+		EssayType:   req.EssayType,
+		TotalScore:  req.TotalScore,
+		Standard:    req.Standard,
+	}
+	if err = s.TeacherQuestionMapper.Insert(ctx, q); err != nil {
+		log.CtxError(ctx, "创建题目失败, teacherId: %s, err: %v", teacherID, err)
+		return nil, consts.ErrCreateQuestion
+	}
+
+	return &show.CreateQuestionResp{
+		Code:     0,
+		Msg:      "创建成功",
+		Question: toTeacherQuestionDTO(q),
+	}, nil
+}
+
+// UpdateQuestion 修改自己题库中的题目，只更新传入的字段
+func (s *QuestionBankService) UpdateQuestion(ctx context.Context, req *show.UpdateQuestionReq) (*show.Response, error) {
+	q, err := s.findMyQuestion(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return nil, consts.ErrInvalidParams
+		}
+		q.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		q.Description = *req.Description
+	}
+	if req.Grade != nil {
+		q.Grade = *req.Grade
+	}
+	if req.Unit != nil {
+		q.Unit = *req.Unit
+	}
+	if req.EssayType != nil {
+		q.EssayType = *req.EssayType
+	}
+	if req.TotalScore != nil {
+		q.TotalScore = req.TotalScore
+	}
+	if req.Standard != nil {
+		q.Standard = req.Standard
+	}
+	if err = s.TeacherQuestionMapper.Update(ctx, q); err != nil {
+		log.CtxError(ctx, "更新题目失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("更新成功")
+}
+
+// DeleteQuestion 删除自己题库中的题目
+func (s *QuestionBankService) DeleteQuestion(ctx context.Context, req *show.DeleteQuestionReq) (*show.Response, error) {
+	q, err := s.findMyQuestion(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if err = s.TeacherQuestionMapper.Delete(ctx, q.ID); err != nil {
+		log.CtxError(ctx, "删除题目失败, id: %s, err: %v", req.Id, err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("删除成功")
+}
+
+// ListMyQuestions 分页获取教师个人题库
+func (s *QuestionBankService) ListMyQuestions(ctx context.Context, req *show.ListMyQuestionsReq) (*show.ListMyQuestionsResp, error) {
+	teacherID, err := s.currentTeacher(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	qs, total, err := s.TeacherQuestionMapper.FindManyByCreator(ctx, teacherID, req.Grade, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询个人题库失败, teacherId: %s, err: %v", teacherID, err)
+		return nil, consts.ErrNotFound
+	}
+
+	dtos := make([]*show.TeacherQuestion, 0, len(qs))
+	for _, q := range qs {
+		dtos = append(dtos, toTeacherQuestionDTO(q))
+	}
+	return &show.ListMyQuestionsResp{
+		Code:      0,
+		Msg:       "success",
+		Questions: dtos,
+		Total:     total,
+	}, nil
+}
+
+// AssignQuestion 把自建题目布置到班级，作业按自定义题目创建
+func (s *QuestionBankService) AssignQuestion(ctx context.Context, req *show.AssignQuestionReq) (*show.CreateHomeworkResp, error) {
+	q, err := s.findMyQuestion(ctx, req.QuestionId)
+	if err != nil {
+		return nil, err
+	}
+	return s.HomeworkService.CreateHomework(ctx, &show.CreateHomeworkReq{
+		Subject:     req.Subject,
+		Topic:       consts.TopicTypeCustom,
+		Title:       q.Name,
+		ClassIds:    req.ClassIds,
+		Description: &q.Description,
+		EssayType:   &q.EssayType,
+		Grade:       &q.Grade,
+		TotalScore:  q.TotalScore,
+		Standard:    q.Standard,
+	})
+}
+
+// AddFavorite 收藏题库题目，重复收藏视为成功
+func (s *QuestionBankService) AddFavorite(ctx context.Context, req *show.FavoriteQuestionReq) (*show.Response, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	id, err := strconv.Atoi(req.QuestionId)
+	if err != nil {
+		return nil, consts.ErrInvalidParams
+	}
+	if _, err = s.QuestionBankMapper.FindOne(ctx, id); err != nil {
+		return nil, err
+	}
+	if err = s.FavoriteMapper.Add(ctx, meta.GetUserId(), id); err != nil {
+		log.CtxError(ctx, "收藏题目失败, userId: %s, questionId: %d, err: %v", meta.GetUserId(), id, err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("收藏成功")
+}
+
+// RemoveFavorite 取消收藏
+func (s *QuestionBankService) RemoveFavorite(ctx context.Context, req *show.FavoriteQuestionReq) (*show.Response, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	id, err := strconv.Atoi(req.QuestionId)
+	if err != nil {
+		return nil, consts.ErrInvalidParams
+	}
+	if err = s.FavoriteMapper.Remove(ctx, meta.GetUserId(), id); err != nil {
+		log.CtxError(ctx, "取消收藏失败, userId: %s, questionId: %d, err: %v", meta.GetUserId(), id, err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("已取消收藏")
+}
+
+// ListFavorites 分页获取收藏的题目，附带写作要求，方便布置作业时直接选用
+func (s *QuestionBankService) ListFavorites(ctx context.Context, req *show.ListFavoritesReq) (*show.ListFavoritesResp, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	favorites, total, err := s.FavoriteMapper.FindManyByUser(ctx, meta.GetUserId(), req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询收藏失败, userId: %s, err: %v", meta.GetUserId(), err)
+		return nil, consts.ErrInternal
+	}
+	ids := make([]int, 0, len(favorites))
+	for _, f := range favorites {
+		ids = append(ids, f.QuestionID)
+	}
+	essays, err := s.QuestionBankMapper.FindByIds(ctx, ids)
+	if err != nil {
+		return nil, consts.ErrInternal
+	}
+	essayMap := make(map[int]*question_bank.Essay, len(essays))
+	for _, e := range essays {
+		essayMap[e.ID] = e
+	}
+
+	// 按收藏顺序返回，题库中已删除的题目跳过
+	dtos := make([]*show.FavoriteQuestion, 0, len(favorites))
+	for _, f := range favorites {
+		e, ok := essayMap[f.QuestionID]
+		if !ok {
+			continue
+		}
+		dto := &show.FavoriteQuestion{
+			QuestionBank: question_bank.ToQuestionBank(e),
+			FavoriteTime: f.CreateTime.Unix(),
+		}
+		if e.Requirement != nil {
+			dto.Requirement = *e.Requirement
+		}
+		dtos = append(dtos, dto)
+	}
+	return &show.ListFavoritesResp{
+		Code:      0,
+		Msg:       "success",
+		Favorites: dtos,
+		Total:     total,
 	}, nil
 }
+
+// AssignFavorite 把收藏的题库题目布置到班级，作业按题库题目创建，未收藏的题目按不存在处理
+func (s *QuestionBankService) AssignFavorite(ctx context.Context, req *show.AssignFavoriteReq) (*show.CreateHomeworkResp, error) {
+	teacherID, err := s.currentTeacher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.Atoi(req.QuestionId)
+	if err != nil {
+		return nil, consts.ErrInvalidParams
+	}
+	ok, err := s.FavoriteMapper.Exists(ctx, teacherID, id)
+	if err != nil {
+		log.CtxError(ctx, "查询收藏失败, userId: %s, questionId: %d, err: %v", teacherID, id, err)
+		return nil, consts.ErrCall
+	}
+	if !ok {
+		return nil, consts.ErrNotFound
+	}
+	essay, err := s.QuestionBankMapper.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	q := question_bank.ToQuestionBank(essay)
+	hw := &show.CreateHomeworkReq{
+		Subject:     req.Subject,
+		Topic:       consts.TopicTypeLibrary,
+		Title:       q.Name,
+		ClassIds:    req.ClassIds,
+		Description: &q.Description,
+		EssayType:   &q.EssayType,
+	}
+	// 题库中未标注年级的题目按作业默认年级处理，不能当作 0 年级
+	if essay.Grade != nil {
+		hw.Grade = &q.Grade
+	}
+	return s.HomeworkService.CreateHomework(ctx, hw)
+}
+
+// currentTeacher 返回路由 TeacherOnly 中间件校验过的当前教师 id
+func (s *QuestionBankService) currentTeacher(ctx context.Context) (string, error) {
+	u, err := CurrentUser(ctx)
+	if err != nil {
+		return "", err
+	}
+	return u.ID.Hex(), nil
+}
+
+// findMyQuestion 获取当前教师自己创建的题目，他人的题目按不存在处理
+func (s *QuestionBankService) findMyQuestion(ctx context.Context, id string) (*question_bank.TeacherQuestion, error) {
+	teacherID, err := s.currentTeacher(ctx)
+	if err != nil {
+		return nil, err
+	}
+	q, err := s.TeacherQuestionMapper.FindOne(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.CreatorID != teacherID {
+		return nil, consts.ErrNotFound
+	}
+	return q, nil
+}
+
+func toTeacherQuestionDTO(q *question_bank.TeacherQuestion) *show.TeacherQuestion {
+	return &show.TeacherQuestion{
+		Id:          q.ID.Hex(),
+		Name:        q.Name,
+		Description: q.Description,
+		Grade:       q.Grade,
+		Unit:        q.Unit,
+		EssayType:   q.EssayType,
+		TotalScore:  q.TotalScore,
+		Standard:    q.Standard,
+		CreateTime:  q.CreateTime.Unix(),
+		UpdateTime:  q.UpdateTime.Unix(),
+	}
+}
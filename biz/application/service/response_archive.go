@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/archive"
+	"essay-show/biz/infrastructure/repository/homework"
+	logRepo "essay-show/biz/infrastructure/repository/log"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"github.com/google/wire"
+)
+
+// ResponseArchiver 批改结果冷归档：创建超过配置月数的批改记录与作业提交，Response 压缩后转存到 cos，
+// 文档中只保留对象路径，mapper 读取时透明回源。归档是幂等的，多实例同时执行只会重复上传同一对象
+type ResponseArchiver struct {
+	LogMapper        *logRepo.MongoMapper
+	SubmissionMapper *homework.SubmissionMongoMapper
+	Store            archive.Store
+}
+
+var ResponseArchiverSet = wire.NewSet(
+	wire.Struct(new(ResponseArchiver), "*"),
+)
+
+// Start 启动冷归档任务，每 consts.ArchiveInterval 执行一次，未开启 Archive.Enabled 时跳过，配置热加载后生效
+func (a *ResponseArchiver) Start(ctx context.Context) {
+	log.CtxInfo(ctx, "启动批改结果冷归档任务")
+	go func() {
+		ticker := time.NewTicker(consts.ArchiveInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if cfg := config.GetConfig().Archive; cfg.Enabled {
+					a.run(ctx, time.Now().AddDate(0, -cfg.GetMonths(), 0))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (a *ResponseArchiver) run(ctx context.Context, before time.Time) {
+	logs, submissions := a.archiveLogs(ctx, before), a.archiveSubmissions(ctx, before)
+	log.CtxInfo(ctx, "批改结果冷归档完成, before: %s, logs: %d, submissions: %d", before.Format(time.DateOnly), logs, submissions)
+}
+
+// archiveLogs 分批归档个人批改记录，某批没有归档成功的记录时结束，避免反复处理同一批失败的记录
+func (a *ResponseArchiver) archiveLogs(ctx context.Context, before time.Time) int {
+	total := 0
+	for {
+		logs, err := a.LogMapper.FindArchivable(ctx, before, consts.ArchiveBatchSize)
+		if err != nil {
+			log.CtxError(ctx, "查询待归档的批改记录失败: %v", err)
+			return total
+		}
+		archived := 0
+		for _, l := range logs {
+			key := archive.Key(logRepo.CollectionName, l.ID.Hex())
+			if err = a.put(ctx, key, l.Response); err != nil {
+				log.CtxError(ctx, "归档批改记录失败, id: %s, error: %v", l.ID.Hex(), err)
+				continue
+			}
+			ok, err := a.LogMapper.Archive(ctx, l, key)
+			if err != nil {
+				log.CtxError(ctx, "更新批改记录归档路径失败, id: %s, error: %v", l.ID.Hex(), err)
+				continue
+			}
+			if ok {
+				archived++
+			}
+		}
+		total += archived
+		if len(logs) < consts.ArchiveBatchSize || archived == 0 {
+			return total
+		}
+	}
+}
+
+// archiveSubmissions 分批归档已结束批改的作业提交，规则同 archiveLogs
+func (a *ResponseArchiver) archiveSubmissions(ctx context.Context, before time.Time) int {
+	total := 0
+	for {
+		submissions, err := a.SubmissionMapper.FindArchivable(ctx, before, consts.ArchiveBatchSize)
+		if err != nil {
+			log.CtxError(ctx, "查询待归档的作业提交失败: %v", err)
+			return total
+		}
+		archived := 0
+		for _, s := range submissions {
+			key := archive.Key(homework.SubmissionCollectionName, s.ID.Hex())
+			if err = a.put(ctx, key, s.Response); err != nil {
+				log.CtxError(ctx, "归档作业提交失败, id: %s, error: %v", s.ID.Hex(), err)
+				continue
+			}
+			ok, err := a.SubmissionMapper.Archive(ctx, s, key)
+			if err != nil {
+				log.CtxError(ctx, "更新作业提交归档路径失败, id: %s, error: %v", s.ID.Hex(), err)
+				continue
+			}
+			if ok {
+				archived++
+			}
+		}
+		total += archived
+		if len(submissions) < consts.ArchiveBatchSize || archived == 0 {
+			return total
+		}
+	}
+}
+
+func (a *ResponseArchiver) put(ctx context.Context, key, content string) error {
+	data, err := archive.Compress(content)
+	if err != nil {
+		return err
+	}
+	return a.Store.Put(ctx, key, data)
+}
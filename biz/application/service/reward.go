@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/setting"
+	"essay-show/biz/infrastructure/util/log"
+
+	"github.com/google/wire"
+)
+
+// Rewards 生效中的奖励参数
+type Rewards struct {
+	DefaultCount       int64
+	AttendReward       int64
+	AttendStreakReward int64
+	InviterReward      int64
+	InviteeReward      int64
+	OcrDailyFree       int64
+}
+
+// RewardConfig 读取奖励参数，优先级：后台运营配置 > 配置文件 > 代码默认值
+type RewardConfig struct {
+	SettingMapper *setting.MongoMapper
+}
+
+var RewardConfigSet = wire.NewSet(
+	wire.Struct(new(RewardConfig), "*"),
+)
+
+// Load 读取当前生效的奖励参数，运营配置读取失败时退回默认值，不影响业务
+func (r *RewardConfig) Load(ctx context.Context) *Rewards {
+	rewards := &Rewards{
+		DefaultCount:       consts.DefaultCount,
+		AttendReward:       consts.AttendReward,
+		AttendStreakReward: consts.AttendStreakReward,
+		InviterReward:      consts.InvitationReward,
+		InviteeReward:      consts.InvitationReward,
+		OcrDailyFree:       consts.OcrDailyFree,
+	}
+	if rule := config.GetConfig().Invitation; rule.InviterReward > 0 {
+		rewards.InviterReward = rule.InviterReward
+	}
+	if rule := config.GetConfig().Invitation; rule.InviteeReward > 0 {
+		rewards.InviteeReward = rule.InviteeReward
+	}
+	if rule := config.GetConfig().OCR; rule.DailyFree > 0 {
+		rewards.OcrDailyFree = rule.DailyFree
+	}
+
+	s, err := r.SettingMapper.GetReward(ctx)
+	if err != nil {
+		log.CtxError(ctx, "读取奖励配置失败, 使用默认值, err: %v", err)
+		return rewards
+	}
+	override := func(dst *int64, src *int64) {
+		if src != nil {
+			*dst = *src
+		}
+	}
+	override(&rewards.DefaultCount, s.DefaultCount)
+	override(&rewards.AttendReward, s.AttendReward)
+	override(&rewards.AttendStreakReward, s.AttendStreakReward)
+	override(&rewards.InviterReward, s.InviterReward)
+	override(&rewards.InviteeReward, s.InviteeReward)
+	override(&rewards.OcrDailyFree, s.OcrDailyFree)
+	return rewards
+}
@@ -5,27 +5,49 @@ import (
 	"errors"
 	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/ocr"
+	"essay-show/biz/infrastructure/repository/upload"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
 	"fmt"
+	"io"
+	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/google/wire"
 )
 
 type IStsService interface {
-	ApplySignedUrl(ctx context.Context, req *show.ApplySignedUrlReq) (*show.ApplySignedUrlResp, error)
-	OCR(ctx context.Context, req *show.OCRReq) (*show.OCRResp, error)
-	APIOCRV1(ctx context.Context, req *show.OCRReq) (*show.OCRResp, error)
+	ApplySignedUrl(ctx context.Context, req *show.ApplySignedUrlWithSizeReq) (*show.ApplySignedUrlResp, error)
+	BatchApplySignedUrl(ctx context.Context, req *show.BatchApplySignedUrlReq) (*show.BatchApplySignedUrlResp, error)
+	CorrectOCRText(ctx context.Context, req *show.CorrectOCRTextReq) (*show.Response, error)
+	GetOcrQuota(ctx context.Context, req *show.GetOcrQuotaReq) (*show.GetOcrQuotaResp, error)
+	UploadFile(ctx context.Context, req *show.UploadFileReq, file *multipart.FileHeader) (*show.UploadFileResp, error)
+	OCR(ctx context.Context, req *show.OCRWithPreprocessReq) (*show.OCRResp, error)
+	APIOCRV1(ctx context.Context, req *show.OCRWithPreprocessReq) (*show.OCRResp, error)
 	SendVerifyCode(ctx context.Context, req *show.SendVerifyCodeReq) (*show.Response, error)
 }
 
 type StsService struct {
-	UserMapper *user.MongoMapper
+	UserMapper       *user.MongoMapper
+	OcrCache         *cache.OcrCacheMapper
+	CorrectionMapper *ocr.MongoMapper
+	UsageMapper      *ocr.UsageMongoMapper
+	OcrQuota         *cache.OcrQuotaMapper
+	Rewards          *RewardConfig
+	UploadLimiter    *cache.UploadLimiter
+	UploadMapper     *upload.MongoMapper
+	Downstream       util.DownstreamClient
 }
 
 var StsServiceSet = wire.NewSet(
@@ -34,17 +56,204 @@ var StsServiceSet = wire.NewSet(
 )
 
 // ApplySignedUrl 向cos申请加签url
-func (s *StsService) ApplySignedUrl(ctx context.Context, req *show.ApplySignedUrlReq) (*show.ApplySignedUrlResp, error) {
+func (s *StsService) ApplySignedUrl(ctx context.Context, req *show.ApplySignedUrlWithSizeReq) (*show.ApplySignedUrlResp, error) {
 	// 获取用户信息
 	aUser := adaptor.ExtractUserMeta(ctx)
 	if aUser.GetUserId() == "" {
 		return nil, consts.ErrNotAuthentication
 	}
-	// 构造响应
-	resp := new(show.ApplySignedUrlResp)
+	var suffix string
+	if req.Suffix != nil {
+		suffix = *req.Suffix
+	}
+	if err := checkUploadFile(suffix, req.Size); err != nil {
+		return nil, err
+	}
 	// 获取cos状态
 	userId := aUser.GetUserId()
-	client := util.GetHttpClient()
+	cred, err := genCosCredential(ctx, s.Downstream, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	// 生成加签url
+	signedUrl, err := genPutSignedUrl(ctx, s.Downstream, cred, userId, req.Prefix, suffix, req.Size)
+	if err != nil {
+		return nil, err
+	}
+	s.recordUpload(ctx, userId, req.Prefix, signedUrl)
+
+	// 返回响应
+	return &show.ApplySignedUrlResp{
+		SessionToken: cred["sessionToken"].(string),
+		Url:          signedUrl,
+	}, nil
+}
+
+// BatchApplySignedUrl 一次申请多个加签url，共用同一份临时密钥，返回的 url 与 files 一一对应
+func (s *StsService) BatchApplySignedUrl(ctx context.Context, req *show.BatchApplySignedUrlReq) (*show.BatchApplySignedUrlResp, error) {
+	aUser := adaptor.ExtractUserMeta(ctx)
+	if aUser.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	if len(req.Files) == 0 || len(req.Files) > consts.SignedUrlBatchMaxCount {
+		return nil, consts.ErrInvalidParams
+	}
+
+	suffixes := make([]string, len(req.Files))
+	for i, f := range req.Files {
+		if f == nil {
+			return nil, consts.ErrInvalidParams
+		}
+		if f.Suffix != nil {
+			suffixes[i] = *f.Suffix
+		}
+		if err := checkUploadFile(suffixes[i], f.Size); err != nil {
+			return nil, err
+		}
+	}
+
+	userId := aUser.GetUserId()
+	cred, err := genCosCredential(ctx, s.Downstream, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	urls := make([]string, 0, len(req.Files))
+	for i, f := range req.Files {
+		suffix := suffixes[i]
+		signedUrl, err := genPutSignedUrl(ctx, s.Downstream, cred, userId, f.Prefix, suffix, f.Size)
+		if err != nil {
+			return nil, err
+		}
+		urls = append(urls, signedUrl)
+		s.recordUpload(ctx, userId, f.Prefix, signedUrl)
+	}
+
+	return &show.BatchApplySignedUrlResp{
+		Code:         0,
+		Msg:          "success",
+		SessionToken: cred["sessionToken"].(string),
+		Urls:         urls,
+	}, nil
+}
+
+// checkUploadFile 按配置的扩展名白名单与大小上限校验待上传文件，必须声明大小，声明的大小会签入上传链接
+func checkUploadFile(suffix string, size int64) error {
+	rule := config.GetConfig().Upload
+	if size <= 0 {
+		return consts.ErrInvalidParams
+	}
+	if size > rule.GetMaxSize() {
+		return consts.ErrFileTooLarge
+	}
+	ext := strings.ToLower(strings.TrimPrefix(suffix, "."))
+	if ext == "" {
+		return consts.ErrFileType
+	}
+	for _, allowed := range rule.GetSuffixes() {
+		if strings.EqualFold(ext, strings.TrimPrefix(allowed, ".")) {
+			return nil
+		}
+	}
+	return consts.ErrFileType
+}
+
+// uploadContentTypes 服务端直传能按内容校验的扩展名及其内容类型，扩展名还需在配置的白名单内
+var uploadContentTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+	".pdf":  "application/pdf",
+}
+
+// UploadFile 服务端直传，供无法使用 cos sdk 的客户端把文件交给后端转存 cos
+func (s *StsService) UploadFile(ctx context.Context, req *show.UploadFileReq, file *multipart.FileHeader) (*show.UploadFileResp, error) {
+	aUser := adaptor.ExtractUserMeta(ctx)
+	if aUser.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	userId := aUser.GetUserId()
+
+	ext := strings.ToLower(filepath.Ext(file.Filename))
+	if err := checkUploadFile(ext, file.Size); err != nil {
+		return nil, err
+	}
+	contentType, ok := uploadContentTypes[ext]
+	if !ok {
+		return nil, consts.ErrFileType
+	}
+	if allowed, err := s.UploadLimiter.Allow(ctx, userId); err != nil {
+		log.CtxError(ctx, "上传限流检查失败, userId: %s, err: %v", userId, err)
+	} else if !allowed {
+		return nil, consts.ErrUploadTooFrequent
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return nil, consts.ErrUpload
+	}
+	defer f.Close()
+	maxSize := config.GetConfig().Upload.GetMaxSize()
+	data, err := io.ReadAll(io.LimitReader(f, maxSize+1))
+	if err != nil {
+		return nil, consts.ErrUpload
+	}
+	if int64(len(data)) > maxSize {
+		return nil, consts.ErrFileTooLarge
+	}
+	// 按文件内容再校验一次，防止改扩展名绕过
+	if http.DetectContentType(data) != contentType {
+		return nil, consts.ErrFileType
+	}
+
+	cred, err := genCosCredential(ctx, s.Downstream, userId)
+	if err != nil {
+		log.CtxError(ctx, "申请cos临时密钥失败, userId: %s, err: %v", userId, err)
+		return nil, consts.ErrUpload
+	}
+	signedUrl, err := genPutSignedUrl(ctx, s.Downstream, cred, userId, req.Prefix, ext, int64(len(data)))
+	if err != nil {
+		log.CtxError(ctx, "生成加签url失败, userId: %s, err: %v", userId, err)
+		return nil, consts.ErrUpload
+	}
+	if err = s.Downstream.PutObject(ctx, signedUrl, contentType, data); err != nil {
+		log.CtxError(ctx, "转存cos失败, userId: %s, err: %v", userId, err)
+		return nil, consts.ErrUpload
+	}
+	s.recordUpload(ctx, userId, req.Prefix, signedUrl)
+
+	u, err := url.Parse(signedUrl)
+	if err != nil {
+		return nil, consts.ErrUpload
+	}
+	u.RawQuery = ""
+	return &show.UploadFileResp{
+		Code: 0,
+		Msg:  "上传成功",
+		Url:  u.String(),
+	}, nil
+}
+
+// recordUpload 登记新生成的上传对象供孤儿文件对账，带 prefix 的上传另作他用，引用不在库内，不登记；
+// 登记失败只记录日志，对象不会被对账清理
+func (s *StsService) recordUpload(ctx context.Context, userId string, prefix *string, signedUrl string) {
+	if prefix != nil && *prefix != "" {
+		return
+	}
+	key, ok := cosKeyFromUrl(signedUrl)
+	if !ok {
+		return
+	}
+	err := s.UploadMapper.Insert(ctx, &upload.Upload{UserID: userId, Key: key, Status: consts.UploadStatusPending})
+	if err != nil {
+		log.CtxError(ctx, "登记上传对象失败, userId: %s, err: %v", userId, err)
+	}
+}
+
+// genCosCredential 申请用户目录下的cos临时密钥
+func genCosCredential(ctx context.Context, client util.DownstreamClient, userId string) (map[string]any, error) {
 	data, err := client.GenCosSts(ctx, fmt.Sprintf("essays_%s/%s/*", config.GetConfig().State, userId))
 	if err != nil {
 		return nil, err
@@ -52,31 +261,44 @@ func (s *StsService) ApplySignedUrl(ctx context.Context, req *show.ApplySignedUr
 	if data["code"].(float64) != 0 {
 		return nil, errors.New(data["message"].(string))
 	}
-	data = data["data"].(map[string]any)
+	return data["data"].(map[string]any), nil
+}
 
-	// 生成加签url
-	resp.SessionToken = data["sessionToken"].(string)
-	if req.Prefix != nil {
-		*req.Prefix += "/"
+// genPutSignedUrl 为用户目录下的一个新文件生成上传用的加签url，prefix 不为空时作为子目录；
+// size 作为 Content-Length 签入链接，实际上传的大小与声明不一致时 cos 拒绝上传
+func genPutSignedUrl(ctx context.Context, client util.DownstreamClient, cred map[string]any, userId string, prefix *string, suffix string, size int64) (string, error) {
+	return genSignedUrl(ctx, client, cred, http.MethodPut, cosObjectKey(userId, prefix, suffix), map[string]string{
+		"Content-Length": strconv.FormatInt(size, 10),
+	})
+}
+
+// cosObjectKey 用户目录下一个新文件的路径，prefix 不为空时作为子目录
+func cosObjectKey(userId string, prefix *string, suffix string) string {
+	dir := ""
+	if prefix != nil {
+		dir = *prefix + "/"
 	}
+	return fmt.Sprintf("essays_%s/%s/%s%s%s", config.GetConfig().State, userId, dir, uuid.New().String(), suffix)
+}
 
-	data2, err := client.GenSignedUrl(ctx,
-		data["secretId"].(string),
-		data["secretKey"].(string),
-		http.MethodPut,
-		fmt.Sprintf("essays_%s/%s/%s%s%s", config.GetConfig().State, userId, req.GetPrefix(), uuid.New().String(), req.GetSuffix()),
+func genSignedUrl(ctx context.Context, client util.DownstreamClient, cred map[string]any, method, key string, headers map[string]string) (string, error) {
+	data, err := client.GenSignedUrl(ctx,
+		cred["secretId"].(string),
+		cred["secretKey"].(string),
+		method,
+		key,
+		headers,
 	)
-	if err != nil || data2["code"].(float64) != 0 {
-		return nil, err
+	if err != nil {
+		return "", err
 	}
-	data2 = data2["data"].(map[string]any)
-
-	// 返回响应
-	resp.Url = data2["signedUrl"].(string)
-	return resp, nil
+	if data["code"].(float64) != 0 {
+		return "", errors.New("生成加签url失败")
+	}
+	return data["data"].(map[string]any)["signedUrl"].(string), nil
 }
 
-func (s *StsService) OCR(ctx context.Context, req *show.OCRReq) (*show.OCRResp, error) {
+func (s *StsService) OCR(ctx context.Context, req *show.OCRWithPreprocessReq) (*show.OCRResp, error) {
 	aUser := adaptor.ExtractUserMeta(ctx)
 	if aUser.GetUserId() == "" {
 		return nil, consts.ErrNotAuthentication
@@ -88,32 +310,43 @@ func (s *StsService) OCR(ctx context.Context, req *show.OCRReq) (*show.OCRResp,
 		return nil, consts.ErrNotFound
 	}
 
-	// 检查剩余次数
-	if u.Count <= 0 {
-		return nil, consts.ErrInSufficientCount
+	if !util.ValidPreprocess(req.Preprocess) {
+		return nil, consts.ErrInvalidParams
 	}
-
 	images := req.Ocr
 	left := ""
 	if req.LeftType != nil {
 		left = *req.LeftType
 	}
 
-	client := util.GetHttpClient()
-	resp, err := client.TitleUrlOCR(ctx, images, left)
-	if err != nil {
-		return nil, err
-	}
-	if resp["code"].(float64) != 0 {
-		return nil, consts.ErrOCR
-	}
-	data := resp["data"].(map[string]any)
-	if data == nil {
-		return nil, consts.ErrOCR
+	// 用户校正过这组图片时直接使用校正后的文本，不调用识别也不扣减配额；
+	// 否则先占用配额（与批改次数分开计算）再识别，PDF 按页识别后合并，识别失败或命中缓存时归还
+	var title, essay string
+	if c, err := s.CorrectionMapper.FindByImages(ctx, aUser.GetUserId(), images); err == nil {
+		title, essay = c.Title, c.Text
+	} else {
+		source, err := s.reserveOcrQuota(ctx, u.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		result, err := ocrWithCache(ctx, s.Downstream, s.OcrCache, images, left, req.Preprocess)
+		if err != nil {
+			log.CtxError(ctx, "OCR识别失败: %v", err)
+			s.releaseOcrQuota(ctx, u.ID.Hex(), source)
+			if errors.Is(err, consts.ErrPdfTooManyPages) {
+				return nil, err
+			}
+			return nil, consts.ErrOCR
+		}
+		if result.Cached {
+			s.releaseOcrQuota(ctx, u.ID.Hex(), source)
+		} else {
+			s.recordOcrUsage(ctx, u.ID.Hex(), source, len(images))
+		}
+		title, essay = result.Title, result.Content
 	}
-	essay, title := data["content"].(string), data["title"].(string)
 
-	resp, err = client.GetEssayInfo(ctx, essay, title)
+	resp, err := s.Downstream.GetEssayInfo(ctx, essay, title)
 	if err != nil {
 		return nil, err
 	}
@@ -127,12 +360,130 @@ func (s *StsService) OCR(ctx context.Context, req *show.OCRReq) (*show.OCRResp,
 	return &show.OCRResp{Title: title, Text: essay, EssayType: essayType, Grade: int64(grade), TotalScore: int64(totalScore)}, nil
 }
 
+// CorrectOCRText 保存用户对一组图片识别文本的校正，之后对这组图片的 OCR 以及批改都使用校正后的文本
+func (s *StsService) CorrectOCRText(ctx context.Context, req *show.CorrectOCRTextReq) (*show.Response, error) {
+	aUser := adaptor.ExtractUserMeta(ctx)
+	if aUser.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	if len(req.Ocr) == 0 || strings.TrimSpace(req.Text) == "" {
+		return nil, consts.ErrInvalidParams
+	}
+
+	err := s.CorrectionMapper.Upsert(ctx, &ocr.Correction{
+		UserID: aUser.GetUserId(),
+		Images: req.Ocr,
+		Title:  req.Title,
+		Text:   req.Text,
+	})
+	if err != nil {
+		log.CtxError(ctx, "保存OCR校正文本失败, userId: %s, err: %v", aUser.GetUserId(), err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("保存成功")
+}
+
+// GetOcrQuota 查看今日免费 OCR 次数的使用情况与剩余 OCR 次数
+func (s *StsService) GetOcrQuota(ctx context.Context, req *show.GetOcrQuotaReq) (*show.GetOcrQuotaResp, error) {
+	aUser := adaptor.ExtractUserMeta(ctx)
+	if aUser.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	u, err := s.UserMapper.FindOne(ctx, aUser.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	used, err := s.OcrQuota.Get(ctx, aUser.GetUserId(), time.Now().Format(time.DateOnly))
+	if err != nil {
+		log.CtxError(ctx, "查询OCR用量失败, userId: %s, err: %v", aUser.GetUserId(), err)
+		return nil, consts.ErrNotFound
+	}
+	dailyFree := s.Rewards.Load(ctx).OcrDailyFree
+	return &show.GetOcrQuotaResp{
+		Code:      0,
+		Msg:       "success",
+		DailyFree: dailyFree,
+		UsedToday: min(used, dailyFree),
+		OcrCount:  u.OcrCount,
+	}, nil
+}
+
+// reserveOcrQuota 识别前占用一次配额，优先使用每日免费次数（redis 原子计数），用完后条件扣减 OcrCount，返回本次的扣减来源
+func (s *StsService) reserveOcrQuota(ctx context.Context, userId string) (string, error) {
+	date := time.Now().Format(time.DateOnly)
+	used, err := s.OcrQuota.Incr(ctx, userId, date)
+	if err != nil {
+		log.CtxError(ctx, "占用免费OCR次数失败, userId: %s, err: %v", userId, err)
+		return "", consts.ErrOCR
+	}
+	if used <= s.Rewards.Load(ctx).OcrDailyFree {
+		return consts.OcrQuotaSourceFree, nil
+	}
+	if err = s.OcrQuota.Decr(ctx, userId, date); err != nil {
+		log.CtxError(ctx, "归还免费OCR次数失败, userId: %s, err: %v", userId, err)
+	}
+
+	ok, err := s.UserMapper.ConsumeOcrCount(ctx, userId)
+	if err != nil {
+		log.CtxError(ctx, "扣减OCR次数失败, userId: %s, err: %v", userId, err)
+		return "", consts.ErrOCR
+	}
+	if !ok {
+		return "", consts.ErrInsufficientOcrCount
+	}
+	return consts.OcrQuotaSourcePaid, nil
+}
+
+// releaseOcrQuota 归还 reserveOcrQuota 占用的配额，失败只记日志
+func (s *StsService) releaseOcrQuota(ctx context.Context, userId, source string) {
+	var err error
+	if source == consts.OcrQuotaSourcePaid {
+		_, err = s.UserMapper.AdjustOcrCount(ctx, userId, 1)
+	} else {
+		err = s.OcrQuota.Decr(ctx, userId, time.Now().Format(time.DateOnly))
+	}
+	if err != nil {
+		log.CtxError(ctx, "归还OCR配额失败, userId: %s, source: %s, err: %v", userId, source, err)
+	}
+}
+
+// recordOcrUsage 识别成功后记录流水，失败只记日志，不影响已返回的识别结果
+func (s *StsService) recordOcrUsage(ctx context.Context, userId, source string, images int) {
+	err := s.UsageMapper.Insert(ctx, &ocr.Usage{
+		UserID: userId,
+		Date:   time.Now().Format(time.DateOnly),
+		Source: source,
+		Images: int64(images),
+	})
+	if err != nil {
+		log.CtxError(ctx, "记录OCR流水失败, userId: %s, err: %v", userId, err)
+	}
+}
+
+// ocrWithCache 识别图片或 PDF 并计算书写工整度，同一批 url 短时间内重复识别时直接使用缓存结果
+func ocrWithCache(ctx context.Context, client util.DownstreamClient, c *cache.OcrCacheMapper, images []string, left string, preprocess []string) (*cache.OcrResult, error) {
+	if cached, err := c.Get(ctx, images, left, preprocess); err != nil {
+		log.CtxError(ctx, "读取OCR缓存失败: %v", err)
+	} else if cached != nil {
+		return cached, nil
+	}
+
+	title, content, confidences, err := client.OcrWithConfidence(ctx, images, left, preprocess)
+	if err != nil {
+		return nil, err
+	}
+	result := &cache.OcrResult{Title: title, Content: content, Neatness: util.HandwritingNeatness(confidences)}
+	if err = c.Set(ctx, images, left, preprocess, result); err != nil {
+		log.CtxError(ctx, "写入OCR缓存失败: %v", err)
+	}
+	return result, nil
+}
+
 // SendVerifyCode 发送验证码
 func (s *StsService) SendVerifyCode(ctx context.Context, req *show.SendVerifyCodeReq) (*show.Response, error) {
-	httpClient := util.GetHttpClient()
-	ret, err := httpClient.SendVerifyCode(ctx, req.AuthType, req.AuthId)
+	ret, err := s.Downstream.SendVerifyCode(ctx, req.AuthType, req.AuthId)
 	if err != nil || ret["code"].(float64) != 0 {
-		log.Error("发送验证码失败:%v, ret:%v", err, ret)
+		log.CtxError(ctx, "发送验证码失败:%v, ret:%v", err, ret)
 		return nil, consts.ErrSend
 	}
 
@@ -141,31 +492,29 @@ func (s *StsService) SendVerifyCode(ctx context.Context, req *show.SendVerifyCod
 
 // APIOCRV1 API网关专用OCR接口
 // 简化版本：无需认证、无需校验次数、只返回核心OCR功能
-func (s *StsService) APIOCRV1(ctx context.Context, req *show.OCRReq) (*show.OCRResp, error) {
+func (s *StsService) APIOCRV1(ctx context.Context, req *show.OCRWithPreprocessReq) (*show.OCRResp, error) {
+	if !util.ValidPreprocess(req.Preprocess) {
+		return nil, consts.ErrInvalidParams
+	}
 	images := req.Ocr
 	left := ""
 	if req.LeftType != nil {
 		left = *req.LeftType
 	}
 
-	// 调用OCR服务
-	client := util.GetHttpClient()
-	resp, err := client.TitleUrlOCR(ctx, images, left)
+	// 调用OCR服务，PDF 按页识别后合并
+	result, err := ocrWithCache(ctx, s.Downstream, s.OcrCache, images, left, req.Preprocess)
 	if err != nil {
-		return nil, err
-	}
-	if resp["code"].(float64) != 0 {
-		return nil, consts.ErrOCR
-	}
-
-	data := resp["data"].(map[string]any)
-	if data == nil {
+		log.CtxError(ctx, "OCR识别失败: %v", err)
+		if errors.Is(err, consts.ErrPdfTooManyPages) {
+			return nil, err
+		}
 		return nil, consts.ErrOCR
 	}
-	essay, title := data["content"].(string), data["title"].(string)
+	title, essay := result.Title, result.Content
 
 	// 获取作文信息
-	resp, err = client.GetEssayInfo(ctx, essay, title)
+	resp, err := s.Downstream.GetEssayInfo(ctx, essay, title)
 	if err != nil {
 		return nil, err
 	}
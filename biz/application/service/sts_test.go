@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util"
+	"testing"
+)
+
+// fakeDownstream 只实现用到的下游方法，其余方法调用时 panic
+type fakeDownstream struct {
+	util.DownstreamClient
+	sendVerifyCode func(authType, authId string) (map[string]any, error)
+}
+
+func (f *fakeDownstream) SendVerifyCode(_ context.Context, authType string, authId string) (map[string]any, error) {
+	return f.sendVerifyCode(authType, authId)
+}
+
+func TestSendVerifyCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		resp    map[string]any
+		err     error
+		wantErr error
+	}{
+		{name: "success", resp: map[string]any{"code": float64(0)}},
+		{name: "downstream error code", resp: map[string]any{"code": float64(1)}, wantErr: consts.ErrSend},
+		{name: "downstream unavailable", err: errors.New("timeout"), wantErr: consts.ErrSend},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotType, gotId string
+			s := &StsService{Downstream: &fakeDownstream{
+				sendVerifyCode: func(authType, authId string) (map[string]any, error) {
+					gotType, gotId = authType, authId
+					return tt.resp, tt.err
+				},
+			}}
+			resp, err := s.SendVerifyCode(context.Background(), &show.SendVerifyCodeReq{AuthType: "phone", AuthId: "13800000000"})
+			if gotType != "phone" || gotId != "13800000000" {
+				t.Fatalf("downstream got (%q, %q)", gotType, gotId)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && resp.Code != 0 {
+				t.Fatalf("code = %d, want 0", resp.Code)
+			}
+		})
+	}
+}
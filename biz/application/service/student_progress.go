@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util/log"
+	"math"
+
+	"github.com/spf13/cast"
+)
+
+// GetStudentProgress 按时间返回学生历次作文的总分、分项分和字数，教师和学生本人可查看
+func (s *HomeworkService) GetStudentProgress(ctx context.Context, req *show.GetStudentProgressReq) (*show.GetStudentProgressResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	member, err := s.checkMemberAccess(ctx, userMeta.GetUserId(), req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions, err := s.latestGradedSubmissions(ctx, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+
+	titles := make(map[string]string)
+	points := make([]*show.StudentProgressPoint, 0, len(submissions))
+	for _, sub := range submissions {
+		title, ok := titles[sub.HomeworkID]
+		if !ok {
+			if hw, err := s.HomeworkMapper.FindOne(ctx, sub.HomeworkID); err == nil {
+				title = hw.Title
+			}
+			titles[sub.HomeworkID] = title
+		}
+		point := &show.StudentProgressPoint{
+			SubmissionId:  sub.ID.Hex(),
+			HomeworkId:    sub.HomeworkID,
+			HomeworkTitle: title,
+			Score:         cast.ToFloat64(sub.GradeResult),
+			SubmitTime:    sub.CreateTime.Unix(),
+		}
+		if err := fillProgressScores(point, sub); err != nil {
+			log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", sub.ID.Hex(), err)
+		}
+		points = append(points, point)
+	}
+
+	return &show.GetStudentProgressResp{
+		Code:   0,
+		Msg:    "success",
+		Name:   member.Name,
+		Points: points,
+	}, nil
+}
+
+// fillProgressScores 按学科解析批改结果，填充成长曲线的分项得分和字数，英语的语言分计入表达
+func fillProgressScores(point *show.StudentProgressPoint, sub *homework.HomeworkSubmission) error {
+	if sub.Subject == consts.SubjectEnglish {
+		var evaluate stateless.EnglishEvaluate
+		if err := json.Unmarshal([]byte(sub.Response), &evaluate); err != nil {
+			return err
+		}
+		point.Content = int64(evaluate.Scores.Content)
+		point.Expression = int64(evaluate.Scores.Language)
+		point.Structure = int64(evaluate.Scores.Structure)
+		point.WordCount = int64(evaluate.WordNum)
+		return nil
+	}
+
+	var evaluate stateless.Evaluate
+	if err := json.Unmarshal([]byte(sub.Response), &evaluate); err != nil {
+		return err
+	}
+	scores := evaluate.AIEvaluation.ScoreEvaluation.Scores
+	point.Content = int64(scores.Content)
+	point.Expression = int64(scores.Expression)
+	point.Structure = int64(scores.Structure)
+	point.Development = int64(scores.Development)
+	point.WordCount = int64(evaluate.EssayInfo.Counting.CharNum)
+	return nil
+}
+
+// radarDimensions 雷达图的维度，初中作文的结构分和高中作文的发展分合并为结构
+var radarDimensions = []struct {
+	Key   string
+	Name  string
+	Ratio func(e *stateless.Evaluate) (float64, bool)
+}{
+	{"content", "内容", func(e *stateless.Evaluate) (float64, bool) {
+		return scoreRatio(e.AIEvaluation.ScoreEvaluation.Scores.ContentWithTotal)
+	}},
+	{"expression", "表达", func(e *stateless.Evaluate) (float64, bool) {
+		return scoreRatio(e.AIEvaluation.ScoreEvaluation.Scores.ExpressionWithTotal)
+	}},
+	{"structure", "结构", func(e *stateless.Evaluate) (float64, bool) {
+		if ratio, ok := scoreRatio(e.AIEvaluation.ScoreEvaluation.Scores.StructureWithTotal); ok {
+			return ratio, true
+		}
+		return scoreRatio(e.AIEvaluation.ScoreEvaluation.Scores.DevelopmentWithTotal)
+	}},
+	{"fluency", "流畅度", func(e *stateless.Evaluate) (float64, bool) {
+		fluency := e.EssayInfo.Counting.Fluency
+		return float64(fluency) / consts.RadarFluencyTotal, fluency > 0
+	}},
+}
+
+// GetStudentRadar 聚合学生最近 N 篇作文的分项平均得分率，输出雷达图数据
+func (s *HomeworkService) GetStudentRadar(ctx context.Context, req *show.GetStudentRadarReq) (*show.GetStudentRadarResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	count := req.Count
+	if count == 0 {
+		count = consts.RadarDefaultCount
+	}
+	if count < 0 || count > consts.RadarMaxCount {
+		return nil, consts.ErrInvalidParams
+	}
+
+	member, err := s.checkMemberAccess(ctx, userMeta.GetUserId(), req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+	submissions, err := s.latestGradedSubmissions(ctx, req.MemberId)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(submissions)) > count {
+		submissions = submissions[int64(len(submissions))-count:]
+	}
+
+	sums := make([]float64, len(radarDimensions))
+	counts := make([]int, len(radarDimensions))
+	for _, sub := range submissions {
+		// 雷达图按语文分项的满分计算得分率，其他学科的批改结果没有分项满分
+		if sub.Subject != consts.SubjectChinese {
+			continue
+		}
+		var evaluate stateless.Evaluate
+		if err := json.Unmarshal([]byte(sub.Response), &evaluate); err != nil {
+			log.CtxError(ctx, "解析批改结果失败, submissionId: %s, error: %v", sub.ID.Hex(), err)
+			continue
+		}
+		for i, dim := range radarDimensions {
+			if ratio, ok := dim.Ratio(&evaluate); ok {
+				sums[i] += ratio
+				counts[i]++
+			}
+		}
+	}
+
+	dimensions := make([]*show.RadarDimension, 0, len(radarDimensions))
+	for i, dim := range radarDimensions {
+		var score float64
+		if counts[i] > 0 {
+			score = math.Round(sums[i]/float64(counts[i])*1000) / 10
+		}
+		dimensions = append(dimensions, &show.RadarDimension{
+			Key:   dim.Key,
+			Name:  dim.Name,
+			Score: score,
+			Max:   100,
+		})
+	}
+	return &show.GetStudentRadarResp{
+		Code:       0,
+		Msg:        "success",
+		Name:       member.Name,
+		Count:      int64(len(submissions)),
+		Dimensions: dimensions,
+	}, nil
+}
+
+// checkMemberAccess 学生本人或班级创建者才能查看成员的批改数据
+func (s *HomeworkService) checkMemberAccess(ctx context.Context, userId, memberId string) (*class.ClassMember, error) {
+	if memberId == "" {
+		return nil, consts.ErrInvalidParams
+	}
+	member, err := s.MemberMapper.FindByMemberID(ctx, memberId)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if member.UserID != nil && *member.UserID == userId {
+		return member, nil
+	}
+	classInfo, err := s.ClassMapper.FindOne(ctx, member.ClassID)
+	if err != nil {
+		log.CtxError(ctx, "获取班级信息失败, classId: %s, error: %v", member.ClassID, err)
+		return nil, consts.ErrNotFound
+	}
+	if classInfo.CreatorID != userId {
+		return nil, consts.ErrForbidden
+	}
+	return member, nil
+}
+
+// latestGradedSubmissions 返回学生每份作业最新一次已完成的批改，按提交时间正序
+func (s *HomeworkService) latestGradedSubmissions(ctx context.Context, memberId string) ([]*homework.HomeworkSubmission, error) {
+	submissions, err := s.SubmissionMapper.FindCompletedByMember(ctx, memberId)
+	if err != nil {
+		log.CtxError(ctx, "查询学生提交记录失败, memberId: %s, error: %v", memberId, err)
+		return nil, consts.ErrCall
+	}
+	// 重批会产生新的提交，同一份作业保留时间最晚的一次
+	index := make(map[string]int)
+	latest := make([]*homework.HomeworkSubmission, 0, len(submissions))
+	for _, sub := range submissions {
+		if i, ok := index[sub.HomeworkID]; ok {
+			latest[i] = nil
+		}
+		index[sub.HomeworkID] = len(latest)
+		latest = append(latest, sub)
+	}
+	result := make([]*homework.HomeworkSubmission, 0, len(index))
+	for _, sub := range latest {
+		if sub != nil {
+			result = append(result, sub)
+		}
+	}
+	return result, nil
+}
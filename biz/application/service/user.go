@@ -6,14 +6,30 @@ import (
 	"essay-show/biz/adaptor"
 	"essay-show/biz/application/dto/essay/show"
 	"essay-show/biz/application/dto/essay/sts"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/repository/attend"
+	"essay-show/biz/infrastructure/repository/certification"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/guardian"
+	"essay-show/biz/infrastructure/repository/homework"
 	"essay-show/biz/infrastructure/repository/invitation"
+	logRepo "essay-show/biz/infrastructure/repository/log"
+	"essay-show/biz/infrastructure/repository/message"
+	"essay-show/biz/infrastructure/repository/risk"
+	"essay-show/biz/infrastructure/repository/session"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/repository/user"
 	"essay-show/biz/infrastructure/util"
 	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"net/mail"
+	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/google/uuid"
 	"github.com/google/wire"
 	"github.com/mitchellh/mapstructure"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -22,19 +38,52 @@ import (
 type IUserService interface {
 	SignIn(ctx context.Context, req *show.SignInReq) (*show.SignInResp, error)
 	BindAuth(ctx context.Context, req *show.BindAuthReq) (*show.BindAuthResp, error)
-	GetUserInfo(ctx context.Context, req *show.GetUserInfoReq) (*show.GetUserInfoResp, error)
+	GetUserInfo(ctx context.Context, req *show.GetUserInfoReq) (*show.UserInfoResp, error)
 	UpdateUserInfo(ctx context.Context, req *show.UpdateUserInfoReq) (*show.Response, error)
 	DailyAttend(ctx context.Context, req *show.DailyAttendReq) (*show.Response, error)
-	GetDailyAttend(ctx context.Context, req *show.GetDailyAttendReq) (*show.GetDailyAttendResp, error)
+	GetDailyAttend(ctx context.Context, req *show.GetDailyAttendReq) (*show.DailyAttendInfoResp, error)
+	MakeupAttend(ctx context.Context, req *show.MakeupAttendReq) (*show.Response, error)
+	GetInvitationStats(ctx context.Context, req *show.GetInvitationStatsReq) (*show.GetInvitationStatsResp, error)
 	FillInvitationCode(ctx context.Context, req *show.FillInvitationCodeReq) (*show.Response, error)
 	GetInvitationCode(ctx context.Context, req *show.GetInvitationCodeReq) (*show.GetInvitationCodeResp, error)
 	GenerateUrlLink(ctx context.Context, req *show.GenerateUrlLinkReq) (*show.GenerateUrlLinkResp, error)
+	SetPassword(ctx context.Context, req *show.SetPasswordReq) (*show.Response, error)
+	ChangePassword(ctx context.Context, req *show.ChangePasswordReq) (*show.Response, error)
+	GetWatermark(ctx context.Context, req *show.GetWatermarkReq) (*show.GetWatermarkResp, error)
+	UpdateWatermark(ctx context.Context, req *show.UpdateWatermarkReq) (*show.Response, error)
+	GetNotifySetting(ctx context.Context, req *show.GetNotifySettingReq) (*show.GetNotifySettingResp, error)
+	UpdateNotifySetting(ctx context.Context, req *show.UpdateNotifySettingReq) (*show.Response, error)
+	GetNotificationPreference(ctx context.Context, req *show.GetNotificationPreferenceReq) (*show.GetNotificationPreferenceResp, error)
+	UpdateNotificationPreference(ctx context.Context, req *show.UpdateNotificationPreferenceReq) (*show.Response, error)
+	ListMessages(ctx context.Context, req *show.ListMessagesReq) (*show.ListMessagesResp, error)
+	ReadMessages(ctx context.Context, req *show.ReadMessagesReq) (*show.Response, error)
+	RefreshToken(ctx context.Context, req *show.RefreshTokenReq) (*show.RefreshTokenResp, error)
+	SignOut(ctx context.Context, req *show.SignOutReq) (*show.Response, error)
+	ListSessions(ctx context.Context, req *show.ListSessionsReq) (*show.ListSessionsResp, error)
+	RevokeSession(ctx context.Context, req *show.RevokeSessionReq) (*show.Response, error)
+	ExportMyData(ctx context.Context, req *show.ExportMyDataReq) (*show.CreateDownloadTaskResp, error)
+	GetMyDataExport(ctx context.Context, req *show.GetDownloadTaskReq) (*show.GetDownloadTaskResp, error)
 }
 type UserService struct {
-	UserMapper   *user.MongoMapper
-	AttendMapper *attend.MongoMapper
-	CodeMapper   *invitation.CodeMongoMapper
-	LogMapper    *invitation.LogMongoMapper
+	UserMapper        *user.MongoMapper
+	AttendMapper      *attend.MongoMapper
+	CodeMapper        *invitation.CodeMongoMapper
+	LogMapper         *invitation.LogMongoMapper
+	EvaluateLogMapper *logRepo.MongoMapper
+	MemberMapper      *class.MemberMongoMapper
+	SubmissionMapper  *homework.SubmissionMongoMapper
+	CertMapper        *certification.MongoMapper
+	GuardianMapper    *guardian.MongoMapper
+	Blacklist         *cache.TokenBlacklistMapper
+	DownloadTask      *cache.DownloadTaskMapper
+	SessionMapper     *session.MongoMapper
+	Audit             *AuditRecorder
+	RiskMapper        *risk.MongoMapper
+	Rewards           *RewardConfig
+	Notifier          *Notifier
+	MessageMapper     *message.MongoMapper
+	Transactor        *transaction.Transactor
+	Downstream        util.DownstreamClient
 }
 
 var UserServiceSet = wire.NewSet(
@@ -46,8 +95,7 @@ func (s *UserService) SignIn(ctx context.Context, req *show.SignInReq) (*show.Si
 	var u *user.User
 	var err error
 
-	httpClient := util.GetHttpClient()
-	signInResponse, err := httpClient.SignIn(ctx, req.AuthType, req.AuthId, req.VerifyCode, req.Password)
+	signInResponse, err := s.Downstream.SignIn(ctx, req.AuthType, req.AuthId, req.VerifyCode, req.Password)
 	if err != nil || signInResponse["code"].(float64) != 0 {
 		return nil, consts.ErrSignIn
 	}
@@ -60,7 +108,17 @@ func (s *UserService) SignIn(ctx context.Context, req *show.SignInReq) (*show.Si
 		return nil, consts.ErrSignIn
 	}
 
-	accessToken, accessExpire, err := adaptor.GenerateJwtToken(resp)
+	// 设备号只认服务端签发的设备令牌，客户端未回传或验签失败时分配新设备号并签发令牌
+	ip, ua := adaptor.ExtractClientInfo(ctx)
+	clientDeviceId := adaptor.ExtractDeviceId(ctx)
+	deviceId := clientDeviceId
+	if deviceId == "" {
+		deviceId = uuid.NewString()
+		if err = adaptor.IssueDeviceToken(ctx, deviceId); err != nil {
+			log.CtxError(ctx, "签发设备令牌失败, err: %v", err)
+		}
+	}
+	accessToken, tm, err := adaptor.GenerateJwtToken(resp, deviceId)
 	if err != nil {
 		return nil, consts.ErrSignIn
 	}
@@ -80,7 +138,7 @@ func (s *UserService) SignIn(ctx context.Context, req *show.SignInReq) (*show.Si
 		u = &user.User{
 			ID:         oid,
 			Username:   "未设置用户名",
-			Count:      consts.DefaultCount,
+			Count:      s.Rewards.Load(ctx).DefaultCount,
 			Status:     0,
 			Role:       consts.RoleStudent,
 			CreateTime: now,
@@ -100,15 +158,74 @@ func (s *UserService) SignIn(ctx context.Context, req *show.SignInReq) (*show.Si
 		return nil, consts.ErrSignIn
 	}
 
+	if u.Status == consts.UserStatusBanned {
+		return nil, consts.ErrUserBanned
+	}
+
+	// 老用户在从未登录过的设备上登录时短信提醒，未回传有效设备令牌的一律视为新设备
+	if !isNew {
+		s.checkNewDeviceLogin(ctx, u, clientDeviceId, ip)
+	}
+
+	// 记录登录设备，同一设备重新登录时吊销被覆盖的旧 token
+	old, err := s.SessionMapper.Upsert(ctx, &session.Session{
+		UserID:     userId,
+		DeviceID:   deviceId,
+		Jti:        tm.Jti,
+		IP:         ip,
+		UserAgent:  ua,
+		ExpireTime: time.Unix(tm.Exp, 0),
+	})
+	if err != nil {
+		log.CtxError(ctx, "记录登录会话失败, userId: %s, err: %v", userId, err)
+	} else if old != nil && old.Jti != "" && old.Jti != tm.Jti && old.Status == consts.SessionStatusActive {
+		if err = s.Blacklist.Revoke(ctx, old.Jti, old.ExpireTime.Unix()-time.Now().Unix()); err != nil {
+			log.CtxError(ctx, "吊销同设备旧 token 失败, userId: %s, jti: %s, err: %v", userId, old.Jti, err)
+		}
+	}
+	s.Audit.Record(ctx, userId, consts.AuditActionSignIn, userId, fmt.Sprintf("登录方式: %s, 设备: %s", req.AuthType, deviceId))
+
+	// 通过密码登录成功说明中台已有密码，补齐本地标记
+	if req.Password != nil && !u.HasPassword {
+		if err = s.UserMapper.SetHasPassword(ctx, userId); err != nil {
+			log.CtxError(ctx, "更新密码标记失败, userId: %s, err: %v", userId, err)
+		}
+	}
+
 	return &show.SignInResp{
 		Id:           userId,
 		AccessToken:  accessToken,
-		AccessExpire: accessExpire,
+		AccessExpire: tm.Exp,
 		Name:         u.Username,
 		IsNew:        isNew,
 	}, nil
 }
 
+// checkNewDeviceLogin 账号在新设备登录时提醒用户，默认走短信，异步发送不阻塞登录；
+// deviceId 为空表示没有服务端签发的设备令牌，直接视为新设备，查询失败时不提醒
+func (s *UserService) checkNewDeviceLogin(ctx context.Context, u *user.User, deviceId, ip string) {
+	if deviceId != "" {
+		exists, err := s.SessionMapper.ExistsByDevice(ctx, u.ID.Hex(), deviceId)
+		if err != nil {
+			log.CtxError(ctx, "查询登录设备失败, userId: %s, err: %v", u.ID.Hex(), err)
+			return
+		}
+		if exists {
+			return
+		}
+	}
+	now := time.Now().Format("2006-01-02 15:04")
+	s.Notifier.NotifyAsync(ctx, u, consts.NotifyEventNewDevice, notifyMessage{
+		Subject:       "账号在新设备登录",
+		Content:       fmt.Sprintf("你的账号于 %s 在新设备登录（IP：%s），如非本人操作请及时修改密码。", now, ip),
+		SmsTemplateId: config.GetConfig().Notify.LoginSmsTemplateId,
+		SmsParams: map[string]string{
+			"time": now,
+			"ip":   ip,
+		},
+	})
+}
+
 func (s *UserService) BindAuth(ctx context.Context, req *show.BindAuthReq) (*show.BindAuthResp, error) {
 	// 获取用户id
 	userMeta := adaptor.ExtractUserMeta(ctx)
@@ -117,8 +234,7 @@ func (s *UserService) BindAuth(ctx context.Context, req *show.BindAuthReq) (*sho
 	}
 
 	// 在中台绑定授权
-	httpClient := util.GetHttpClient()
-	bindAuthResponse, err := httpClient.BindAuth(ctx, req.AuthType, req.AuthId, req.VerifyCode, userMeta.GetUserId())
+	bindAuthResponse, err := s.Downstream.BindAuth(ctx, req.AuthType, req.AuthId, req.VerifyCode, userMeta.GetUserId())
 	if err != nil || bindAuthResponse["code"].(float64) != 0 {
 		return nil, consts.ErrBindAuth
 	}
@@ -142,7 +258,7 @@ func (s *UserService) BindAuth(ctx context.Context, req *show.BindAuthReq) (*sho
 	}, nil
 }
 
-func (s *UserService) GetUserInfo(ctx context.Context, req *show.GetUserInfoReq) (*show.GetUserInfoResp, error) {
+func (s *UserService) GetUserInfo(ctx context.Context, req *show.GetUserInfoReq) (*show.UserInfoResp, error) {
 	// 用户信息
 	meta := adaptor.ExtractUserMeta(ctx)
 	if meta.GetUserId() == "" {
@@ -152,7 +268,7 @@ func (s *UserService) GetUserInfo(ctx context.Context, req *show.GetUserInfoReq)
 	// 查询用户
 	u, err := s.UserMapper.FindOne(ctx, meta.GetUserId())
 	if err != nil {
-		return &show.GetUserInfoResp{
+		return &show.UserInfoResp{
 			Code:    -1,
 			Msg:     "查询用户信息失败，请先登录或重试",
 			Payload: nil,
@@ -181,16 +297,38 @@ func (s *UserService) GetUserInfo(ctx context.Context, req *show.GetUserInfoReq)
 		count = -1
 		vipExpireTime = u.VipExpireTime.Unix()
 	}
-	return &show.GetUserInfoResp{
+
+	// 教师认证状态
+	certStatus := int64(consts.CertStatusNone)
+	if c, err := s.CertMapper.FindLatestByUser(ctx, u.ID.Hex()); err == nil {
+		certStatus = int64(c.Status)
+	} else if !errors.Is(err, consts.ErrNotFound) {
+		log.CtxError(ctx, "查询教师认证状态失败, userId: %s, err: %v", u.ID.Hex(), err)
+	}
+
+	// 家长不单独设角色，有绑定关系即为家长
+	var isParent bool
+	if n, err := s.GuardianMapper.CountByParent(ctx, u.ID.Hex()); err == nil {
+		isParent = n > 0
+	} else {
+		log.CtxError(ctx, "查询家长绑定关系失败, userId: %s, err: %v", u.ID.Hex(), err)
+	}
+
+	return &show.UserInfoResp{
 		Code: 0,
 		Msg:  "查询成功",
-		Payload: &show.GetUserInfoResp_Payload{
-			Name:          u.Username,
-			Count:         count,
-			Phone:         u.Phone,
-			Role:          role,
-			IsVip:         isVip,
-			VipExpireTime: vipExpireTime,
+		Payload: &show.UserInfoPayload{
+			GetUserInfoResp_Payload: &show.GetUserInfoResp_Payload{
+				Name:          u.Username,
+				Count:         count,
+				Phone:         u.Phone,
+				Role:          role,
+				IsVip:         isVip,
+				VipExpireTime: vipExpireTime,
+			},
+			CertStatus:  certStatus,
+			IsParent:    isParent,
+			HasPassword: u.HasPassword,
 		},
 	}, nil
 }
@@ -219,9 +357,19 @@ func (s *UserService) UpdateUserInfo(ctx context.Context, req *show.UpdateUserIn
 	if req.Role != nil {
 		switch *req.Role {
 		case show.UserRole_TEACHER:
+			// 教师身份需通过资质认证，已认证用户可以在角色间切换
+			if u.Role != consts.RoleTeacher {
+				c, err := s.CertMapper.FindLatestByUser(ctx, u.ID.Hex())
+				if err != nil || c.Status != consts.CertStatusApproved {
+					return nil, consts.ErrTeacherNotCertified
+				}
+			}
 			u.Role = consts.RoleTeacher
 		case show.UserRole_ADMIN:
-			u.Role = consts.RoleAdmin
+			// 管理员身份只能由后台授予
+			if u.Role != consts.RoleAdmin {
+				return nil, consts.ErrForbidden
+			}
 		case show.UserRole_EXAM_199:
 			u.Role = consts.Role199th
 		case show.UserRole_EXAM_396:
@@ -256,7 +404,8 @@ func (s *UserService) DailyAttend(ctx context.Context, req *show.DailyAttendReq)
 	}
 
 	// 今日有签到记录且不是第一次签到
-	if a != nil && time.Unix(a.Timestamp.Unix(), 0).Day() == time.Now().Day() && !a.Timestamp.IsZero() {
+	now := time.Now()
+	if a != nil && !a.Timestamp.IsZero() && daysBetween(a.Timestamp, now) == 0 {
 		return nil, consts.ErrRepeatDailyAttend
 	}
 
@@ -264,27 +413,143 @@ func (s *UserService) DailyAttend(ctx context.Context, req *show.DailyAttendReq)
 	_a := &attend.Attend{
 		ID:        primitive.NewObjectID(),
 		UserId:    meta.GetUserId(),
-		Timestamp: time.Now(),
+		Timestamp: now,
+		Streak:    nextStreak(a, now),
 	}
 	err = s.AttendMapper.Insert(ctx, _a)
 	if err != nil {
 		return nil, consts.ErrDailyAttend
 	}
 
-	// 增加次数
-	err = s.UserMapper.UpdateCount(ctx, meta.GetUserId(), consts.AttendReward)
+	// 增加次数，连续签到满一个周期额外奖励
+	rewards := s.Rewards.Load(ctx)
+	reward := rewards.AttendReward
+	bonus := _a.Streak%consts.AttendStreakCycle == 0 && rewards.AttendStreakReward > 0
+	if bonus {
+		reward += rewards.AttendStreakReward
+	}
+	err = s.UserMapper.UpdateCount(ctx, meta.GetUserId(), reward)
 	if err != nil {
 		return nil, consts.ErrDailyAttend
 	}
 
+	if bonus {
+		return util.Succeed(fmt.Sprintf("签到成功，已连续签到%d天，额外奖励%d次", _a.Streak, rewards.AttendStreakReward))
+	}
 	return util.Succeed("签到成功")
 }
 
-func (s *UserService) GetDailyAttend(ctx context.Context, req *show.GetDailyAttendReq) (*show.GetDailyAttendResp, error) {
-	resp := &show.GetDailyAttendResp{
-		Code:   0,
-		Msg:    "success",
-		Attend: 0,
+// MakeupAttend 补签本月今天之前漏签的日期，每月限量，补签不发放签到奖励
+func (s *UserService) MakeupAttend(ctx context.Context, req *show.MakeupAttendReq) (*show.Response, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	now := time.Now()
+	if req.Day < 1 || req.Day >= int64(now.Day()) {
+		return nil, consts.ErrInvalidMakeupDate
+	}
+
+	data, _, err := s.AttendMapper.FindByYearAndMonth(ctx, meta.GetUserId(), now.Year(), int(now.Month()))
+	if err != nil {
+		return nil, consts.ErrDailyAttend
+	}
+	for _, d := range data {
+		if int64(d.Timestamp.Day()) == req.Day {
+			return nil, consts.ErrRepeatDailyAttend
+		}
+	}
+	if countMakeup(data) >= consts.MakeupAttendMonthlyLimit {
+		return nil, consts.ErrMakeupAttendLimit
+	}
+
+	// 补签记录落在当天正午，避免跨时区比较时串到相邻日期
+	err = s.AttendMapper.Insert(ctx, &attend.Attend{
+		ID:        primitive.NewObjectID(),
+		UserId:    meta.GetUserId(),
+		Timestamp: time.Date(now.Year(), now.Month(), int(req.Day), 12, 0, 0, 0, time.Local),
+		Makeup:    true,
+	})
+	if err != nil {
+		return nil, consts.ErrDailyAttend
+	}
+
+	if err = s.refreshStreak(ctx, meta.GetUserId(), now); err != nil {
+		log.CtxError(ctx, "补签后重算连续签到失败, userId: %s, err: %v", meta.GetUserId(), err)
+	}
+	return util.Succeed("补签成功")
+}
+
+// refreshStreak 补签可能接上断签，重算最近一条签到记录的连续天数
+func (s *UserService) refreshStreak(ctx context.Context, userId string, now time.Time) error {
+	as, err := s.AttendMapper.FindSince(ctx, userId, now.AddDate(0, 0, -consts.AttendStreakLookback))
+	if err != nil || len(as) == 0 {
+		return err
+	}
+
+	latest := as[0]
+	streak := int64(1)
+	for i := 1; i < len(as); i++ {
+		gap := daysBetween(as[i].Timestamp, as[i-1].Timestamp)
+		if gap == 0 {
+			continue
+		}
+		if gap != 1 {
+			break
+		}
+		streak++
+	}
+	if latest.Streak == streak {
+		return nil
+	}
+	latest.Streak = streak
+	return s.AttendMapper.Update(ctx, latest)
+}
+
+func countMakeup(as []*attend.Attend) int64 {
+	var n int64
+	for _, a := range as {
+		if a.Makeup {
+			n++
+		}
+	}
+	return n
+}
+
+// daysBetween 计算两个时间相差的自然日数
+func daysBetween(from, to time.Time) int {
+	y1, m1, d1 := from.In(time.Local).Date()
+	y2, m2, d2 := to.In(time.Local).Date()
+	start := time.Date(y1, m1, d1, 0, 0, 0, 0, time.Local)
+	end := time.Date(y2, m2, d2, 0, 0, 0, 0, time.Local)
+	return int(end.Sub(start).Hours()/24 + 0.5)
+}
+
+// nextStreak 根据上一次签到计算本次签到后的连续天数，断签则从 1 开始
+func nextStreak(last *attend.Attend, now time.Time) int64 {
+	if last == nil || last.Timestamp.IsZero() || daysBetween(last.Timestamp, now) != 1 {
+		return 1
+	}
+	// 旧签到记录没有 streak 字段，按 1 天计
+	return max(last.Streak, 1) + 1
+}
+
+// currentStreak 当前仍然有效的连续签到天数，最近一次签到早于昨天视为已断签
+func currentStreak(last *attend.Attend, now time.Time) int64 {
+	if last == nil || last.Timestamp.IsZero() || daysBetween(last.Timestamp, now) > 1 {
+		return 0
+	}
+	return max(last.Streak, 1)
+}
+
+func (s *UserService) GetDailyAttend(ctx context.Context, req *show.GetDailyAttendReq) (*show.DailyAttendInfoResp, error) {
+	resp := &show.DailyAttendInfoResp{
+		GetDailyAttendResp: &show.GetDailyAttendResp{
+			Code:   0,
+			Msg:    "success",
+			Attend: 0,
+		},
 	}
 
 	// 用户信息
@@ -296,27 +561,47 @@ func (s *UserService) GetDailyAttend(ctx context.Context, req *show.GetDailyAtte
 	// 获取最新的, 确定今天的更新状态
 	a, err := s.findAttend(ctx, meta.GetUserId())
 	if err != nil {
-		log.Error("获取签到记录失败, err:%v", err.Error())
+		log.CtxError(ctx, "获取签到记录失败, err:%v", err.Error())
 		return nil, consts.ErrNotFound
 	}
-	if !a.Timestamp.IsZero() && time.Unix(a.Timestamp.Unix(), 0).Day() == time.Now().Day() {
+	now := time.Now()
+	if !a.Timestamp.IsZero() && daysBetween(a.Timestamp, now) == 0 {
 		resp.Attend = 1
 	}
+	resp.Streak = currentStreak(a, now)
+	resp.NextRewardStreak = (resp.Streak/consts.AttendStreakCycle + 1) * consts.AttendStreakCycle
 
 	// 获取所有的指定年月的所有签到记录
 	data, _, err := s.AttendMapper.FindByYearAndMonth(ctx, meta.GetUserId(), int(req.Year), int(req.Month))
 	if err != nil {
-		log.Error("获取签到记录失败, err:%v", err.Error())
+		log.CtxError(ctx, "获取签到记录失败, err:%v", err.Error())
 		return nil, consts.ErrNotFound
 	}
 
 	dtos := make([]int64, 0, len(data))
+	resp.MakeupHistory = make([]int64, 0)
 	for _, d := range data {
 		dtos = append(dtos, int64(d.Timestamp.Day()))
+		if d.Makeup {
+			resp.MakeupHistory = append(resp.MakeupHistory, int64(d.Timestamp.Day()))
+		}
 	}
 	resp.History = dtos
 	resp.Total = int64(len(dtos))
 
+	// 补签次数按自然月计算，只有查询本月时才需要剩余次数
+	resp.MakeupRemain = consts.MakeupAttendMonthlyLimit
+	if int(req.Year) == now.Year() && int(req.Month) == int(now.Month()) {
+		resp.MakeupRemain = max(consts.MakeupAttendMonthlyLimit-int64(len(resp.MakeupHistory)), 0)
+	} else {
+		current, _, err := s.AttendMapper.FindByYearAndMonth(ctx, meta.GetUserId(), now.Year(), int(now.Month()))
+		if err != nil {
+			log.CtxError(ctx, "获取签到记录失败, err:%v", err.Error())
+			return nil, consts.ErrNotFound
+		}
+		resp.MakeupRemain = max(consts.MakeupAttendMonthlyLimit-countMakeup(current), 0)
+	}
+
 	return resp, nil
 }
 
@@ -346,36 +631,203 @@ func (s *UserService) FillInvitationCode(ctx context.Context, req *show.FillInvi
 		return nil, err
 	}
 
+	// 设备号取自服务端签发的 token，客户端无法通过请求头伪造
+	ip, _ := adaptor.ExtractClientInfo(ctx)
+	deviceId := userMeta.GetDeviceId()
+	if err = s.checkInvitationRisk(ctx, inviter, invitee, deviceId, ip); err != nil {
+		return nil, err
+	}
+
 	// 插入邀请记录
-	err = s.LogMapper.Insert(ctx, inviter, invitee, req.Source)
+	// 邀请记录与双方奖励在同一事务中，任一失败时都不生效
+	inviterReward, inviteeReward := s.invitationRewards(ctx, inviter)
+	err = s.Transactor.Do(ctx, func(ctx context.Context) error {
+		err := s.LogMapper.Insert(ctx, &invitation.Log{
+			Inviter:  inviter,
+			Invitee:  invitee,
+			Source:   req.Source,
+			Reward:   inviterReward,
+			DeviceId: deviceId,
+			IP:       ip,
+		})
+		if err != nil {
+			return err
+		}
+		if err = s.UserMapper.UpdateCount(ctx, inviter, inviterReward); err != nil {
+			return err
+		}
+		return s.UserMapper.UpdateCount(ctx, invitee, inviteeReward)
+	})
 	if err != nil {
+		log.CtxError(ctx, "写入邀请记录失败, inviter: %s, invitee: %s, error: %v", inviter, invitee, err)
 		return nil, consts.ErrInvitation
 	}
 
-	err, err2 := s.UserMapper.UpdateCount(ctx, inviter, consts.InvitationReward), s.UserMapper.UpdateCount(ctx, invitee, consts.InvitationReward)
-	if err != nil || err2 != nil {
-		return nil, consts.ErrUpdate
+	// 对邀请者推送消息，按邀请者的通知偏好选择渠道，默认微信
+	inviterUser, err := s.UserMapper.FindOne(ctx, inviter)
+	if err != nil {
+		log.CtxError(ctx, "获取邀请人信息失败, inviter: %s, error: %v", inviter, err)
+		return util.Succeed("success")
 	}
+	s.Notifier.Notify(ctx, inviterUser, consts.NotifyEventInvitation, notifyMessage{
+		Subject:    "邀请好友成功",
+		Content:    "你邀请的好友已填写邀请码，批改次数到账了，请在小程序领取奖励吧~",
+		TemplateId: consts.InvitationTemplateId,
+		Data: map[string]string{
+			"thing4": "邀请好友成功",
+			"thing9": "批改次数到账了，请在小程序领取奖励吧~",
+		},
+		Page: consts.InvitationJumpPage,
+	})
+	return util.Succeed("success")
+}
 
-	// 对邀请者推送微信消息
-	client := util.GetHttpClient()
-	page := consts.InvitationJumpPage
+// checkInvitationRisk 邀请防刷：只有新注册用户可以填写，同设备超限直接拒绝，同 IP 短时间大量填写记入风控待人工审核
+func (s *UserService) checkInvitationRisk(ctx context.Context, inviter, invitee, deviceId, ip string) error {
+	rule := config.GetConfig().Invitation
+	window, deviceLimit, ipLimit := rule.RegisterWindow, rule.DeviceLimit, rule.IPLimit
+	if window <= 0 {
+		window = consts.InvitationRegisterWindow
+	}
+	if deviceLimit <= 0 {
+		deviceLimit = consts.InvitationDeviceLimit
+	}
+	if ipLimit <= 0 {
+		ipLimit = consts.InvitationIPLimit
+	}
 
-	resp, err := client.SendWechatMessage(ctx, inviter, consts.InvitationTemplateId, map[string]string{
-		"thing4": "邀请好友成功",
-		"thing9": "批改次数到账了，请在小程序领取奖励吧~",
-	}, &page)
+	u, err := s.UserMapper.FindOne(ctx, invitee)
 	if err != nil {
-		log.Error("发送微信消息失败: %v", err)
-		return nil, consts.ErrSendWechatMessage
+		return consts.ErrNotFound
+	}
+	if time.Since(u.CreateTime) > time.Duration(window)*time.Hour {
+		return consts.ErrInvitationExpired
 	}
 
-	if code, ok := resp["code"].(float64); !ok || code != 0 {
-		log.Error("发送微信消息失败, resp=%v", resp)
-		return nil, consts.ErrSendWechatMessage
+	if deviceId != "" {
+		n, err := s.LogMapper.CountByDevice(ctx, deviceId)
+		if err != nil {
+			log.CtxError(ctx, "统计设备邀请次数失败, deviceId: %s, err: %v", deviceId, err)
+			return consts.ErrInvitation
+		}
+		if n >= deviceLimit {
+			s.recordRisk(ctx, &risk.Record{
+				UserID:   invitee,
+				Type:     consts.RiskTypeInvitationDevice,
+				DeviceID: deviceId,
+				IP:       ip,
+				Detail:   fmt.Sprintf("设备已填写邀请码 %d 次, 本次邀请人: %s", n, inviter),
+			})
+			return consts.ErrInvitationRisk
+		}
 	}
 
-	return util.Succeed("success")
+	if ip != "" {
+		n, err := s.LogMapper.CountByIP(ctx, ip, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			log.CtxError(ctx, "统计 IP 邀请次数失败, ip: %s, err: %v", ip, err)
+			return nil
+		}
+		// IP 可能是公共出口，只记录不拦截
+		if n >= ipLimit {
+			s.recordRisk(ctx, &risk.Record{
+				UserID:   invitee,
+				Type:     consts.RiskTypeInvitationIP,
+				DeviceID: deviceId,
+				IP:       ip,
+				Detail:   fmt.Sprintf("IP 24 小时内已填写邀请码 %d 次, 本次邀请人: %s", n, inviter),
+			})
+		}
+	}
+	return nil
+}
+
+func (s *UserService) recordRisk(ctx context.Context, r *risk.Record) {
+	r.Status = consts.RiskStatusPending
+	if err := s.RiskMapper.Insert(ctx, r); err != nil {
+		log.CtxError(ctx, "写入风控记录失败, userId: %s, type: %s, err: %v", r.UserID, r.Type, err)
+	}
+}
+
+// invitationRewards 计算本次邀请双方的奖励，邀请人累计人数（含本次）恰好达到阶梯时追加阶梯奖励
+func (s *UserService) invitationRewards(ctx context.Context, inviter string) (inviterReward, inviteeReward int64) {
+	rewards := s.Rewards.Load(ctx)
+	inviterReward, inviteeReward = rewards.InviterReward, rewards.InviteeReward
+	rule := config.GetConfig().Invitation
+	if len(rule.Tiers) == 0 {
+		return
+	}
+
+	total, err := s.LogMapper.CountByInviter(ctx, inviter)
+	if err != nil {
+		log.CtxError(ctx, "统计邀请人数失败, inviter: %s, err: %v", inviter, err)
+		return
+	}
+	total++
+	for _, tier := range rule.Tiers {
+		if tier.Count == total {
+			inviterReward += tier.Reward
+			log.CtxInfo(ctx, "邀请人 %s 累计邀请 %d 人, 阶梯奖励 %d 次", inviter, total, tier.Reward)
+		}
+	}
+	return
+}
+
+// GetInvitationStats 查看我的邀请数据，活跃指被邀请人批改过作文
+func (s *UserService) GetInvitationStats(ctx context.Context, req *show.GetInvitationStatsReq) (*show.GetInvitationStatsResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	stat, err := s.LogMapper.StatByInviter(ctx, userMeta.GetUserId())
+	if err != nil {
+		log.CtxError(ctx, "统计邀请数据失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+
+	logs, total, err := s.LogMapper.FindByInviter(ctx, userMeta.GetUserId(), req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询邀请记录失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+
+	invitees := make([]string, 0, len(logs))
+	for _, l := range logs {
+		invitees = append(invitees, l.Invitee)
+	}
+	activeUsers, err := s.EvaluateLogMapper.DistinctUsers(ctx, invitees)
+	if err != nil {
+		log.CtxError(ctx, "查询被邀请人批改记录失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+	active := make(map[string]bool, len(activeUsers))
+	for _, uid := range activeUsers {
+		active[uid] = true
+	}
+
+	dtos := make([]*show.Invitee, 0, len(logs))
+	for _, l := range logs {
+		dto := &show.Invitee{
+			UserId:     l.Invitee,
+			Active:     active[l.Invitee],
+			Reward:     l.Reward,
+			InviteTime: l.Timestamp.Unix(),
+		}
+		if u, err := s.UserMapper.FindOne(ctx, l.Invitee); err == nil {
+			dto.Username = u.Username
+		}
+		dtos = append(dtos, dto)
+	}
+
+	return &show.GetInvitationStatsResp{
+		Code:         0,
+		Msg:          "success",
+		InvitedCount: stat.Count,
+		RewardTotal:  stat.Reward,
+		Invitees:     dtos,
+		Total:        total,
+	}, nil
 }
 
 func (s *UserService) GetInvitationCode(ctx context.Context, req *show.GetInvitationCodeReq) (*show.GetInvitationCodeResp, error) {
@@ -388,11 +840,11 @@ func (s *UserService) GetInvitationCode(ctx context.Context, req *show.GetInvita
 	if errors.Is(err, consts.ErrNotFound) {
 		c, err = s.CodeMapper.Insert(ctx, userMeta.GetUserId())
 		if err != nil {
-			log.Error("获取邀请码失败, err:%v", err.Error())
+			log.CtxError(ctx, "获取邀请码失败, err:%v", err.Error())
 			return nil, consts.ErrCall
 		}
 	} else if err != nil {
-		log.Error("获取邀请码失败, err:%v", err.Error())
+		log.CtxError(ctx, "获取邀请码失败, err:%v", err.Error())
 		return nil, consts.ErrCall
 	}
 
@@ -420,10 +872,9 @@ func (s *UserService) GenerateUrlLink(ctx context.Context, req *show.GenerateUrl
 	}
 	appId := wechatMeta.GetAppId()
 
-	client := util.GetHttpClient()
-	resp, err := client.GenerateUrlLink(ctx, appId, req.Path, req.Query)
+	resp, err := s.Downstream.GenerateUrlLink(ctx, appId, req.Path, req.Query)
 	if err != nil {
-		log.Error("GenerateUrlLink: 调用下游服务失败, err=%v", err)
+		log.CtxError(ctx, "GenerateUrlLink: 调用下游服务失败, err=%v", err)
 		return nil, err
 	}
 
@@ -446,3 +897,464 @@ func (s *UserService) GenerateUrlLink(ctx context.Context, req *show.GenerateUrl
 		UrlLink: urlLink,
 	}, nil
 }
+
+// RefreshToken 在刷新窗口内用旧 token 换发新 token，封禁用户不予刷新
+func (s *UserService) RefreshToken(ctx context.Context, req *show.RefreshTokenReq) (*show.RefreshTokenResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotAuthentication
+	}
+	if u.Status == consts.UserStatusBanned {
+		return nil, consts.ErrUserBanned
+	}
+
+	old, err := adaptor.ExtractTokenMeta(ctx)
+	if err != nil {
+		return nil, consts.ErrNotAuthentication
+	}
+	accessToken, tm, err := adaptor.RefreshJwtToken(ctx)
+	if err != nil {
+		log.CtxError(ctx, "刷新token失败, userId: %s, err: %v", u.ID.Hex(), err)
+		return nil, err
+	}
+	if err = s.SessionMapper.UpdateToken(ctx, tm.UserId, tm.DeviceId, tm.Jti, time.Unix(tm.Exp, 0)); err != nil {
+		log.CtxError(ctx, "更新登录会话失败, userId: %s, err: %v", u.ID.Hex(), err)
+	}
+	// 换发后旧 token 作废
+	if err = s.Blacklist.Revoke(ctx, old.Jti, old.Exp-time.Now().Unix()); err != nil {
+		log.CtxError(ctx, "吊销旧token失败, userId: %s, err: %v", u.ID.Hex(), err)
+	}
+	return &show.RefreshTokenResp{
+		Code:         0,
+		Msg:          "success",
+		AccessToken:  accessToken,
+		AccessExpire: tm.Exp,
+	}, nil
+}
+
+// SignOut 登出当前设备，All 为 true 时踢出该账号的所有设备
+func (s *UserService) SignOut(ctx context.Context, req *show.SignOutReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	tm, err := adaptor.ExtractTokenMeta(ctx)
+	if err != nil {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if req.All {
+		err = s.Blacklist.RevokeAllBefore(ctx, userMeta.GetUserId(), time.Now().UnixMilli())
+	} else {
+		err = s.Blacklist.Revoke(ctx, tm.Jti, tm.Exp-time.Now().Unix())
+	}
+	if err != nil {
+		log.CtxError(ctx, "登出失败, userId: %s, all: %v, err: %v", userMeta.GetUserId(), req.All, err)
+		return nil, consts.ErrCall
+	}
+
+	if req.All {
+		err = s.SessionMapper.RevokeAllByUser(ctx, userMeta.GetUserId())
+	} else {
+		err = s.SessionMapper.RevokeByDevice(ctx, userMeta.GetUserId(), tm.DeviceId)
+	}
+	if err != nil {
+		log.CtxError(ctx, "更新登录会话失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+	}
+	return util.Succeed("已退出登录")
+}
+
+// ListSessions 查看当前账号所有在线设备
+func (s *UserService) ListSessions(ctx context.Context, req *show.ListSessionsReq) (*show.ListSessionsResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	sessions, err := s.SessionMapper.FindActiveByUser(ctx, userMeta.GetUserId())
+	if err != nil {
+		log.CtxError(ctx, "查询登录会话失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+
+	dtos := make([]*show.SessionInfo, 0, len(sessions))
+	for _, ss := range sessions {
+		dtos = append(dtos, &show.SessionInfo{
+			Id:         ss.ID.Hex(),
+			DeviceId:   ss.DeviceID,
+			Ip:         ss.IP,
+			UserAgent:  ss.UserAgent,
+			LoginTime:  ss.LoginTime.Unix(),
+			ExpireTime: ss.ExpireTime.Unix(),
+			Current:    ss.DeviceID == userMeta.GetDeviceId(),
+		})
+	}
+	return &show.ListSessionsResp{Code: 0, Msg: "success", Sessions: dtos}, nil
+}
+
+// RevokeSession 下线指定设备，该设备上的 token 立即失效
+func (s *UserService) RevokeSession(ctx context.Context, req *show.RevokeSessionReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	ss, err := s.SessionMapper.FindOne(ctx, req.SessionId)
+	if err != nil {
+		return nil, err
+	}
+	if ss.UserID != userMeta.GetUserId() {
+		return nil, consts.ErrForbidden
+	}
+
+	if err = s.Blacklist.Revoke(ctx, ss.Jti, ss.ExpireTime.Unix()-time.Now().Unix()); err != nil {
+		log.CtxError(ctx, "吊销会话token失败, sessionId: %s, err: %v", req.SessionId, err)
+		return nil, consts.ErrCall
+	}
+	if err = s.SessionMapper.RevokeByDevice(ctx, ss.UserID, ss.DeviceID); err != nil {
+		log.CtxError(ctx, "更新登录会话失败, sessionId: %s, err: %v", req.SessionId, err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("已下线该设备")
+}
+
+// SetPassword 首次设置账号密码，设置后可使用密码登录
+func (s *UserService) SetPassword(ctx context.Context, req *show.SetPasswordReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if !validPassword(req.Password) {
+		return nil, consts.ErrInvalidPassword
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if u.HasPassword {
+		return nil, consts.ErrPasswordAlreadySet
+	}
+
+	if err = s.setPassword(ctx, u, req.Password, nil); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, u.ID.Hex(), consts.AuditActionSetPassword, u.ID.Hex(), "首次设置密码")
+	return util.Succeed("设置成功")
+}
+
+// ChangePassword 校验原密码后修改账号密码
+func (s *UserService) ChangePassword(ctx context.Context, req *show.ChangePasswordReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if req.OldPassword == "" || !validPassword(req.NewPassword) {
+		return nil, consts.ErrInvalidPassword
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if !u.HasPassword {
+		return nil, consts.ErrPasswordNotSet
+	}
+
+	if err = s.setPassword(ctx, u, req.NewPassword, &req.OldPassword); err != nil {
+		return nil, err
+	}
+	s.Audit.Record(ctx, u.ID.Hex(), consts.AuditActionChangePassword, u.ID.Hex(), "修改密码")
+	return util.Succeed("修改成功")
+}
+
+// GetWatermark 查询导出批改结果时的水印设置
+func (s *UserService) GetWatermark(ctx context.Context, req *show.GetWatermarkReq) (*show.GetWatermarkResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	resp := &show.GetWatermarkResp{
+		Code: 0,
+		Msg:  "success",
+	}
+	if u.Watermark != nil {
+		resp.Disabled = u.Watermark.Disabled
+		resp.Text = u.Watermark.Text
+	}
+	return resp, nil
+}
+
+// UpdateWatermark 教师自定义水印文字或关闭水印，对之后的下载生效
+func (s *UserService) UpdateWatermark(ctx context.Context, req *show.UpdateWatermarkReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	text := strings.TrimSpace(req.Text)
+	if utf8.RuneCountInString(text) > consts.WatermarkMaxLength {
+		return nil, consts.ErrInvalidParams
+	}
+
+	err := s.UserMapper.UpdateWatermark(ctx, userMeta.GetUserId(), &user.Watermark{
+		Disabled: req.Disabled,
+		Text:     text,
+	})
+	if err != nil {
+		log.CtxError(ctx, "更新水印设置失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("更新成功")
+}
+
+// GetNotifySetting 查询通知渠道偏好与绑定的邮箱
+func (s *UserService) GetNotifySetting(ctx context.Context, req *show.GetNotifySettingReq) (*show.GetNotifySettingResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	resp := &show.GetNotifySettingResp{
+		Code:    0,
+		Msg:     "success",
+		Channel: consts.NotifyChannelWechat,
+	}
+	if u.Notify != nil {
+		resp.Email = u.Notify.Email
+		if u.Notify.Channel != "" {
+			resp.Channel = u.Notify.Channel
+		}
+	}
+	return resp, nil
+}
+
+// UpdateNotifySetting 绑定接收通知的邮箱并选择默认通知渠道
+func (s *UserService) UpdateNotifySetting(ctx context.Context, req *show.UpdateNotifySettingReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	addr := strings.TrimSpace(req.Email)
+	if addr != "" {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil || parsed.Address != addr {
+			return nil, consts.ErrInvalidEmail
+		}
+	}
+	channel := req.Channel
+	if channel == "" {
+		channel = consts.NotifyChannelWechat
+	}
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if err = checkNotifyChannel(u, addr, channel); err != nil {
+		return nil, err
+	}
+
+	err = s.UserMapper.UpdateNotifySetting(ctx, userMeta.GetUserId(), &user.NotifySetting{
+		Email:   addr,
+		Channel: channel,
+	})
+	if err != nil {
+		log.CtxError(ctx, "更新通知设置失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("更新成功")
+}
+
+// GetNotificationPreference 查询各通知事件当前生效的接收渠道
+func (s *UserService) GetNotificationPreference(ctx context.Context, req *show.GetNotificationPreferenceReq) (*show.GetNotificationPreferenceResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	items := make([]*show.NotificationPreferenceItem, 0, len(notifyEvents))
+	for _, e := range notifyEvents {
+		items = append(items, &show.NotificationPreferenceItem{
+			Event:   e.Event,
+			Name:    e.Name,
+			Channel: notifyChannel(u, e.Event),
+		})
+	}
+	return &show.GetNotificationPreferenceResp{
+		Code:  0,
+		Msg:   "success",
+		Items: items,
+	}, nil
+}
+
+// UpdateNotificationPreference 按事件设置接收渠道，渠道为空表示恢复默认
+func (s *UserService) UpdateNotificationPreference(ctx context.Context, req *show.UpdateNotificationPreferenceReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	u, err := s.UserMapper.FindOne(ctx, userMeta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	var addr string
+	if u.Notify != nil {
+		addr = u.Notify.Email
+	}
+
+	preference := make(user.NotificationPreference)
+	for event, channel := range req.Preference {
+		if !isNotifyEvent(event) {
+			return nil, consts.ErrInvalidParams
+		}
+		if channel == "" {
+			continue
+		}
+		if err = checkNotifyChannel(u, addr, channel); err != nil {
+			return nil, err
+		}
+		preference[event] = channel
+	}
+
+	if err = s.UserMapper.UpdateNotificationPreference(ctx, userMeta.GetUserId(), preference); err != nil {
+		log.CtxError(ctx, "更新通知偏好失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("更新成功")
+}
+
+// ListMessages 分页查询站内通知
+func (s *UserService) ListMessages(ctx context.Context, req *show.ListMessagesReq) (*show.ListMessagesResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	messages, total, err := s.MessageMapper.FindByUser(ctx, userMeta.GetUserId(), req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询站内通知失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrCall
+	}
+	unread, err := s.MessageMapper.CountUnread(ctx, userMeta.GetUserId())
+	if err != nil {
+		log.CtxError(ctx, "统计未读通知失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+	}
+
+	dtos := make([]*show.Message, 0, len(messages))
+	for _, m := range messages {
+		dtos = append(dtos, &show.Message{
+			Id:         m.ID.Hex(),
+			Event:      m.Event,
+			Title:      m.Title,
+			Content:    m.Content,
+			Page:       m.Page,
+			Read:       m.Read,
+			CreateTime: m.CreateTime.Unix(),
+		})
+	}
+	return &show.ListMessagesResp{
+		Code:     0,
+		Msg:      "success",
+		Messages: dtos,
+		Total:    total,
+		Unread:   unread,
+	}, nil
+}
+
+// ReadMessages 将站内通知标记为已读，不传 ids 时全部已读
+func (s *UserService) ReadMessages(ctx context.Context, req *show.ReadMessagesReq) (*show.Response, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	if err := s.MessageMapper.MarkRead(ctx, userMeta.GetUserId(), req.Ids); err != nil {
+		if errors.Is(err, consts.ErrInvalidObjectId) {
+			return nil, err
+		}
+		log.CtxError(ctx, "标记站内通知已读失败, userId: %s, err: %v", userMeta.GetUserId(), err)
+		return nil, consts.ErrUpdate
+	}
+	return util.Succeed("success")
+}
+
+// checkNotifyChannel 校验渠道可用：邮件需要绑定邮箱，短信需要账号绑定手机号
+func checkNotifyChannel(u *user.User, email, channel string) error {
+	switch channel {
+	case consts.NotifyChannelWechat, consts.NotifyChannelInApp, consts.NotifyChannelNone:
+		return nil
+	case consts.NotifyChannelEmail:
+		if email == "" {
+			return consts.ErrInvalidEmail
+		}
+		return nil
+	case consts.NotifyChannelSms:
+		if u.Phone == "" {
+			return consts.ErrInvalidParams
+		}
+		return nil
+	default:
+		return consts.ErrInvalidParams
+	}
+}
+
+func isNotifyEvent(event string) bool {
+	for _, e := range notifyEvents {
+		if e.Event == event {
+			return true
+		}
+	}
+	return false
+}
+
+// setPassword 调用中台设置密码并更新本地标记
+func (s *UserService) setPassword(ctx context.Context, u *user.User, password string, oldPassword *string) error {
+	resp, err := s.Downstream.SetPassword(ctx, u.ID.Hex(), password, oldPassword)
+	if err != nil {
+		log.CtxError(ctx, "调用中台设置密码失败, userId: %s, err: %v", u.ID.Hex(), err)
+		return consts.ErrSetPassword
+	}
+	if code, ok := resp["code"].(float64); !ok || code != 0 {
+		log.CtxError(ctx, "中台设置密码失败, userId: %s, resp: %v", u.ID.Hex(), resp)
+		return consts.ErrSetPassword
+	}
+
+	if !u.HasPassword {
+		if err = s.UserMapper.SetHasPassword(ctx, u.ID.Hex()); err != nil {
+			log.CtxError(ctx, "更新密码标记失败, userId: %s, err: %v", u.ID.Hex(), err)
+			return consts.ErrUpdate
+		}
+	}
+	return nil
+}
+
+func validPassword(password string) bool {
+	n := len([]rune(password))
+	return n >= consts.PasswordMinLength && n <= consts.PasswordMaxLength
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/cache"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const dataExportPrefix = "data_export"
+
+// ExportMyData 把用户的个人资料、批改记录、作业提交、签到和邀请数据打包为 zip（每类一个 JSON 文件），
+// 后台生成后存到用户的 cos 目录，返回的 taskId 用于查询下载链接
+func (s *UserService) ExportMyData(ctx context.Context, req *show.ExportMyDataReq) (*show.CreateDownloadTaskResp, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+	u, err := s.UserMapper.FindOne(ctx, meta.GetUserId())
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+
+	task := &cache.DownloadTask{
+		Id:         uuid.New().String(),
+		UserId:     meta.GetUserId(),
+		Status:     consts.ReportStatusGenerating,
+		CreateTime: time.Now().Unix(),
+	}
+	if err = s.DownloadTask.Set(ctx, task); err != nil {
+		log.CtxError(ctx, "创建数据导出任务失败: %v", err)
+		return nil, consts.ErrCall
+	}
+	s.Audit.Record(ctx, u.ID.Hex(), consts.AuditActionExportMyData, task.Id, "导出个人数据")
+
+	go func() {
+		ctx := context.Background()
+		data, err := s.buildMyDataArchive(ctx, u)
+		if err == nil {
+			task.Url, task.SessionToken, err = putCosObject(ctx, s.Downstream, u.ID.Hex(), dataExportPrefix, ".zip", "application/zip", data)
+		}
+		if err != nil {
+			log.CtxError(ctx, "导出个人数据失败, userId: %s, error: %v", u.ID.Hex(), err)
+			task.Status = consts.ReportStatusFailed
+			task.Message = err.Error()
+		} else {
+			task.Status = consts.ReportStatusDone
+		}
+		if err = s.DownloadTask.Set(ctx, task); err != nil {
+			log.CtxError(ctx, "保存数据导出结果失败, taskId: %s, error: %v", task.Id, err)
+		}
+	}()
+
+	return &show.CreateDownloadTaskResp{Code: 0, Msg: "导出任务已提交", TaskId: task.Id}, nil
+}
+
+// GetMyDataExport 查询个人数据导出任务的状态与下载链接，任务结果保留 1 小时
+func (s *UserService) GetMyDataExport(ctx context.Context, req *show.GetDownloadTaskReq) (*show.GetDownloadTaskResp, error) {
+	meta := adaptor.ExtractUserMeta(ctx)
+	if meta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	task, err := s.DownloadTask.Get(ctx, req.TaskId)
+	if err != nil {
+		log.CtxError(ctx, "查询数据导出任务失败, taskId: %s, error: %v", req.TaskId, err)
+		return nil, consts.ErrCall
+	}
+	if task == nil || task.UserId != meta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+	return &show.GetDownloadTaskResp{
+		Code:         0,
+		Msg:          "success",
+		Status:       int64(task.Status),
+		Url:          task.Url,
+		SessionToken: task.SessionToken,
+		Message:      task.Message,
+	}, nil
+}
+
+// buildMyDataArchive 按类收集用户数据写入 zip，其中批改结果保持原始 JSON 结构；
+// 不导出其他用户的 id 和邀请的设备、IP 等风控信息
+func (s *UserService) buildMyDataArchive(ctx context.Context, u *user.User) ([]byte, error) {
+	userId := u.ID.Hex()
+	files := []struct {
+		name  string
+		build func() (any, error)
+	}{
+		{"profile.json", func() (any, error) { return exportProfile(u), nil }},
+		{"evaluate_logs.json", func() (any, error) { return s.exportEvaluateLogs(ctx, userId) }},
+		{"homework_submissions.json", func() (any, error) { return s.exportSubmissions(ctx, userId) }},
+		{"attendance.json", func() (any, error) { return s.exportAttendance(ctx, userId) }},
+		{"invitations.json", func() (any, error) { return s.exportInvitations(ctx, userId) }},
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range files {
+		v, err := f.build()
+		if err != nil {
+			return nil, fmt.Errorf("收集 %s 失败: %w", f.name, err)
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = w.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func exportProfile(u *user.User) map[string]any {
+	return map[string]any{
+		"id":            u.ID.Hex(),
+		"username":      u.Username,
+		"phone":         u.Phone,
+		"role":          u.Role,
+		"school":        u.School,
+		"grade":         u.Grade,
+		"count":         u.Count,
+		"ocrCount":      u.OcrCount,
+		"vipExpireTime": exportTime(u.VipExpireTime),
+		"createTime":    exportTime(u.CreateTime),
+	}
+}
+
+func (s *UserService) exportEvaluateLogs(ctx context.Context, userId string) ([]map[string]any, error) {
+	logs, err := s.EvaluateLogMapper.FindAllByUser(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, 0, len(logs))
+	for _, l := range logs {
+		result = append(result, map[string]any{
+			"id":         l.ID.Hex(),
+			"grade":      l.Grade,
+			"images":     l.Ocr,
+			"response":   exportResponse(l.Response),
+			"modified":   l.Status == 1,
+			"createTime": exportTime(l.CreateTime),
+		})
+	}
+	return result, nil
+}
+
+// exportSubmissions 用户作为学生在各班级中的作业提交
+func (s *UserService) exportSubmissions(ctx context.Context, userId string) ([]map[string]any, error) {
+	members, _, err := s.MemberMapper.FindByStuID(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, 0)
+	for _, member := range members {
+		submissions, err := s.SubmissionMapper.FindAllByMember(ctx, member.ID.Hex())
+		if err != nil {
+			return nil, err
+		}
+		for _, sub := range submissions {
+			result = append(result, map[string]any{
+				"id":         sub.ID.Hex(),
+				"homeworkId": sub.HomeworkID,
+				"classId":    member.ClassID,
+				"title":      sub.Title,
+				"text":       sub.Text,
+				"images":     sub.Images,
+				"response":   exportResponse(sub.Response),
+				"status":     sub.Status,
+				"createTime": exportTime(sub.CreateTime),
+			})
+		}
+	}
+	return result, nil
+}
+
+func (s *UserService) exportAttendance(ctx context.Context, userId string) ([]map[string]any, error) {
+	attends, err := s.AttendMapper.FindSince(ctx, userId, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	result := make([]map[string]any, 0, len(attends))
+	for _, a := range attends {
+		// 注册时插入的零值签到记录不是真实签到
+		if a.Timestamp.IsZero() {
+			continue
+		}
+		result = append(result, map[string]any{
+			"time":   exportTime(a.Timestamp),
+			"streak": a.Streak,
+			"makeup": a.Makeup,
+		})
+	}
+	return result, nil
+}
+
+// exportInvitations 用户的邀请码、填写邀请码的时间和邀请到的人数与奖励，不含被邀请人信息
+func (s *UserService) exportInvitations(ctx context.Context, userId string) (map[string]any, error) {
+	result := map[string]any{}
+	if code, err := s.CodeMapper.FindOneByUserId(ctx, userId); err == nil {
+		result["code"] = code.Code
+	}
+	if used, err := s.LogMapper.FindOneByInvitee(ctx, userId); err == nil {
+		result["invitedTime"] = exportTime(used.Timestamp)
+	}
+	logs, err := s.LogMapper.FindAllByInviter(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+	invitees := make([]map[string]any, 0, len(logs))
+	for _, l := range logs {
+		invitees = append(invitees, map[string]any{
+			"reward":     l.Reward,
+			"inviteTime": exportTime(l.Timestamp),
+		})
+	}
+	result["invitees"] = invitees
+	return result, nil
+}
+
+// exportResponse 批改结果按 JSON 原样导出，无法解析时导出原文
+func exportResponse(response string) any {
+	if json.Valid([]byte(response)) {
+		return json.RawMessage(response)
+	}
+	return response
+}
+
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
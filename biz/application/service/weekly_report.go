@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"essay-show/biz/adaptor"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+)
+
+// weeklyDimensions 周报统计得分率的分项，初中作文有结构分，高中作文有发展分
+var weeklyDimensions = []struct {
+	Name      string
+	WithTotal func(s *stateless.Scores) string
+}{
+	{"内容", func(s *stateless.Scores) string { return s.ContentWithTotal }},
+	{"表达", func(s *stateless.Scores) string { return s.ExpressionWithTotal }},
+	{"结构", func(s *stateless.Scores) string { return s.StructureWithTotal }},
+	{"发展", func(s *stateless.Scores) string { return s.DevelopmentWithTotal }},
+}
+
+// StartWeeklyReport 启动班级学情周报定时器，每小时检查一次，周日到达生成时刻后汇总本周作业并推送给教师
+func (s *HomeworkService) StartWeeklyReport(ctx context.Context) {
+	log.CtxInfo(ctx, "启动班级学情周报定时器")
+	go func() {
+		ticker := time.NewTicker(1 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				s.generateWeeklyReports(context.Background(), time.Now())
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// ListWeeklyReports 分页查询班级的历史周报
+func (s *HomeworkService) ListWeeklyReports(ctx context.Context, req *show.ListWeeklyReportsReq) (*show.ListWeeklyReportsResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	classInfo, err := s.ClassMapper.FindOne(ctx, req.ClassId)
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if classInfo.CreatorID != userMeta.GetUserId() {
+		return nil, consts.ErrForbidden
+	}
+
+	reports, total, err := s.WeeklyMapper.FindByClass(ctx, req.ClassId, req.PaginationOptions)
+	if err != nil {
+		log.CtxError(ctx, "查询班级周报失败, classId: %s, error: %v", req.ClassId, err)
+		return nil, consts.ErrCall
+	}
+	dtos := make([]*show.WeeklyReport, 0, len(reports))
+	for _, report := range reports {
+		dto := weeklyReportDTO(report)
+		dto.Homeworks = nil
+		dtos = append(dtos, dto)
+	}
+	return &show.ListWeeklyReportsResp{
+		Code:    0,
+		Msg:     "success",
+		Reports: dtos,
+		Total:   total,
+	}, nil
+}
+
+// GetWeeklyReport 查看一份周报及各作业的统计明细
+func (s *HomeworkService) GetWeeklyReport(ctx context.Context, req *show.GetWeeklyReportReq) (*show.GetWeeklyReportResp, error) {
+	userMeta := adaptor.ExtractUserMeta(ctx)
+	if userMeta.GetUserId() == "" {
+		return nil, consts.ErrNotAuthentication
+	}
+
+	report, err := s.WeeklyMapper.FindOne(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+	if report.CreatorID != userMeta.GetUserId() {
+		return nil, consts.ErrNotFound
+	}
+	return &show.GetWeeklyReportResp{
+		Code:   0,
+		Msg:    "success",
+		Report: weeklyReportDTO(report),
+	}, nil
+}
+
+func (s *HomeworkService) generateWeeklyReports(ctx context.Context, now time.Time) {
+	if now.Weekday() != time.Sunday || now.Hour() != config.GetConfig().Notify.GetWeeklyReportHour() {
+		return
+	}
+
+	// 本周一 0 点到下周一 0 点
+	y, m, d := now.Date()
+	start := time.Date(y, m, d-6, 0, 0, 0, 0, now.Location())
+	end := start.AddDate(0, 0, 7)
+	ok, err := s.NotifyCache.TryMarkWeeklyReport(ctx, start.Format(time.DateOnly))
+	if err != nil || !ok {
+		return
+	}
+
+	homeworks, err := s.HomeworkMapper.FindAllByTime(ctx, start, end)
+	if err != nil {
+		log.CtxError(ctx, "查询本周作业失败: %v", err)
+		return
+	}
+	// 本周没有布置作业的班级不生成周报
+	byClass := make(map[string][]*homework.Homework)
+	var classIds []string
+	for _, hw := range homeworks {
+		if byClass[hw.ClassID] == nil {
+			classIds = append(classIds, hw.ClassID)
+		}
+		byClass[hw.ClassID] = append(byClass[hw.ClassID], hw)
+	}
+
+	for _, classId := range classIds {
+		report, err := s.buildWeeklyReport(ctx, classId, byClass[classId])
+		if err != nil {
+			log.CtxError(ctx, "生成班级周报失败, classId: %s, error: %v", classId, err)
+			continue
+		}
+		report.WeekStart, report.WeekEnd = start, end
+		if err = s.WeeklyMapper.Upsert(ctx, report); err != nil {
+			log.CtxError(ctx, "保存班级周报失败, classId: %s, error: %v", classId, err)
+			continue
+		}
+		s.notifyWeeklyReport(ctx, report)
+	}
+}
+
+// buildWeeklyReport 统计班级本周每份作业的提交率和平均分，每个学生只取最新一次已完成的批改，
+// 分项得分率在全班平均低于 consts.WeeklyWeakRatio 时列为共性问题
+func (s *HomeworkService) buildWeeklyReport(ctx context.Context, classId string, homeworks []*homework.Homework) (*homework.WeeklyReport, error) {
+	classInfo, err := s.ClassMapper.FindOne(ctx, classId)
+	if err != nil {
+		return nil, fmt.Errorf("获取班级信息失败: %w", err)
+	}
+	report := &homework.WeeklyReport{
+		ClassID:        classId,
+		CreatorID:      classInfo.CreatorID,
+		MemberCount:    classInfo.MemberCount,
+		CommonProblems: []string{},
+	}
+
+	var (
+		allScores  []float64
+		rateSum    float64
+		ratioSum   = make([]float64, len(weeklyDimensions))
+		ratioCount = make([]int, len(weeklyDimensions))
+	)
+	for _, hw := range homeworks {
+		submissions, err := s.SubmissionMapper.FindAllByHomework(ctx, hw.ID.Hex(), nil)
+		if err != nil {
+			log.CtxError(ctx, "查询作业提交记录失败, homeworkId: %s, error: %v", hw.ID.Hex(), err)
+			continue
+		}
+
+		submitted := make(map[string]bool)
+		graded := make(map[string]bool)
+		var scores []float64
+		// 提交记录按更新时间倒序，每个学生只统计最新一次已完成的批改
+		for _, sub := range submissions {
+			submitted[sub.MemberId] = true
+			if sub.Status != consts.StatusCompleted && sub.Status != consts.StatusModified {
+				continue
+			}
+			if graded[sub.MemberId] {
+				continue
+			}
+			graded[sub.MemberId] = true
+			if score, err := cast.ToFloat64E(sub.GradeResult); err == nil {
+				scores = append(scores, score)
+			}
+
+			// 分项得分率按语文的评分项统计，其他学科只计入总分
+			if sub.Subject != consts.SubjectChinese {
+				continue
+			}
+			var evaluate stateless.Evaluate
+			if err := json.Unmarshal([]byte(sub.Response), &evaluate); err != nil {
+				continue
+			}
+			for i, dim := range weeklyDimensions {
+				if ratio, ok := scoreRatio(dim.WithTotal(&evaluate.AIEvaluation.ScoreEvaluation.Scores)); ok {
+					ratioSum[i] += ratio
+					ratioCount[i]++
+				}
+			}
+		}
+
+		var rate float64
+		if classInfo.MemberCount > 0 {
+			rate = roundRatio(float64(len(submitted)) / float64(classInfo.MemberCount))
+		}
+		avg, _, _ := scoreSummary(scores)
+		rateSum += rate
+		allScores = append(allScores, scores...)
+		report.Homeworks = append(report.Homeworks, &homework.WeeklyHomework{
+			HomeworkID:   hw.ID.Hex(),
+			Title:        hw.Title,
+			Submitted:    int64(len(submitted)),
+			SubmitRate:   rate,
+			AverageScore: avg,
+		})
+	}
+	if len(report.Homeworks) == 0 {
+		return nil, consts.ErrNotFound
+	}
+
+	report.SubmitRate = roundRatio(rateSum / float64(len(report.Homeworks)))
+	report.AverageScore, _, _ = scoreSummary(allScores)
+	for i, dim := range weeklyDimensions {
+		if ratioCount[i] == 0 {
+			continue
+		}
+		if ratio := ratioSum[i] / float64(ratioCount[i]); ratio < consts.WeeklyWeakRatio {
+			report.CommonProblems = append(report.CommonProblems, fmt.Sprintf("%s得分率偏低（%.0f%%）", dim.Name, ratio*100))
+		}
+	}
+	return report, nil
+}
+
+// notifyWeeklyReport 按教师的通知偏好推送周报摘要，推送失败只记录日志
+func (s *HomeworkService) notifyWeeklyReport(ctx context.Context, report *homework.WeeklyReport) {
+	teacher, err := s.UserMapper.FindOne(ctx, report.CreatorID)
+	if err != nil {
+		log.CtxError(ctx, "获取教师信息失败, userId: %s, error: %v", report.CreatorID, err)
+		return
+	}
+	var className string
+	if classInfo, err := s.ClassMapper.FindOne(ctx, report.ClassID); err == nil {
+		className = classInfo.Name
+	}
+
+	summary := fmt.Sprintf("%d份作业，提交率%.0f%%，平均分%v", len(report.Homeworks), report.SubmitRate*100, report.AverageScore)
+	lines := []string{summary}
+	for _, hw := range report.Homeworks {
+		lines = append(lines, fmt.Sprintf("「%s」提交 %d 人，提交率 %.0f%%，平均分 %v", hw.Title, hw.Submitted, hw.SubmitRate*100, hw.AverageScore))
+	}
+	if len(report.CommonProblems) > 0 {
+		lines = append(lines, "共性问题："+strings.Join(report.CommonProblems, "；"))
+	}
+
+	weekEnd := report.WeekEnd.Add(-time.Second).Format(time.DateOnly)
+	s.Notifier.Notify(ctx, teacher, consts.NotifyEventWeeklyReport, notifyMessage{
+		Subject:    fmt.Sprintf("%s 学情周报 %s 至 %s", className, report.WeekStart.Format(time.DateOnly), weekEnd),
+		Content:    fmt.Sprintf("%s %s 至 %s 的学情周报：\n%s", className, report.WeekStart.Format(time.DateOnly), weekEnd, strings.Join(lines, "\n")),
+		TemplateId: config.GetConfig().Notify.WeeklyReportTemplateId,
+		Data: map[string]string{
+			"thing1": className,
+			"thing2": summary,
+		},
+		Page: fmt.Sprintf("%s?classId=%s", consts.WeeklyReportJumpPage, report.ClassID),
+	})
+}
+
+// scoreRatio 解析 "得分/满分" 形式的分项分数，返回得分率
+func scoreRatio(withTotal string) (float64, bool) {
+	parts := strings.Split(withTotal, "/")
+	if len(parts) != 2 {
+		return 0, false
+	}
+	score, err1 := cast.ToFloat64E(strings.TrimSpace(parts[0]))
+	total, err2 := cast.ToFloat64E(strings.TrimSpace(parts[1]))
+	if err1 != nil || err2 != nil || total <= 0 {
+		return 0, false
+	}
+	return score / total, true
+}
+
+// roundRatio 比例保留两位小数
+func roundRatio(ratio float64) float64 {
+	return math.Round(ratio*100) / 100
+}
+
+func weeklyReportDTO(report *homework.WeeklyReport) *show.WeeklyReport {
+	homeworks := make([]*show.WeeklyHomework, 0, len(report.Homeworks))
+	for _, hw := range report.Homeworks {
+		homeworks = append(homeworks, &show.WeeklyHomework{
+			HomeworkId:   hw.HomeworkID,
+			Title:        hw.Title,
+			Submitted:    hw.Submitted,
+			SubmitRate:   hw.SubmitRate,
+			AverageScore: hw.AverageScore,
+		})
+	}
+	return &show.WeeklyReport{
+		Id:             report.ID.Hex(),
+		ClassId:        report.ClassID,
+		WeekStart:      report.WeekStart.Unix(),
+		WeekEnd:        report.WeekEnd.Unix(),
+		MemberCount:    report.MemberCount,
+		SubmitRate:     report.SubmitRate,
+		AverageScore:   report.AverageScore,
+		Homeworks:      homeworks,
+		CommonProblems: report.CommonProblems,
+		CreateTime:     report.CreateTime.Unix(),
+	}
+}
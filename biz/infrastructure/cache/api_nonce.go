@@ -0,0 +1,35 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	apiNoncePrefix    = "api_nonce:"
+	apiIPDeniedPrefix = "api_ip_denied:"
+)
+
+// ApiNonceMapper 外部 API 签名请求的 nonce 去重，同一密钥的 nonce 在过期前只能使用一次
+type ApiNonceMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewApiNonceMapper(config *config.Config) *ApiNonceMapper {
+	return &ApiNonceMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Use 占用 nonce，已被使用过时返回 false
+func (m *ApiNonceMapper) Use(ctx context.Context, keyId, nonce string, expire int) (bool, error) {
+	return m.rds.SetnxExCtx(ctx, apiNoncePrefix+keyId+":"+nonce, "1", expire)
+}
+
+// MarkIPDenied 记录密钥被某来源 IP 拒绝，period 秒内首次拒绝时返回 true，用于审计日志限频
+func (m *ApiNonceMapper) MarkIPDenied(ctx context.Context, keyId, ip string, period int) (bool, error) {
+	return m.rds.SetnxExCtx(ctx, apiIPDeniedPrefix+keyId+":"+ip, "1", period)
+}
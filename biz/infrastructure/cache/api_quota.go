@@ -0,0 +1,79 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"strconv"
+	"time"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	apiQuotaPrefix        = "api_quota:"
+	apiQuotaDailyExpire   = 2 * 24 * 3600  // 按天计数的过期时间，留出跨天的余量
+	apiQuotaMonthlyExpire = 32 * 24 * 3600 // 按月计数的过期时间
+)
+
+// ApiQuotaMapper 按 API Key 统计当天和当月已受理的调用次数，用于配额判断，多实例共享
+type ApiQuotaMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewApiQuotaMapper(config *config.Config) *ApiQuotaMapper {
+	return &ApiQuotaMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Incr 当天和当月的计数各加一，返回加一后的值
+func (m *ApiQuotaMapper) Incr(ctx context.Context, keyId string, now time.Time) (daily, monthly int64, err error) {
+	dayKey, monthKey := m.keys(keyId, now)
+	if daily, err = m.incr(ctx, dayKey, apiQuotaDailyExpire); err != nil {
+		return
+	}
+	if monthly, err = m.incr(ctx, monthKey, apiQuotaMonthlyExpire); err != nil {
+		_, _ = m.rds.DecrCtx(ctx, dayKey)
+	}
+	return
+}
+
+// Get 当天和当月已受理的调用次数
+func (m *ApiQuotaMapper) Get(ctx context.Context, keyId string, now time.Time) (daily, monthly int64, err error) {
+	dayKey, monthKey := m.keys(keyId, now)
+	vals, err := m.rds.MgetCtx(ctx, dayKey, monthKey)
+	if err != nil {
+		return 0, 0, err
+	}
+	daily, _ = strconv.ParseInt(vals[0], 10, 64)
+	monthly, _ = strconv.ParseInt(vals[1], 10, 64)
+	return daily, monthly, nil
+}
+
+// Decr 撤销一次 Incr，用于超出配额或调用失败时归还次数
+func (m *ApiQuotaMapper) Decr(ctx context.Context, keyId string, now time.Time) error {
+	dayKey, monthKey := m.keys(keyId, now)
+	if _, err := m.rds.DecrCtx(ctx, dayKey); err != nil {
+		return err
+	}
+	_, err := m.rds.DecrCtx(ctx, monthKey)
+	return err
+}
+
+func (m *ApiQuotaMapper) incr(ctx context.Context, key string, expire int) (int64, error) {
+	n, err := m.rds.IncrCtx(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if err = m.rds.ExpireCtx(ctx, key, expire); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func (m *ApiQuotaMapper) keys(keyId string, now time.Time) (string, string) {
+	return apiQuotaPrefix + keyId + ":" + now.Format("20060102"), apiQuotaPrefix + keyId + ":" + now.Format("200601")
+}
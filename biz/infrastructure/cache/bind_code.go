@@ -0,0 +1,98 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	bindCodePrefix     = "parent_bind_code"
+	bindFailUserPrefix = "parent_bind_fail:user"
+	bindFailCodePrefix = "parent_bind_fail:code"
+)
+
+// BindCodeMapper 学生生成的家长绑定码，code -> 学生 userId，同时按用户和绑定码统计错误次数
+type BindCodeMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewBindCodeMapper(config *config.Config) *BindCodeMapper {
+	return &BindCodeMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Set 保存绑定码，code 已存在时返回 false
+func (m *BindCodeMapper) Set(ctx context.Context, code, childID string) (bool, error) {
+	return m.rds.SetnxExCtx(ctx, m.buildCacheKey(code), childID, consts.BindCodeExpire)
+}
+
+// Get 获取绑定码对应的学生，不存在时返回空字符串
+func (m *BindCodeMapper) Get(ctx context.Context, code string) (string, error) {
+	return m.rds.GetCtx(ctx, m.buildCacheKey(code))
+}
+
+func (m *BindCodeMapper) Delete(ctx context.Context, code string) error {
+	_, err := m.rds.DelCtx(ctx, m.buildCacheKey(code))
+	return err
+}
+
+// Expire 绑定码有效期（秒）
+func (m *BindCodeMapper) Expire() int64 {
+	return consts.BindCodeExpire
+}
+
+// Locked 用户在统计周期内的错误次数是否已达上限
+func (m *BindCodeMapper) Locked(ctx context.Context, userID string) (bool, error) {
+	n, err := m.rds.GetCtx(ctx, fmt.Sprintf("%s:%s", bindFailUserPrefix, userID))
+	if err != nil {
+		return false, err
+	}
+	var count int
+	_, _ = fmt.Sscan(n, &count)
+	return count >= consts.BindCodeMaxFailures, nil
+}
+
+// Fail 记录一次错误，同一绑定码错误次数达到上限时作废该绑定码，防止被逐个用户轮流猜测
+func (m *BindCodeMapper) Fail(ctx context.Context, userID, code string) error {
+	if _, err := m.incr(ctx, fmt.Sprintf("%s:%s", bindFailUserPrefix, userID)); err != nil {
+		return err
+	}
+	n, err := m.incr(ctx, fmt.Sprintf("%s:%s", bindFailCodePrefix, code))
+	if err != nil {
+		return err
+	}
+	if n >= consts.BindCodeMaxFailures {
+		return m.Delete(ctx, code)
+	}
+	return nil
+}
+
+// Reset 绑定成功后清除用户的错误次数
+func (m *BindCodeMapper) Reset(ctx context.Context, userID string) error {
+	_, err := m.rds.DelCtx(ctx, fmt.Sprintf("%s:%s", bindFailUserPrefix, userID))
+	return err
+}
+
+// incr 计数加一，首次计数时设置统计周期
+func (m *BindCodeMapper) incr(ctx context.Context, key string) (int64, error) {
+	n, err := m.rds.IncrCtx(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if err = m.rds.ExpireCtx(ctx, key, consts.BindCodeLockPeriod); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+func (m *BindCodeMapper) buildCacheKey(code string) string {
+	return fmt.Sprintf("%s:%s", bindCodePrefix, code)
+}
@@ -2,11 +2,15 @@ package cache
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"essay-show/biz/application/dto/essay/show"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/redis"
 	"fmt"
+	"sort"
+	"strings"
 
 	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
 )
@@ -14,12 +18,17 @@ import (
 const (
 	downloadEvaluateCachePrefix = "download_evaluate"
 	downloadEvaluateCacheExpire = 3600 // 1小时
+	// 每个提交登记了哪些组合 key，提交的批改结果修改后据此一并删除
+	downloadSubmissionIndexPrefix = "download_evaluate_index"
 )
 
 type IDownloadCacheMapper interface {
 	Get(ctx context.Context, id string) (*show.DownloadEvaluateResp, error)
 	Set(ctx context.Context, id string, data *show.DownloadEvaluateResp) error
 	Delete(ctx context.Context, id string) error
+	SetBatch(ctx context.Context, submissionIds []string, variant string, data *show.DownloadEvaluateResp) error
+	GetBatch(ctx context.Context, submissionIds []string, variant string) (*show.DownloadEvaluateResp, error)
+	DeleteBySubmission(ctx context.Context, submissionId string) error
 }
 
 type DownloadCacheMapper struct {
@@ -72,6 +81,56 @@ func (m *DownloadCacheMapper) Delete(ctx context.Context, id string) error {
 	return err
 }
 
+// SetBatch 缓存一组提交的下载结果，variant 区分格式、导出选项等，组合 key 会登记到每个提交的索引中
+func (m *DownloadCacheMapper) SetBatch(ctx context.Context, submissionIds []string, variant string, data *show.DownloadEvaluateResp) error {
+	batchKey := m.buildBatchKey(submissionIds, variant)
+	if err := m.Set(ctx, batchKey, data); err != nil {
+		return err
+	}
+	for _, id := range submissionIds {
+		indexKey := m.buildIndexKey(id)
+		if _, err := m.rds.SaddCtx(ctx, indexKey, batchKey); err != nil {
+			return err
+		}
+		if err := m.rds.ExpireCtx(ctx, indexKey, downloadEvaluateCacheExpire); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *DownloadCacheMapper) GetBatch(ctx context.Context, submissionIds []string, variant string) (*show.DownloadEvaluateResp, error) {
+	return m.Get(ctx, m.buildBatchKey(submissionIds, variant))
+}
+
+// DeleteBySubmission 提交的批改结果修改后调用，删除该提交自身以及所有包含它的组合 key
+func (m *DownloadCacheMapper) DeleteBySubmission(ctx context.Context, submissionId string) error {
+	indexKey := m.buildIndexKey(submissionId)
+	batchKeys, err := m.rds.SmembersCtx(ctx, indexKey)
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(batchKeys)+2)
+	keys = append(keys, m.buildCacheKey(submissionId), indexKey)
+	for _, batchKey := range batchKeys {
+		keys = append(keys, m.buildCacheKey(batchKey))
+	}
+	_, err = m.rds.DelCtx(ctx, keys...)
+	return err
+}
+
+// buildBatchKey 组合 key 与提交顺序无关
+func (m *DownloadCacheMapper) buildBatchKey(submissionIds []string, variant string) string {
+	ids := append([]string(nil), submissionIds...)
+	sort.Strings(ids)
+	sum := md5.Sum([]byte(strings.Join(ids, ",") + "|" + variant))
+	return "batch:" + hex.EncodeToString(sum[:])
+}
+
+func (m *DownloadCacheMapper) buildIndexKey(submissionId string) string {
+	return fmt.Sprintf("%s:%s", downloadSubmissionIndexPrefix, submissionId)
+}
+
 // buildCacheKey 构造缓存key
 func (m *DownloadCacheMapper) buildCacheKey(id string) string {
 	return fmt.Sprintf("%s:%s", downloadEvaluateCachePrefix, id)
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	downloadTaskPrefix = "download_task"
+	downloadTaskExpire = 3600 // 1小时，与下载链接的有效期一致
+)
+
+// DownloadTask 异步下载任务，生成完成后回填下载链接
+type DownloadTask struct {
+	Id           string `json:"id"`
+	UserId       string `json:"userId"`
+	Status       int    `json:"status"` // 0: 生成中, 1: 已完成, 2: 生成失败
+	Url          string `json:"url,omitempty"`
+	SessionToken string `json:"sessionToken,omitempty"`
+	Message      string `json:"message,omitempty"` // 失败原因
+	CreateTime   int64  `json:"createTime"`
+}
+
+type DownloadTaskMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewDownloadTaskMapper(config *config.Config) *DownloadTaskMapper {
+	return &DownloadTaskMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Get 任务不存在或已过期时返回 nil, nil
+func (m *DownloadTaskMapper) Get(ctx context.Context, id string) (*DownloadTask, error) {
+	cachedData, err := m.rds.GetCtx(ctx, m.buildCacheKey(id))
+	if err != nil || cachedData == "" {
+		return nil, err
+	}
+
+	var task DownloadTask
+	if err := json.Unmarshal([]byte(cachedData), &task); err != nil {
+		return nil, fmt.Errorf("unmarshal cached data failed: %w", err)
+	}
+	return &task, nil
+}
+
+func (m *DownloadTaskMapper) Set(ctx context.Context, task *DownloadTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("marshal data failed: %w", err)
+	}
+	return m.rds.SetexCtx(ctx, m.buildCacheKey(task.Id), string(data), downloadTaskExpire)
+}
+
+func (m *DownloadTaskMapper) buildCacheKey(id string) string {
+	return fmt.Sprintf("%s:%s", downloadTaskPrefix, id)
+}
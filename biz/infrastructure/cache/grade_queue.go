@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+	"strings"
+	"time"
+
+	red "github.com/redis/go-redis/v9"
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	gradeQueueStream = "stream:homework_grade"
+	gradeQueueGroup  = "grader"
+	gradeQueueField  = "submission_id"
+)
+
+// GradeTask 一条作业批改任务
+type GradeTask struct {
+	Id           string // 消息 id，确认时使用
+	SubmissionId string
+}
+
+// GradeQueueMapper 作业批改任务总线，基于 Redis Stream 的消费组，同一条消息只投递给组内的一个消费者，
+// 多实例部署时不会重复领取；go-zero 未封装 Stream 命令，通过 Pipelined 调用
+type GradeQueueMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewGradeQueueMapper(config *config.Config) *GradeQueueMapper {
+	return &GradeQueueMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// EnsureGroup 创建消费组，已存在时跳过
+func (m *GradeQueueMapper) EnsureGroup(ctx context.Context) error {
+	err := m.rds.PipelinedCtx(ctx, func(p gozero_redis.Pipeliner) error {
+		p.XGroupCreateMkStream(ctx, gradeQueueStream, gradeQueueGroup, "0")
+		return nil
+	})
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+// Publish 投递批改任务，超过 consts.GradeQueueMaxLen 的历史消息被裁剪
+func (m *GradeQueueMapper) Publish(ctx context.Context, submissionId string) error {
+	return m.rds.PipelinedCtx(ctx, func(p gozero_redis.Pipeliner) error {
+		p.XAdd(ctx, &red.XAddArgs{
+			Stream: gradeQueueStream,
+			MaxLen: consts.GradeQueueMaxLen,
+			Approx: true,
+			Values: map[string]any{gradeQueueField: submissionId},
+		})
+		return nil
+	})
+}
+
+// Read 以 consumer 的身份领取最多 count 条新任务，没有任务时最多阻塞 block，
+// block 需小于 redis 客户端的读超时
+func (m *GradeQueueMapper) Read(ctx context.Context, consumer string, count int64, block time.Duration) ([]*GradeTask, error) {
+	var cmd *red.XStreamSliceCmd
+	err := m.rds.PipelinedCtx(ctx, func(p gozero_redis.Pipeliner) error {
+		cmd = p.XReadGroup(ctx, &red.XReadGroupArgs{
+			Group:    gradeQueueGroup,
+			Consumer: consumer,
+			Streams:  []string{gradeQueueStream, ">"},
+			Count:    count,
+			Block:    block,
+		})
+		return nil
+	})
+	if errors.Is(err, red.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*GradeTask
+	for _, stream := range cmd.Val() {
+		for _, msg := range stream.Messages {
+			id, _ := msg.Values[gradeQueueField].(string)
+			tasks = append(tasks, &GradeTask{Id: msg.ID, SubmissionId: id})
+		}
+	}
+	return tasks, nil
+}
+
+// Ack 确认任务，确认后不会再投递
+func (m *GradeQueueMapper) Ack(ctx context.Context, ids ...string) error {
+	return m.rds.PipelinedCtx(ctx, func(p gozero_redis.Pipeliner) error {
+		p.XAck(ctx, gradeQueueStream, gradeQueueGroup, ids...)
+		return nil
+	})
+}
@@ -0,0 +1,37 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	dailySummaryPrefix = "notify_daily_summary"
+	dailySummaryExpire = 2 * 24 * 3600
+	weeklyReportPrefix = "notify_weekly_report"
+)
+
+// NotifyMapper 定时通知的去重标记，多实例部署时同一周期只有一个实例发送
+type NotifyMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewNotifyMapper(config *config.Config) *NotifyMapper {
+	return &NotifyMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// TryMarkDailySummary 标记某天的提交汇总已发送，已被标记时返回 false
+func (m *NotifyMapper) TryMarkDailySummary(ctx context.Context, date string) (bool, error) {
+	return m.rds.SetnxExCtx(ctx, fmt.Sprintf("%s:%s", dailySummaryPrefix, date), "1", dailySummaryExpire)
+}
+
+// TryMarkWeeklyReport 标记某周的学情周报已生成，已被标记时返回 false
+func (m *NotifyMapper) TryMarkWeeklyReport(ctx context.Context, week string) (bool, error) {
+	return m.rds.SetnxExCtx(ctx, fmt.Sprintf("%s:%s", weeklyReportPrefix, week), "1", dailySummaryExpire)
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	ocrCachePrefix = "ocr_result"
+	ocrCacheExpire = 600 // 10分钟，只用于避免短时间内同一批图片重复识别
+)
+
+// OcrResult 一次 OCR 的识别结果
+type OcrResult struct {
+	Title    string   `json:"title"`
+	Content  string   `json:"content"`
+	Neatness *float64 `json:"neatness,omitempty"` // 书写工整度，下游没有返回字符置信度时为空
+	Cached   bool     `json:"-"`                  // 是否命中缓存，命中时没有调用下游识别
+}
+
+// OcrCacheMapper OCR 结果缓存，key 为图片 url 列表（含顺序）、保留类型与预处理选项的哈希
+type OcrCacheMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewOcrCacheMapper(config *config.Config) *OcrCacheMapper {
+	return &OcrCacheMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Get 未命中时返回 nil, nil
+func (m *OcrCacheMapper) Get(ctx context.Context, images []string, left string, preprocess []string) (*OcrResult, error) {
+	cachedData, err := m.rds.GetCtx(ctx, m.buildCacheKey(images, left, preprocess))
+	if err != nil || cachedData == "" {
+		return nil, err
+	}
+
+	var result OcrResult
+	if err := json.Unmarshal([]byte(cachedData), &result); err != nil {
+		return nil, fmt.Errorf("unmarshal cached data failed: %w", err)
+	}
+	result.Cached = true
+	return &result, nil
+}
+
+func (m *OcrCacheMapper) Set(ctx context.Context, images []string, left string, preprocess []string, data *OcrResult) error {
+	resultBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal data failed: %w", err)
+	}
+	return m.rds.SetexCtx(ctx, m.buildCacheKey(images, left, preprocess), string(resultBytes), ocrCacheExpire)
+}
+
+func (m *OcrCacheMapper) buildCacheKey(images []string, left string, preprocess []string) string {
+	h := md5.New()
+	for _, img := range images {
+		h.Write([]byte(img))
+		h.Write([]byte{'\n'})
+	}
+	h.Write([]byte(left))
+	for _, p := range preprocess {
+		h.Write([]byte{'\n'})
+		h.Write([]byte(p))
+	}
+	return fmt.Sprintf("%s:%s", ocrCachePrefix, hex.EncodeToString(h.Sum(nil)))
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"strconv"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	ocrFreePrefix = "ocr_free:"
+	ocrFreeExpire = 2 * 24 * 3600 // 按天计数的过期时间，留出跨天的余量
+)
+
+// OcrQuotaMapper 按用户统计当天已使用的免费 OCR 次数，INCR 原子占用，并发请求不会超出每日免费额度
+type OcrQuotaMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewOcrQuotaMapper(config *config.Config) *OcrQuotaMapper {
+	return &OcrQuotaMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Incr 占用一次当天的免费次数，返回占用后的计数
+func (m *OcrQuotaMapper) Incr(ctx context.Context, userId, date string) (int64, error) {
+	key := ocrFreePrefix + userId + ":" + date
+	n, err := m.rds.IncrCtx(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if n == 1 {
+		if err = m.rds.ExpireCtx(ctx, key, ocrFreeExpire); err != nil {
+			return 0, err
+		}
+	}
+	return n, nil
+}
+
+// Decr 归还一次 Incr 占用的免费次数，用于超出额度、识别失败或命中缓存时
+func (m *OcrQuotaMapper) Decr(ctx context.Context, userId, date string) error {
+	_, err := m.rds.DecrCtx(ctx, ocrFreePrefix+userId+":"+date)
+	return err
+}
+
+// Get 当天已使用的免费次数
+func (m *OcrQuotaMapper) Get(ctx context.Context, userId, date string) (int64, error) {
+	val, err := m.rds.GetCtx(ctx, ocrFreePrefix+userId+":"+date)
+	if err != nil || val == "" {
+		return 0, err
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
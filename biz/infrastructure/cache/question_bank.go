@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	questionBankCachePrefix  = "question_bank_list"
+	questionBankVersionKey   = "question_bank_list:version"
+	questionBankCacheExpire  = 24 * 3600 // 24小时，题库基本静态，失效主要靠版本号
+	questionBankVersionStart = "0"
+)
+
+// QuestionBankCacheMapper 题库列表缓存，key 由版本号和筛选条件哈希组成，
+// 题库更新时递增版本号即可让所有旧缓存失效
+type QuestionBankCacheMapper struct {
+	rds *gozero_redis.Redis
+}
+
+func NewQuestionBankCacheMapper(config *config.Config) *QuestionBankCacheMapper {
+	return &QuestionBankCacheMapper{
+		rds: redis.GetRedis(config),
+	}
+}
+
+// Get 未命中时返回 nil, nil
+func (m *QuestionBankCacheMapper) Get(ctx context.Context, req *show.SearchQuestionBanksReq) (*show.ListQuestionBanksResp, error) {
+	key, err := m.buildCacheKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	cachedData, err := m.rds.GetCtx(ctx, key)
+	if err != nil || cachedData == "" {
+		return nil, err
+	}
+
+	var result show.ListQuestionBanksResp
+	if err := json.Unmarshal([]byte(cachedData), &result); err != nil {
+		return nil, fmt.Errorf("unmarshal cached data failed: %w", err)
+	}
+	return &result, nil
+}
+
+func (m *QuestionBankCacheMapper) Set(ctx context.Context, req *show.SearchQuestionBanksReq, data *show.ListQuestionBanksResp) error {
+	key, err := m.buildCacheKey(ctx, req)
+	if err != nil {
+		return err
+	}
+	resultBytes, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal data failed: %w", err)
+	}
+	return m.rds.SetexCtx(ctx, key, string(resultBytes), questionBankCacheExpire)
+}
+
+// Invalidate 递增版本号，旧版本的缓存不再被读取并随过期时间清理
+func (m *QuestionBankCacheMapper) Invalidate(ctx context.Context) error {
+	_, err := m.rds.IncrCtx(ctx, questionBankVersionKey)
+	return err
+}
+
+func (m *QuestionBankCacheMapper) buildCacheKey(ctx context.Context, req *show.SearchQuestionBanksReq) (string, error) {
+	version, err := m.rds.GetCtx(ctx, questionBankVersionKey)
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		version = questionBankVersionStart
+	}
+	filter, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := md5.Sum(filter)
+	return fmt.Sprintf("%s:%s:%s", questionBankCachePrefix, version, hex.EncodeToString(sum[:])), nil
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+
+	"github.com/zeromicro/go-zero/core/limit"
+)
+
+const shortAnswerLimitPrefix = "short_answer_limit:"
+
+// ShortAnswerLimiter 简答题判分调用下游付费接口，按用户每天最多判分 consts.ShortAnswerDailyLimit 次
+type ShortAnswerLimiter struct {
+	limiter *limit.PeriodLimit
+}
+
+func NewShortAnswerLimiter(config *config.Config) *ShortAnswerLimiter {
+	return &ShortAnswerLimiter{
+		limiter: limit.NewPeriodLimit(consts.ShortAnswerRatePeriod, consts.ShortAnswerDailyLimit, redis.GetRedis(config), shortAnswerLimitPrefix, limit.Align()),
+	}
+}
+
+// Allow 返回本次判分是否在限额内
+func (l *ShortAnswerLimiter) Allow(ctx context.Context, userId string) (bool, error) {
+	code, err := l.limiter.TakeCtx(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return code != limit.OverQuota, nil
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+
+	"github.com/zeromicro/go-zero/core/limit"
+)
+
+const smsLimitPrefix = "sms_limit:"
+
+// SmsLimiter 通知短信按手机号限流，每个周期内最多发送 consts.SmsRateLimit 条
+type SmsLimiter struct {
+	limiter *limit.PeriodLimit
+}
+
+func NewSmsLimiter(config *config.Config) *SmsLimiter {
+	return &SmsLimiter{
+		limiter: limit.NewPeriodLimit(consts.SmsRatePeriod, consts.SmsRateLimit, redis.GetRedis(config), smsLimitPrefix),
+	}
+}
+
+// Allow 返回本次发送是否在限额内
+func (l *SmsLimiter) Allow(ctx context.Context, phone string) (bool, error) {
+	code, err := l.limiter.TakeCtx(ctx, phone)
+	if err != nil {
+		return false, err
+	}
+	return code != limit.OverQuota, nil
+}
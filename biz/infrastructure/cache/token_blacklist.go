@@ -0,0 +1,71 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/redis"
+	"fmt"
+	"strconv"
+
+	gozero_redis "github.com/zeromicro/go-zero/core/stores/redis"
+)
+
+const (
+	tokenBlacklistPrefix = "token_blacklist"
+	tokenRevokeAllPrefix = "token_revoke_before"
+	secondTimestampLimit = 1e11 // 小于该值的时间点按秒存储
+)
+
+// TokenBlacklistMapper 已登出 token 的 jti 黑名单，以及"踢出所有设备"的时间点
+type TokenBlacklistMapper struct {
+	rds    *gozero_redis.Redis
+	expire int64
+}
+
+func NewTokenBlacklistMapper(config *config.Config) *TokenBlacklistMapper {
+	return &TokenBlacklistMapper{
+		rds:    redis.GetRedis(config),
+		expire: config.Auth.AccessExpire,
+	}
+}
+
+// Revoke 将 jti 加入黑名单，ttl 为 token 剩余有效期（秒）
+func (m *TokenBlacklistMapper) Revoke(ctx context.Context, jti string, ttl int64) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return m.rds.SetexCtx(ctx, m.buildBlacklistKey(jti), "1", int(ttl))
+}
+
+// RevokeAllBefore 吊销用户在 before（毫秒时间戳）及之前签发的所有 token，保留一个完整 token 有效期
+func (m *TokenBlacklistMapper) RevokeAllBefore(ctx context.Context, userId string, before int64) error {
+	return m.rds.SetexCtx(ctx, m.buildRevokeAllKey(userId), strconv.FormatInt(before, 10), int(m.expire))
+}
+
+// Lookup 一次 MGET 同时查询 jti 是否在黑名单和用户"踢出所有设备"的毫秒时间点，未设置时间点时返回 0
+func (m *TokenBlacklistMapper) Lookup(ctx context.Context, jti, userId string) (revoked bool, before int64, err error) {
+	vals, err := m.rds.MgetCtx(ctx, m.buildBlacklistKey(jti), m.buildRevokeAllKey(userId))
+	if err != nil || len(vals) < 2 {
+		return false, 0, err
+	}
+	revoked = vals[0] != ""
+	if vals[1] == "" {
+		return revoked, 0, nil
+	}
+	if before, err = strconv.ParseInt(vals[1], 10, 64); err != nil {
+		return revoked, 0, err
+	}
+	if before < secondTimestampLimit {
+		// 兼容旧版本按秒写入的时间点
+		before *= 1000
+	}
+	return revoked, before, nil
+}
+
+func (m *TokenBlacklistMapper) buildBlacklistKey(jti string) string {
+	return fmt.Sprintf("%s:%s", tokenBlacklistPrefix, jti)
+}
+
+func (m *TokenBlacklistMapper) buildRevokeAllKey(userId string) string {
+	return fmt.Sprintf("%s:%s", tokenRevokeAllPrefix, userId)
+}
@@ -0,0 +1,32 @@
+package cache
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/redis"
+
+	"github.com/zeromicro/go-zero/core/limit"
+)
+
+const uploadLimitPrefix = "upload_limit:"
+
+// UploadLimiter 服务端直传按用户限流，每个周期内最多上传 consts.UploadRateLimit 次
+type UploadLimiter struct {
+	limiter *limit.PeriodLimit
+}
+
+func NewUploadLimiter(config *config.Config) *UploadLimiter {
+	return &UploadLimiter{
+		limiter: limit.NewPeriodLimit(consts.UploadRatePeriod, consts.UploadRateLimit, redis.GetRedis(config), uploadLimitPrefix),
+	}
+}
+
+// Allow 返回本次上传是否在限额内
+func (l *UploadLimiter) Allow(ctx context.Context, userId string) (bool, error) {
+	code, err := l.limiter.TakeCtx(ctx, userId)
+	if err != nil {
+		return false, err
+	}
+	return code != limit.OverQuota, nil
+}
@@ -1,9 +1,16 @@
 package config
 
 import (
+	"bytes"
+	"context"
 	_ "embed"
+	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/util/log"
 	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/service"
@@ -14,12 +21,16 @@ import (
 // //go:embed config.local.yaml
 var embeddedConfig []byte
 
-var config *Config
+var config atomic.Pointer[Config]
 
 type Auth struct {
 	SecretKey    string
 	PublicKey    string
 	AccessExpire int64
+	// RefreshWindow token 剩余有效期小于该值（秒）时才允许刷新
+	RefreshWindow int64 `json:",default=86400"`
+	// RevokeCheckStrict 查询 token 吊销状态时 redis 异常的处理方式，true 拒绝请求，默认放行以保证可用性
+	RevokeCheckStrict bool `json:",optional"`
 }
 
 type Config struct {
@@ -31,17 +42,238 @@ type Config struct {
 		URL string
 		DB  string
 	}
-	MySQL struct {
-		DSN string
+	MySQL      MySQLConf
+	Cache      cache.CacheConf
+	Redis      *redis.RedisConf
+	Api        API
+	Log        LogConfig
+	Invitation Invitation `json:",optional"`
+	OCR        OCRQuota   `json:",optional"`
+	Upload     Upload     `json:",optional"`
+	Notify     Notify     `json:",optional"`
+	LocalPdf   LocalPdf   `json:",optional"`
+	Smtp       Smtp       `json:",optional"`
+	Profile    Profile    `json:",optional"`
+	RateLimit  RateLimit  `json:",optional"`
+	ApiSign    ApiSign    `json:",optional"`
+	Grpc       Grpc       `json:",optional"`
+	Stream     Stream     `json:",optional"`
+	Archive    Archive    `json:",optional"`
+	ImageTTL   ImageTTL   `json:",optional"`
+	Orphan     Orphan     `json:",optional"`
+	Moderation Moderation `json:",optional"`
+	AiDetect   AiDetect   `json:",optional"`
+	// TrustedProxies 可信代理的 IP 或 CIDR，只有来自这些地址的请求才采信 X-Forwarded-For / X-Real-IP，
+	// 为空时客户端 IP 一律取连接地址，修改后需重启
+	TrustedProxies []string `json:",optional"`
+}
+
+// Archive 批改结果冷归档，开启后创建超过 Months 个月的 Response 压缩转存到 cos，读取时透明回源
+type Archive struct {
+	Enabled bool `json:",optional"`
+	Months  int  `json:",optional"` // 为 0 时使用 consts.ArchiveAfterMonths
+}
+
+func (a Archive) GetMonths() int {
+	if a.Months <= 0 {
+		return consts.ArchiveAfterMonths
+	}
+	return a.Months
+}
+
+// ImageTTL 作业提交原图的保留策略，开启后批改完成超过 Days 天的提交删除 cos 上的原图
+type ImageTTL struct {
+	Enabled      bool `json:",optional"`
+	Days         int  `json:",optional"` // 为 0 时使用 consts.ImageRetentionDays
+	KeepModified bool `json:",optional"` // 保留已人工修改的提交的原图，便于复核
+}
+
+func (t ImageTTL) GetDays() int {
+	if t.Days <= 0 {
+		return consts.ImageRetentionDays
+	}
+	return t.Days
+}
+
+// Moderation 批改前的作文内容安全审核，先匹配本地词库，Remote 开启时再调用中台接口
+type Moderation struct {
+	Enabled bool     `json:",optional"`
+	Words   []string `json:",optional"` // 本地敏感词，忽略大小写与空白
+	Remote  bool     `json:",optional"`
+}
+
+// AiDetect 作业批改时检测作文是否疑似 AI 生成，检测失败不影响批改
+type AiDetect struct {
+	Enabled bool `json:",optional"`
+}
+
+// Orphan 孤儿文件对账，开启后上传超过 Days 天仍未被业务数据引用的 cos 对象会被删除
+type Orphan struct {
+	Enabled bool `json:",optional"`
+	Days    int  `json:",optional"` // 为 0 时使用 consts.OrphanUploadDays
+}
+
+func (o Orphan) GetDays() int {
+	if o.Days <= 0 {
+		return consts.OrphanUploadDays
+	}
+	return o.Days
+}
+
+// Stream SSE 流式消息通道配置，为空时使用 consts 中的默认值
+type Stream struct {
+	Policy       string `json:",optional"` // 通道写满时的策略：block、drop_oldest 或 drop_newest，默认 block
+	Buffer       int    `json:",optional"` // 通道容量
+	BlockTimeout int64  `json:",optional"` // block 策略的最长等待时间（毫秒）
+}
+
+func (s Stream) GetPolicy() string {
+	switch s.Policy {
+	case consts.StreamPolicyDropOldest, consts.StreamPolicyDropNewest:
+		return s.Policy
+	default:
+		return consts.StreamPolicyBlock
+	}
+}
+
+func (s Stream) GetBuffer() int {
+	if s.Buffer <= 0 {
+		return consts.StreamBuffer
+	}
+	return s.Buffer
+}
+
+func (s Stream) GetBlockTimeout() time.Duration {
+	if s.BlockTimeout <= 0 {
+		return consts.StreamBlockTimeout
+	}
+	return time.Duration(s.BlockTimeout) * time.Millisecond
+}
+
+// Grpc 内部系统使用的 gRPC 服务，ListenOn 为空时不启动
+type Grpc struct {
+	ListenOn string `json:",optional"`
+}
+
+// ApiSign 外部 API 的请求签名配置，签名算法见 middleware.ApiSignature
+type ApiSign struct {
+	Required bool  `json:",optional"` // 为 true 时所有 /api/v1 请求都必须签名，否则只校验携带了签名的请求，便于调用方逐步接入
+	Window   int64 `json:",optional"` // 请求时间戳允许的偏差（秒），为 0 时使用 consts.ApiSignWindow
+}
+
+// RateLimit 令牌桶限流配置，Rate 为 0 的桶不限流
+type RateLimit struct {
+	IP     Bucket            `json:",optional"` // 按 IP 的全局限流
+	User   Bucket            `json:",optional"` // 按登录用户的全局限流
+	Routes map[string]Bucket `json:",optional"` // 昂贵接口的单独配额，键为 consts.RateLimitEvaluate 等分组，未配置的分组使用默认配额
+}
+
+// Bucket 令牌桶，每秒补充 Rate 个令牌，最多积攒 Burst 个
+type Bucket struct {
+	Rate  int `json:",optional"`
+	Burst int `json:",optional"` // 小于 Rate 时取 Rate
+}
+
+// Smtp 邮件通知的发信配置，Host 为空时不发送邮件
+type Smtp struct {
+	Host     string `json:",optional"`
+	Port     int    `json:",default=465"`
+	Username string `json:",optional"`
+	Password string `json:",optional"`
+	From     string `json:",optional"` // 发件人地址，为空时使用 Username
+}
+
+// LocalPdf 下游不可用时本地渲染 PDF 的配置
+type LocalPdf struct {
+	Chromium string `json:",optional"` // chromium 可执行文件路径，为空时使用 consts.LocalPdfChromium
+}
+
+// Notify 通知推送配置，微信订阅消息模板为空时不推送对应消息
+type Notify struct {
+	ReportTemplateId       string `json:",optional"` // 班级报告生成完成
+	GradeNotify            bool   `json:",optional"` // 作业批改完成后是否推送通知
+	GradeTemplateId        string `json:",optional"` // 学生作业批改完成
+	ClassGradeTemplateId   string `json:",optional"` // 教师全班批改完成汇总
+	GradeFailTemplateId    string `json:",optional"` // 教师作业批改失败告警
+	DailySummaryHour       *int   `json:",optional"` // 每日作业提交汇总的发送时刻（0-23），未配置时使用 consts.DailySummaryHour
+	WeeklyReportTemplateId string `json:",optional"` // 班级学情周报生成
+	WeeklyReportHour       *int   `json:",optional"` // 周日生成学情周报的时刻（0-23），未配置时使用 consts.WeeklyReportHour
+	// 中台短信模板，为空时不发送对应短信
+	GradeFailSmsTemplateId string `json:",optional"` // 作业批改失败需人工处理
+	LoginSmsTemplateId     string `json:",optional"` // 账号在新设备登录
+}
+
+// GetDailySummaryHour 配置为 0 表示零点发送，未配置或超出 0-23 时使用默认值
+func (n Notify) GetDailySummaryHour() int {
+	if n.DailySummaryHour == nil || *n.DailySummaryHour < 0 || *n.DailySummaryHour > 23 {
+		return consts.DailySummaryHour
 	}
-	Cache cache.CacheConf
-	Redis *redis.RedisConf
-	Api   API
-	Log   LogConfig
+	return *n.DailySummaryHour
+}
+
+// GetWeeklyReportHour 规则同 GetDailySummaryHour
+func (n Notify) GetWeeklyReportHour() int {
+	if n.WeeklyReportHour == nil || *n.WeeklyReportHour < 0 || *n.WeeklyReportHour > 23 {
+		return consts.WeeklyReportHour
+	}
+	return *n.WeeklyReportHour
+}
+
+// Upload 上传文件校验规则，对加签 url 与服务端直传都生效，为空时使用 consts 中的默认值
+type Upload struct {
+	Suffixes []string `json:",optional"` // 允许的扩展名，不含点，如 jpg
+	MaxSize  int64    `json:",optional"` // 单个文件大小上限（字节）
+}
+
+func (u Upload) GetSuffixes() []string {
+	if len(u.Suffixes) == 0 {
+		return strings.Split(consts.UploadSuffixes, ",")
+	}
+	return u.Suffixes
+}
+
+func (u Upload) GetMaxSize() int64 {
+	if u.MaxSize <= 0 {
+		return consts.UploadMaxSize
+	}
+	return u.MaxSize
+}
+
+// OCRQuota OCR 配额规则，为 0 时使用 consts.OcrDailyFree
+type OCRQuota struct {
+	DailyFree int64 `json:",optional"` // 每天免费识别次数
+}
+
+// MySQLConf 题库所在 MySQL 的连接与连接池配置
+type MySQLConf struct {
+	DSN             string
+	MaxOpenConns    int   `json:",default=20"`
+	MaxIdleConns    int   `json:",default=10"`
+	ConnMaxLifetime int64 `json:",default=3600"` // 连接最长存活时间（秒）
+	SlowThreshold   int64 `json:",default=500"`  // 超过该耗时（毫秒）的查询记为慢查询，为 0 时不记录
+}
+
+// Invitation 邀请奖励规则，奖励为 0 时使用 consts.InvitationReward
+type Invitation struct {
+	InviterReward int64            `json:",optional"` // 邀请人每邀请一人获得的次数
+	InviteeReward int64            `json:",optional"` // 被邀请人填写邀请码获得的次数
+	Tiers         []InvitationTier `json:",optional"` // 阶梯奖励
+	// 风控阈值，为 0 时使用 consts 中的默认值
+	DeviceLimit    int64 `json:",optional"` // 同一设备最多填写邀请码次数
+	IPLimit        int64 `json:",optional"` // 同一 IP 一天内填写次数超过该值记入风控
+	RegisterWindow int64 `json:",optional"` // 注册多少小时内可以填写邀请码
+}
+
+// InvitationTier 累计邀请人数达到 Count 时额外奖励 Reward 次
+type InvitationTier struct {
+	Count  int64
+	Reward int64
 }
 
 type LogConfig struct {
-	NoLogPaths []string
+	NoLogPaths  []string
+	SampleRates map[string]float64 `json:",optional"` // 按路由配置访问日志采样率（0-1），未配置的路由全部记录，出错的请求总会记录
+	MaskFields  []string           `json:",optional"` // 访问日志中额外需要脱敏的字段名，手机号、token、密码等已默认脱敏
 }
 
 type API struct {
@@ -59,10 +291,13 @@ func NewConfig() (*Config, error) {
 	if len(embeddedConfig) == 0 {
 		path := os.Getenv("CONFIG_PATH")
 		log.Info("NewConfig load config from path: %s", path)
-		err := conf.Load(path, c)
+		data, err := loadLayers(path)
 		if err != nil {
 			return nil, err
 		}
+		if err = conf.LoadFromJsonBytes(data, c); err != nil {
+			return nil, err
+		}
 	} else {
 		err := conf.LoadFromYamlBytes(embeddedConfig, c)
 		if err != nil {
@@ -70,14 +305,80 @@ func NewConfig() (*Config, error) {
 		}
 	}
 
+	if err := applyEnv(c); err != nil {
+		return nil, err
+	}
+
 	err := c.SetUp()
 	if err != nil {
 		return nil, err
 	}
-	config = c
+	log.Info("NewConfig loaded: %s", dumpConfig(c))
+	config.Store(c)
 	return c, nil
 }
 
+// GetConfig 返回当前生效的配置，热加载后返回新配置，调用方不要长期持有
 func GetConfig() *Config {
-	return config
+	return config.Load()
+}
+
+// StartWatcher 定时检查 CONFIG_PATH 指向的配置文件及其环境覆盖层，内容变化时热加载；使用内嵌配置时不启动
+func StartWatcher(ctx context.Context) {
+	path := os.Getenv("CONFIG_PATH")
+	if len(embeddedConfig) > 0 || path == "" {
+		return
+	}
+	last, err := loadLayers(path)
+	if err != nil {
+		log.Error("读取配置文件失败, path: %s, error: %v", path, err)
+		return
+	}
+	log.Info("启动配置热加载, path: %s", path)
+	go func() {
+		ticker := time.NewTicker(consts.ConfigReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				// configmap 挂载的文件通过替换软链更新，每次都重新读取内容比较
+				data, err := loadLayers(path)
+				if err != nil || bytes.Equal(data, last) {
+					continue
+				}
+				if err := reload(data); err != nil {
+					log.Error("热加载配置失败，继续使用原配置, error: %v", err)
+					continue
+				}
+				last = data
+			}
+		}
+	}()
+}
+
+// reload 使用合并后的配置替换当前配置，Mongo、Redis 等连接类配置在启动时已建立连接，沿用原值，修改后需重启生效
+func reload(data []byte) error {
+	c := new(Config)
+	if err := conf.LoadFromJsonBytes(data, c); err != nil {
+		return err
+	}
+	if err := applyEnv(c); err != nil {
+		return err
+	}
+	old := config.Load()
+	if !reflect.DeepEqual(c.Mongo, old.Mongo) || !reflect.DeepEqual(c.MySQL, old.MySQL) ||
+		!reflect.DeepEqual(c.Redis, old.Redis) || !reflect.DeepEqual(c.Cache, old.Cache) || c.ListenOn != old.ListenOn {
+		log.Info("连接类配置变更不支持热加载，需重启生效")
+	}
+	c.ServiceConf = old.ServiceConf
+	c.ListenOn = old.ListenOn
+	c.Mongo = old.Mongo
+	c.MySQL = old.MySQL
+	c.Cache = old.Cache
+	c.Redis = old.Redis
+	config.Store(c)
+	log.Info("配置热加载完成")
+	return nil
 }
@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envPrefix 覆盖配置的环境变量前缀，变量名为前缀加上大写的字段路径，如 ESSAY_AUTH_SECRETKEY、ESSAY_MYSQL_DSN、ESSAY_MONGO_URL
+const envPrefix = "ESSAY_"
+
+// sensitiveNames 字段名包含这些词时在日志中屏蔽值
+var sensitiveNames = []string{"secret", "pass", "dsn", "token", "privatekey"}
+
+// applyEnv 用环境变量覆盖配置项，支持字符串、数值、布尔、时长和逗号分隔的字符串切片，未配置的 Redis 等指针字段不覆盖
+func applyEnv(c *Config) error {
+	return walkConfig(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) error {
+		name := envPrefix + strings.ToUpper(strings.ReplaceAll(path, ".", "_"))
+		raw, ok := os.LookupEnv(name)
+		if !ok {
+			return nil
+		}
+		if err := setValue(v, raw); err != nil {
+			return fmt.Errorf("环境变量 %s 覆盖配置失败: %w", name, err)
+		}
+		return nil
+	})
+}
+
+// dumpConfig 输出全部配置项供启动日志使用，敏感值已屏蔽
+func dumpConfig(c *Config) string {
+	var items []string
+	_ = walkConfig(reflect.ValueOf(c).Elem(), "", func(path string, v reflect.Value) error {
+		items = append(items, path+"="+maskValue(path, fmt.Sprint(v.Interface())))
+		return nil
+	})
+	return strings.Join(items, ", ")
+}
+
+// walkConfig 遍历配置中的叶子字段，嵌入的结构体不计入路径
+func walkConfig(v reflect.Value, path string, fn func(path string, v reflect.Value) error) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		fv := v.Field(i)
+		p := path
+		if !sf.Anonymous {
+			p = strings.TrimPrefix(path+"."+sf.Name, ".")
+		}
+		if fv.Kind() == reflect.Ptr {
+			if fv.IsNil() || fv.Elem().Kind() != reflect.Struct {
+				continue
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Struct {
+			if err := walkConfig(fv, p, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		// 结构体切片（如 Cache 节点）按下标展开，如 ESSAY_CACHE_0_PASS
+		if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() == reflect.Struct {
+			for j := 0; j < fv.Len(); j++ {
+				if err := walkConfig(fv.Index(j), p+"."+strconv.Itoa(j), fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := fn(p, fv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("不支持的类型 %s", v.Type())
+		}
+		var items []string
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("不支持的类型 %s", v.Type())
+	}
+	return nil
+}
+
+// maskValue 屏蔽敏感字段的值和连接串中的密码
+func maskValue(path, value string) string {
+	if value == "" {
+		return value
+	}
+	name := strings.ToLower(path[strings.LastIndex(path, ".")+1:])
+	for _, s := range sensitiveNames {
+		if strings.Contains(name, s) {
+			return "***"
+		}
+	}
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
@@ -0,0 +1,128 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Profile 各环境之间的差异项，在环境覆盖层中配置
+type Profile struct {
+	XhEnv            string `json:",optional"` // 请求中台和无状态服务时注入的 X-Xh-Env 头，为空时 test 环境注入 test，其余不注入
+	MiniProgramState string `json:",optional"` // 小程序消息与链接跳转的版本，为空时 test 环境为 trial，其余为 formal
+}
+
+// XhEnv 请求中台和无状态服务时注入的 X-Xh-Env 头
+func (c *Config) XhEnv() string {
+	if c.Profile.XhEnv == "" && c.State == "test" {
+		return "test"
+	}
+	return c.Profile.XhEnv
+}
+
+// MiniProgramState 小程序消息与链接跳转的版本
+func (c *Config) MiniProgramState() string {
+	if c.Profile.MiniProgramState != "" {
+		return c.Profile.MiniProgramState
+	}
+	if c.State == "test" {
+		return "trial"
+	}
+	return "formal"
+}
+
+// loadLayers 读取基础配置并合并环境覆盖层，返回合并后的 json。
+// 覆盖层与基础配置同目录，文件名为 <基础配置名>.<profile>.yaml，如 config.yaml 对应 config.test.yaml；
+// profile 取环境变量 CONFIG_PROFILE，未设置时取基础配置中的 State，覆盖层不存在时只使用基础配置
+func loadLayers(path string) ([]byte, error) {
+	base, err := readYaml(path)
+	if err != nil {
+		return nil, err
+	}
+	profile := os.Getenv("CONFIG_PROFILE")
+	if profile == "" {
+		if state, ok := lookupKey(base, "State"); ok {
+			profile = fmt.Sprint(state)
+		}
+	}
+	if profile != "" {
+		ext := filepath.Ext(path)
+		overlayPath := strings.TrimSuffix(path, ext) + "." + profile + ext
+		if _, err := os.Stat(overlayPath); err == nil {
+			overlay, err := readYaml(overlayPath)
+			if err != nil {
+				return nil, err
+			}
+			mergeLayer(base, overlay)
+		}
+	}
+	return json.Marshal(base)
+}
+
+func readYaml(path string) (map[string]any, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var val any
+	if err := yaml.Unmarshal(data, &val); err != nil {
+		return nil, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+	}
+	m, _ := toStringKeys(val).(map[string]any)
+	if m == nil {
+		m = make(map[string]any)
+	}
+	return m, nil
+}
+
+// toStringKeys yaml 解析出的 map 键为 any，转为 string 后才能序列化为 json
+func toStringKeys(v any) any {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			m[fmt.Sprint(k)] = toStringKeys(val)
+		}
+		return m
+	case []any:
+		for i := range v {
+			v[i] = toStringKeys(v[i])
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// mergeLayer 用覆盖层的值深度覆盖基础配置，与配置加载一致键名不区分大小写，数组整体替换
+func mergeLayer(base, overlay map[string]any) {
+	for k, v := range overlay {
+		key := k
+		for bk := range base {
+			if strings.EqualFold(bk, k) {
+				key = bk
+				break
+			}
+		}
+		bm, ok1 := base[key].(map[string]any)
+		om, ok2 := v.(map[string]any)
+		if ok1 && ok2 {
+			mergeLayer(bm, om)
+			continue
+		}
+		base[key] = v
+	}
+}
+
+func lookupKey(m map[string]any, key string) (any, bool) {
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return nil, false
+}
@@ -15,6 +15,7 @@ const (
 	Phone        = "phone"
 	Timestamp    = "timestamp"
 	LogId        = "log_id"
+	Difficulty   = "difficulty"
 	NotEqual     = "$ne"
 	RoleStudent  = "student"
 	RoleTeacher  = "teacher"
@@ -23,6 +24,71 @@ const (
 	Role396th    = "exam_396"
 )
 
+// 用户状态
+const (
+	UserStatusNormal = 0 // 正常
+	UserStatusBanned = 1 // 已封禁
+)
+
+// 登录会话状态
+const (
+	SessionStatusActive  = 0 // 在线
+	SessionStatusRevoked = 1 // 已下线
+)
+
+// API Key 状态
+const (
+	ApiKeyStatusEnabled  = 0 // 启用
+	ApiKeyStatusDisabled = 1 // 已禁用
+)
+
+// 教师认证状态
+const (
+	CertStatusNone     = -1 // 未申请，仅用于接口返回
+	CertStatusPending  = 0  // 待审核
+	CertStatusApproved = 1  // 已通过
+	CertStatusRejected = 2  // 已驳回
+)
+
+// 风控记录状态
+const (
+	RiskStatusPending = 0 // 待审核
+	RiskStatusNormal  = 1 // 确认正常
+	RiskStatusFraud   = 2 // 确认作弊
+)
+
+// 风控类型
+const (
+	RiskTypeInvitationDevice = "invitation_device" // 同设备多次填写邀请码
+	RiskTypeInvitationIP     = "invitation_ip"     // 同 IP 多次填写邀请码
+)
+
+// 练习题型
+const (
+	QuestionTypeChoice = "choice" // 选择题
+	QuestionTypeFill   = "fill"   // 填空题
+	QuestionTypeShort  = "short"  // 简答题
+)
+
+// 练习难度
+const (
+	DifficultyBasic    = "basic"    // 基础
+	DifficultyImprove  = "improve"  // 提高
+	DifficultyAdvanced = "advanced" // 拔高
+)
+
+// OCR 配额扣减来源
+const (
+	OcrQuotaSourceFree = "free" // 每日免费次数
+	OcrQuotaSourcePaid = "paid" // 用户的 OCR 次数 OcrCount
+)
+
+// OCR 前的图片预处理
+const (
+	PreprocessRotate   = "rotate"   // 旋转矫正
+	PreprocessDeshadow = "deshadow" // 去阴影
+)
+
 // http
 const (
 	Post            = "POST"
@@ -30,6 +96,15 @@ const (
 	CharSetUTF8     = "UTF-8"
 )
 
+// 批改结果导出格式
+const (
+	ExportFormatPdf  = "pdf"
+	ExportFormatDocx = "docx"
+
+	LocalPdfChromium = "chromium-browser" // 本地渲染 PDF 默认使用的 chromium 可执行文件
+	LocalPdfTimeout  = 60                 // 本地渲染 PDF 超时时间（秒）
+)
+
 // 默认值
 const (
 	DefaultCount     = 30
@@ -37,7 +112,171 @@ const (
 	Like             = 1
 	DisLike          = -1
 	InvitationReward = 10
-	AttendReward     = 1
+
+	InvitationDeviceLimit    = 3   // 同一设备最多填写邀请码次数
+	InvitationIPLimit        = 10  // 同一 IP 一天内填写邀请码超过该次数记入风控
+	InvitationRegisterWindow = 168 // 注册超过该小时数后不能再填写邀请码
+	AttendReward             = 1
+
+	AttendStreakCycle  = 7 // 每连续签到满 7 天发放一次额外奖励
+	AttendStreakReward = 3
+
+	MakeupAttendMonthlyLimit = 3   // 每月可补签次数
+	AttendStreakLookback     = 366 // 补签后重算连续天数时向前回溯的天数
+
+	WeaknessEssayCount    = 5  // 分析薄弱点默认取最近的作文篇数
+	WeaknessEssayMaxCount = 20 // 分析薄弱点最多取的作文篇数
+
+	ExerciseStatsTrendDays = 30  // 练习统计趋势覆盖的天数
+	ExerciseStatsMaxCount  = 500 // 练习统计最多取最近的练习数
+
+	SignedUrlBatchMaxCount = 20 // 一次最多申请的加签 url 数量
+
+	OcrDailyFree = 10 // 每天免费 OCR 次数
+
+	PdfMaxPages        = 30 // 单次识别的 PDF 总页数上限
+	OcrPageConcurrency = 4  // PDF 逐页识别的最大并发数
+
+	UploadSuffixes   = "jpg,jpeg,png,webp,pdf" // 允许上传的扩展名
+	UploadMaxSize    = 10 << 20                // 单个上传文件大小上限（字节）
+	UploadRatePeriod = 60                      // 服务端直传限流周期（秒）
+	UploadRateLimit  = 30                      // 每个限流周期内每个用户最多上传次数
+	SmsRatePeriod    = 3600                    // 短信通知限流周期（秒）
+	SmsRateLimit     = 5                       // 每个限流周期内同一手机号最多接收的通知短信数
+
+	ShortAnswerRatePeriod = 86400 // 简答题判分限额周期（秒），按自然日对齐
+	ShortAnswerDailyLimit = 100   // 每个用户每天最多判分的简答题作答次数
+
+	BindCodeLength      = 8       // 家长绑定码长度，字母数字混合
+	BindCodeExpire      = 30 * 60 // 家长绑定码有效期（秒）
+	BindCodeMaxFailures = 5       // 同一用户或同一绑定码最多的错误次数，超过后锁定
+	BindCodeLockPeriod  = 3600    // 错误次数的统计与锁定周期（秒）
+
+	PasswordMinLength = 6
+	PasswordMaxLength = 32
+
+	WatermarkMaxLength = 20 // 自定义水印文字最多字数
+
+	LessonPlanDuration       = 40  // 教案默认课时时长（分钟）
+	LessonPlanMaxDuration    = 180 // 教案课时时长上限（分钟）
+	LessonPlanFocusMaxLength = 100 // 教案讲评侧重点最多字数
+
+	RadarDefaultCount = 10  // 能力雷达图默认统计最近的作文篇数
+	RadarMaxCount     = 50  // 能力雷达图最多统计的作文篇数
+	RadarFluencyTotal = 100 // 流畅度满分，批改结果中流畅度没有分项总分
+
+	CommonMistakeDefaultTop = 10 // 全班共性错误默认返回条数
+	CommonMistakeMaxTop     = 50 // 全班共性错误最多返回条数
+
+	DashboardRecentHomeworks = 5 // 教师工作台展示提交率的最近作业数
+
+	SoftDeleteRetentionDays = 30 // 软删除的记录保留天数，超过后由清理任务物理删除
+
+	// 批改结果冷归档，归档后文档中只保留 cos 对象路径
+	ArchiveCosDir             = "archive"      // 归档对象在 cos 中的目录
+	ArchiveAfterMonths        = 12             // 未配置时归档创建超过多少个月的批改结果
+	ArchiveInterval           = 24 * time.Hour // 归档任务的执行间隔
+	ArchiveBatchSize          = 200            // 每批归档的文档数
+	ArchiveRestoreConcurrency = 8              // 列表查询回源归档数据的最大并发数
+
+	// 作业提交原图清理
+	ImageRetentionDays    = 180            // 未配置时批改完成后原图保留的天数
+	ImageCleanupInterval  = 24 * time.Hour // 清理任务的执行间隔
+	ImageCleanupBatchSize = 100            // 每批清理的提交数
+
+	// 孤儿文件对账
+	OrphanUploadDays       = 7              // 未配置时生成超过多少天仍未被引用的对象视为孤儿文件
+	OrphanCleanupInterval  = 24 * time.Hour // 对账任务的执行间隔
+	OrphanCleanupBatchSize = 500            // 每批对账的对象数
+
+	UserCacheExpiry  = time.Minute     // 用户信息缓存时长，更新时会删除缓存，短时长兜底事务中提前删除的情况
+	ClassCacheExpiry = 5 * time.Minute // 班级信息缓存时长，班级信息很少变化
+
+	// 外部 API 调用方的密钥
+	ApiKeyHeader      = "X-Api-Key"
+	ApiKeyPlainPrefix = "esk_"          // 明文密钥的固定前缀，便于在日志和代码仓库中识别泄露
+	ApiKeyRandomBytes = 24              // 明文密钥中随机部分的字节数
+	ApiKeyShownLength = 12              // 列表中展示的明文前缀长度
+	ApiKeyCacheExpiry = 5 * time.Minute // 密钥缓存时长，禁用和轮换时会删除缓存
+	ApiUsageMaxDays   = 92              // 查询密钥用量的最大时间跨度（天）
+	ApiSecretBytes    = 32              // 签名密钥的随机字节数
+
+	// 外部 API 请求签名
+	ApiSignHeaderTimestamp = "X-Timestamp" // 秒级时间戳
+	ApiSignHeaderNonce     = "X-Nonce"     // 调用方生成的随机串，同一密钥在时间窗口内不能重复
+	ApiSignHeaderSignature = "X-Signature" // 十六进制的 HMAC-SHA256 签名
+	ApiSignWindow          = 300           // 请求时间戳允许的默认偏差（秒）
+	ApiNonceMaxLength      = 64
+	ApiIPWhitelistMax      = 50  // 每个密钥最多配置的白名单条目数
+	ApiIPDeniedAuditPeriod = 600 // 同一密钥和来源 IP 被白名单拒绝时，审计日志的最短记录间隔（秒）
+
+	// 外部 API 的计量类型，对应用量记录中的计数字段
+	ApiUsageEvaluate = "evaluate_count"
+	ApiUsageOcr      = "ocr_count"
+
+	// 外部调用方查询用量时的聚合粒度
+	ApiUsageGranularityDay   = "day"
+	ApiUsageGranularityMonth = "month"
+
+	ApiPathPrefix = "/api/" // 外部 API 的路径前缀，错误文案固定为英文
+
+	// 外部 API v2
+	ApiV2PathPrefix      = "/api/v2/"      // 该前缀下的接口使用 v2 的响应结构与错误码，包括鉴权等中间件返回的错误
+	ApiV2EvaluateTimeout = 3 * time.Minute // 同步批改等待下游完成的最长时间
+
+	HealthCheckTimeout = 2 * time.Second // 就绪检查中单个依赖的超时时间
+
+	// SSE 流式消息通道写满时的处理策略，见 config.Stream
+	StreamPolicyBlock      = "block"         // 阻塞等待消费，超过等待时长仍写不进去时丢弃当前消息
+	StreamPolicyDropOldest = "drop_oldest"   // 丢弃通道中最旧的一条，为当前消息腾出位置
+	StreamPolicyDropNewest = "drop_newest"   // 直接丢弃当前消息
+	StreamBuffer           = 100             // 流式消息通道的默认容量
+	StreamBlockTimeout     = 5 * time.Second // block 策略的默认最长等待时间
+
+	GradingDrainTimeout = 20 * time.Second // 停机时等待在途批改完成的时长，超时后把未完成的提交重置为待批改
+	ShutdownTimeout     = 30 * time.Second // 停机的总等待时长，需要大于 GradingDrainTimeout，留出重置状态的时间
+
+	// 作业批改任务总线
+	GradeWorkers       = 10               // 每个实例的批改消费者数，即最大并发批改数
+	GradeQueueBlock    = 2 * time.Second  // 领取任务时的最长阻塞时间，需小于 redis 客户端的读超时
+	GradeQueueMaxLen   = 100000           // 任务流保留的最大消息数
+	GradeSweepInterval = time.Minute      // 检查超时批改和漏投任务的间隔
+	GradeResendAfter   = 5 * time.Minute  // 待批改超过该时长仍未被领取的提交重新投递，兜底投递失败
+	GradeTimeout       = 20 * time.Minute // 没有租约的批改中提交超过该时长视为超时，重置为待批改
+	GradeLease         = 5 * time.Minute  // 领取批改的租约时长，批改期间按 GradeLease/3 续约，过期后其他实例可以接管
+
+	ConfigReloadInterval = 10 * time.Second // 检查配置文件变更的间隔
+
+	// 请求参数的统一校验规则
+	ImageMaxCount        = 20      // 一次请求最多携带的图片数
+	TextMaxLength        = 10000   // 作文等正文最多字数
+	TitleMaxLength       = 100     // 标题最多字数
+	DescriptionMaxLength = 1000    // 描述、备注最多字数
+	JsonBodyMaxSize      = 1 << 20 // 非文件上传请求的请求体大小上限（字节）
+
+	// 昂贵接口的限流分组及默认配额（每秒令牌数 / 桶容量）
+	RateLimitEvaluate      = "evaluate"
+	RateLimitEvaluateRate  = 1
+	RateLimitEvaluateBurst = 5
+	RateLimitOcr           = "ocr"
+	RateLimitOcrRate       = 1
+	RateLimitOcrBurst      = 10
+	RateLimitKeyPrefix     = "rate_limit:"
+	RateLimiterCacheExpire = 10 * time.Minute // 进程内令牌桶对象的缓存时间，过期后重新创建
+	RateLimiterCacheLimit  = 100000           // 进程内最多缓存的令牌桶对象数
+
+	// 批改次数变更 outbox 的事件状态
+	OutboxStatusPending = 0
+	OutboxStatusDone    = 1
+	OutboxStatusFailed  = 2 // 重试次数用完，需要人工处理
+	OutboxMaxRetry      = 10
+	OutboxRetryDelay    = time.Minute // 写入超过该时长仍未执行的事件由后台任务重试
+	OutboxBatchSize     = 100
+
+	// 批改次数变更原因
+	CountReasonEssay    = "essay_evaluate"
+	CountReasonHomework = "homework_grade"
+	CountReasonMba      = "mba_grade"
 )
 
 const (
@@ -47,6 +286,7 @@ const (
 	StatusGrading       = 1 // 批改中
 	StatusCompleted     = 2 // 批改完成
 	StatusModified      = 3 // 已人工修改
+	StatusPendingText   = 4 // 识别完成，等待学生确认文本
 	StatusFailed        = 7 // 批改失败
 
 	// 定时器配置常量
@@ -56,6 +296,41 @@ const (
 	InvitationTemplateId = "KglmTXE65kiACeTM85kwpA2oO9SU0urRGBJTo4gH9O0"
 	InvitationJumpPage   = "pages/tabbar/profile"
 
+	// 班级报告、异步下载任务的生成状态
+	ReportStatusGenerating = 0
+	ReportStatusDone       = 1
+	ReportStatusFailed     = 2
+	ClassReportMaxDays     = 366 // 班级报告最多覆盖的天数
+	FeedbackExportMaxDays  = 366 // 教师修改数据导出最多覆盖的天数
+	ClassReportJumpPage    = "pages/class/report"
+
+	GradeResultJumpPage = "pages/homework/result"  // 学生查看作业批改结果
+	ClassGradeJumpPage  = "pages/homework/summary" // 教师查看作业批改汇总
+
+	// 通知渠道，用户未设置时默认微信
+	NotifyChannelWechat = "wechat"
+	NotifyChannelEmail  = "email"
+	NotifyChannelSms    = "sms"
+	NotifyChannelInApp  = "inapp"
+	NotifyChannelNone   = "none" // 不接收
+	DailySummaryHour    = 20     // 每日作业提交汇总默认在 20 点发送
+	WeeklyReportHour    = 20     // 班级学情周报默认在周日 20 点生成
+
+	SmtpTimeout = 10 * time.Second // 连接 smtp 服务及整封邮件收发的超时时间
+
+	WeeklyReportJumpPage = "pages/class/weekly"
+	WeeklyWeakRatio      = 0.6 // 全班分项平均得分率低于该值时列为共性问题
+
+	// 通知事件类型，用户可按事件选择接收渠道
+	NotifyEventGradeDone      = "grade_done"       // 学生作业批改完成
+	NotifyEventClassGradeDone = "class_grade_done" // 全班作业批改完成
+	NotifyEventGradeFailed    = "grade_failed"     // 作业批改失败
+	NotifyEventDailySummary   = "daily_summary"    // 每日作业提交汇总
+	NotifyEventClassReport    = "class_report"     // 班级报告生成完成
+	NotifyEventNewDevice      = "new_device_login" // 账号在新设备登录
+	NotifyEventInvitation     = "invitation"       // 邀请好友成功
+	NotifyEventWeeklyReport   = "weekly_report"    // 班级学情周报
+
 	RecorrectTypeFirst  = 0 // 首次提交
 	RecorrectTypeImage  = 1 // 上传图片重批
 	RecorrectTypeText   = 2 // 修改原文后重批
@@ -67,6 +342,37 @@ const (
 	TopicTypeReading = 4 // 阅读作业
 )
 
+// 作业学科，与 IDL 的 Subject 取值一致
+const (
+	SubjectChinese = 0 // 语文
+	SubjectEnglish = 1 // 英语，只支持自定义与题库作业
+)
+
+// 作文内容安全审核
+const (
+	ModerationSceneEssay    = "essay"    // 个人批改
+	ModerationSceneHomework = "homework" // 作业批改
+
+	ModerationSourceLocal  = "local"  // 本地词库
+	ModerationSourceRemote = "remote" // 中台内容安全接口
+
+	ModerationExcerptLength = 200 // 审核记录中保存的作文开头字数
+)
+
+// 书写工整度，按 OCR 字符置信度计算，满分 100
+const (
+	NeatnessMinChars      = 50  // 识别出的字符少于该数量时不评价
+	NeatnessLowConfidence = 0.6 // 置信度低于该值的字符视为书写不清
+	NeatnessMaxDeduction  = 3   // 纳入总评时最多扣的卷面分，按 100 - 工整度 折算
+)
+
+// 上传对象的对账状态
+const (
+	UploadStatusPending    = 0 // 待对账
+	UploadStatusReferenced = 1 // 已被业务数据引用
+	UploadStatusDeleted    = 2 // 未被引用，已删除
+)
+
 const (
 	AuthTypeEmail           = "email"
 	AuthTypePhone           = "phone"
@@ -83,3 +389,60 @@ const (
 	MembershipOrderStatusSuccess = 1 // 成功
 	MembershipOrderStatusFailed  = 2 // 失败
 )
+
+// 审计操作类型
+const (
+	AuditActionSignIn              = "sign_in"
+	AuditActionSetPassword         = "set_password"
+	AuditActionChangePassword      = "change_password"
+	AuditActionModifyEvaluate      = "modify_evaluate"
+	AuditActionDeleteEvaluate      = "delete_evaluate"
+	AuditActionModifySubmission    = "modify_submission"
+	AuditActionRollbackEvaluate    = "rollback_evaluate"
+	AuditActionExportFeedback      = "export_evaluate_feedback"
+	AuditActionExportMyData        = "export_my_data"
+	AuditActionDeleteHomework      = "delete_homework"
+	AuditActionUpdateUserStatus    = "update_user_status"
+	AuditActionAdjustUserCount     = "adjust_user_count"
+	AuditActionAdjustOcrCount      = "adjust_ocr_count"
+	AuditActionUpdateRewardSetting = "update_reward_setting"
+	AuditActionRefreshQuestionBank = "refresh_question_bank"
+	AuditActionCreateApiKey        = "create_api_key"
+	AuditActionDisableApiKey       = "disable_api_key"
+	AuditActionRotateApiKey        = "rotate_api_key"
+	AuditActionUpdateApiKeyQuota   = "update_api_key_quota"
+	AuditActionUpdateApiWhitelist  = "update_api_key_whitelist"
+	AuditActionApiIPDenied         = "api_ip_denied" // 外部调用方来源 IP 不在白名单，操作人为密钥 id
+)
+
+// 批改结果修改历史的对象类型
+const (
+	RevisionTargetSubmission = "submission" // 作业提交
+	RevisionTargetLog        = "log"        // 个人作文批改记录
+)
+
+// 批改结果版本的来源
+const (
+	VersionSourceOriginal = "original" // 首次修改前的结果，通常为 AI 原始批改结果
+	VersionSourceModify   = "modify"   // 人工修改
+	VersionSourceRollback = "rollback" // 回滚到历史版本
+	VersionSourceRegrade  = "regrade"  // AI 重新批改前后的结果
+
+	VersionInsertRetry = 3 // 版本号冲突（并发写入同一版本号）时的重试次数
+)
+
+// 外部 API v2 的错误码，即响应体中的 code，调用方应按 code 而不是 message 处理错误
+const (
+	ApiCodeOK                   = "OK"
+	ApiCodeInvalidArgument      = "INVALID_ARGUMENT"
+	ApiCodeUnauthenticated      = "UNAUTHENTICATED"
+	ApiCodeInvalidSignature     = "INVALID_SIGNATURE"
+	ApiCodeReplayedRequest      = "REPLAYED_REQUEST"
+	ApiCodeIPNotAllowed         = "IP_NOT_ALLOWED"
+	ApiCodeRateLimited          = "RATE_LIMITED"
+	ApiCodeDailyQuotaExceeded   = "DAILY_QUOTA_EXCEEDED"
+	ApiCodeMonthlyQuotaExceeded = "MONTHLY_QUOTA_EXCEEDED"
+	ApiCodeEvaluateFailed       = "EVALUATE_FAILED"
+	ApiCodeEvaluateTimeout      = "EVALUATE_TIMEOUT"
+	ApiCodeInternal             = "INTERNAL"
+)
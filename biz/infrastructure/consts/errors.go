@@ -2,32 +2,76 @@ package consts
 
 import (
 	"errors"
+	"fmt"
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
 type Errno struct {
-	err  error
-	code codes.Code
+	err    error
+	code   codes.Code
+	detail string // 具体原因，如出错的字段，不翻译
+	base   *Errno // WithDetail 派生时指向原错误，按原错误查找多语言文案
 }
 
 // GRPCStatus 实现 GRPCStatus 方法
 func (en *Errno) GRPCStatus() *status.Status {
-	return status.New(en.code, en.err.Error())
+	return status.New(en.code, en.Error())
 }
 
 // 实现 Error 方法
 func (en *Errno) Error() string {
-	return en.err.Error()
+	if en.detail == "" {
+		return en.err.Error()
+	}
+	return en.err.Error() + ": " + en.detail
+}
+
+// Code 错误码
+func (en *Errno) Code() codes.Code {
+	return en.code
+}
+
+// Detail 具体原因，没有时为空
+func (en *Errno) Detail() string {
+	return en.detail
 }
 
-// NewErrno 创建自定义错误
+// WithDetail 派生一个带具体原因的同码错误，文案与原错误相同
+func (en *Errno) WithDetail(detail string) *Errno {
+	base := en
+	if en.base != nil {
+		base = en.base
+	}
+	return &Errno{err: en.err, code: en.code, detail: detail, base: base}
+}
+
+// NewErrno 创建自定义错误，包初始化期间定义的错误登记到错误码目录
 func NewErrno(code codes.Code, err error) *Errno {
-	return &Errno{
+	en := &Errno{
 		err:  err,
 		code: code,
 	}
+	if !catalogSealed {
+		catalog = append(catalog, en)
+	}
+	return en
+}
+
+var (
+	catalog       []*Errno
+	catalogSealed bool
+)
+
+// 包级变量在 init 之前完成初始化，之后运行时创建的错误不再登记
+func init() {
+	catalogSealed = true
+}
+
+// Errnos 本包定义的全部错误，按定义顺序排列
+func Errnos() []*Errno {
+	return append([]*Errno(nil), catalog...)
 }
 
 // 定义常量错误
@@ -78,12 +122,60 @@ var (
 var (
 	ErrInvalidParams            = NewErrno(codes.InvalidArgument, errors.New("参数错误"))
 	ErrCall                     = NewErrno(codes.Unknown, errors.New("调用接口失败，请重试"))
+	ErrInternal                 = NewErrno(codes.Internal, errors.New("服务器内部错误，请稍后重试"))
 	ErrOneCall                  = NewErrno(codes.Code(3001), errors.New("同一时刻仅可以批改一篇作文, 请等待上一篇作文批改结束"))
 	ErrAlreadyExists            = NewErrno(codes.AlreadyExists, errors.New("资源已存在"))
 	ErrProductNotFound          = NewErrno(codes.Code(1038), errors.New("套餐不存在或已下架"))
 	ErrPurchaseMembershipFailed = NewErrno(codes.Code(1039), errors.New("发起购买失败，请重试"))
+	ErrUserBanned               = NewErrno(codes.Code(1040), errors.New("账号已被封禁，请联系管理员"))
+	ErrTeacherNotCertified      = NewErrno(codes.Code(1041), errors.New("教师资质尚未认证，请先提交认证材料并等待审核"))
+	ErrCertificationPending     = NewErrno(codes.Code(1042), errors.New("认证申请正在审核中，请勿重复提交"))
+	ErrInvalidBindCode          = NewErrno(codes.Code(1043), errors.New("绑定码无效或已过期"))
+	ErrNotGuardian              = NewErrno(codes.Code(1044), errors.New("未绑定该孩子，无权查看"))
+	ErrBindChild                = NewErrno(codes.Code(1045), errors.New("绑定孩子失败，请重试"))
+	ErrSetPassword              = NewErrno(codes.Code(1046), errors.New("设置密码失败，请检查原密码或重试"))
+	ErrPasswordAlreadySet       = NewErrno(codes.Code(1047), errors.New("已设置过密码，请使用修改密码"))
+	ErrPasswordNotSet           = NewErrno(codes.Code(1048), errors.New("尚未设置密码，请先设置密码"))
+	ErrInvalidPassword          = NewErrno(codes.Code(1049), errors.New("密码长度需为6-32位"))
+	ErrRefreshTooEarly          = NewErrno(codes.Code(1050), errors.New("token 尚未临近过期，无需刷新"))
+	ErrMakeupAttendLimit        = NewErrno(codes.Code(1051), errors.New("本月补签次数已用完"))
+	ErrInvalidMakeupDate        = NewErrno(codes.Code(1052), errors.New("只能补签本月今天之前的日期"))
+	ErrInvitationRisk           = NewErrno(codes.Code(1053), errors.New("当前设备填写邀请码次数过多，已提交人工审核"))
+	ErrInvitationExpired        = NewErrno(codes.Code(1054), errors.New("仅新注册用户可以填写邀请码"))
+	ErrNoBoundStudent           = NewErrno(codes.Code(1055), errors.New("班级内没有已绑定账号的学生"))
+	ErrNoEvaluateLog            = NewErrno(codes.Code(1056), errors.New("暂无批改记录，无法分析薄弱点"))
+	ErrInvalidDifficulty        = NewErrno(codes.Code(1057), errors.New("练习难度不合法"))
+	ErrGradeShortAnswer         = NewErrno(codes.Code(1058), errors.New("简答题判分失败，请稍后重试"))
+	ErrCreateQuestion           = NewErrno(codes.Code(1059), errors.New("创建题目失败"))
+	ErrSubmissionNotPendingText = NewErrno(codes.Code(1060), errors.New("该提交不在等待确认文本状态"))
+	ErrInsufficientOcrCount     = NewErrno(codes.Code(1061), errors.New("今日免费识别次数已用完，识别次数不足"))
+	ErrFileTooLarge             = NewErrno(codes.Code(1062), errors.New("文件大小超过限制"))
+	ErrFileType                 = NewErrno(codes.Code(1063), errors.New("不支持的文件类型"))
+	ErrUploadTooFrequent        = NewErrno(codes.Code(1064), errors.New("上传过于频繁，请稍后再试"))
+	ErrUpload                   = NewErrno(codes.Code(1065), errors.New("上传失败，请重试"))
+	ErrReportTimeRange          = NewErrno(codes.Code(1066), errors.New("报告时间范围不合法"))
+	ErrCreateReport             = NewErrno(codes.Code(1067), errors.New("创建报告任务失败"))
+	ErrInvalidEmail             = NewErrno(codes.Code(1068), errors.New("邮箱格式不正确"))
+	ErrTooManyRequests          = NewErrno(codes.Code(1069), errors.New("请求过于频繁，请稍后再试"))
+	ErrApiKeyInvalid            = NewErrno(codes.Code(1070), errors.New("API Key 无效或已禁用"))
+	ErrApiDailyQuota            = NewErrno(codes.Code(1071), errors.New("今日调用次数已达上限"))
+	ErrApiMonthlyQuota          = NewErrno(codes.Code(1072), errors.New("本月调用次数已达上限，请联系我们提升配额"))
+	ErrApiSignature             = NewErrno(codes.Code(1073), errors.New("请求签名校验失败"))
+	ErrApiReplay                = NewErrno(codes.Code(1074), errors.New("请求已过期或重复提交"))
+	ErrApiIPDenied              = NewErrno(codes.Code(1075), errors.New("请求来源 IP 不在白名单中"))
+	ErrApiEvaluateTimeout       = NewErrno(codes.Code(1076), errors.New("批改超时，请稍后重试"))
+	ErrContentBlocked           = NewErrno(codes.Code(1077), errors.New("作文内容未通过安全审核，请修改后重新提交"))
+	ErrUnsupportedSubject       = NewErrno(codes.Code(1078), errors.New("该学科暂不支持批改"))
+	ErrBindCodeLocked           = NewErrno(codes.Code(1079), errors.New("绑定码错误次数过多，请稍后再试"))
+	ErrPdfTooManyPages          = NewErrno(codes.Code(1080), errors.New("PDF 页数过多，请拆分后上传"))
+	ErrShortAnswerQuota         = NewErrno(codes.Code(1081), errors.New("今日简答题判分次数已达上限，请明天再试"))
 )
 
+// InvalidParams 带出错字段的参数错误，错误码与 ErrInvalidParams 相同
+func InvalidParams(field, reason string) *Errno {
+	return ErrInvalidParams.WithDetail(fmt.Sprintf("%s %s", field, reason))
+}
+
 // 数据库相关错误
 var (
 	ErrNotFound        = NewErrno(codes.NotFound, errors.New("not found"))
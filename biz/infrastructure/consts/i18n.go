@@ -0,0 +1,182 @@
+package consts
+
+import (
+	"strings"
+
+	"google.golang.org/grpc/codes"
+)
+
+// 错误文案的语言，错误定义中的文案为中文
+const (
+	LangZh = "zh"
+	LangEn = "en"
+)
+
+// errMessages 错误的多语言文案，未登记的语言或错误使用定义时的中文文案
+var errMessages = map[string]map[*Errno]string{
+	LangEn: {
+		ErrForbidden:                   "Forbidden",
+		ErrNotAuthentication:           "Not authenticated",
+		ErrSignUp:                      "Sign up failed, please try again",
+		ErrSignIn:                      "Sign in failed, please sign up first or try again",
+		ErrInSufficientCount:           "Insufficient remaining count, please recharge or contact the administrator",
+		ErrRepeatedSignUp:              "This phone number is already registered",
+		ErrOCR:                         "OCR failed, please try again",
+		ErrNotSignUp:                   "Please make sure the phone number is registered",
+		ErrSend:                        "Failed to send verification code, please try again",
+		ErrVerifyCode:                  "Incorrect verification code",
+		ErrDailyAttend:                 "Check-in failed",
+		ErrRepeatDailyAttend:           "You can only check in once a day",
+		ErrRepeatInvitation:            "Invitation code already used",
+		ErrInvitation:                  "Failed to use invitation code, please try again",
+		ErrCreateExercise:              "Failed to create exercise",
+		ErrDoExercise:                  "Failed to submit exercise",
+		ErrBindAuth:                    "Failed to bind authorization",
+		ErrCreateClass:                 "Failed to create class",
+		ErrGetClassList:                "Failed to get class list",
+		ErrCreateClassMember:           "Failed to create class member",
+		ErrGetClassMembers:             "Failed to get class members",
+		ErrCreateHomework:              "Failed to create homework",
+		ErrGetHomeworkList:             "Failed to get homework list",
+		ErrSubmitHomework:              "Failed to submit homework",
+		ErrGradeHomework:               "Failed to grade homework",
+		ErrGetSubmission:               "Failed to get submission",
+		ErrGetHomework:                 "Failed to get homework",
+		ErrHomeworkNotGrade:            "Homework grading is not finished",
+		ErrNotClassMember:              "User is not a member of the class",
+		ErrInvalidScore:                "Score cannot be negative",
+		ErrScoreSumMismatch:            "The sum of custom scores must equal the total score",
+		ErrIncompleteScoreDistribution: "Custom scores must include content, expression and structure (or development)",
+		ErrInvalidScoreDistribution:    "Structure and development cannot both be set",
+		ErrSendWechatMessage:           "Failed to send WeChat message",
+		ErrNoCompletedSubmissions:      "No graded submissions",
+		ErrMemberAlreadyBound:          "The student is already bound to another member position",
+		ErrMemberPositionOccupied:      "The member position is occupied by another student",
+		ErrMemberPositionNotFound:      "The member position does not exist",
+		ErrBindClassMember:             "Failed to bind class member",
+		ErrExtractRubricCategories:     "Failed to extract rubric categories",
+
+		ErrInvalidParams:            "Invalid parameter",
+		ErrCall:                     "Service call failed, please try again",
+		ErrInternal:                 "Internal server error, please try again later",
+		ErrOneCall:                  "Only one essay can be evaluated at a time, please wait for the previous one to finish",
+		ErrAlreadyExists:            "Resource already exists",
+		ErrProductNotFound:          "Product does not exist or is no longer available",
+		ErrPurchaseMembershipFailed: "Failed to start purchase, please try again",
+		ErrUserBanned:               "Account is banned, please contact the administrator",
+		ErrTeacherNotCertified:      "Teacher certification is pending, please submit materials and wait for review",
+		ErrCertificationPending:     "Certification is under review, please do not submit again",
+		ErrInvalidBindCode:          "Bind code is invalid or expired",
+		ErrNotGuardian:              "Not bound to this child",
+		ErrBindChild:                "Failed to bind child, please try again",
+		ErrSetPassword:              "Failed to set password, please check the old password or try again",
+		ErrPasswordAlreadySet:       "Password already set, please use change password",
+		ErrPasswordNotSet:           "Password not set, please set a password first",
+		ErrInvalidPassword:          "Password must be 6-32 characters",
+		ErrRefreshTooEarly:          "Token is not close to expiry, no need to refresh",
+		ErrMakeupAttendLimit:        "No make-up check-ins left this month",
+		ErrInvalidMakeupDate:        "Only dates earlier this month can be made up",
+		ErrInvitationRisk:           "Too many invitation codes used on this device, submitted for manual review",
+		ErrInvitationExpired:        "Only newly registered users can use an invitation code",
+		ErrNoBoundStudent:           "No students with bound accounts in the class",
+		ErrNoEvaluateLog:            "No evaluation records to analyze",
+		ErrInvalidDifficulty:        "Invalid exercise difficulty",
+		ErrGradeShortAnswer:         "Failed to grade short answer, please try again later",
+		ErrCreateQuestion:           "Failed to create question",
+		ErrSubmissionNotPendingText: "The submission is not waiting for text confirmation",
+		ErrInsufficientOcrCount:     "Free OCR count for today is used up",
+		ErrFileTooLarge:             "File size exceeds the limit",
+		ErrFileType:                 "Unsupported file type",
+		ErrUploadTooFrequent:        "Uploading too frequently, please try again later",
+		ErrUpload:                   "Upload failed, please try again",
+		ErrReportTimeRange:          "Invalid report time range",
+		ErrCreateReport:             "Failed to create report task",
+		ErrInvalidEmail:             "Invalid email address",
+		ErrTooManyRequests:          "Too many requests, please try again later",
+		ErrApiKeyInvalid:            "API key is invalid or disabled",
+		ErrApiDailyQuota:            "Daily call quota exceeded",
+		ErrApiMonthlyQuota:          "Monthly call quota exceeded, please contact us to raise the quota",
+		ErrApiSignature:             "Invalid request signature",
+		ErrApiReplay:                "Request expired or replayed",
+		ErrApiIPDenied:              "Source IP is not in the whitelist",
+		ErrApiEvaluateTimeout:       "Evaluation timed out, please try again later",
+		ErrContentBlocked:           "The essay failed the content safety check, please revise and resubmit",
+		ErrUnsupportedSubject:       "Essays of this subject are not supported yet",
+		ErrBindCodeLocked:           "Too many wrong bind codes, please try again later",
+		ErrPdfTooManyPages:          "The PDF has too many pages, please split it and upload again",
+		ErrShortAnswerQuota:         "The daily short answer grading limit has been reached, please try again tomorrow",
+
+		ErrNotFound:        "Not found",
+		ErrInvalidObjectId: "Invalid id",
+		ErrUpdate:          "Update failed",
+	},
+}
+
+// errAdvice 错误的建议处理方式（英文），供错误码目录使用，未登记的按错误码类别给出通用建议
+var errAdvice = map[*Errno]string{
+	ErrNotAuthentication:  "Sign in again to obtain a new token",
+	ErrInSufficientCount:  "Recharge or ask the administrator to add evaluation count",
+	ErrOneCall:            "Wait for the previous evaluation to finish before starting a new one",
+	ErrTooManyRequests:    "Back off and retry later",
+	ErrApiKeyInvalid:      "Check the X-Api-Key header; the key may be disabled or rotated",
+	ErrApiDailyQuota:      "Retry tomorrow or ask us to raise the daily quota",
+	ErrApiMonthlyQuota:    "Ask us to raise the monthly quota",
+	ErrApiSignature:       "Check the signing string and secret; see the signature headers in the OpenAPI document",
+	ErrApiReplay:          "Use the current timestamp and a new nonce for every request",
+	ErrApiIPDenied:        "Call from a whitelisted IP or ask the administrator to update the whitelist",
+	ErrApiEvaluateTimeout: "Retry later, or use the streaming endpoint for long essays",
+	ErrContentBlocked:     "Remove the inappropriate content from the essay and resubmit",
+	ErrUnsupportedSubject: "Use a supported subject: Chinese, or English for custom and library homework",
+	ErrBindCodeLocked:     "Wait an hour, or ask the student to generate a new bind code",
+	ErrPdfTooManyPages:    "Split the PDF so that each request has at most 30 pages",
+	ErrShortAnswerQuota:   "Retry tomorrow, or submit attempts without short answers",
+	ErrInternal:           "Retry later; contact us with the request id if it persists",
+}
+
+// Advice 错误的建议处理方式（英文）
+func (en *Errno) Advice() string {
+	key := en
+	if en.base != nil {
+		key = en.base
+	}
+	if advice, ok := errAdvice[key]; ok {
+		return advice
+	}
+	switch en.code {
+	case codes.InvalidArgument:
+		return "Fix the request according to detail; do not retry as is"
+	case codes.NotFound:
+		return "Check that the id exists and belongs to the caller"
+	case codes.PermissionDenied:
+		return "The caller has no permission for this resource"
+	case codes.Unknown:
+		return "Retry later with backoff"
+	default:
+		return "Show msg to the user; contact us with the request id if it persists"
+	}
+}
+
+// Message 错误在 lang 下的文案，不含 Detail
+func (en *Errno) Message(lang string) string {
+	key := en
+	if en.base != nil {
+		key = en.base
+	}
+	if msg, ok := errMessages[lang][key]; ok {
+		return msg
+	}
+	return en.err.Error()
+}
+
+// ParseLang 从 Accept-Language 中选出支持的语言，按出现顺序取第一个支持的，都不支持时为中文
+func ParseLang(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		switch primary {
+		case LangZh, LangEn:
+			return primary
+		}
+	}
+	return LangZh
+}
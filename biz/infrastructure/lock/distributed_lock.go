@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/metrics"
 	"essay-show/biz/infrastructure/redis"
+	"essay-show/biz/infrastructure/util/log"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,11 +17,12 @@ import (
 
 // 分布式锁实现
 // 基于Redis实现的分布式锁，支持自动续期和Watch Dog机制
-// 分布式锁会设定初始有效时间, 最长有效时间
+// 分布式锁会设定单次有效时间 expire, 最长有效时间 ttl
 // 在本项目中该分布式锁的应用是用户批改时会先尝试获取对应的锁, 获取失败则直接返回有一个批改进程
-// 若获取成功, 则进入处理流程, 同时会启动一个watch dog线程, 用于锁的生命周期管理
-// watch dog 发现锁过期但处理函数仍未结束时, 自动续期
-// 处理函数结束时, 通知watch dog释放锁, 若超时, 则自动释放锁
+// 若获取成功, 则进入处理流程, 同时会启动一个watch dog线程, 每隔 expire/3 把锁的有效期续回 expire
+// 续期请求失败时在下个周期重试, 只有确认锁已被他人持有时才停止续期
+// 持有超过 ttl 后不再续期, 锁在 expire 内自然过期, 避免处理进程卡死时永远占用锁
+// 加锁时写入唯一 value 作为 token, 续期和释放都校验 token, 不会误删他人的锁
 
 // IDistributedLock 分布式锁接口
 type IDistributedLock interface {
@@ -38,14 +42,14 @@ type EvaMutex struct {
 	ctx context.Context
 	// cancel 取消函数
 	cancel context.CancelFunc
-	// expire 有效时长
+	// expire 单次有效时长（秒）, 每次续期都续回该时长
 	expire int
 	// start 获取到锁的时间
 	start time.Time
-	// ttl 最长存活时间
+	// ttl 最长存活时间（秒）
 	ttl int
-	// isExpired 是否过期
-	isExpired bool
+	// expired 锁在释放前是否已失效（超过最长存活时间或被他人持有）
+	expired atomic.Bool
 }
 
 // retries 默认重试次数3
@@ -65,18 +69,20 @@ else
     return 0
 end`
 
+// errLockLost 续期时发现锁已不属于自己
+var errLockLost = errors.New("续期失败，锁已丢失")
+
 // NewEvaMutex 创建一个新的Redis分布式锁
 func NewEvaMutex(c context.Context, key string, expire, ttl int) *EvaMutex {
 	ctx, cancel := context.WithCancel(c)
 	return &EvaMutex{
-		rds:       redis.GetRedis(config.GetConfig()),
-		key:       key,
-		value:     uuid.New().String(),
-		ctx:       ctx,
-		cancel:    cancel,
-		expire:    expire,
-		ttl:       ttl,
-		isExpired: false,
+		rds:    redis.GetRedis(config.GetConfig()),
+		key:    key,
+		value:  uuid.New().String(),
+		ctx:    ctx,
+		cancel: cancel,
+		expire: expire,
+		ttl:    ttl,
 	}
 }
 
@@ -90,20 +96,28 @@ func (e *EvaMutex) Lock() error {
 			continue
 		}
 		e.start = time.Now()
+		metrics.LockAcquire.WithLabelValues("success").Inc()
 		go e.watchDog()
 		return nil
 	}
+	metrics.LockAcquire.WithLabelValues("fail").Inc()
 	return errors.New("获取锁失败")
 }
 
-// Unlock 释放锁
+// Unlock 释放锁，只删除 value 与自己一致的锁
 func (e *EvaMutex) Unlock() (err error) {
 	// 停止watch dog
 	e.cancel()
+	metrics.LockHoldSeconds.Observe(time.Since(e.start).Seconds())
 	// 释放锁
 	for i := 0; i < retries; i++ {
-		_, err = e.rds.EvalCtx(context.Background(), unlockScript, []string{e.key}, e.value)
+		var val any
+		val, err = e.rds.EvalCtx(context.Background(), unlockScript, []string{e.key}, e.value)
 		if err == nil {
+			if n, _ := val.(int64); n != 1 {
+				// 锁已过期或被他人持有
+				e.expired.Store(true)
+			}
 			return nil
 		}
 		time.Sleep(1 * time.Second)
@@ -111,15 +125,18 @@ func (e *EvaMutex) Unlock() (err error) {
 	return fmt.Errorf("释放锁失败: %v", err)
 }
 
-// Expired 返回是否超时
+// Expired 返回锁在释放前是否已失效
 func (e *EvaMutex) Expired() bool {
-	return e.isExpired
+	return e.expired.Load()
 }
 
 // watchDog 看门狗, 实现自动续期
 func (e *EvaMutex) watchDog() {
-	// 初始有效时间计时器
-	ticker := time.NewTicker(time.Duration(e.expire) * time.Second / 2)
+	interval := time.Duration(e.expire) * time.Second / 3
+	if interval < time.Second {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for {
@@ -128,39 +145,37 @@ func (e *EvaMutex) watchDog() {
 			return
 		case <-ticker.C:
 			if time.Since(e.start) > time.Duration(e.ttl)*time.Second {
-				e.isExpired = true
-				e.cancel()
+				e.expired.Store(true)
+				metrics.LockRenew.WithLabelValues("timeout").Inc()
+				log.Error("锁超过最长存活时间, 停止续期, key: %s, ttl: %ds", e.key, e.ttl)
 				return
 			}
-			if err := e.renew(); err != nil {
-				e.cancel()
+			err := e.renew()
+			switch {
+			case err == nil:
+				metrics.LockRenew.WithLabelValues("success").Inc()
+			case errors.Is(err, errLockLost):
+				e.expired.Store(true)
+				metrics.LockRenew.WithLabelValues("lost").Inc()
+				log.Error("锁已丢失, 停止续期, key: %s", e.key)
 				return
+			default:
+				// 请求失败时锁仍可能有效, 下个周期重试
+				metrics.LockRenew.WithLabelValues("error").Inc()
+				log.Error("锁续期失败, key: %s, error: %v", e.key, err)
 			}
-			ticker.Reset(time.Duration(e.expire) * time.Second / 2)
 		}
 	}
 }
 
 // renew 锁续期
 func (e *EvaMutex) renew() error {
-	e.reExpire()
 	val, err := e.rds.EvalCtx(e.ctx, renewScript, []string{e.key}, e.value, e.expire)
 	if err != nil {
 		return fmt.Errorf("续期请求失败: %w", err)
 	}
 	if success, _ := val.(int64); success != 1 {
-		return errors.New("续期失败，锁可能已丢失")
-	}
-	return err
-}
-
-// reExpire 更新剩余有效期
-// 目前这里的计算方式没有实际的依据, 估计一篇修改需要16s, 初始有效期设置24s, 最长存活40s
-// watch dog第一次休眠12秒, 第二次休眠6秒, 此后每次5s直到超过ttl
-// 第二次休眠前大概率就释放锁了, 如果是没有, 那么大概率是算法端导致的阻塞, 等到40s后杀死线程
-func (e *EvaMutex) reExpire() {
-	e.expire = e.expire / 2
-	if e.expire < 5 {
-		e.expire = 5
+		return errLockLost
 	}
+	return nil
 }
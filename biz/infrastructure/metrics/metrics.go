@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry 业务指标与 hertz 的请求指标共用的注册表，从指标端口的 /metrics 暴露
+var Registry = prometheus.NewRegistry()
+
+// CacheRequests 缓存读取次数，按缓存名和是否命中区分，命中率为 hit / (hit + miss)
+var CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "cache_requests_total",
+	Help:      "缓存读取次数，result 为 hit 或 miss",
+}, []string{"cache", "result"})
+
+// LockAcquire 分布式锁加锁次数，result 为 success 或 fail
+var LockAcquire = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "lock_acquire_total",
+	Help:      "分布式锁加锁次数，result 为 success 或 fail",
+}, []string{"result"})
+
+// LockRenew 分布式锁续期次数，result 为 success、error（请求失败）、lost（锁已丢失）或 timeout（超过最长存活时间）
+var LockRenew = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "lock_renew_total",
+	Help:      "分布式锁续期次数，result 为 success、error、lost 或 timeout",
+}, []string{"result"})
+
+// LockHoldSeconds 分布式锁从加锁到释放的持有时长
+var LockHoldSeconds = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: "essay_show",
+	Name:      "lock_hold_seconds",
+	Help:      "分布式锁持有时长（秒）",
+	Buckets:   []float64{1, 5, 10, 20, 30, 60, 120, 200, 300},
+})
+
+// EvaluateRequests 批改次数，source 为 essay、api、homework 或 mba，result 为 success 或 fail
+var EvaluateRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "evaluate_requests_total",
+	Help:      "批改次数，source 为 essay、api、homework 或 mba，result 为 success 或 fail",
+}, []string{"source", "result"})
+
+// DownstreamRequests 下游调用次数，api 为请求路径，result 为 success 或 fail
+var DownstreamRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "downstream_requests_total",
+	Help:      "下游调用次数，api 为请求路径，result 为 success 或 fail",
+}, []string{"api", "result"})
+
+// DownstreamSeconds 下游调用耗时，流式调用为整个流的耗时
+var DownstreamSeconds = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: "essay_show",
+	Name:      "downstream_seconds",
+	Help:      "下游调用耗时（秒），流式调用为整个流的耗时",
+	Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 20, 30, 60, 120},
+}, []string{"api"})
+
+// GradingQueue 后台批改任务每次扫描到的待批改数，queue 为 homework 或 mba
+var GradingQueue = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "essay_show",
+	Name:      "grading_queue_length",
+	Help:      "待批改数，queue 为 homework 或 mba",
+}, []string{"queue"})
+
+// CountConsumed 扣除的批改次数，reason 为 consts.CountReasonXxx，消耗速率为 rate(essay_show_count_consumed_total)
+var CountConsumed = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "count_consumed_total",
+	Help:      "扣除的批改次数，reason 为扣除原因",
+}, []string{"reason"})
+
+// SSEConnections 当前的 SSE 连接数，stream 为接口名
+var SSEConnections = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Namespace: "essay_show",
+	Name:      "sse_connections",
+	Help:      "当前的 SSE 连接数，stream 为接口名",
+}, []string{"stream"})
+
+// SSEDropped 通道写满被丢弃的流式消息数，type 为消息类型，policy 为当时的通道策略
+var SSEDropped = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "essay_show",
+	Name:      "sse_dropped_messages_total",
+	Help:      "通道写满被丢弃的流式消息数，type 为消息类型，policy 为通道策略",
+}, []string{"type", "policy"})
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		CacheRequests, LockAcquire, LockRenew, LockHoldSeconds,
+		EvaluateRequests, DownstreamRequests, DownstreamSeconds, GradingQueue, CountConsumed, SSEConnections, SSEDropped,
+	)
+}
+
+// EvaluateDone 记录一次批改结果
+func EvaluateDone(source string, ok bool) {
+	EvaluateRequests.WithLabelValues(source, result(ok)).Inc()
+}
+
+// ObserveDownstream 记录一次下游调用，按 url 的路径区分接口
+func ObserveDownstream(rawUrl string, start time.Time, err error) {
+	api := rawUrl
+	if u, e := url.Parse(rawUrl); e == nil {
+		api = u.Path
+	}
+	DownstreamSeconds.WithLabelValues(api).Observe(time.Since(start).Seconds())
+	DownstreamRequests.WithLabelValues(api, result(err == nil)).Inc()
+}
+
+func result(ok bool) string {
+	if ok {
+		return "success"
+	}
+	return "fail"
+}
+
+// CacheHit 记录一次缓存命中
+func CacheHit(name string) {
+	CacheRequests.WithLabelValues(name, "hit").Inc()
+}
+
+// CacheMiss 记录一次缓存未命中
+func CacheMiss(name string) {
+	CacheRequests.WithLabelValues(name, "miss").Inc()
+}
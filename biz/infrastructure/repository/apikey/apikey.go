@@ -0,0 +1,156 @@
+package apikey
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	prefixApiKeyCacheKey = "cache:api_key:"
+	CollectionName       = "api_key"
+)
+
+// ApiKey 外部 API 调用方的密钥，库中只保存密钥的 sha256 摘要，明文只在创建和轮换时返回一次
+type ApiKey struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Name         string             `bson:"name" json:"name"`                  // 调用方名称
+	Prefix       string             `bson:"prefix" json:"prefix"`              // 明文密钥的前几位，用于列表中辨认
+	KeyHash      string             `bson:"key_hash" json:"keyHash"`           // 明文密钥的 sha256 摘要
+	SignSecret   string             `bson:"sign_secret" json:"signSecret"`     // 请求签名的 HMAC 密钥，服务端需要原文参与计算
+	Status       int                `bson:"status" json:"status"`              // 0: 启用, 1: 已禁用
+	CreatorID    string             `bson:"creator_id" json:"creatorId"`       // 创建该密钥的管理员
+	DailyQuota   int64              `bson:"daily_quota" json:"dailyQuota"`     // 每日调用次数上限，0 表示不限
+	MonthlyQuota int64              `bson:"monthly_quota" json:"monthlyQuota"` // 每月调用次数上限，0 表示不限
+	IPWhitelist  []string           `bson:"ip_whitelist" json:"ipWhitelist"`   // 允许的来源 IP 或 CIDR，为空时不限制
+	RotateTime   time.Time          `bson:"rotate_time,omitempty" json:"rotateTime"`
+	CreateTime   time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime   time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache, cache.WithExpiry(consts.ApiKeyCacheExpiry))
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, k *ApiKey) error {
+	if k.ID.IsZero() {
+		k.ID = primitive.NewObjectID()
+		k.CreateTime = time.Now()
+		k.UpdateTime = k.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, k)
+	return err
+}
+
+func (m *MongoMapper) FindOne(ctx context.Context, id string) (*ApiKey, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var k ApiKey
+	if err = m.conn.FindOneNoCache(ctx, &k, bson.M{consts.ID: oid}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &k, nil
+}
+
+// FindByHash 按密钥摘要查询，网关每个请求都会调用，先读缓存，禁用和轮换时删除缓存
+func (m *MongoMapper) FindByHash(ctx context.Context, hash string) (*ApiKey, error) {
+	key := prefixApiKeyCacheKey + hash
+	var k ApiKey
+	if err := m.conn.GetCache(key, &k); err == nil {
+		return &k, nil
+	}
+	if err := m.conn.FindOneNoCache(ctx, &k, bson.M{"key_hash": hash}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	if err := m.conn.SetCache(key, &k); err != nil {
+		log.Error("写入 API Key 缓存失败, id: %s, error: %v", k.ID.Hex(), err)
+	}
+	return &k, nil
+}
+
+// FindMany 按状态分页查询密钥，status 为空时不过滤
+func (m *MongoMapper) FindMany(ctx context.Context, status *int64, p *basic.PaginationOptions) ([]*ApiKey, int64, error) {
+	filter := bson.M{}
+	if status != nil {
+		filter[consts.Status] = *status
+	}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*ApiKey, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// UpdateStatus 启用或禁用密钥，并删除该密钥的缓存使其立即生效
+func (m *MongoMapper) UpdateStatus(ctx context.Context, k *ApiKey, status int) error {
+	_, err := m.conn.UpdateOne(ctx, prefixApiKeyCacheKey+k.KeyHash, bson.M{consts.ID: k.ID}, bson.M{"$set": bson.M{
+		consts.Status: status,
+		"update_time": time.Now(),
+	}})
+	return err
+}
+
+// UpdateQuota 修改密钥的调用配额，并删除该密钥的缓存使其立即生效
+func (m *MongoMapper) UpdateQuota(ctx context.Context, k *ApiKey, daily, monthly int64) error {
+	_, err := m.conn.UpdateOne(ctx, prefixApiKeyCacheKey+k.KeyHash, bson.M{consts.ID: k.ID}, bson.M{"$set": bson.M{
+		"daily_quota":   daily,
+		"monthly_quota": monthly,
+		"update_time":   time.Now(),
+	}})
+	return err
+}
+
+// UpdateWhitelist 修改密钥的来源 IP 白名单，并删除该密钥的缓存使其立即生效
+func (m *MongoMapper) UpdateWhitelist(ctx context.Context, k *ApiKey, whitelist []string) error {
+	_, err := m.conn.UpdateOne(ctx, prefixApiKeyCacheKey+k.KeyHash, bson.M{consts.ID: k.ID}, bson.M{"$set": bson.M{
+		"ip_whitelist": whitelist,
+		"update_time":  time.Now(),
+	}})
+	return err
+}
+
+// Rotate 用新的密钥和签名密钥替换旧的，旧密钥的缓存被删除后立即失效；并发轮换时只有一次成功
+func (m *MongoMapper) Rotate(ctx context.Context, k *ApiKey, prefix, hash, signSecret string) (bool, error) {
+	now := time.Now()
+	res, err := m.conn.UpdateOne(ctx, prefixApiKeyCacheKey+k.KeyHash, bson.M{
+		consts.ID:  k.ID,
+		"key_hash": k.KeyHash,
+	}, bson.M{"$set": bson.M{
+		"prefix":      prefix,
+		"key_hash":    hash,
+		"sign_secret": signSecret,
+		"rotate_time": now,
+		"update_time": now,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
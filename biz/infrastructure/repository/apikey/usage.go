@@ -0,0 +1,68 @@
+package apikey
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const UsageCollectionName = "api_usage"
+
+// Usage 一个密钥一天的用量，每次成功调用累加，供与调用方对账
+type Usage struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	KeyID         string             `bson:"key_id" json:"keyId"`
+	Date          string             `bson:"date" json:"date"`   // 2006-01-02
+	Month         string             `bson:"month" json:"month"` // 2006-01，按月汇总时使用
+	Calls         int64              `bson:"calls" json:"calls"`
+	EvaluateCount int64              `bson:"evaluate_count" json:"evaluateCount"`
+	OcrCount      int64              `bson:"ocr_count" json:"ocrCount"`
+	Tokens        int64              `bson:"tokens" json:"tokens"`
+	UpdateTime    time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type UsageMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewUsageMongoMapper(cfg *config.Config) *UsageMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, UsageCollectionName, cfg.Cache)
+	return &UsageMongoMapper{conn: conn}
+}
+
+// Inc 累加密钥当天的用量，当天第一次调用时创建记录，field 为 evaluate_count 或 ocr_count
+func (m *UsageMongoMapper) Inc(ctx context.Context, keyID string, now time.Time, field string, tokens int64) error {
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"key_id": keyID,
+		"date":   now.Format(time.DateOnly),
+	}, bson.M{
+		"$inc": bson.M{
+			"calls":  1,
+			field:    1,
+			"tokens": tokens,
+		},
+		"$set":         bson.M{"update_time": now},
+		"$setOnInsert": bson.M{"month": now.Format("2006-01")},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// FindByKey 查询密钥在 [startDate, endDate] 内每天的用量，按日期升序
+func (m *UsageMongoMapper) FindByKey(ctx context.Context, keyID, startDate, endDate string) ([]*Usage, error) {
+	data := make([]*Usage, 0)
+	err := m.conn.Find(ctx, &data, bson.M{
+		"key_id": keyID,
+		"date":   bson.M{"$gte": startDate, "$lte": endDate},
+	}, &options.FindOptions{
+		Sort: bson.M{"date": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
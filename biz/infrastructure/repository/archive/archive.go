@@ -0,0 +1,95 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Store 冷数据归档的对象存储，key 为完整的对象路径，默认实现为 util.CosArchiveStore
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Key 文档归档对象的路径，同一文档重复归档时覆盖
+func Key(collection, id string) string {
+	return fmt.Sprintf("essays_%s/%s/%s/%s.json.gz", config.GetConfig().State, consts.ArchiveCosDir, collection, id)
+}
+
+// Compress 用 gzip 压缩待归档的内容
+func Compress(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Load 读取并解压归档对象
+func Load(ctx context.Context, store Store, key string) (string, error) {
+	data, err := store.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+// Restore 字段已归档（内容为空且有归档路径）时从对象存储回源到 field，回源失败只记录日志，field 保持为空
+func Restore(ctx context.Context, store Store, field *string, key string) {
+	if *field != "" || key == "" {
+		return
+	}
+	content, err := Load(ctx, store, key)
+	if err != nil {
+		log.CtxError(ctx, "回源归档数据失败, key: %s, err: %v", key, err)
+		return
+	}
+	*field = content
+}
+
+// Target 一个可能已归档的字段及其归档路径
+type Target struct {
+	Field *string
+	Key   string
+}
+
+// RestoreAll 并发回源列表中已归档的字段，并发数不超过 consts.ArchiveRestoreConcurrency，未归档的字段不发起请求
+func RestoreAll(ctx context.Context, store Store, targets []Target) {
+	sem := make(chan struct{}, consts.ArchiveRestoreConcurrency)
+	var wg sync.WaitGroup
+	for _, t := range targets {
+		if *t.Field != "" || t.Key == "" {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(t Target) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			Restore(ctx, store, t.Field, t.Key)
+		}(t)
+	}
+	wg.Wait()
+}
@@ -7,7 +7,9 @@ import (
 
 // Attend 记录用户每日的签到情况
 type Attend struct {
-	ID        primitive.ObjectID `bson:"_id,omitempty"` // uid
-	UserId    string             `bson:"user_id"`       // 记录的用户Id
-	Timestamp time.Time          `bson:"timestamp"`     // 签到的时间
+	ID        primitive.ObjectID `bson:"_id,omitempty"`    // uid
+	UserId    string             `bson:"user_id"`          // 记录的用户Id
+	Timestamp time.Time          `bson:"timestamp"`        // 签到的时间
+	Streak    int64              `bson:"streak,omitempty"` // 截至本次签到的连续天数
+	Makeup    bool               `bson:"makeup,omitempty"` // 是否为补签
 }
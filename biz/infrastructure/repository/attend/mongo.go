@@ -25,6 +25,7 @@ type IMongoMapper interface {
 	FindLatestOneByUserId(ctx context.Context, userId string) (a *Attend, err error)
 	Update(ctx context.Context, a *Attend) error
 	FindByYearAndMonth(ctx context.Context, userId string, year int, month int) (as []*Attend, total int64, err error)
+	FindSince(ctx context.Context, userId string, since time.Time) ([]*Attend, error)
 }
 
 type MongoMapper struct {
@@ -95,3 +96,16 @@ func (m *MongoMapper) FindByYearAndMonth(ctx context.Context, userId string, yea
 	}
 	return as, total, nil
 }
+
+// FindSince 获取指定时间之后的签到记录，按签到时间倒序
+func (m *MongoMapper) FindSince(ctx context.Context, userId string, since time.Time) ([]*Attend, error) {
+	as := make([]*Attend, 0)
+	err := m.conn.Find(ctx, &as, bson.M{
+		consts.UserID:    userId,
+		consts.Timestamp: bson.M{"$gte": since},
+	}, options.Find().SetSort(bson.M{consts.Timestamp: -1}))
+	if err != nil {
+		return nil, err
+	}
+	return as, nil
+}
@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "audit_log"
+
+// AuditLog 一条登录或敏感操作记录，只增不改
+type AuditLog struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	OperatorID string             `bson:"operator_id" json:"operatorId"`
+	Action     string             `bson:"action" json:"action"`
+	TargetID   string             `bson:"target_id,omitempty" json:"targetId"`
+	IP         string             `bson:"ip" json:"ip"`
+	UserAgent  string             `bson:"user_agent" json:"userAgent"`
+	Summary    string             `bson:"summary" json:"summary"` // 变更摘要
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+// Filter 审计日志查询条件，零值字段不参与过滤
+type Filter struct {
+	OperatorID string
+	Action     string
+	StartTime  *int64
+	EndTime    *int64
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, l *AuditLog) error {
+	if l.ID.IsZero() {
+		l.ID = primitive.NewObjectID()
+		l.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, l)
+	return err
+}
+
+// FindMany 按条件分页查询审计日志，按时间倒序
+func (m *MongoMapper) FindMany(ctx context.Context, f *Filter, p *basic.PaginationOptions) ([]*AuditLog, int64, error) {
+	filter := bson.M{}
+	if f.OperatorID != "" {
+		filter["operator_id"] = f.OperatorID
+	}
+	if f.Action != "" {
+		filter["action"] = f.Action
+	}
+	if f.StartTime != nil || f.EndTime != nil {
+		timeFilter := bson.M{}
+		if f.StartTime != nil {
+			timeFilter["$gte"] = time.Unix(*f.StartTime, 0)
+		}
+		if f.EndTime != nil {
+			timeFilter["$lt"] = time.Unix(*f.EndTime, 0)
+		}
+		filter[consts.CreateTime] = timeFilter
+	}
+
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*AuditLog, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
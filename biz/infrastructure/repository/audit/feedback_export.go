@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const FeedbackExportCollectionName = "evaluate_feedback_export"
+
+// FeedbackExport 教师修改数据的导出任务，把期间内被人工修改过的批改结果匿名化后导出为 jsonl 文件，完成后回填下载链接
+type FeedbackExport struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CreatorID    string             `bson:"creator_id" json:"creatorId"`
+	StartTime    time.Time          `bson:"start_time" json:"startTime"`
+	EndTime      time.Time          `bson:"end_time" json:"endTime"`
+	Status       int                `bson:"status" json:"status"` // 0: 生成中, 1: 已完成, 2: 生成失败
+	Count        int64              `bson:"count" json:"count"`   // 导出的样本数
+	Url          string             `bson:"url,omitempty" json:"url,omitempty"`
+	SessionToken string             `bson:"session_token,omitempty" json:"sessionToken,omitempty"`
+	Message      string             `bson:"message,omitempty" json:"message,omitempty"` // 失败原因
+	CreateTime   time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime   time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type FeedbackExportMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewFeedbackExportMongoMapper(cfg *config.Config) *FeedbackExportMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, FeedbackExportCollectionName, cfg.Cache)
+	return &FeedbackExportMongoMapper{conn: conn}
+}
+
+func (m *FeedbackExportMongoMapper) Insert(ctx context.Context, e *FeedbackExport) error {
+	if e.ID.IsZero() {
+		e.ID = primitive.NewObjectID()
+		e.CreateTime = time.Now()
+		e.UpdateTime = e.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, e)
+	return err
+}
+
+func (m *FeedbackExportMongoMapper) Update(ctx context.Context, e *FeedbackExport) error {
+	e.UpdateTime = time.Now()
+	_, err := m.conn.UpdateByIDNoCache(ctx, e.ID, bson.M{"$set": e})
+	return err
+}
+
+func (m *FeedbackExportMongoMapper) FindOne(ctx context.Context, id string) (*FeedbackExport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var e FeedbackExport
+	if err = m.conn.FindOneNoCache(ctx, &e, bson.M{consts.ID: oid}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &e, nil
+}
@@ -0,0 +1,42 @@
+package audit
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const ImageCleanupCollectionName = "image_cleanup_log"
+
+// ImageCleanup 一次作业提交原图清理的记录，只增不改
+type ImageCleanup struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SubmissionID string             `bson:"submission_id" json:"submissionId"`
+	HomeworkID   string             `bson:"homework_id" json:"homeworkId"`
+	MemberID     string             `bson:"member_id" json:"memberId"`
+	Images       []string           `bson:"images" json:"images"`                     // 清理前的图片 url
+	Failed       []string           `bson:"failed,omitempty" json:"failed,omitempty"` // 删除失败的图片 url，留给下次清理
+	CompleteTime time.Time          `bson:"complete_time" json:"completeTime"`        // 批改完成时间
+	CreateTime   time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type ImageCleanupMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewImageCleanupMongoMapper(cfg *config.Config) *ImageCleanupMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, ImageCleanupCollectionName, cfg.Cache)
+	return &ImageCleanupMongoMapper{conn: conn}
+}
+
+func (m *ImageCleanupMongoMapper) Insert(ctx context.Context, c *ImageCleanup) error {
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+		c.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, c)
+	return err
+}
@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const ModerationCollectionName = "content_moderation"
+
+// Moderation 一次未通过的作文内容安全审核，批改被拦截，只增不改
+type Moderation struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	Scene      string             `bson:"scene" json:"scene"`                  // consts.ModerationScene*
+	TargetID   string             `bson:"target_id,omitempty" json:"targetId"` // 作业提交 id，个人批改为空
+	UserID     string             `bson:"user_id" json:"userId"`               // 作业为布置作业的教师，个人批改为用户本人
+	Source     string             `bson:"source" json:"source"`                // consts.ModerationSource*
+	Hits       []string           `bson:"hits" json:"hits"`                    // 命中的敏感词或中台返回的违规标签
+	Excerpt    string             `bson:"excerpt" json:"excerpt"`              // 作文开头，长度不超过 consts.ModerationExcerptLength
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type ModerationMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewModerationMongoMapper(cfg *config.Config) *ModerationMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, ModerationCollectionName, cfg.Cache)
+	return &ModerationMongoMapper{conn: conn}
+}
+
+func (m *ModerationMongoMapper) Insert(ctx context.Context, r *Moderation) error {
+	if r.ID.IsZero() {
+		r.ID = primitive.NewObjectID()
+		r.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, r)
+	return err
+}
+
+// FindMany 分页查询审核记录，scene、userId 为空时不过滤，按时间倒序
+func (m *ModerationMongoMapper) FindMany(ctx context.Context, scene, userId string, p *basic.PaginationOptions) ([]*Moderation, int64, error) {
+	filter := bson.M{}
+	if scene != "" {
+		filter["scene"] = scene
+	}
+	if userId != "" {
+		filter[consts.UserID] = userId
+	}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Moderation, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
@@ -0,0 +1,72 @@
+package audit
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const RevisionCollectionName = "evaluate_revision"
+
+// Revision 一次批改结果修改，记录修改人与字段级差异，只增不改
+type Revision struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TargetType string             `bson:"target_type" json:"targetType"` // consts.RevisionTarget*
+	TargetID   string             `bson:"target_id" json:"targetId"`
+	OperatorID string             `bson:"operator_id" json:"operatorId"`
+	Changes    []*FieldChange     `bson:"changes" json:"changes"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+// FieldChange 批改结果中一个字段的修改，Field 为 JSON 路径，如 aiEvaluation.scoreEvaluation.comment
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before string `bson:"before" json:"before"`
+	After  string `bson:"after" json:"after"`
+}
+
+type RevisionMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewRevisionMongoMapper(cfg *config.Config) *RevisionMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, RevisionCollectionName, cfg.Cache)
+	return &RevisionMongoMapper{conn: conn}
+}
+
+func (m *RevisionMongoMapper) Insert(ctx context.Context, r *Revision) error {
+	if r.ID.IsZero() {
+		r.ID = primitive.NewObjectID()
+		r.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, r)
+	return err
+}
+
+// FindByTarget 分页查询一条批改结果的修改历史，按时间倒序
+func (m *RevisionMongoMapper) FindByTarget(ctx context.Context, targetType, targetId string, p *basic.PaginationOptions) ([]*Revision, int64, error) {
+	filter := bson.M{"target_type": targetType, "target_id": targetId}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Revision, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
@@ -0,0 +1,108 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const VersionCollectionName = "evaluate_version"
+
+// Version 批改结果的一个完整版本，第 1 版为首次修改前的结果，之后每次人工修改、回滚或 AI 重批追加一版，只增不改；
+// (target_type, target_id, version) 有唯一索引，并发写入同一版本号时后写入的返回重复键错误
+type Version struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	TargetType   string             `bson:"target_type" json:"targetType"` // consts.RevisionTarget*
+	TargetID     string             `bson:"target_id" json:"targetId"`
+	Version      int64              `bson:"version" json:"version"` // 从 1 开始递增
+	Source       string             `bson:"source" json:"source"`   // consts.VersionSource*
+	OperatorID   string             `bson:"operator_id" json:"operatorId"`
+	RollbackFrom int64              `bson:"rollback_from,omitempty" json:"rollbackFrom"` // 回滚时恢复的版本号
+	Response     string             `bson:"response" json:"response"`
+	CreateTime   time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type VersionMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewVersionMongoMapper(cfg *config.Config) *VersionMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, VersionCollectionName, cfg.Cache)
+	return &VersionMongoMapper{conn: conn}
+}
+
+func (m *VersionMongoMapper) Insert(ctx context.Context, v *Version) error {
+	if v.ID.IsZero() {
+		v.ID = primitive.NewObjectID()
+		v.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, v)
+	return err
+}
+
+// FindOne 查询一条批改结果的指定版本
+func (m *VersionMongoMapper) FindOne(ctx context.Context, targetType, targetId string, version int64) (*Version, error) {
+	var v Version
+	err := m.conn.FindOneNoCache(ctx, &v, bson.M{"target_type": targetType, "target_id": targetId, "version": version})
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &v, nil
+}
+
+// FindLatest 查询一条批改结果的最新版本，还没有版本时返回 consts.ErrNotFound，其他错误原样返回
+func (m *VersionMongoMapper) FindLatest(ctx context.Context, targetType, targetId string) (*Version, error) {
+	var v Version
+	err := m.conn.FindOneNoCache(ctx, &v, bson.M{"target_type": targetType, "target_id": targetId},
+		options.FindOne().SetSort(bson.M{"version": -1}))
+	switch {
+	case err == nil:
+		return &v, nil
+	case errors.Is(err, monc.ErrNotFound):
+		return nil, consts.ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// FindByTarget 分页查询一条批改结果的版本列表，按版本号倒序
+func (m *VersionMongoMapper) FindByTarget(ctx context.Context, targetType, targetId string, p *basic.PaginationOptions) ([]*Version, int64, error) {
+	filter := bson.M{"target_type": targetType, "target_id": targetId}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Version, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{"version": -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// FindModifiedBetween 查询 [start, end) 内的人工修改与回滚版本，不返回批改结果内容，按时间正序
+func (m *VersionMongoMapper) FindModifiedBetween(ctx context.Context, start, end time.Time) ([]*Version, error) {
+	var data []*Version
+	err := m.conn.Find(ctx, &data, bson.M{
+		"source":          bson.M{"$ne": consts.VersionSourceOriginal},
+		consts.CreateTime: bson.M{"$gte": start, "$lt": end},
+	}, &options.FindOptions{
+		Sort:       bson.M{consts.CreateTime: 1},
+		Projection: bson.M{"response": 0},
+	})
+	return data, err
+}
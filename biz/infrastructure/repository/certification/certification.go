@@ -0,0 +1,128 @@
+package certification
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/upload"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "teacher_certification"
+
+// Certification 一次教师资质认证申请，同一用户可在被驳回后重新提交
+type Certification struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	RealName   string             `bson:"real_name" json:"realName"`
+	School     string             `bson:"school" json:"school"`
+	Images     []string           `bson:"images" json:"images"` // 教师资格证/工作证图片
+	Status     int                `bson:"status" json:"status"` // 0: 待审核, 1: 已通过, 2: 已驳回
+	Reason     string             `bson:"reason,omitempty" json:"reason"`
+	ReviewerID string             `bson:"reviewer_id,omitempty" json:"reviewerId"`
+	ReviewTime time.Time          `bson:"review_time,omitempty" json:"reviewTime"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, c *Certification) error {
+	if c.ID.IsZero() {
+		c.ID = primitive.NewObjectID()
+		c.CreateTime = time.Now()
+		c.UpdateTime = c.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, c)
+	return err
+}
+
+func (m *MongoMapper) FindOne(ctx context.Context, id string) (*Certification, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var c Certification
+	if err = m.conn.FindOneNoCache(ctx, &c, bson.M{consts.ID: oid}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &c, nil
+}
+
+// FindLatestByUser 获取用户最近一次认证申请
+func (m *MongoMapper) FindLatestByUser(ctx context.Context, userID string) (*Certification, error) {
+	var c Certification
+	err := m.conn.FindOneNoCache(ctx, &c, bson.M{consts.UserID: userID}, &options.FindOneOptions{
+		Sort: bson.M{consts.CreateTime: -1},
+	})
+	switch {
+	case err == nil:
+		return &c, nil
+	case errors.Is(err, monc.ErrNotFound):
+		return nil, consts.ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// FindMany 按状态分页查询认证申请，status 为空时查询全部
+func (m *MongoMapper) FindMany(ctx context.Context, status *int64, p *basic.PaginationOptions) ([]*Certification, int64, error) {
+	filter := bson.M{}
+	if status != nil {
+		filter[consts.Status] = *status
+	}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Certification, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// Review 审核一条待审核的申请，只有待审核状态可以被更新
+func (m *MongoMapper) Review(ctx context.Context, id primitive.ObjectID, status int, reason, reviewerID string) (bool, error) {
+	now := time.Now()
+	res, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.ID:     id,
+		consts.Status: consts.CertStatusPending,
+	}, bson.M{"$set": bson.M{
+		consts.Status: status,
+		"reason":      reason,
+		"reviewer_id": reviewerID,
+		"review_time": now,
+		"update_time": now,
+	}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
+
+// DistinctImagesSince 返回 since 之后提交或更新的认证申请引用的图片 url，用于孤儿文件对账
+func (m *MongoMapper) DistinctImagesSince(ctx context.Context, since time.Time) ([]string, error) {
+	return upload.Strings(m.conn.Distinct(ctx, "images", bson.M{"update_time": bson.M{"$gte": since}}))
+}
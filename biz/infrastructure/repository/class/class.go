@@ -4,9 +4,13 @@ import (
 	"context"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
+	"essay-show/biz/infrastructure/repository/softdelete"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/util/log"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/stores/cache"
 	"github.com/zeromicro/go-zero/core/stores/monc"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -34,8 +38,8 @@ type MongoMapper struct {
 }
 
 func NewMongoMapper(config *config.Config) *MongoMapper {
-	log.Info("NewClassMongoMapper config: %v, collection: %s", config, ClassCollectionName)
-	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, ClassCollectionName, config.Cache)
+	log.Info("NewClassMongoMapper collection: %s", ClassCollectionName)
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, ClassCollectionName, config.Cache, cache.WithExpiry(consts.ClassCacheExpiry))
 	return &MongoMapper{
 		conn: conn,
 	}
@@ -51,24 +55,40 @@ func (m *MongoMapper) Insert(ctx context.Context, class *Class) error {
 	return err
 }
 
-func (m *MongoMapper) FindOne(ctx context.Context, id string) (*Class, error) {
+// FindOne 默认只查未删除的班级，scope 为 softdelete.All 时可查到已删除的班级。
+// 只缓存未删除的班级，更新和删除时删除缓存
+func (m *MongoMapper) FindOne(ctx context.Context, id string, scope ...softdelete.Scope) (*Class, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, consts.ErrInvalidObjectId
 	}
+	cached := len(scope) == 0 || scope[0] == softdelete.Alive
+	key := prefixClassCacheKey + oid.Hex()
 	var c Class
-	err = m.conn.FindOneNoCache(ctx, &c, bson.M{
+	if cached {
+		if err = m.conn.GetCache(key, &c); err == nil {
+			metrics.CacheHit(ClassCollectionName)
+			return &c, nil
+		}
+		metrics.CacheMiss(ClassCollectionName)
+	}
+	err = m.conn.FindOneNoCache(ctx, &c, softdelete.Filter(bson.M{
 		consts.ID: oid,
-	})
+	}, scope...))
 	if err != nil {
 		return nil, consts.ErrNotFound
 	}
+	if cached {
+		if err = m.conn.SetCache(key, &c); err != nil {
+			log.Error("写入班级缓存失败, classId: %s, error: %v", id, err)
+		}
+	}
 	return &c, nil
 }
 
 func (m *MongoMapper) FindByCreator(ctx context.Context, creatorID string, page, pageSize int64) ([]*Class, int64, error) {
 	var classes []*Class
-	filter := bson.M{"creator_id": creatorID}
+	filter := softdelete.Filter(bson.M{"creator_id": creatorID})
 
 	// 获取总数
 	total, err := m.conn.CountDocuments(ctx, filter)
@@ -103,5 +123,37 @@ func (m *MongoMapper) UpdateMemberCount(ctx context.Context, id string, incremen
 			"update_time": time.Now(),
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	m.delCache(ctx, oid)
+	return nil
+}
+
+// Delete 软删除班级，成员和作业保留，班级不再出现在查询结果中
+func (m *MongoMapper) Delete(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	_, err = m.conn.UpdateOneNoCache(ctx, softdelete.Filter(bson.M{consts.ID: oid}), softdelete.Update())
+	if err != nil {
+		return err
+	}
+	m.delCache(ctx, oid)
+	return nil
+}
+
+// delCache 清理班级缓存，在事务中时等提交后再清理，失败只记录日志
+func (m *MongoMapper) delCache(ctx context.Context, oid primitive.ObjectID) {
+	transaction.AfterCommit(ctx, func() {
+		if err := m.conn.DelCache(ctx, prefixClassCacheKey+oid.Hex()); err != nil {
+			log.CtxError(ctx, "删除班级缓存失败, classId: %s, err: %v", oid.Hex(), err)
+		}
+	})
+}
+
+// Purge 物理删除软删除时间早于 before 的班级
+func (m *MongoMapper) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return m.conn.DeleteMany(ctx, softdelete.PurgeFilter(before))
 }
@@ -34,7 +34,7 @@ type MemberMongoMapper struct {
 }
 
 func NewMemberMongoMapper(config *config.Config) *MemberMongoMapper {
-	log.Info("NewMemberMongoMapper config: %v, collection: %s", config, MemberCollectionName)
+	log.Info("NewMemberMongoMapper collection: %s", MemberCollectionName)
 	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, MemberCollectionName, config.Cache)
 	return &MemberMongoMapper{
 		conn: conn,
@@ -0,0 +1,54 @@
+package exercise
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const AssignmentCollectionName = "exercise_assignment"
+
+// Assignment 教师把一套练习布置给班级，每个已绑定账号的学生各生成一份练习实例
+type Assignment struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	SourceID     string             `bson:"source_id" json:"sourceId"` // 被布置的原练习
+	ClassID      string             `bson:"class_id" json:"classId"`
+	TeacherID    string             `bson:"teacher_id" json:"teacherId"`
+	StudentCount int64              `bson:"student_count" json:"studentCount"` // 生成的练习实例数
+	CreateTime   time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type AssignmentMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewAssignmentMongoMapper(config *config.Config) *AssignmentMongoMapper {
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, AssignmentCollectionName, config.Cache)
+	return &AssignmentMongoMapper{conn: conn}
+}
+
+func (m *AssignmentMongoMapper) Insert(ctx context.Context, a *Assignment) error {
+	if a.ID.IsZero() {
+		a.ID = primitive.NewObjectID()
+		a.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, a)
+	return err
+}
+
+func (m *AssignmentMongoMapper) FindOne(ctx context.Context, id string) (*Assignment, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var a Assignment
+	if err = m.conn.FindOneNoCache(ctx, &a, bson.M{consts.ID: oid}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &a, nil
+}
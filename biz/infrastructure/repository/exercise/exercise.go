@@ -7,32 +7,38 @@ import (
 )
 
 type (
-	// Exercise  是一次生成的所有题目，暂时只有选择题
+	// Exercise  是一次生成的所有题目
 	// 单条记录多次生成题目会有多个Exercises对象
 	Exercise struct {
-		ID         primitive.ObjectID `bson:"_id" json:"id,omitempty"`
-		UserId     string             `bson:"user_id" json:"userId"`                             // 归属的用户ID
-		LogId      string             `bson:"log_id" json:"logId"`                               // 批改记录的ID
-		Question   *Question          `bson:"question" json:"question"`                          // 生成的题目
-		History    *History           `bson:"history" json:"history"`                            // 用户做题记录
-		Like       int64              `bson:"like" json:"like"`                                  // 点赞, -1是不喜欢该题，1是喜欢该题
-		CreateTime time.Time          `bson:"create_time" json:"createTime"`                     // 创建时间
-		UpdateTime time.Time          `bson:"update_time" json:"updateTime"`                     // 更新时间
-		DeleteTime time.Time          `bson:"delete_time,omitempty" json:"deleteTime,omitempty"` // 删除时间
-		Status     int64              `bson:"status" json:"status"`
+		ID           primitive.ObjectID `bson:"_id" json:"id,omitempty"`
+		UserId       string             `bson:"user_id" json:"userId"`                             // 归属的用户ID
+		LogId        string             `bson:"log_id" json:"logId"`                               // 批改记录的ID
+		Question     *Question          `bson:"question" json:"question"`                          // 生成的题目
+		History      *History           `bson:"history" json:"history"`                            // 用户做题记录
+		Like         int64              `bson:"like" json:"like"`                                  // 点赞, -1是不喜欢该题，1是喜欢该题
+		CreateTime   time.Time          `bson:"create_time" json:"createTime"`                     // 创建时间
+		UpdateTime   time.Time          `bson:"update_time" json:"updateTime"`                     // 更新时间
+		DeleteTime   time.Time          `bson:"delete_time,omitempty" json:"deleteTime,omitempty"` // 删除时间
+		Status       int64              `bson:"status" json:"status"`
+		AssignmentID string             `bson:"assignment_id,omitempty" json:"assignmentId,omitempty"`  // 教师布置生成时所属的布置记录
+		SourceLogIds []string           `bson:"source_log_ids,omitempty" json:"sourceLogIds,omitempty"` // 基于薄弱点生成时参与分析的批改记录
+		Difficulty   string             `bson:"difficulty,omitempty" json:"difficulty,omitempty"`       // 生成时指定的难度，未指定为空
 	}
 
 	// Question 一组问题, 抽离出来方便扩充其他体型
 	Question struct {
-		ChoiceQuestions []*ChoiceQuestion `bson:"choice_questions" json:"choiceQuestions"` // 选择题列表
+		ChoiceQuestions []*ChoiceQuestion `bson:"choice_questions" json:"choiceQuestions"`                   // 选择题列表
+		FillQuestions   []*FillQuestion   `bson:"fill_questions,omitempty" json:"fillQuestions,omitempty"`   // 填空题列表
+		ShortQuestions  []*ShortQuestion  `bson:"short_questions,omitempty" json:"shortQuestions,omitempty"` // 简答题列表
 	}
 
 	// ChoiceQuestion 是一道完整的选择题
 	ChoiceQuestion struct {
-		Id          string    `bson:"id" json:"id"`                   // 题目id，如 "Q01"
-		Question    string    `bson:"question" json:"question"`       // 问题描述
-		Explanation string    `bson:"explanation" json:"explanation"` // 题目解答
-		Options     []*Option `bson:"options" json:"options"`         // 题目选项
+		Id          string    `bson:"id" json:"id"`                                   // 题目id，如 "Q01"
+		Question    string    `bson:"question" json:"question"`                       // 问题描述
+		Explanation string    `bson:"explanation" json:"explanation"`                 // 题目解答
+		Options     []*Option `bson:"options" json:"options"`                         // 题目选项
+		Knowledge   string    `bson:"knowledge,omitempty" json:"knowledge,omitempty"` // 考察的知识点
 	}
 
 	// Option 是一道选择题中的选项
@@ -42,6 +48,26 @@ type (
 		Score   int64  `bson:"score" json:"score"`     // 选项对应得分
 	}
 
+	// FillQuestion 是一道填空题，作答与任一参考答案一致即得满分
+	FillQuestion struct {
+		Id          string   `bson:"id" json:"id"`
+		Question    string   `bson:"question" json:"question"`
+		Explanation string   `bson:"explanation" json:"explanation"`
+		Answers     []string `bson:"answers" json:"answers"` // 可接受的答案
+		Score       int64    `bson:"score" json:"score"`     // 满分
+		Knowledge   string   `bson:"knowledge,omitempty" json:"knowledge,omitempty"`
+	}
+
+	// ShortQuestion 是一道简答题，由下游按参考答案判分
+	ShortQuestion struct {
+		Id          string `bson:"id" json:"id"`
+		Question    string `bson:"question" json:"question"`
+		Explanation string `bson:"explanation" json:"explanation"`
+		Reference   string `bson:"reference" json:"reference"` // 参考答案
+		Score       int64  `bson:"score" json:"score"`         // 满分
+		Knowledge   string `bson:"knowledge,omitempty" json:"knowledge,omitempty"`
+	}
+
 	// History 一组题目的总记录
 	History struct {
 		Records []*Records `bson:"records" json:"records"`
@@ -49,15 +75,18 @@ type (
 
 	// Records 是用户做的一组题目的记录
 	Records struct {
-		Records    []*Record `bson:"records" json:"records"`        // 作答记录
-		Score      int64     `bson:"score" json:"score"`            // 总得分
-		CreateTime time.Time `bson:"create_time" json:"createTime"` // 提交时间
+		Records    []*Record `bson:"records" json:"records"`             // 作答记录
+		Score      int64     `bson:"score" json:"score"`                 // 总得分
+		Duration   int64     `bson:"duration,omitempty" json:"duration"` // 作答用时，单位秒
+		CreateTime time.Time `bson:"create_time" json:"createTime"`      // 提交时间
 	}
 
 	// Record 一道题的记录
 	Record struct {
-		Id     string `bson:"id" json:"id"`         // 题目Id
-		Option string `bson:"option" json:"option"` // 选择内容
-		Score  int64  `bson:"score" json:"score"`   // 得分
+		Id      string `bson:"id" json:"id"`                               // 题目Id
+		Option  string `bson:"option" json:"option"`                       // 选择内容
+		Answer  string `bson:"answer,omitempty" json:"answer,omitempty"`   // 填空题、简答题的作答内容
+		Comment string `bson:"comment,omitempty" json:"comment,omitempty"` // 简答题的判分评语
+		Score   int64  `bson:"score" json:"score"`                         // 得分
 	}
 )
@@ -52,6 +52,25 @@ func (m *MongoMapper) Update(ctx context.Context, e *Exercise) error {
 	return err
 }
 
+// PushRecords 在一次更新内追加一次作答记录，返回追加后的作答次数，并发作答不会互相覆盖；
+// 旧数据的 history 可能为 null，因此用 $concatArrays 代替 $push
+func (m *MongoMapper) PushRecords(ctx context.Context, id primitive.ObjectID, rds *Records) (int64, error) {
+	var e Exercise
+	err := m.conn.FindOneAndUpdate(ctx, prefixKeyCacheKey+id.Hex(), &e, bson.M{consts.ID: id}, bson.A{
+		bson.M{"$set": bson.M{
+			"history.records": bson.M{"$concatArrays": bson.A{
+				bson.M{"$ifNull": bson.A{"$history.records", bson.A{}}},
+				bson.A{bson.M{"$literal": rds}},
+			}},
+			"update_time": time.Now(),
+		}},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(e.History.Records)), nil
+}
+
 func (m *MongoMapper) FindManyByLogId(ctx context.Context, logId string, p *basic.PaginationOptions) (exercise []*Exercise, total int64, err error) {
 	skip, limt := util.ParsePageOpt(p)
 
@@ -81,6 +100,112 @@ func (m *MongoMapper) FindManyByLogId(ctx context.Context, logId string, p *basi
 	return data, total, nil
 }
 
+// FindManyByUserId 分页查询用户生成过的练习，logId、difficulty 不为空时按其过滤
+func (m *MongoMapper) FindManyByUserId(ctx context.Context, userId string, logId, difficulty *string, p *basic.PaginationOptions) ([]*Exercise, int64, error) {
+	skip, limit := util.ParsePageOpt(p)
+
+	filter := bson.M{
+		consts.UserID: userId,
+		consts.Status: bson.M{consts.NotEqual: consts.DeleteStatus},
+	}
+	if logId != nil && *logId != "" {
+		filter[consts.LogId] = *logId
+	}
+	if difficulty != nil && *difficulty != "" {
+		filter[consts.Difficulty] = *difficulty
+	}
+
+	data := make([]*Exercise, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Limit: &limit,
+		Skip:  &skip,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// InsertMany 批量插入练习，用于布置给班级
+func (m *MongoMapper) InsertMany(ctx context.Context, es []*Exercise) error {
+	if len(es) == 0 {
+		return nil
+	}
+	docs := make([]any, 0, len(es))
+	now := time.Now()
+	for _, e := range es {
+		if e.ID.IsZero() {
+			e.ID = primitive.NewObjectID()
+			e.CreateTime = now
+			e.UpdateTime = now
+		}
+		docs = append(docs, e)
+	}
+	_, err := m.conn.InsertMany(ctx, docs)
+	return err
+}
+
+// FindPendingAssigned 分页查询布置给学生且尚未作答的练习
+func (m *MongoMapper) FindPendingAssigned(ctx context.Context, userId string, p *basic.PaginationOptions) ([]*Exercise, int64, error) {
+	skip, limit := util.ParsePageOpt(p)
+
+	filter := bson.M{
+		consts.UserID:       userId,
+		"assignment_id":     bson.M{"$exists": true, consts.NotEqual: ""},
+		"history.records.0": bson.M{"$exists": false},
+		consts.Status:       bson.M{consts.NotEqual: consts.DeleteStatus},
+	}
+
+	data := make([]*Exercise, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Limit: &limit,
+		Skip:  &skip,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// FindAnsweredByUserId 获取用户最近 limit 个作答过的练习，用于统计
+func (m *MongoMapper) FindAnsweredByUserId(ctx context.Context, userId string, limit int64) ([]*Exercise, error) {
+	data := make([]*Exercise, 0, limit)
+	err := m.conn.Find(ctx, &data, bson.M{
+		consts.UserID:       userId,
+		"history.records.0": bson.M{"$exists": true},
+		consts.Status:       bson.M{consts.NotEqual: consts.DeleteStatus},
+	}, &options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// FindByAssignment 获取一次布置生成的所有练习实例
+func (m *MongoMapper) FindByAssignment(ctx context.Context, assignmentId string) ([]*Exercise, error) {
+	data := make([]*Exercise, 0)
+	err := m.conn.Find(ctx, &data, bson.M{"assignment_id": assignmentId})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
 func (m *MongoMapper) FindOneById(ctx context.Context, id string) (*Exercise, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -3,9 +3,11 @@ package feedback
 import (
 	"context"
 	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/repository/upload"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -36,3 +38,8 @@ func (m *MongoMapper) Insert(ctx context.Context, f *Feedback) error {
 	_, err := m.conn.InsertOneNoCache(ctx, f)
 	return err
 }
+
+// DistinctImagesSince 返回 since 之后提交的反馈引用的图片 url，用于孤儿文件对账
+func (m *MongoMapper) DistinctImagesSince(ctx context.Context, since time.Time) ([]string, error) {
+	return upload.Strings(m.conn.Distinct(ctx, "images", bson.M{"create_time": bson.M{"$gte": since}}))
+}
@@ -0,0 +1,83 @@
+package guardian
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "parent_child"
+
+// Binding 家长与孩子账号的绑定关系
+type Binding struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ParentID   string             `bson:"parent_id" json:"parentId"`
+	ChildID    string             `bson:"child_id" json:"childId"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, b *Binding) error {
+	if b.ID.IsZero() {
+		b.ID = primitive.NewObjectID()
+		b.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, b)
+	return err
+}
+
+// FindOne 查询家长与孩子的绑定关系
+func (m *MongoMapper) FindOne(ctx context.Context, parentID, childID string) (*Binding, error) {
+	var b Binding
+	err := m.conn.FindOneNoCache(ctx, &b, bson.M{"parent_id": parentID, "child_id": childID})
+	switch {
+	case err == nil:
+		return &b, nil
+	case errors.Is(err, monc.ErrNotFound):
+		return nil, consts.ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// FindByParent 查询家长绑定的所有孩子
+func (m *MongoMapper) FindByParent(ctx context.Context, parentID string) ([]*Binding, error) {
+	data := make([]*Binding, 0)
+	err := m.conn.Find(ctx, &data, bson.M{"parent_id": parentID}, &options.FindOptions{
+		Sort: bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// CountByChild 统计孩子已绑定的家长数
+func (m *MongoMapper) CountByChild(ctx context.Context, childID string) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{"child_id": childID})
+}
+
+// CountByParent 统计家长已绑定的孩子数
+func (m *MongoMapper) CountByParent(ctx context.Context, parentID string) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{"parent_id": parentID})
+}
+
+func (m *MongoMapper) Delete(ctx context.Context, parentID, childID string) error {
+	_, err := m.conn.DeleteOneNoCache(ctx, bson.M{"parent_id": parentID, "child_id": childID})
+	return err
+}
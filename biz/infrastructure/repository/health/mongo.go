@@ -0,0 +1,29 @@
+package health
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// MongoPinger 健康检查用的 Mongo 连接，mon 的 Model 不暴露底层 client，单独建一个
+type MongoPinger struct {
+	cli *mongo.Client
+}
+
+func NewMongoPinger(config *config.Config) *MongoPinger {
+	// Connect 不会真正建立连接，Mongo 不可用时不影响启动
+	cli, err := mongo.Connect(context.Background(), options.Client().ApplyURI(config.Mongo.URL))
+	if err != nil {
+		panic(err)
+	}
+	return &MongoPinger{cli: cli}
+}
+
+// Ping 检查主节点是否可用
+func (p *MongoPinger) Ping(ctx context.Context) error {
+	return p.cli.Ping(ctx, readpref.Primary())
+}
@@ -5,6 +5,7 @@ import (
 	"essay-show/biz/application/dto/essay/show"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/softdelete"
 	"essay-show/biz/infrastructure/util/log"
 	"time"
 
@@ -38,6 +39,16 @@ type Homework struct {
 	// 阅读作业内容
 	ReadingContent *show.ReadingContent `bson:"reading_content,omitempty" json:"readingContent,omitempty"`
 
+	// 书写工整度是否纳入总评，纳入时批改得分按工整度扣除卷面分
+	NeatnessInTotal bool `bson:"neatness_in_total,omitempty" json:"neatnessInTotal,omitempty"`
+
+	// 字数要求，为 0 时不限制
+	MinWords int64 `bson:"min_words,omitempty" json:"minWords,omitempty"`
+	MaxWords int64 `bson:"max_words,omitempty" json:"maxWords,omitempty"`
+
+	// 全班批改完成汇总通知的发送时间，用于避免重复推送
+	GradeNotifyTime time.Time `bson:"grade_notify_time,omitempty" json:"-"`
+
 	CreateTime time.Time `bson:"create_time" json:"createTime"`
 	UpdateTime time.Time `bson:"update_time" json:"updateTime"`
 	DeleteTime time.Time `bson:"delete_time,omitempty" json:"deleteTime"`
@@ -53,7 +64,7 @@ type MongoMapper struct {
 }
 
 func NewMongoMapper(config *config.Config) *MongoMapper {
-	log.Info("NewHomeworkMongoMapper config: %v, collection: %s", config, HomeworkCollectionName)
+	log.Info("NewHomeworkMongoMapper collection: %s", HomeworkCollectionName)
 	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, HomeworkCollectionName, config.Cache)
 	return &MongoMapper{
 		conn: conn,
@@ -76,15 +87,31 @@ func (m *MongoMapper) Update(ctx context.Context, homework *Homework) error {
 	return err
 }
 
-func (m *MongoMapper) FindOne(ctx context.Context, id string) (*Homework, error) {
+// MarkGradeNotified 标记已发送全班批改完成通知，返回 false 表示此前已经标记过
+func (m *MongoMapper) MarkGradeNotified(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		consts.ID:           id,
+		"grade_notify_time": bson.M{"$exists": false},
+	}
+	result, err := m.conn.UpdateOneNoCache(ctx, filter, bson.M{
+		"$set": bson.M{"grade_notify_time": time.Now()},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FindOne 默认只查未删除的作业，scope 为 softdelete.All 时可查到已删除的作业
+func (m *MongoMapper) FindOne(ctx context.Context, id string, scope ...softdelete.Scope) (*Homework, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, consts.ErrInvalidObjectId
 	}
 	var h Homework
-	err = m.conn.FindOneNoCache(ctx, &h, bson.M{
+	err = m.conn.FindOneNoCache(ctx, &h, softdelete.Filter(bson.M{
 		consts.ID: oid,
-	})
+	}, scope...))
 	if err != nil {
 		return nil, consts.ErrNotFound
 	}
@@ -93,9 +120,9 @@ func (m *MongoMapper) FindOne(ctx context.Context, id string) (*Homework, error)
 
 func (m *MongoMapper) FindByClassID(ctx context.Context, classID string, page, pageSize int64) ([]*Homework, int64, error) {
 	var homeworks []*Homework
-	filter := bson.M{}
+	filter := softdelete.Filter(bson.M{})
 	if classID != "" {
-		filter = bson.M{"class_id": classID}
+		filter["class_id"] = classID
 	}
 
 	// 获取总数
@@ -123,13 +150,18 @@ func (m *MongoMapper) Delete(ctx context.Context, id string) error {
 	if err != nil {
 		return consts.ErrInvalidObjectId
 	}
-	_, err = m.conn.DeleteOneNoCache(ctx, bson.M{consts.ID: oid})
+	_, err = m.conn.UpdateOneNoCache(ctx, softdelete.Filter(bson.M{consts.ID: oid}), softdelete.Update())
 	return err
 }
 
-func (m *MongoMapper) FindHomeworks(ctx context.Context, page, pageSize int64, topic *int64, startTime, endTime *int64) ([]*Homework, int64, error) {
+// Purge 物理删除软删除时间早于 before 的作业
+func (m *MongoMapper) Purge(ctx context.Context, before time.Time) (int64, error) {
+	return m.conn.DeleteMany(ctx, softdelete.PurgeFilter(before))
+}
+
+func (m *MongoMapper) FindHomeworks(ctx context.Context, page, pageSize int64, topic *int64, startTime, endTime *int64, scope ...softdelete.Scope) ([]*Homework, int64, error) {
 	var homeworks []*Homework
-	filter := bson.M{}
+	filter := softdelete.Filter(bson.M{}, scope...)
 	if startTime != nil {
 		filter["create_time"] = bson.M{"$gte": time.Unix(*startTime, 0)}
 	}
@@ -158,3 +190,47 @@ func (m *MongoMapper) FindHomeworks(ctx context.Context, page, pageSize int64, t
 	}
 	return homeworks, total, nil
 }
+
+// FindRecentByCreator 查询教师最近布置的作业，按布置时间倒序
+func (m *MongoMapper) FindRecentByCreator(ctx context.Context, creatorID string, limit int64) ([]*Homework, error) {
+	var homeworks []*Homework
+	err := m.conn.Find(ctx, &homeworks, softdelete.Filter(bson.M{
+		"creator_id": creatorID,
+	}), &options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return homeworks, nil
+}
+
+// FindAllByTime 查询全部班级在 [start, end) 内布置的作业，按布置时间正序
+func (m *MongoMapper) FindAllByTime(ctx context.Context, start, end time.Time) ([]*Homework, error) {
+	var homeworks []*Homework
+	err := m.conn.Find(ctx, &homeworks, softdelete.Filter(bson.M{
+		consts.CreateTime: bson.M{"$gte": start, "$lt": end},
+	}), &options.FindOptions{
+		Sort: bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return homeworks, nil
+}
+
+// FindAllByClassAndTime 查询班级在 [start, end) 内布置的全部作业，按布置时间正序
+func (m *MongoMapper) FindAllByClassAndTime(ctx context.Context, classID string, start, end time.Time) ([]*Homework, error) {
+	var homeworks []*Homework
+	err := m.conn.Find(ctx, &homeworks, softdelete.Filter(bson.M{
+		"class_id":        classID,
+		consts.CreateTime: bson.M{"$gte": start, "$lt": end},
+	}), &options.FindOptions{
+		Sort: bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return homeworks, nil
+}
@@ -0,0 +1,96 @@
+package homework
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// LessonPlan 教师基于某次作业的已批改提交生成的讲评教案
+type LessonPlan struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	HomeworkID    string             `bson:"homework_id" json:"homeworkId"`
+	ClassID       string             `bson:"class_id" json:"classId"`
+	CreatorID     string             `bson:"creator_id" json:"creatorId"`
+	SubmissionIDs []string           `bson:"submission_ids" json:"submissionIds"`    // 生成教案使用的提交
+	Duration      int64              `bson:"duration" json:"duration"`               // 课时时长（分钟）
+	Focus         string             `bson:"focus,omitempty" json:"focus,omitempty"` // 讲评侧重点
+	Url           string             `bson:"url" json:"url"`
+	SessionToken  string             `bson:"session_token" json:"sessionToken"`
+	CreateTime    time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime    time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+const (
+	LessonPlanCollectionName = "lesson_plan"
+)
+
+type LessonPlanMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewLessonPlanMongoMapper(config *config.Config) *LessonPlanMongoMapper {
+	log.Info("NewLessonPlanMongoMapper collection: %s", LessonPlanCollectionName)
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, LessonPlanCollectionName, config.Cache)
+	return &LessonPlanMongoMapper{
+		conn: conn,
+	}
+}
+
+func (m *LessonPlanMongoMapper) Insert(ctx context.Context, plan *LessonPlan) error {
+	if plan.ID.IsZero() {
+		plan.ID = primitive.NewObjectID()
+		plan.CreateTime = time.Now()
+		plan.UpdateTime = plan.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, plan)
+	return err
+}
+
+func (m *LessonPlanMongoMapper) FindOne(ctx context.Context, id string) (*LessonPlan, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var p LessonPlan
+	err = m.conn.FindOneNoCache(ctx, &p, bson.M{
+		consts.ID: oid,
+	})
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &p, nil
+}
+
+// FindByCreator 分页查询教师生成的教案，homeworkID 不为空时只查该作业的，按生成时间倒序
+func (m *LessonPlanMongoMapper) FindByCreator(ctx context.Context, creatorID, homeworkID string, p *basic.PaginationOptions) ([]*LessonPlan, int64, error) {
+	filter := bson.M{"creator_id": creatorID}
+	if homeworkID != "" {
+		filter["homework_id"] = homeworkID
+	}
+
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*LessonPlan, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
@@ -0,0 +1,75 @@
+package homework
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ClassReport 班级整册批改报告的生成任务，报告由下游异步生成，完成后回填下载链接
+type ClassReport struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ClassID      string             `bson:"class_id" json:"classId"`
+	CreatorID    string             `bson:"creator_id" json:"creatorId"`
+	StartTime    time.Time          `bson:"start_time" json:"startTime"`
+	EndTime      time.Time          `bson:"end_time" json:"endTime"`
+	Status       int                `bson:"status" json:"status"` // 0: 生成中, 1: 已完成, 2: 生成失败
+	Url          string             `bson:"url,omitempty" json:"url,omitempty"`
+	SessionToken string             `bson:"session_token,omitempty" json:"sessionToken,omitempty"`
+	Message      string             `bson:"message,omitempty" json:"message,omitempty"` // 失败原因
+	CreateTime   time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime   time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+const (
+	ReportCollectionName = "class_report"
+)
+
+type ReportMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewReportMongoMapper(config *config.Config) *ReportMongoMapper {
+	log.Info("NewReportMongoMapper collection: %s", ReportCollectionName)
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, ReportCollectionName, config.Cache)
+	return &ReportMongoMapper{
+		conn: conn,
+	}
+}
+
+func (m *ReportMongoMapper) Insert(ctx context.Context, report *ClassReport) error {
+	if report.ID.IsZero() {
+		report.ID = primitive.NewObjectID()
+		report.CreateTime = time.Now()
+		report.UpdateTime = report.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, report)
+	return err
+}
+
+func (m *ReportMongoMapper) Update(ctx context.Context, report *ClassReport) error {
+	report.UpdateTime = time.Now()
+	_, err := m.conn.UpdateByIDNoCache(ctx, report.ID, bson.M{"$set": report})
+	return err
+}
+
+func (m *ReportMongoMapper) FindOne(ctx context.Context, id string) (*ClassReport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var r ClassReport
+	err = m.conn.FindOneNoCache(ctx, &r, bson.M{
+		consts.ID: oid,
+	})
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &r, nil
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/archive"
+	"essay-show/biz/infrastructure/repository/upload"
 	"essay-show/biz/infrastructure/util/log"
 	"time"
 
@@ -20,17 +22,44 @@ type HomeworkSubmission struct {
 	HomeworkID  string             `bson:"homework_id" json:"homeworkId"`
 	MemberId    string             `bson:"member_id" json:"memberId"`
 	TeacherID   string             `bson:"teacher_id" json:"teacherId"`
-	Images      []string           `bson:"images" json:"images"`
+	Images      []string           `bson:"images" json:"images"` // 作文图片或 PDF 扫描件，PDF 批改时按页识别
 	GradeResult string             `bson:"grade_result" json:"gradeResult"`
 	Title       string             `bson:"title" json:"title"`
 	Text        string             `bson:"text" json:"text"`
 	Response    string             `bson:"response" json:"response"`
 	Message     string             `bson:"message" json:"message"`
-	Status      int                `bson:"status" json:"status"`          // 0: 初始化, 1: 批改中, 2: 批改完成, 3: 批改已人工修改, 7:批改失败
+	Status      int                `bson:"status" json:"status"`          // 0: 初始化, 1: 批改中, 2: 批改完成, 3: 批改已人工修改, 4: 等待确认文本, 7:批改失败
 	SubmitType  int                `bson:"submit_type" json:"submitType"` // 0: 首次提交, 1: 重批：上传图片提交, 2: 重批：修改原文提交 3: 小项重批
 	Aspect      string             `bson:"aspect" json:"aspect"`
-	CreateTime  time.Time          `bson:"create_time" json:"createTime"`
-	UpdateTime  time.Time          `bson:"update_time" json:"updateTime"`
+	// ConfirmText 为 true 时识别完成后先等待学生确认文本，TextConfirmed 表示文本已确认，批改时不再识别
+	ConfirmText   bool      `bson:"confirm_text,omitempty" json:"confirmText,omitempty"`
+	TextConfirmed bool      `bson:"text_confirmed,omitempty" json:"textConfirmed,omitempty"`
+	TextSubmit    bool      `bson:"text_submit,omitempty" json:"textSubmit,omitempty"` // 纯文本提交，没有图片，批改时直接使用 Title、Text
+	CreateTime    time.Time `bson:"create_time" json:"createTime"`
+	UpdateTime    time.Time `bson:"update_time" json:"updateTime"`
+	// Owner 领取批改的实例，LeaseExpire 之前由该实例独占，过期后其他实例可以接管
+	Owner       string    `bson:"owner,omitempty" json:"-"`
+	LeaseExpire time.Time `bson:"lease_expire,omitempty" json:"-"`
+	// PartialResponse 批改过程中已收到的分步结果，键为下游的 step（word_sentence、score 等），
+	// 只由 SavePartial 增量写入，实例崩溃后接管的实例可据此恢复，批改结束时清除
+	PartialResponse map[string]any `bson:"partial_response,omitempty" json:"-"`
+	// ResponseArchive Response 冷归档后的 cos 对象路径，归档后 Response 置空，读取时回源
+	ResponseArchive string `bson:"response_archive,omitempty" json:"-"`
+	// ImagesPurgeTime 原图按保留策略从 cos 删除的时间，删除后 Images 置空
+	ImagesPurgeTime time.Time `bson:"images_purge_time,omitempty" json:"imagesPurgeTime,omitempty"`
+	// PurgingImages 原图清理已认领、正在删除的图片，认领时从 Images 移出，删除结束后清除；清理中断时下次继续删除
+	PurgingImages []string `bson:"purging_images,omitempty" json:"-"`
+	// AiScore 作文疑似 AI 生成的程度，0~1，未检测或检测失败时为空
+	AiScore *float64 `bson:"ai_score,omitempty" json:"aiScore,omitempty"`
+	// Neatness 书写工整度，0~100，由 OCR 字符置信度计算，纯文本提交或下游不支持时为空；
+	// NeatnessDeduction 作业设置纳入总评时从 GradeResult 中扣除的卷面分
+	Neatness          *float64 `bson:"neatness,omitempty" json:"neatness,omitempty"`
+	NeatnessDeduction float64  `bson:"neatness_deduction,omitempty" json:"neatnessDeduction,omitempty"`
+	// WordCount 批改前统计的字数，WordLimitMessage 不符合作业字数要求时的标注，符合时为空
+	WordCount        int64  `bson:"word_count,omitempty" json:"wordCount,omitempty"`
+	WordLimitMessage string `bson:"word_limit_message,omitempty" json:"wordLimitMessage,omitempty"`
+	// Subject 批改时作业的学科，决定 Response 的结构，见 consts.Subject*
+	Subject int64 `bson:"subject,omitempty" json:"subject"`
 }
 
 const (
@@ -39,17 +68,28 @@ const (
 )
 
 type SubmissionMongoMapper struct {
-	conn *monc.Model
+	conn    *monc.Model
+	archive archive.Store
 }
 
-func NewSubmissionMongoMapper(config *config.Config) *SubmissionMongoMapper {
-	log.Info("NewSubmissionMongoMapper config: %v, collection: %s", config, SubmissionCollectionName)
+func NewSubmissionMongoMapper(config *config.Config, store archive.Store) *SubmissionMongoMapper {
+	log.Info("NewSubmissionMongoMapper collection: %s", SubmissionCollectionName)
 	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, SubmissionCollectionName, config.Cache)
 	return &SubmissionMongoMapper{
-		conn: conn,
+		conn:    conn,
+		archive: store,
 	}
 }
 
+// restore 已归档的批改结果从 cos 回源，列表按 consts.ArchiveRestoreConcurrency 并发回源
+func (m *SubmissionMongoMapper) restore(ctx context.Context, submissions ...*HomeworkSubmission) {
+	targets := make([]archive.Target, 0, len(submissions))
+	for _, s := range submissions {
+		targets = append(targets, archive.Target{Field: &s.Response, Key: s.ResponseArchive})
+	}
+	archive.RestoreAll(ctx, m.archive, targets)
+}
+
 func (m *SubmissionMongoMapper) Insert(ctx context.Context, submission *HomeworkSubmission) error {
 	if submission.ID.IsZero() {
 		submission.ID = primitive.NewObjectID()
@@ -87,6 +127,7 @@ func (m *SubmissionMongoMapper) FindOne(ctx context.Context, id string) (*Homewo
 	if err != nil {
 		return nil, consts.ErrNotFound
 	}
+	m.restore(ctx, &s)
 	return &s, nil
 }
 
@@ -115,6 +156,7 @@ func (m *SubmissionMongoMapper) FindByHomeworkID(ctx context.Context, homeworkID
 	if err != nil {
 		return nil, err
 	}
+	m.restore(ctx, submissions...)
 
 	return submissions, nil
 }
@@ -132,6 +174,7 @@ func (m *SubmissionMongoMapper) FindAllByHomework(ctx context.Context, homeworkI
 	if err != nil {
 		return nil, err
 	}
+	m.restore(ctx, submissions...)
 	return submissions, nil
 }
 
@@ -148,6 +191,7 @@ func (m *SubmissionMongoMapper) FindLatestByMemberAndHomework(ctx context.Contex
 	})
 	switch {
 	case err == nil:
+		m.restore(ctx, &submission)
 		return &submission, nil
 	case errors.Is(err, mongo.ErrNoDocuments):
 		return nil, consts.ErrNotFound
@@ -178,6 +222,7 @@ func (m *SubmissionMongoMapper) FindByMemberAndHomework(ctx context.Context, mem
 	if err != nil {
 		return nil, 0, err
 	}
+	m.restore(ctx, submissions...)
 	return submissions, total, nil
 }
 
@@ -194,9 +239,76 @@ func (m *SubmissionMongoMapper) FindAllByMemberAndHomework(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+	m.restore(ctx, submissions...)
+	return submissions, nil
+}
+
+// FindCompletedByMember 查询学生全部已完成批改的提交，按提交时间正序
+func (m *SubmissionMongoMapper) FindCompletedByMember(ctx context.Context, memberID string) ([]*HomeworkSubmission, error) {
+	var submissions = make([]*HomeworkSubmission, 0)
+	err := m.conn.Find(ctx, &submissions, bson.M{
+		"member_id": memberID,
+		"status":    bson.M{"$in": []int{consts.StatusCompleted, consts.StatusModified}},
+	}, &options.FindOptions{
+		Sort: bson.M{"create_time": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.restore(ctx, submissions...)
+	return submissions, nil
+}
+
+// FindAllByMember 查询学生全部提交，不区分状态，按提交时间正序
+func (m *SubmissionMongoMapper) FindAllByMember(ctx context.Context, memberID string) ([]*HomeworkSubmission, error) {
+	var submissions = make([]*HomeworkSubmission, 0)
+	err := m.conn.Find(ctx, &submissions, bson.M{"member_id": memberID}, &options.FindOptions{
+		Sort: bson.M{"create_time": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.restore(ctx, submissions...)
 	return submissions, nil
 }
 
+// CountByTeacher 统计教师名下指定状态的提交数，since 不为零值时只统计此后更新过的提交
+func (m *SubmissionMongoMapper) CountByTeacher(ctx context.Context, teacherID string, status []int, since time.Time) (int64, error) {
+	filter := bson.M{
+		"teacher_id": teacherID,
+		"status":     bson.M{"$in": status},
+	}
+	if !since.IsZero() {
+		filter["update_time"] = bson.M{"$gte": since}
+	}
+	return m.conn.CountDocuments(ctx, filter)
+}
+
+// CountOwned 统计 ids 中属于该教师的提交数，用于批量操作前的归属校验
+func (m *SubmissionMongoMapper) CountOwned(ctx context.Context, teacherID string, ids []string) (int64, error) {
+	oids := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return 0, consts.ErrInvalidObjectId
+		}
+		oids = append(oids, oid)
+	}
+	return m.conn.CountDocuments(ctx, bson.M{
+		consts.ID:    bson.M{"$in": oids},
+		"teacher_id": teacherID,
+	})
+}
+
+// CountMembersByHomework 统计作业下提交过的学生数，同一学生多次提交只算一次
+func (m *SubmissionMongoMapper) CountMembersByHomework(ctx context.Context, homeworkID string) (int64, error) {
+	ids, err := m.conn.Distinct(ctx, "member_id", bson.M{"homework_id": homeworkID})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
 // FindByStatus 根据状态查找作业提交
 func (m *SubmissionMongoMapper) FindByStatus(ctx context.Context, status []int) ([]*HomeworkSubmission, error) {
 	var submissions []*HomeworkSubmission
@@ -250,3 +362,240 @@ func (m *SubmissionMongoMapper) TryUpdateStatusToGrading(ctx context.Context, id
 	// 如果 ModifiedCount > 0，说明更新成功
 	return result.ModifiedCount > 0, nil
 }
+
+// ConfirmText 学生确认识别文本，仅当提交仍在等待确认文本时写入标题、正文并置为待批改，
+// 已被确认或状态已变化时返回 false
+func (m *SubmissionMongoMapper) ConfirmText(ctx context.Context, id primitive.ObjectID, title, text string) (bool, error) {
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"_id":    id,
+		"status": consts.StatusPendingText,
+	}, bson.M{"$set": bson.M{
+		"title":          title,
+		"text":           text,
+		"text_confirmed": true,
+		"status":         consts.StatusInitialized,
+		"update_time":    time.Now(),
+	}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Claim 原子地领取一个待批改或租约已过期的提交，置为批改中并记录 owner 和租约到期时间；
+// 已被其他实例领取时返回 nil
+func (m *SubmissionMongoMapper) Claim(ctx context.Context, id primitive.ObjectID, owner string, lease time.Duration) (*HomeworkSubmission, error) {
+	now := time.Now()
+	filter := bson.M{
+		"_id": id,
+		"$or": bson.A{
+			bson.M{"status": consts.StatusInitialized},
+			bson.M{"status": consts.StatusGrading, "lease_expire": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{
+		"status":       consts.StatusGrading,
+		"owner":        owner,
+		"lease_expire": now.Add(lease),
+		"update_time":  now,
+	}}
+	var submission HomeworkSubmission
+	err := m.conn.FindOneAndUpdateNoCache(ctx, &submission, filter, update, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &submission, nil
+}
+
+// RenewLease 延长 owner 持有的批改租约，租约已被其他实例接管时返回 false
+func (m *SubmissionMongoMapper) RenewLease(ctx context.Context, id primitive.ObjectID, owner string, lease time.Duration) (bool, error) {
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"_id":    id,
+		"owner":  owner,
+		"status": consts.StatusGrading,
+	}, bson.M{"$set": bson.M{"lease_expire": time.Now().Add(lease)}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// UpdateOwned 仅当提交仍由 submission.Owner 持有时更新，批改过程中的写入使用，避免被接管后覆盖新 owner 的结果；
+// Owner 为空时等同于 Update，更新为完成或失败时同时清除中间结果
+func (m *SubmissionMongoMapper) UpdateOwned(ctx context.Context, submission *HomeworkSubmission) (bool, error) {
+	filter := bson.M{consts.ID: submission.ID}
+	if submission.Owner != "" {
+		filter["owner"] = submission.Owner
+	}
+	submission.UpdateTime = time.Now()
+	// 租约只由 Claim 和 RenewLease 维护，中间结果只由 SavePartial 写入，这里不覆盖
+	doc := *submission
+	doc.LeaseExpire = time.Time{}
+	doc.PartialResponse = nil
+	update := bson.M{"$set": &doc}
+	if submission.Status == consts.StatusCompleted || submission.Status == consts.StatusFailed {
+		update["$unset"] = bson.M{"partial_response": ""}
+		submission.PartialResponse = nil
+	}
+	result, err := m.conn.UpdateOneNoCache(ctx, filter, update)
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// SavePartial 增量写入一步批改中间结果，提交已被其他实例接管时返回 false
+func (m *SubmissionMongoMapper) SavePartial(ctx context.Context, id primitive.ObjectID, owner, step string, data any) (bool, error) {
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"_id":    id,
+		"owner":  owner,
+		"status": consts.StatusGrading,
+	}, bson.M{"$set": bson.M{"partial_response." + step: data}})
+	if err != nil {
+		return false, err
+	}
+	return result.MatchedCount > 0, nil
+}
+
+// Release owner 放弃批改中的提交，重置为待批改并清除 owner 和租约，之后原 owner 的写入不再生效
+func (m *SubmissionMongoMapper) Release(ctx context.Context, id primitive.ObjectID, owner string) (bool, error) {
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"_id":    id,
+		"owner":  owner,
+		"status": consts.StatusGrading,
+	}, bson.M{
+		"$set":   bson.M{"status": consts.StatusInitialized, "update_time": time.Now()},
+		"$unset": bson.M{"owner": "", "lease_expire": ""},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FindExpiredLeases 查询租约已过期仍处于批改中的提交，领取它们的实例可能已宕机
+func (m *SubmissionMongoMapper) FindExpiredLeases(ctx context.Context, now time.Time) ([]*HomeworkSubmission, error) {
+	var submissions []*HomeworkSubmission
+	err := m.conn.Find(ctx, &submissions, bson.M{
+		"status":       consts.StatusGrading,
+		"lease_expire": bson.M{"$lt": now},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// FindArchivable 查询 before 之前创建、批改已结束且结果还未归档的提交，按创建时间正序
+func (m *SubmissionMongoMapper) FindArchivable(ctx context.Context, before time.Time, limit int64) ([]*HomeworkSubmission, error) {
+	submissions := make([]*HomeworkSubmission, 0, limit)
+	err := m.conn.Find(ctx, &submissions, bson.M{
+		"status":      bson.M{"$in": []int{consts.StatusCompleted, consts.StatusModified, consts.StatusFailed}},
+		"create_time": bson.M{"$lt": before},
+		"response":    bson.M{"$ne": ""},
+	}, &options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.M{"create_time": 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// Archive 批改结果已转存到 key 后清空 Response，查询后提交被更新过（重批、修改等）时不生效，返回是否已归档
+func (m *SubmissionMongoMapper) Archive(ctx context.Context, submission *HomeworkSubmission, key string) (bool, error) {
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"_id":         submission.ID,
+		"update_time": submission.UpdateTime,
+	}, bson.M{
+		"$set": bson.M{"response": "", "response_archive": key},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// FindImageExpired 查询批改结束于 before 之前、原图还未清理或清理中断的提交，按更新时间正序
+func (m *SubmissionMongoMapper) FindImageExpired(ctx context.Context, status []int, before time.Time, limit int64) ([]*HomeworkSubmission, error) {
+	submissions := make([]*HomeworkSubmission, 0, limit)
+	err := m.conn.Find(ctx, &submissions, bson.M{
+		"status":      bson.M{"$in": status},
+		"update_time": bson.M{"$lt": before},
+		"$or": bson.A{
+			bson.M{"images.0": bson.M{"$exists": true}},
+			bson.M{"purging_images.0": bson.M{"$exists": true}},
+		},
+	}, &options.FindOptions{
+		Limit:      &limit,
+		Sort:       bson.M{"update_time": 1},
+		Projection: bson.M{"response": 0, "partial_response": 0},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
+
+// ClaimImages 删除原图前认领：把 Images 移入 PurgingImages（与上次中断时未删完的合并），
+// 查询后提交被更新过（重批、修改等）时不生效，返回是否认领成功，成功后 submission.PurgingImages 为待删除的图片
+func (m *SubmissionMongoMapper) ClaimImages(ctx context.Context, submission *HomeworkSubmission) (bool, error) {
+	purging := append(append([]string{}, submission.PurgingImages...), submission.Images...)
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"_id":         submission.ID,
+		"update_time": submission.UpdateTime,
+	}, bson.M{
+		"$set": bson.M{"images": []string{}, "purging_images": purging},
+	})
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+	submission.PurgingImages = purging
+	submission.Images = []string{}
+	return true, nil
+}
+
+// FinishPurgeImages 原图删除结束，删除失败的图片 remain 放回 Images 等下次重试，清除认领并记录清理时间
+func (m *SubmissionMongoMapper) FinishPurgeImages(ctx context.Context, id primitive.ObjectID, remain []string) error {
+	update := bson.M{
+		"$set":   bson.M{"images_purge_time": time.Now()},
+		"$unset": bson.M{"purging_images": ""},
+	}
+	if len(remain) > 0 {
+		update["$push"] = bson.M{"images": bson.M{"$each": remain}}
+	}
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// DistinctImagesSince 返回 since 之后创建或重新提交的作业引用的图片 url，用于孤儿文件对账
+func (m *SubmissionMongoMapper) DistinctImagesSince(ctx context.Context, since time.Time) ([]string, error) {
+	return upload.Strings(m.conn.Distinct(ctx, "images", bson.M{"update_time": bson.M{"$gte": since}}))
+}
+
+// CountByStatusAndTime 统计时间区间内处于指定状态的提交数
+func (m *SubmissionMongoMapper) CountByStatusAndTime(ctx context.Context, status []int, start, end time.Time) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{
+		"status":      bson.M{"$in": status},
+		"create_time": bson.M{"$gte": start, "$lt": end},
+	})
+}
+
+// FindByCreateTime 查询时间区间内创建的全部提交，用于每日提交汇总
+func (m *SubmissionMongoMapper) FindByCreateTime(ctx context.Context, start, end time.Time) ([]*HomeworkSubmission, error) {
+	var submissions []*HomeworkSubmission
+	err := m.conn.Find(ctx, &submissions, bson.M{
+		"create_time": bson.M{"$gte": start, "$lt": end},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return submissions, nil
+}
@@ -0,0 +1,116 @@
+package homework
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/util/log"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// WeeklyReport 班级学情周报，每周定时汇总本周布置的作业，同一班级同一周只保留一份
+type WeeklyReport struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	ClassID        string             `bson:"class_id" json:"classId"`
+	CreatorID      string             `bson:"creator_id" json:"creatorId"`
+	WeekStart      time.Time          `bson:"week_start" json:"weekStart"`
+	WeekEnd        time.Time          `bson:"week_end" json:"weekEnd"`
+	MemberCount    int64              `bson:"member_count" json:"memberCount"`
+	SubmitRate     float64            `bson:"submit_rate" json:"submitRate"`     // 各作业提交率的平均值
+	AverageScore   float64            `bson:"average_score" json:"averageScore"` // 本周全部已批改提交的平均分
+	Homeworks      []*WeeklyHomework  `bson:"homeworks" json:"homeworks"`
+	CommonProblems []string           `bson:"common_problems" json:"commonProblems"` // 全班得分率偏低的分项
+	CreateTime     time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime     time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+// WeeklyHomework 周报中单份作业的统计
+type WeeklyHomework struct {
+	HomeworkID   string  `bson:"homework_id" json:"homeworkId"`
+	Title        string  `bson:"title" json:"title"`
+	Submitted    int64   `bson:"submitted" json:"submitted"`
+	SubmitRate   float64 `bson:"submit_rate" json:"submitRate"`
+	AverageScore float64 `bson:"average_score" json:"averageScore"`
+}
+
+const (
+	WeeklyReportCollectionName = "weekly_report"
+)
+
+type WeeklyReportMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewWeeklyReportMongoMapper(config *config.Config) *WeeklyReportMongoMapper {
+	log.Info("NewWeeklyReportMongoMapper collection: %s", WeeklyReportCollectionName)
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, WeeklyReportCollectionName, config.Cache)
+	return &WeeklyReportMongoMapper{
+		conn: conn,
+	}
+}
+
+// Upsert 按班级和周起始时间写入周报，重复生成时覆盖统计结果
+func (m *WeeklyReportMongoMapper) Upsert(ctx context.Context, report *WeeklyReport) error {
+	now := time.Now()
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		"class_id":   report.ClassID,
+		"week_start": report.WeekStart,
+	}, bson.M{
+		"$set": bson.M{
+			"creator_id":      report.CreatorID,
+			"week_end":        report.WeekEnd,
+			"member_count":    report.MemberCount,
+			"submit_rate":     report.SubmitRate,
+			"average_score":   report.AverageScore,
+			"homeworks":       report.Homeworks,
+			"common_problems": report.CommonProblems,
+			"update_time":     now,
+		},
+		"$setOnInsert": bson.M{
+			consts.CreateTime: now,
+		},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+func (m *WeeklyReportMongoMapper) FindOne(ctx context.Context, id string) (*WeeklyReport, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var r WeeklyReport
+	err = m.conn.FindOneNoCache(ctx, &r, bson.M{
+		consts.ID: oid,
+	})
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &r, nil
+}
+
+// FindByClass 分页查询班级的历史周报，按周倒序
+func (m *WeeklyReportMongoMapper) FindByClass(ctx context.Context, classID string, p *basic.PaginationOptions) ([]*WeeklyReport, int64, error) {
+	filter := bson.M{"class_id": classID}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*WeeklyReport, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{"week_start": -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
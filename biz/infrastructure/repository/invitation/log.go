@@ -11,5 +11,8 @@ type Log struct {
 	Inviter   string             `bson:"inviter"`
 	Invitee   string             `bson:"invitee"`
 	Source    *string            `bson:"source,omitempty"`
+	Reward    int64              `bson:"reward,omitempty"` // 邀请人因本次邀请获得的次数
+	DeviceId  string             `bson:"device_id,omitempty"`
+	IP        string             `bson:"ip,omitempty"`
 	Timestamp time.Time          `bson:"timestamp"`
 }
@@ -2,25 +2,39 @@ package invitation
 
 import (
 	"errors"
+	"essay-show/biz/application/dto/basic"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
 	"time"
 
 	"github.com/zeromicro/go-zero/core/stores/monc"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"golang.org/x/net/context"
 )
 
 const (
 	logPrefixUserCacheKey = "cache:invitation_log"
-	logCollectionName     = "invitation_log"
+	LogCollectionName     = "invitation_log"
 )
 
 type ILogMongoMapper interface {
-	Insert(ctx context.Context, inviter string, invitee string) error
+	Insert(ctx context.Context, l *Log) error
 	FindOneByInvitee(ctx context.Context, invitee string) (*Log, error)
+	CountByInviter(ctx context.Context, inviter string) (int64, error)
+	CountByDevice(ctx context.Context, deviceId string) (int64, error)
+	CountByIP(ctx context.Context, ip string, since time.Time) (int64, error)
+	StatByInviter(ctx context.Context, inviter string) (*InviterStat, error)
+	FindByInviter(ctx context.Context, inviter string, p *basic.PaginationOptions) ([]*Log, int64, error)
+}
+
+// InviterStat 邀请人维度的聚合结果
+type InviterStat struct {
+	Count  int64 `bson:"count"`
+	Reward int64 `bson:"reward"`
 }
 
 type LogMongoMapper struct {
@@ -28,21 +42,18 @@ type LogMongoMapper struct {
 }
 
 func NewLogMongoMapper(config *config.Config) *LogMongoMapper {
-	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, logCollectionName, config.Cache)
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, LogCollectionName, config.Cache)
 	return &LogMongoMapper{
 		conn: conn,
 	}
 }
 
-func (m *LogMongoMapper) Insert(ctx context.Context, inviter string, invitee string, source *string) error {
-	l := Log{
-		ID:        primitive.NewObjectID(),
-		Inviter:   inviter,
-		Invitee:   invitee,
-		Source:    source,
-		Timestamp: time.Now(),
+func (m *LogMongoMapper) Insert(ctx context.Context, l *Log) error {
+	if l.ID.IsZero() {
+		l.ID = primitive.NewObjectID()
+		l.Timestamp = time.Now()
 	}
-	_, err := m.conn.InsertOneNoCache(ctx, &l)
+	_, err := m.conn.InsertOneNoCache(ctx, l)
 	return err
 }
 
@@ -58,3 +69,70 @@ func (m *LogMongoMapper) FindOneByInvitee(ctx context.Context, invitee string) (
 		return nil, err
 	}
 }
+
+// CountByInviter 统计邀请人累计邀请人数
+func (m *LogMongoMapper) CountByInviter(ctx context.Context, inviter string) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{"inviter": inviter})
+}
+
+// CountByDevice 统计同一设备填写邀请码的次数
+func (m *LogMongoMapper) CountByDevice(ctx context.Context, deviceId string) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{"device_id": deviceId})
+}
+
+// CountByIP 统计同一 IP 在指定时间之后填写邀请码的次数
+func (m *LogMongoMapper) CountByIP(ctx context.Context, ip string, since time.Time) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{"ip": ip, consts.Timestamp: bson.M{"$gte": since}})
+}
+
+// StatByInviter 聚合邀请人的邀请人数与累计奖励，早期记录没有 reward 字段时按默认奖励计
+func (m *LogMongoMapper) StatByInviter(ctx context.Context, inviter string) (*InviterStat, error) {
+	pipeline := bson.A{
+		bson.M{"$match": bson.M{"inviter": inviter}},
+		bson.M{"$group": bson.M{
+			"_id":    nil,
+			"count":  bson.M{"$sum": 1},
+			"reward": bson.M{"$sum": bson.M{"$ifNull": bson.A{"$reward", consts.InvitationReward}}},
+		}},
+	}
+	var result []*InviterStat
+	if err := m.conn.Aggregate(ctx, &result, pipeline); err != nil {
+		return nil, err
+	}
+	if len(result) == 0 {
+		return &InviterStat{}, nil
+	}
+	return result[0], nil
+}
+
+// FindAllByInviter 查询邀请人的全部邀请记录，按邀请时间正序
+func (m *LogMongoMapper) FindAllByInviter(ctx context.Context, inviter string) ([]*Log, error) {
+	logs := make([]*Log, 0)
+	err := m.conn.Find(ctx, &logs, bson.M{"inviter": inviter}, &options.FindOptions{
+		Sort: bson.M{consts.Timestamp: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// FindByInviter 分页查询邀请人的邀请记录，按邀请时间倒序
+func (m *LogMongoMapper) FindByInviter(ctx context.Context, inviter string, p *basic.PaginationOptions) ([]*Log, int64, error) {
+	filter := bson.M{"inviter": inviter}
+	skip, limit := util.ParsePageOpt(p)
+	logs := make([]*Log, 0, limit)
+	err := m.conn.Find(ctx, &logs, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.Timestamp: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
@@ -15,4 +15,6 @@ type Log struct {
 	Like       int64              `bson:"like" json:"like"`
 	Status     int                `bson:"status" json:"status"` // 0: 正常, 1: 已修改
 	CreateTime time.Time          `bson:"create_time,omitempty" json:"createTime"`
+	// ResponseArchive Response 冷归档后的 cos 对象路径，归档后 Response 置空，读取时回源
+	ResponseArchive string `bson:"response_archive,omitempty" json:"-"`
 }
@@ -5,6 +5,8 @@ import (
 	"essay-show/biz/application/dto/basic"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/archive"
+	"essay-show/biz/infrastructure/repository/upload"
 	util "essay-show/biz/infrastructure/util/page"
 	"time"
 
@@ -31,12 +33,22 @@ type IMongoMapper interface {
 type MongoMapper struct {
 	conn    *monc.Model
 	errConn *monc.Model
+	archive archive.Store
 }
 
-func NewMongoMapper(config *config.Config) *MongoMapper {
+func NewMongoMapper(config *config.Config, store archive.Store) *MongoMapper {
 	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, CollectionName, config.Cache)
 	errConn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, ErrCollectionName, config.Cache)
-	return &MongoMapper{conn: conn, errConn: errConn}
+	return &MongoMapper{conn: conn, errConn: errConn, archive: store}
+}
+
+// restore 已归档的批改结果从 cos 回源，列表按 consts.ArchiveRestoreConcurrency 并发回源
+func (m *MongoMapper) restore(ctx context.Context, logs ...*Log) {
+	targets := make([]archive.Target, 0, len(logs))
+	for _, l := range logs {
+		targets = append(targets, archive.Target{Field: &l.Response, Key: l.ResponseArchive})
+	}
+	archive.RestoreAll(ctx, m.archive, targets)
 }
 
 func (m *MongoMapper) Insert(ctx context.Context, l *Log) error {
@@ -72,6 +84,7 @@ func (m *MongoMapper) FindMany(ctx context.Context, userId string, p *basic.Pagi
 	if err != nil {
 		return nil, 0, err
 	}
+	m.restore(ctx, logs...)
 
 	total, err = m.conn.CountDocuments(ctx, bson.M{
 		consts.UserID: userId,
@@ -82,6 +95,19 @@ func (m *MongoMapper) FindMany(ctx context.Context, userId string, p *basic.Pagi
 	return logs, total, nil
 }
 
+// FindAllByUser 查询用户全部批改记录，按批改时间正序
+func (m *MongoMapper) FindAllByUser(ctx context.Context, userId string) ([]*Log, error) {
+	logs := make([]*Log, 0)
+	err := m.conn.Find(ctx, &logs, bson.M{consts.UserID: userId}, &options.FindOptions{
+		Sort: bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.restore(ctx, logs...)
+	return logs, nil
+}
+
 func (m *MongoMapper) FindOne(ctx context.Context, id string) (l *Log, err error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
@@ -97,7 +123,38 @@ func (m *MongoMapper) FindOne(ctx context.Context, id string) (l *Log, err error
 	l = &Log{}
 	//err = m.conn.FindOne(ctx, key, l, filter)
 	err = m.conn.FindOneNoCache(ctx, l, filter)
-	return l, err
+	if err != nil {
+		return l, err
+	}
+	m.restore(ctx, l)
+	return l, nil
+}
+
+// FindArchivable 查询 before 之前创建、批改结果还未归档的记录，按创建时间正序
+func (m *MongoMapper) FindArchivable(ctx context.Context, before time.Time, limit int64) ([]*Log, error) {
+	logs := make([]*Log, 0, limit)
+	err := m.conn.Find(ctx, &logs, bson.M{
+		consts.CreateTime: bson.M{"$lt": before},
+		"response":        bson.M{"$ne": ""},
+	}, &options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logs, nil
+}
+
+// Archive 批改结果已转存到 key 后清空 Response，只在 Response 未被修改时生效，返回是否已归档
+func (m *MongoMapper) Archive(ctx context.Context, l *Log, key string) (bool, error) {
+	result, err := m.conn.UpdateOne(ctx, prefixKeyCacheKey+l.ID.Hex(),
+		bson.M{consts.ID: l.ID, "response": l.Response},
+		bson.M{"$set": bson.M{"response": "", "response_archive": key}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
 }
 
 func (m *MongoMapper) Update(ctx context.Context, l *Log) error {
@@ -114,3 +171,46 @@ func (m *MongoMapper) Delete(ctx context.Context, id string) error {
 	_, err = m.conn.DeleteOneNoCache(ctx, bson.M{consts.ID: oid})
 	return err
 }
+
+// CountByTime 统计时间区间内的批改记录数
+func (m *MongoMapper) CountByTime(ctx context.Context, start, end time.Time) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{
+		consts.CreateTime: bson.M{"$gte": start, "$lt": end},
+	})
+}
+
+// CountUsersByTime 统计时间区间内发起过批改的用户数
+func (m *MongoMapper) CountUsersByTime(ctx context.Context, start, end time.Time) (int64, error) {
+	ids, err := m.conn.Distinct(ctx, consts.UserID, bson.M{
+		consts.CreateTime: bson.M{"$gte": start, "$lt": end},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(ids)), nil
+}
+
+// DistinctUsers 返回给定用户中发起过批改的用户
+func (m *MongoMapper) DistinctUsers(ctx context.Context, userIds []string) ([]string, error) {
+	if len(userIds) == 0 {
+		return nil, nil
+	}
+	ids, err := m.conn.Distinct(ctx, consts.UserID, bson.M{
+		consts.UserID: bson.M{"$in": userIds},
+	})
+	if err != nil {
+		return nil, err
+	}
+	users := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if uid, ok := id.(string); ok {
+			users = append(users, uid)
+		}
+	}
+	return users, nil
+}
+
+// DistinctImagesSince 返回 since 之后创建的批改记录引用的图片 url，用于孤儿文件对账
+func (m *MongoMapper) DistinctImagesSince(ctx context.Context, since time.Time) ([]string, error) {
+	return upload.Strings(m.conn.Distinct(ctx, "ocr", bson.M{consts.CreateTime: bson.M{"$gte": since}}))
+}
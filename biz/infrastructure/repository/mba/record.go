@@ -5,6 +5,7 @@ import (
 	"essay-show/biz/application/dto/basic"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/upload"
 	pageutil "essay-show/biz/infrastructure/util/page"
 	"time"
 
@@ -239,3 +240,8 @@ func (m *RecordMongoMapper) AverageScoreRateByExamAndTopic(ctx context.Context,
 	}
 	return result[0].AvgRate, nil
 }
+
+// DistinctImagesSince 返回 since 之后提交的批改记录引用的图片 url，用于孤儿文件对账
+func (m *RecordMongoMapper) DistinctImagesSince(ctx context.Context, since time.Time) ([]string, error) {
+	return upload.Strings(m.conn.Distinct(ctx, "ocr", bson.M{"create_time": bson.M{"$gte": since}}))
+}
@@ -0,0 +1,90 @@
+package message
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "message"
+
+// Message 站内通知，用户在通知偏好中选择站内渠道时写入
+type Message struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	Event      string             `bson:"event" json:"event"` // consts.NotifyEventXxx
+	Title      string             `bson:"title" json:"title"`
+	Content    string             `bson:"content" json:"content"`
+	Page       string             `bson:"page,omitempty" json:"page,omitempty"` // 小程序跳转页面
+	Read       bool               `bson:"read" json:"read"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, msg *Message) error {
+	if msg.ID.IsZero() {
+		msg.ID = primitive.NewObjectID()
+		msg.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, msg)
+	return err
+}
+
+// FindByUser 分页查询用户的站内通知，按时间倒序
+func (m *MongoMapper) FindByUser(ctx context.Context, userID string, p *basic.PaginationOptions) ([]*Message, int64, error) {
+	filter := bson.M{consts.UserID: userID}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Message, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// CountUnread 统计用户未读的站内通知数
+func (m *MongoMapper) CountUnread(ctx context.Context, userID string) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{consts.UserID: userID, "read": false})
+}
+
+// MarkRead 将用户的站内通知标记为已读，ids 为空时全部标记
+func (m *MongoMapper) MarkRead(ctx context.Context, userID string, ids []string) error {
+	filter := bson.M{consts.UserID: userID, "read": false}
+	if len(ids) > 0 {
+		oids := make([]primitive.ObjectID, 0, len(ids))
+		for _, id := range ids {
+			oid, err := primitive.ObjectIDFromHex(id)
+			if err != nil {
+				return consts.ErrInvalidObjectId
+			}
+			oids = append(oids, oid)
+		}
+		filter[consts.ID] = bson.M{"$in": oids}
+	}
+	_, err := m.conn.UpdateManyNoCache(ctx, filter, bson.M{"$set": bson.M{"read": true}})
+	return err
+}
@@ -0,0 +1,166 @@
+package migration
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/repository/apikey"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/guardian"
+	"essay-show/biz/infrastructure/repository/homework"
+	"essay-show/biz/infrastructure/repository/invitation"
+	logRepo "essay-show/biz/infrastructure/repository/log"
+	"essay-show/biz/infrastructure/repository/message"
+	"essay-show/biz/infrastructure/repository/outbox"
+	"essay-show/biz/infrastructure/repository/session"
+	"essay-show/biz/infrastructure/repository/upload"
+	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const indexTimeout = 5 * time.Minute
+
+// collectionIndexes 一个集合需要的索引，只声明普通索引，唯一约束由业务保证，避免历史重复数据导致建索引失败
+type collectionIndexes struct {
+	Collection string
+	Keys       []bson.D
+}
+
+// indexes 按查询路径声明的索引，新增查询条件时在这里补充
+var indexes = []collectionIndexes{
+	{homework.SubmissionCollectionName, []bson.D{
+		{{Key: "homework_id", Value: 1}, {Key: "update_time", Value: -1}},
+		{{Key: "member_id", Value: 1}, {Key: "homework_id", Value: 1}, {Key: "create_time", Value: -1}},
+		{{Key: "status", Value: 1}, {Key: "update_time", Value: 1}},
+		{{Key: "status", Value: 1}, {Key: "lease_expire", Value: 1}},
+		{{Key: "teacher_id", Value: 1}, {Key: "status", Value: 1}},
+		{{Key: "create_time", Value: 1}},
+		{{Key: "update_time", Value: 1}},
+	}},
+	{homework.HomeworkCollectionName, []bson.D{
+		{{Key: "class_id", Value: 1}, {Key: "create_time", Value: -1}},
+		{{Key: "creator_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{homework.ReportCollectionName, []bson.D{
+		{{Key: "creator_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{homework.LessonPlanCollectionName, []bson.D{
+		{{Key: "creator_id", Value: 1}, {Key: "homework_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{homework.WeeklyReportCollectionName, []bson.D{
+		{{Key: "class_id", Value: 1}, {Key: "week_start", Value: -1}},
+	}},
+	{class.ClassCollectionName, []bson.D{
+		{{Key: "creator_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{class.MemberCollectionName, []bson.D{
+		{{Key: "class_id", Value: 1}, {Key: "name", Value: 1}},
+		{{Key: "user_id", Value: 1}, {Key: "class_id", Value: 1}},
+	}},
+	{logRepo.CollectionName, []bson.D{
+		{{Key: "user_id", Value: 1}, {Key: "create_time", Value: -1}},
+		{{Key: "create_time", Value: 1}},
+	}},
+	{user.CollectionName, []bson.D{
+		{{Key: "phone", Value: 1}},
+	}},
+	{session.CollectionName, []bson.D{
+		{{Key: "user_id", Value: 1}, {Key: "device_id", Value: 1}},
+		{{Key: "jti", Value: 1}},
+	}},
+	{message.CollectionName, []bson.D{
+		{{Key: "user_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{invitation.LogCollectionName, []bson.D{
+		{{Key: "inviter", Value: 1}},
+		{{Key: "invitee", Value: 1}},
+	}},
+	{guardian.CollectionName, []bson.D{
+		{{Key: "parent_id", Value: 1}, {Key: "child_id", Value: 1}},
+	}},
+	{outbox.CollectionName, []bson.D{
+		{{Key: "status", Value: 1}, {Key: "create_time", Value: 1}},
+	}},
+	{apikey.CollectionName, []bson.D{
+		{{Key: "key_hash", Value: 1}},
+	}},
+	{apikey.UsageCollectionName, []bson.D{
+		{{Key: "key_id", Value: 1}, {Key: "date", Value: 1}},
+	}},
+	{audit.RevisionCollectionName, []bson.D{
+		{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{audit.ModerationCollectionName, []bson.D{
+		{{Key: "scene", Value: 1}, {Key: "create_time", Value: -1}},
+		{{Key: "user_id", Value: 1}, {Key: "create_time", Value: -1}},
+	}},
+	{upload.CollectionName, []bson.D{
+		{{Key: "status", Value: 1}, {Key: "create_time", Value: 1}},
+	}},
+	{audit.ImageCleanupCollectionName, []bson.D{
+		{{Key: "submission_id", Value: 1}},
+		{{Key: "create_time", Value: -1}},
+	}},
+	{audit.VersionCollectionName, []bson.D{
+		{{Key: "create_time", Value: 1}},
+	}},
+}
+
+// uniqueIndexes 业务依赖重复键错误做并发控制的唯一索引，逐个创建，已有重复数据时失败只记录日志，不影响普通索引
+var uniqueIndexes = []collectionIndexes{
+	{audit.VersionCollectionName, []bson.D{
+		{{Key: "target_type", Value: 1}, {Key: "target_id", Value: 1}, {Key: "version", Value: 1}},
+	}},
+}
+
+// EnsureIndexes 启动时创建声明的索引，createIndexes 对已存在的同名同定义索引是幂等的，
+// 单个集合失败只记录日志，不影响服务启动
+func EnsureIndexes(ctx context.Context, config *config.Config) {
+	ctx, cancel := context.WithTimeout(ctx, indexTimeout)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(config.Mongo.URL))
+	if err != nil {
+		log.Error("创建索引连接 Mongo 失败: %v", err)
+		return
+	}
+	defer func() {
+		_ = client.Disconnect(context.Background())
+	}()
+
+	db := client.Database(config.Mongo.DB)
+	for _, c := range indexes {
+		models := make([]mongo.IndexModel, 0, len(c.Keys))
+		for _, keys := range c.Keys {
+			models = append(models, mongo.IndexModel{
+				Keys:    keys,
+				Options: options.Index().SetBackground(true),
+			})
+		}
+		names, err := db.Collection(c.Collection).Indexes().CreateMany(ctx, models)
+		if err != nil {
+			log.Error("创建索引失败, collection: %s, error: %v", c.Collection, err)
+			continue
+		}
+		log.Info("索引已就绪, collection: %s, indexes: %v", c.Collection, names)
+	}
+
+	for _, c := range uniqueIndexes {
+		for _, keys := range c.Keys {
+			name, err := db.Collection(c.Collection).Indexes().CreateOne(ctx, mongo.IndexModel{
+				Keys:    keys,
+				Options: options.Index().SetBackground(true).SetUnique(true),
+			})
+			if err != nil {
+				log.Error("创建唯一索引失败，需先清理重复数据, collection: %s, keys: %v, error: %v", c.Collection, keys, err)
+				continue
+			}
+			log.Info("唯一索引已就绪, collection: %s, index: %s", c.Collection, name)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package ocr
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "ocr_correction"
+
+// Correction 用户对一组图片 OCR 结果的人工校正，同一用户同一组图片只保留最新一次
+type Correction struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	ImagesHash string             `bson:"images_hash" json:"imagesHash"` // 见 HashImages
+	Images     []string           `bson:"images" json:"images"`
+	Title      string             `bson:"title" json:"title"`
+	Text       string             `bson:"text" json:"text"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+// HashImages 图片 url 列表（含顺序）的哈希，用于定位同一组图片
+func HashImages(images []string) string {
+	sum := md5.Sum([]byte(strings.Join(images, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// Upsert 保存校正结果，已校正过的同一组图片覆盖原文本
+func (m *MongoMapper) Upsert(ctx context.Context, c *Correction) error {
+	now := time.Now()
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.UserID: c.UserID,
+		"images_hash": HashImages(c.Images),
+	}, bson.M{
+		"$set": bson.M{
+			"images":      c.Images,
+			"title":       c.Title,
+			"text":        c.Text,
+			"update_time": now,
+		},
+		"$setOnInsert": bson.M{consts.CreateTime: now},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// FindByImages 查询用户对这组图片的校正结果
+func (m *MongoMapper) FindByImages(ctx context.Context, userID string, images []string) (*Correction, error) {
+	var c Correction
+	err := m.conn.FindOneNoCache(ctx, &c, bson.M{
+		consts.UserID: userID,
+		"images_hash": HashImages(images),
+	})
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &c, nil
+}
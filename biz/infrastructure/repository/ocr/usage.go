@@ -0,0 +1,51 @@
+package ocr
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const UsageCollectionName = "ocr_usage"
+
+// Usage 一次 OCR 的配额流水，与批改次数 Count 分开记录
+type Usage struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	Date       string             `bson:"date" json:"date"`     // 使用日期 2006-01-02，统计每日免费次数
+	Source     string             `bson:"source" json:"source"` // 扣减来源，见 consts.OcrQuotaSource*
+	Images     int64              `bson:"images" json:"images"` // 本次识别的文件数
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type UsageMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewUsageMongoMapper(cfg *config.Config) *UsageMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, UsageCollectionName, cfg.Cache)
+	return &UsageMongoMapper{conn: conn}
+}
+
+func (m *UsageMongoMapper) Insert(ctx context.Context, u *Usage) error {
+	if u.ID.IsZero() {
+		u.ID = primitive.NewObjectID()
+		u.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, u)
+	return err
+}
+
+// CountFreeByDate 统计用户某天已使用的免费次数
+func (m *UsageMongoMapper) CountFreeByDate(ctx context.Context, userID, date string) (int64, error) {
+	return m.conn.CountDocuments(ctx, bson.M{
+		consts.UserID: userID,
+		"date":        date,
+		"source":      consts.OcrQuotaSourceFree,
+	})
+}
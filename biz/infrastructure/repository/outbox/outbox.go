@@ -0,0 +1,101 @@
+package outbox
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "count_outbox"
+
+// Event 一次批改次数变更，与业务结果在同一事务中写入，执行成功后标记为完成
+type Event struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	Delta      int64              `bson:"delta" json:"delta"`
+	Reason     string             `bson:"reason" json:"reason"` // consts.CountReasonXxx
+	RefID      string             `bson:"ref_id" json:"refId"`  // 关联的批改记录或作业提交
+	Status     int                `bson:"status" json:"status"` // consts.OutboxStatusXxx
+	Retry      int                `bson:"retry" json:"retry"`
+	Message    string             `bson:"message,omitempty" json:"message,omitempty"` // 最近一次执行失败的原因
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, e *Event) error {
+	if e.ID.IsZero() {
+		e.ID = primitive.NewObjectID()
+		e.CreateTime = time.Now()
+		e.UpdateTime = e.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, e)
+	return err
+}
+
+// FindPending 查询在 before 之前写入且仍未执行的事件，按写入时间正序
+func (m *MongoMapper) FindPending(ctx context.Context, before time.Time, limit int64) ([]*Event, error) {
+	data := make([]*Event, 0, limit)
+	err := m.conn.Find(ctx, &data, bson.M{
+		consts.Status:     consts.OutboxStatusPending,
+		consts.CreateTime: bson.M{"$lt": before},
+	}, &options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// MarkDone 将未执行的事件标记为完成，返回 false 表示事件已被其它流程执行过
+func (m *MongoMapper) MarkDone(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	result, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.ID:     id,
+		consts.Status: consts.OutboxStatusPending,
+	}, bson.M{
+		"$set": bson.M{
+			consts.Status: consts.OutboxStatusDone,
+			"update_time": time.Now(),
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+// MarkRetry 记录一次执行失败，重试次数达到 consts.OutboxMaxRetry 后不再自动重试
+func (m *MongoMapper) MarkRetry(ctx context.Context, e *Event, reason string) error {
+	status := consts.OutboxStatusPending
+	if e.Retry+1 >= consts.OutboxMaxRetry {
+		status = consts.OutboxStatusFailed
+	}
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.ID:     e.ID,
+		consts.Status: consts.OutboxStatusPending,
+	}, bson.M{
+		"$inc": bson.M{"retry": 1},
+		"$set": bson.M{
+			consts.Status: status,
+			"message":     reason,
+			"update_time": time.Now(),
+		},
+	})
+	return err
+}
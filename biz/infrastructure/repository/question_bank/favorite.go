@@ -0,0 +1,83 @@
+package question_bank
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const FavoriteCollectionName = "question_favorite"
+
+// Favorite 用户收藏的一道题库题目，QuestionID 为 Essays 表的 id
+type Favorite struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	QuestionID int                `bson:"question_id" json:"questionId"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+}
+
+type FavoriteMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewFavoriteMongoMapper(cfg *config.Config) *FavoriteMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, FavoriteCollectionName, cfg.Cache)
+	return &FavoriteMongoMapper{conn: conn}
+}
+
+// Add 收藏题目，重复收藏不会产生多条记录，也不会改变收藏时间
+func (m *FavoriteMongoMapper) Add(ctx context.Context, userID string, questionID int) error {
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.UserID: userID,
+		"question_id": questionID,
+	}, bson.M{
+		"$setOnInsert": bson.M{consts.CreateTime: time.Now()},
+	}, options.Update().SetUpsert(true))
+	return err
+}
+
+// Remove 取消收藏，未收藏时不报错
+func (m *FavoriteMongoMapper) Remove(ctx context.Context, userID string, questionID int) error {
+	_, err := m.conn.DeleteOneNoCache(ctx, bson.M{
+		consts.UserID: userID,
+		"question_id": questionID,
+	})
+	return err
+}
+
+// Exists 用户是否收藏了该题目
+func (m *FavoriteMongoMapper) Exists(ctx context.Context, userID string, questionID int) (bool, error) {
+	n, err := m.conn.CountDocuments(ctx, bson.M{
+		consts.UserID: userID,
+		"question_id": questionID,
+	})
+	return n > 0, err
+}
+
+// FindManyByUser 分页查询用户的收藏，最近收藏的在前
+func (m *FavoriteMongoMapper) FindManyByUser(ctx context.Context, userID string, p *basic.PaginationOptions) ([]*Favorite, int64, error) {
+	filter := bson.M{consts.UserID: userID}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Favorite, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.D{{Key: consts.CreateTime, Value: -1}, {Key: consts.ID, Value: -1}},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
@@ -3,18 +3,25 @@ package question_bank
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"essay-show/biz/application/dto/essay/show"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/util/log"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/zeromicro/go-zero/core/stores/sqlx"
 )
 
 type MySQLMapper struct {
-	db *sql.DB
+	db            *sql.DB
+	conn          sqlx.SqlConn
+	slowThreshold time.Duration
 }
 
 // Essay 对应数据库中的 Essays 表
@@ -27,29 +34,63 @@ type Essay struct {
 	Name            *string `db:"name"`
 	Description     *string `db:"description"`
 	Genre           *string `db:"genre"`
+	Requirement     *string `db:"requirement"` // 写作要求
 }
 
-func NewMySQLMapper(dsn string) (*MySQLMapper, error) {
-	db, err := sql.Open("mysql", dsn)
+func NewMySQLMapper(c config.MySQLConf) (*MySQLMapper, error) {
+	db, err := sql.Open("mysql", c.DSN)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open mysql connection: %w", err)
 	}
+	db.SetMaxOpenConns(c.MaxOpenConns)
+	db.SetMaxIdleConns(c.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Duration(c.ConnMaxLifetime) * time.Second)
 
 	// 测试连接
 	if err := db.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping mysql: %w", err)
 	}
 
+	// 语句与慢查询统一由 observe 记录
+	sqlx.DisableStmtLog()
 	log.Info("MySQL connection established successfully")
-	return &MySQLMapper{db: db}, nil
+	return &MySQLMapper{
+		db:            db,
+		conn:          sqlx.NewSqlConnFromDB(db),
+		slowThreshold: time.Duration(c.SlowThreshold) * time.Millisecond,
+	}, nil
 }
 
 func (m *MySQLMapper) Close() error {
 	return m.db.Close()
 }
 
+// Ping 检查 MySQL 连接是否可用
+func (m *MySQLMapper) Ping(ctx context.Context) error {
+	return m.db.PingContext(ctx)
+}
+
+// queryRow 查询单行并按 db 标签映射到 v，允许查询的列少于结构体字段
+func (m *MySQLMapper) queryRow(ctx context.Context, v any, query string, args ...any) error {
+	defer m.observe(query, args, time.Now())
+	return m.conn.QueryRowPartialCtx(ctx, v, query, args...)
+}
+
+// queryRows 查询多行并按 db 标签映射到 v，允许查询的列少于结构体字段
+func (m *MySQLMapper) queryRows(ctx context.Context, v any, query string, args ...any) error {
+	defer m.observe(query, args, time.Now())
+	return m.conn.QueryRowsPartialCtx(ctx, v, query, args...)
+}
+
+// observe 记录超过阈值的慢查询
+func (m *MySQLMapper) observe(query string, args []any, start time.Time) {
+	if cost := time.Since(start); m.slowThreshold > 0 && cost > m.slowThreshold {
+		log.Info("[SQL] slow query, cost: %v, query: %s, args: %v", cost, strings.Join(strings.Fields(query), " "), args)
+	}
+}
+
 // ListQuestionBanks 获取题库列表
-func (m *MySQLMapper) ListQuestionBanks(ctx context.Context, req *show.ListQuestionBanksReq) ([]*show.QuestionBank, int64, error) {
+func (m *MySQLMapper) ListQuestionBanks(ctx context.Context, req *show.SearchQuestionBanksReq) ([]*show.QuestionBank, int64, error) {
 	// 构建查询条件
 	var conditions []string
 	var args []interface{}
@@ -70,6 +111,19 @@ func (m *MySQLMapper) ListQuestionBanks(ctx context.Context, req *show.ListQuest
 		conditions = append(conditions, fmt.Sprintf("grade IN (%s)", strings.Join(placeholders, ",")))
 	}
 
+	// 按文体筛选
+	if req.Genre != nil && *req.Genre != "" {
+		conditions = append(conditions, "genre = ?")
+		args = append(args, *req.Genre)
+	}
+
+	// 按名称/描述关键字搜索，题库规模不大，LIKE 即可
+	if req.Keyword != nil && strings.TrimSpace(*req.Keyword) != "" {
+		kw := "%" + escapeLike(strings.TrimSpace(*req.Keyword)) + "%"
+		conditions = append(conditions, "(name LIKE ? OR description LIKE ?)")
+		args = append(args, kw, kw)
+	}
+
 	// 构建 WHERE 子句
 	whereClause := ""
 	if len(conditions) > 0 {
@@ -79,7 +133,7 @@ func (m *MySQLMapper) ListQuestionBanks(ctx context.Context, req *show.ListQuest
 	// 获取总数
 	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM Essays %s", whereClause)
 	var total int64
-	err := m.db.QueryRowContext(ctx, countQuery, args...).Scan(&total)
+	err := m.queryRow(ctx, &total, countQuery, args...)
 	if err != nil {
 		log.Error("Failed to count question banks: %v", err)
 		return nil, 0, fmt.Errorf("failed to count question banks: %w", err)
@@ -109,50 +163,75 @@ func (m *MySQLMapper) ListQuestionBanks(ctx context.Context, req *show.ListQuest
 
 	args = append(args, limit, offset)
 
-	rows, err := m.db.QueryContext(ctx, dataQuery, args...)
-	if err != nil {
+	var essays []*Essay
+	if err = m.queryRows(ctx, &essays, dataQuery, args...); err != nil {
 		log.Error("Failed to query question banks: %v", err)
 		return nil, 0, fmt.Errorf("failed to query question banks: %w", err)
 	}
-	defer rows.Close()
-
-	var questionBanks []*show.QuestionBank
-	for rows.Next() {
-		var essay Essay
-		err := rows.Scan(
-			&essay.ID,
-			&essay.Type,
-			&essay.TextbookVersion,
-			&essay.Grade,
-			&essay.Unit,
-			&essay.Name,
-			&essay.Description,
-			&essay.Genre,
-		)
-		if err != nil {
-			log.Error("Failed to scan essay row: %v", err)
-			continue
-		}
 
-		// 转换为 QuestionBank 结构
-		questionBank := &show.QuestionBank{
-			Id:          strconv.Itoa(essay.ID),
-			Name:        safeString(essay.Name),
-			Description: safeString(essay.Description),
-			Grade:       safeInt64(essay.Grade),
-			Unit:        safeInt64(essay.Unit),
-			EssayType:   safeString(essay.Genre),
-		}
+	questionBanks := make([]*show.QuestionBank, 0, len(essays))
+	for _, essay := range essays {
+		questionBanks = append(questionBanks, ToQuestionBank(essay))
+	}
+
+	return questionBanks, total, nil
+}
 
-		questionBanks = append(questionBanks, questionBank)
+// FindOne 获取一道题目的完整信息，不存在时返回 consts.ErrNotFound
+func (m *MySQLMapper) FindOne(ctx context.Context, id int) (*Essay, error) {
+	var essay Essay
+	err := m.queryRow(ctx, &essay, `
+		SELECT id, type, textbook_version, grade, unit, name, description, genre, requirement
+		FROM Essays WHERE id = ?
+	`, id)
+	if errors.Is(err, sqlx.ErrNotFound) {
+		return nil, consts.ErrNotFound
+	}
+	if err != nil {
+		log.Error("Failed to query essay %d: %v", id, err)
+		return nil, fmt.Errorf("failed to query essay: %w", err)
 	}
+	return &essay, nil
+}
 
-	if err = rows.Err(); err != nil {
-		log.Error("Error iterating over rows: %v", err)
-		return nil, 0, fmt.Errorf("error iterating over rows: %w", err)
+// FindByIds 批量获取题目，已不存在的题目不会出现在结果中，结果顺序不保证与 ids 一致
+func (m *MySQLMapper) FindByIds(ctx context.Context, ids []int) ([]*Essay, error) {
+	essays := make([]*Essay, 0, len(ids))
+	if len(ids) == 0 {
+		return essays, nil
 	}
+	placeholders := make([]string, len(ids))
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		placeholders[i] = "?"
+		args[i] = id
+	}
+	query := fmt.Sprintf(`
+		SELECT id, type, textbook_version, grade, unit, name, description, genre, requirement
+		FROM Essays WHERE id IN (%s)
+	`, strings.Join(placeholders, ","))
+	if err := m.queryRows(ctx, &essays, query, args...); err != nil {
+		log.Error("Failed to query essays by ids: %v", err)
+		return nil, fmt.Errorf("failed to query essays: %w", err)
+	}
+	return essays, nil
+}
 
-	return questionBanks, total, nil
+// ToQuestionBank 转换为 QuestionBank 结构
+func ToQuestionBank(essay *Essay) *show.QuestionBank {
+	return &show.QuestionBank{
+		Id:          strconv.Itoa(essay.ID),
+		Name:        safeString(essay.Name),
+		Description: safeString(essay.Description),
+		Grade:       safeInt64(essay.Grade),
+		Unit:        safeInt64(essay.Unit),
+		EssayType:   safeString(essay.Genre),
+	}
+}
+
+// escapeLike 转义 LIKE 中的通配符，避免用户输入的 % 和 _ 被当作通配
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
 }
 
 // safeString 安全地将 *string 转换为 string
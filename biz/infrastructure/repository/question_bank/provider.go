@@ -7,6 +7,6 @@ import (
 
 // NewMySQLMapperFromConfig 创建 MySQL 映射器
 func NewMySQLMapperFromConfig(config *config.Config) (*MySQLMapper, error) {
-	log.Info("Creating MySQL mapper with DSN: %s", config.MySQL.DSN)
-	return NewMySQLMapper(config.MySQL.DSN)
+	log.Info("Creating MySQL mapper, maxOpenConns: %d, maxIdleConns: %d", config.MySQL.MaxOpenConns, config.MySQL.MaxIdleConns)
+	return NewMySQLMapper(config.MySQL)
 }
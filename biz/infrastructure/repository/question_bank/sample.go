@@ -0,0 +1,34 @@
+package question_bank
+
+import (
+	"context"
+	"fmt"
+
+	"essay-show/biz/infrastructure/util/log"
+)
+
+// Sample 对应数据库中的 EssaySamples 表，一道题目可以有多篇范文
+type Sample struct {
+	ID      int     `db:"id"`
+	EssayID int     `db:"essay_id"`
+	Title   string  `db:"title"`
+	Content string  `db:"content"`
+	Score   *int    `db:"score"`
+	Comment *string `db:"comment"` // 范文点评
+	Sort    int     `db:"sort"`
+}
+
+// FindSamples 获取题目下的范文，按 sort、id 升序
+func (m *MySQLMapper) FindSamples(ctx context.Context, essayID int) ([]*Sample, error) {
+	samples := make([]*Sample, 0)
+	err := m.queryRows(ctx, &samples, `
+		SELECT id, essay_id, title, content, score, comment, sort
+		FROM EssaySamples WHERE essay_id = ?
+		ORDER BY sort ASC, id ASC
+	`, essayID)
+	if err != nil {
+		log.Error("Failed to query samples of essay %d: %v", essayID, err)
+		return nil, fmt.Errorf("failed to query samples: %w", err)
+	}
+	return samples, nil
+}
@@ -0,0 +1,110 @@
+package question_bank
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const TeacherQuestionCollectionName = "teacher_question"
+
+// TeacherQuestion 教师个人题库中的一道作文题，字段与布置作业所需的题目信息对应
+type TeacherQuestion struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	CreatorID   string             `bson:"creator_id" json:"creatorId"`
+	Name        string             `bson:"name" json:"name"`               // 题目名称，布置时作为作业标题
+	Description string             `bson:"description" json:"description"` // 写作要求
+	Grade       int64              `bson:"grade" json:"grade"`
+	Unit        int64              `bson:"unit,omitempty" json:"unit"`
+	EssayType   string             `bson:"essay_type" json:"essayType"`
+	TotalScore  *int64             `bson:"total_score,omitempty" json:"totalScore,omitempty"`
+	Standard    *string            `bson:"standard,omitempty" json:"standard,omitempty"` // 批改标准
+	Status      int64              `bson:"status" json:"status"`
+	CreateTime  time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime  time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type TeacherQuestionMongoMapper struct {
+	conn *monc.Model
+}
+
+func NewTeacherQuestionMongoMapper(cfg *config.Config) *TeacherQuestionMongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, TeacherQuestionCollectionName, cfg.Cache)
+	return &TeacherQuestionMongoMapper{conn: conn}
+}
+
+func (m *TeacherQuestionMongoMapper) Insert(ctx context.Context, q *TeacherQuestion) error {
+	if q.ID.IsZero() {
+		q.ID = primitive.NewObjectID()
+		q.CreateTime = time.Now()
+		q.UpdateTime = q.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, q)
+	return err
+}
+
+func (m *TeacherQuestionMongoMapper) Update(ctx context.Context, q *TeacherQuestion) error {
+	q.UpdateTime = time.Now()
+	_, err := m.conn.UpdateByIDNoCache(ctx, q.ID, bson.M{"$set": q})
+	return err
+}
+
+// FindOne 获取一道未删除的题目
+func (m *TeacherQuestionMongoMapper) FindOne(ctx context.Context, id string) (*TeacherQuestion, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var q TeacherQuestion
+	err = m.conn.FindOneNoCache(ctx, &q, bson.M{
+		consts.ID:     oid,
+		consts.Status: bson.M{consts.NotEqual: consts.DeleteStatus},
+	})
+	if err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &q, nil
+}
+
+// Delete 软删除，已布置的作业不受影响
+func (m *TeacherQuestionMongoMapper) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := m.conn.UpdateByIDNoCache(ctx, id, bson.M{"$set": bson.M{
+		consts.Status: consts.DeleteStatus,
+		"update_time": time.Now(),
+	}})
+	return err
+}
+
+// FindManyByCreator 分页查询教师自建的题目，grade 不为空时按年级过滤
+func (m *TeacherQuestionMongoMapper) FindManyByCreator(ctx context.Context, creatorID string, grade *int64, p *basic.PaginationOptions) ([]*TeacherQuestion, int64, error) {
+	filter := bson.M{
+		"creator_id":  creatorID,
+		consts.Status: bson.M{consts.NotEqual: consts.DeleteStatus},
+	}
+	if grade != nil {
+		filter["grade"] = *grade
+	}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*TeacherQuestion, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
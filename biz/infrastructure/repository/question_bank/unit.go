@@ -0,0 +1,39 @@
+package question_bank
+
+import (
+	"context"
+	"fmt"
+
+	"essay-show/biz/infrastructure/util/log"
+)
+
+// UnitCount 某教材版本、年级、单元下的题目数量
+type UnitCount struct {
+	TextbookVersion int64 `db:"textbook_version"`
+	Grade           int64 `db:"grade"`
+	Unit            int64 `db:"unit"`
+	Count           int64 `db:"cnt"`
+}
+
+// CountByUnit 按教材版本、年级、单元分组统计课内题目数量，textbookVersion 不为空时只统计该版本
+func (m *MySQLMapper) CountByUnit(ctx context.Context, textbookVersion *int64) ([]*UnitCount, error) {
+	query := `
+		SELECT COALESCE(textbook_version, 0) AS textbook_version, COALESCE(grade, 0) AS grade,
+			COALESCE(unit, 0) AS unit, COUNT(*) AS cnt
+		FROM Essays WHERE type = 0`
+	var args []interface{}
+	if textbookVersion != nil {
+		query += " AND textbook_version = ?"
+		args = append(args, *textbookVersion)
+	}
+	query += `
+		GROUP BY 1, 2, 3
+		ORDER BY 1 ASC, 2 ASC, 3 ASC`
+
+	counts := make([]*UnitCount, 0)
+	if err := m.queryRows(ctx, &counts, query, args...); err != nil {
+		log.Error("Failed to count essays by unit: %v", err)
+		return nil, fmt.Errorf("failed to count essays by unit: %w", err)
+	}
+	return counts, nil
+}
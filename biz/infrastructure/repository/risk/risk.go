@@ -0,0 +1,106 @@
+package risk
+
+import (
+	"context"
+	"essay-show/biz/application/dto/basic"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	util "essay-show/biz/infrastructure/util/page"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "risk_record"
+
+// Record 一条风控命中记录，等待人工审核
+type Record struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	Type       string             `bson:"type" json:"type"`
+	DeviceID   string             `bson:"device_id,omitempty" json:"deviceId"`
+	IP         string             `bson:"ip,omitempty" json:"ip"`
+	Detail     string             `bson:"detail" json:"detail"`
+	Status     int                `bson:"status" json:"status"` // 0: 待审核, 1: 确认正常, 2: 确认作弊
+	ReviewerID string             `bson:"reviewer_id,omitempty" json:"reviewerId"`
+	Remark     string             `bson:"remark,omitempty" json:"remark"`
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+	UpdateTime time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, r *Record) error {
+	if r.ID.IsZero() {
+		r.ID = primitive.NewObjectID()
+		r.CreateTime = time.Now()
+		r.UpdateTime = r.CreateTime
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, r)
+	return err
+}
+
+func (m *MongoMapper) FindOne(ctx context.Context, id string) (*Record, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var r Record
+	if err = m.conn.FindOneNoCache(ctx, &r, bson.M{consts.ID: oid}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &r, nil
+}
+
+// FindMany 按类型与状态分页查询风控记录，条件为空时不过滤
+func (m *MongoMapper) FindMany(ctx context.Context, typ string, status *int64, p *basic.PaginationOptions) ([]*Record, int64, error) {
+	filter := bson.M{}
+	if typ != "" {
+		filter["type"] = typ
+	}
+	if status != nil {
+		filter[consts.Status] = *status
+	}
+	skip, limit := util.ParsePageOpt(p)
+	data := make([]*Record, 0, limit)
+	err := m.conn.Find(ctx, &data, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return data, total, nil
+}
+
+// Review 审核一条待审核的风控记录
+func (m *MongoMapper) Review(ctx context.Context, id primitive.ObjectID, status int, remark, reviewerID string) (bool, error) {
+	res, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.ID:     id,
+		consts.Status: consts.RiskStatusPending,
+	}, bson.M{"$set": bson.M{
+		consts.Status: status,
+		"remark":      remark,
+		"reviewer_id": reviewerID,
+		"update_time": time.Now(),
+	}})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
+}
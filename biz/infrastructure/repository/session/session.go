@@ -0,0 +1,141 @@
+package session
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "user_session"
+
+// Session 用户在一台设备上的登录会话，同一设备重复登录复用同一条记录
+type Session struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	DeviceID   string             `bson:"device_id" json:"deviceId"`
+	Jti        string             `bson:"jti" json:"jti"` // 当前有效 token 的 jti，刷新后更新
+	IP         string             `bson:"ip" json:"ip"`
+	UserAgent  string             `bson:"user_agent" json:"userAgent"`
+	Status     int                `bson:"status" json:"status"` // 0: 在线, 1: 已下线
+	LoginTime  time.Time          `bson:"login_time" json:"loginTime"`
+	ExpireTime time.Time          `bson:"expire_time" json:"expireTime"`
+	UpdateTime time.Time          `bson:"update_time" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+// Upsert 记录一次登录，同一用户同一设备只保留一条会话，返回被覆盖的旧会话，设备首次登录时返回 nil
+func (m *MongoMapper) Upsert(ctx context.Context, s *Session) (*Session, error) {
+	now := time.Now()
+	var old Session
+	err := m.conn.FindOneAndUpdateNoCache(ctx, &old, bson.M{
+		consts.UserID: s.UserID,
+		"device_id":   s.DeviceID,
+	}, bson.M{
+		"$set": bson.M{
+			"jti":         s.Jti,
+			"ip":          s.IP,
+			"user_agent":  s.UserAgent,
+			consts.Status: consts.SessionStatusActive,
+			"login_time":  now,
+			"expire_time": s.ExpireTime,
+			"update_time": now,
+		},
+	}, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before))
+	switch {
+	case err == nil:
+		return &old, nil
+	case errors.Is(err, monc.ErrNotFound):
+		return nil, nil
+	default:
+		return nil, err
+	}
+}
+
+// UpdateToken token 刷新后更新会话的 jti 与过期时间
+func (m *MongoMapper) UpdateToken(ctx context.Context, userID, deviceID, jti string, expireTime time.Time) error {
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.UserID: userID,
+		"device_id":   deviceID,
+		consts.Status: consts.SessionStatusActive,
+	}, bson.M{
+		"$set": bson.M{
+			"jti":         jti,
+			"expire_time": expireTime,
+			"update_time": time.Now(),
+		},
+	})
+	return err
+}
+
+func (m *MongoMapper) FindOne(ctx context.Context, id string) (*Session, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var s Session
+	if err = m.conn.FindOneNoCache(ctx, &s, bson.M{consts.ID: oid}); err != nil {
+		return nil, consts.ErrNotFound
+	}
+	return &s, nil
+}
+
+// FindActiveByUser 查询用户所有未过期的在线会话
+func (m *MongoMapper) FindActiveByUser(ctx context.Context, userID string) ([]*Session, error) {
+	data := make([]*Session, 0)
+	err := m.conn.Find(ctx, &data, bson.M{
+		consts.UserID: userID,
+		consts.Status: consts.SessionStatusActive,
+		"expire_time": bson.M{"$gt": time.Now()},
+	}, &options.FindOptions{
+		Sort: bson.M{"login_time": -1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// ExistsByDevice 用户是否在该设备上登录过，包括已下线的会话
+func (m *MongoMapper) ExistsByDevice(ctx context.Context, userID, deviceID string) (bool, error) {
+	count, err := m.conn.CountDocuments(ctx, bson.M{
+		consts.UserID: userID,
+		"device_id":   deviceID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// RevokeByDevice 将用户某设备的会话标记为下线
+func (m *MongoMapper) RevokeByDevice(ctx context.Context, userID, deviceID string) error {
+	_, err := m.conn.UpdateOneNoCache(ctx, bson.M{
+		consts.UserID: userID,
+		"device_id":   deviceID,
+	}, bson.M{"$set": bson.M{consts.Status: consts.SessionStatusRevoked, "update_time": time.Now()}})
+	return err
+}
+
+// RevokeAllByUser 将用户所有会话标记为下线
+func (m *MongoMapper) RevokeAllByUser(ctx context.Context, userID string) error {
+	_, err := m.conn.UpdateManyNoCache(ctx, bson.M{
+		consts.UserID: userID,
+		consts.Status: consts.SessionStatusActive,
+	}, bson.M{"$set": bson.M{consts.Status: consts.SessionStatusRevoked, "update_time": time.Now()}})
+	return err
+}
@@ -0,0 +1,78 @@
+package setting
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	prefixKeyCacheKey = "cache:operation_config:"
+	CollectionName    = "operation_config"
+	RewardSettingID   = "reward"
+)
+
+// RewardSetting 运营可调整的奖励参数，字段为空时使用代码中的默认值
+type RewardSetting struct {
+	ID                 string    `bson:"_id" json:"id"`
+	DefaultCount       *int64    `bson:"default_count,omitempty" json:"defaultCount"`              // 新用户初始批改次数
+	AttendReward       *int64    `bson:"attend_reward,omitempty" json:"attendReward"`              // 每日签到奖励
+	AttendStreakReward *int64    `bson:"attend_streak_reward,omitempty" json:"attendStreakReward"` // 连续签到满周期额外奖励
+	InviterReward      *int64    `bson:"inviter_reward,omitempty" json:"inviterReward"`            // 邀请人奖励
+	InviteeReward      *int64    `bson:"invitee_reward,omitempty" json:"inviteeReward"`            // 被邀请人奖励
+	OcrDailyFree       *int64    `bson:"ocr_daily_free,omitempty" json:"ocrDailyFree"`             // 每天免费 OCR 次数
+	UpdaterID          string    `bson:"updater_id,omitempty" json:"updaterId"`
+	UpdateTime         time.Time `bson:"update_time,omitempty" json:"updateTime"`
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+// GetReward 读取奖励配置，走缓存；尚未配置时返回空配置
+func (m *MongoMapper) GetReward(ctx context.Context) (*RewardSetting, error) {
+	s := &RewardSetting{}
+	err := m.conn.FindOne(ctx, prefixKeyCacheKey+RewardSettingID, s, bson.M{"_id": RewardSettingID})
+	switch {
+	case err == nil:
+		return s, nil
+	case errors.Is(err, monc.ErrNotFound):
+		return &RewardSetting{ID: RewardSettingID}, nil
+	default:
+		return nil, err
+	}
+}
+
+// UpdateReward 更新非空的奖励字段并清除缓存，修改即时生效
+func (m *MongoMapper) UpdateReward(ctx context.Context, s *RewardSetting) error {
+	set := bson.M{
+		"updater_id":  s.UpdaterID,
+		"update_time": time.Now(),
+	}
+	fields := map[string]*int64{
+		"default_count":        s.DefaultCount,
+		"attend_reward":        s.AttendReward,
+		"attend_streak_reward": s.AttendStreakReward,
+		"inviter_reward":       s.InviterReward,
+		"invitee_reward":       s.InviteeReward,
+		"ocr_daily_free":       s.OcrDailyFree,
+	}
+	for k, v := range fields {
+		if v != nil {
+			set[k] = *v
+		}
+	}
+	_, err := m.conn.UpdateOne(ctx, prefixKeyCacheKey+RewardSettingID, bson.M{"_id": RewardSettingID},
+		bson.M{"$set": set}, options.Update().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,84 @@
+package softdelete
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/util/log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Field 软删除时间字段，未删除的记录没有该字段
+const Field = "delete_time"
+
+// Scope 查询时对已软删除记录的处理方式
+type Scope int
+
+const (
+	Alive   Scope = iota // 只查未删除的记录，默认
+	Deleted              // 只查已删除的记录
+	All                  // 不过滤
+)
+
+// Filter 按 scope 给查询条件加上软删除过滤，不传 scope 时只查未删除的记录，会修改并返回传入的 filter
+func Filter(filter bson.M, scope ...Scope) bson.M {
+	s := Alive
+	if len(scope) > 0 {
+		s = scope[0]
+	}
+	switch s {
+	case Alive:
+		filter[Field] = nil
+	case Deleted:
+		filter[Field] = bson.M{"$ne": nil}
+	}
+	return filter
+}
+
+// Update 标记删除的更新语句
+func Update() bson.M {
+	now := time.Now()
+	return bson.M{
+		"$set": bson.M{
+			Field:         now,
+			"update_time": now,
+		},
+	}
+}
+
+// PurgeFilter 删除时间早于 before 的记录
+func PurgeFilter(before time.Time) bson.M {
+	return bson.M{Field: bson.M{"$lt": before}}
+}
+
+// Purger 支持物理清理软删除记录的 mapper
+type Purger interface {
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// StartPurge 启动软删除清理任务，每天物理删除一次软删除超过 retention 的记录，多实例重复执行不影响结果
+func StartPurge(ctx context.Context, retention time.Duration, purgers map[string]Purger) {
+	log.Info("启动软删除清理任务, retention: %v", retention)
+	go func() {
+		ticker := time.NewTicker(24 * time.Hour)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				before := time.Now().Add(-retention)
+				for name, p := range purgers {
+					n, err := p.Purge(context.Background(), before)
+					if err != nil {
+						log.Error("清理软删除记录失败, collection: %s, error: %v", name, err)
+						continue
+					}
+					if n > 0 {
+						log.Info("清理软删除记录, collection: %s, count: %d", name, n)
+					}
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
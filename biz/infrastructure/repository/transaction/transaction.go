@@ -0,0 +1,60 @@
+package transaction
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+
+	"github.com/zeromicro/go-zero/core/stores/mon"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// sessionCollectionName 会话属于整个客户端，集合名只用于创建连接，不会写入数据
+const sessionCollectionName = "transaction"
+
+// Transactor 把多个 mapper 的写操作放进同一个 Mongo 事务，需要副本集或分片集群部署
+type Transactor struct {
+	conn *mon.Model
+}
+
+func NewTransactor(config *config.Config) *Transactor {
+	return &Transactor{
+		conn: mon.MustNewModel(config.Mongo.URL, config.Mongo.DB, sessionCollectionName),
+	}
+}
+
+// afterCommitKey ctx 中保存本次事务提交后要执行的回调
+type afterCommitKey struct{}
+
+// Do 在事务中执行 fn，fn 内的 mapper 调用必须使用传入的 ctx 才会加入事务，返回错误时整体回滚；
+// fn 内通过 AfterCommit 登记的回调在提交成功后执行
+func (t *Transactor) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	sess, err := t.conn.StartSession()
+	if err != nil {
+		return err
+	}
+	defer sess.EndSession(ctx)
+
+	var hooks []func()
+	_, err = sess.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (any, error) {
+		// 事务可能被驱动重试，只保留最后一次执行登记的回调
+		hooks = nil
+		return nil, fn(mongo.NewSessionContext(context.WithValue(sessCtx, afterCommitKey{}, &hooks), sessCtx))
+	})
+	if err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit ctx 处于 Do 的事务中时 fn 延后到提交成功后执行，回滚时丢弃；不在事务中时立即执行。
+// 用于写入后清理缓存，避免提交前被并发读取用旧值回填
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn()
+}
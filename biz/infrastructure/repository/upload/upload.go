@@ -0,0 +1,87 @@
+package upload
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/stores/monc"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const CollectionName = "cos_upload"
+
+// Upload 一次申请加签 url 或服务端转存生成的 cos 对象，孤儿文件对账时据此判断对象是否被业务数据引用
+type Upload struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID     string             `bson:"user_id" json:"userId"`
+	Key        string             `bson:"key" json:"key"`
+	Status     int                `bson:"status" json:"status"` // consts.UploadStatus*
+	CreateTime time.Time          `bson:"create_time" json:"createTime"`
+	CheckTime  time.Time          `bson:"check_time,omitempty" json:"checkTime"` // 对账时间
+}
+
+// Referrer 引用上传图片的业务数据，返回 since 之后写入的文档中引用的图片 url
+type Referrer interface {
+	DistinctImagesSince(ctx context.Context, since time.Time) ([]string, error)
+}
+
+// Strings 把 Distinct 的结果转为字符串列表，忽略非字符串的值
+func Strings(values []any, err error) ([]string, error) {
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok && s != "" {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+type MongoMapper struct {
+	conn *monc.Model
+}
+
+func NewMongoMapper(cfg *config.Config) *MongoMapper {
+	conn := monc.MustNewModel(cfg.Mongo.URL, cfg.Mongo.DB, CollectionName, cfg.Cache)
+	return &MongoMapper{conn: conn}
+}
+
+func (m *MongoMapper) Insert(ctx context.Context, u *Upload) error {
+	if u.ID.IsZero() {
+		u.ID = primitive.NewObjectID()
+		u.CreateTime = time.Now()
+	}
+	_, err := m.conn.InsertOneNoCache(ctx, u)
+	return err
+}
+
+// FindPending 查询 before 之前生成、还未对账的对象，按生成时间正序
+func (m *MongoMapper) FindPending(ctx context.Context, before time.Time, limit int64) ([]*Upload, error) {
+	uploads := make([]*Upload, 0, limit)
+	err := m.conn.Find(ctx, &uploads, bson.M{
+		"status":          consts.UploadStatusPending,
+		consts.CreateTime: bson.M{"$lt": before},
+	}, &options.FindOptions{
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: 1},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uploads, nil
+}
+
+// UpdateStatus 记录对账结果
+func (m *MongoMapper) UpdateStatus(ctx context.Context, id primitive.ObjectID, status int) error {
+	_, err := m.conn.UpdateByIDNoCache(ctx, id, bson.M{"$set": bson.M{
+		"status":     status,
+		"check_time": time.Now(),
+	}})
+	return err
+}
@@ -3,14 +3,21 @@ package user
 import (
 	"context"
 	"errors"
+	"essay-show/biz/application/dto/basic"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
+	"essay-show/biz/infrastructure/repository/transaction"
 	"essay-show/biz/infrastructure/util/log"
+	util "essay-show/biz/infrastructure/util/page"
+	"regexp"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/stores/cache"
 	"github.com/zeromicro/go-zero/core/stores/monc"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 const (
@@ -23,8 +30,8 @@ type MongoMapper struct {
 }
 
 func NewMongoMapper(config *config.Config) *MongoMapper {
-	log.Info("NewMongoMapper capnio config: %v, collection: %s", config, CollectionName)
-	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, CollectionName, config.Cache)
+	log.Info("NewMongoMapper collection: %s", CollectionName)
+	conn := monc.MustNewModel(config.Mongo.URL, config.Mongo.DB, CollectionName, config.Cache, cache.WithExpiry(consts.UserCacheExpiry))
 	return &MongoMapper{
 		conn: conn,
 	}
@@ -42,22 +49,32 @@ func (m *MongoMapper) Insert(ctx context.Context, user *User) error {
 
 func (m *MongoMapper) Update(ctx context.Context, user *User) error {
 	user.UpdateTime = time.Now()
-	_, err := m.conn.UpdateByIDNoCache(ctx, user.ID, bson.M{"$set": user})
+	_, err := m.conn.UpdateByID(ctx, prefixUserCacheKey+user.ID.Hex(), user.ID, bson.M{"$set": user})
 	return err
 }
 
+// FindOne 先读缓存，未命中时查库并写入缓存，所有更新方法都会删除对应缓存
 func (m *MongoMapper) FindOne(ctx context.Context, id string) (*User, error) {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, consts.ErrInvalidObjectId
 	}
+	key := prefixUserCacheKey + oid.Hex()
 	var u User
+	if err = m.conn.GetCache(key, &u); err == nil {
+		metrics.CacheHit(CollectionName)
+		return &u, nil
+	}
+	metrics.CacheMiss(CollectionName)
 	err = m.conn.FindOneNoCache(ctx, &u, bson.M{
 		consts.ID: oid,
 	})
 	if err != nil {
 		return nil, consts.ErrNotFound
 	}
+	if err = m.conn.SetCache(key, &u); err != nil {
+		log.Error("写入用户缓存失败, userId: %s, error: %v", id, err)
+	}
 	return &u, nil
 }
 
@@ -86,7 +103,66 @@ func (m *MongoMapper) UpdateCount(ctx context.Context, id string, increment int6
 			"count": increment,
 		},
 	})
-	return err
+	if err != nil {
+		return err
+	}
+	// 批改完成扣次数在事务中调用，缓存等提交后再清理
+	transaction.AfterCommit(ctx, func() {
+		if err := m.conn.DelCache(ctx, prefixUserCacheKey+oid.Hex()); err != nil {
+			log.CtxError(ctx, "删除用户缓存失败, userId: %s, err: %v", id, err)
+		}
+	})
+	return nil
+}
+
+// AdjustCount 原子调整 Count，扣减时最多扣到 0，返回调整后的用户
+func (m *MongoMapper) AdjustCount(ctx context.Context, id string, delta int64) (*User, error) {
+	return m.adjustClamped(ctx, id, "count", delta)
+}
+
+// AdjustOcrCount 原子调整 OcrCount，扣减时最多扣到 0，返回调整后的用户
+func (m *MongoMapper) AdjustOcrCount(ctx context.Context, id string, delta int64) (*User, error) {
+	return m.adjustClamped(ctx, id, "ocr_count", delta)
+}
+
+// adjustClamped 在一次更新内对计数字段加 delta 并保证结果不小于 0，避免先读后写时并发调整互相覆盖
+func (m *MongoMapper) adjustClamped(ctx context.Context, id, field string, delta int64) (*User, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, consts.ErrInvalidObjectId
+	}
+	var u User
+	err = m.conn.FindOneAndUpdate(ctx, prefixUserCacheKey+oid.Hex(), &u, bson.M{consts.ID: oid}, bson.A{
+		bson.M{"$set": bson.M{
+			field: bson.M{"$max": bson.A{0, bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$" + field, 0}}, delta}}}},
+		}},
+	}, options.FindOneAndUpdate().SetReturnDocument(options.After))
+	switch {
+	case err == nil:
+		return &u, nil
+	case errors.Is(err, monc.ErrNotFound):
+		return nil, consts.ErrNotFound
+	default:
+		return nil, err
+	}
+}
+
+// ConsumeOcrCount OcrCount 大于 0 时扣减一次，返回是否扣减成功
+func (m *MongoMapper) ConsumeOcrCount(ctx context.Context, id string) (bool, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return false, consts.ErrInvalidObjectId
+	}
+	res, err := m.conn.UpdateOne(ctx, prefixUserCacheKey+oid.Hex(), bson.M{
+		consts.ID:   oid,
+		"ocr_count": bson.M{"$gt": 0},
+	}, bson.M{
+		"$inc": bson.M{"ocr_count": -1},
+	})
+	if err != nil {
+		return false, err
+	}
+	return res.ModifiedCount > 0, nil
 }
 
 // UpdateMbaMemory 更新某用户某 essay_type 下的 memory_summary
@@ -95,7 +171,7 @@ func (m *MongoMapper) UpdateMbaMemory(ctx context.Context, id, essayType, memory
 	if err != nil {
 		return consts.ErrInvalidObjectId
 	}
-	_, err = m.conn.UpdateByIDNoCache(ctx, oid, bson.M{
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
 		"$set": bson.M{
 			"mba_memory." + essayType: memorySummary,
 			"update_time":             time.Now(),
@@ -104,13 +180,89 @@ func (m *MongoMapper) UpdateMbaMemory(ctx context.Context, id, essayType, memory
 	return err
 }
 
+func (m *MongoMapper) UpdateWatermark(ctx context.Context, id string, watermark *Watermark) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
+		"$set": bson.M{
+			"watermark":   watermark,
+			"update_time": time.Now(),
+		},
+	})
+	return err
+}
+
+// UpdateNotifySetting 更新邮箱与默认通知渠道，不影响按事件设置的偏好
+func (m *MongoMapper) UpdateNotifySetting(ctx context.Context, id string, setting *NotifySetting) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
+		"$set": bson.M{
+			"notify.email":   setting.Email,
+			"notify.channel": setting.Channel,
+			"update_time":    time.Now(),
+		},
+	})
+	return err
+}
+
+func (m *MongoMapper) UpdateNotificationPreference(ctx context.Context, id string, preference NotificationPreference) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
+		"$set": bson.M{
+			"notify.preference": preference,
+			"update_time":       time.Now(),
+		},
+	})
+	return err
+}
+
+// UpdateRole 修改用户角色，school 非空时一并更新，只更新这两个字段，不覆盖并发修改的状态与次数
+func (m *MongoMapper) UpdateRole(ctx context.Context, id, role, school string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	set := bson.M{
+		"role":        role,
+		"update_time": time.Now(),
+	}
+	if school != "" {
+		set["school"] = school
+	}
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{"$set": set})
+	return err
+}
+
+// SetHasPassword 标记用户已在中台设置过密码，只更新该字段，不覆盖并发修改的状态与次数
+func (m *MongoMapper) SetHasPassword(ctx context.Context, id string) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
+		"$set": bson.M{
+			"has_password": true,
+			"update_time":  time.Now(),
+		},
+	})
+	return err
+}
+
 // UpdateVip 叠加一次会员购买时长
 func (m *MongoMapper) UpdateVip(ctx context.Context, id string, expireTime time.Time) error {
 	oid, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return consts.ErrInvalidObjectId
 	}
-	_, err = m.conn.UpdateByIDNoCache(ctx, oid, bson.M{
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
 		"$set": bson.M{
 			"vip_expire_time": expireTime,
 			"update_time":     time.Now(),
@@ -133,3 +285,53 @@ func (m *MongoMapper) FindUsersNearExpiry(ctx context.Context, expireAfter, expi
 	}
 	return users, nil
 }
+
+// Search 管理后台按手机号/用户名模糊搜索用户，可按角色、状态过滤
+func (m *MongoMapper) Search(ctx context.Context, keyword, role string, status *int64, p *basic.PaginationOptions) ([]*User, int64, error) {
+	filter := bson.M{}
+	if keyword != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(keyword), Options: "i"}
+		filter["$or"] = bson.A{
+			bson.M{consts.Phone: pattern},
+			bson.M{"username": pattern},
+		}
+	}
+	if role != "" {
+		filter["role"] = role
+	}
+	if status != nil {
+		filter[consts.Status] = *status
+	}
+
+	skip, limit := util.ParsePageOpt(p)
+	users := make([]*User, 0, limit)
+	err := m.conn.Find(ctx, &users, filter, &options.FindOptions{
+		Skip:  &skip,
+		Limit: &limit,
+		Sort:  bson.M{consts.CreateTime: -1},
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total, err := m.conn.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// UpdateStatus 更新用户状态（封禁/解封）
+func (m *MongoMapper) UpdateStatus(ctx context.Context, id string, status int) error {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return consts.ErrInvalidObjectId
+	}
+	_, err = m.conn.UpdateByID(ctx, prefixUserCacheKey+oid.Hex(), oid, bson.M{
+		"$set": bson.M{
+			consts.Status: status,
+			"update_time": time.Now(),
+		},
+	})
+	return err
+}
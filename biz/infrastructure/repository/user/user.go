@@ -14,7 +14,15 @@ type User struct {
 	Status   int                `bson:"status" json:"status"`
 	School   string             `bson:"school" json:"school"`
 	Grade    int64              `bson:"grade" json:"grade"` // 默认0，从一开始依次递增
-	Role     string             `bson:"role" json:"role"`   // 用户角色：student/teacher/admin
+	Role     string             `bson:"role" json:"role"`   // 用户角色：student/teacher/admin/parent
+	// HasPassword 是否已在中台设置过账号密码，密码本身只存在中台
+	HasPassword bool `bson:"has_password,omitempty" json:"hasPassword"`
+	// OcrCount 每日免费 OCR 次数用完后使用的识别次数，与批改次数分开
+	OcrCount int64 `bson:"ocr_count,omitempty" json:"ocrCount"`
+	// Watermark 导出批改结果时的水印设置，为空时按各下载接口的默认行为
+	Watermark *Watermark `bson:"watermark,omitempty" json:"watermark,omitempty"`
+	// Notify 通知渠道偏好与绑定的邮箱，为空时默认走微信
+	Notify *NotifySetting `bson:"notify,omitempty" json:"notify,omitempty"`
 	// MBA 记忆摘要，key 为 essay_type（如 "199_lunxiao"），value 为上次批改后更新的 memory_summary
 	MbaMemory map[string]string `bson:"mba_memory,omitempty" json:"mbaMemory"`
 	// VipExpireTime 是会员是否生效的唯一来源：会员为一次性购买时长（xpay 虚拟支付），无自动续费，
@@ -25,6 +33,22 @@ type User struct {
 	DeleteTime    time.Time `bson:"delete_time,omitempty" json:"deleteTime"`
 }
 
+// Watermark 导出水印设置，Text 为空时使用用户名
+type Watermark struct {
+	Disabled bool   `bson:"disabled" json:"disabled"`
+	Text     string `bson:"text,omitempty" json:"text,omitempty"` // 学校名、班级名等
+}
+
+// NotifySetting 通知偏好，Channel 为 consts.NotifyChannelXxx，是未单独设置的事件使用的默认渠道
+type NotifySetting struct {
+	Email      string                 `bson:"email,omitempty" json:"email,omitempty"`
+	Channel    string                 `bson:"channel,omitempty" json:"channel,omitempty"`
+	Preference NotificationPreference `bson:"preference,omitempty" json:"preference,omitempty"`
+}
+
+// NotificationPreference 按事件类型选择的接收渠道，key 为 consts.NotifyEventXxx
+type NotificationPreference map[string]string
+
 func IsVipActive(u *User) bool {
 	return u.VipExpireTime.After(time.Now())
 }
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"context"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/archive"
+	"fmt"
+	"net/http"
+)
+
+// CosArchiveStore 归档数据的 cos 存储，每次读写按归档目录申请临时密钥并生成加签 url
+type CosArchiveStore struct {
+	client DownstreamClient
+}
+
+var _ archive.Store = (*CosArchiveStore)(nil)
+
+func NewArchiveStore(client DownstreamClient) archive.Store {
+	return &CosArchiveStore{client: client}
+}
+
+func (s *CosArchiveStore) Put(ctx context.Context, key string, data []byte) error {
+	url, err := s.signedUrl(ctx, http.MethodPut, key)
+	if err != nil {
+		return err
+	}
+	return s.client.PutObject(ctx, url, "application/gzip", data)
+}
+
+func (s *CosArchiveStore) Get(ctx context.Context, key string) ([]byte, error) {
+	url, err := s.signedUrl(ctx, http.MethodGet, key)
+	if err != nil {
+		return nil, err
+	}
+	return s.client.GetObject(ctx, url)
+}
+
+func (s *CosArchiveStore) signedUrl(ctx context.Context, method, key string) (string, error) {
+	sts, err := s.client.GenCosSts(ctx, fmt.Sprintf("essays_%s/%s/*", config.GetConfig().State, consts.ArchiveCosDir))
+	if err != nil {
+		return "", fmt.Errorf("申请cos临时密钥失败: %w", err)
+	}
+	cred, ok := sts["data"].(map[string]any)
+	if code, _ := sts["code"].(float64); code != 0 || !ok {
+		return "", errors.New("申请cos临时密钥失败")
+	}
+	secretId, _ := cred["secretId"].(string)
+	secretKey, _ := cred["secretKey"].(string)
+	signed, err := s.client.GenSignedUrl(ctx, secretId, secretKey, method, key, nil)
+	if err != nil {
+		return "", err
+	}
+	data, ok := signed["data"].(map[string]any)
+	if code, _ := signed["code"].(float64); code != 0 || !ok {
+		return "", errors.New("生成加签url失败")
+	}
+	url, _ := data["signedUrl"].(string)
+	return url, nil
+}
@@ -8,6 +8,7 @@ import (
 	"errors"
 	"essay-show/biz/infrastructure/config"
 	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
 	"essay-show/biz/infrastructure/repository/class"
 	"essay-show/biz/infrastructure/repository/homework"
 	"essay-show/biz/infrastructure/util/log"
@@ -15,6 +16,7 @@ import (
 	"io"
 	"net/http"
 	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -46,7 +48,9 @@ func GetHttpClient() *HttpClient {
 }
 
 // SendRequest 发送 HTTP 请求
-func (c *HttpClient) SendRequest(ctx context.Context, method, url string, headers map[string]string, body interface{}) (map[string]interface{}, error) {
+func (c *HttpClient) SendRequest(ctx context.Context, method, url string, headers map[string]string, body interface{}) (_ map[string]interface{}, err error) {
+	defer func(start time.Time) { metrics.ObserveDownstream(url, start, err) }(time.Now())
+
 	// 创建子span用于追踪HTTP请求
 	// tracer := otel.Tracer("essay-show-http-client")
 	// ctx, span := tracer.Start(ctx, fmt.Sprintf("HTTP %s", method))
@@ -68,9 +72,13 @@ func (c *HttpClient) SendRequest(ctx context.Context, method, url string, header
 	}
 
 	// 设置请求头
+	setEnvHeader(req, url)
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
+	if requestId := log.RequestId(ctx); requestId != "" {
+		req.Header.Set(log.HeaderRequestId, requestId)
+	}
 
 	// 发送请求
 	resp, err := c.Client.Do(req)
@@ -111,9 +119,26 @@ func (c *HttpClient) SendRequest(ctx context.Context, method, url string, header
 	return responseMap, nil
 }
 
+// setEnvHeader 按 profile 为中台和无状态服务的请求注入 X-Xh-Env，由对应环境的下游处理，其余下游不注入
+func setEnvHeader(req *http.Request, url string) {
+	c := config.GetConfig()
+	env := c.XhEnv()
+	if env == "" {
+		return
+	}
+	for _, base := range []string{c.Api.PlatfromURL, c.Api.StatelessURL} {
+		if base != "" && strings.HasPrefix(url, base) {
+			req.Header.Set("X-Xh-Env", env)
+			return
+		}
+	}
+}
+
 // SendRequestStream 发送流式 HTTP 请求，支持context和链路追踪
 // 使用标准HTTP客户端而非Hertz客户端，确保trace context自动传递
-func (c *HttpClient) SendRequestStream(ctx context.Context, method, url string, headers map[string]string, body interface{}, resultChan chan<- string) error {
+func (c *HttpClient) SendRequestStream(ctx context.Context, method, url string, headers map[string]string, body interface{}, resultChan chan<- string) (err error) {
+	defer func(start time.Time) { metrics.ObserveDownstream(url, start, err) }(time.Now())
+
 	// 创建span用于追踪流式HTTP请求
 	tracer := otel.Tracer("essay-show-http-client")
 	ctx, span := tracer.Start(ctx, "SendRequestStream")
@@ -147,9 +172,13 @@ func (c *HttpClient) SendRequestStream(ctx context.Context, method, url string,
 	req.Header.Set("Connection", "keep-alive")
 
 	// 设置自定义请求头
+	setEnvHeader(req, url)
 	for key, value := range headers {
 		req.Header.Set(key, value)
 	}
+	if requestId := log.RequestId(ctx); requestId != "" {
+		req.Header.Set(log.HeaderRequestId, requestId)
+	}
 
 	// 发送请求
 	resp, err := c.Client.Do(req)
@@ -302,6 +331,27 @@ func (c *HttpClient) BindAuth(ctx context.Context, authType string, authId strin
 	return resp, nil
 }
 
+// SetPassword 在中台设置或修改账号密码，oldPassword 不为空时中台会校验原密码
+func (c *HttpClient) SetPassword(ctx context.Context, userId string, password string, oldPassword *string) (map[string]interface{}, error) {
+	body := make(map[string]interface{})
+	body["userId"] = userId
+	body["password"] = password
+	if oldPassword != nil {
+		body["oldPassword"] = *oldPassword
+	}
+	body["appId"] = consts.AppId
+
+	header := make(map[string]string)
+	header["Content-Type"] = consts.ContentTypeJson
+	header["Charset"] = consts.CharSetUTF8
+
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.PlatfromURL+"/sts/set_password", header, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 // SendVerifyCode SetPassword 用于用户登录
 func (c *HttpClient) SendVerifyCode(ctx context.Context, authType string, authId string) (map[string]interface{}, error) {
 
@@ -313,11 +363,6 @@ func (c *HttpClient) SendVerifyCode(ctx context.Context, authType string, authId
 	header["Content-Type"] = consts.ContentTypeJson
 	header["Charset"] = consts.CharSetUTF8
 
-	// 如果是测试环境则向测试环境中台发送请求
-	if config.GetConfig().State == "test" {
-		header["X-Xh-Env"] = "test"
-	}
-
 	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.PlatfromURL+"/sts/send_verify_code", header, body)
 	if err != nil {
 		return nil, err
@@ -326,7 +371,7 @@ func (c *HttpClient) SendVerifyCode(ctx context.Context, authType string, authId
 }
 
 // TitleUrlOCR ocr - 带标题
-func (c *HttpClient) TitleUrlOCR(ctx context.Context, images []string, left string) (map[string]interface{}, error) {
+func (c *HttpClient) TitleUrlOCR(ctx context.Context, images []string, left string, preprocess []string) (map[string]interface{}, error) {
 	body := make(map[string]interface{})
 	// 图片url列表
 	body["images"] = images
@@ -334,12 +379,13 @@ func (c *HttpClient) TitleUrlOCR(ctx context.Context, images []string, left stri
 	if len(left) > 0 {
 		body["leftType"] = left
 	}
+	// 识别前的图片预处理，由下游完成
+	if len(preprocess) > 0 {
+		body["preprocess"] = preprocess
+	}
 
 	header := make(map[string]string)
 	header["Content-Type"] = consts.ContentTypeJson
-	if config.GetConfig().State == "test" {
-		header["X-Xh-Env"] = "test"
-	}
 
 	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.StatelessURL+"/sts/ocr/title/ark/url", header, body)
 	if err != nil {
@@ -349,23 +395,10 @@ func (c *HttpClient) TitleUrlOCR(ctx context.Context, images []string, left stri
 }
 
 // OcrExtract 调用 OCR 接口并提取 title / content，供 homework 和 MBA 批改共用。
+// images 中可以包含 PDF 扫描件，按页识别后合并。
 // 返回 (title, content, error)。
 func (c *HttpClient) OcrExtract(ctx context.Context, images []string) (title, content string, err error) {
-	resp, err := c.TitleUrlOCR(ctx, images, "")
-	if err != nil {
-		return "", "", err
-	}
-	code, _ := resp["code"].(float64)
-	if code != 0 {
-		return "", "", fmt.Errorf("OCR 接口返回错误码 %.0f", code)
-	}
-	data, ok := resp["data"].(map[string]any)
-	if !ok {
-		return "", "", fmt.Errorf("OCR 响应 data 字段格式非法")
-	}
-	title, _ = data["title"].(string)
-	content, _ = data["content"].(string)
-	return title, content, nil
+	return c.OcrMerged(ctx, images, "", nil)
 }
 
 func (c *HttpClient) GetEssayInfo(ctx context.Context, essay string, title string) (map[string]interface{}, error) {
@@ -383,15 +416,38 @@ func (c *HttpClient) GetEssayInfo(ctx context.Context, essay string, title strin
 	return resp, nil
 }
 
+// DetectAIText 调用算法服务检测作文是否由 AI 生成，返回 0~1 的疑似度，越大越可能是 AI 生成
+func (c *HttpClient) DetectAIText(ctx context.Context, title, text string) (float64, error) {
+	body := make(map[string]any)
+	body["title"] = title
+	body["essay"] = text
+
+	header := make(map[string]string)
+	header["Content-Type"] = consts.ContentTypeJson
+	header["Charset"] = consts.CharSetUTF8
+
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/ai_detect", header, body)
+	if err != nil {
+		return 0, err
+	}
+	data, ok := resp["data"].(map[string]any)
+	if code, _ := resp["code"].(float64); code != 0 || !ok {
+		msg, _ := resp["msg"].(string)
+		return 0, fmt.Errorf("AI 生成检测失败: %s", msg)
+	}
+	score, ok := data["score"].(float64)
+	if !ok || score < 0 || score > 1 {
+		return 0, fmt.Errorf("AI 生成检测结果不合法: %v", data["score"])
+	}
+	return score, nil
+}
+
 func (c *HttpClient) GenCosSts(ctx context.Context, path string) (map[string]any, error) {
 	body := make(map[string]any)
 	body["path"] = path
 
 	header := make(map[string]string)
 	header["Content-Type"] = consts.ContentTypeJson
-	if config.GetConfig().State == "test" {
-		header["X-Xh-Env"] = "test"
-	}
 
 	URL := config.GetConfig().Api.PlatfromURL + "/sts/gen_cos_sts"
 	resp, err := c.SendRequest(ctx, consts.Post, URL, header, body)
@@ -410,11 +466,7 @@ func (c *HttpClient) SendWechatMessage(ctx context.Context, userId, templateId s
 		body["page"] = *page
 	}
 
-	if config.GetConfig().State == "test" {
-		body["miniProgramState"] = "trial"
-	} else {
-		body["miniProgramState"] = "formal"
-	}
+	body["miniProgramState"] = config.GetConfig().MiniProgramState()
 
 	header := make(map[string]string)
 	header["Content-Type"] = consts.ContentTypeJson
@@ -428,18 +480,66 @@ func (c *HttpClient) SendWechatMessage(ctx context.Context, userId, templateId s
 	return resp, nil
 }
 
-func (c *HttpClient) GenSignedUrl(ctx context.Context, secretId, secretKey string, method string, path string) (map[string]any, error) {
+// TextSecurityCheck 调用中台内容安全接口检测文本，返回是否通过与命中的违规标签
+func (c *HttpClient) TextSecurityCheck(ctx context.Context, text string) (bool, []string, error) {
+	body := make(map[string]any)
+	body["content"] = text
+
+	header := make(map[string]string)
+	header["Content-Type"] = consts.ContentTypeJson
+	header["Charset"] = consts.CharSetUTF8
+
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.PlatfromURL+"/sts/text_check", header, body)
+	if err != nil {
+		return false, nil, err
+	}
+	data, ok := resp["data"].(map[string]any)
+	if code, _ := resp["code"].(float64); code != 0 || !ok {
+		msg, _ := resp["msg"].(string)
+		return false, nil, fmt.Errorf("内容安全检测失败: %s", msg)
+	}
+	pass, _ := data["pass"].(bool)
+	var labels []string
+	if list, ok := data["labels"].([]any); ok {
+		for _, l := range list {
+			if label, ok := l.(string); ok {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return pass, labels, nil
+}
+
+// SendSms 通过中台短信通道发送模板短信
+func (c *HttpClient) SendSms(ctx context.Context, phone, templateId string, params map[string]string) (map[string]any, error) {
+	body := make(map[string]any)
+	body["phone"] = phone
+	body["templateId"] = templateId
+	body["params"] = params
+
+	header := make(map[string]string)
+	header["Content-Type"] = consts.ContentTypeJson
+	header["Charset"] = consts.CharSetUTF8
+
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.PlatfromURL+"/sts/send_sms", header, body)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *HttpClient) GenSignedUrl(ctx context.Context, secretId, secretKey string, method string, path string, headers map[string]string) (map[string]any, error) {
 	body := make(map[string]any)
 	body["secretId"] = secretId
 	body["secretKey"] = secretKey
 	body["method"] = method
 	body["path"] = path
+	if len(headers) > 0 {
+		body["headers"] = headers
+	}
 
 	header := make(map[string]string)
 	header["Content-Type"] = consts.ContentTypeJson
-	if config.GetConfig().State == "test" {
-		header["X-Xh-Env"] = "test"
-	}
 
 	URL := config.GetConfig().Api.PlatfromURL + "/sts/gen_signed_url"
 	resp, err := c.SendRequest(ctx, consts.Post, URL, header, body)
@@ -459,11 +559,7 @@ func (c *HttpClient) GenerateUrlLink(ctx context.Context, appId string, path *st
 		body["query"] = *query
 	}
 
-	if config.GetConfig().State == "test" {
-		body["miniProgramState"] = "trial"
-	} else {
-		body["miniProgramState"] = "formal"
-	}
+	body["miniProgramState"] = config.GetConfig().MiniProgramState()
 
 	header := make(map[string]string)
 	header["Content-Type"] = consts.ContentTypeJson
@@ -519,7 +615,8 @@ func CalculateScoreRatio(grade int64, totalScore int64) *ScoreRatio {
 	return ratio
 }
 
-func (c *HttpClient) EvaluateStream(ctx context.Context, title string, text string, grade, totalScore *int64, essayType *string, prompt *string, standard *string, ratio *ScoreRatio, resultChan chan<- string) error {
+// EvaluateStream 语文作文流式批改，partial 为中断前已完成步骤的中间结果，非空时下游跳过这些步骤直接重放
+func (c *HttpClient) EvaluateStream(ctx context.Context, title string, text string, grade, totalScore *int64, essayType *string, prompt *string, standard *string, ratio *ScoreRatio, partial map[string]any, resultChan chan<- string) error {
 	data := make(map[string]interface{})
 	data["title"] = title
 	data["content"] = text
@@ -552,6 +649,9 @@ func (c *HttpClient) EvaluateStream(ctx context.Context, title string, text stri
 			data["developmentScore"] = 0
 		}
 	}
+	if len(partial) > 0 {
+		data["partial"] = partial
+	}
 
 	headers := make(map[string]string)
 	headers["Content-Type"] = "application/json"
@@ -561,6 +661,32 @@ func (c *HttpClient) EvaluateStream(ctx context.Context, title string, text stri
 	return c.SendRequestStream(ctx, "POST", url, headers, data, resultChan)
 }
 
+// EvaluateEnglishStream 英语作文流式批改，消息格式与 partial 的含义与 EvaluateStream 相同，complete 消息的结果为 stateless.EnglishEvaluate
+func (c *HttpClient) EvaluateEnglishStream(ctx context.Context, title string, text string, grade, totalScore *int64, prompt *string, partial map[string]any, resultChan chan<- string) error {
+	data := make(map[string]any)
+	data["title"] = title
+	data["content"] = text
+	if grade != nil {
+		data["grade"] = *grade
+	}
+	if totalScore != nil {
+		data["totalScore"] = *totalScore
+	}
+	if prompt != nil {
+		data["prompt"] = *prompt
+	}
+	if len(partial) > 0 {
+		data["partial"] = partial
+	}
+
+	headers := make(map[string]string)
+	headers["Content-Type"] = "application/json"
+
+	url := config.GetConfig().Api.StatelessURL + "/evaluate/english/stream"
+
+	return c.SendRequestStream(ctx, "POST", url, headers, data, resultChan)
+}
+
 func (c *HttpClient) EssayPolish(ctx context.Context, data map[string]any) (map[string]any, error) {
 	header := make(map[string]string)
 	header["Content-Type"] = "application/json"
@@ -573,12 +699,27 @@ func (c *HttpClient) EssayPolish(ctx context.Context, data map[string]any) (map[
 	return resp, nil
 }
 
-func (c *HttpClient) LessonPlan(ctx context.Context, classInfo *class.Class, homework *homework.Homework, essayList []map[string]any) (map[string]any, error) {
+// EssayPolishDocx 与 EssayPolish 入参、响应相同，生成可编辑的 docx
+func (c *HttpClient) EssayPolishDocx(ctx context.Context, data map[string]any) (map[string]any, error) {
+	header := make(map[string]string)
+	header["Content-Type"] = "application/json"
+	header["Charset"] = "utf-8"
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/essay_polish_docx", header, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// LessonPlan 生成讲评教案，duration 为课时时长（分钟），focus 为教师指定的讲评侧重点，可为空
+func (c *HttpClient) LessonPlan(ctx context.Context, classInfo *class.Class, homework *homework.Homework, essayList []map[string]any, duration int64, focus string) (map[string]any, error) {
 	lessonPlanData := map[string]any{
 		"class_id":        classInfo.Name,
 		"grade":           homework.Grade,
 		"last_topic":      "",
-		"lesson_duration": 40,
+		"lesson_duration": duration,
+		"focus":           focus,
 		"essays":          essayList,
 	}
 
@@ -592,6 +733,18 @@ func (c *HttpClient) LessonPlan(ctx context.Context, classInfo *class.Class, hom
 	return resp, nil
 }
 
+// ClassReport 生成班级整册批改报告 PDF，封面、目录和统计页由下游按 data 排版，响应格式与 EssayPolish 相同
+func (c *HttpClient) ClassReport(ctx context.Context, data map[string]any) (map[string]any, error) {
+	header := make(map[string]string)
+	header["Content-Type"] = "application/json"
+	header["Charset"] = "utf-8"
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/class_report", header, data)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
 func (c *HttpClient) AnalyzeClassStatistics(ctx context.Context, data map[string]any) (map[string]any, error) {
 	header := make(map[string]string)
 	header["Content-Type"] = "application/json"
@@ -633,6 +786,28 @@ func (c *HttpClient) GradeSingleStudent(ctx context.Context, data map[string]any
 	return resp, nil
 }
 
+// GenerateExercises 按批改结果生成一套练习题
+func (c *HttpClient) GenerateExercises(ctx context.Context, body map[string]any) (map[string]any, error) {
+	return c.SendRequest(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/generate_exercises", exerciseHeader(), body)
+}
+
+// GenerateExercisesStream 流式生成练习题，下游的每条 JSON 消息写入 resultChan
+func (c *HttpClient) GenerateExercisesStream(ctx context.Context, body map[string]any, resultChan chan<- string) error {
+	return c.SendRequestStream(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/generate_exercises_stream", exerciseHeader(), body, resultChan)
+}
+
+// GradeShortAnswer 按参考答案给简答题判分
+func (c *HttpClient) GradeShortAnswer(ctx context.Context, body map[string]any) (map[string]any, error) {
+	return c.SendRequest(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/grade_short_answer", exerciseHeader(), body)
+}
+
+func exerciseHeader() map[string]string {
+	header := make(map[string]string)
+	header["Content-Type"] = consts.ContentTypeJson
+	header["Charset"] = consts.CharSetUTF8
+	return header
+}
+
 func (c *HttpClient) MbaGrade(ctx context.Context, essayType, material, perspectives, essay, memorySummary string) (map[string]interface{}, error) {
 	body := map[string]interface{}{
 		"essay_type":       essayType,
@@ -661,6 +836,20 @@ func (c *HttpClient) OpencourseEssayExportPdf(ctx context.Context, data map[stri
 	return resp, nil
 }
 
+// OpencourseEssayExportDocx 与 OpencourseEssayExportPdf 入参、响应相同，生成可编辑的 docx
+func (c *HttpClient) OpencourseEssayExportDocx(ctx context.Context, data map[string]any) (map[string]any, error) {
+	header := make(map[string]string)
+	header["Content-Type"] = "application/json"
+	header["Charset"] = "utf-8"
+	url := config.GetConfig().Api.WebEndpointURL + "/opencourse_essay_export_docx"
+	resp, err := c.SendRequest(ctx, consts.Post, url, header, data)
+	if err != nil {
+		log.Error("OpencourseEssayExportDocx error: %v, data: %v", err, data)
+		return nil, err
+	}
+	return resp, nil
+}
+
 // VirtualPaySign 调中台生成小程序虚拟支付所需的签名参数（signData/paySig/signature），
 // 供小程序前端直接传给 wx.requestVirtualPayment 发起支付。
 func (c *HttpClient) VirtualPaySign(ctx context.Context, userID, jsCode, productID string, goodsPriceFen int64, outTradeNo string) (signData, paySig, signature string, err error) {
@@ -694,3 +883,16 @@ func (c *HttpClient) VirtualPaySign(ctx context.Context, userID, jsCode, product
 	}
 	return signData, paySig, signature, nil
 }
+
+// Probe 检查下游地址是否可连通，能收到任意 http 响应即认为可用
+func (c *HttpClient) Probe(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
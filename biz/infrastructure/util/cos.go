@@ -0,0 +1,70 @@
+package util
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PutObject 通过 PUT 加签 url 把文件上传到 cos
+func (c *HttpClient) PutObject(ctx context.Context, signedUrl, contentType string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, signedUrl, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("创建上传请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.ContentLength = int64(len(data))
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("上传文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("上传文件失败, status: %d, body: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
+
+// GetObject 通过 GET 加签 url 下载 cos 上的文件
+func (c *HttpClient) GetObject(ctx context.Context, signedUrl string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, signedUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("创建下载请求失败: %w", err)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("下载文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("下载文件失败, status: %d, body: %s", resp.StatusCode, msg)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// DeleteObject 通过 DELETE 加签 url 删除 cos 上的文件，文件不存在时视为成功
+func (c *HttpClient) DeleteObject(ctx context.Context, signedUrl string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, signedUrl, nil)
+	if err != nil {
+		return fmt.Errorf("创建删除请求失败: %w", err)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("删除文件失败: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound:
+		return nil
+	default:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("删除文件失败, status: %d, body: %s", resp.StatusCode, msg)
+	}
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/repository/class"
+	"essay-show/biz/infrastructure/repository/homework"
+)
+
+// DownstreamClient 中台、算法和 cos 等下游调用，service 通过 wire 注入，默认实现为 HttpClient
+type DownstreamClient interface {
+	// 中台账号
+	SignIn(ctx context.Context, authType string, authId string, verifyCode *string, password *string) (map[string]interface{}, error)
+	BindAuth(ctx context.Context, authType string, authId string, verifyCode *string, userId string) (map[string]interface{}, error)
+	SetPassword(ctx context.Context, userId string, password string, oldPassword *string) (map[string]interface{}, error)
+	SendVerifyCode(ctx context.Context, authType string, authId string) (map[string]interface{}, error)
+
+	// 中台消息、支付与小程序
+	SendWechatMessage(ctx context.Context, userId, templateId string, templateData map[string]string, page *string) (map[string]any, error)
+	SendSms(ctx context.Context, phone, templateId string, params map[string]string) (map[string]any, error)
+	GenerateUrlLink(ctx context.Context, appId string, path *string, query *string) (map[string]any, error)
+	VirtualPaySign(ctx context.Context, userID, jsCode, productID string, goodsPriceFen int64, outTradeNo string) (signData, paySig, signature string, err error)
+	TextSecurityCheck(ctx context.Context, text string) (pass bool, labels []string, err error)
+
+	// cos
+	GenCosSts(ctx context.Context, path string) (map[string]any, error)
+	// GenSignedUrl headers 为需要一并签名的请求头，请求时与签名不一致的会被 cos 拒绝
+	GenSignedUrl(ctx context.Context, secretId, secretKey string, method string, path string, headers map[string]string) (map[string]any, error)
+	PutObject(ctx context.Context, signedUrl, contentType string, data []byte) error
+	GetObject(ctx context.Context, signedUrl string) ([]byte, error)
+	DeleteObject(ctx context.Context, signedUrl string) error
+
+	// OCR
+	TitleUrlOCR(ctx context.Context, images []string, left string, preprocess []string) (map[string]interface{}, error)
+	OcrExtract(ctx context.Context, images []string) (title, content string, err error)
+	OcrMerged(ctx context.Context, urls []string, left string, preprocess []string) (title, content string, err error)
+	OcrWithConfidence(ctx context.Context, urls []string, left string, preprocess []string) (title, content string, confidences []float64, err error)
+	PdfToImages(ctx context.Context, pdfUrl string) ([]string, error)
+
+	// 批改与报告
+	GetEssayInfo(ctx context.Context, essay string, title string) (map[string]interface{}, error)
+	DetectAIText(ctx context.Context, title, text string) (float64, error)
+	EvaluateStream(ctx context.Context, title string, text string, grade, totalScore *int64, essayType *string, prompt *string, standard *string, ratio *ScoreRatio, partial map[string]any, resultChan chan<- string) error
+	EvaluateEnglishStream(ctx context.Context, title string, text string, grade, totalScore *int64, prompt *string, partial map[string]any, resultChan chan<- string) error
+	EssayPolish(ctx context.Context, data map[string]any) (map[string]any, error)
+	EssayPolishDocx(ctx context.Context, data map[string]any) (map[string]any, error)
+	LessonPlan(ctx context.Context, classInfo *class.Class, homework *homework.Homework, essayList []map[string]any, duration int64, focus string) (map[string]any, error)
+	ClassReport(ctx context.Context, data map[string]any) (map[string]any, error)
+	AnalyzeClassStatistics(ctx context.Context, data map[string]any) (map[string]any, error)
+	ExtractRubricCategories(ctx context.Context, data map[string]any) (map[string]any, error)
+	GradeSingleStudent(ctx context.Context, data map[string]any) (map[string]any, error)
+	MbaGrade(ctx context.Context, essayType, material, perspectives, essay, memorySummary string) (map[string]interface{}, error)
+	OpencourseEssayExportPdf(ctx context.Context, data map[string]any) (map[string]any, error)
+	OpencourseEssayExportDocx(ctx context.Context, data map[string]any) (map[string]any, error)
+
+	// 练习
+	GenerateExercises(ctx context.Context, body map[string]any) (map[string]any, error)
+	GenerateExercisesStream(ctx context.Context, body map[string]any, resultChan chan<- string) error
+	GradeShortAnswer(ctx context.Context, body map[string]any) (map[string]any, error)
+
+	// 健康检查
+	Probe(ctx context.Context, url string) error
+}
+
+var _ DownstreamClient = (*HttpClient)(nil)
+
+// NewDownstreamClient 默认实现，复用全局的 HttpClient
+func NewDownstreamClient() DownstreamClient {
+	return GetHttpClient()
+}
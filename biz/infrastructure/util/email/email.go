@@ -0,0 +1,101 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotConfigured 未配置 SMTP 时不发送邮件
+var ErrNotConfigured = errors.New("smtp 未配置")
+
+// Send 通过 SMTP 发送一封纯文本邮件，465 端口使用 SSL 直连，其余端口按服务端支持情况升级 STARTTLS
+func Send(to, subject, body string) error {
+	c := config.GetConfig().Smtp
+	if c.Host == "" {
+		return ErrNotConfigured
+	}
+	from := c.From
+	if from == "" {
+		from = c.Username
+	}
+
+	addr := net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+	tlsConfig := &tls.Config{ServerName: c.Host}
+	// 连接和之后的整个收发过程都受 consts.SmtpTimeout 限制，smtp 服务无响应时不会一直挂起
+	dialer := &net.Dialer{Timeout: consts.SmtpTimeout}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if c.Port == 465 {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("连接 smtp 服务失败: %w", err)
+	}
+	if err = conn.SetDeadline(time.Now().Add(consts.SmtpTimeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 smtp 服务失败: %w", err)
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("连接 smtp 服务失败: %w", err)
+	}
+	if c.Port != 465 {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err = client.StartTLS(tlsConfig); err != nil {
+				client.Close()
+				return fmt.Errorf("smtp starttls 失败: %w", err)
+			}
+		}
+	}
+	defer client.Close()
+
+	if c.Username != "" {
+		if err = client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return fmt.Errorf("smtp 认证失败: %w", err)
+		}
+	}
+	if err = client.Mail(from); err != nil {
+		return err
+	}
+	if err = client.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(buildMessage(from, to, subject, body)); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+func buildMessage(from, to, subject, body string) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -3,7 +3,7 @@ package exercise
 import (
 	"context"
 	"encoding/json"
-	"essay-show/biz/infrastructure/config"
+	"errors"
 	"essay-show/biz/infrastructure/consts"
 	"essay-show/biz/infrastructure/repository/exercise"
 	"essay-show/biz/infrastructure/repository/log"
@@ -11,12 +11,21 @@ import (
 	logx "essay-show/biz/infrastructure/util/log"
 )
 
-func GenerateExercise(ctx context.Context, grade int64, l *log.Log) (*exercise.Exercise, error) {
+// ValidDifficulty 难度为空表示不指定，由下游决定
+func ValidDifficulty(d string) bool {
+	switch d {
+	case "", consts.DifficultyBasic, consts.DifficultyImprove, consts.DifficultyAdvanced:
+		return true
+	}
+	return false
+}
+
+func GenerateExercise(ctx context.Context, client util.DownstreamClient, grade int64, difficulty string, l *log.Log) (*exercise.Exercise, error) {
 	m, err := parseLog(l)
 	if err != nil {
 		return nil, err
 	}
-	resp, err := generateByHttp(ctx, grade, m)
+	resp, err := client.GenerateExercises(ctx, buildBody(grade, difficulty, m))
 	if err != nil {
 		return nil, err
 	}
@@ -27,7 +36,7 @@ func GenerateExercise(ctx context.Context, grade int64, l *log.Log) (*exercise.E
 	return e, nil
 }
 
-func GenerateExerciseStream(ctx context.Context, grade int64, l *log.Log, resultChan chan<- string) (*exercise.Exercise, error) {
+func GenerateExerciseStream(ctx context.Context, client util.DownstreamClient, grade int64, difficulty string, l *log.Log, resultChan chan string) (*exercise.Exercise, error) {
 	// 创建下游JSON字符串通道
 	downstreamChan := make(chan string, 100)
 	defer close(downstreamChan)
@@ -37,17 +46,11 @@ func GenerateExerciseStream(ctx context.Context, grade int64, l *log.Log, result
 		return nil, err
 	}
 
-	header := make(map[string]string)
-	header["Content-Type"] = consts.ContentTypeJson
-	header["Charset"] = consts.CharSetUTF8
-
-	body := buildBody(grade, m)
-	client := util.GetHttpClient()
-	url := config.GetConfig().Api.AlgorithmURL + "/generate_exercises_stream"
-
-	go client.SendRequestStream(ctx, consts.Post, url, header, body, downstreamChan)
+	go client.GenerateExercisesStream(ctx, buildBody(grade, difficulty, m), downstreamChan)
 
-	cqs := make([]*exercise.ChoiceQuestion, 0)
+	que := &exercise.Question{
+		ChoiceQuestions: make([]*exercise.ChoiceQuestion, 0),
+	}
 	for jsonMessage := range downstreamChan {
 		// 解析JSON消息
 		var data map[string]any
@@ -60,18 +63,17 @@ func GenerateExerciseStream(ctx context.Context, grade int64, l *log.Log, result
 			break
 		}
 
-		cq, _ := parseExerciseFromStream(data)
-		cqs = append(cqs, cq)
+		qType, q, err := parseExerciseFromStream(que, data)
+		if err != nil {
+			logx.Error("解析下游题目失败: %v", err)
+			continue
+		}
 
-		// 返回部分数据
-		util.SendStreamMessage(resultChan, util.STPart, "", cq)
+		// 返回部分数据，message 为题型
+		util.SendStreamMessage(resultChan, util.STPart, qType, q)
 	}
 
 	// 构建最终练习对象
-	que := &exercise.Question{
-		ChoiceQuestions: cqs,
-	}
-
 	records := make([]*exercise.Records, 0)
 	h := &exercise.History{
 		Records: records,
@@ -95,41 +97,23 @@ func parseLog(l *log.Log) (map[string]any, error) {
 }
 
 func parseExercise(resp map[string]any) (*exercise.Exercise, error) {
-	// 选择题数组
-	cqs := make([]*exercise.ChoiceQuestion, 0)
+	que := &exercise.Question{
+		ChoiceQuestions: make([]*exercise.ChoiceQuestion, 0),
+	}
 
 	// 题目数组
-	questions := resp["result"].([]any)
+	questions, ok := resp["result"].([]any)
+	if !ok {
+		return nil, errors.New("下游返回的题目格式错误")
+	}
 	for _, question := range questions {
-		q := question.(map[string]any)
-		cq := &exercise.ChoiceQuestion{Options: make([]*exercise.Option, 0)}
-		for k, v := range q {
-			switch k {
-			case "question":
-				cq.Question = v.(string)
-			case "explaion":
-				fallthrough
-			case "explanation":
-				cq.Explanation = v.(string)
-			case "id":
-				cq.Id = v.(string)
-			default:
-				detailQuestion := v.(map[string]any)
-				opt := &exercise.Option{
-					Option:  k,
-					Content: detailQuestion["content"].(string),
-					Score:   int64(detailQuestion["score"].(float64)),
-				}
-				cq.Options = append(cq.Options, opt)
-			}
+		q, ok := question.(map[string]any)
+		if !ok {
+			continue
 		}
-		cqs = append(cqs, cq)
+		addQuestion(que, q)
 	}
 
-	// 题目列表
-	q := &exercise.Question{
-		ChoiceQuestions: cqs,
-	}
 	// 作答记录
 	records := make([]*exercise.Records, 0)
 	h := &exercise.History{
@@ -137,7 +121,7 @@ func parseExercise(resp map[string]any) (*exercise.Exercise, error) {
 	}
 	// 练习
 	e := &exercise.Exercise{
-		Question: q,
+		Question: que,
 		History:  h,
 		Like:     0,
 		Status:   0,
@@ -145,51 +129,103 @@ func parseExercise(resp map[string]any) (*exercise.Exercise, error) {
 	return e, nil
 }
 
-func parseExerciseFromStream(result map[string]any) (*exercise.ChoiceQuestion, error) {
+// parseExerciseFromStream 解析流式返回的单道题目并追加到 que，返回题型与解析出的题目
+func parseExerciseFromStream(que *exercise.Question, result map[string]any) (string, any, error) {
+	content, _ := result["content"].(string)
 	var q map[string]any
-	json.Unmarshal([]byte(result["content"].(string)), &q)
+	if err := json.Unmarshal([]byte(content), &q); err != nil {
+		return "", nil, err
+	}
+	qType, parsed := addQuestion(que, q)
+	return qType, parsed, nil
+}
 
+// addQuestion 按题型分支解析一道题目并追加到 que，未声明题型的按选择题处理
+func addQuestion(que *exercise.Question, q map[string]any) (string, any) {
+	switch q["type"] {
+	case consts.QuestionTypeFill:
+		fq := parseFillQuestion(q)
+		que.FillQuestions = append(que.FillQuestions, fq)
+		return consts.QuestionTypeFill, fq
+	case consts.QuestionTypeShort:
+		sq := parseShortQuestion(q)
+		que.ShortQuestions = append(que.ShortQuestions, sq)
+		return consts.QuestionTypeShort, sq
+	default:
+		cq := parseChoiceQuestion(q)
+		que.ChoiceQuestions = append(que.ChoiceQuestions, cq)
+		return consts.QuestionTypeChoice, cq
+	}
+}
+
+// parseChoiceQuestion 除固定字段外，其余的键都是选项
+func parseChoiceQuestion(q map[string]any) *exercise.ChoiceQuestion {
 	cq := &exercise.ChoiceQuestion{Options: make([]*exercise.Option, 0)}
 	for k, v := range q {
 		switch k {
+		case "type":
 		case "question":
-			cq.Question = v.(string)
+			cq.Question, _ = v.(string)
 		case "explaion":
 			fallthrough
 		case "explanation":
-			cq.Explanation = v.(string)
+			cq.Explanation, _ = v.(string)
 		case "id":
-			cq.Id = v.(string)
+			cq.Id, _ = v.(string)
+		case "knowledge":
+			cq.Knowledge, _ = v.(string)
 		default:
-			detailQuestion := v.(map[string]any)
-			opt := &exercise.Option{
-				Option:  k,
-				Content: detailQuestion["content"].(string),
-				Score:   int64(detailQuestion["score"].(float64)),
+			detailQuestion, ok := v.(map[string]any)
+			if !ok {
+				continue
 			}
-			cq.Options = append(cq.Options, opt)
+			content, _ := detailQuestion["content"].(string)
+			score, _ := detailQuestion["score"].(float64)
+			cq.Options = append(cq.Options, &exercise.Option{
+				Option:  k,
+				Content: content,
+				Score:   int64(score),
+			})
 		}
 	}
-
-	return cq, nil
+	return cq
 }
 
-func generateByHttp(ctx context.Context, grade int64, m map[string]any) (map[string]any, error) {
-	header := make(map[string]string)
-	header["Content-Type"] = consts.ContentTypeJson
-	header["Charset"] = consts.CharSetUTF8
-
-	body := buildBody(grade, m)
-
-	client := util.GetHttpClient()
-	resp, err := client.SendRequest(ctx, consts.Post, config.GetConfig().Api.AlgorithmURL+"/generate_exercises", header, body)
-	if err != nil {
-		return nil, err
+// parseFillQuestion answer 可以是单个答案或答案数组
+func parseFillQuestion(q map[string]any) *exercise.FillQuestion {
+	fq := &exercise.FillQuestion{Answers: make([]string, 0)}
+	fq.Id, _ = q["id"].(string)
+	fq.Question, _ = q["question"].(string)
+	fq.Explanation, _ = q["explanation"].(string)
+	fq.Knowledge, _ = q["knowledge"].(string)
+	score, _ := q["score"].(float64)
+	fq.Score = int64(score)
+	switch a := q["answer"].(type) {
+	case string:
+		fq.Answers = append(fq.Answers, a)
+	case []any:
+		for _, v := range a {
+			if s, ok := v.(string); ok {
+				fq.Answers = append(fq.Answers, s)
+			}
+		}
 	}
-	return resp, nil
+	return fq
+}
+
+func parseShortQuestion(q map[string]any) *exercise.ShortQuestion {
+	sq := &exercise.ShortQuestion{}
+	sq.Id, _ = q["id"].(string)
+	sq.Question, _ = q["question"].(string)
+	sq.Explanation, _ = q["explanation"].(string)
+	sq.Knowledge, _ = q["knowledge"].(string)
+	sq.Reference, _ = q["answer"].(string)
+	score, _ := q["score"].(float64)
+	sq.Score = int64(score)
+	return sq
 }
 
-func buildBody(grade int64, m map[string]any) map[string]any {
+func buildBody(grade int64, difficulty string, m map[string]any) map[string]any {
 	body := make(map[string]any)
 
 	essay := ""
@@ -205,5 +241,8 @@ func buildBody(grade int64, m map[string]any) map[string]any {
 	body["title"] = m["title"]
 	body["essay"] = essay
 	body["result"] = m
+	if difficulty != "" {
+		body["difficulty"] = difficulty
+	}
 	return body
 }
@@ -0,0 +1,43 @@
+package exercise
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/repository/exercise"
+	"essay-show/biz/infrastructure/util"
+	"strings"
+)
+
+// GradeFillAnswer 去掉首尾空白后与任一参考答案一致得满分，否则不得分
+func GradeFillAnswer(q *exercise.FillQuestion, answer string) int64 {
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return 0
+	}
+	for _, a := range q.Answers {
+		if strings.TrimSpace(a) == answer {
+			return q.Score
+		}
+	}
+	return 0
+}
+
+// GradeShortAnswer 调用下游按参考答案给简答题判分，得分限制在 [0, 满分] 内
+func GradeShortAnswer(ctx context.Context, client util.DownstreamClient, q *exercise.ShortQuestion, answer string) (int64, string, error) {
+	if strings.TrimSpace(answer) == "" {
+		return 0, "", nil
+	}
+
+	resp, err := client.GradeShortAnswer(ctx, map[string]any{
+		"question":  q.Question,
+		"reference": q.Reference,
+		"answer":    answer,
+		"score":     q.Score,
+	})
+	if err != nil {
+		return 0, "", err
+	}
+
+	score, _ := resp["score"].(float64)
+	comment, _ := resp["comment"].(string)
+	return min(max(int64(score), 0), q.Score), comment, nil
+}
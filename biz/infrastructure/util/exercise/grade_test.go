@@ -0,0 +1,47 @@
+package exercise
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/repository/exercise"
+	"essay-show/biz/infrastructure/util"
+	"testing"
+)
+
+type fakeDownstream struct {
+	util.DownstreamClient
+	calls int
+	resp  map[string]any
+}
+
+func (f *fakeDownstream) GradeShortAnswer(_ context.Context, _ map[string]any) (map[string]any, error) {
+	f.calls++
+	return f.resp, nil
+}
+
+func TestGradeShortAnswer(t *testing.T) {
+	q := &exercise.ShortQuestion{Id: "S01", Question: "q", Reference: "r", Score: 10}
+	tests := []struct {
+		name      string
+		answer    string
+		resp      map[string]any
+		wantScore int64
+		wantCalls int
+	}{
+		{name: "blank answer skips downstream", answer: "  ", wantScore: 0, wantCalls: 0},
+		{name: "normal", answer: "a", resp: map[string]any{"score": float64(6), "comment": "ok"}, wantScore: 6, wantCalls: 1},
+		{name: "clamped to full score", answer: "a", resp: map[string]any{"score": float64(15)}, wantScore: 10, wantCalls: 1},
+		{name: "clamped to zero", answer: "a", resp: map[string]any{"score": float64(-3)}, wantScore: 0, wantCalls: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeDownstream{resp: tt.resp}
+			score, _, err := GradeShortAnswer(context.Background(), f, q, tt.answer)
+			if err != nil {
+				t.Fatalf("err = %v", err)
+			}
+			if score != tt.wantScore || f.calls != tt.wantCalls {
+				t.Fatalf("score = %d, calls = %d, want %d, %d", score, f.calls, tt.wantScore, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package exercise
+
+import (
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/repository/exercise"
+	"sort"
+	"time"
+)
+
+const unknownKnowledge = "未分类"
+
+// StatItem 一个题型或知识点的作答统计
+type StatItem struct {
+	Name         string
+	AnswerCount  int64 // 作答题数
+	CorrectCount int64 // 得满分的题数
+}
+
+// DailyStat 一天内的作答统计
+type DailyStat struct {
+	Date         time.Time // 当天 0 点
+	AttemptCount int64
+	AnswerCount  int64
+	CorrectCount int64
+}
+
+// Stats 学生全部练习的作答统计
+type Stats struct {
+	ExerciseCount int64 // 作答过的练习套数
+	AttemptCount  int64 // 累计作答次数
+	AnswerCount   int64
+	CorrectCount  int64
+	QuestionTypes []*StatItem  // 按作答题数倒序
+	Knowledges    []*StatItem  // 按作答题数倒序
+	Trend         []*DailyStat // 最近 days 天，按日期正序，没有作答的日期计 0
+}
+
+// questionMeta 判定一道题归属与是否答对所需的信息
+type questionMeta struct {
+	qType     string
+	knowledge string
+	full      int64
+}
+
+// AnalyzeStats 汇总所有作答记录，题目得满分视为答对
+func AnalyzeStats(es []*exercise.Exercise, now time.Time, days int) *Stats {
+	s := &Stats{}
+	types := make(map[string]*StatItem)
+	knowledges := make(map[string]*StatItem)
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, 1-days)
+	s.Trend = make([]*DailyStat, 0, days)
+	for i := 0; i < days; i++ {
+		s.Trend = append(s.Trend, &DailyStat{Date: start.AddDate(0, 0, i)})
+	}
+
+	for _, e := range es {
+		if e.History == nil || len(e.History.Records) == 0 {
+			continue
+		}
+		s.ExerciseCount++
+		metas := questionMetas(e.Question)
+		for _, rds := range e.History.Records {
+			s.AttemptCount++
+			var day *DailyStat
+			if t := rds.CreateTime.In(now.Location()); !t.Before(start) {
+				if i := int(t.Sub(start).Hours() / 24); i < len(s.Trend) {
+					day = s.Trend[i]
+					day.AttemptCount++
+				}
+			}
+			for _, r := range rds.Records {
+				meta, ok := metas[r.Id]
+				if !ok {
+					continue
+				}
+				correct := meta.full > 0 && r.Score == meta.full
+				count(types, meta.qType, correct)
+				count(knowledges, meta.knowledge, correct)
+				s.AnswerCount++
+				if correct {
+					s.CorrectCount++
+				}
+				if day != nil {
+					day.AnswerCount++
+					if correct {
+						day.CorrectCount++
+					}
+				}
+			}
+		}
+	}
+
+	s.QuestionTypes = sortedItems(types)
+	s.Knowledges = sortedItems(knowledges)
+	return s
+}
+
+func count(items map[string]*StatItem, name string, correct bool) {
+	item, ok := items[name]
+	if !ok {
+		item = &StatItem{Name: name}
+		items[name] = item
+	}
+	item.AnswerCount++
+	if correct {
+		item.CorrectCount++
+	}
+}
+
+func sortedItems(items map[string]*StatItem) []*StatItem {
+	res := make([]*StatItem, 0, len(items))
+	for _, item := range items {
+		res = append(res, item)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].AnswerCount != res[j].AnswerCount {
+			return res[i].AnswerCount > res[j].AnswerCount
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+func questionMetas(q *exercise.Question) map[string]*questionMeta {
+	metas := make(map[string]*questionMeta)
+	if q == nil {
+		return metas
+	}
+	for _, cq := range q.ChoiceQuestions {
+		meta := &questionMeta{qType: consts.QuestionTypeChoice, knowledge: knowledgeOf(cq.Knowledge)}
+		for _, o := range cq.Options {
+			meta.full = max(meta.full, o.Score)
+		}
+		metas[cq.Id] = meta
+	}
+	for _, fq := range q.FillQuestions {
+		metas[fq.Id] = &questionMeta{qType: consts.QuestionTypeFill, knowledge: knowledgeOf(fq.Knowledge), full: fq.Score}
+	}
+	for _, sq := range q.ShortQuestions {
+		metas[sq.Id] = &questionMeta{qType: consts.QuestionTypeShort, knowledge: knowledgeOf(sq.Knowledge), full: sq.Score}
+	}
+	return metas
+}
+
+func knowledgeOf(k string) string {
+	if k == "" {
+		return unknownKnowledge
+	}
+	return k
+}
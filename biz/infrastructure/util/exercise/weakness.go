@@ -0,0 +1,106 @@
+package exercise
+
+import (
+	"context"
+	"encoding/json"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/repository/exercise"
+	"essay-show/biz/infrastructure/repository/log"
+	"essay-show/biz/infrastructure/util"
+	logx "essay-show/biz/infrastructure/util/log"
+	"sort"
+)
+
+const topWrongWords = 10
+
+// Weakness 学生近几篇作文的共性问题，作为生成针对性练习的依据
+type Weakness struct {
+	EssayCount        int            `json:"essayCount"`
+	GrammarMistakeNum int            `json:"grammarMistakeNum"` // 语法错误总数
+	WrittenMistakeNum int            `json:"writtenMistakeNum"` // 书写错误总数
+	MistakeTypes      map[string]int `json:"mistakeTypes"`      // 错误类型 -> 次数
+	WrongWords        []*WrongWord   `json:"wrongWords"`        // 高频错词，按次数倒序
+	PolishReasons     map[string]int `json:"polishReasons"`     // 润色原因 -> 次数
+}
+
+// WrongWord 一个被纠正过的词及其出现次数
+type WrongWord struct {
+	Ori     string `json:"ori"`
+	Revised string `json:"revised"`
+	Count   int    `json:"count"`
+}
+
+// AnalyzeWeakness 汇总多篇批改结果中的错误类型、高频错词与润色原因，解析失败的记录会被跳过
+func AnalyzeWeakness(logs []*log.Log) *Weakness {
+	w := &Weakness{
+		MistakeTypes:  make(map[string]int),
+		PolishReasons: make(map[string]int),
+	}
+	words := make(map[[2]string]int)
+	for _, l := range logs {
+		var e stateless.Evaluate
+		if err := json.Unmarshal([]byte(l.Response), &e); err != nil {
+			logx.Error("解析批改记录失败, logId: %s, err: %v", l.ID.Hex(), err)
+			continue
+		}
+		w.EssayCount++
+		w.GrammarMistakeNum += e.EssayInfo.Counting.GrammarMistakeNum
+		w.WrittenMistakeNum += e.EssayInfo.Counting.WrittenMistakeNum
+
+		for _, para := range e.AIEvaluation.WordSentenceEvaluation.SentenceEvaluations {
+			for _, sent := range para {
+				for _, we := range sent.WordEvaluations {
+					// 只有带修改建议的词才算错误
+					if we.Ori == "" || we.Revised == "" {
+						continue
+					}
+					w.MistakeTypes[MistakeType(we.Type)]++
+					words[[2]string{we.Ori, we.Revised}]++
+				}
+			}
+		}
+		for _, pe := range e.AIEvaluation.PolishingEvaluation {
+			for _, edit := range pe.Edits {
+				if edit.Reason != "" {
+					w.PolishReasons[edit.Reason]++
+				}
+			}
+		}
+	}
+
+	for k, n := range words {
+		w.WrongWords = append(w.WrongWords, &WrongWord{Ori: k[0], Revised: k[1], Count: n})
+	}
+	sort.Slice(w.WrongWords, func(i, j int) bool {
+		return w.WrongWords[i].Count > w.WrongWords[j].Count
+	})
+	if len(w.WrongWords) > topWrongWords {
+		w.WrongWords = w.WrongWords[:topWrongWords]
+	}
+	return w
+}
+
+// MistakeType 优先取细分类型，没有时取大类
+func MistakeType(t map[string]string) string {
+	for _, k := range []string{"level2", "level1"} {
+		if v := t[k]; v != "" {
+			return v
+		}
+	}
+	return "其他"
+}
+
+// GenerateExerciseByWeakness 以最近一篇作文为上下文，附带共性问题生成针对性练习
+func GenerateExerciseByWeakness(ctx context.Context, client util.DownstreamClient, grade int64, difficulty string, latest *log.Log, w *Weakness) (*exercise.Exercise, error) {
+	m, err := parseLog(latest)
+	if err != nil {
+		return nil, err
+	}
+	body := buildBody(grade, difficulty, m)
+	body["weakness"] = w
+	resp, err := client.GenerateExercises(ctx, body)
+	if err != nil {
+		return nil, err
+	}
+	return parseExercise(resp)
+}
@@ -0,0 +1,110 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"essay-show/biz/application/dto/essay/stateless"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"fmt"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Essay 本地渲染的一篇批改结果
+type Essay struct {
+	Name string // 学生姓名或用户名
+	Data *stateless.ExportEvaluate
+}
+
+// evaluateTpl 简版样式：每篇作文一页，依次为原文、得分与各项点评
+var evaluateTpl = template.Must(template.New("evaluate").Funcs(template.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<style>
+body { font-family: "Noto Sans CJK SC", "PingFang SC", sans-serif; font-size: 14px; line-height: 1.8; color: #333; }
+.essay { page-break-after: always; }
+.essay:last-child { page-break-after: auto; }
+h1 { font-size: 20px; text-align: center; margin-bottom: 4px; }
+.name { text-align: center; color: #888; margin-bottom: 16px; }
+h2 { font-size: 16px; border-left: 4px solid #4a90e2; padding-left: 8px; margin-top: 20px; }
+p { text-indent: 2em; margin: 4px 0; }
+.score { font-size: 18px; color: #e25c4a; }
+</style>
+</head>
+<body>
+{{range .}}<div class="essay">
+<h1>{{.Data.Title}}</h1>
+<div class="name">{{.Name}}</div>
+<h2>原文</h2>
+{{range .Data.Text}}<p>{{join . ""}}</p>
+{{end}}
+{{with .Data.AIEvaluation.ScoreEvaluation}}<h2>得分</h2>
+<p class="score">{{.Scores.AllWithTotal}}</p>
+{{if .Comment}}<p>{{.Comment}}</p>{{end}}
+{{if .Comments.Content}}<p>内容：{{.Comments.Content}}</p>{{end}}
+{{if .Comments.Expression}}<p>表达：{{.Comments.Expression}}</p>{{end}}
+{{if .Comments.Structure}}<p>结构：{{.Comments.Structure}}</p>{{end}}
+{{if .Comments.Development}}<p>发展：{{.Comments.Development}}</p>{{end}}
+{{if .Comments.Appearance}}<p>卷面：{{.Comments.Appearance}}</p>{{end}}
+{{end}}
+<h2>总评</h2>
+<p>{{.Data.AIEvaluation.OverallEvaluation.Description}}</p>
+{{with .Data.AIEvaluation.ParagraphEvaluations}}<h2>段落点评</h2>
+{{range .}}<p>第 {{.ParagraphIndex}} 段：{{.Comment}}</p>
+{{end}}{{end}}
+{{with .Data.AIEvaluation.SuggestionEvaluation}}{{if .SuggestionDescription}}<h2>建议</h2>
+<p>{{.SuggestionDescription}}</p>{{end}}{{end}}
+</div>
+{{end}}
+</body>
+</html>`))
+
+// RenderEvaluatePdf 本地渲染批改结果 PDF，只包含原文、得分和文字点评，用于下游 essay_polish 不可用时兜底
+func RenderEvaluatePdf(ctx context.Context, essays []Essay) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := evaluateTpl.Execute(&buf, essays); err != nil {
+		return nil, fmt.Errorf("渲染批改结果 html 失败: %w", err)
+	}
+	return HtmlToPdf(ctx, buf.Bytes())
+}
+
+// HtmlToPdf 调用本机 headless chromium 把 html 打印成 PDF
+func HtmlToPdf(ctx context.Context, html []byte) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "essay-pdf-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "index.html"), filepath.Join(dir, "output.pdf")
+	if err = os.WriteFile(in, html, 0o600); err != nil {
+		return nil, err
+	}
+
+	chromium := config.GetConfig().LocalPdf.Chromium
+	if chromium == "" {
+		chromium = consts.LocalPdfChromium
+	}
+	ctx, cancel := context.WithTimeout(ctx, consts.LocalPdfTimeout*time.Second)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, chromium,
+		"--headless",
+		"--disable-gpu",
+		"--no-sandbox",
+		"--no-pdf-header-footer",
+		"--print-to-pdf="+out,
+		"file://"+in,
+	)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("chromium 渲染 PDF 失败: %w, output: %s", err, output)
+	}
+	return os.ReadFile(out)
+}
@@ -18,3 +18,15 @@ func GetGradeType(grade *int64) string {
 func IsSupportHomeworkTopic(topic int64) bool {
 	return topic == consts.TopicTypeCustom || topic == consts.TopicTypeLibrary || topic == consts.TopicTypeWeb
 }
+
+// IsSupportSubject 语文支持全部作业类型；英语没有课堂练习的自定义评分链路，只支持自定义与题库作业
+func IsSupportSubject(subject, topic int64) bool {
+	switch subject {
+	case consts.SubjectChinese:
+		return true
+	case consts.SubjectEnglish:
+		return topic == consts.TopicTypeCustom || topic == consts.TopicTypeLibrary
+	default:
+		return false
+	}
+}
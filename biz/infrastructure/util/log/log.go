@@ -33,3 +33,37 @@ func Error(format string, v ...any) {
 func CtxDebug(ctx context.Context, format string, v ...any) {
 	getLoggerCtx(ctx).Debugf(format, v...)
 }
+
+// HeaderRequestId 请求 id 的请求头，入站时读取或生成，调用下游时透传
+const HeaderRequestId = "X-Request-Id"
+
+type requestIdKey struct{}
+
+// WithRequest 在 ctx 中记录 request_id 和 user_id，之后 Ctx 系列方法打印的日志都会带上这两个字段，userId 为空时不带
+func WithRequest(ctx context.Context, requestId, userId string) context.Context {
+	ctx = context.WithValue(ctx, requestIdKey{}, requestId)
+	fields := []logx.LogField{logx.Field("request_id", requestId)}
+	if userId != "" {
+		fields = append(fields, logx.Field("user_id", userId))
+	}
+	return logx.ContextWithFields(ctx, fields...)
+}
+
+// RequestId 返回 ctx 中的 request_id，不在请求中时为空
+func RequestId(ctx context.Context) string {
+	id, _ := ctx.Value(requestIdKey{}).(string)
+	return id
+}
+
+// Field 结构化日志的字段
+func Field(key string, value any) logx.LogField {
+	return logx.Field(key, value)
+}
+
+func CtxInfow(ctx context.Context, msg string, fields ...logx.LogField) {
+	getLoggerCtx(ctx).Infow(msg, fields...)
+}
+
+func CtxErrorw(ctx context.Context, msg string, fields ...logx.LogField) {
+	getLoggerCtx(ctx).Errorw(msg, fields...)
+}
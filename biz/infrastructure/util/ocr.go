@@ -0,0 +1,180 @@
+package util
+
+import (
+	"context"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"fmt"
+	"math"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// IsPdf 按扩展名判断文件是否为 PDF，忽略 url 中的查询参数
+func IsPdf(fileUrl string) bool {
+	if u, err := url.Parse(fileUrl); err == nil {
+		fileUrl = u.Path
+	}
+	return strings.EqualFold(path.Ext(fileUrl), ".pdf")
+}
+
+// ValidPreprocess 校验预处理选项是否都受支持
+func ValidPreprocess(preprocess []string) bool {
+	for _, p := range preprocess {
+		if p != consts.PreprocessRotate && p != consts.PreprocessDeshadow {
+			return false
+		}
+	}
+	return true
+}
+
+// PdfToImages 由下游将 PDF 逐页转成图片，返回按页码排序的图片 url
+func (c *HttpClient) PdfToImages(ctx context.Context, pdfUrl string) ([]string, error) {
+	body := make(map[string]any)
+	body["url"] = pdfUrl
+
+	header := make(map[string]string)
+	header["Content-Type"] = consts.ContentTypeJson
+
+	resp, err := c.SendRequest(ctx, consts.Post, config.GetConfig().Api.StatelessURL+"/sts/pdf/images", header, body)
+	if err != nil {
+		return nil, err
+	}
+	code, _ := resp["code"].(float64)
+	if code != 0 {
+		return nil, fmt.Errorf("PDF 转图片接口返回错误码 %.0f", code)
+	}
+	data, _ := resp["data"].(map[string]any)
+	pages, _ := data["images"].([]any)
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("PDF 转图片结果为空")
+	}
+	images := make([]string, 0, len(pages))
+	for _, p := range pages {
+		if s, ok := p.(string); ok {
+			images = append(images, s)
+		}
+	}
+	return images, nil
+}
+
+// OcrMerged 识别图片或 PDF 扫描件，返回 (title, content, error)。
+// 只有图片时与 TitleUrlOCR 一致，整体识别；含 PDF 时先转成页图片，逐页识别后按页合并，
+// 标题取第一页识别出的标题，其余页识别出的标题视为正文。preprocess 对每一页都生效。
+func (c *HttpClient) OcrMerged(ctx context.Context, urls []string, left string, preprocess []string) (title, content string, err error) {
+	title, content, _, err = c.OcrWithConfidence(ctx, urls, left, preprocess)
+	return title, content, err
+}
+
+// OcrWithConfidence 与 OcrMerged 相同，同时返回下游给出的字符识别置信度，按页顺序合并；下游不支持时为空
+func (c *HttpClient) OcrWithConfidence(ctx context.Context, urls []string, left string, preprocess []string) (title, content string, confidences []float64, err error) {
+	pages, hasPdf, err := c.expandPdf(ctx, urls)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if !hasPdf {
+		return c.ocrPage(ctx, pages, left, preprocess)
+	}
+
+	titles := make([]string, len(pages))
+	contents := make([]string, len(pages))
+	pageConfidences := make([][]float64, len(pages))
+	errs := make([]error, len(pages))
+	sem := make(chan struct{}, consts.OcrPageConcurrency)
+	fns := make([]func(), 0, len(pages))
+	for i, page := range pages {
+		i, page := i, page
+		fns = append(fns, func() {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			titles[i], contents[i], pageConfidences[i], errs[i] = c.ocrPage(ctx, []string{page}, left, preprocess)
+		})
+	}
+	ParallelRun(fns...)
+
+	texts := make([]string, 0, len(pages)*2)
+	for i := range pages {
+		if errs[i] != nil {
+			return "", "", nil, fmt.Errorf("第 %d 页识别失败: %w", i+1, errs[i])
+		}
+		if i == 0 {
+			title = titles[i]
+		} else if titles[i] != "" {
+			texts = append(texts, titles[i])
+		}
+		if contents[i] != "" {
+			texts = append(texts, contents[i])
+		}
+		confidences = append(confidences, pageConfidences[i]...)
+	}
+	return title, strings.Join(texts, "\n"), confidences, nil
+}
+
+// expandPdf 将 urls 中的 PDF 替换为其页图片，保持原有顺序，总页数超过 consts.PdfMaxPages 时拒绝识别
+func (c *HttpClient) expandPdf(ctx context.Context, urls []string) (pages []string, hasPdf bool, err error) {
+	pages = make([]string, 0, len(urls))
+	for _, u := range urls {
+		if !IsPdf(u) {
+			pages = append(pages, u)
+			continue
+		}
+		images, err := c.PdfToImages(ctx, u)
+		if err != nil {
+			return nil, false, err
+		}
+		pages = append(pages, images...)
+		hasPdf = true
+		if len(pages) > consts.PdfMaxPages {
+			return nil, false, consts.ErrPdfTooManyPages
+		}
+	}
+	return pages, hasPdf, nil
+}
+
+func (c *HttpClient) ocrPage(ctx context.Context, images []string, left string, preprocess []string) (title, content string, confidences []float64, err error) {
+	resp, err := c.TitleUrlOCR(ctx, images, left, preprocess)
+	if err != nil {
+		return "", "", nil, err
+	}
+	code, _ := resp["code"].(float64)
+	if code != 0 {
+		return "", "", nil, fmt.Errorf("OCR 接口返回错误码 %.0f", code)
+	}
+	data, ok := resp["data"].(map[string]any)
+	if !ok {
+		return "", "", nil, fmt.Errorf("OCR 响应 data 字段格式非法")
+	}
+	title, _ = data["title"].(string)
+	content, _ = data["content"].(string)
+	// confidences 为正文逐字的识别置信度，0~1，旧版下游不返回
+	if list, ok := data["confidences"].([]any); ok {
+		for _, v := range list {
+			if f, ok := v.(float64); ok && f >= 0 && f <= 1 {
+				confidences = append(confidences, f)
+			}
+		}
+	}
+	return title, content, confidences, nil
+}
+
+// HandwritingNeatness 按字符识别置信度估算书写工整度，0~100：置信度均值反映整体清晰程度，
+// 低于 consts.NeatnessLowConfidence 的字符占比反映潦草、涂改的程度，两者按 7:3 计分。
+// 识别出的字符少于 consts.NeatnessMinChars 时不足以评价，返回 nil
+func HandwritingNeatness(confidences []float64) *float64 {
+	if len(confidences) < consts.NeatnessMinChars {
+		return nil
+	}
+	var sum float64
+	low := 0
+	for _, c := range confidences {
+		sum += c
+		if c < consts.NeatnessLowConfidence {
+			low++
+		}
+	}
+	mean := sum / float64(len(confidences))
+	lowRatio := float64(low) / float64(len(confidences))
+	score := math.Round((mean*0.7 + (1-lowRatio)*0.3) * 100)
+	return &score
+}
@@ -0,0 +1,252 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Spec 需要生成描述的一组接口
+type Spec struct {
+	Title        string
+	Version      string
+	ApiKeyHeader string   // 非空时声明 header 形式的 API Key 鉴权，作用于所有接口
+	Headers      []string // 所有接口都可携带的可选请求头
+	Operations   []Operation
+}
+
+// Operation 一个接口的描述，请求和响应的 schema 由结构体反射生成，结构体变更后描述随之变化
+type Operation struct {
+	Method   string
+	Path     string
+	Summary  string
+	Tags     []string
+	Req      any  // 请求结构体，GET 时按 query 参数展开，其余作为 JSON 请求体
+	Resp     any  // 成功时的响应结构体
+	Envelope any  // 响应的外层结构，非空时 Resp 作为其中的 data 字段
+	Error    any  // 错误时的响应结构体
+	Stream   bool // 响应为 text/event-stream，Resp 为每个事件的 data
+}
+
+// Document OpenAPI 3.0 文档
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"`
+	Components Components                       `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type string `json:"type"`
+	In   string `json:"in"`
+	Name string `json:"name"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+type operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*parameter          `json:"parameters,omitempty"`
+	RequestBody *body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*body      `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type body struct {
+	Description string                `json:"description,omitempty"`
+	Content     map[string]*mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+const securitySchemeName = "ApiKeyAuth"
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// Build 按 spec 生成文档，结构体引用到的其他命名结构体统一放入 components.schemas
+func Build(spec *Spec) *Document {
+	doc := &Document{
+		OpenAPI:    "3.0.3",
+		Info:       Info{Title: spec.Title, Version: spec.Version},
+		Paths:      map[string]map[string]*operation{},
+		Components: Components{Schemas: map[string]*Schema{}},
+	}
+	if spec.ApiKeyHeader != "" {
+		doc.Components.SecuritySchemes = map[string]*SecurityScheme{
+			securitySchemeName: {Type: "apiKey", In: "header", Name: spec.ApiKeyHeader},
+		}
+	}
+	for _, o := range spec.Operations {
+		if doc.Paths[o.Path] == nil {
+			doc.Paths[o.Path] = map[string]*operation{}
+		}
+		doc.Paths[o.Path][strings.ToLower(o.Method)] = doc.operation(spec, &o)
+	}
+	return doc
+}
+
+func (d *Document) operation(spec *Spec, o *Operation) *operation {
+	op := &operation{
+		Summary:   o.Summary,
+		Tags:      o.Tags,
+		Responses: map[string]*body{},
+	}
+	if spec.ApiKeyHeader != "" {
+		op.Security = []map[string][]string{{securitySchemeName: {}}}
+	}
+	for _, h := range spec.Headers {
+		op.Parameters = append(op.Parameters, &parameter{Name: h, In: "header", Schema: &Schema{Type: "string"}})
+	}
+	if o.Req != nil {
+		if o.Method == http.MethodGet {
+			op.Parameters = append(op.Parameters, d.queryParameters(reflect.TypeOf(o.Req))...)
+		} else {
+			op.RequestBody = &body{Content: map[string]*mediaType{
+				"application/json": {Schema: d.schemaOf(reflect.TypeOf(o.Req))},
+			}}
+		}
+	}
+
+	ok := &body{Description: "OK"}
+	if o.Resp != nil {
+		s := d.schemaOf(reflect.TypeOf(o.Resp))
+		if o.Envelope != nil {
+			s = &Schema{AllOf: []*Schema{
+				d.schemaOf(reflect.TypeOf(o.Envelope)),
+				{Type: "object", Properties: map[string]*Schema{"data": s}},
+			}}
+		}
+		contentType := "application/json"
+		if o.Stream {
+			contentType = "text/event-stream"
+		}
+		ok.Content = map[string]*mediaType{contentType: {Schema: s}}
+	}
+	op.Responses["200"] = ok
+	if o.Error != nil {
+		op.Responses["default"] = &body{Description: "Error", Content: map[string]*mediaType{
+			"application/json": {Schema: d.schemaOf(reflect.TypeOf(o.Error))},
+		}}
+	}
+	return op
+}
+
+// queryParameters 把请求结构体中带 query 标签的字段展开为 query 参数
+func (d *Document) queryParameters(t reflect.Type) []*parameter {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []*parameter
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, _ := strings.Cut(sf.Tag.Get("query"), ",")
+		if !sf.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		params = append(params, &parameter{Name: name, In: "query", Schema: d.schemaOf(sf.Type)})
+	}
+	return params
+}
+
+// schemaOf 生成类型的 schema，命名结构体返回对 components 的引用
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{Type: "object"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := d.schemaOf(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: d.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return d.structSchema(t)
+		}
+		ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+		if _, ok := d.Components.Schemas[t.Name()]; !ok {
+			// 先占位，避免自引用的结构体无限递归
+			d.Components.Schemas[t.Name()] = &Schema{}
+			d.Components.Schemas[t.Name()] = d.structSchema(t)
+		}
+		return ref
+	default:
+		return &Schema{}
+	}
+}
+
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		s.Properties[name] = d.schemaOf(sf.Type)
+	}
+	return s
+}
@@ -1,8 +1,18 @@
 package util
 
 import (
+	"context"
 	"encoding/json"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
 	"essay-show/biz/infrastructure/util/log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type StreamType string
@@ -18,21 +28,99 @@ type StreamMessage struct {
 	Type    StreamType `json:"type"`              // 消息类型
 	Message string     `json:"message,omitempty"` // 文本消息
 	Data    any        `json:"data,omitempty"`    // 数据内容
+	Seq     int64      `json:"seq,omitempty"`     // 同一任务内从 1 开始单调递增，客户端按 seq 去重与排序
+	TaskId  string     `json:"task_id,omitempty"` // 流式任务 id，同一任务的消息相同
+}
+
+// EventId SSE 事件的 id，使用消息序号，网关转发时透传给客户端；没有序号时为空
+func (m *StreamMessage) EventId() string {
+	if m.Seq == 0 {
+		return ""
+	}
+	return strconv.FormatInt(m.Seq, 10)
 }
 
-func SendStreamMessage(resultChan chan<- string, msgType StreamType, message string, data any) {
+// streamState 通道所属任务的 id 与下一条消息的序号
+type streamState struct {
+	taskId string
+	seq    atomic.Int64
+}
+
+// streams 由 NewStreamChan 创建的通道到其任务状态的映射，CloseStreamChan 时删除
+var streams sync.Map
+
+// NewStreamChan 创建流式消息通道，容量取 config.Stream；任务 id 使用请求 id，没有时随机生成。
+// 通过 SendStreamMessage 写入的消息带 seq 和 task_id，结束时需调用 CloseStreamChan
+func NewStreamChan(ctx context.Context) chan string {
+	ch := make(chan string, config.GetConfig().Stream.GetBuffer())
+	taskId := log.RequestId(ctx)
+	if taskId == "" {
+		taskId = uuid.NewString()
+	}
+	streams.Store(ch, &streamState{taskId: taskId})
+	return ch
+}
+
+// CloseStreamChan 关闭 NewStreamChan 创建的通道并释放其任务状态
+func CloseStreamChan(ch chan string) {
+	streams.Delete(ch)
+	close(ch)
+}
+
+// SendStreamMessage 写入一条流式消息，序号在写入前分配，被丢弃的消息表现为 seq 不连续；通道写满时 part 消息按 config.Stream 的策略
+// 阻塞等待或丢弃，丢弃计入 metrics.SSEDropped，init、complete、error 消息决定客户端能否开始和结束，一律阻塞到写入为止
+func SendStreamMessage(resultChan chan string, msgType StreamType, message string, data any) {
 	msg := StreamMessage{
 		Type:    msgType,
 		Message: message,
 		Data:    data,
 	}
-	if jsonData, err := json.Marshal(msg); err == nil {
+	if v, ok := streams.Load(resultChan); ok {
+		state := v.(*streamState)
+		msg.Seq = state.seq.Add(1)
+		msg.TaskId = state.taskId
+	}
+	jsonData, err := json.Marshal(msg)
+	if err != nil {
+		log.Error("流式消息JSON序列化失败: %v", err)
+		return
+	}
+	select {
+	case resultChan <- string(jsonData):
+		return
+	default:
+	}
+	if msgType != STPart {
+		resultChan <- string(jsonData)
+		return
+	}
+
+	cfg := config.GetConfig().Stream
+	policy := cfg.GetPolicy()
+	switch policy {
+	case consts.StreamPolicyBlock:
+		timer := time.NewTimer(cfg.GetBlockTimeout())
+		defer timer.Stop()
+		select {
+		case resultChan <- string(jsonData):
+			return
+		case <-timer.C:
+		}
+	case consts.StreamPolicyDropOldest:
+		// 消费者同时在读，腾出的位置可能被其他写入占用，只重试一次
+		select {
+		case oldest := <-resultChan:
+			var dropped StreamMessage
+			_ = json.Unmarshal([]byte(oldest), &dropped)
+			metrics.SSEDropped.WithLabelValues(string(dropped.Type), policy).Inc()
+		default:
+		}
 		select {
 		case resultChan <- string(jsonData):
+			return
 		default:
-			log.Error("流式消息通道已满，跳过消息: %s", msgType)
 		}
-	} else {
-		log.Error("流式消息JSON序列化失败: %v", err)
 	}
+	metrics.SSEDropped.WithLabelValues(string(msgType), policy).Inc()
+	log.Error("流式消息通道已满，跳过消息: %s, policy: %s", msgType, policy)
 }
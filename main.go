@@ -5,6 +5,12 @@ package main
 import (
 	"context"
 	"essay-show/biz/adaptor"
+	"essay-show/biz/adaptor/middleware"
+	"essay-show/biz/adaptor/rpc"
+	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/consts"
+	"essay-show/biz/infrastructure/metrics"
+	"essay-show/biz/infrastructure/repository/migration"
 	"essay-show/biz/infrastructure/util/log"
 	"essay-show/provider"
 	"net/http"
@@ -15,6 +21,7 @@ import (
 	"github.com/cloudwego/hertz/pkg/network/standard"
 	prometheus "github.com/hertz-contrib/monitor-prometheus"
 	"github.com/hertz-contrib/obs-opentelemetry/tracing"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/zeromicro/go-zero/core/logx"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/contrib/propagators/b3"
@@ -33,32 +40,88 @@ func main() {
 	Init()
 	c := provider.Get().Config
 
-	// 启动作业批改定时器
+	// 创建 Mongo 索引，已存在时跳过
+	migration.EnsureIndexes(context.Background(), c)
+
+	// 启动作业批改消费者，停机时取消 graderCtx 停止领取新任务
 	p := provider.Get()
 	homeworkService := p.HomeworkService
-	homeworkService.StartGrader(context.Background())
+	graderCtx, stopGrader := context.WithCancel(context.Background())
+	homeworkService.StartGrader(graderCtx)
+
+	// 启动每日作业提交汇总定时器
+	homeworkService.StartDailySummary(context.Background())
+
+	// 启动班级学情周报定时器
+	homeworkService.StartWeeklyReport(context.Background())
+
+	// 启动软删除清理任务
+	homeworkService.StartSoftDeletePurge(context.Background())
 
 	// 启动 MBA 批改定时器
-	p.MbaService.StartGrader(context.Background())
+	p.MbaService.StartGrader(graderCtx)
 
 	// 启动会员自动续费定时器
 	p.MembershipService.StartExpiryReminder(context.Background())
 
+	// 启动批改次数变更重试任务
+	p.CountOutbox.StartDispatcher(context.Background())
+
+	// 启动批改结果冷归档任务
+	p.ResponseArchiver.Start(context.Background())
+
+	// 启动作业提交原图清理任务
+	p.ImageCleaner.Start(context.Background())
+
+	// 启动孤儿文件对账任务
+	p.OrphanCleaner.Start(context.Background())
+
+	// 启动配置热加载，Api、通知、定时器时刻等非连接类配置修改后无需重启
+	config.StartWatcher(context.Background())
+
+	// 指标端口上同时暴露 /metrics 与 hertz 默认的 /server/metrics
+	http.Handle("/metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError}))
+
 	// hertz接入optl: https://www.volcengine.com/docs/6431/1439035
 	tracer, cfg := tracing.NewServerTracer()
 	h := server.New(
 		server.WithHostPorts(c.ListenOn),
 		server.WithTransport(standard.NewTransporter),
-		server.WithTracer(prometheus.NewServerTracer(":9091", "/server/metrics")),
+		server.WithMaxRequestBodySize(consts.UploadMaxSize+1<<20), // 服务端直传的文件大小上限，另留 1M 给表单的其余部分
+		server.WithExitWaitTime(consts.ShutdownTimeout),
+		server.WithCustomValidator(adaptor.NewValidator()), // 统一校验图片数、文本字数和必填字段
+		server.WithTracer(prometheus.NewServerTracer(":9091", "/server/metrics", prometheus.WithRegistry(metrics.Registry))),
 		tracer,
 	)
+	h.SetClientIPFunc(adaptor.NewClientIP(c))
 
 	// h.Use(hertztracing.ServerMiddleware(cfg)) 入站的HTTP span, span的名称通常是 HTTP GET /path 或 HTTP POST /path 格式
 	h.Use(tracing.ServerMiddleware(cfg), recovery.Recovery(), func(ctx context.Context, c *app.RequestContext) {
 		ctx = adaptor.InjectContext(ctx, c)
 		c.Next(ctx)
+	}, middleware.RequestId(), middleware.RateLimit(), middleware.BodyLimit())
+
+	// 优雅停机：停止领取新的批改任务，等待在途批改完成，超时未完成的重置为待批改
+	h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+		stopGrader()
+		homeworkService.DrainGrading(ctx)
+	}, func(ctx context.Context) {
+		stopGrader()
+		p.MbaService.DrainGrading(ctx)
 	})
 
+	// 内部系统使用的 gRPC 服务，与 hertz 一同停机
+	if c.Grpc.ListenOn != "" {
+		grpcServer, err := rpc.Serve(c.Grpc.ListenOn)
+		if err != nil {
+			log.Error("gRPC server 启动失败, err: %v", err)
+		} else {
+			h.OnShutdown = append(h.OnShutdown, func(ctx context.Context) {
+				grpcServer.GracefulStop()
+			})
+		}
+	}
+
 	register(h)
 	log.Info("server start")
 	h.Spin()
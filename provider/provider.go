@@ -4,17 +4,31 @@ import (
 	"essay-show/biz/application/service"
 	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/repository/apikey"
 	"essay-show/biz/infrastructure/repository/attend"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/repository/certification"
 	"essay-show/biz/infrastructure/repository/class"
 	"essay-show/biz/infrastructure/repository/exercise"
 	"essay-show/biz/infrastructure/repository/feedback"
+	"essay-show/biz/infrastructure/repository/guardian"
+	"essay-show/biz/infrastructure/repository/health"
 	"essay-show/biz/infrastructure/repository/homework"
 	"essay-show/biz/infrastructure/repository/invitation"
 	"essay-show/biz/infrastructure/repository/log"
 	mbaRepo "essay-show/biz/infrastructure/repository/mba"
 	membershipRepo "essay-show/biz/infrastructure/repository/membership"
+	"essay-show/biz/infrastructure/repository/message"
+	"essay-show/biz/infrastructure/repository/ocr"
+	"essay-show/biz/infrastructure/repository/outbox"
 	"essay-show/biz/infrastructure/repository/question_bank"
+	"essay-show/biz/infrastructure/repository/risk"
+	"essay-show/biz/infrastructure/repository/session"
+	"essay-show/biz/infrastructure/repository/setting"
+	"essay-show/biz/infrastructure/repository/transaction"
+	"essay-show/biz/infrastructure/repository/upload"
 	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
 
 	"github.com/google/wire"
 )
@@ -43,6 +57,16 @@ type Provider struct {
 	AdminService        service.IAdminService
 	MbaService          service.IMbaService
 	MembershipService   service.IMembershipService
+	CertService         service.ICertificationService
+	ParentService       service.IParentService
+	HealthService       service.IHealthService
+	CountOutbox         *service.CountOutbox
+	Guard               *service.Guard
+	ApiKeyService       service.IApiKeyService
+	ApiMeter            *service.ApiMeter
+	ResponseArchiver    *service.ResponseArchiver
+	ImageCleaner        *service.ImageCleaner
+	OrphanCleaner       *service.OrphanCleaner
 }
 
 func Get() *Provider {
@@ -65,6 +89,20 @@ var ApplicationSet = wire.NewSet(
 	service.AdminServiceSet,
 	service.MbaServiceSet,
 	service.MembershipServiceSet,
+	service.CertificationServiceSet,
+	service.ParentServiceSet,
+	service.AuditRecorderSet,
+	service.NotifierSet,
+	service.RewardConfigSet,
+	service.CountOutboxSet,
+	service.HealthServiceSet,
+	service.GuardSet,
+	service.ApiKeyServiceSet,
+	service.ApiMeterSet,
+	service.ResponseArchiverSet,
+	service.ImageCleanerSet,
+	service.OrphanCleanerSet,
+	service.ContentModeratorSet,
 )
 
 var InfrastructureSet = wire.NewSet(
@@ -75,6 +113,7 @@ var InfrastructureSet = wire.NewSet(
 	user.NewMongoMapper,
 	log.NewMongoMapper,
 	exercise.NewMongoMapper,
+	exercise.NewAssignmentMongoMapper,
 	attend.NewMongoMapper,
 	invitation.NewCodeMongoMapper,
 	invitation.NewLogMongoMapper,
@@ -83,14 +122,54 @@ var InfrastructureSet = wire.NewSet(
 	class.NewMemberMongoMapper,
 	homework.NewMongoMapper,
 	homework.NewSubmissionMongoMapper,
+	homework.NewReportMongoMapper,
+	homework.NewLessonPlanMongoMapper,
+	homework.NewWeeklyReportMongoMapper,
 	question_bank.NewMySQLMapperFromConfig,
+	question_bank.NewTeacherQuestionMongoMapper,
+	question_bank.NewFavoriteMongoMapper,
 	mbaRepo.NewQuestionMongoMapper,
 	mbaRepo.NewRecordMongoMapper,
 	membershipRepo.NewProductMongoMapper,
 	membershipRepo.NewOrderMongoMapper,
+	certification.NewMongoMapper,
+	guardian.NewMongoMapper,
+	session.NewMongoMapper,
+	audit.NewMongoMapper,
+	audit.NewRevisionMongoMapper,
+	audit.NewVersionMongoMapper,
+	audit.NewFeedbackExportMongoMapper,
+	audit.NewImageCleanupMongoMapper,
+	audit.NewModerationMongoMapper,
+	risk.NewMongoMapper,
+	apikey.NewMongoMapper,
+	apikey.NewUsageMongoMapper,
+	setting.NewMongoMapper,
+	ocr.NewMongoMapper,
+	message.NewMongoMapper,
+	ocr.NewUsageMongoMapper,
+	transaction.NewTransactor,
+	outbox.NewMongoMapper,
+	upload.NewMongoMapper,
+	util.NewDownstreamClient,
+	util.NewArchiveStore,
+	health.NewMongoPinger,
 
 	// Cache Layer
 	cache.NewDownloadCacheMapper,
+	cache.NewBindCodeMapper,
+	cache.NewTokenBlacklistMapper,
+	cache.NewQuestionBankCacheMapper,
+	cache.NewOcrCacheMapper,
+	cache.NewOcrQuotaMapper,
+	cache.NewUploadLimiter,
+	cache.NewShortAnswerLimiter,
+	cache.NewDownloadTaskMapper,
+	cache.NewNotifyMapper,
+	cache.NewSmsLimiter,
+	cache.NewApiQuotaMapper,
+	cache.NewApiNonceMapper,
+	cache.NewGradeQueueMapper,
 
 	//RpcSet,
 )
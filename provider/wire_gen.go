@@ -10,17 +10,31 @@ import (
 	"essay-show/biz/application/service"
 	"essay-show/biz/infrastructure/cache"
 	"essay-show/biz/infrastructure/config"
+	"essay-show/biz/infrastructure/repository/apikey"
 	"essay-show/biz/infrastructure/repository/attend"
+	"essay-show/biz/infrastructure/repository/audit"
+	"essay-show/biz/infrastructure/repository/certification"
 	"essay-show/biz/infrastructure/repository/class"
 	"essay-show/biz/infrastructure/repository/exercise"
 	"essay-show/biz/infrastructure/repository/feedback"
+	"essay-show/biz/infrastructure/repository/guardian"
+	"essay-show/biz/infrastructure/repository/health"
 	"essay-show/biz/infrastructure/repository/homework"
 	"essay-show/biz/infrastructure/repository/invitation"
 	"essay-show/biz/infrastructure/repository/log"
-	mbaRepo "essay-show/biz/infrastructure/repository/mba"
-	membershipRepo "essay-show/biz/infrastructure/repository/membership"
+	"essay-show/biz/infrastructure/repository/mba"
+	"essay-show/biz/infrastructure/repository/membership"
+	"essay-show/biz/infrastructure/repository/message"
+	"essay-show/biz/infrastructure/repository/ocr"
+	"essay-show/biz/infrastructure/repository/outbox"
 	"essay-show/biz/infrastructure/repository/question_bank"
+	"essay-show/biz/infrastructure/repository/risk"
+	"essay-show/biz/infrastructure/repository/session"
+	"essay-show/biz/infrastructure/repository/setting"
+	"essay-show/biz/infrastructure/repository/transaction"
+	"essay-show/biz/infrastructure/repository/upload"
 	"essay-show/biz/infrastructure/repository/user"
+	"essay-show/biz/infrastructure/util"
 )
 
 // Injectors from wire.go:
@@ -34,47 +48,146 @@ func NewProvider() (*Provider, error) {
 	attendMongoMapper := attend.NewMongoMapper(configConfig)
 	codeMongoMapper := invitation.NewCodeMongoMapper(configConfig)
 	logMongoMapper := invitation.NewLogMongoMapper(configConfig)
-	userService := service.UserService{
+	downstreamClient := util.NewDownstreamClient()
+	archiveStore := util.NewArchiveStore(downstreamClient)
+	mongoMapper2 := log.NewMongoMapper(configConfig, archiveStore)
+	certificationMongoMapper := certification.NewMongoMapper(configConfig)
+	tokenBlacklistMapper := cache.NewTokenBlacklistMapper(configConfig)
+	sessionMongoMapper := session.NewMongoMapper(configConfig)
+	auditMongoMapper := audit.NewMongoMapper(configConfig)
+	revisionMongoMapper := audit.NewRevisionMongoMapper(configConfig)
+	versionMongoMapper := audit.NewVersionMongoMapper(configConfig)
+	auditRecorder := &service.AuditRecorder{
+		AuditMapper:    auditMongoMapper,
+		RevisionMapper: revisionMongoMapper,
+		VersionMapper:  versionMongoMapper,
+	}
+	riskMongoMapper := risk.NewMongoMapper(configConfig)
+	settingMongoMapper := setting.NewMongoMapper(configConfig)
+	rewardConfig := &service.RewardConfig{
+		SettingMapper: settingMongoMapper,
+	}
+	smsLimiter := cache.NewSmsLimiter(configConfig)
+	messageMongoMapper := message.NewMongoMapper(configConfig)
+	transactor := transaction.NewTransactor(configConfig)
+	outboxMongoMapper := outbox.NewMongoMapper(configConfig)
+	countOutbox := &service.CountOutbox{
+		OutboxMapper: outboxMongoMapper,
 		UserMapper:   mongoMapper,
-		AttendMapper: attendMongoMapper,
-		CodeMapper:   codeMongoMapper,
-		LogMapper:    logMongoMapper,
+		Transactor:   transactor,
+	}
+	notifier := &service.Notifier{
+		SmsLimiter:    smsLimiter,
+		MessageMapper: messageMongoMapper,
+		Downstream:    downstreamClient,
+	}
+	memberMongoMapper := class.NewMemberMongoMapper(configConfig)
+	submissionMongoMapper := homework.NewSubmissionMongoMapper(configConfig, archiveStore)
+	downloadTaskMapper := cache.NewDownloadTaskMapper(configConfig)
+	guardianMongoMapper := guardian.NewMongoMapper(configConfig)
+	userService := service.UserService{
+		UserMapper:        mongoMapper,
+		AttendMapper:      attendMongoMapper,
+		CodeMapper:        codeMongoMapper,
+		LogMapper:         logMongoMapper,
+		EvaluateLogMapper: mongoMapper2,
+		MemberMapper:      memberMongoMapper,
+		SubmissionMapper:  submissionMongoMapper,
+		CertMapper:        certificationMongoMapper,
+		GuardianMapper:    guardianMongoMapper,
+		Blacklist:         tokenBlacklistMapper,
+		DownloadTask:      downloadTaskMapper,
+		SessionMapper:     sessionMongoMapper,
+		Audit:             auditRecorder,
+		RiskMapper:        riskMongoMapper,
+		Rewards:           rewardConfig,
+		Notifier:          notifier,
+		MessageMapper:     messageMongoMapper,
+		Transactor:        transactor,
+		Downstream:        downstreamClient,
 	}
-	mongoMapper2 := log.NewMongoMapper(configConfig)
 	downloadCacheMapper := cache.NewDownloadCacheMapper(configConfig)
+	moderationMongoMapper := audit.NewModerationMongoMapper(configConfig)
+	contentModerator := &service.ContentModerator{
+		ModerationMapper: moderationMongoMapper,
+		Downstream:       downstreamClient,
+	}
 	essayService := service.EssayService{
 		LogMapper:           mongoMapper2,
 		UserMapper:          mongoMapper,
 		DownloadCacheMapper: downloadCacheMapper,
+		VersionMapper:       versionMongoMapper,
+		Audit:               auditRecorder,
+		Transactor:          transactor,
+		CountOutbox:         countOutbox,
+		Downstream:          downstreamClient,
+		Moderator:           contentModerator,
 	}
+	ocrCacheMapper := cache.NewOcrCacheMapper(configConfig)
+	ocrQuotaMapper := cache.NewOcrQuotaMapper(configConfig)
+	ocrMongoMapper := ocr.NewMongoMapper(configConfig)
+	usageMongoMapper := ocr.NewUsageMongoMapper(configConfig)
+	uploadLimiter := cache.NewUploadLimiter(configConfig)
+	uploadMongoMapper := upload.NewMongoMapper(configConfig)
 	stsService := service.StsService{
-		UserMapper: mongoMapper,
+		UserMapper:       mongoMapper,
+		OcrCache:         ocrCacheMapper,
+		CorrectionMapper: ocrMongoMapper,
+		UsageMapper:      usageMongoMapper,
+		OcrQuota:         ocrQuotaMapper,
+		Rewards:          rewardConfig,
+		UploadLimiter:    uploadLimiter,
+		UploadMapper:     uploadMongoMapper,
+		Downstream:       downstreamClient,
 	}
 	exerciseMongoMapper := exercise.NewMongoMapper(configConfig)
+	classMongoMapper := class.NewMongoMapper(configConfig)
+	assignmentMongoMapper := exercise.NewAssignmentMongoMapper(configConfig)
+	guard := &service.Guard{
+		UserMapper:  mongoMapper,
+		ClassMapper: classMongoMapper,
+	}
+	shortAnswerLimiter := cache.NewShortAnswerLimiter(configConfig)
 	exerciseService := service.ExerciseService{
-		ExerciseMapper: exerciseMongoMapper,
-		LogMapper:      mongoMapper2,
-		UserMapper:     mongoMapper,
+		ExerciseMapper:   exerciseMongoMapper,
+		LogMapper:        mongoMapper2,
+		UserMapper:       mongoMapper,
+		ClassMapper:      classMongoMapper,
+		MemberMapper:     memberMongoMapper,
+		AssignmentMapper: assignmentMongoMapper,
+		Guard:            guard,
+		ShortAnswerLimit: shortAnswerLimiter,
+		Downstream:       downstreamClient,
 	}
 	feedbackMongoMapper := feedback.NewMongoMapper(configConfig)
 	feedBackService := service.FeedBackService{
 		FeedbackMapper: feedbackMongoMapper,
 		UserMapper:     mongoMapper,
 	}
-	classMongoMapper := class.NewMongoMapper(configConfig)
-	memberMongoMapper := class.NewMemberMongoMapper(configConfig)
 	classService := &service.ClassService{
 		ClassMapper:  classMongoMapper,
 		MemberMapper: memberMongoMapper,
 		UserMapper:   mongoMapper,
+		Transactor:   transactor,
+		Guard:        guard,
 	}
 	homeworkMongoMapper := homework.NewMongoMapper(configConfig)
-	submissionMongoMapper := homework.NewSubmissionMongoMapper(configConfig)
+	reportMongoMapper := homework.NewReportMongoMapper(configConfig)
+	lessonPlanMongoMapper := homework.NewLessonPlanMongoMapper(configConfig)
+	weeklyReportMongoMapper := homework.NewWeeklyReportMongoMapper(configConfig)
+	notifyMapper := cache.NewNotifyMapper(configConfig)
 	serviceEssayService := &service.EssayService{
 		LogMapper:           mongoMapper2,
 		UserMapper:          mongoMapper,
 		DownloadCacheMapper: downloadCacheMapper,
+		VersionMapper:       versionMongoMapper,
+		Audit:               auditRecorder,
+		Transactor:          transactor,
+		CountOutbox:         countOutbox,
+		Downstream:          downstreamClient,
+		Moderator:           contentModerator,
 	}
+	gradeQueueMapper := cache.NewGradeQueueMapper(configConfig)
 	homeworkService := &service.HomeworkService{
 		HomeworkMapper:   homeworkMongoMapper,
 		SubmissionMapper: submissionMongoMapper,
@@ -82,32 +195,132 @@ func NewProvider() (*Provider, error) {
 		MemberMapper:     memberMongoMapper,
 		UserMapper:       mongoMapper,
 		EssayService:     serviceEssayService,
+		VersionMapper:    versionMongoMapper,
+		Audit:            auditRecorder,
+		OcrCache:         ocrCacheMapper,
+		CorrectionMapper: ocrMongoMapper,
+		ReportMapper:     reportMongoMapper,
+		DownloadTask:     downloadTaskMapper,
+		DownloadCache:    downloadCacheMapper,
+		NotifyCache:      notifyMapper,
+		Notifier:         notifier,
+		LessonPlanMapper: lessonPlanMongoMapper,
+		WeeklyMapper:     weeklyReportMongoMapper,
+		Transactor:       transactor,
+		CountOutbox:      countOutbox,
+		Downstream:       downstreamClient,
+		Guard:            guard,
+		GradeQueue:       gradeQueueMapper,
+		Moderator:        contentModerator,
 	}
 	mySQLMapper, err := question_bank.NewMySQLMapperFromConfig(configConfig)
 	if err != nil {
 		return nil, err
 	}
+	teacherQuestionMongoMapper := question_bank.NewTeacherQuestionMongoMapper(configConfig)
+	questionBankCacheMapper := cache.NewQuestionBankCacheMapper(configConfig)
+	favoriteMongoMapper := question_bank.NewFavoriteMongoMapper(configConfig)
 	questionBankService := &service.QuestionBankService{
-		QuestionBankMapper: mySQLMapper,
+		QuestionBankMapper:    mySQLMapper,
+		TeacherQuestionMapper: teacherQuestionMongoMapper,
+		UserMapper:            mongoMapper,
+		QuestionBankCache:     questionBankCacheMapper,
+		FavoriteMapper:        favoriteMongoMapper,
+		HomeworkService:       homeworkService,
 	}
+	feedbackExportMongoMapper := audit.NewFeedbackExportMongoMapper(configConfig)
 	adminService := &service.AdminService{
-		HomeworkMapper:   homeworkMongoMapper,
-		UserMapper:       mongoMapper,
-		SubmissionMapper: submissionMongoMapper,
+		HomeworkMapper:    homeworkMongoMapper,
+		UserMapper:        mongoMapper,
+		SubmissionMapper:  submissionMongoMapper,
+		LogMapper:         mongoMapper2,
+		AuditMapper:       auditMongoMapper,
+		RevisionMapper:    revisionMongoMapper,
+		VersionMapper:     versionMongoMapper,
+		FeedbackExport:    feedbackExportMongoMapper,
+		ModerationMapper:  moderationMongoMapper,
+		Audit:             auditRecorder,
+		RiskMapper:        riskMongoMapper,
+		SettingMapper:     settingMongoMapper,
+		Rewards:           rewardConfig,
+		QuestionBankCache: questionBankCacheMapper,
+		Downstream:        downstreamClient,
+		Blacklist:         tokenBlacklistMapper,
+		SessionMapper:     sessionMongoMapper,
 	}
-	mbaQuestionMapper := mbaRepo.NewQuestionMongoMapper(configConfig)
-	mbaRecordMapper := mbaRepo.NewRecordMongoMapper(configConfig)
+	questionMongoMapper := mba.NewQuestionMongoMapper(configConfig)
+	recordMongoMapper := mba.NewRecordMongoMapper(configConfig)
 	mbaService := &service.MbaService{
-		QuestionMapper: mbaQuestionMapper,
-		RecordMapper:   mbaRecordMapper,
+		QuestionMapper: questionMongoMapper,
+		RecordMapper:   recordMongoMapper,
 		UserMapper:     mongoMapper,
+		Transactor:     transactor,
+		CountOutbox:    countOutbox,
+		Downstream:     downstreamClient,
 	}
-	membershipProductMapper := membershipRepo.NewProductMongoMapper(configConfig)
-	membershipOrderMapper := membershipRepo.NewOrderMongoMapper(configConfig)
+	productMongoMapper := membership.NewProductMongoMapper(configConfig)
+	orderMongoMapper := membership.NewOrderMongoMapper(configConfig)
 	membershipService := &service.MembershipService{
-		ProductMapper: membershipProductMapper,
-		OrderMapper:   membershipOrderMapper,
+		ProductMapper: productMongoMapper,
+		OrderMapper:   orderMongoMapper,
 		UserMapper:    mongoMapper,
+		Downstream:    downstreamClient,
+	}
+	certificationService := &service.CertificationService{
+		CertMapper: certificationMongoMapper,
+		UserMapper: mongoMapper,
+	}
+	bindCodeMapper := cache.NewBindCodeMapper(configConfig)
+	parentService := &service.ParentService{
+		GuardianMapper:   guardianMongoMapper,
+		BindCodeMapper:   bindCodeMapper,
+		UserMapper:       mongoMapper,
+		ClassMapper:      classMongoMapper,
+		MemberMapper:     memberMongoMapper,
+		HomeworkMapper:   homeworkMongoMapper,
+		SubmissionMapper: submissionMongoMapper,
+	}
+	apikeyMongoMapper := apikey.NewMongoMapper(configConfig)
+	apikeyUsageMongoMapper := apikey.NewUsageMongoMapper(configConfig)
+	apiQuotaMapper := cache.NewApiQuotaMapper(configConfig)
+	apiNonceMapper := cache.NewApiNonceMapper(configConfig)
+	apiKeyService := &service.ApiKeyService{
+		ApiKeyMapper: apikeyMongoMapper,
+		UsageMapper:  apikeyUsageMongoMapper,
+		QuotaMapper:  apiQuotaMapper,
+		NonceMapper:  apiNonceMapper,
+		Audit:        auditRecorder,
+	}
+	apiMeter := &service.ApiMeter{
+		QuotaMapper: apiQuotaMapper,
+		UsageMapper: apikeyUsageMongoMapper,
+	}
+	mongoPinger := health.NewMongoPinger(configConfig)
+	healthService := &service.HealthService{
+		Config:             configConfig,
+		MongoPinger:        mongoPinger,
+		QuestionBankMapper: mySQLMapper,
+		Downstream:         downstreamClient,
+	}
+	responseArchiver := &service.ResponseArchiver{
+		LogMapper:        mongoMapper2,
+		SubmissionMapper: submissionMongoMapper,
+		Store:            archiveStore,
+	}
+	imageCleanupMongoMapper := audit.NewImageCleanupMongoMapper(configConfig)
+	imageCleaner := &service.ImageCleaner{
+		SubmissionMapper: submissionMongoMapper,
+		CleanupMapper:    imageCleanupMongoMapper,
+		Downstream:       downstreamClient,
+	}
+	orphanCleaner := &service.OrphanCleaner{
+		UploadMapper:     uploadMongoMapper,
+		LogMapper:        mongoMapper2,
+		SubmissionMapper: submissionMongoMapper,
+		CertMapper:       certificationMongoMapper,
+		FeedbackMapper:   feedbackMongoMapper,
+		MbaRecordMapper:  recordMongoMapper,
+		Downstream:       downstreamClient,
 	}
 	providerProvider := &Provider{
 		Config:              configConfig,
@@ -122,6 +335,16 @@ func NewProvider() (*Provider, error) {
 		AdminService:        adminService,
 		MbaService:          mbaService,
 		MembershipService:   membershipService,
+		CertService:         certificationService,
+		ParentService:       parentService,
+		HealthService:       healthService,
+		CountOutbox:         countOutbox,
+		Guard:               guard,
+		ApiKeyService:       apiKeyService,
+		ApiMeter:            apiMeter,
+		ResponseArchiver:    responseArchiver,
+		ImageCleaner:        imageCleaner,
+		OrphanCleaner:       orphanCleaner,
 	}
 	return providerProvider, nil
 }
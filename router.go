@@ -4,6 +4,7 @@ import (
 	handler "essay-show/biz/adaptor/controller"
 	"essay-show/biz/adaptor/controller/apigateway"
 	showHandler "essay-show/biz/adaptor/controller/show"
+	"essay-show/biz/adaptor/middleware"
 
 	"github.com/cloudwego/hertz/pkg/app/server"
 )
@@ -11,14 +12,153 @@ import (
 // customizeRegister registers customize routers.
 func customizedRegister(r *server.Hertz) {
 	r.GET("/ping", handler.Ping)
+	r.GET("/healthz", handler.Healthz)
+	r.GET("/readyz", handler.Readyz)
 	r.POST("/membership/notify", showHandler.MembershipNotify)
 
 	// 静态文件服务 - 直接提供文件访问
 	r.StaticFile("/static/test_stream.html", "./static/test_stream.html")
 	r.StaticFile("/static/test_exercise_stream.html", "./static/test_exercise_stream.html")
 
-	// 版本化API路由 - 用于外部API客户端
-	apiV1 := r.Group("/api/v1")
+	// 角色在路由上用 TeacherOnly 等中间件统一校验，service 通过 service.CurrentUser 取当前用户
+	// 管理后台路由 - IDL 之外的接口，统一校验 admin 身份
+	admin := r.Group("/admin", middleware.AdminOnly())
+	{
+		adminUser := admin.Group("/user")
+		adminUser.GET("/search", showHandler.SearchUsers)
+		adminUser.POST("/status", showHandler.UpdateUserStatus)
+		adminUser.POST("/count", showHandler.AdjustUserCount)
+		adminUser.POST("/ocr_count", showHandler.AdjustUserOcrCount)
+
+		admin.GET("/evaluate/statistics", showHandler.GetEvaluateStatistics)
+		admin.POST("/evaluate/feedback_export", showHandler.CreateFeedbackExport)
+		admin.GET("/evaluate/feedback_export", showHandler.GetFeedbackExport)
+		admin.GET("/audit/logs", showHandler.ListAuditLogs)
+		admin.GET("/audit/revisions", showHandler.ListEvaluateRevisions)
+		admin.GET("/audit/moderation", showHandler.ListModerationRecords)
+		admin.GET("/risk/list", showHandler.ListRiskRecords)
+		admin.POST("/risk/review", showHandler.ReviewRiskRecord)
+		admin.GET("/setting/reward", showHandler.GetRewardSetting)
+		admin.POST("/setting/reward", showHandler.UpdateRewardSetting)
+		admin.POST("/question_bank/refresh", showHandler.RefreshQuestionBankCache)
+
+		adminCert := admin.Group("/certification")
+		adminCert.GET("/list", showHandler.ListCertifications)
+		adminCert.POST("/review", showHandler.ReviewCertification)
+
+		adminApiKey := admin.Group("/api_key")
+		adminApiKey.POST("/create", showHandler.CreateApiKey)
+		adminApiKey.GET("/list", showHandler.ListApiKeys)
+		adminApiKey.POST("/disable", showHandler.DisableApiKey)
+		adminApiKey.POST("/rotate", showHandler.RotateApiKey)
+		adminApiKey.POST("/quota", showHandler.UpdateApiKeyQuota)
+		adminApiKey.POST("/whitelist", showHandler.UpdateApiKeyWhitelist)
+		adminApiKey.GET("/usage", showHandler.GetApiKeyUsage)
+	}
+
+	user := r.Group("/user")
+	{
+		user.GET("/certification", showHandler.GetCertification)
+		user.POST("/certification/apply", showHandler.ApplyCertification)
+		user.POST("/parent/bind_code", middleware.StudentOnly(), showHandler.GenerateBindCode)
+		user.POST("/password/set", showHandler.SetPassword)
+		user.POST("/password/change", showHandler.ChangePassword)
+		user.POST("/refresh_token", showHandler.RefreshToken)
+		user.POST("/sign_out", showHandler.SignOut)
+		user.GET("/sessions", showHandler.ListSessions)
+		user.POST("/session/revoke", showHandler.RevokeSession)
+		user.POST("/data_export", showHandler.ExportMyData)
+		user.GET("/data_export", showHandler.GetMyDataExport)
+		user.POST("/daily_attend/makeup", showHandler.MakeupAttend)
+		user.GET("/invitation/stats", showHandler.GetInvitationStats)
+		user.GET("/watermark", showHandler.GetWatermark)
+		user.POST("/watermark", showHandler.UpdateWatermark)
+		user.GET("/notify", showHandler.GetNotifySetting)
+		user.POST("/notify", showHandler.UpdateNotifySetting)
+		user.GET("/notify/preference", showHandler.GetNotificationPreference)
+		user.POST("/notify/preference", showHandler.UpdateNotificationPreference)
+		user.GET("/messages", showHandler.ListMessages)
+		user.POST("/messages/read", showHandler.ReadMessages)
+	}
+
+	sts := r.Group("/sts")
+	{
+		sts.POST("/apply/batch", showHandler.BatchApplySignedUrl)
+		sts.POST("/ocr/correct", showHandler.CorrectOCRText)
+		sts.GET("/ocr/quota", showHandler.GetOcrQuota)
+		sts.POST("/upload", showHandler.UploadFile)
+	}
+
+	essay := r.Group("/essay")
+	{
+		essay.GET("/evaluate/versions", showHandler.ListEvaluateVersions)
+		essay.POST("/evaluate/rollback", showHandler.RollbackEvaluate)
+	}
+
+	homework := r.Group("/homework")
+	{
+		homework.GET("/submission/versions", middleware.TeacherOnly(), showHandler.ListSubmissionEvaluateVersions)
+		homework.POST("/submission/rollback", middleware.TeacherOnly(), showHandler.RollbackSubmissionEvaluate)
+		homework.GET("/submission/text", showHandler.GetSubmissionText)
+		homework.POST("/submission/text/confirm", showHandler.ConfirmSubmissionText)
+		homework.POST("/neatness", middleware.TeacherOnly(), showHandler.SetHomeworkNeatness)
+		homework.POST("/word_limit", middleware.TeacherOnly(), showHandler.SetHomeworkWordLimit)
+		homework.POST("/class/report", middleware.TeacherOnly(), showHandler.DownloadClassReport)
+		homework.GET("/class/report", middleware.TeacherOnly(), showHandler.GetClassReport)
+		homework.GET("/class/weekly_report/list", middleware.TeacherOnly(), showHandler.ListWeeklyReports)
+		homework.GET("/class/weekly_report", middleware.TeacherOnly(), showHandler.GetWeeklyReport)
+		homework.GET("/student/progress", showHandler.GetStudentProgress)
+		homework.GET("/student/radar", showHandler.GetStudentRadar)
+		homework.GET("/common_mistakes", middleware.TeacherOnly(), showHandler.GetClassCommonMistakes)
+		homework.GET("/dashboard", middleware.TeacherOnly(), showHandler.GetTeacherDashboard)
+		homework.POST("/submission/download/task", showHandler.CreateDownloadTask)
+		homework.GET("/submission/download/task", showHandler.GetDownloadTask)
+		homework.POST("/lesson_plan/generate", middleware.TeacherOnly(), showHandler.GenerateLessonPlan)
+		homework.POST("/lesson_plan/regenerate", middleware.TeacherOnly(), showHandler.RegenerateLessonPlan)
+		homework.GET("/lesson_plan/list", middleware.TeacherOnly(), showHandler.ListLessonPlans)
+		homework.GET("/lesson_plan", middleware.TeacherOnly(), showHandler.GetLessonPlan)
+	}
+
+	exercise := r.Group("/exercise")
+	{
+		exercise.POST("/list", showHandler.ListExercises)
+		exercise.POST("/history", showHandler.GetExerciseHistory)
+		exercise.POST("/assign", middleware.TeacherOnly(), showHandler.AssignExerciseToClass)
+		exercise.POST("/pending", showHandler.ListPendingExercises)
+		exercise.POST("/assignment/progress", showHandler.GetAssignmentProgress)
+		exercise.POST("/create/weakness", showHandler.CreateWeaknessExercise)
+		exercise.POST("/stats", showHandler.GetExerciseStats)
+	}
+
+	questionBank := r.Group("/question_bank")
+	{
+		questionBank.POST("/create", middleware.TeacherOnly(), showHandler.CreateQuestion)
+		questionBank.POST("/update", middleware.TeacherOnly(), showHandler.UpdateQuestion)
+		questionBank.POST("/delete", middleware.TeacherOnly(), showHandler.DeleteQuestion)
+		questionBank.GET("/mine", middleware.TeacherOnly(), showHandler.ListMyQuestions)
+		questionBank.POST("/assign", middleware.TeacherOnly(), showHandler.AssignQuestion)
+		questionBank.GET("/detail", showHandler.GetQuestionBankDetail)
+		questionBank.GET("/tree", showHandler.GetQuestionBankTree)
+		questionBank.POST("/favorite/add", showHandler.AddFavorite)
+		questionBank.POST("/favorite/remove", showHandler.RemoveFavorite)
+		questionBank.GET("/favorites", showHandler.ListFavorites)
+		questionBank.POST("/favorite/assign", middleware.TeacherOnly(), showHandler.AssignFavorite)
+	}
+
+	parent := r.Group("/parent", middleware.GuardianOnly())
+	{
+		parent.POST("/bind", showHandler.BindChild)
+		parent.POST("/unbind", showHandler.UnbindChild)
+		parent.GET("/children", showHandler.ListChildren)
+		parent.GET("/child/homeworks", showHandler.ListChildHomeworks)
+		parent.GET("/child/submission/evaluate", showHandler.GetChildSubmissionEvaluate)
+	}
+
+	// 外部 API 的 OpenAPI 描述，无需鉴权
+	r.GET("/api/openapi.json", apigateway.OpenAPI)
+
+	// 版本化API路由 - 用于外部API客户端，通过 X-Api-Key 鉴权，可选请求签名
+	apiV1 := r.Group("/api/v1", middleware.ApiKeyAuth(), middleware.ApiIPWhitelist(), middleware.ApiSignature())
 	{
 		essay := apiV1.Group("/essay")
 		{
@@ -30,5 +170,14 @@ func customizedRegister(r *server.Hertz) {
 		{
 			sts.POST("/ocr", apigateway.APIOCRV1)
 		}
+
+		apiV1.GET("/usage", apigateway.APIUsageV1)
+		apiV1.GET("/errors", apigateway.APIErrorsV1)
+	}
+
+	// v2 同步接口，响应结构与错误码统一为 adaptor.ApiV2Response，鉴权方式与 v1 相同
+	apiV2 := r.Group("/api/v2", middleware.ApiKeyAuth(), middleware.ApiIPWhitelist(), middleware.ApiSignature())
+	{
+		apiV2.POST("/essay/evaluate", apigateway.APIEssayEvaluateV2)
 	}
 }